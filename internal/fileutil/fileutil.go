@@ -26,12 +26,22 @@ import (
 
 // AtomicWriteFile atomically (as atomic as os.Rename allows) writes a file to a
 // disk.
-func AtomicWriteFile(filename string, reader io.Reader, mode os.FileMode) error {
+//
+// The content is written to a temporary file in the same directory, which is
+// given the requested mode and then renamed over filename. Readers therefore
+// never observe a partially written file. The temporary file is removed if any
+// step fails.
+func AtomicWriteFile(filename string, reader io.Reader, mode os.FileMode) (err error) {
 	tempFile, err := os.CreateTemp(filepath.Split(filename))
 	if err != nil {
 		return err
 	}
 	tempName := tempFile.Name()
+	defer func() {
+		if err != nil {
+			os.Remove(tempName) // return value is ignored as we are already on error path
+		}
+	}()
 
 	if _, err := io.Copy(tempFile, reader); err != nil {
 		tempFile.Close() // return value is ignored as we are already on error path
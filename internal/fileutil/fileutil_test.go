@@ -18,6 +18,7 @@ package fileutil
 
 import (
 	"bytes"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -55,3 +56,38 @@ func TestAtomicWriteFile(t *testing.T) {
 			mode, gotinfo.Mode())
 	}
 }
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+func TestAtomicWriteFileCleanupOnError(t *testing.T) {
+	dir := t.TempDir()
+
+	testpath := filepath.Join(dir, "test")
+	if err := os.WriteFile(testpath, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := AtomicWriteFile(testpath, failingReader{}, 0644); err == nil {
+		t.Fatal("expected error from AtomicWriteFile")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected temporary file to be removed, found %d entries", len(entries))
+	}
+
+	got, err := os.ReadFile(testpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "original" {
+		t.Errorf("expected original content to be preserved, got %q", got)
+	}
+}
@@ -177,7 +177,7 @@ func (r *Resolver) Resolve(reqs []*chart.Dependency, repoNames map[string]string
 		for _, ver := range vs {
 			v, err := semver.NewVersion(ver.Version)
 			// OCI does not need URLs
-			if err != nil || (!registry.IsOCI(d.Repository) && len(ver.URLs) == 0) {
+			if err != nil || ver.Removed || (!registry.IsOCI(d.Repository) && len(ver.URLs) == 0) {
 				// Not a legit entry.
 				continue
 			}
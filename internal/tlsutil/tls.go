@@ -46,6 +46,9 @@ func WithCertKeyPairFiles(certFile, keyFile string) TLSConfigOption {
 		if certFile == "" && keyFile == "" {
 			return nil
 		}
+		if certFile == "" || keyFile == "" {
+			return fmt.Errorf("both a client certificate file and a key file are required, got cert file %q and key file %q", certFile, keyFile)
+		}
 
 		certPEMBlock, err := os.ReadFile(certFile)
 		if err != nil {
@@ -57,6 +60,10 @@ func WithCertKeyPairFiles(certFile, keyFile string) TLSConfigOption {
 			return fmt.Errorf("unable to read key file: %q: %w", keyFile, err)
 		}
 
+		if _, err := tls.X509KeyPair(certPEMBlock, keyPEMBlock); err != nil {
+			return fmt.Errorf("unable to load client certificate %q with key %q: %w", certFile, keyFile, err)
+		}
+
 		options.certPEMBlock = certPEMBlock
 		options.keyPEMBlock = keyPEMBlock
 
@@ -75,6 +82,10 @@ func WithCAFile(caFile string) TLSConfigOption {
 			return fmt.Errorf("can't read CA file: %q: %w", caFile, err)
 		}
 
+		if !x509.NewCertPool().AppendCertsFromPEM(caPEMBlock) {
+			return fmt.Errorf("no valid PEM certificates found in CA file: %q", caFile)
+		}
+
 		options.caPEMBlock = caPEMBlock
 
 		return nil
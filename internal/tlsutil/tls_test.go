@@ -18,6 +18,7 @@ package tlsutil
 
 import (
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -104,3 +105,51 @@ func TestNewTLSConfig(t *testing.T) {
 		}
 	}
 }
+
+func TestNewTLSConfigErrors(t *testing.T) {
+	certFile := testfile(t, testCertFile)
+	keyFile := testfile(t, testKeyFile)
+	caCertFile := testfile(t, testCaCertFile)
+
+	for _, tt := range []struct {
+		name   string
+		option TLSConfigOption
+		expect string
+	}{
+		{
+			name:   "cert without key",
+			option: WithCertKeyPairFiles(certFile, ""),
+			expect: "both a client certificate file and a key file are required",
+		},
+		{
+			name:   "key without cert",
+			option: WithCertKeyPairFiles("", keyFile),
+			expect: "both a client certificate file and a key file are required",
+		},
+		{
+			name:   "mismatched pair",
+			option: WithCertKeyPairFiles(certFile, caCertFile),
+			expect: "unable to load client certificate",
+		},
+		{
+			name:   "missing cert",
+			option: WithCertKeyPairFiles(filepath.Join(t.TempDir(), "missing.pem"), keyFile),
+			expect: "unable to read cert file",
+		},
+		{
+			name:   "invalid CA",
+			option: WithCAFile(keyFile),
+			expect: "no valid PEM certificates found in CA file",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTLSConfig(tt.option)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.expect) {
+				t.Errorf("expected error to contain %q, got %q", tt.expect, err)
+			}
+		})
+	}
+}
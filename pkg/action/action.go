@@ -25,6 +25,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -86,6 +87,11 @@ type Configuration struct {
 
 	// HookOutputFunc called with container name and returns and expects writer that will receive the log output.
 	HookOutputFunc func(namespace, pod, container string) io.Writer
+
+	// MaxHistory is the default limit of the revisions kept per release by
+	// the actions creating revisions, such as Install and Upgrade. Zero
+	// means no limit.
+	MaxHistory int
 }
 
 // renderResources renders the templates in a chart
@@ -94,7 +100,10 @@ type Configuration struct {
 // TODO: As part of the refactor the duplicate code in cmd/helm/template.go should be removed
 //
 //	This code has to do with writing files to disk.
-func (cfg *Configuration) renderResources(ch *chart.Chart, values chartutil.Values, releaseName, outputDir string, subNotes, useReleaseName, includeCrds bool, pr postrender.PostRenderer, interactWithRemote, enableDNS, hideSecret bool) ([]*release.Hook, *bytes.Buffer, string, error) {
+//
+// If result is not nil, it is filled with the rendered documents and the
+// templates they come from.
+func (cfg *Configuration) renderResources(ch *chart.Chart, values chartutil.Values, releaseName, outputDir string, subNotes, useReleaseName, includeCrds bool, pr postrender.PostRenderer, interactWithRemote, enableDNS, hideSecret bool, result *RenderResult) ([]*release.Hook, *bytes.Buffer, string, error) {
 	hs := []*release.Hook{}
 	b := bytes.NewBuffer(nil)
 
@@ -139,23 +148,8 @@ func (cfg *Configuration) renderResources(ch *chart.Chart, values chartutil.Valu
 
 	// NOTES.txt gets rendered like all the other files, but because it's not a hook nor a resource,
 	// pull it out of here into a separate file so that we can actually use the output of the rendered
-	// text file. We have to spin through this map because the file contains path information, so we
-	// look for terminating NOTES.txt. We also remove it from the files so that we don't have to skip
-	// it in the sortHooks.
-	var notesBuffer bytes.Buffer
-	for k, v := range files {
-		if strings.HasSuffix(k, notesFileSuffix) {
-			if subNotes || (k == path.Join(ch.Name(), "templates", notesFileSuffix)) {
-				// If buffer contains data, add newline before adding more
-				if notesBuffer.Len() > 0 {
-					notesBuffer.WriteString("\n")
-				}
-				notesBuffer.WriteString(v)
-			}
-			delete(files, k)
-		}
-	}
-	notes := notesBuffer.String()
+	// text file. It is also removed from the files so that we don't have to skip it in the sortHooks.
+	notes := engine.ExtractNotes(files, ch.Name(), subNotes)
 
 	// Sort hooks, manifests, and partials. Only hooks and manifests are returned,
 	// as partials are not used after renderer.Render. Empty manifests are also
@@ -193,10 +187,14 @@ func (cfg *Configuration) renderResources(ch *chart.Chart, values chartutil.Valu
 		}
 	}
 
+	if result != nil {
+		result.fill(ch, values, manifests, hs, includeCrds, hideSecret)
+	}
+
 	for _, m := range manifests {
 		if outputDir == "" {
 			if hideSecret && m.Head.Kind == "Secret" && m.Head.Version == "v1" {
-				fmt.Fprintf(b, "---\n# Source: %s\n# HIDDEN: The Secret output has been suppressed\n", m.Name)
+				fmt.Fprintf(b, "---\n# Source: %s\n%s\n", m.Name, hiddenSecret)
 			} else {
 				fmt.Fprintf(b, "---\n# Source: %s\n%s\n", m.Name, m.Content)
 			}
@@ -286,6 +284,55 @@ func (cfg *Configuration) KubernetesClientSet() (kubernetes.Interface, error) {
 	return kubernetes.NewForConfig(conf)
 }
 
+// setFieldManager sets the field manager of the Kubernetes client to name,
+// unless name is empty. The returned reset function restores the previous
+// field manager once the action is done.
+func (cfg *Configuration) setFieldManager(name string) (reset func(), err error) {
+	if name == "" {
+		return func() {}, nil
+	}
+	fm, ok := cfg.KubeClient.(kube.InterfaceFieldManager)
+	if !ok {
+		return nil, errors.New("the Kubernetes client does not support setting the field manager")
+	}
+	previous := fm.SetFieldManager(name)
+	return func() { fm.SetFieldManager(previous) }, nil
+}
+
+// setParallelism sets the maximum number of resources the Kubernetes client
+// applies at once to n, unless n is zero. The returned reset function
+// restores the previous value once the action is done.
+func (cfg *Configuration) setParallelism(n int) (reset func(), err error) {
+	if n == 0 {
+		return func() {}, nil
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("invalid parallelism %d: must not be negative", n)
+	}
+	p, ok := cfg.KubeClient.(kube.InterfaceParallelism)
+	if !ok {
+		return nil, errors.New("the Kubernetes client does not support setting the parallelism")
+	}
+	previous := p.SetParallelism(n)
+	return func() { p.SetParallelism(previous) }, nil
+}
+
+// setForceRecreate makes the Kubernetes client recreate the resources whose
+// update changes an immutable field, if enabled. The client is shared by the
+// actions of cfg, so the returned reset function turns it off again once the
+// action is done.
+func (cfg *Configuration) setForceRecreate(enabled bool) (reset func(), err error) {
+	if !enabled {
+		return func() {}, nil
+	}
+	fr, ok := cfg.KubeClient.(kube.InterfaceForceRecreate)
+	if !ok {
+		return nil, errors.New("the Kubernetes client does not support recreating resources")
+	}
+	fr.SetForceRecreate(true)
+	return func() { fr.SetForceRecreate(false) }, nil
+}
+
 // Now generates a timestamp
 //
 // If the configuration has a Timestamper on it, that will be used.
@@ -361,6 +408,24 @@ func (cfg *Configuration) recordRelease(r *release.Release) {
 	}
 }
 
+// computeDefaults returns vals with the defaults computed by the defaults
+// files of the chart beneath them. See engine.Engine.ComputeDefaults.
+func (cfg *Configuration) computeDefaults(ch *chart.Chart, vals map[string]interface{}, options chartutil.ReleaseOptions, caps *chartutil.Capabilities, enableDNS bool) (map[string]interface{}, error) {
+	var e engine.Engine
+	e.EnableDNS = enableDNS
+	e.CustomTemplateFuncs = cfg.CustomTemplateFuncs
+	return e.ComputeDefaults(ch, vals, options, caps)
+}
+
+// pruneHistory removes the oldest revisions of the release name beyond the
+// limit of the storage, once an operation succeeded. Failures are logged, as
+// they do not affect the operation.
+func (cfg *Configuration) pruneHistory(name string) {
+	if err := cfg.Releases.Prune(name); err != nil {
+		slog.Warn("failed to prune the history of the release", "name", name, slog.Any("error", err))
+	}
+}
+
 // Init initializes the action configuration
 func (cfg *Configuration) Init(getter genericclioptions.RESTClientGetter, namespace, helmDriver string) error {
 	kc := kube.New(getter)
@@ -374,6 +439,15 @@ func (cfg *Configuration) Init(getter genericclioptions.RESTClientGetter, namesp
 	switch helmDriver {
 	case "secret", "secrets", "":
 		d := driver.NewSecrets(newSecretClient(lazyClient))
+		if v, ok := os.LookupEnv("HELM_DRIVER_COMPRESSION_LEVEL"); ok {
+			level, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("invalid HELM_DRIVER_COMPRESSION_LEVEL %q: %w", v, err)
+			}
+			if err := d.SetCompressionLevel(level); err != nil {
+				return err
+			}
+		}
 		store = storage.Init(d)
 	case "configmap", "configmaps":
 		d := driver.NewConfigMaps(newConfigMapClient(lazyClient))
@@ -393,7 +467,8 @@ func (cfg *Configuration) Init(getter genericclioptions.RESTClientGetter, namesp
 		}
 		d.SetNamespace(namespace)
 		store = storage.Init(d)
-	case "sql":
+	case "sql", "postgres":
+		// The SQL driver stores releases in PostgreSQL.
 		d, err := driver.NewSQL(
 			os.Getenv("HELM_DRIVER_SQL_CONNECTION_STRING"),
 			namespace,
@@ -23,6 +23,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
 	fakeclientset "k8s.io/client-go/kubernetes/fake"
 
 	"helm.sh/helm/v4/internal/logging"
@@ -329,6 +331,12 @@ func TestConfiguration_Init(t *testing.T) {
 			expectErr:  true,
 			errMsg:     "unable to instantiate SQL driver",
 		},
+		{
+			name:       "Test postgres driver",
+			helmDriver: "postgres",
+			expectErr:  true,
+			errMsg:     "unable to instantiate SQL driver",
+		},
 		{
 			name:       "Test unknown driver",
 			helmDriver: "someDriver",
@@ -353,6 +361,18 @@ func TestConfiguration_Init(t *testing.T) {
 	}
 }
 
+func TestConfiguration_InitCompressionLevel(t *testing.T) {
+	t.Setenv("HELM_DRIVER_COMPRESSION_LEVEL", "1")
+	cfg := &Configuration{}
+	assert.NoError(t, cfg.Init(nil, "default", "secret"))
+
+	t.Setenv("HELM_DRIVER_COMPRESSION_LEVEL", "11")
+	assert.ErrorContains(t, cfg.Init(nil, "default", "secret"), "invalid compression level 11")
+
+	t.Setenv("HELM_DRIVER_COMPRESSION_LEVEL", "best")
+	assert.ErrorContains(t, cfg.Init(nil, "default", "secret"), "invalid HELM_DRIVER_COMPRESSION_LEVEL")
+}
+
 func TestGetVersionSet(t *testing.T) {
 	client := fakeclientset.NewClientset()
 
@@ -368,3 +388,22 @@ func TestGetVersionSet(t *testing.T) {
 		t.Error("Non-existent version is reported found.")
 	}
 }
+
+func TestGetVersionSetCustomResources(t *testing.T) {
+	client := fakeclientset.NewClientset()
+	fakeDiscovery := client.Discovery().(*fakediscovery.FakeDiscovery)
+	fakeDiscovery.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "cert-manager.io/v1",
+			APIResources: []metav1.APIResource{{Name: "certificates", Kind: "Certificate"}},
+		},
+	}
+
+	vs, err := GetVersionSet(client.Discovery())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !vs.Has("cert-manager.io/v1/Certificate") {
+		t.Errorf("Expected the custom resources of the discovered CRDs, got %v", vs)
+	}
+}
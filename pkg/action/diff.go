@@ -0,0 +1,560 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/resource"
+	"sigs.k8s.io/yaml"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	"helm.sh/helm/v4/pkg/kube"
+	releaseutil "helm.sh/helm/v4/pkg/release/util"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+// Diff is the action for comparing two revisions of a release, or a release
+// and the result of upgrading it.
+//
+// It provides the implementation of 'helm diff'.
+type Diff struct {
+	cfg *Configuration
+
+	// RedactSecrets hides the values of the data of Secrets in the text of
+	// the diffs, only showing which of them change.
+	RedactSecrets bool
+	// Context is the number of unchanged lines shown around changes in the
+	// text of the diffs.
+	Context int
+}
+
+// ReleaseDiff describes the changes between two revisions of a release,
+// resource by resource.
+type ReleaseDiff struct {
+	Release string `json:"release"`
+	// From and To are the revisions compared. To is the revision an upgrade
+	// would create when previewing an upgrade.
+	From      int                    `json:"from"`
+	To        int                    `json:"to"`
+	Resources []ResourceManifestDiff `json:"resources"`
+}
+
+// ResourceManifestDiff describes the changes of a single resource, along
+// with the unified diff of its manifest.
+type ResourceManifestDiff struct {
+	release.ResourceDiff
+	// Diff is the unified diff of the manifest of the resource. It is empty
+	// when the resource is unchanged.
+	Diff string `json:"diff,omitempty"`
+}
+
+// NewDiff creates a new Diff object with the given configuration.
+func NewDiff(cfg *Configuration) *Diff {
+	return &Diff{
+		cfg:     cfg,
+		Context: 3,
+	}
+}
+
+// RunRevisions compares the revisions from and to of the release name. A to
+// of 0 stands for the last revision.
+func (d *Diff) RunRevisions(name string, from, to int) (*ReleaseDiff, error) {
+	if err := chartutil.ValidateReleaseName(name); err != nil {
+		return nil, fmt.Errorf("release name is invalid: %s", name)
+	}
+	if from <= 0 || to < 0 {
+		return nil, errInvalidRevision
+	}
+	fromRelease, err := d.cfg.releaseContent(name, from)
+	if err != nil {
+		return nil, err
+	}
+	toRelease, err := d.cfg.releaseContent(name, to)
+	if err != nil {
+		return nil, err
+	}
+	return d.diff(fromRelease, toRelease, fmt.Sprintf("revision %d", toRelease.Version))
+}
+
+// RunUpgrade compares the last revision of the release name with the
+// revision that upgrading it to chart with vals would create. The upgrade is
+// rendered by upgrade, as a dry run, without changing the release or the
+// cluster.
+func (d *Diff) RunUpgrade(ctx context.Context, upgrade *Upgrade, name string, chart *chart.Chart, vals map[string]interface{}) (*ReleaseDiff, error) {
+	current, err := d.cfg.releaseContent(name, 0)
+	if err != nil {
+		return nil, err
+	}
+	upgrade.DryRun = true
+	if upgrade.DryRunOption == "" || upgrade.DryRunOption == "none" || upgrade.DryRunOption == "false" {
+		upgrade.DryRunOption = "client"
+	}
+	upgraded, err := upgrade.RunWithContext(ctx, name, chart, vals)
+	if err != nil {
+		return nil, err
+	}
+	return d.diff(current, upgraded, "upgrade")
+}
+
+// diff compares the manifests of two releases. The revision of to is named
+// toLabel in the headers of the text of the diffs.
+func (d *Diff) diff(from, to *release.Release, toLabel string) (*ReleaseDiff, error) {
+	fromObjs, err := parseManifestObjects(from.Manifest, from.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the manifest of revision %d: %w", from.Version, err)
+	}
+	toObjs, err := parseManifestObjects(to.Manifest, from.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the manifest of %s: %w", toLabel, err)
+	}
+	fromByKey := make(map[string]*unstructured.Unstructured, len(fromObjs))
+	for _, obj := range fromObjs {
+		fromByKey[unstructuredKey(obj)] = obj
+	}
+	toByKey := make(map[string]*unstructured.Unstructured, len(toObjs))
+	for _, obj := range toObjs {
+		toByKey[unstructuredKey(obj)] = obj
+	}
+
+	result := &ReleaseDiff{Release: from.Name, From: from.Version, To: to.Version}
+	for _, rd := range objectsDiff(fromObjs, toObjs) {
+		md := ResourceManifestDiff{ResourceDiff: rd}
+		if rd.Action != release.DiffUnchanged {
+			key := fmt.Sprintf("%s/%s/%s/%s", rd.APIVersion, rd.Kind, rd.Namespace, rd.Name)
+			md.Diff, err = d.manifestText(fromByKey[key], toByKey[key], fmt.Sprintf("revision %d", from.Version), toLabel)
+			if err != nil {
+				return nil, err
+			}
+		}
+		result.Resources = append(result.Resources, md)
+	}
+	return result, nil
+}
+
+// manifestText returns the unified diff of the manifests of a resource, either
+// of which may be nil.
+func (d *Diff) manifestText(from, to *unstructured.Unstructured, fromLabel, toLabel string) (string, error) {
+	if d.RedactSecrets {
+		from, to = redactSecretData(from, to)
+	}
+	fromYAML, err := objectYAML(from)
+	if err != nil {
+		return "", err
+	}
+	toYAML, err := objectYAML(to)
+	if err != nil {
+		return "", err
+	}
+	obj := to
+	if obj == nil {
+		obj = from
+	}
+	resourceName := fmt.Sprintf("%s/%s", obj.GetKind(), obj.GetName())
+	if obj.GetNamespace() != "" {
+		resourceName = fmt.Sprintf("%s/%s/%s", obj.GetNamespace(), obj.GetKind(), obj.GetName())
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(fromYAML),
+		B:        difflib.SplitLines(toYAML),
+		FromFile: fmt.Sprintf("%s (%s)", resourceName, fromLabel),
+		ToFile:   fmt.Sprintf("%s (%s)", resourceName, toLabel),
+		Context:  d.Context,
+	})
+}
+
+func objectYAML(obj *unstructured.Unstructured) (string, error) {
+	if obj == nil {
+		return "", nil
+	}
+	data, err := yaml.Marshal(obj.Object)
+	return string(data), err
+}
+
+// redactedSecretFields are the fields of Secrets whose values are redacted.
+var redactedSecretFields = []string{"data", "stringData"}
+
+// redactSecretData returns copies of the two versions of a resource, either of
+// which may be nil, with the values of the data of Secrets replaced. Values
+// that differ between the versions are replaced by different placeholders,
+// so that the diff shows which change.
+func redactSecretData(from, to *unstructured.Unstructured) (*unstructured.Unstructured, *unstructured.Unstructured) {
+	isSecret := func(obj *unstructured.Unstructured) bool {
+		return obj != nil && obj.GetAPIVersion() == "v1" && obj.GetKind() == "Secret"
+	}
+	if !isSecret(from) && !isSecret(to) {
+		return from, to
+	}
+	redact := func(obj, other *unstructured.Unstructured, changed string) *unstructured.Unstructured {
+		if obj == nil {
+			return nil
+		}
+		obj = obj.DeepCopy()
+		for _, field := range redactedSecretFields {
+			values, ok := obj.Object[field].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			var otherValues map[string]interface{}
+			if other != nil {
+				otherValues, _ = other.Object[field].(map[string]interface{})
+			}
+			for k, v := range values {
+				if ov, ok := otherValues[k]; other == nil || (ok && reflect.DeepEqual(ov, v)) {
+					values[k] = "(redacted)"
+				} else {
+					values[k] = changed
+				}
+			}
+		}
+		return obj
+	}
+	return redact(from, to, "(redacted, before)"), redact(to, from, "(redacted, after)")
+}
+
+// ignoredDiffFields are maintained by the API server and change on every
+// write, so they are left out of diffs.
+var ignoredDiffFields = map[string]bool{
+	"metadata.creationTimestamp": true,
+	"metadata.generation":        true,
+	"metadata.managedFields":     true,
+	"metadata.resourceVersion":   true,
+	"metadata.uid":               true,
+	"status":                     true,
+}
+
+// serverDryRunDiff performs a server-side apply dry run of target and
+// describes how each resource would change. Resources in current that are not
+// part of target are reported as deletions.
+//
+// Resources annotated with kube.MergeAnno that are not merged with their live
+// object are described from their manifests instead.
+func serverDryRunDiff(client kube.Interface, current, target kube.ResourceList) ([]release.ResourceDiff, error) {
+	dryRunner, ok := client.(kube.InterfaceDryRunApply)
+	if !ok {
+		return nil, errors.New("the Kubernetes client does not support server-side dry run diffs")
+	}
+
+	var dryRun kube.ResourceList
+	var merged []release.ResourceDiff
+	for _, r := range target {
+		d, ok, err := mergeDiff(current, r)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			merged = append(merged, d)
+			continue
+		}
+		dryRun = append(dryRun, r)
+	}
+
+	results, err := dryRunner.DryRunApply(dryRun)
+	if err != nil {
+		return nil, err
+	}
+	diffs := make([]release.ResourceDiff, 0, len(results)+len(merged))
+	for _, res := range results {
+		d, err := resultDiff(res)
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, d)
+	}
+	diffs = append(diffs, merged...)
+
+	targetKeys := make(map[string]bool, len(target))
+	for _, r := range target {
+		targetKeys[objectKey(r)] = true
+	}
+	for _, r := range current {
+		if !targetKeys[objectKey(r)] {
+			diffs = append(diffs, newResourceDiff(r, release.DiffDelete))
+		}
+	}
+	return diffs, nil
+}
+
+// mergeDiff describes the change of the resource target from its manifest
+// in current, when it is annotated with kube.MergeAnno so that the upgrade
+// does not merge it with its live object. It returns false for the resources
+// to be diffed with their live object.
+func mergeDiff(current kube.ResourceList, target *resource.Info) (release.ResourceDiff, bool, error) {
+	merge, err := kube.ResourceMerge(target.Object)
+	if err != nil {
+		return release.ResourceDiff{}, false, fmt.Errorf("unable to diff %q: %w", target.Name, err)
+	}
+	if merge == "" {
+		return release.ResourceDiff{}, false, nil
+	}
+	i := slices.IndexFunc(current, func(r *resource.Info) bool {
+		return objectKey(r) == objectKey(target)
+	})
+	if i < 0 {
+		return release.ResourceDiff{}, false, nil
+	}
+	currentContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(current[i].Object)
+	if err != nil {
+		return release.ResourceDiff{}, false, err
+	}
+	targetContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(target.Object)
+	if err != nil {
+		return release.ResourceDiff{}, false, err
+	}
+
+	d := newResourceDiff(target, release.DiffUnchanged)
+	d.Added, d.Changed, d.Removed = diffFields(currentContent, targetContent)
+	changed := len(d.Added)+len(d.Changed)+len(d.Removed) > 0
+	switch {
+	case merge == kube.MergeIgnore && !changed:
+		d.Message = fmt.Sprintf("not updated as its manifest is unchanged (%s: %s)", kube.MergeAnno, merge)
+	case merge == kube.MergeIgnore:
+		// Changed resources are merged with their live object.
+		return release.ResourceDiff{}, false, nil
+	default:
+		d.Message = fmt.Sprintf("only the changes of its manifest are applied (%s: %s)", kube.MergeAnno, merge)
+		if changed {
+			d.Action = release.DiffUpdate
+		}
+	}
+	return d, true, nil
+}
+
+// manifestDiff describes how replacing the resources of the current manifest
+// with those of the target manifest would change them. It works on the
+// manifests alone, without consulting the cluster. Resources without a
+// namespace are assumed to be in namespace.
+func manifestDiff(current, target, namespace string) ([]release.ResourceDiff, error) {
+	currentObjs, err := parseManifestObjects(current, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse current release manifest: %w", err)
+	}
+	targetObjs, err := parseManifestObjects(target, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse target release manifest: %w", err)
+	}
+	return objectsDiff(currentObjs, targetObjs), nil
+}
+
+// objectsDiff describes how replacing the current resources with the target
+// ones would change them.
+func objectsDiff(currentObjs, targetObjs []*unstructured.Unstructured) []release.ResourceDiff {
+	currentByKey := make(map[string]*unstructured.Unstructured, len(currentObjs))
+	for _, obj := range currentObjs {
+		currentByKey[unstructuredKey(obj)] = obj
+	}
+	targetKeys := make(map[string]bool, len(targetObjs))
+
+	var diffs []release.ResourceDiff
+	for _, obj := range targetObjs {
+		key := unstructuredKey(obj)
+		targetKeys[key] = true
+		d := release.ResourceDiff{
+			APIVersion: obj.GetAPIVersion(),
+			Kind:       obj.GetKind(),
+			Namespace:  obj.GetNamespace(),
+			Name:       obj.GetName(),
+			Action:     release.DiffCreate,
+		}
+		if live, ok := currentByKey[key]; ok {
+			d.Action = release.DiffUnchanged
+			d.Added, d.Changed, d.Removed = diffFields(live.Object, obj.Object)
+			if len(d.Added)+len(d.Changed)+len(d.Removed) > 0 {
+				d.Action = release.DiffUpdate
+			}
+		}
+		diffs = append(diffs, d)
+	}
+	for _, obj := range currentObjs {
+		if !targetKeys[unstructuredKey(obj)] {
+			diffs = append(diffs, release.ResourceDiff{
+				APIVersion: obj.GetAPIVersion(),
+				Kind:       obj.GetKind(),
+				Namespace:  obj.GetNamespace(),
+				Name:       obj.GetName(),
+				Action:     release.DiffDelete,
+			})
+		}
+	}
+	return diffs
+}
+
+// parseManifestObjects decodes the resources of a release manifest in the
+// order they appear.
+func parseManifestObjects(manifest, namespace string) ([]*unstructured.Unstructured, error) {
+	manifests := releaseutil.SplitManifests(manifest)
+	keys := make([]string, 0, len(manifests))
+	for k := range manifests {
+		keys = append(keys, k)
+	}
+	sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+
+	var objs []*unstructured.Unstructured
+	for _, k := range keys {
+		var content map[string]interface{}
+		if err := yaml.Unmarshal([]byte(manifests[k]), &content); err != nil {
+			return nil, err
+		}
+		if len(content) == 0 {
+			continue
+		}
+		obj := &unstructured.Unstructured{Object: content}
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace(namespace)
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+func unstructuredKey(obj *unstructured.Unstructured) string {
+	return fmt.Sprintf("%s/%s/%s/%s", obj.GetAPIVersion(), obj.GetKind(), obj.GetNamespace(), obj.GetName())
+}
+
+// resultDiff turns the dry run result of a single resource into a diff.
+func resultDiff(res kube.DryRunApplyResult) (release.ResourceDiff, error) {
+	if res.Err != nil {
+		if !isImmutableFieldError(res.Err) {
+			return release.ResourceDiff{}, fmt.Errorf("dry run of %q failed: %w", res.Info.Name, res.Err)
+		}
+		d := newResourceDiff(res.Info, release.DiffUpdate)
+		d.RequiresRecreate = true
+		d.Message = res.Err.Error()
+		var statusErr apierrors.APIStatus
+		if errors.As(res.Err, &statusErr) && statusErr.Status().Details != nil {
+			for _, cause := range statusErr.Status().Details.Causes {
+				if cause.Field != "" {
+					d.Changed = append(d.Changed, cause.Field)
+				}
+			}
+		}
+		return d, nil
+	}
+
+	if res.Live == nil {
+		return newResourceDiff(res.Info, release.DiffCreate), nil
+	}
+
+	d := newResourceDiff(res.Info, release.DiffUnchanged)
+	d.Added, d.Changed, d.Removed = diffFields(res.Live, res.Applied)
+	if len(d.Added)+len(d.Changed)+len(d.Removed) > 0 {
+		d.Action = release.DiffUpdate
+	}
+	return d, nil
+}
+
+func newResourceDiff(info *resource.Info, action release.DiffAction) release.ResourceDiff {
+	d := release.ResourceDiff{
+		Namespace: info.Namespace,
+		Name:      info.Name,
+		Action:    action,
+	}
+	if info.Object != nil {
+		gvk := info.Object.GetObjectKind().GroupVersionKind()
+		d.APIVersion, d.Kind = gvk.GroupVersion().String(), gvk.Kind
+	}
+	return d
+}
+
+// isImmutableFieldError reports whether err is the API server rejecting a
+// change to a field that cannot be updated in place.
+func isImmutableFieldError(err error) bool {
+	return apierrors.IsInvalid(err) && strings.Contains(err.Error(), "immutable")
+}
+
+// diffFields compares two objects and returns the paths of the fields that
+// are only in desired, that differ, and that are only in live. Paths are
+// sorted.
+func diffFields(live, desired map[string]interface{}) (added, changed, removed []string) {
+	var walk func(path string, live, desired interface{})
+	walk = func(path string, live, desired interface{}) {
+		if ignoredDiffFields[path] {
+			return
+		}
+		switch d := desired.(type) {
+		case map[string]interface{}:
+			l, ok := live.(map[string]interface{})
+			if !ok {
+				break
+			}
+			for k, v := range d {
+				p := fieldPath(path, k)
+				if lv, ok := l[k]; ok {
+					walk(p, lv, v)
+				} else if !ignoredDiffFields[p] {
+					added = append(added, p)
+				}
+			}
+			for k := range l {
+				p := fieldPath(path, k)
+				if _, ok := d[k]; !ok && !ignoredDiffFields[p] {
+					removed = append(removed, p)
+				}
+			}
+			return
+		case []interface{}:
+			l, ok := live.([]interface{})
+			if !ok {
+				break
+			}
+			for i := range max(len(l), len(d)) {
+				p := fmt.Sprintf("%s[%d]", path, i)
+				switch {
+				case i >= len(l):
+					added = append(added, p)
+				case i >= len(d):
+					removed = append(removed, p)
+				default:
+					walk(p, l[i], d[i])
+				}
+			}
+			return
+		}
+		if !reflect.DeepEqual(live, desired) {
+			changed = append(changed, path)
+		}
+	}
+	walk("", live, desired)
+
+	sort.Strings(added)
+	sort.Strings(changed)
+	sort.Strings(removed)
+	return added, changed, removed
+}
+
+// fieldPath appends key to path. Keys that contain separators, such as most
+// label and annotation keys, are quoted in brackets.
+func fieldPath(path, key string) string {
+	if strings.ContainsAny(key, ".[]") {
+		return fmt.Sprintf("%s[%q]", path, key)
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
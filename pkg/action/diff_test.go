@@ -0,0 +1,399 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/cli-runtime/pkg/resource"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	"helm.sh/helm/v4/pkg/kube"
+	kubefake "helm.sh/helm/v4/pkg/kube/fake"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+func TestDiffFields(t *testing.T) {
+	live := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":            "web",
+			"resourceVersion": "1",
+			"labels": map[string]interface{}{
+				"app.kubernetes.io/name": "web",
+				"tier":                   "frontend",
+			},
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(1),
+			"paused":   false,
+			"containers": []interface{}{
+				map[string]interface{}{"name": "web", "image": "nginx:1.0"},
+				map[string]interface{}{"name": "sidecar", "image": "envoy"},
+			},
+		},
+		"status": map[string]interface{}{"readyReplicas": int64(1)},
+	}
+	desired := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":            "web",
+			"resourceVersion": "2",
+			"labels": map[string]interface{}{
+				"app.kubernetes.io/name": "web-v2",
+				"tier":                   "frontend",
+			},
+			"annotations": map[string]interface{}{"owner": "me"},
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"containers": []interface{}{
+				map[string]interface{}{"name": "web", "image": "nginx:1.1"},
+			},
+		},
+		"status": map[string]interface{}{"readyReplicas": int64(0)},
+	}
+
+	added, changed, removed := diffFields(live, desired)
+	assert.Equal(t, []string{"metadata.annotations"}, added)
+	assert.Equal(t, []string{
+		`metadata.labels["app.kubernetes.io/name"]`,
+		"spec.containers[0].image",
+		"spec.replicas",
+	}, changed)
+	assert.Equal(t, []string{"spec.containers[1]", "spec.paused"}, removed)
+
+	added, changed, removed = diffFields(live, live)
+	assert.Empty(t, added)
+	assert.Empty(t, changed)
+	assert.Empty(t, removed)
+}
+
+func diffTestInfo(apiVersion, kind, name string) *resource.Info {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetName(name)
+	obj.SetNamespace("spaced")
+	return &resource.Info{Name: name, Namespace: "spaced", Object: obj}
+}
+
+func TestServerDryRunDiff(t *testing.T) {
+	created := diffTestInfo("v1", "ConfigMap", "created")
+	updated := diffTestInfo("apps/v1", "Deployment", "updated")
+	unchanged := diffTestInfo("v1", "Service", "unchanged")
+	immutable := diffTestInfo("batch/v1", "Job", "immutable")
+	deleted := diffTestInfo("v1", "Secret", "deleted")
+
+	immutableErr := apierrors.NewInvalid(schema.GroupKind{Group: "batch", Kind: "Job"}, "immutable", field.ErrorList{
+		field.Invalid(field.NewPath("spec", "template"), nil, "field is immutable"),
+	})
+	client := &kubefake.FailingKubeClient{
+		DryRunApplyResults: []kube.DryRunApplyResult{
+			{Info: created, Applied: map[string]interface{}{"data": map[string]interface{}{"a": "b"}}},
+			{
+				Info:    updated,
+				Live:    map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(1)}},
+				Applied: map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(2)}},
+			},
+			{
+				Info:    unchanged,
+				Live:    map[string]interface{}{"spec": map[string]interface{}{"type": "ClusterIP"}},
+				Applied: map[string]interface{}{"spec": map[string]interface{}{"type": "ClusterIP"}},
+			},
+			{Info: immutable, Live: map[string]interface{}{}, Err: immutableErr},
+		},
+	}
+
+	current := kube.ResourceList{updated, unchanged, immutable, deleted}
+	target := kube.ResourceList{created, updated, unchanged, immutable}
+	diffs, err := serverDryRunDiff(client, current, target)
+	require.NoError(t, err)
+	require.Len(t, diffs, 5)
+
+	assert.Equal(t, release.ResourceDiff{
+		APIVersion: "v1", Kind: "ConfigMap", Namespace: "spaced", Name: "created", Action: release.DiffCreate,
+	}, diffs[0])
+	assert.Equal(t, release.ResourceDiff{
+		APIVersion: "apps/v1", Kind: "Deployment", Namespace: "spaced", Name: "updated", Action: release.DiffUpdate,
+		Changed: []string{"spec.replicas"},
+	}, diffs[1])
+	assert.Equal(t, release.DiffUnchanged, diffs[2].Action)
+	assert.Equal(t, release.DiffUpdate, diffs[3].Action)
+	assert.True(t, diffs[3].RequiresRecreate)
+	assert.Equal(t, []string{"spec.template"}, diffs[3].Changed)
+	assert.Contains(t, diffs[3].Message, "field is immutable")
+	assert.Equal(t, release.ResourceDiff{
+		APIVersion: "v1", Kind: "Secret", Namespace: "spaced", Name: "deleted", Action: release.DiffDelete,
+	}, diffs[4])
+
+	// Other validation errors abort the diff.
+	client.DryRunApplyResults = []kube.DryRunApplyResult{{
+		Info: created,
+		Err: apierrors.NewInvalid(schema.GroupKind{Kind: "ConfigMap"}, "created", field.ErrorList{
+			field.Required(field.NewPath("data"), "data is required"),
+		}),
+	}}
+	_, err = serverDryRunDiff(client, nil, target)
+	assert.ErrorContains(t, err, `dry run of "created" failed`)
+}
+
+func TestServerDryRunDiffMerge(t *testing.T) {
+	annotated := func(name, merge, value string) *resource.Info {
+		info := diffTestInfo("v1", "ConfigMap", name)
+		obj := info.Object.(*unstructured.Unstructured)
+		obj.SetAnnotations(map[string]string{kube.MergeAnno: merge})
+		require.NoError(t, unstructured.SetNestedField(obj.Object, value, "data", "key"))
+		return info
+	}
+	ignored := annotated("ignored", kube.MergeIgnore, "a")
+	ignoredChanged := annotated("ignored-changed", kube.MergeIgnore, "b")
+	clientMerged := annotated("client", kube.MergeClient, "b")
+	client := &kubefake.FailingKubeClient{
+		DryRunApplyResults: []kube.DryRunApplyResult{{
+			Info:    ignoredChanged,
+			Live:    map[string]interface{}{"data": map[string]interface{}{"key": "a"}},
+			Applied: map[string]interface{}{"data": map[string]interface{}{"key": "b"}},
+		}},
+	}
+
+	current := kube.ResourceList{
+		annotated("ignored", kube.MergeIgnore, "a"),
+		annotated("ignored-changed", kube.MergeIgnore, "a"),
+		annotated("client", kube.MergeClient, "a"),
+	}
+	target := kube.ResourceList{ignored, ignoredChanged, clientMerged}
+	diffs, err := serverDryRunDiff(client, current, target)
+	require.NoError(t, err)
+	require.Len(t, diffs, 3)
+
+	// Changed resources ignoring the merge are diffed by the dry run.
+	assert.Equal(t, "ignored-changed", diffs[0].Name)
+	assert.Equal(t, release.DiffUpdate, diffs[0].Action)
+	assert.Equal(t, "ignored", diffs[1].Name)
+	assert.Equal(t, release.DiffUnchanged, diffs[1].Action)
+	assert.Contains(t, diffs[1].Message, "helm.sh/merge: ignore")
+	assert.Equal(t, "client", diffs[2].Name)
+	assert.Equal(t, release.DiffUpdate, diffs[2].Action)
+	assert.Equal(t, []string{"data.key"}, diffs[2].Changed)
+	assert.Contains(t, diffs[2].Message, "helm.sh/merge: client")
+
+	_, err = serverDryRunDiff(client, current, kube.ResourceList{annotated("invalid", "three-way", "a")})
+	assert.ErrorContains(t, err, "invalid helm.sh/merge annotation")
+}
+
+func TestManifestDiff(t *testing.T) {
+	current := `---
+# Source: chart/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 80
+---
+# Source: chart/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: other
+spec:
+  replicas: 3
+  paused: true
+---
+# Source: chart/templates/added-since.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: added-since
+`
+	target := `---
+# Source: chart/templates/removed-since.yaml
+apiVersion: v1
+kind: Secret
+metadata:
+  name: removed-since
+---
+# Source: chart/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: other
+  labels:
+    tier: web
+spec:
+  replicas: 1
+---
+# Source: chart/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: spaced
+spec:
+  ports:
+  - port: 80
+`
+
+	diffs, err := manifestDiff(current, target, "spaced")
+	require.NoError(t, err)
+	assert.Equal(t, []release.ResourceDiff{
+		{APIVersion: "v1", Kind: "Secret", Namespace: "spaced", Name: "removed-since", Action: release.DiffCreate},
+		{
+			APIVersion: "apps/v1", Kind: "Deployment", Namespace: "other", Name: "web", Action: release.DiffUpdate,
+			Added:   []string{"metadata.labels"},
+			Changed: []string{"spec.replicas"},
+			Removed: []string{"spec.paused"},
+		},
+		{APIVersion: "v1", Kind: "Service", Namespace: "spaced", Name: "web", Action: release.DiffUnchanged},
+		{APIVersion: "v1", Kind: "ConfigMap", Namespace: "spaced", Name: "added-since", Action: release.DiffDelete},
+	}, diffs)
+
+	_, err = manifestDiff("kind: [", target, "spaced")
+	assert.ErrorContains(t, err, "unable to parse current release manifest")
+}
+
+const diffConfigMapV1 = `---
+# Source: hello/templates/config.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  color: blue
+  size: large
+---
+# Source: hello/templates/secret.yaml
+apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+stringData:
+  password: hunter2
+  user: admin
+`
+
+const diffConfigMapV2 = `---
+# Source: hello/templates/config.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  color: red
+  size: large
+---
+# Source: hello/templates/secret.yaml
+apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+stringData:
+  password: correcthorse
+  user: admin
+---
+# Source: hello/templates/extra.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: extra
+`
+
+func TestDiffRunRevisions(t *testing.T) {
+	config := actionConfigFixture(t)
+	for i, manifest := range []string{diffConfigMapV1, diffConfigMapV2} {
+		rel := namedReleaseStub("diffy", release.StatusSuperseded)
+		rel.Namespace = "spaced"
+		rel.Version = i + 1
+		rel.Manifest = manifest
+		require.NoError(t, config.Releases.Create(rel))
+	}
+
+	client := NewDiff(config)
+	client.Context = 1
+	result, err := client.RunRevisions("diffy", 1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "diffy", result.Release)
+	assert.Equal(t, 1, result.From)
+	assert.Equal(t, 2, result.To)
+	require.Len(t, result.Resources, 3)
+
+	config1 := result.Resources[0]
+	assert.Equal(t, release.DiffUpdate, config1.Action)
+	assert.Equal(t, []string{"data.color"}, config1.Changed)
+	assert.Equal(t, `--- spaced/ConfigMap/config (revision 1)
++++ spaced/ConfigMap/config (revision 2)
+@@ -2,3 +2,3 @@
+ data:
+-  color: blue
++  color: red
+   size: large
+`, config1.Diff)
+
+	assert.Contains(t, result.Resources[1].Diff, "-  password: hunter2\n+  password: correcthorse\n")
+	assert.Equal(t, release.DiffCreate, result.Resources[2].Action)
+	assert.Contains(t, result.Resources[2].Diff, "+++ spaced/ConfigMap/extra (revision 2)\n")
+
+	// Secrets are redacted, showing which values change.
+	client.RedactSecrets = true
+	result, err = client.RunRevisions("diffy", 1, 2)
+	require.NoError(t, err)
+	secret := result.Resources[1].Diff
+	assert.NotContains(t, secret, "hunter2")
+	assert.NotContains(t, secret, "correcthorse")
+	assert.Contains(t, secret, "-  password: (redacted, before)\n+  password: (redacted, after)\n   user: (redacted)\n")
+
+	_, err = client.RunRevisions("diffy", 0, 2)
+	assert.ErrorIs(t, err, errInvalidRevision)
+	_, err = client.RunRevisions("diffy", 1, 3)
+	assert.Error(t, err)
+}
+
+func TestDiffRunUpgrade(t *testing.T) {
+	upAction := upgradeAction(t)
+	rel := releaseStub()
+	rel.Namespace = "spaced"
+	rel.Manifest = diffConfigMapV1
+	require.NoError(t, upAction.cfg.Releases.Create(rel))
+
+	ch := buildChartWithTemplates([]*chart.File{
+		{Name: "templates/config.yaml", Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\ndata:\n  color: {{ .Values.color }}\n  size: large\n")},
+	})
+	client := NewDiff(upAction.cfg)
+	result, err := client.RunUpgrade(context.Background(), upAction, rel.Name, ch, map[string]interface{}{"color": "green"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.From)
+	assert.Equal(t, 2, result.To)
+	require.Len(t, result.Resources, 2)
+	assert.Equal(t, release.DiffUpdate, result.Resources[0].Action)
+	assert.Contains(t, result.Resources[0].Diff, "+++ spaced/ConfigMap/config (upgrade)\n")
+	assert.Contains(t, result.Resources[0].Diff, "-  color: blue\n+  color: green\n")
+	assert.Equal(t, release.DiffDelete, result.Resources[1].Action)
+
+	// The release is left as is.
+	last, err := upAction.cfg.Releases.Last(rel.Name)
+	require.NoError(t, err)
+	assert.Equal(t, 1, last.Version)
+}
@@ -17,6 +17,13 @@ limitations under the License.
 package action
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	releaseutil "helm.sh/helm/v4/pkg/release/util"
 	release "helm.sh/helm/v4/pkg/release/v1"
 )
 
@@ -28,6 +35,11 @@ type Get struct {
 
 	// Initializing Version to 0 will get the latest revision of the release.
 	Version int
+	// Kinds restricts the manifest to the resources of any of these kinds.
+	// Kinds are matched regardless of case.
+	Kinds []string
+	// Name restricts the manifest to the resources with this name.
+	Name string
 }
 
 // NewGet creates a new Get object with the given configuration.
@@ -38,10 +50,75 @@ func NewGet(cfg *Configuration) *Get {
 }
 
 // Run executes 'helm get' against the given release.
+//
+// When Kinds or Name is set, the manifest of the returned release only holds
+// the matching resources, in their original order and formatting.
 func (g *Get) Run(name string) (*release.Release, error) {
 	if err := g.cfg.KubeClient.IsReachable(); err != nil {
 		return nil, err
 	}
 
-	return g.cfg.releaseContent(name, g.Version)
+	rel, err := g.cfg.releaseContent(name, g.Version)
+	if err != nil || (len(g.Kinds) == 0 && g.Name == "") {
+		return rel, err
+	}
+
+	manifest, err := g.filterManifest(rel.Manifest)
+	if err != nil {
+		return nil, fmt.Errorf("release %q: %w", name, err)
+	}
+	// The release is copied, not to modify the one of the storage.
+	filtered := *rel
+	filtered.Manifest = manifest
+	return &filtered, nil
+}
+
+// filterManifest returns the documents of the manifest matching Kinds and Name.
+func (g *Get) filterManifest(manifest string) (string, error) {
+	docs := releaseutil.SplitManifests(manifest)
+	keys := make([]string, 0, len(docs))
+	for k := range docs {
+		keys = append(keys, k)
+	}
+	sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+
+	var b strings.Builder
+	for _, k := range keys {
+		var head releaseutil.SimpleHead
+		if err := yaml.Unmarshal([]byte(docs[k]), &head); err != nil {
+			return "", fmt.Errorf("unable to parse manifest: %w", err)
+		}
+		if !g.matches(head) {
+			continue
+		}
+		b.WriteString("---\n")
+		b.WriteString(docs[k])
+		b.WriteString("\n")
+	}
+	if b.Len() == 0 {
+		var filters []string
+		if len(g.Kinds) > 0 {
+			filters = append(filters, "kind "+strings.Join(g.Kinds, " or "))
+		}
+		if g.Name != "" {
+			filters = append(filters, fmt.Sprintf("name %q", g.Name))
+		}
+		return "", fmt.Errorf("no resource with %s in the manifest", strings.Join(filters, " and "))
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+func (g *Get) matches(head releaseutil.SimpleHead) bool {
+	if g.Name != "" && (head.Metadata == nil || head.Metadata.Name != g.Name) {
+		return false
+	}
+	if len(g.Kinds) == 0 {
+		return true
+	}
+	for _, kind := range g.Kinds {
+		if strings.EqualFold(kind, head.Kind) {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,136 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const getManifestFixture = `---
+# Source: web/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+# Source: web/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+---
+# Source: web/templates/worker.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: worker
+`
+
+func TestGetFilterManifest(t *testing.T) {
+	tests := []struct {
+		name   string
+		kinds  []string
+		rname  string
+		expect string
+		err    string
+	}{
+		{
+			name:  "by kind",
+			kinds: []string{"deployment"},
+			expect: `---
+# Source: web/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+---
+# Source: web/templates/worker.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: worker`,
+		},
+		{
+			name:  "by kind and name",
+			kinds: []string{"Deployment"},
+			rname: "web",
+			expect: `---
+# Source: web/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1`,
+		},
+		{
+			name:  "by name",
+			rname: "web",
+			expect: `---
+# Source: web/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+# Source: web/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1`,
+		},
+		{
+			name:  "no match",
+			kinds: []string{"ConfigMap", "Secret"},
+			rname: "web",
+			err:   `release "angry-panda": no resource with kind ConfigMap or Secret and name "web" in the manifest`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			get := NewGet(actionConfigFixture(t))
+			rel := releaseStub()
+			rel.Manifest = getManifestFixture
+			require.NoError(t, get.cfg.Releases.Create(rel))
+
+			get.Kinds = tt.kinds
+			get.Name = tt.rname
+			res, err := get.Run(rel.Name)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expect, res.Manifest)
+
+			// The stored release is left untouched.
+			stored, err := get.cfg.Releases.Get(rel.Name, rel.Version)
+			require.NoError(t, err)
+			assert.Equal(t, getManifestFixture, stored.Manifest)
+		})
+	}
+}
@@ -17,6 +17,9 @@ limitations under the License.
 package action
 
 import (
+	"errors"
+	"reflect"
+
 	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
 )
 
@@ -26,8 +29,16 @@ import (
 type GetValues struct {
 	cfg *Configuration
 
-	Version   int
+	Version int
+	// AllValues returns the effective values of the release: the values
+	// supplied by the user coalesced with the defaults of its chart and
+	// dependencies.
 	AllValues bool
+	// Delta returns the effective values of the release that differ from
+	// the defaults of its chart and dependencies, with the defaults removed
+	// by the user set to null. Installing the chart with them reproduces the
+	// effective values of the release.
+	Delta bool
 }
 
 // NewGetValues creates a new GetValues object with the given configuration.
@@ -48,13 +59,51 @@ func (g *GetValues) Run(name string) (map[string]interface{}, error) {
 		return nil, err
 	}
 
+	if g.AllValues && g.Delta {
+		return nil, errors.New("all values and the delta from the chart defaults cannot be requested together")
+	}
+
 	// If the user wants all values, compute the values and return.
-	if g.AllValues {
+	if g.AllValues || g.Delta {
 		cfg, err := chartutil.CoalesceValues(rel.Chart, rel.Config)
 		if err != nil {
 			return nil, err
 		}
-		return cfg, nil
+		if !g.Delta {
+			return cfg, nil
+		}
+		defaults, err := chartutil.CoalesceValues(rel.Chart, map[string]interface{}{})
+		if err != nil {
+			return nil, err
+		}
+		return valuesDelta(defaults, cfg), nil
 	}
 	return rel.Config, nil
 }
+
+// valuesDelta returns the values of vals that differ from those of defaults,
+// descending into the tables of both. The values of defaults missing from
+// vals are set to nil.
+func valuesDelta(defaults, vals map[string]interface{}) map[string]interface{} {
+	delta := map[string]interface{}{}
+	for k, v := range vals {
+		d, ok := defaults[k]
+		if table, isTable := v.(map[string]interface{}); isTable && ok {
+			if defaultTable, isTable := d.(map[string]interface{}); isTable {
+				if sub := valuesDelta(defaultTable, table); len(sub) > 0 {
+					delta[k] = sub
+				}
+				continue
+			}
+		}
+		if !ok || !reflect.DeepEqual(d, v) {
+			delta[k] = v
+		}
+	}
+	for k := range defaults {
+		if _, ok := vals[k]; !ok {
+			delta[k] = nil
+		}
+	}
+	return delta
+}
@@ -0,0 +1,84 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+)
+
+func TestGetValues(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	cfg := actionConfigFixture(t)
+	rel := releaseStub()
+	rel.Chart = buildChart(
+		withValues(map[string]interface{}{
+			"replicas": 1.0,
+			"image":    map[string]interface{}{"repository": "nginx", "tag": "1.0"},
+			"resources": map[string]interface{}{
+				"limits": map[string]interface{}{"cpu": "100m"},
+			},
+		}),
+		withDependency(withName("cache"), withValues(map[string]interface{}{"enabled": true})),
+	)
+	rel.Config = map[string]interface{}{
+		"replicas":  1.0,
+		"image":     map[string]interface{}{"repository": "nginx", "tag": "2.0"},
+		"resources": nil,
+		"cache":     map[string]interface{}{"enabled": false},
+	}
+	req.NoError(cfg.Releases.Create(rel))
+
+	client := NewGetValues(cfg)
+	vals, err := client.Run(rel.Name)
+	req.NoError(err)
+	is.Equal(rel.Config, vals)
+
+	client.AllValues = true
+	all, err := client.Run(rel.Name)
+	req.NoError(err)
+	is.Equal(map[string]interface{}{
+		"replicas": 1.0,
+		"image":    map[string]interface{}{"repository": "nginx", "tag": "2.0"},
+		"cache":    map[string]interface{}{"enabled": false, "global": map[string]interface{}{}},
+	}, all)
+
+	client.AllValues = false
+	client.Delta = true
+	delta, err := client.Run(rel.Name)
+	req.NoError(err)
+	is.Equal(map[string]interface{}{
+		"image":     map[string]interface{}{"tag": "2.0"},
+		"resources": nil,
+		"cache":     map[string]interface{}{"enabled": false},
+	}, delta)
+
+	// The delta reproduces the effective values of the release.
+	reproduced, err := chartutil.CoalesceValues(rel.Chart, delta)
+	req.NoError(err)
+	is.Equal(all, map[string]interface{}(reproduced))
+
+	client.AllValues = true
+	_, err = client.Run(rel.Name)
+	is.Error(err)
+}
@@ -17,14 +17,57 @@ limitations under the License.
 package action
 
 import (
-	"log/slog"
-
 	"fmt"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"strings"
 
+	chart "helm.sh/helm/v4/pkg/chart/v2"
 	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
 	release "helm.sh/helm/v4/pkg/release/v1"
 )
 
+// RevisionCause is the kind of change that made a revision of a release.
+type RevisionCause string
+
+const (
+	// RevisionInstall is the first revision of a release, or the revision
+	// installing it again once uninstalled.
+	RevisionInstall RevisionCause = "install"
+	// RevisionUpgrade is an upgrade to another chart or chart version.
+	RevisionUpgrade RevisionCause = "upgrade"
+	// RevisionRollback is a rollback to an earlier revision.
+	RevisionRollback RevisionCause = "rollback"
+	// RevisionConfig is an upgrade changing the values only.
+	RevisionConfig RevisionCause = "config"
+)
+
+// RevisionChange describes the change that made a revision of a release.
+type RevisionChange struct {
+	Cause RevisionCause `json:"cause"`
+	// RollbackTo is the revision rolled back to, if known.
+	RollbackTo int `json:"rollback_to,omitempty"`
+	// Description is the description given to the revision, such as with
+	// --description, or one summarizing the change when the revision only
+	// has the default description of its action.
+	Description string `json:"description"`
+}
+
+// defaultDescriptions are the descriptions given to revisions by the actions
+// when no description is provided. They are replaced with a summary of the
+// change; other descriptions, such as failures, are kept.
+var defaultDescriptions = map[string]bool{
+	"Initial install underway":  true,
+	"Install complete":          true,
+	"Preparing upgrade":         true,
+	"Upgrade complete":          true,
+	"superseded by new release": true,
+}
+
+// rollbackDescription is the prefix of the default description of rollbacks.
+const rollbackDescription = "Rollback to "
+
 // History is the action for checking the release's ledger.
 //
 // It provides the implementation of 'helm history'.
@@ -34,7 +77,11 @@ import (
 type History struct {
 	cfg *Configuration
 
-	Max     int
+	// Max limits the number of revisions returned, newest first. Zero
+	// returns all revisions.
+	Max int
+	// Offset skips that many of the newest revisions.
+	Offset  int
 	Version int
 }
 
@@ -56,5 +103,103 @@ func (h *History) Run(name string) ([]*release.Release, error) {
 	}
 
 	slog.Debug("getting history for release", "release", name)
+	if h.Max > 0 || h.Offset > 0 {
+		hist, _, err := h.cfg.Releases.HistoryPage(name, h.Offset, h.Max)
+		return hist, err
+	}
 	return h.cfg.Releases.History(name)
 }
+
+// Changes describes the change that made each of the revisions in hist,
+// indexed by revision. The revisions are compared with the previous revision
+// of the release, which is looked up in storage when it is not part of hist.
+func (h *History) Changes(hist []*release.Release) map[int]RevisionChange {
+	byVersion := make(map[int]*release.Release, len(hist))
+	for _, r := range hist {
+		byVersion[r.Version] = r
+	}
+
+	changes := make(map[int]RevisionChange, len(hist))
+	for _, r := range hist {
+		previous, ok := byVersion[r.Version-1]
+		if !ok && r.Version > 1 {
+			var err error
+			if previous, err = h.cfg.Releases.Get(r.Name, r.Version-1); err != nil {
+				slog.Debug("unable to get the previous revision", "release", r.Name, "revision", r.Version-1, slog.Any("error", err))
+				previous = nil
+			}
+		}
+		changes[r.Version] = revisionChange(previous, r)
+	}
+	return changes
+}
+
+// revisionChange describes the change from previous, which may be unknown,
+// to r.
+func revisionChange(previous, r *release.Release) RevisionChange {
+	var description string
+	if r.Info != nil {
+		description = r.Info.Description
+	}
+	change := RevisionChange{Description: description}
+
+	if target, ok := strings.CutPrefix(description, rollbackDescription); ok {
+		change.Cause = RevisionRollback
+		change.RollbackTo, _ = strconv.Atoi(target)
+		return change
+	}
+	if strings.HasPrefix(description, fmt.Sprintf("Rollback %q failed", r.Name)) {
+		change.Cause = RevisionRollback
+		return change
+	}
+
+	generated := description == "" || defaultDescriptions[description]
+	switch {
+	case r.Version <= 1 || (previous != nil && previous.Info != nil && previous.Info.Status == release.StatusUninstalled):
+		change.Cause = RevisionInstall
+		if generated {
+			change.Description = "Install of " + chartVersion(r.Chart)
+		}
+	case previous == nil:
+		change.Cause = RevisionUpgrade
+		if generated {
+			change.Description = "Upgrade to " + chartVersion(r.Chart)
+		}
+	case chartVersion(previous.Chart) != chartVersion(r.Chart):
+		change.Cause = RevisionUpgrade
+		if generated {
+			change.Description = fmt.Sprintf("Upgrade from %s to %s", chartVersion(previous.Chart), chartVersion(r.Chart))
+			if !sameValues(previous.Config, r.Config) {
+				change.Description += " with changed values"
+			}
+		}
+	case !sameValues(previous.Config, r.Config):
+		change.Cause = RevisionConfig
+		if generated {
+			change.Description = "Values changed, chart " + chartVersion(r.Chart) + " unchanged"
+		}
+	default:
+		change.Cause = RevisionUpgrade
+		if generated {
+			change.Description = "Upgrade without changes to the chart or values"
+		}
+	}
+	return change
+}
+
+// chartVersion names a chart along with its version, as in "mysql-1.2.0".
+func chartVersion(c *chart.Chart) string {
+	if c == nil || c.Metadata == nil {
+		return "unknown chart"
+	}
+	return c.Name() + "-" + c.Metadata.Version
+}
+
+// sameValues reports whether two sets of user-supplied values are equal,
+// treating nil and empty values alike.
+func sameValues(a, b map[string]interface{}) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
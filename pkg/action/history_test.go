@@ -0,0 +1,75 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+func TestHistoryChanges(t *testing.T) {
+	config := actionConfigFixture(t)
+	revision := func(version int, chartVersion string, values map[string]interface{}, description string) *release.Release {
+		rel := release.Mock(&release.MockReleaseOptions{
+			Name:    "timeline",
+			Version: version,
+			Status:  release.StatusSuperseded,
+			Chart:   &chart.Chart{Metadata: &chart.Metadata{Name: "foo", Version: chartVersion}},
+		})
+		rel.Config = values
+		rel.Info.Description = description
+		require.NoError(t, config.Releases.Create(rel))
+		return rel
+	}
+	hist := []*release.Release{
+		revision(1, "0.1.0", nil, "Install complete"),
+		revision(2, "0.2.0", map[string]interface{}{}, "Upgrade complete"),
+		revision(3, "0.2.0", map[string]interface{}{"replicas": 2}, "scale up"),
+		revision(4, "0.2.0", nil, "Rollback to 2"),
+		revision(5, "0.2.0", nil, `Upgrade "timeline" failed: timed out`),
+		revision(6, "0.3.0", map[string]interface{}{"replicas": 3}, "Upgrade complete"),
+		revision(7, "0.3.0", map[string]interface{}{"replicas": 3}, "Upgrade complete"),
+	}
+
+	changes := NewHistory(config).Changes(hist)
+	assert.Equal(t, map[int]RevisionChange{
+		1: {Cause: RevisionInstall, Description: "Install of foo-0.1.0"},
+		2: {Cause: RevisionUpgrade, Description: "Upgrade from foo-0.1.0 to foo-0.2.0"},
+		3: {Cause: RevisionConfig, Description: "scale up"},
+		4: {Cause: RevisionRollback, RollbackTo: 2, Description: "Rollback to 2"},
+		5: {Cause: RevisionUpgrade, Description: `Upgrade "timeline" failed: timed out`},
+		6: {Cause: RevisionUpgrade, Description: "Upgrade from foo-0.2.0 to foo-0.3.0 with changed values"},
+		7: {Cause: RevisionUpgrade, Description: "Upgrade without changes to the chart or values"},
+	}, changes)
+
+	// The previous revision is looked up in storage when not in the history.
+	changes = NewHistory(config).Changes(hist[2:4])
+	assert.Equal(t, RevisionConfig, changes[3].Cause)
+	assert.Equal(t, RevisionRollback, changes[4].Cause)
+
+	// Changes from unknown revisions are upgrades.
+	_, err := config.Releases.Delete("timeline", 2)
+	require.NoError(t, err)
+	hist[2].Info.Description = "Upgrade complete"
+	changes = NewHistory(config).Changes(hist[2:3])
+	assert.Equal(t, RevisionChange{Cause: RevisionUpgrade, Description: "Upgrade to foo-0.2.0"}, changes[3])
+}
@@ -0,0 +1,87 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+// HookRun records an execution of a hook by a release operation.
+type HookRun struct {
+	// Name is the name of the resource of the hook.
+	Name string
+	// Kind is the Kubernetes kind of the hook.
+	Kind string
+	// Path is the chart-relative path to the template of the hook.
+	Path string
+	// Weight is the weight ordering the hook among those of the event.
+	Weight int
+	// Event is the event the hook ran for.
+	Event release.HookEvent
+	// StartedAt is the time the resources of the hook were created.
+	StartedAt time.Time
+	// CompletedAt is the time the hook completed, successfully or not.
+	CompletedAt time.Time
+	// Phase tells whether the hook succeeded.
+	Phase release.HookPhase
+}
+
+// Duration returns how long the hook ran.
+func (r HookRun) Duration() time.Duration {
+	return r.CompletedAt.Sub(r.StartedAt)
+}
+
+// hookRecorder collects the hook runs of an operation, which may run its
+// hooks in the background once its context is done.
+type hookRecorder struct {
+	mu   sync.Mutex
+	runs []HookRun
+}
+
+// record adds the last run of h for event to the runs. A nil recorder
+// records nothing.
+func (r *hookRecorder) record(h *release.Hook, event release.HookEvent) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs = append(r.runs, HookRun{
+		Name:        h.Name,
+		Kind:        h.Kind,
+		Path:        h.Path,
+		Weight:      h.Weight,
+		Event:       event,
+		StartedAt:   h.LastRun.StartedAt.Time,
+		CompletedAt: h.LastRun.CompletedAt.Time,
+		Phase:       h.LastRun.Phase,
+	})
+}
+
+// all returns a copy of the runs recorded so far.
+func (r *hookRecorder) all() []HookRun {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.runs)
+}
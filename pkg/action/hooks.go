@@ -21,6 +21,7 @@ import (
 	"log"
 	"slices"
 	"sort"
+	"sync"
 	"time"
 
 	"helm.sh/helm/v4/pkg/kube"
@@ -32,8 +33,15 @@ import (
 	helmtime "helm.sh/helm/v4/pkg/time"
 )
 
-// execHook executes all of the hooks for the given hook event.
-func (cfg *Configuration) execHook(rl *release.Release, hook release.HookEvent, waitStrategy kube.WaitStrategy, timeout time.Duration) error {
+// execHook executes all of the hooks for the given hook event, recording
+// their runs in runs if not nil.
+func (cfg *Configuration) execHook(rl *release.Release, hook release.HookEvent, waitStrategy kube.WaitStrategy, timeout time.Duration, runs *hookRecorder) error {
+	return cfg.execHookWithCallback(rl, hook, waitStrategy, timeout, runs, nil)
+}
+
+// execHookWithCallback is execHook, calling created, if not nil, once the
+// resources of each hook are created.
+func (cfg *Configuration) execHookWithCallback(rl *release.Release, hook release.HookEvent, waitStrategy kube.WaitStrategy, timeout time.Duration, runs *hookRecorder, created func(*release.Hook)) error {
 	executingHooks := []*release.Hook{}
 
 	for _, h := range rl.Hooks {
@@ -47,55 +55,18 @@ func (cfg *Configuration) execHook(rl *release.Release, hook release.HookEvent,
 	// hooke are pre-ordered by kind, so keep order stable
 	sort.Stable(hookByWeight(executingHooks))
 
+	// The hooks run one after the other, so the lock is never contended.
+	var mu sync.Mutex
 	for i, h := range executingHooks {
-		// Set default delete policy to before-hook-creation
-		if len(h.DeletePolicies) == 0 {
-			// TODO(jlegrone): Only apply before-hook-creation delete policy to run to completion
-			//                 resources. For all other resource types update in place if a
-			//                 resource with the same name already exists and is owned by the
-			//                 current release.
-			h.DeletePolicies = []release.HookDeletePolicy{release.HookBeforeHookCreation}
-		}
-
-		if err := cfg.deleteHookByPolicy(h, release.HookBeforeHookCreation, waitStrategy, timeout); err != nil {
+		watched, err := cfg.runHook(rl, h, hook, waitStrategy, timeout, &mu, created)
+		if err != nil && !watched {
+			if h.LastRun.Phase == release.HookPhaseFailed {
+				runs.record(h, hook)
+			}
 			return err
 		}
-
-		resources, err := cfg.KubeClient.Build(bytes.NewBufferString(h.Manifest), true)
-		if err != nil {
-			return fmt.Errorf("unable to build kubernetes object for %s hook %s: %w", hook, h.Path, err)
-		}
-
-		// Record the time at which the hook was applied to the cluster
-		h.LastRun = release.HookExecution{
-			StartedAt: helmtime.Now(),
-			Phase:     release.HookPhaseRunning,
-		}
-		cfg.recordRelease(rl)
-
-		// As long as the implementation of WatchUntilReady does not panic, HookPhaseFailed or HookPhaseSucceeded
-		// should always be set by this function. If we fail to do that for any reason, then HookPhaseUnknown is
-		// the most appropriate value to surface.
-		h.LastRun.Phase = release.HookPhaseUnknown
-
-		// Create hook resources
-		if _, err := cfg.KubeClient.Create(resources); err != nil {
-			h.LastRun.CompletedAt = helmtime.Now()
-			h.LastRun.Phase = release.HookPhaseFailed
-			return fmt.Errorf("warning: Hook %s %s failed: %w", hook, h.Path, err)
-		}
-
-		waiter, err := cfg.KubeClient.GetWaiter(waitStrategy)
+		runs.record(h, hook)
 		if err != nil {
-			return fmt.Errorf("unable to get waiter: %w", err)
-		}
-		// Watch hook resources until they have completed
-		err = waiter.WatchUntilReady(resources, timeout)
-		// Note the time of success/failure
-		h.LastRun.CompletedAt = helmtime.Now()
-		// Mark hook as succeeded or failed
-		if err != nil {
-			h.LastRun.Phase = release.HookPhaseFailed
 			// If a hook is failed, check the annotation of the hook to determine if we should copy the logs client side
 			if errOutputting := cfg.outputLogsByPolicy(h, rl.Namespace, release.HookOutputOnFailed); errOutputting != nil {
 				// We log the error here as we want to propagate the hook failure upwards to the release object.
@@ -116,7 +87,6 @@ func (cfg *Configuration) execHook(rl *release.Release, hook release.HookEvent,
 
 			return err
 		}
-		h.LastRun.Phase = release.HookPhaseSucceeded
 	}
 
 	// If all hooks are successful, check the annotation of each hook to determine whether the hook should be deleted
@@ -135,6 +105,75 @@ func (cfg *Configuration) execHook(rl *release.Release, hook release.HookEvent,
 	return nil
 }
 
+// runHook creates the resources of the hook h, calling created, if not nil,
+// once they are, and watches them until they are ready, recording the run of
+// the hook in h.LastRun. mu guards h and the release rl.
+//
+// watched reports whether the error, if any, is the failure of the hook
+// resources to become ready.
+func (cfg *Configuration) runHook(rl *release.Release, h *release.Hook, hook release.HookEvent, waitStrategy kube.WaitStrategy, timeout time.Duration, mu sync.Locker, created func(*release.Hook)) (watched bool, err error) {
+	mu.Lock()
+	// Set default delete policy to before-hook-creation
+	if len(h.DeletePolicies) == 0 {
+		// TODO(jlegrone): Only apply before-hook-creation delete policy to run to completion
+		//                 resources. For all other resource types update in place if a
+		//                 resource with the same name already exists and is owned by the
+		//                 current release.
+		h.DeletePolicies = []release.HookDeletePolicy{release.HookBeforeHookCreation}
+	}
+	mu.Unlock()
+
+	if err := cfg.deleteHookByPolicy(h, release.HookBeforeHookCreation, waitStrategy, timeout); err != nil {
+		return false, err
+	}
+
+	resources, err := cfg.KubeClient.Build(bytes.NewBufferString(h.Manifest), true)
+	if err != nil {
+		return false, fmt.Errorf("unable to build kubernetes object for %s hook %s: %w", hook, h.Path, err)
+	}
+
+	// Record the time at which the hook was applied to the cluster
+	mu.Lock()
+	h.LastRun = release.HookExecution{
+		StartedAt: helmtime.Now(),
+		Phase:     release.HookPhaseRunning,
+	}
+	cfg.recordRelease(rl)
+	// As long as the implementation of WatchUntilReady does not panic, HookPhaseFailed or HookPhaseSucceeded
+	// should always be set by this function. If we fail to do that for any reason, then HookPhaseUnknown is
+	// the most appropriate value to surface.
+	h.LastRun.Phase = release.HookPhaseUnknown
+	mu.Unlock()
+
+	complete := func(phase release.HookPhase) {
+		mu.Lock()
+		h.LastRun.CompletedAt = helmtime.Now()
+		h.LastRun.Phase = phase
+		mu.Unlock()
+	}
+
+	// Create hook resources
+	if _, err := cfg.KubeClient.Create(resources); err != nil {
+		complete(release.HookPhaseFailed)
+		return false, fmt.Errorf("warning: Hook %s %s failed: %w", hook, h.Path, err)
+	}
+	if created != nil {
+		created(h)
+	}
+
+	waiter, err := cfg.KubeClient.GetWaiter(waitStrategy)
+	if err != nil {
+		return false, fmt.Errorf("unable to get waiter: %w", err)
+	}
+	// Watch hook resources until they have completed
+	if err := waiter.WatchUntilReady(resources, timeout); err != nil {
+		complete(release.HookPhaseFailed)
+		return true, err
+	}
+	complete(release.HookPhaseSucceeded)
+	return false, nil
+}
+
 // hookByWeight is a sorter for hooks
 type hookByWeight []*release.Hook
 
@@ -385,7 +385,8 @@ data:
 				Capabilities: chartutil.DefaultCapabilities,
 			}
 
-			err := configuration.execHook(&tc.inputRelease, hookEvent, kube.StatusWatcherStrategy, 600)
+			runs := &hookRecorder{}
+			err := configuration.execHook(&tc.inputRelease, hookEvent, kube.StatusWatcherStrategy, 600, runs)
 
 			if !reflect.DeepEqual(kubeClient.deleteRecord, tc.expectedDeleteRecord) {
 				t.Fatalf("Got unexpected delete record, expected: %#v, but got: %#v", kubeClient.deleteRecord, tc.expectedDeleteRecord)
@@ -398,6 +399,15 @@ data:
 			if err == nil && tc.expectError {
 				t.Fatalf("Expected and error but did not get it.")
 			}
+
+			// The failing hook is the last run recorded.
+			recorded := runs.all()
+			if len(recorded) == 0 {
+				t.Fatal("Expected the hook runs to be recorded")
+			}
+			if last := recorded[len(recorded)-1]; tc.expectError && last.Phase != release.HookPhaseFailed {
+				t.Fatalf("Expected the last hook run to fail, got %s for %s", last.Phase, last.Name)
+			}
 		})
 	}
 }
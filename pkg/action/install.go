@@ -24,10 +24,12 @@ import (
 	"io"
 	"io/fs"
 	"log/slog"
+	"math/rand/v2"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"text/template"
@@ -35,9 +37,11 @@ import (
 
 	"github.com/Masterminds/sprig/v3"
 	v1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/cli-runtime/pkg/resource"
 	"sigs.k8s.io/yaml"
 
@@ -57,17 +61,17 @@ import (
 	"helm.sh/helm/v4/pkg/storage/driver"
 )
 
-// notesFileSuffix that we want to treat special. It goes through the templating engine
-// but it's not a yaml file (resource) hence can't have hooks, etc. And the user actually
-// wants to see this file after rendering in the status command. However, it must be a suffix
-// since there can be filepath in front of it.
-const notesFileSuffix = "NOTES.txt"
-
 const defaultDirectoryPermission = 0755
 
 // Install performs an installation operation.
 type Install struct {
 	cfg *Configuration
+	// renderResult is the result of the last render of Run.
+	renderResult *RenderResult
+	// hookRuns records the hooks run by the last run.
+	hookRuns *hookRecorder
+	// waitExpressions are the parsed WaitFor expressions.
+	waitExpressions []*kube.WaitExpression
 
 	ChartPathOptions
 
@@ -100,6 +104,13 @@ type Install struct {
 	DisableOpenAPIValidation bool
 	IncludeCRDs              bool
 	Labels                   map[string]string
+	// Annotations are added to the storage object of the release. Their keys
+	// must be prefixed with "helm.sh/".
+	Annotations map[string]string
+	// ListMergeKeys maps the dotted paths of lists in the values to the field
+	// their elements are merged by, instead of replacing the lists of the
+	// chart values.
+	ListMergeKeys map[string]string
 	// KubeVersion allows specifying a custom kubernetes version to use and
 	// APIVersions allows a manual set of supported API Versions to be passed
 	// (for things like templating). These are ignored if ClientOnly is false
@@ -114,7 +125,42 @@ type Install struct {
 	UseReleaseName bool
 	// TakeOwnership will ignore the check for helm annotations and take ownership of the resources.
 	TakeOwnership bool
-	PostRenderer  postrender.PostRenderer
+	// FieldManager is the name of the field manager recorded for the changes
+	// made to resources. The default of the Kubernetes client is used if empty.
+	FieldManager string
+	// Parallelism is the maximum number of resources of the same kind applied
+	// at once. The default of the Kubernetes client is used if zero.
+	Parallelism int
+	// Preflight checks, before the install, that the ConfigMaps and Secrets
+	// referenced by the workloads of the release are created by the release
+	// or present in the cluster, and warns about those missing.
+	Preflight bool
+	// PreflightStrict fails the install on the missing references found by
+	// the preflight check. It implies Preflight.
+	PreflightStrict bool
+	// ApplyOnly limits the resources applied to those matching one of these
+	// selectors, of the form "Kind/name" or a glob matching the paths of the
+	// templates in the chart, such as "charts/database/templates/*.yaml".
+	// The names of the resources and the paths can be globs, and a path
+	// matching a directory, such as "charts/database", selects the
+	// templates in it. Hooks are run as for a complete apply. The manifest of
+	// the release records the selectors and holds the resources applied.
+	// It requires the FeatureGatePartialApply gate: applying a part of a
+	// chart may leave the release in a state the chart does not expect.
+	ApplyOnly []string
+	// WaitFor lists expressions, such as
+	// "deployment/web:.status.availableReplicas>=3", that must all hold for
+	// the resources of the release namespace they name before the install
+	// succeeds, after the wait of WaitStrategy. They are checked by polling
+	// the resources for what is left of Timeout after that wait, as
+	// described by kube.WaitExpression.
+	WaitFor      []string
+	PostRenderer postrender.PostRenderer
+	// MaxHistory limits the maximum number of revisions saved per release
+	// when replacing a release, pruning the oldest ones after a successful
+	// install. It defaults to the MaxHistory of the configuration; zero
+	// means no limit.
+	MaxHistory int
 	// Lock to control raceconditions when the process receives a SIGTERM
 	Lock sync.Mutex
 }
@@ -123,6 +169,7 @@ type Install struct {
 type ChartPathOptions struct {
 	CaFile                string // --ca-file
 	CertFile              string // --cert-file
+	DownloadCache         string // --download-cache
 	KeyFile               string // --key-file
 	InsecureSkipTLSverify bool   // --insecure-skip-verify
 	PlainHTTP             bool   // --plain-http
@@ -130,10 +177,17 @@ type ChartPathOptions struct {
 	Password              string // --password
 	PassCredentialsAll    bool   // --pass-credentials
 	RepoURL               string // --repo
+	Retries               int    // --retries
+	SkipDigestVerify      bool   // --skip-digest-verify
 	Username              string // --username
 	Verify                bool   // --verify
 	Version               string // --version
 
+	// RepoMaxAge is the age past which the cached index of the repository of
+	// a chart is downloaded again before locating the chart. Zero never
+	// refreshes it.
+	RepoMaxAge time.Duration // --repo-max-age
+
 	// registryClient provides a registry client but is not added with
 	// options from a flag
 	registryClient *registry.Client
@@ -142,7 +196,8 @@ type ChartPathOptions struct {
 // NewInstall creates a new Install object with the given configuration.
 func NewInstall(cfg *Configuration) *Install {
 	in := &Install{
-		cfg: cfg,
+		cfg:        cfg,
+		MaxHistory: cfg.MaxHistory,
 	}
 	in.registryClient = cfg.RegistryClient
 
@@ -159,6 +214,18 @@ func (i *Install) GetRegistryClient() *registry.Client {
 	return i.registryClient
 }
 
+// RenderResult returns the documents rendered by the last run, along with the
+// templates they come from, or nil if nothing was rendered.
+func (i *Install) RenderResult() *RenderResult {
+	return i.renderResult
+}
+
+// HookRuns returns the runs of the hooks by the last run, in their order. A
+// hook failing is the last run, and its run is recorded.
+func (i *Install) HookRuns() []HookRun {
+	return i.hookRuns.all()
+}
+
 func (i *Install) installCRDs(crds []chart.CRD) error {
 	// We do these one file at a time in the order they were read.
 	totalItems := []*resource.Info{}
@@ -206,6 +273,12 @@ func (i *Install) installCRDs(crds []chart.CRD) error {
 			discoveryClient.Invalidate()
 
 			_, _ = discoveryClient.ServerGroups()
+
+			// The capabilities are not gathered again, so the new CRDs are
+			// added to them.
+			capabilities := i.cfg.Capabilities.Copy()
+			capabilities.APIVersions = capabilities.APIVersions.Merge(installedCRDVersions(totalItems))
+			i.cfg.Capabilities = capabilities
 		}
 
 		// Invalidate the REST mapper, since it will not have the new CRDs
@@ -222,6 +295,24 @@ func (i *Install) installCRDs(crds []chart.CRD) error {
 	return nil
 }
 
+// installedCRDVersions returns the API versions served by the custom resource
+// definitions of infos.
+func installedCRDVersions(infos []*resource.Info) chartutil.VersionSet {
+	var versions chartutil.VersionSet
+	for _, info := range infos {
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(info.Object)
+		if err != nil {
+			continue
+		}
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj, crd); err != nil {
+			continue
+		}
+		versions = versions.Merge(chartutil.CRDVersions(crd))
+	}
+	return versions
+}
+
 // Run executes the installation
 //
 // If DryRun is set to true, this will prepare the release, but not install it
@@ -236,12 +327,25 @@ func (i *Install) Run(chrt *chart.Chart, vals map[string]interface{}) (*release.
 // When the task is cancelled through ctx, the function returns and the install
 // proceeds in the background.
 func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals map[string]interface{}) (*release.Release, error) {
+	i.renderResult = nil
+	i.hookRuns = &hookRecorder{}
+
 	// Check reachability of cluster unless in client-only mode (e.g. `helm template` without `--validate`)
 	if !i.ClientOnly {
 		if err := i.cfg.KubeClient.IsReachable(); err != nil {
 			slog.Error(fmt.Sprintf("cluster reachability check failed: %v", err))
 			return nil, fmt.Errorf("cluster reachability check failed: %w", err)
 		}
+		resetFieldManager, err := i.cfg.setFieldManager(i.FieldManager)
+		if err != nil {
+			return nil, err
+		}
+		defer resetFieldManager()
+		resetParallelism, err := i.cfg.setParallelism(i.Parallelism)
+		if err != nil {
+			return nil, err
+		}
+		defer resetParallelism()
 	}
 
 	// HideSecret must be used with dry run. Otherwise, return an error.
@@ -250,7 +354,25 @@ func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals ma
 		return nil, errors.New("hiding Kubernetes secrets requires a dry-run mode")
 	}
 
-	if err := i.availableName(); err != nil {
+	var applySelectors []applySelector
+	if len(i.ApplyOnly) > 0 {
+		var err error
+		if applySelectors, err = parseApplySelectors(i.ApplyOnly); err != nil {
+			return nil, err
+		}
+	}
+	waitExpressions, err := parseWaitExpressions(i.WaitFor)
+	if err != nil {
+		return nil, err
+	}
+	i.waitExpressions = waitExpressions
+
+	if i.ReleaseName == "" && i.GenerateName && i.NameTemplate != "" {
+		if err := i.generateName(chrt.Name()); err != nil {
+			slog.Error("release name generation failed", slog.Any("error", err))
+			return nil, fmt.Errorf("release name generation failed: %w", err)
+		}
+	} else if err := i.availableName(); err != nil {
 		slog.Error("release name check failed", slog.Any("error", err))
 		return nil, fmt.Errorf("release name check failed: %w", err)
 	}
@@ -307,13 +429,20 @@ func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals ma
 	// special case for helm template --is-upgrade
 	isUpgrade := i.IsUpgrade && i.isDryRun()
 	options := chartutil.ReleaseOptions{
-		Name:      i.ReleaseName,
-		Namespace: i.Namespace,
-		Revision:  1,
-		IsInstall: !isUpgrade,
-		IsUpgrade: isUpgrade,
+		Name:          i.ReleaseName,
+		Namespace:     i.Namespace,
+		Revision:      1,
+		IsInstall:     !isUpgrade,
+		IsUpgrade:     isUpgrade,
+		ListMergeKeys: i.ListMergeKeys,
+	}
+	// The computed defaults are not saved with the release, to be computed
+	// again on upgrade.
+	withDefaults, err := i.cfg.computeDefaults(chrt, vals, options, caps, i.EnableDNS)
+	if err != nil {
+		return nil, err
 	}
-	valuesToRender, err := chartutil.ToRenderValuesWithSchemaValidation(chrt, vals, options, caps, i.SkipSchemaValidation)
+	valuesToRender, err := chartutil.ToRenderValuesWithSchemaValidation(chrt, withDefaults, options, caps, i.SkipSchemaValidation)
 	if err != nil {
 		return nil, err
 	}
@@ -321,11 +450,17 @@ func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals ma
 	if driver.ContainsSystemLabels(i.Labels) {
 		return nil, fmt.Errorf("user supplied labels contains system reserved label name. System labels: %+v", driver.GetSystemLabels())
 	}
+	if driver.ContainsNonReleaseAnnotations(i.Annotations) {
+		return nil, fmt.Errorf("user supplied annotations must be prefixed with %q", driver.ReleaseAnnotationPrefix)
+	}
 
 	rel := i.createRelease(chrt, vals, i.Labels)
+	rel.Annotations = i.Annotations
+
+	i.renderResult = &RenderResult{}
 
 	var manifestDoc *bytes.Buffer
-	rel.Hooks, manifestDoc, rel.Info.Notes, err = i.cfg.renderResources(chrt, valuesToRender, i.ReleaseName, i.OutputDir, i.SubNotes, i.UseReleaseName, i.IncludeCRDs, i.PostRenderer, interactWithRemote, i.EnableDNS, i.HideSecret)
+	rel.Hooks, manifestDoc, rel.Info.Notes, err = i.cfg.renderResources(chrt, valuesToRender, i.ReleaseName, i.OutputDir, i.SubNotes, i.UseReleaseName, i.IncludeCRDs, i.PostRenderer, interactWithRemote, i.EnableDNS, i.HideSecret, i.renderResult)
 	// Even for errors, attach this if available
 	if manifestDoc != nil {
 		rel.Manifest = manifestDoc.String()
@@ -336,6 +471,11 @@ func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals ma
 		// Return a release with partial data so that the client can show debugging information.
 		return rel, err
 	}
+	if len(applySelectors) > 0 {
+		if rel.Manifest, err = partialManifest("", rel.Manifest, applySelectors); err != nil {
+			return nil, err
+		}
+	}
 
 	// Mark this release as in-progress
 	rel.SetStatus(release.StatusPendingInstall, "Initial install underway")
@@ -369,6 +509,12 @@ func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals ma
 		}
 	}
 
+	if !i.ClientOnly && (i.Preflight || i.PreflightStrict) {
+		if err := i.cfg.preflight(rel.Manifest, rel.Namespace, i.PreflightStrict); err != nil {
+			return nil, err
+		}
+	}
+
 	// Bail out here if it is a dry run
 	if i.isDryRun() {
 		rel.Info.Description = "Dry run complete"
@@ -410,6 +556,7 @@ func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals ma
 
 	// Store the release in history before continuing (new in Helm 3). We always know
 	// that this is a create operation.
+	i.cfg.Releases.MaxHistory = i.MaxHistory
 	if err := i.cfg.Releases.Create(rel); err != nil {
 		// We could try to recover gracefully here, but since nothing has been installed
 		// yet, this is probably safer than trying to continue when we know storage is
@@ -419,9 +566,10 @@ func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals ma
 
 	rel, err = i.performInstallCtx(ctx, rel, toBeAdopted, resources)
 	if err != nil {
-		rel, err = i.failRelease(rel, err)
+		return i.failRelease(rel, err)
 	}
-	return rel, err
+	i.cfg.pruneHistory(rel.Name)
+	return rel, nil
 }
 
 func (i *Install) performInstallCtx(ctx context.Context, rel *release.Release, toBeAdopted kube.ResourceList, resources kube.ResourceList) (*release.Release, error) {
@@ -456,7 +604,7 @@ func (i *Install) performInstall(rel *release.Release, toBeAdopted kube.Resource
 	var err error
 	// pre-install hooks
 	if !i.DisableHooks {
-		if err := i.cfg.execHook(rel, release.HookPreInstall, i.WaitStrategy, i.Timeout); err != nil {
+		if err := i.cfg.execHook(rel, release.HookPreInstall, i.WaitStrategy, i.Timeout, i.hookRuns); err != nil {
 			return rel, fmt.Errorf("failed pre-install: %s", err)
 		}
 	}
@@ -482,6 +630,8 @@ func (i *Install) performInstall(rel *release.Release, toBeAdopted kube.Resource
 		return rel, fmt.Errorf("failed to get waiter: %w", err)
 	}
 
+	// The wait for expressions shares the timeout of the wait of the resources.
+	deadline := time.Now().Add(i.Timeout)
 	if i.WaitForJobs {
 		err = waiter.WaitWithJobs(resources, i.Timeout)
 	} else {
@@ -490,9 +640,12 @@ func (i *Install) performInstall(rel *release.Release, toBeAdopted kube.Resource
 	if err != nil {
 		return rel, err
 	}
+	if err := i.cfg.waitForExpressions(rel.Namespace, i.waitExpressions, time.Until(deadline)); err != nil {
+		return rel, err
+	}
 
 	if !i.DisableHooks {
-		if err := i.cfg.execHook(rel, release.HookPostInstall, i.WaitStrategy, i.Timeout); err != nil {
+		if err := i.cfg.execHook(rel, release.HookPostInstall, i.WaitStrategy, i.Timeout, i.hookRuns); err != nil {
 			return rel, fmt.Errorf("failed post-install: %s", err)
 		}
 	}
@@ -563,7 +716,52 @@ func (i *Install) availableName() error {
 	if st := rel.Info.Status; i.Replace && (st == release.StatusUninstalled || st == release.StatusFailed) {
 		return nil
 	}
-	return errors.New("cannot reuse a name that is still in use")
+	return errNameInUse
+}
+
+// errNameInUse is returned by availableName for the names of existing
+// releases.
+var errNameInUse = errors.New("cannot reuse a name that is still in use")
+
+// maxNameAttempts is the number of names generated from the name template
+// before giving up on finding one that is not in use.
+const maxNameAttempts = 10
+
+// generateName sets the release name to the first available name rendered
+// from the name template for the chart chartName, each attempt with a new
+// random token.
+func (i *Install) generateName(chartName string) error {
+	var tried []string
+	for range maxNameAttempts {
+		name, err := templateName(i.NameTemplate, NameTemplateData{Chart: chartName, Token: nameToken()})
+		if err != nil {
+			return err
+		}
+		if slices.Contains(tried, name) {
+			// The template does not use the token.
+			break
+		}
+		tried = append(tried, name)
+		i.ReleaseName = name
+		if err := i.availableName(); !errors.Is(err, errNameInUse) {
+			return err
+		}
+		slog.Debug("generated release name is in use", "name", name)
+	}
+	return fmt.Errorf("no generated name is available after trying %s: %w", strings.Join(tried, ", "), errNameInUse)
+}
+
+// nameTokenLength is the length of the random tokens of name templates.
+const nameTokenLength = 5
+
+// nameToken returns a random token valid in release names.
+var nameToken = func() string {
+	const chars = "abcdefghijklmnopqrstuvwxyz0123456789"
+	token := make([]byte, nameTokenLength)
+	for i := range token {
+		token[i] = chars[rand.IntN(len(chars))]
+	}
+	return string(token)
 }
 
 // createRelease creates a new release object
@@ -663,7 +861,9 @@ func ensureDirectoryForFile(file string) error {
 
 // NameAndChart returns the name and chart that should be used.
 //
-// This will read the flags and handle name generation if necessary.
+// This will read the flags and handle name generation if necessary. The name
+// is empty when both GenerateName and NameTemplate are set, in which case it
+// is generated by Run once the chart is loaded.
 func (i *Install) NameAndChart(args []string) (string, string, error) {
 	flagsNotSet := func() error {
 		if i.GenerateName {
@@ -684,6 +884,9 @@ func (i *Install) NameAndChart(args []string) (string, string, error) {
 	}
 
 	if i.NameTemplate != "" {
+		if i.GenerateName {
+			return "", args[0], nil
+		}
 		name, err := TemplateName(i.NameTemplate)
 		return name, args[0], err
 	}
@@ -708,8 +911,23 @@ func (i *Install) NameAndChart(args []string) (string, string, error) {
 	return fmt.Sprintf("%s-%d", base, time.Now().Unix()), args[0], nil
 }
 
+// NameTemplateData is the data name templates are rendered with when names
+// are generated, as in "{{ .Chart }}-{{ .Token }}".
+type NameTemplateData struct {
+	// Chart is the name of the chart.
+	Chart string
+	// Token is a random token of lowercase letters and digits, different
+	// for each attempt at finding a name not in use.
+	Token string
+}
+
 // TemplateName renders a name template, returning the name or an error.
 func TemplateName(nameTemplate string) (string, error) {
+	return templateName(nameTemplate, nil)
+}
+
+// templateName renders a name template with data.
+func templateName(nameTemplate string, data interface{}) (string, error) {
 	if nameTemplate == "" {
 		return "", nil
 	}
@@ -719,7 +937,7 @@ func TemplateName(nameTemplate string) (string, error) {
 		return "", err
 	}
 	var b bytes.Buffer
-	if err := t.Execute(&b, nil); err != nil {
+	if err := t.Execute(&b, data); err != nil {
 		return "", err
 	}
 
@@ -780,6 +998,12 @@ func (c *ChartPathOptions) LocateChart(name string, settings *cli.EnvSettings) (
 		return name, fmt.Errorf("path %q not found", name)
 	}
 
+	if c.RepoMaxAge > 0 && c.RepoURL == "" && !registry.IsOCI(name) {
+		if repoName, _, ok := strings.Cut(name, "/"); ok {
+			refreshStaleRepository(repoName, settings, c.RepoMaxAge)
+		}
+	}
+
 	dl := downloader.ChartDownloader{
 		Out:     os.Stdout,
 		Keyring: c.Keyring,
@@ -790,10 +1014,13 @@ func (c *ChartPathOptions) LocateChart(name string, settings *cli.EnvSettings) (
 			getter.WithInsecureSkipVerifyTLS(c.InsecureSkipTLSverify),
 			getter.WithPlainHTTP(c.PlainHTTP),
 			getter.WithBasicAuth(c.Username, c.Password),
+			getter.WithRetries(c.Retries),
+			getter.WithCacheDir(c.DownloadCache),
 		},
 		RepositoryConfig: settings.RepositoryConfig,
 		RepositoryCache:  settings.RepositoryCache,
 		RegistryClient:   c.registryClient,
+		SkipDigestVerify: c.SkipDigestVerify,
 	}
 
 	if registry.IsOCI(name) {
@@ -857,3 +1084,24 @@ func (c *ChartPathOptions) LocateChart(name string, settings *cli.EnvSettings) (
 	}
 	return lname, nil
 }
+
+// refreshStaleRepository downloads again the index of the repository named
+// name when its cached index is older than maxAge. Failures are left to the
+// lookup of the chart in the cached index.
+func refreshStaleRepository(name string, settings *cli.EnvSettings, maxAge time.Duration) {
+	f, err := repo.LoadFile(settings.RepositoryConfig)
+	if err != nil {
+		return
+	}
+	entry := f.Get(name)
+	if entry == nil {
+		return
+	}
+	r, err := repo.NewChartRepository(entry, getter.All(settings))
+	if err != nil {
+		slog.Debug("unable to refresh the repository", "name", name, slog.Any("error", err))
+		return
+	}
+	r.CachePath = settings.RepositoryCache
+	repo.RefreshStaleIndexes([]*repo.ChartRepository{r}, maxAge)
+}
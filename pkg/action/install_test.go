@@ -37,6 +37,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	kuberuntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/cli-runtime/pkg/resource"
@@ -152,6 +153,12 @@ func TestInstallRelease(t *testing.T) {
 	is.Contains(rel.Manifest, "---\n# Source: hello/templates/hello\nhello: world")
 	is.Equal(rel.Info.Description, "Install complete")
 
+	runs := instAction.HookRuns()
+	if is.Len(runs, 1) {
+		is.Equal(release.HookPostInstall, runs[0].Event)
+		is.Equal(release.HookPhaseSucceeded, runs[0].Phase)
+	}
+
 	// Detecting previous bug where context termination after successful release
 	// caused release to fail.
 	done()
@@ -272,6 +279,26 @@ func TestInstallRelease_NoName(t *testing.T) {
 	assert.Contains(t, err.Error(), "no name provided")
 }
 
+func TestInstallRelease_ComputedDefaults(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	instAction := installAction(t)
+	chrt := buildChartWithTemplates([]*chart.File{
+		{Name: "templates/hello", Data: []byte("cpu: {{ .Values.cpu }}")},
+	}, withValues(map[string]interface{}{"tier": "small", "cpu": "50m"}))
+	chrt.Files = []*chart.File{{Name: "defaults.tpl", Data: []byte(`cpu: {{ if eq .Values.tier "large" }}2{{ else }}500m{{ end }}`)}}
+	vals := map[string]interface{}{"tier": "large"}
+
+	res, err := instAction.Run(chrt, vals)
+	req.NoError(err)
+	is.Contains(res.Manifest, "cpu: 2")
+
+	// Only the values of the user are saved, for the defaults to be
+	// computed again on upgrade.
+	is.Equal(vals, res.Config)
+}
+
 func TestInstallRelease_WithNotes(t *testing.T) {
 	is := assert.New(t)
 	instAction := installAction(t)
@@ -488,6 +515,17 @@ func TestInstallRelease_FailedHooks(t *testing.T) {
 	is.Contains(res.Info.Description, "failed post-install")
 	is.Equal("", outBuffer.String())
 	is.Equal(release.StatusFailed, res.Info.Status)
+
+	// The failed hook run is recorded.
+	runs := instAction.HookRuns()
+	if is.Len(runs, 1) {
+		is.Equal("test-cm", runs[0].Name)
+		is.Equal("ConfigMap", runs[0].Kind)
+		is.Equal(release.HookPostInstall, runs[0].Event)
+		is.Equal(release.HookPhaseFailed, runs[0].Phase)
+		is.False(runs[0].StartedAt.IsZero())
+		is.False(runs[0].CompletedAt.Before(runs[0].StartedAt))
+	}
 }
 
 func TestInstallRelease_ReplaceRelease(t *testing.T) {
@@ -902,6 +940,69 @@ func TestNameAndChartGenerateName(t *testing.T) {
 	}
 }
 
+func TestInstallGenerateNameTemplate(t *testing.T) {
+	is := assert.New(t)
+	instAction := installAction(t)
+	instAction.ReleaseName = ""
+	instAction.GenerateName = true
+	instAction.NameTemplate = "{{ .Chart }}-{{ .Token }}"
+
+	name, chrt, err := instAction.NameAndChart([]string{"./foo"})
+	is.NoError(err)
+	is.Equal("", name, "expected the name to be generated on install")
+	is.Equal("./foo", chrt)
+
+	tokens := []string{"a1", "b2"}
+	defer func(orig func() string) { nameToken = orig }(nameToken)
+	nameToken = func() string {
+		token := tokens[0]
+		tokens = append(tokens[1:], token)
+		return token
+	}
+	is.NoError(instAction.cfg.Releases.Create(namedReleaseStub("hello-a1", release.StatusDeployed)))
+
+	// A name in use is retried with a new token.
+	res, err := instAction.Run(buildChart(), map[string]interface{}{})
+	is.NoError(err)
+	is.Equal("hello-b2", res.Name)
+
+	// Templates without the token are rendered once.
+	instAction = installActionWithConfig(instAction.cfg)
+	instAction.ReleaseName = ""
+	instAction.GenerateName = true
+	instAction.NameTemplate = "{{ .Chart }}-b2"
+	_, err = instAction.Run(buildChart(), map[string]interface{}{})
+	is.ErrorContains(err, "no generated name is available after trying hello-b2: cannot reuse a name that is still in use")
+
+	instAction.ReleaseName = ""
+	instAction.NameTemplate = "{{ .Chart }}_{{ .Token }}"
+	_, err = instAction.Run(buildChart(), map[string]interface{}{})
+	is.ErrorContains(err, "release name generation failed: release name \"hello_")
+}
+
+func TestInstalledCRDVersions(t *testing.T) {
+	crd := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]interface{}{"name": "certificates.cert-manager.io"},
+		"spec": map[string]interface{}{
+			"group": "cert-manager.io",
+			"names": map[string]interface{}{"kind": "Certificate", "plural": "certificates"},
+			"versions": []interface{}{
+				map[string]interface{}{"name": "v1", "served": true, "storage": true},
+			},
+		},
+	}}
+	other := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "config"},
+	}}
+
+	vs := installedCRDVersions([]*resource.Info{{Object: crd}, {Object: other}})
+	assert.Equal(t, chartutil.VersionSet{"cert-manager.io/v1", "cert-manager.io/v1/Certificate"}, vs)
+}
+
 func TestInstallWithLabels(t *testing.T) {
 	is := assert.New(t)
 	instAction := installAction(t)
@@ -917,6 +1018,58 @@ func TestInstallWithLabels(t *testing.T) {
 	is.Equal(instAction.Labels, res.Labels)
 }
 
+func TestInstallWithAnnotations(t *testing.T) {
+	is := assert.New(t)
+	instAction := installAction(t)
+	instAction.Annotations = map[string]string{
+		"helm.sh/team": "payments",
+	}
+	res, err := instAction.Run(buildChart(), nil)
+	if err != nil {
+		t.Fatalf("Failed install: %s", err)
+	}
+
+	is.Equal(instAction.Annotations, res.Annotations)
+}
+
+func TestInstallRenderResult(t *testing.T) {
+	is := assert.New(t)
+	instAction := installAction(t)
+	chrt := buildChart(
+		withValues(map[string]interface{}{"who": "parent"}),
+		withDependency(withName("sub"), withValues(map[string]interface{}{"who": "sub"})),
+	)
+	is.Nil(instAction.RenderResult())
+
+	_, err := instAction.Run(chrt, nil)
+	is.NoError(err)
+
+	result := instAction.RenderResult()
+	if !is.NotNil(result) {
+		return
+	}
+	documents := map[string]RenderedDocument{}
+	for _, doc := range result.Documents {
+		documents[doc.Template] = doc
+	}
+	is.Len(documents, 4)
+
+	parent := documents["hello/templates/hello"]
+	is.Equal("hello: world", parent.Content)
+	is.False(parent.Hook)
+	is.Equal("parent", parent.Values["who"])
+
+	sub := documents["hello/charts/sub/templates/hello"]
+	is.Equal("hello: world", sub.Content)
+	is.Equal("sub", sub.Values["who"])
+
+	hook := documents["hello/charts/sub/templates/hooks"]
+	is.True(hook.Hook)
+	is.Equal("ConfigMap", hook.Kind)
+	is.Equal("test-cm", hook.Name)
+	is.Equal("sub", hook.Values["who"])
+}
+
 func TestInstallWithSystemLabels(t *testing.T) {
 	is := assert.New(t)
 	instAction := installAction(t)
@@ -931,3 +1084,77 @@ func TestInstallWithSystemLabels(t *testing.T) {
 
 	is.Equal(fmt.Errorf("user supplied labels contains system reserved label name. System labels: %+v", driver.GetSystemLabels()), err)
 }
+
+func TestInstallWithNonReleaseAnnotations(t *testing.T) {
+	is := assert.New(t)
+	instAction := installAction(t)
+	instAction.Annotations = map[string]string{
+		"example.com/team": "payments",
+	}
+	_, err := instAction.Run(buildChart(), nil)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	is.Equal(fmt.Errorf("user supplied annotations must be prefixed with %q", driver.ReleaseAnnotationPrefix), err)
+}
+
+// settingsClient records the parallelism and the field manager of the client
+// on each create and update.
+type settingsClient struct {
+	*kubefake.FailingKubeClient
+	parallelisms  []int
+	fieldManagers []string
+}
+
+func (c *settingsClient) record() {
+	c.parallelisms = append(c.parallelisms, c.Parallelism)
+	c.fieldManagers = append(c.fieldManagers, c.FieldManager)
+}
+
+func (c *settingsClient) Create(resources kube.ResourceList) (*kube.Result, error) {
+	c.record()
+	return c.FailingKubeClient.Create(resources)
+}
+
+func (c *settingsClient) Update(original, target kube.ResourceList, force bool) (*kube.Result, error) {
+	c.record()
+	return c.FailingKubeClient.Update(original, target, force)
+}
+
+func TestInstallRelease_FieldManager(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	instAction := installAction(t)
+	instAction.FieldManager = "helm-gitops"
+	client := &settingsClient{FailingKubeClient: instAction.cfg.KubeClient.(*kubefake.FailingKubeClient)}
+	instAction.cfg.KubeClient = client
+	_, err := instAction.Run(buildChart(), map[string]interface{}{})
+	req.NoError(err)
+
+	// The field manager is that of the install only.
+	is.Equal([]string{"helm-gitops"}, client.fieldManagers)
+	is.Equal("", client.FieldManager)
+}
+
+func TestInstallRelease_Parallelism(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	instAction := installAction(t)
+	instAction.Parallelism = 4
+	client := &settingsClient{FailingKubeClient: instAction.cfg.KubeClient.(*kubefake.FailingKubeClient)}
+	instAction.cfg.KubeClient = client
+	_, err := instAction.Run(buildChart(), map[string]interface{}{})
+	req.NoError(err)
+
+	// The parallelism is that of the install only.
+	is.Equal([]int{4}, client.parallelisms)
+	is.Equal(0, client.Parallelism)
+
+	instAction = installAction(t)
+	instAction.Parallelism = -1
+	_, err = instAction.Run(buildChart(), map[string]interface{}{})
+	is.EqualError(err, "invalid parallelism -1: must not be negative")
+}
@@ -24,6 +24,7 @@ import (
 
 	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
 	"helm.sh/helm/v4/pkg/lint"
+	"helm.sh/helm/v4/pkg/lint/rules"
 	"helm.sh/helm/v4/pkg/lint/support"
 )
 
@@ -36,7 +37,11 @@ type Lint struct {
 	WithSubcharts        bool
 	Quiet                bool
 	SkipSchemaValidation bool
+	SkipDeprecations     bool
+	SkipValuesReferences bool
 	KubeVersion          *chartutil.KubeVersion
+	// Policy lists the metadata required in charts, if set.
+	Policy *rules.Policy
 }
 
 // LintResult is the result of Lint
@@ -59,7 +64,7 @@ func (l *Lint) Run(paths []string, vals map[string]interface{}) *LintResult {
 	}
 	result := &LintResult{}
 	for _, path := range paths {
-		linter, err := lintChart(path, vals, l.Namespace, l.KubeVersion, l.SkipSchemaValidation)
+		linter, err := lintChart(path, vals, l.Namespace, l.KubeVersion, l.SkipSchemaValidation, l.SkipDeprecations, l.SkipValuesReferences, l.Policy)
 		if err != nil {
 			result.Errors = append(result.Errors, err)
 			continue
@@ -86,7 +91,7 @@ func HasWarningsOrErrors(result *LintResult) bool {
 	return len(result.Errors) > 0
 }
 
-func lintChart(path string, vals map[string]interface{}, namespace string, kubeVersion *chartutil.KubeVersion, skipSchemaValidation bool) (support.Linter, error) {
+func lintChart(path string, vals map[string]interface{}, namespace string, kubeVersion *chartutil.KubeVersion, skipSchemaValidation, skipDeprecations, skipValuesReferences bool, policy *rules.Policy) (support.Linter, error) {
 	var chartPath string
 	linter := support.Linter{}
 
@@ -131,5 +136,8 @@ func lintChart(path string, vals map[string]interface{}, namespace string, kubeV
 		namespace,
 		lint.WithKubeVersion(kubeVersion),
 		lint.WithSkipSchemaValidation(skipSchemaValidation),
+		lint.WithSkipDeprecations(skipDeprecations),
+		lint.WithSkipValuesReferences(skipValuesReferences),
+		lint.WithPolicy(policy),
 	), nil
 }
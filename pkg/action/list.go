@@ -159,7 +159,14 @@ func (l *List) Run() ([]*release.Release, error) {
 		}
 	}
 
-	results, err := l.cfg.Releases.List(func(rel *release.Release) bool {
+	// The selector is applied by the storage driver when it supports it,
+	// sparing the decoding of the releases it does not select.
+	selectorObj, err := labels.Parse(l.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := l.cfg.Releases.ListSelector(selectorObj, func(rel *release.Release) bool {
 		// Skip anything that doesn't match the filter.
 		if filter != nil && !filter.MatchString(rel.Name) {
 			return false
@@ -181,19 +188,19 @@ func (l *List) Run() ([]*release.Release, error) {
 	// is _only_ ListSuperseded, skip the latest release filter
 	if l.StateMask != ListSuperseded {
 		results = filterLatestReleases(results)
+		// The latest selected revision of a release is not its last one when
+		// the labels of the last revision do not match the selector any more.
+		if !selectorObj.Empty() {
+			if results, err = l.filterLastRevisions(results); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	// State mask application must occur after filtering to
 	// latest releases, otherwise outdated entries can be returned
 	results = l.filterStateMask(results)
 
-	// Skip anything that doesn't match the selector
-	selectorObj, err := labels.Parse(l.Selector)
-	if err != nil {
-		return nil, err
-	}
-	results = l.filterSelector(results, selectorObj)
-
 	// Unfortunately, we have to sort before truncating, which can incur substantial overhead
 	l.sort(results)
 
@@ -276,16 +283,23 @@ func (l *List) filterStateMask(releases []*release.Release) []*release.Release {
 	return desiredStateReleases
 }
 
-func (l *List) filterSelector(releases []*release.Release, selector labels.Selector) []*release.Release {
-	desiredStateReleases := make([]*release.Release, 0)
+// filterLastRevisions keeps the releases that are the last revision of their
+// release. The last versions of all the releases are read once from the
+// storage, without decoding the releases when the driver supports it.
+func (l *List) filterLastRevisions(releases []*release.Release) ([]*release.Release, error) {
+	lastVersions, err := l.cfg.Releases.LastVersions()
+	if err != nil {
+		return nil, err
+	}
 
+	lastRevisions := make([]*release.Release, 0, len(releases))
 	for _, rls := range releases {
-		if selector.Matches(labels.Set(rls.Labels)) {
-			desiredStateReleases = append(desiredStateReleases, rls)
+		if rls.Version >= lastVersions[path.Join(rls.Namespace, rls.Name)] {
+			lastRevisions = append(lastRevisions, rls)
 		}
 	}
 
-	return desiredStateReleases
+	return lastRevisions, nil
 }
 
 // SetStateMask calculates the state mask based on parameters.
@@ -359,6 +359,26 @@ func TestSelectorList(t *testing.T) {
 		assert.ElementsMatch(t, expectedFilteredList, res)
 	})
 
+	t.Run("should not select releases whose last revision does not match", func(t *testing.T) {
+		r1v2 := releaseStub()
+		r1v2.Name = "r1"
+		r1v2.Version = 2
+		r1v2.Labels = map[string]string{"key": "value2"}
+		if err := lister.cfg.Releases.Create(r1v2); err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if _, err := lister.cfg.Releases.Delete(r1v2.Name, r1v2.Version); err != nil {
+				t.Fatal(err)
+			}
+		}()
+
+		lister.Selector = "key==value1"
+		res, err := lister.Run()
+		assert.NoError(t, err)
+		assert.Empty(t, res)
+	})
+
 	t.Run("should select two releases with non matching label", func(t *testing.T) {
 		lister.Selector = "key!=value1"
 		res, _ := lister.Run()
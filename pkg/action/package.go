@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"syscall"
 
 	"github.com/Masterminds/semver/v3"
@@ -35,7 +36,15 @@ import (
 //
 // It provides the implementation of 'helm package'.
 type Package struct {
-	Sign             bool
+	Sign bool
+	// Signer selects how the package is signed: SignerPGP, the default, or
+	// SignerSigstore.
+	Signer string
+	// AppendSignature, when signing with PGP, adds a signature to the
+	// provenance file of the chart archive already at the destination
+	// instead of packaging the chart again, so that several keys can sign
+	// the same archive.
+	AppendSignature  bool
 	Key              string
 	Keyring          string
 	PassphraseFile   string
@@ -54,12 +63,26 @@ type Package struct {
 	KeyFile               string
 	CaFile                string
 	InsecureSkipTLSverify bool
+
+	// SigstoreIDToken, FulcioURL and RekorURL configure keyless signing
+	// when Signer is SignerSigstore.
+	SigstoreIDToken string
+	FulcioURL       string
+	RekorURL        string
 }
 
 const (
 	passPhraseFileStdin = "-"
 )
 
+const (
+	// SignerPGP signs packages with a PGP key, producing a .prov file.
+	SignerPGP = "pgp"
+	// SignerSigstore signs packages with sigstore keyless signing, producing
+	// a sigstore bundle.
+	SignerSigstore = "sigstore"
+)
+
 // NewPackage creates a new Package object with the given configuration.
 func NewPackage() *Package {
 	return &Package{}
@@ -103,13 +126,28 @@ func (p *Package) Run(path string, _ map[string]interface{}) (string, error) {
 		dest = p.Destination
 	}
 
+	if p.Sign && p.AppendSignature {
+		if p.Signer != "" && p.Signer != SignerPGP {
+			return "", errors.New("signatures can only be appended to PGP provenance files")
+		}
+		name := filepath.Join(dest, fmt.Sprintf("%s-%s.tgz", ch.Name(), ch.Metadata.Version))
+		return name, p.AppendClearsign(name)
+	}
+
 	name, err := chartutil.Save(ch, dest)
 	if err != nil {
 		return "", fmt.Errorf("failed to save: %w", err)
 	}
 
 	if p.Sign {
-		err = p.Clearsign(name)
+		switch p.Signer {
+		case "", SignerPGP:
+			err = p.Clearsign(name)
+		case SignerSigstore:
+			err = p.SigstoreSign(name)
+		default:
+			err = fmt.Errorf("unknown signer %q", p.Signer)
+		}
 	}
 
 	return name, err
@@ -125,30 +163,70 @@ func validateVersion(ver string) error {
 
 // Clearsign signs a chart
 func (p *Package) Clearsign(filename string) error {
+	signer, err := p.Signatory()
+	if err != nil {
+		return err
+	}
+
+	sig, err := signer.ClearSign(filename)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename+".prov", []byte(sig), 0644)
+}
+
+// AppendClearsign adds a signature to the provenance file of a chart signed
+// by other keys.
+func (p *Package) AppendClearsign(filename string) error {
+	signer, err := p.Signatory()
+	if err != nil {
+		return err
+	}
+
+	sig, err := signer.AppendSign(filename, filename+".prov")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename+".prov", []byte(sig), 0644)
+}
+
+// Signatory loads the PGP key named Key from Keyring and decrypts it with
+// the passphrase from PassphraseFile, or prompts for it.
+func (p *Package) Signatory() (*provenance.Signatory, error) {
 	// Load keyring
 	signer, err := provenance.NewFromKeyring(p.Keyring, p.Key)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	passphraseFetcher := promptUser
 	if p.PassphraseFile != "" {
 		passphraseFetcher, err = p.passphraseFileFetcher(p.PassphraseFile, os.Stdin)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
 	if err := signer.DecryptKey(passphraseFetcher); err != nil {
-		return err
+		return nil, err
 	}
+	return signer, nil
+}
 
-	sig, err := signer.ClearSign(filename)
+// SigstoreSign signs a chart with sigstore and writes the bundle next to it.
+func (p *Package) SigstoreSign(filename string) error {
+	sig, err := provenance.SignSigstore(filename, provenance.SigstoreSignOptions{
+		IDToken:   p.SigstoreIDToken,
+		FulcioURL: p.FulcioURL,
+		RekorURL:  p.RekorURL,
+	})
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(filename+".prov", []byte(sig), 0644)
+	return os.WriteFile(filename+provenance.SigstoreBundleExt, sig, 0644)
 }
 
 // promptUser implements provenance.PassphraseFetcher
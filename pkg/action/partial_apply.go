@@ -0,0 +1,211 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v4/pkg/gates"
+	releaseutil "helm.sh/helm/v4/pkg/release/util"
+)
+
+// FeatureGatePartialApply is the feature gate enabling the apply of only a
+// subset of the resources of a chart, with the ApplyOnly option of installs
+// and upgrades.
+const FeatureGatePartialApply = gates.Gate("HELM_EXPERIMENTAL_PARTIAL_APPLY")
+
+// partialApplyRecord starts the comment recorded at the top of the manifest
+// of a release partially applied, followed by the selectors applied.
+const partialApplyRecord = "# Partial apply of the resources matching: "
+
+// applySelector selects the resources applied by a partial apply, either
+// by the path of their template or by their kind and name.
+type applySelector struct {
+	raw string
+	// path is the glob matched against the template paths, relative to the
+	// chart, or the directory of the templates matched.
+	path string
+	// kind and name, a glob, select resources when path is empty.
+	kind, name string
+}
+
+// parseApplySelectors parses selectors of the form "Kind/name", or template
+// path globs such as "charts/database/templates/*.yaml", as told apart by
+// isTemplatePath. The FeatureGatePartialApply gate must be enabled.
+func parseApplySelectors(selectors []string) ([]applySelector, error) {
+	if !FeatureGatePartialApply.IsEnabled() {
+		return nil, FeatureGatePartialApply.Error()
+	}
+	parsed := make([]applySelector, 0, len(selectors))
+	for _, raw := range selectors {
+		if strings.TrimSpace(raw) == "" {
+			return nil, fmt.Errorf("invalid apply selector %q: expected Kind/name or a template path", raw)
+		}
+		s := applySelector{raw: raw}
+		kind, name, ok := strings.Cut(raw, "/")
+		if ok && !isTemplatePath(raw) {
+			if name == "" {
+				return nil, fmt.Errorf("invalid apply selector %q: expected Kind/name", raw)
+			}
+			s.kind, s.name = kind, name
+		} else {
+			s.path = path.Clean(raw)
+		}
+		pattern := s.path
+		if pattern == "" {
+			pattern = s.name
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid apply selector %q: %w", raw, err)
+		}
+		parsed = append(parsed, s)
+	}
+	return parsed, nil
+}
+
+// isTemplatePath returns true if the selector raw is a template path rather
+// than a "Kind/name". The templates of a chart are all in its templates/ and
+// charts/ directories, and neither kinds nor names contain glob patterns or
+// slashes, so the paths are told apart from the first of their elements or
+// from their depth. Paths are matched in their case, as the kinds are not.
+func isTemplatePath(raw string) bool {
+	first, rest, _ := strings.Cut(path.Clean(raw), "/")
+	switch {
+	case first == "templates", first == "charts":
+		return true
+	case first == "" || first == "." || first == "..":
+		return true
+	}
+	return strings.ContainsAny(first, "*?[\\") || strings.Contains(rest, "/")
+}
+
+// manifestDoc is a document of a release manifest.
+type manifestDoc struct {
+	content string
+	// source is the path of the template of the document, relative to the
+	// chart, if known.
+	source     string
+	kind, name string
+}
+
+func (d manifestDoc) key() string {
+	return strings.ToLower(d.kind) + "/" + d.name
+}
+
+func (s applySelector) matches(d manifestDoc) bool {
+	if s.path == "" {
+		matched, _ := path.Match(s.name, d.name)
+		return matched && strings.EqualFold(s.kind, d.kind)
+	}
+	if d.source == "" {
+		return false
+	}
+	// A path matching a directory selects the templates in it.
+	for dir := d.source; dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if matched, _ := path.Match(s.path, dir); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// splitManifestDocs returns the documents of a manifest describing a
+// resource, in order.
+func splitManifestDocs(manifest string) []manifestDoc {
+	split := releaseutil.SplitManifests(manifest)
+	keys := make([]string, 0, len(split))
+	for k := range split {
+		keys = append(keys, k)
+	}
+	sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+
+	var docs []manifestDoc
+	for _, k := range keys {
+		d := manifestDoc{content: split[k]}
+		var head releaseutil.SimpleHead
+		if err := yaml.Unmarshal([]byte(d.content), &head); err != nil || head.Kind == "" || head.Metadata == nil {
+			continue
+		}
+		d.kind, d.name = head.Kind, head.Metadata.Name
+		if line, _, _ := strings.Cut(d.content, "\n"); strings.HasPrefix(line, "# Source: ") {
+			// The source starts with the name of the chart.
+			if _, source, ok := strings.Cut(strings.TrimPrefix(line, "# Source: "), "/"); ok {
+				d.source = source
+			}
+		}
+		docs = append(docs, d)
+	}
+	return docs
+}
+
+// partialManifest returns the manifest of a release partially applied with
+// selectors: the documents of the rendered manifest selected, along with
+// those of the manifest of the previous release, if any, that are not
+// selected and are kept unchanged. Each selector must match a document.
+func partialManifest(previous, rendered string, selectors []applySelector) (string, error) {
+	selected := func(d manifestDoc) bool {
+		for _, s := range selectors {
+			if s.matches(d) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var docs []manifestDoc
+	applied := map[string]bool{}
+	renderedDocs := splitManifestDocs(rendered)
+	for _, d := range renderedDocs {
+		if selected(d) {
+			docs = append(docs, d)
+			applied[d.key()] = true
+		}
+	}
+	previousDocs := splitManifestDocs(previous)
+	for _, d := range previousDocs {
+		if !selected(d) && !applied[d.key()] {
+			docs = append(docs, d)
+		}
+	}
+
+	raw := make([]string, 0, len(selectors))
+	for _, s := range selectors {
+		raw = append(raw, s.raw)
+		found := false
+		for _, d := range append(renderedDocs, previousDocs...) {
+			if s.matches(d) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", fmt.Errorf("no resource matches the apply selector %q", s.raw)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(partialApplyRecord + strings.Join(raw, ", ") + "\n")
+	for _, d := range docs {
+		fmt.Fprintf(&b, "---\n%s\n", d.content)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,219 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+const partialApplyPrevious = `---
+# Source: app/templates/web.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+data:
+  revision: "1"
+---
+# Source: app/charts/db/templates/statefulset.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: db
+data:
+  revision: "1"
+---
+# Source: app/charts/db/templates/removed.yaml
+apiVersion: v1
+kind: Secret
+metadata:
+  name: db-removed
+`
+
+const partialApplyRendered = `---
+# Source: app/templates/web.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+data:
+  revision: "2"
+---
+# Source: app/templates/cache.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cache
+---
+# Source: app/charts/db/templates/statefulset.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: db
+data:
+  revision: "2"
+`
+
+func TestParseApplySelectors(t *testing.T) {
+	_, err := parseApplySelectors([]string{"charts/db"})
+	assert.EqualError(t, err, FeatureGatePartialApply.Error().Error())
+
+	t.Setenv(string(FeatureGatePartialApply), "1")
+	selectors, err := parseApplySelectors([]string{
+		"charts/db/", "./templates/*.yaml", "StatefulSet/db-*", "statefulset/db",
+		"Charts/sub/templates/x.yaml", "Templates/x", "*/x.yaml",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []applySelector{
+		{raw: "charts/db/", path: "charts/db"},
+		{raw: "./templates/*.yaml", path: "templates/*.yaml"},
+		{raw: "StatefulSet/db-*", kind: "StatefulSet", name: "db-*"},
+		{raw: "statefulset/db", kind: "statefulset", name: "db"},
+		// Paths are told apart by their depth as well, and are matched in
+		// their case.
+		{raw: "Charts/sub/templates/x.yaml", path: "Charts/sub/templates/x.yaml"},
+		{raw: "Templates/x", kind: "Templates", name: "x"},
+		{raw: "*/x.yaml", path: "*/x.yaml"},
+	}, selectors)
+
+	for _, raw := range []string{"", "Deployment/", "templates/[", "Deployment/web["} {
+		_, err := parseApplySelectors([]string{raw})
+		assert.ErrorContains(t, err, "invalid apply selector", raw)
+	}
+}
+
+func TestPartialManifest(t *testing.T) {
+	t.Setenv(string(FeatureGatePartialApply), "1")
+
+	tests := []struct {
+		name      string
+		selectors []string
+		previous  string
+		expect    []string
+	}{
+		{
+			name:      "install a directory of templates",
+			selectors: []string{"charts/db"},
+			expect:    []string{"db:2"},
+		},
+		{
+			name:      "install by kind and name",
+			selectors: []string{"ConfigMap/ca*", "ConfigMap/web"},
+			expect:    []string{"web:2", "cache:"},
+		},
+		{
+			name:      "upgrade a subchart",
+			selectors: []string{"charts/db/templates/*"},
+			previous:  partialApplyPrevious,
+			expect:    []string{"db:2", "web:1"},
+		},
+		{
+			name:      "upgrade a subchart by a directory glob",
+			selectors: []string{"charts/*"},
+			previous:  partialApplyPrevious,
+			expect:    []string{"db:2", "web:1"},
+		},
+		{
+			name:      "upgrade by template path",
+			selectors: []string{"templates/*.yaml"},
+			previous:  partialApplyPrevious,
+			expect:    []string{"web:2", "cache:", "db:1", "db-removed:"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selectors, err := parseApplySelectors(tt.selectors)
+			require.NoError(t, err)
+			manifest, err := partialManifest(tt.previous, partialApplyRendered, selectors)
+			require.NoError(t, err)
+
+			var got []string
+			for _, d := range splitManifestDocs(manifest) {
+				got = append(got, d.name+":"+revisionOf(d.content))
+			}
+			assert.Equal(t, tt.expect, got)
+			assert.Contains(t, manifest, partialApplyRecord)
+
+			// A partial manifest can be applied partially again.
+			_, err = partialManifest(manifest, partialApplyRendered, selectors)
+			assert.NoError(t, err)
+		})
+	}
+
+	selectors, err := parseApplySelectors([]string{"templates/web.yaml", "Deployment/db"})
+	require.NoError(t, err)
+	_, err = partialManifest(partialApplyPrevious, partialApplyRendered, selectors)
+	assert.EqualError(t, err, `no resource matches the apply selector "Deployment/db"`)
+}
+
+func revisionOf(content string) string {
+	switch {
+	case strings.Contains(content, `revision: "1"`):
+		return "1"
+	case strings.Contains(content, `revision: "2"`):
+		return "2"
+	}
+	return ""
+}
+
+func TestInstallReleaseApplyOnly(t *testing.T) {
+	t.Setenv(string(FeatureGatePartialApply), "1")
+
+	instAction := installAction(t)
+	instAction.ApplyOnly = []string{"templates/cache.yaml"}
+	ch := buildChartWithTemplates([]*chart.File{
+		{Name: "templates/web.yaml", Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web\n")},
+		{Name: "templates/cache.yaml", Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cache\n")},
+	})
+	rel, err := instAction.RunWithContext(context.Background(), ch, map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, release.StatusDeployed, rel.Info.Status)
+	assert.Equal(t, partialApplyRecord+"templates/cache.yaml\n---\n# Source: hello/templates/cache.yaml\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cache\n", rel.Manifest)
+}
+
+func TestUpgradeReleaseApplyOnly(t *testing.T) {
+	upAction := upgradeAction(t)
+	upAction.ApplyOnly = []string{"ConfigMap/db"}
+	rel := releaseStub()
+	rel.Manifest = partialApplyPrevious
+	require.NoError(t, upAction.cfg.Releases.Create(rel))
+
+	ch := buildChartWithTemplates([]*chart.File{
+		{Name: "templates/all.yaml", Data: []byte(partialApplyRendered)},
+	})
+	_, err := upAction.RunWithContext(context.Background(), rel.Name, ch, map[string]interface{}{})
+	assert.EqualError(t, err, FeatureGatePartialApply.Error().Error())
+
+	t.Setenv(string(FeatureGatePartialApply), "1")
+	res, err := upAction.RunWithContext(context.Background(), rel.Name, ch, map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, release.StatusDeployed, res.Info.Status)
+
+	var got []string
+	for _, d := range splitManifestDocs(res.Manifest) {
+		got = append(got, d.name+":"+revisionOf(d.content))
+	}
+	assert.Equal(t, []string{"db:2", "web:1", "db-removed:"}, got)
+}
@@ -0,0 +1,216 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"helm.sh/helm/v4/pkg/kube"
+)
+
+// MissingReference is a ConfigMap or Secret referenced by a workload of a
+// release that is neither created by the release nor present in the cluster.
+type MissingReference struct {
+	// Kind is ConfigMap or Secret.
+	Kind      string
+	Namespace string
+	Name      string
+	// Referrer is the kind and name of the referencing workload, such as
+	// "Deployment/web".
+	Referrer string
+}
+
+func (m MissingReference) String() string {
+	return fmt.Sprintf("%s references %s %q in namespace %q, which is neither created by the release nor present in the cluster", m.Referrer, m.Kind, m.Name, m.Namespace)
+}
+
+// preflight checks that the ConfigMaps and Secrets referenced by the
+// workloads of manifest, through environment variables or volumes, are
+// created by the manifest or exist in the cluster. Missing objects are
+// logged as warnings, or fail the check if strict is set. Optional
+// references are not checked.
+func (cfg *Configuration) preflight(manifest, namespace string, strict bool) error {
+	missing, err := cfg.missingReferences(manifest, namespace)
+	if err != nil {
+		return fmt.Errorf("preflight check failed: %w", err)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if strict {
+		msgs := make([]string, 0, len(missing))
+		for _, m := range missing {
+			msgs = append(msgs, m.String())
+		}
+		return fmt.Errorf("preflight check failed: %s", strings.Join(msgs, "; "))
+	}
+	for _, m := range missing {
+		slog.Warn("missing referenced object", "referrer", m.Referrer, "kind", m.Kind, "namespace", m.Namespace, "name", m.Name)
+	}
+	return nil
+}
+
+// missingReferences returns the ConfigMaps and Secrets referenced by the
+// workloads of manifest that are neither created by the manifest nor
+// present in the cluster, sorted by referrer and object.
+func (cfg *Configuration) missingReferences(manifest, namespace string) ([]MissingReference, error) {
+	objs, err := parseManifestObjects(manifest, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	created := map[string]bool{}
+	var refs []MissingReference
+	for _, obj := range objs {
+		if obj.GetAPIVersion() == "v1" && (obj.GetKind() == "ConfigMap" || obj.GetKind() == "Secret") {
+			created[obj.GetKind()+"/"+obj.GetNamespace()+"/"+obj.GetName()] = true
+			continue
+		}
+		spec := podSpec(obj)
+		if spec == nil {
+			continue
+		}
+		referrer := obj.GetKind() + "/" + obj.GetName()
+		for _, ref := range podSpecReferences(spec) {
+			ref.Namespace = obj.GetNamespace()
+			ref.Referrer = referrer
+			refs = append(refs, ref)
+		}
+	}
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	lookup, ok := cfg.KubeClient.(kube.InterfaceExists)
+	if !ok {
+		return nil, errors.New("the Kubernetes client does not support looking up objects")
+	}
+	seen := map[string]bool{}
+	var missing []MissingReference
+	for _, ref := range refs {
+		key := ref.Kind + "/" + ref.Namespace + "/" + ref.Name
+		if created[key] || seen[ref.Referrer+"/"+key] {
+			continue
+		}
+		seen[ref.Referrer+"/"+key] = true
+		exists, err := lookup.Exists(ref.Kind, ref.Namespace, ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			missing = append(missing, ref)
+		}
+	}
+	sort.SliceStable(missing, func(i, j int) bool {
+		a, b := missing[i], missing[j]
+		if a.Referrer != b.Referrer {
+			return a.Referrer < b.Referrer
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+	return missing, nil
+}
+
+// podSpec returns the pod spec of a Pod, a CronJob or a workload with a pod
+// template, or nil.
+func podSpec(obj *unstructured.Unstructured) map[string]interface{} {
+	var path []string
+	switch obj.GetKind() {
+	case "Pod":
+		path = []string{"spec"}
+	case "CronJob":
+		path = []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	default:
+		path = []string{"spec", "template", "spec"}
+	}
+	spec, found, err := unstructured.NestedMap(obj.Object, path...)
+	if !found || err != nil {
+		return nil
+	}
+	if _, ok := spec["containers"]; !ok {
+		return nil
+	}
+	return spec
+}
+
+// podSpecReferences returns the ConfigMaps and Secrets that the pod spec
+// requires through the environment of its containers and its volumes.
+func podSpecReferences(spec map[string]interface{}) []MissingReference {
+	var refs []MissingReference
+	add := func(kind string, src map[string]interface{}, nameField string) {
+		if src == nil {
+			return
+		}
+		if optional, _ := src["optional"].(bool); optional {
+			return
+		}
+		if name, _ := src[nameField].(string); name != "" {
+			refs = append(refs, MissingReference{Kind: kind, Name: name})
+		}
+	}
+
+	for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		for _, container := range mapsOf(spec[field]) {
+			for _, env := range mapsOf(container["env"]) {
+				valueFrom, _ := env["valueFrom"].(map[string]interface{})
+				add("ConfigMap", mapOf(valueFrom["configMapKeyRef"]), "name")
+				add("Secret", mapOf(valueFrom["secretKeyRef"]), "name")
+			}
+			for _, envFrom := range mapsOf(container["envFrom"]) {
+				add("ConfigMap", mapOf(envFrom["configMapRef"]), "name")
+				add("Secret", mapOf(envFrom["secretRef"]), "name")
+			}
+		}
+	}
+	for _, volume := range mapsOf(spec["volumes"]) {
+		add("ConfigMap", mapOf(volume["configMap"]), "name")
+		add("Secret", mapOf(volume["secret"]), "secretName")
+		projected := mapOf(volume["projected"])
+		for _, source := range mapsOf(projected["sources"]) {
+			add("ConfigMap", mapOf(source["configMap"]), "name")
+			add("Secret", mapOf(source["secret"]), "name")
+		}
+	}
+	return refs
+}
+
+// mapOf returns v if it is a map, or nil.
+func mapOf(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+// mapsOf returns the maps of the list v.
+func mapsOf(v interface{}) []map[string]interface{} {
+	list, _ := v.([]interface{})
+	var maps []map[string]interface{}
+	for _, item := range list {
+		if m := mapOf(item); m != nil {
+			maps = append(maps, m)
+		}
+	}
+	return maps
+}
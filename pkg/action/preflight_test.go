@@ -0,0 +1,139 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	kubefake "helm.sh/helm/v4/pkg/kube/fake"
+)
+
+const preflightManifest = `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: migrate
+          envFrom:
+            - secretRef:
+                name: migrations
+      containers:
+        - name: web
+          env:
+            - name: DB_PASSWORD
+              valueFrom:
+                secretKeyRef:
+                  name: db
+                  key: password
+            - name: FEATURE
+              valueFrom:
+                configMapKeyRef:
+                  name: features
+                  key: feature
+                  optional: true
+          envFrom:
+            - configMapRef:
+                name: web-config
+      volumes:
+        - name: tls
+          secret:
+            secretName: web-tls
+        - name: settings
+          configMap:
+            name: settings
+        - name: bundle
+          projected:
+            sources:
+              - configMap:
+                  name: ca-bundle
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: backup
+  namespace: jobs
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: backup
+              env:
+                - name: DB_PASSWORD
+                  valueFrom:
+                    secretKeyRef:
+                      name: db
+                      key: password
+`
+
+func TestMissingReferences(t *testing.T) {
+	config := actionConfigFixture(t)
+	config.KubeClient.(*kubefake.FailingKubeClient).ExistingObjects = []string{
+		"Secret/spaced/web-tls",
+		"ConfigMap/spaced/settings",
+	}
+
+	missing, err := config.missingReferences(preflightManifest, "spaced")
+	require.NoError(t, err)
+	assert.Equal(t, []MissingReference{
+		{Kind: "Secret", Namespace: "jobs", Name: "db", Referrer: "CronJob/backup"},
+		{Kind: "ConfigMap", Namespace: "spaced", Name: "ca-bundle", Referrer: "Deployment/web"},
+		{Kind: "Secret", Namespace: "spaced", Name: "db", Referrer: "Deployment/web"},
+		{Kind: "Secret", Namespace: "spaced", Name: "migrations", Referrer: "Deployment/web"},
+	}, missing)
+}
+
+func TestInstallRelease_Preflight(t *testing.T) {
+	chrt := buildChartWithTemplates([]*chart.File{
+		{Name: "templates/workloads.yaml", Data: []byte(preflightManifest)},
+	})
+
+	instAction := installAction(t)
+	instAction.Preflight = true
+	instAction.cfg.KubeClient.(*kubefake.FailingKubeClient).ExistingObjects = []string{}
+	_, err := instAction.Run(chrt, map[string]interface{}{})
+	require.NoError(t, err, "missing references are only warned about")
+
+	instAction = installAction(t)
+	instAction.PreflightStrict = true
+	instAction.cfg.KubeClient.(*kubefake.FailingKubeClient).ExistingObjects = []string{}
+	_, err = instAction.Run(chrt, map[string]interface{}{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `preflight check failed: CronJob/backup references Secret "db" in namespace "jobs", which is neither created by the release nor present in the cluster`)
+	_, err = instAction.cfg.Releases.Get(instAction.ReleaseName, 1)
+	assert.Error(t, err, "the release is not recorded")
+
+	// The references present in the cluster pass the strict check.
+	instAction = installAction(t)
+	instAction.PreflightStrict = true
+	_, err = instAction.Run(chrt, map[string]interface{}{})
+	require.NoError(t, err)
+}
@@ -44,6 +44,11 @@ type Pull struct {
 	UntarDir    string
 	DestDir     string
 	cfg         *Configuration
+
+	// Progress, if set, is called as the chart is downloaded over HTTP,
+	// with the number of bytes downloaded and the total size of the chart,
+	// or -1 if it is unknown.
+	Progress func(downloaded, total int64)
 }
 
 type PullOpt func(*Pull)
@@ -84,10 +89,17 @@ func (p *Pull) Run(chartRef string) (string, error) {
 			getter.WithTLSClientConfig(p.CertFile, p.KeyFile, p.CaFile),
 			getter.WithInsecureSkipVerifyTLS(p.InsecureSkipTLSverify),
 			getter.WithPlainHTTP(p.PlainHTTP),
+			getter.WithRetries(p.Retries),
+			getter.WithCacheDir(p.DownloadCache),
 		},
 		RegistryClient:   p.cfg.RegistryClient,
 		RepositoryConfig: p.Settings.RepositoryConfig,
 		RepositoryCache:  p.Settings.RepositoryCache,
+		SkipDigestVerify: p.SkipDigestVerify,
+	}
+
+	if p.Progress != nil {
+		c.Options = append(c.Options, getter.WithProgress(p.Progress))
 	}
 
 	if registry.IsOCI(chartRef) {
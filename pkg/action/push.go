@@ -38,6 +38,7 @@ type Push struct {
 	insecureSkipTLSverify bool
 	plainHTTP             bool
 	out                   io.Writer
+	mountFrom             []string
 }
 
 // PushOpt is a type of function that sets options for a push action.
@@ -73,6 +74,14 @@ func WithPlainHTTP(plainHTTP bool) PushOpt {
 	}
 }
 
+// WithMountFrom sets the repositories of the registry to mount the blobs of
+// the chart from rather than uploading them.
+func WithMountFrom(repositories ...string) PushOpt {
+	return func(p *Push) {
+		p.mountFrom = repositories
+	}
+}
+
 // WithPushOptWriter sets the registryOut field on the push configuration object.
 func WithPushOptWriter(out io.Writer) PushOpt {
 	return func(p *Push) {
@@ -100,6 +109,7 @@ func (p *Push) Run(chartRef string, remote string) (string, error) {
 			pusher.WithTLSClientConfig(p.certFile, p.keyFile, p.caFile),
 			pusher.WithInsecureSkipTLSVerify(p.insecureSkipTLSverify),
 			pusher.WithPlainHTTP(p.plainHTTP),
+			pusher.WithMountFrom(p.mountFrom...),
 		},
 	}
 
@@ -17,11 +17,16 @@ limitations under the License.
 package action
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"log/slog"
 	"slices"
 	"sort"
+	"sync"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
@@ -46,6 +51,23 @@ type ReleaseTesting struct {
 	Namespace string
 	Filters   map[string][]string
 	HideNotes bool
+	// LogOutput, if set, receives the logs of the test pods as they run.
+	// Each line is prefixed with the name of its pod. The logs are output
+	// once the tests are complete instead when the Kubernetes client cannot
+	// stream them.
+	LogOutput io.Writer
+	// Parallel is the number of tests run at the same time. Tests of
+	// different weights still run in the order of their weights. Unlike
+	// tests run one at a time, a failed test does not stop the others.
+	Parallel int
+
+	results TestResults
+}
+
+// TestResults counts the outcomes of the tests run by ReleaseTesting.
+type TestResults struct {
+	Passed int
+	Failed int
 }
 
 // NewReleaseTesting creates a new ReleaseTesting object with the given configuration.
@@ -66,6 +88,17 @@ func (r *ReleaseTesting) Run(name string) (*release.Release, error) {
 		return nil, fmt.Errorf("releaseTest: Release name is invalid: %s", name)
 	}
 
+	var streamer *logStreamer
+	batchLogs := false
+	if r.LogOutput != nil {
+		if client, ok := r.cfg.KubeClient.(kube.InterfaceLogStream); ok {
+			streamer = newLogStreamer(client, r.LogOutput)
+		} else {
+			slog.Debug("the Kubernetes client does not support streaming pod logs, the logs of the test pods are output once the tests are complete")
+			batchLogs = true
+		}
+	}
+
 	// finds the non-deleted release with the given name
 	rel, err := r.cfg.Releases.Last(name)
 	if err != nil {
@@ -96,7 +129,40 @@ func (r *ReleaseTesting) Run(name string) (*release.Release, error) {
 		rel.Hooks = executingHooks
 	}
 
-	if err := r.cfg.execHook(rel, release.HookTest, kube.StatusWatcherStrategy, r.Timeout); err != nil {
+	previousRuns := map[*release.Hook]release.HookExecution{}
+	for _, h := range rel.Hooks {
+		previousRuns[h] = h.LastRun
+	}
+	var created func(*release.Hook)
+	if streamer != nil {
+		created = func(h *release.Hook) { streamer.start(r.cfg, rel.Namespace, h) }
+	}
+	if r.Parallel > 1 {
+		err = r.execTestsParallel(rel, created)
+	} else {
+		err = r.cfg.execHookWithCallback(rel, release.HookTest, kube.StatusWatcherStrategy, r.Timeout, nil, created)
+	}
+	if streamer != nil {
+		streamer.wait()
+	}
+	if batchLogs {
+		if errLogs := r.GetPodLogs(r.LogOutput, rel); errLogs != nil {
+			err = errors.Join(err, errLogs)
+		}
+	}
+	r.results = TestResults{}
+	for _, h := range rel.Hooks {
+		if !slices.Contains(h.Events, release.HookTest) || h.LastRun == previousRuns[h] {
+			continue
+		}
+		if h.LastRun.Phase == release.HookPhaseSucceeded {
+			r.results.Passed++
+		} else {
+			r.results.Failed++
+		}
+	}
+
+	if err != nil {
 		rel.Hooks = append(skippedHooks, rel.Hooks...)
 		r.cfg.Releases.Update(rel)
 		return rel, err
@@ -106,6 +172,89 @@ func (r *ReleaseTesting) Run(name string) (*release.Release, error) {
 	return rel, r.cfg.Releases.Update(rel)
 }
 
+// Results returns the number of tests that passed and failed in the last
+// call to Run.
+func (r *ReleaseTesting) Results() TestResults {
+	return r.results
+}
+
+// execTestsParallel runs the test hooks of rel, up to r.Parallel at a time.
+// The hooks of each weight run once those of lower weights are complete.
+func (r *ReleaseTesting) execTestsParallel(rel *release.Release, created func(*release.Hook)) error {
+	var tests []*release.Hook
+	for _, h := range rel.Hooks {
+		if slices.Contains(h.Events, release.HookTest) {
+			tests = append(tests, h)
+		}
+	}
+	sort.Stable(hookByWeight(tests))
+
+	// mu guards the release, which is recorded as the tests progress, and
+	// the output of the logs of the test pods, for the logs of each pod to
+	// be output as a whole.
+	var mu sync.Mutex
+	var errs []error
+	sem := make(chan struct{}, r.Parallel)
+	for start := 0; start < len(tests); {
+		end := start + 1
+		for end < len(tests) && tests[end].Weight == tests[start].Weight {
+			end++
+		}
+
+		var wg sync.WaitGroup
+		for _, h := range tests[start:end] {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				if err := r.execTest(rel, h, &mu, created); err != nil {
+					mu.Lock()
+					errs = append(errs, fmt.Errorf("test %s failed: %w", h.Name, err))
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		start = end
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%d of %d tests failed: %w", len(errs), len(tests), errors.Join(errs...))
+	}
+	return nil
+}
+
+// execTest runs the test hook h, as execHookWithCallback does, and applies
+// its delete and output log policies once it is complete.
+func (r *ReleaseTesting) execTest(rel *release.Release, h *release.Hook, mu *sync.Mutex, created func(*release.Hook)) error {
+	cfg := r.cfg
+	watched, err := cfg.runHook(rel, h, release.HookTest, kube.StatusWatcherStrategy, r.Timeout, mu, created)
+	if err != nil {
+		if !watched {
+			return err
+		}
+		mu.Lock()
+		errOutputting := cfg.outputLogsByPolicy(h, rel.Namespace, release.HookOutputOnFailed)
+		mu.Unlock()
+		if errOutputting != nil {
+			log.Printf("error outputting logs for hook failure: %v", errOutputting)
+		}
+		if errDeleting := cfg.deleteHookByPolicy(h, release.HookFailed, kube.StatusWatcherStrategy, r.Timeout); errDeleting != nil {
+			log.Printf("error deleting the hook resource on hook failure: %v", errDeleting)
+		}
+		return err
+	}
+
+	mu.Lock()
+	errOutputting := cfg.outputLogsByPolicy(h, rel.Namespace, release.HookOutputOnSucceeded)
+	mu.Unlock()
+	if errOutputting != nil {
+		log.Printf("error outputting logs for hook failure: %v", errOutputting)
+	}
+	return cfg.deleteHookByPolicy(h, release.HookSucceeded, kube.StatusWatcherStrategy, r.Timeout)
+}
+
 // GetPodLogs will write the logs for all test pods in the given release into
 // the given writer. These can be immediately output to the user or captured for
 // other uses
@@ -143,3 +292,108 @@ func (r *ReleaseTesting) GetPodLogs(out io.Writer, rel *release.Release) error {
 	}
 	return nil
 }
+
+// logStreamGracePeriod is how long the logs of the test pods are still
+// streamed once the tests are complete, for their last lines to be received.
+const logStreamGracePeriod = 5 * time.Second
+
+// logStreamer streams the logs of test pods to an output as they run.
+type logStreamer struct {
+	client kube.InterfaceLogStream
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// mu serializes the lines written to out.
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func newLogStreamer(client kube.InterfaceLogStream, out io.Writer) *logStreamer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &logStreamer{client: client, ctx: ctx, cancel: cancel, out: out}
+}
+
+// start streams the logs of the pod of the test hook h, if it is one.
+func (s *logStreamer) start(cfg *Configuration, releaseNamespace string, h *release.Hook) {
+	if h.Kind != "Pod" {
+		return
+	}
+	namespace, err := cfg.deriveNamespace(h, releaseNamespace)
+	if err != nil {
+		slog.Warn("unable to stream the logs of test pod", "pod", h.Name, slog.Any("error", err))
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		// The writers are created concurrently for the containers of the pod.
+		var writersMu sync.Mutex
+		var writers []*prefixWriter
+		writerFunc := func(_, pod, _ string) io.Writer {
+			w := &prefixWriter{streamer: s, prefix: fmt.Sprintf("[%s] ", pod)}
+			writersMu.Lock()
+			writers = append(writers, w)
+			writersMu.Unlock()
+			return w
+		}
+		err := s.client.StreamPodLogs(s.ctx, namespace, h.Name, writerFunc)
+		for _, w := range writers {
+			w.flush()
+		}
+		if err != nil && s.ctx.Err() == nil {
+			slog.Warn("unable to stream the logs of test pod", "pod", h.Name, slog.Any("error", err))
+		}
+	}()
+}
+
+// wait waits for the streams to end, for up to logStreamGracePeriod, and
+// stops those still running.
+func (s *logStreamer) wait() {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(logStreamGracePeriod):
+	}
+	s.cancel()
+	s.wg.Wait()
+}
+
+// prefixWriter writes the complete lines written to it to the output of
+// its streamer, prefixed with prefix.
+type prefixWriter struct {
+	streamer *logStreamer
+	prefix   string
+	buf      []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		w.writeLine(w.buf[:i+1])
+		w.buf = w.buf[i+1:]
+	}
+}
+
+// flush writes the last line, if it is not terminated by a newline.
+func (w *prefixWriter) flush() {
+	if len(w.buf) > 0 {
+		w.writeLine(append(w.buf, '\n'))
+		w.buf = nil
+	}
+}
+
+func (w *prefixWriter) writeLine(line []byte) {
+	w.streamer.mu.Lock()
+	defer w.streamer.mu.Unlock()
+	fmt.Fprintf(w.streamer.out, "%s%s", w.prefix, line)
+}
@@ -0,0 +1,230 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"helm.sh/helm/v4/pkg/kube"
+	kubefake "helm.sh/helm/v4/pkg/kube/fake"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+// testHookClient builds a resource named after the pod of each hook, and fails the tests whose name starts with "fail".
+type testHookClient struct {
+	*kubefake.FailingKubeClient
+
+	mu      sync.Mutex
+	running int
+	// maxRunning is the highest number of tests that ran at the same time.
+	maxRunning int
+	// order lists the tests in the order they started.
+	order []string
+}
+
+func (c *testHookClient) Build(r io.Reader, _ bool) (kube.ResourceList, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	_, name, _ := strings.Cut(string(data), "name: ")
+	return kube.ResourceList{{Name: strings.TrimSpace(name)}}, nil
+}
+
+func (c *testHookClient) GetWaiter(kube.WaitStrategy) (kube.Waiter, error) {
+	waiter, err := c.FailingKubeClient.GetWaiter(kube.StatusWatcherStrategy)
+	return &testHookWaiter{Waiter: waiter, client: c}, err
+}
+
+func (c *testHookClient) StreamPodLogs(_ context.Context, namespace, name string, writerFunc func(namespace, pod, container string) io.Writer) error {
+	w := writerFunc(namespace, name, "main")
+	fmt.Fprintf(w, "%s started\n%s ", name, name)
+	fmt.Fprint(w, "done")
+	return nil
+}
+
+type testHookWaiter struct {
+	kube.Waiter
+	client *testHookClient
+}
+
+func (w *testHookWaiter) WatchUntilReady(resources kube.ResourceList, _ time.Duration) error {
+	name := resources[0].Name
+	c := w.client
+	c.mu.Lock()
+	c.running++
+	c.maxRunning = max(c.maxRunning, c.running)
+	c.order = append(c.order, name)
+	c.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	c.mu.Lock()
+	c.running--
+	c.mu.Unlock()
+	if strings.HasPrefix(name, "fail") {
+		return errors.New("pod failed")
+	}
+	return nil
+}
+
+func testHooksRelease(weights map[string]int) *release.Release {
+	rel := releaseStub()
+	rel.Hooks = nil
+	for name, weight := range weights {
+		rel.Hooks = append(rel.Hooks, &release.Hook{
+			Name:     name,
+			Kind:     "Pod",
+			Path:     name,
+			Manifest: fmt.Sprintf("kind: Pod\nmetadata:\n  name: %s\n", name),
+			Weight:   weight,
+			Events:   []release.HookEvent{release.HookTest},
+		})
+	}
+	return rel
+}
+
+func TestReleaseTestingParallel(t *testing.T) {
+	config := actionConfigFixture(t)
+	client := &testHookClient{FailingKubeClient: config.KubeClient.(*kubefake.FailingKubeClient)}
+	config.KubeClient = client
+
+	weights := map[string]int{"last": 1}
+	for i := 0; i < 6; i++ {
+		weights[fmt.Sprintf("test-%d", i)] = 0
+	}
+	weights["fail-a"] = 0
+	weights["fail-b"] = 0
+	rel := testHooksRelease(weights)
+	if err := config.Releases.Create(rel); err != nil {
+		t.Fatal(err)
+	}
+
+	rt := NewReleaseTesting(config)
+	rt.Parallel = 3
+	res, err := rt.Run(rel.Name)
+	if err == nil {
+		t.Fatal("expected the failed tests to be reported")
+	}
+	for _, expect := range []string{"2 of 9 tests failed", "test fail-a failed: pod failed", "test fail-b failed: pod failed"} {
+		if !strings.Contains(err.Error(), expect) {
+			t.Errorf("expected the error to contain %q, got %q", expect, err)
+		}
+	}
+
+	// The failures do not stop the other tests, including those of higher
+	// weights.
+	if results := rt.Results(); results != (TestResults{Passed: 7, Failed: 2}) {
+		t.Errorf("unexpected results: %+v", results)
+	}
+	for _, h := range res.Hooks {
+		want := release.HookPhaseSucceeded
+		if strings.HasPrefix(h.Name, "fail") {
+			want = release.HookPhaseFailed
+		}
+		if h.LastRun.Phase != want {
+			t.Errorf("expected test %s to be %s, got %s", h.Name, want, h.LastRun.Phase)
+		}
+	}
+	if client.maxRunning < 2 || client.maxRunning > 3 {
+		t.Errorf("expected up to 3 tests to run at the same time, got %d", client.maxRunning)
+	}
+	if last := client.order[len(client.order)-1]; last != "last" {
+		t.Errorf("expected the test of the highest weight to run last, got %v", client.order)
+	}
+}
+
+func TestReleaseTestingResults(t *testing.T) {
+	config := actionConfigFixture(t)
+	client := &testHookClient{FailingKubeClient: config.KubeClient.(*kubefake.FailingKubeClient)}
+	config.KubeClient = client
+
+	rel := testHooksRelease(map[string]int{"first": 0, "fail": 1, "never": 2})
+	if err := config.Releases.Create(rel); err != nil {
+		t.Fatal(err)
+	}
+
+	// Tests run one at a time stop at the first failure.
+	rt := NewReleaseTesting(config)
+	if _, err := rt.Run(rel.Name); err == nil {
+		t.Fatal("expected the failed test to be reported")
+	}
+	if results := rt.Results(); results != (TestResults{Passed: 1, Failed: 1}) {
+		t.Errorf("unexpected results: %+v", results)
+	}
+	if client.maxRunning != 1 {
+		t.Errorf("expected the tests to run one at a time, got %d", client.maxRunning)
+	}
+}
+
+func TestReleaseTestingStreamLogs(t *testing.T) {
+	config := actionConfigFixture(t)
+	client := &testHookClient{FailingKubeClient: config.KubeClient.(*kubefake.FailingKubeClient)}
+	config.KubeClient = client
+
+	rel := testHooksRelease(map[string]int{"one": 0, "two": 0, "fail": 1})
+	if err := config.Releases.Create(rel); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	rt := NewReleaseTesting(config)
+	rt.Parallel = 2
+	rt.LogOutput = &out
+	if _, err := rt.Run(rel.Name); err == nil {
+		t.Fatal("expected the failed test to be reported")
+	}
+
+	// Every line is labeled with its pod, including the last one without a
+	// newline, and the lines of each pod are in order.
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("expected 6 lines of logs, got %q", out.String())
+	}
+	for _, name := range []string{"one", "two", "fail"} {
+		var got []string
+		for _, line := range lines {
+			if strings.HasPrefix(line, "["+name+"] ") {
+				got = append(got, line)
+			}
+		}
+		want := []string{"[" + name + "] " + name + " started", "[" + name + "] " + name + " done"}
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("unexpected logs for %s: %q", name, got)
+		}
+	}
+
+	// The logs are retrieved once the tests are complete with clients that
+	// cannot stream them.
+	config.KubeClient = client.FailingKubeClient
+	kubeconfig := filepath.Join(t.TempDir(), "missing")
+	config.RESTClientGetter = &genericclioptions.ConfigFlags{KubeConfig: &kubeconfig}
+	if _, err := rt.Run(rel.Name); err == nil || !strings.Contains(err.Error(), "unable to get kubernetes client to fetch pod logs") {
+		t.Errorf("expected the logs to be retrieved after the tests, got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"strings"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	releaseutil "helm.sh/helm/v4/pkg/release/util"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+// hiddenSecret replaces the content of the Secrets hidden from the output.
+const hiddenSecret = "# HIDDEN: The Secret output has been suppressed"
+
+// RenderResult maps the documents rendered for a release to the templates
+// they come from, so that tools do not have to parse the "# Source" comments
+// of the manifest.
+//
+// The documents are those rendered by the chart, before any post-renderer
+// runs.
+type RenderResult struct {
+	// Documents are the documents of the manifest, in its order, followed by
+	// those of the hooks.
+	Documents []RenderedDocument
+}
+
+// RenderedDocument is a rendered document and its source.
+type RenderedDocument struct {
+	// Template is the path of the template the document comes from, such as
+	// "mychart/charts/mysubchart/templates/deployment.yaml", or the file name
+	// of a CRD.
+	Template string
+	// Kind is the kind of the resource of the document.
+	Kind string
+	// Name is the name of the resource of the document.
+	Name string
+	// Content is the rendered document.
+	Content string
+	// Hook is set for the documents of hooks, which are not in the manifest.
+	Hook bool
+	// Values are the values the template was rendered with: the values of
+	// the chart or subchart holding the template. They are nil for CRDs,
+	// which are not templates.
+	Values chartutil.Values
+}
+
+// fill adds the documents of the manifests and hooks rendered for ch with the
+// render values to the result.
+func (r *RenderResult) fill(ch *chart.Chart, values chartutil.Values, manifests []releaseutil.Manifest, hooks []*release.Hook, includeCrds, hideSecret bool) {
+	if includeCrds {
+		for _, crd := range ch.CRDObjects() {
+			r.Documents = append(r.Documents, RenderedDocument{
+				Template: crd.Filename,
+				Kind:     "CustomResourceDefinition",
+				Content:  string(crd.File.Data),
+			})
+		}
+	}
+	for _, m := range manifests {
+		doc := RenderedDocument{
+			Template: m.Name,
+			Content:  m.Content,
+			Values:   templateValues(values, m.Name),
+		}
+		if m.Head != nil {
+			doc.Kind = m.Head.Kind
+			if hideSecret && m.Head.Kind == "Secret" && m.Head.Version == "v1" {
+				doc.Content = hiddenSecret
+			}
+			if m.Head.Metadata != nil {
+				doc.Name = m.Head.Metadata.Name
+			}
+		}
+		r.Documents = append(r.Documents, doc)
+	}
+	for _, h := range hooks {
+		r.Documents = append(r.Documents, RenderedDocument{
+			Template: h.Path,
+			Kind:     h.Kind,
+			Name:     h.Name,
+			Content:  h.Manifest,
+			Hook:     true,
+			Values:   templateValues(values, h.Path),
+		})
+	}
+}
+
+// templateValues returns the values the template at path was rendered with,
+// following the "charts" directories of its path down to its chart, from the
+// render values of the top level chart.
+func templateValues(values chartutil.Values, path string) chartutil.Values {
+	scope, ok := asTable(values["Values"])
+	if !ok {
+		return nil
+	}
+	// The path starts with the name of the top level chart.
+	parts := strings.Split(path, "/")
+	for i := 1; i+1 < len(parts) && parts[i] == "charts"; i += 2 {
+		if scope, ok = asTable(scope[parts[i+1]]); !ok {
+			return nil
+		}
+	}
+	return scope
+}
+
+func asTable(v interface{}) (chartutil.Values, bool) {
+	switch v := v.(type) {
+	case chartutil.Values:
+		return v, true
+	case map[string]interface{}:
+		return v, true
+	}
+	return nil, false
+}
@@ -19,8 +19,12 @@ package action
 import (
 	"strings"
 
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+
 	"helm.sh/helm/v4/pkg/kube"
 	releaseutil "helm.sh/helm/v4/pkg/release/util"
+	release "helm.sh/helm/v4/pkg/release/v1"
 )
 
 func filterManifestsToKeep(manifests []releaseutil.Manifest) (keep, remaining []releaseutil.Manifest) {
@@ -44,3 +48,70 @@ func filterManifestsToKeep(manifests []releaseutil.Manifest) (keep, remaining []
 	}
 	return keep, remaining
 }
+
+// filterManifestsByKeepOptions splits manifests into those matching either
+// the label selector or one of the kinds, and the rest. A nil selector
+// matches nothing.
+func filterManifestsByKeepOptions(manifests []releaseutil.Manifest, selector labels.Selector, kinds []string) (keep, remaining []releaseutil.Manifest, err error) {
+	if selector == nil && len(kinds) == 0 {
+		return nil, manifests, nil
+	}
+	for _, m := range manifests {
+		if m.Head != nil && containsFold(kinds, m.Head.Kind) {
+			keep = append(keep, m)
+			continue
+		}
+		if selector != nil {
+			var obj struct {
+				Metadata struct {
+					Labels map[string]string `json:"labels"`
+				} `json:"metadata"`
+			}
+			if err := yaml.Unmarshal([]byte(m.Content), &obj); err != nil {
+				return nil, nil, err
+			}
+			if selector.Matches(labels.Set(obj.Metadata.Labels)) {
+				keep = append(keep, m)
+				continue
+			}
+		}
+		remaining = append(remaining, m)
+	}
+	return keep, remaining, nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(strings.TrimSpace(v), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// manifestReferences identifies the resources of manifests. Resources without
+// a namespace are assumed to be in namespace.
+func manifestReferences(manifests []releaseutil.Manifest, namespace string) []release.ResourceReference {
+	var refs []release.ResourceReference
+	for _, m := range manifests {
+		if m.Head == nil || m.Head.Metadata == nil {
+			continue
+		}
+		ref := release.ResourceReference{
+			APIVersion: m.Head.Version,
+			Kind:       m.Head.Kind,
+			Namespace:  namespace,
+			Name:       m.Head.Metadata.Name,
+		}
+		var obj struct {
+			Metadata struct {
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+		}
+		if err := yaml.Unmarshal([]byte(m.Content), &obj); err == nil && obj.Metadata.Namespace != "" {
+			ref.Namespace = obj.Metadata.Namespace
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
@@ -44,13 +44,23 @@ type Rollback struct {
 	Recreate      bool // will (if true) recreate pods after a rollback.
 	Force         bool // will (if true) force resource upgrade through uninstall/recreate if needed
 	CleanupOnFail bool
-	MaxHistory    int // MaxHistory limits the maximum number of revisions saved per release
+	MaxHistory    int // MaxHistory limits the maximum number of revisions saved per release, defaulting to that of the configuration
+	// FieldManager is the name of the field manager recorded for the changes
+	// made to resources. The default of the Kubernetes client is used if empty.
+	FieldManager string
+	// Parallelism is the maximum number of resources of the same kind applied
+	// at once. The default of the Kubernetes client is used if zero.
+	Parallelism int
+	// ForceRecreate deletes and creates again the resources whose update is
+	// rejected because it changes an immutable field, as for Upgrade.
+	ForceRecreate bool
 }
 
 // NewRollback creates a new Rollback object with the given configuration.
 func NewRollback(cfg *Configuration) *Rollback {
 	return &Rollback{
-		cfg: cfg,
+		cfg:        cfg,
+		MaxHistory: cfg.MaxHistory,
 	}
 }
 
@@ -59,6 +69,21 @@ func (r *Rollback) Run(name string) error {
 	if err := r.cfg.KubeClient.IsReachable(); err != nil {
 		return err
 	}
+	resetFieldManager, err := r.cfg.setFieldManager(r.FieldManager)
+	if err != nil {
+		return err
+	}
+	defer resetFieldManager()
+	resetParallelism, err := r.cfg.setParallelism(r.Parallelism)
+	if err != nil {
+		return err
+	}
+	defer resetParallelism()
+	resetForceRecreate, err := r.cfg.setForceRecreate(r.ForceRecreate)
+	if err != nil {
+		return err
+	}
+	defer resetForceRecreate()
 
 	r.cfg.Releases.MaxHistory = r.MaxHistory
 
@@ -85,10 +110,23 @@ func (r *Rollback) Run(name string) error {
 		if err := r.cfg.Releases.Update(targetRelease); err != nil {
 			return err
 		}
+		r.cfg.pruneHistory(name)
 	}
 	return nil
 }
 
+// Diff describes what rolling back the given release would change, without
+// performing the rollback. It compares the manifest of the current release
+// with that of the target revision: resources added since the target revision
+// would be deleted, and resources removed since would be created again.
+func (r *Rollback) Diff(name string) ([]release.ResourceDiff, error) {
+	currentRelease, targetRelease, err := r.prepareRollback(name)
+	if err != nil {
+		return nil, err
+	}
+	return manifestDiff(currentRelease.Manifest, targetRelease.Manifest, currentRelease.Namespace)
+}
+
 // prepareRollback finds the previous release and prepares a new release object with
 // the previous release's configuration
 func (r *Rollback) prepareRollback(name string) (*release.Release, *release.Release, error) {
@@ -150,10 +188,11 @@ func (r *Rollback) prepareRollback(name string) (*release.Release, *release.Rele
 			// message here, and only override it later if we experience failure.
 			Description: fmt.Sprintf("Rollback to %d", previousVersion),
 		},
-		Version:  currentRelease.Version + 1,
-		Labels:   previousRelease.Labels,
-		Manifest: previousRelease.Manifest,
-		Hooks:    previousRelease.Hooks,
+		Version:     currentRelease.Version + 1,
+		Labels:      previousRelease.Labels,
+		Annotations: previousRelease.Annotations,
+		Manifest:    previousRelease.Manifest,
+		Hooks:       previousRelease.Hooks,
 	}
 
 	return currentRelease, targetRelease, nil
@@ -176,7 +215,7 @@ func (r *Rollback) performRollback(currentRelease, targetRelease *release.Releas
 
 	// pre-rollback hooks
 	if !r.DisableHooks {
-		if err := r.cfg.execHook(targetRelease, release.HookPreRollback, r.WaitStrategy, r.Timeout); err != nil {
+		if err := r.cfg.execHook(targetRelease, release.HookPreRollback, r.WaitStrategy, r.Timeout, nil); err != nil {
 			return targetRelease, err
 		}
 	} else {
@@ -242,7 +281,7 @@ func (r *Rollback) performRollback(currentRelease, targetRelease *release.Releas
 
 	// post-rollback hooks
 	if !r.DisableHooks {
-		if err := r.cfg.execHook(targetRelease, release.HookPostRollback, r.WaitStrategy, r.Timeout); err != nil {
+		if err := r.cfg.execHook(targetRelease, release.HookPostRollback, r.WaitStrategy, r.Timeout, nil); err != nil {
 			return targetRelease, err
 		}
 	}
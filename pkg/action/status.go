@@ -35,6 +35,10 @@ type Status struct {
 	// ShowResourcesTable is used with ShowResources. When true this will cause
 	// the resulting objects to be retrieved as a kind=table.
 	ShowResourcesTable bool
+
+	// ShowHealth computes the health of the resources of the release, with
+	// the readiness checks of the wait for them, into Info.Health.
+	ShowHealth bool
 }
 
 // NewStatus creates a new Status object with the given configuration.
@@ -76,7 +80,54 @@ func (s *Status) Run(name string) (*release.Release, error) {
 
 		rel.Info.Resources = resp
 
+		if s.ShowHealth {
+			if rel.Info.Health, err = s.health(rel); err != nil {
+				return nil, err
+			}
+		}
+
 		return rel, nil
 	}
 	return nil, errors.New("unable to get kubeClient with interface InterfaceResources")
 }
+
+// health returns the health of the resources of rel.
+func (s *Status) health(rel *release.Release) (*release.Health, error) {
+	kubeClient, ok := s.cfg.KubeClient.(kube.InterfaceHealth)
+	if !ok {
+		return nil, errors.New("the Kubernetes client does not support checking the health of resources")
+	}
+	resources, err := s.cfg.KubeClient.Build(bytes.NewBufferString(rel.Manifest), false)
+	if err != nil {
+		return nil, err
+	}
+	results, err := kubeClient.Health(resources)
+	if err != nil {
+		return nil, err
+	}
+
+	health := &release.Health{}
+	for _, r := range results {
+		if r.Ready {
+			health.Ready++
+		} else {
+			health.NotReady++
+			if r.Kind == "PersistentVolumeClaim" {
+				health.PendingVolumeClaims++
+			}
+		}
+		health.FailingPods += len(r.FailingPods)
+		if !r.Ready || len(r.FailingPods) > 0 {
+			health.Unhealthy = append(health.Unhealthy, release.UnhealthyResource{
+				Kind:        r.Kind,
+				Namespace:   r.Namespace,
+				Name:        r.Name,
+				Ready:       r.Ready,
+				Missing:     r.Missing,
+				FailingPods: r.FailingPods,
+			})
+		}
+	}
+	health.Healthy = health.NotReady == 0 && health.FailingPods == 0
+	return health, nil
+}
@@ -0,0 +1,76 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm.sh/helm/v4/pkg/kube"
+	kubefake "helm.sh/helm/v4/pkg/kube/fake"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+func TestStatusHealth(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	config := actionConfigFixture(t)
+	rel := releaseStub()
+	req.NoError(config.Releases.Create(rel))
+
+	failer := config.KubeClient.(*kubefake.FailingKubeClient)
+	failer.HealthResults = []kube.ResourceHealth{
+		{Kind: "Deployment", Namespace: "default", Name: "web", Ready: true},
+		{Kind: "Deployment", Namespace: "default", Name: "worker", FailingPods: []string{"worker-1", "worker-2"}},
+		{Kind: "PersistentVolumeClaim", Namespace: "default", Name: "data"},
+		{Kind: "Service", Namespace: "default", Name: "web", Missing: true},
+	}
+
+	status := NewStatus(config)
+	res, err := status.Run(rel.Name)
+	req.NoError(err)
+	is.Nil(res.Info.Health, "the health is only computed on demand")
+
+	status.ShowHealth = true
+	res, err = status.Run(rel.Name)
+	req.NoError(err)
+	is.Equal(&release.Health{
+		Ready:               1,
+		NotReady:            3,
+		PendingVolumeClaims: 1,
+		FailingPods:         2,
+		Unhealthy: []release.UnhealthyResource{
+			{Kind: "Deployment", Namespace: "default", Name: "worker", FailingPods: []string{"worker-1", "worker-2"}},
+			{Kind: "PersistentVolumeClaim", Namespace: "default", Name: "data"},
+			{Kind: "Service", Namespace: "default", Name: "web", Missing: true},
+		},
+	}, res.Info.Health)
+
+	failer.HealthResults = failer.HealthResults[:1]
+	res, err = status.Run(rel.Name)
+	req.NoError(err)
+	is.True(res.Info.Health.Healthy)
+	is.Empty(res.Info.Health.Unhealthy)
+
+	failer.HealthError = errors.New("forbidden")
+	_, err = status.Run(rel.Name)
+	is.EqualError(err, "forbidden")
+}
@@ -23,6 +23,7 @@ import (
 	"time"
 
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 
 	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
 	"helm.sh/helm/v4/pkg/kube"
@@ -45,6 +46,16 @@ type Uninstall struct {
 	DeletionPropagation string
 	Timeout             time.Duration
 	Description         string
+	// KeepSelector is a label selector. Resources of the release matching it
+	// are not deleted, whatever their resource policy.
+	KeepSelector string
+	// KeepKinds lists resource kinds, such as "PersistentVolumeClaim", that
+	// are not deleted, whatever their resource policy. Kinds are matched
+	// case-insensitively.
+	KeepKinds []string
+	// WaitForTiers waits for each tier of resources, such as the custom
+	// resources or the workloads, to be deleted before deleting the next one.
+	WaitForTiers bool
 }
 
 // NewUninstall creates a new Uninstall object with the given configuration.
@@ -78,6 +89,13 @@ func (u *Uninstall) Run(name string) (*release.UninstallReleaseResponse, error)
 		return nil, fmt.Errorf("uninstall: Release name is invalid: %s", name)
 	}
 
+	var keepSelector labels.Selector
+	if u.KeepSelector != "" {
+		if keepSelector, err = labels.Parse(u.KeepSelector); err != nil {
+			return nil, fmt.Errorf("uninstall: invalid keep selector: %w", err)
+		}
+	}
+
 	rels, err := u.cfg.Releases.History(name)
 	if err != nil {
 		if u.IgnoreNotFound {
@@ -111,7 +129,7 @@ func (u *Uninstall) Run(name string) (*release.UninstallReleaseResponse, error)
 	res := &release.UninstallReleaseResponse{Release: rel}
 
 	if !u.DisableHooks {
-		if err := u.cfg.execHook(rel, release.HookPreDelete, u.WaitStrategy, u.Timeout); err != nil {
+		if err := u.cfg.execHook(rel, release.HookPreDelete, u.WaitStrategy, u.Timeout, nil); err != nil {
 			return res, err
 		}
 	} else {
@@ -124,23 +142,22 @@ func (u *Uninstall) Run(name string) (*release.UninstallReleaseResponse, error)
 		slog.Debug("uninstall: Failed to store updated release", slog.Any("error", err))
 	}
 
-	deletedResources, kept, errs := u.deleteRelease(rel)
+	var tierWaiter kube.Waiter
+	if u.WaitForTiers {
+		tierWaiter = waiter
+	}
+	deletedResources, errs := u.deleteRelease(rel, keepSelector, tierWaiter, res)
 	if errs != nil {
 		slog.Debug("uninstall: Failed to delete release", slog.Any("error", errs))
 		return nil, fmt.Errorf("failed to delete release: %s", name)
 	}
 
-	if kept != "" {
-		kept = "These resources were kept due to the resource policy:\n" + kept
-	}
-	res.Info = kept
-
-	if err := waiter.WaitForDelete(deletedResources, u.Timeout); err != nil {
+	if err := u.waitForDelete(waiter, deletedResources); err != nil {
 		errs = append(errs, err)
 	}
 
 	if !u.DisableHooks {
-		if err := u.cfg.execHook(rel, release.HookPostDelete, u.WaitStrategy, u.Timeout); err != nil {
+		if err := u.cfg.execHook(rel, release.HookPostDelete, u.WaitStrategy, u.Timeout, nil); err != nil {
 			errs = append(errs, err)
 		}
 	}
@@ -177,6 +194,50 @@ func (u *Uninstall) Run(name string) (*release.UninstallReleaseResponse, error)
 	return res, nil
 }
 
+// deletionPollInterval is the interval at which the resources deleted by an
+// uninstall are looked up until they are gone.
+var deletionPollInterval = 2 * time.Second
+
+// waitForDelete waits with waiter, within the timeout, for the deleted
+// resources to be gone. Unless only hooks are waited for, the resources are
+// then looked up on the API server until none remains, for those blocked on
+// finalizers to be waited for too. The resources remaining when the timeout
+// is hit are reported in the error.
+func (u *Uninstall) waitForDelete(waiter kube.Waiter, deleted kube.ResourceList) error {
+	deadline := time.Now().Add(u.Timeout)
+	err := waiter.WaitForDelete(deleted, u.Timeout)
+
+	checker, ok := u.cfg.KubeClient.(kube.InterfaceRemaining)
+	if !ok || u.WaitStrategy == kube.HookOnlyStrategy || len(deleted) == 0 {
+		return err
+	}
+	for {
+		remaining, checkErr := checker.Remaining(deleted)
+		if checkErr != nil {
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("unable to check the deletion of resources: %w", checkErr)
+		}
+		if len(remaining) == 0 {
+			return err
+		}
+		left := time.Until(deadline)
+		if left <= 0 {
+			stuck := make([]string, 0, len(remaining))
+			for _, r := range remaining {
+				stuck = append(stuck, r.String())
+			}
+			stuckErr := fmt.Errorf("timed out waiting for the deletion of %d resource(s): %s", len(remaining), strings.Join(stuck, ", "))
+			if err != nil {
+				return joinErrors([]error{err, stuckErr}, "; ")
+			}
+			return stuckErr
+		}
+		time.Sleep(min(deletionPollInterval, left))
+	}
+}
+
 func (u *Uninstall) purgeReleases(rels ...*release.Release) error {
 	for _, rel := range rels {
 		if _, err := u.cfg.Releases.Delete(rel.Name, rel.Version); err != nil {
@@ -210,8 +271,10 @@ func (e *joinedErrors) Unwrap() []error {
 	return e.errs
 }
 
-// deleteRelease deletes the release and returns list of delete resources and manifests that were kept in the deletion process
-func (u *Uninstall) deleteRelease(rel *release.Release) (kube.ResourceList, string, []error) {
+// deleteRelease deletes the release and returns the list of deleted resources.
+// The resources that were kept and deleted are recorded in res. When waiter is
+// set, each tier of resources is waited for to be deleted before the next one.
+func (u *Uninstall) deleteRelease(rel *release.Release, keepSelector labels.Selector, waiter kube.Waiter, res *release.UninstallReleaseResponse) (kube.ResourceList, []error) {
 	var errs []error
 
 	manifests := releaseutil.SplitManifests(rel.Manifest)
@@ -221,15 +284,32 @@ func (u *Uninstall) deleteRelease(rel *release.Release) (kube.ResourceList, stri
 		// FIXME: One way to delete at this point would be to try a label-based
 		// deletion. The problem with this is that we could get a false positive
 		// and delete something that was not legitimately part of this release.
-		return nil, rel.Manifest, []error{fmt.Errorf("corrupted release record. You must manually delete the resources: %w", err)}
+		return nil, []error{fmt.Errorf("corrupted release record. You must manually delete the resources: %w", err)}
 	}
 
 	filesToKeep, filesToDelete := filterManifestsToKeep(files)
-	var kept string
-	for _, f := range filesToKeep {
-		kept += "[" + f.Head.Kind + "] " + f.Head.Metadata.Name + "\n"
+	overridesToKeep, filesToDelete, err := filterManifestsByKeepOptions(filesToDelete, keepSelector, u.KeepKinds)
+	if err != nil {
+		return nil, []error{fmt.Errorf("corrupted release record. You must manually delete the resources: %w", err)}
 	}
 
+	var info strings.Builder
+	if len(filesToKeep) > 0 {
+		info.WriteString("These resources were kept due to the resource policy:\n")
+		for _, f := range filesToKeep {
+			info.WriteString("[" + f.Head.Kind + "] " + f.Head.Metadata.Name + "\n")
+		}
+	}
+	if len(overridesToKeep) > 0 {
+		info.WriteString("These resources were kept due to the keep options:\n")
+		for _, f := range overridesToKeep {
+			info.WriteString("[" + f.Head.Kind + "] " + f.Head.Metadata.Name + "\n")
+		}
+	}
+	res.Info = info.String()
+	res.Kept = manifestReferences(append(filesToKeep, overridesToKeep...), rel.Namespace)
+	res.Deleted = manifestReferences(filesToDelete, rel.Namespace)
+
 	var builder strings.Builder
 	for _, file := range filesToDelete {
 		builder.WriteString("\n---\n" + file.Content)
@@ -237,16 +317,20 @@ func (u *Uninstall) deleteRelease(rel *release.Release) (kube.ResourceList, stri
 
 	resources, err := u.cfg.KubeClient.Build(strings.NewReader(builder.String()), false)
 	if err != nil {
-		return nil, "", []error{fmt.Errorf("unable to build kubernetes objects for delete: %w", err)}
+		return nil, []error{fmt.Errorf("unable to build kubernetes objects for delete: %w", err)}
 	}
 	if len(resources) > 0 {
+		if kubeClient, ok := u.cfg.KubeClient.(kube.InterfaceTieredDeletion); ok && waiter != nil {
+			_, errs = kubeClient.DeleteInTiers(resources, parseCascadingFlag(u.DeletionPropagation), waiter, u.Timeout)
+			return resources, errs
+		}
 		if kubeClient, ok := u.cfg.KubeClient.(kube.InterfaceDeletionPropagation); ok {
 			_, errs = kubeClient.DeleteWithPropagationPolicy(resources, parseCascadingFlag(u.DeletionPropagation))
-			return resources, kept, errs
+			return resources, errs
 		}
 		_, errs = u.cfg.KubeClient.Delete(resources)
 	}
-	return resources, kept, errs
+	return resources, errs
 }
 
 func parseCascadingFlag(cascadingFlag string) v1.DeletionPropagation {
@@ -18,7 +18,9 @@ package action
 
 import (
 	"fmt"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -109,6 +111,82 @@ func TestUninstallRelease_Wait(t *testing.T) {
 	is.Equal(res.Release.Info.Status, release.StatusUninstalled)
 }
 
+// remainingKubeClient reports the resources as remaining for the first calls
+// to Remaining.
+type remainingKubeClient struct {
+	*kubefake.FailingKubeClient
+	remainingCalls int
+	calls          int
+}
+
+func (c *remainingKubeClient) Remaining(resources kube.ResourceList) ([]kube.RemainingResource, error) {
+	c.calls++
+	if c.calls <= c.remainingCalls {
+		return []kube.RemainingResource{{Kind: "Pod", Namespace: "spaced", Name: "starfish", Terminating: true}}, nil
+	}
+	return nil, nil
+}
+
+func TestUninstallRelease_WaitForRemaining(t *testing.T) {
+	is := assert.New(t)
+	interval := deletionPollInterval
+	deletionPollInterval = time.Millisecond
+	defer func() { deletionPollInterval = interval }()
+
+	unAction := uninstallAction(t)
+	unAction.DisableHooks = true
+	unAction.WaitStrategy = kube.StatusWatcherStrategy
+	unAction.Timeout = time.Minute
+
+	rel := releaseStub()
+	rel.Name = "come-fail-away"
+	unAction.cfg.Releases.Create(rel)
+	failer := unAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+	failer.DummyResources = kube.ResourceList{diffTestInfo("v1", "Pod", "starfish")}
+	client := &remainingKubeClient{FailingKubeClient: failer, remainingCalls: 2}
+	unAction.cfg.KubeClient = client
+
+	_, err := unAction.Run(rel.Name)
+	is.NoError(err)
+	is.Equal(3, client.calls, "the resources are looked up until they are gone")
+}
+
+func TestUninstallRelease_WaitForRemainingTimeout(t *testing.T) {
+	is := assert.New(t)
+
+	unAction := uninstallAction(t)
+	unAction.DisableHooks = true
+	unAction.WaitStrategy = kube.StatusWatcherStrategy
+	unAction.Timeout = 10 * time.Millisecond
+
+	rel := releaseStub()
+	rel.Name = "come-fail-away"
+	unAction.cfg.Releases.Create(rel)
+	failer := unAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+	failer.DummyResources = kube.ResourceList{diffTestInfo("v1", "Pod", "starfish")}
+	failer.RemainingResults = []kube.RemainingResource{
+		{Kind: "Pod", Namespace: "spaced", Name: "starfish", Terminating: true, Finalizers: []string{"example.com/cleanup"}},
+	}
+
+	res, err := unAction.Run(rel.Name)
+	is.Error(err)
+	is.Contains(err.Error(), `timed out waiting for the deletion of 1 resource(s): Pod "starfish" in namespace "spaced" (blocked on finalizers example.com/cleanup)`)
+	is.Equal(release.StatusUninstalled, res.Release.Info.Status)
+
+	// Only the hooks are waited for without --wait.
+	unAction = uninstallAction(t)
+	unAction.DisableHooks = true
+	unAction.WaitStrategy = kube.HookOnlyStrategy
+	rel = releaseStub()
+	rel.Name = "come-fail-away"
+	unAction.cfg.Releases.Create(rel)
+	failer = unAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+	failer.DummyResources = kube.ResourceList{diffTestInfo("v1", "Pod", "starfish")}
+	failer.RemainingError = fmt.Errorf("unexpected lookup")
+	_, err = unAction.Run(rel.Name)
+	is.NoError(err)
+}
+
 func TestUninstallRelease_Cascade(t *testing.T) {
 	is := assert.New(t)
 
@@ -140,3 +218,135 @@ func TestUninstallRelease_Cascade(t *testing.T) {
 	is.Error(err)
 	is.Contains(err.Error(), "failed to delete release: come-fail-away")
 }
+
+func TestUninstallRelease_WaitForTiers(t *testing.T) {
+	is := assert.New(t)
+
+	unAction := uninstallAction(t)
+	unAction.DisableHooks = true
+	unAction.WaitForTiers = true
+
+	rel := releaseStub()
+	rel.Name = "come-fail-away"
+	rel.Manifest = `{"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "space"}}`
+	unAction.cfg.Releases.Create(rel)
+	failer := unAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+	failer.DummyResources = kube.ResourceList{
+		diffTestInfo("v1", "Pod", "starfish"),
+		diffTestInfo("v1", "Namespace", "space"),
+	}
+	failer.WaitForDeleteError = fmt.Errorf("U timed out")
+
+	// The pod is waited for before the namespace is deleted.
+	_, err := unAction.Run(rel.Name)
+	is.Error(err)
+	is.Contains(err.Error(), "failed to delete release: come-fail-away")
+}
+
+func TestUninstallRelease_KeepOptions(t *testing.T) {
+	manifest := `---
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: data
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: other
+  labels:
+    preserve: "true"
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: token
+  annotations:
+    helm.sh/resource-policy: keep
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  labels:
+    preserve: "false"
+`
+
+	tests := []struct {
+		name     string
+		selector string
+		kinds    []string
+		kept     []string
+		deleted  []string
+	}{
+		{
+			name:    "resource policy only",
+			kept:    []string{"Secret/token"},
+			deleted: []string{"ConfigMap/settings", "PersistentVolumeClaim/data", "Service/web"},
+		},
+		{
+			name:    "kinds",
+			kinds:   []string{"persistentvolumeclaim"},
+			kept:    []string{"PersistentVolumeClaim/data", "Secret/token"},
+			deleted: []string{"ConfigMap/settings", "Service/web"},
+		},
+		{
+			name:     "selector and kinds",
+			selector: "preserve=true",
+			kinds:    []string{"PersistentVolumeClaim"},
+			kept:     []string{"ConfigMap/settings", "PersistentVolumeClaim/data", "Secret/token"},
+			deleted:  []string{"Service/web"},
+		},
+	}
+
+	names := func(refs []release.ResourceReference) []string {
+		var out []string
+		for _, ref := range refs {
+			out = append(out, ref.Kind+"/"+ref.Name)
+		}
+		sort.Strings(out)
+		return out
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := assert.New(t)
+
+			unAction := uninstallAction(t)
+			unAction.DisableHooks = true
+			unAction.KeepSelector = tt.selector
+			unAction.KeepKinds = tt.kinds
+
+			rel := releaseStub()
+			rel.Name = "keep-data"
+			rel.Namespace = "spaced"
+			rel.Manifest = manifest
+			unAction.cfg.Releases.Create(rel)
+
+			res, err := unAction.Run(rel.Name)
+			is.NoError(err)
+			is.Equal(tt.kept, names(res.Kept))
+			is.Equal(tt.deleted, names(res.Deleted))
+			is.Contains(res.Info, "These resources were kept due to the resource policy:\n[Secret] token\n")
+			if tt.selector != "" || len(tt.kinds) > 0 {
+				is.Contains(res.Info, "These resources were kept due to the keep options:\n")
+			}
+			for _, ref := range append(res.Kept, res.Deleted...) {
+				if ref.Name == "settings" {
+					is.Equal("other", ref.Namespace)
+				} else {
+					is.Equal("spaced", ref.Namespace)
+				}
+			}
+		})
+	}
+
+	unAction := uninstallAction(t)
+	unAction.KeepSelector = "preserve in (true"
+	rel := releaseStub()
+	rel.Name = "keep-data"
+	unAction.cfg.Releases.Create(rel)
+	_, err := unAction.Run(rel.Name)
+	assert.ErrorContains(t, err, "invalid keep selector")
+}
@@ -26,6 +26,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/mitchellh/copystructure"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/cli-runtime/pkg/resource"
 
@@ -39,11 +40,19 @@ import (
 	"helm.sh/helm/v4/pkg/storage/driver"
 )
 
+// ErrPostUpgradeHooksFailed is wrapped by the errors of upgrades whose
+// resources were applied, but whose post-upgrade hooks failed.
+var ErrPostUpgradeHooksFailed = errors.New("post-upgrade hooks failed")
+
 // Upgrade is the action for upgrading releases.
 //
 // It provides the implementation of 'helm upgrade'.
 type Upgrade struct {
 	cfg *Configuration
+	// hookRuns records the hooks run by the last run.
+	hookRuns *hookRecorder
+	// waitExpressions are the parsed WaitFor expressions.
+	waitExpressions []*kube.WaitExpression
 
 	ChartPathOptions
 
@@ -78,6 +87,10 @@ type Upgrade struct {
 	// HideSecret can be set to true when DryRun is enabled in order to hide
 	// Kubernetes Secrets in the output. It cannot be used outside of DryRun.
 	HideSecret bool
+	// ShowDiff can be set to true when DryRunOption is "server" in order to
+	// compare the upgrade with the live objects in the cluster. The result is
+	// stored in the Diff field of the release info.
+	ShowDiff bool
 	// Force will, if set to `true`, ignore certain warnings and perform the upgrade anyway.
 	//
 	// This should be used with caution.
@@ -88,12 +101,24 @@ type Upgrade struct {
 	ReuseValues bool
 	// ResetThenReuseValues will reset the values to the chart's built-ins then merge with user's last supplied values.
 	ResetThenReuseValues bool
+	// ReuseValuesKeys lists dotted paths, such as "auth.password", of the
+	// user's last supplied values to carry over to the upgrade. Values
+	// passed to Run take precedence, and paths missing from the last release
+	// are ignored. It applies when neither ReuseValues nor
+	// ResetThenReuseValues is set, including together with ResetValues.
+	ReuseValuesKeys []string
 	// Recreate will (if true) recreate pods after a rollback.
 	Recreate bool
-	// MaxHistory limits the maximum number of revisions saved per release
+	// MaxHistory limits the maximum number of revisions saved per release,
+	// pruning the oldest ones after a successful upgrade. It defaults to the
+	// MaxHistory of the configuration; zero means no limit.
 	MaxHistory int
 	// Atomic, if true, will roll back on failure.
 	Atomic bool
+	// NoRollbackOnHookFailure, if true, keeps an atomic upgrade from being
+	// rolled back when only its post-upgrade hooks fail, for hooks that are
+	// advisory. The release is marked as failed.
+	NoRollbackOnHookFailure bool
 	// CleanupOnFail will, if true, cause the upgrade to delete newly-created resources on a failed update.
 	CleanupOnFail bool
 	// SubNotes determines whether sub-notes are rendered in the chart.
@@ -102,9 +127,17 @@ type Upgrade struct {
 	HideNotes bool
 	// SkipSchemaValidation determines if JSON schema validation is disabled.
 	SkipSchemaValidation bool
+	// ListMergeKeys maps the dotted paths of lists in the values to the field
+	// their elements are merged by, instead of replacing the lists of the
+	// chart values.
+	ListMergeKeys map[string]string
 	// Description is the description of this operation
 	Description string
 	Labels      map[string]string
+	// Annotations are added to the storage object of the release, merged
+	// with those of the previous release. An annotation set to "null" is
+	// removed. Their keys must be prefixed with "helm.sh/".
+	Annotations map[string]string
 	// PostRenderer is an optional post-renderer
 	//
 	// If this is non-nil, then after templates are rendered, they will be sent to the
@@ -120,6 +153,43 @@ type Upgrade struct {
 	EnableDNS bool
 	// TakeOwnership will skip the check for helm annotations and adopt all existing resources.
 	TakeOwnership bool
+	// FieldManager is the name of the field manager recorded for the changes
+	// made to resources. The default of the Kubernetes client is used if empty.
+	FieldManager string
+	// Parallelism is the maximum number of resources of the same kind applied
+	// at once. The default of the Kubernetes client is used if zero.
+	Parallelism int
+	// Preflight checks, before the upgrade, that the ConfigMaps and Secrets
+	// referenced by the workloads of the release are created by the release
+	// or present in the cluster, and warns about those missing.
+	Preflight bool
+	// PreflightStrict fails the upgrade on the missing references found by
+	// the preflight check. It implies Preflight.
+	PreflightStrict bool
+	// ForceRecreate deletes and creates again the resources whose update is
+	// rejected because it changes an immutable field, such as the pod
+	// template of a Job or the clusterIP of a Service. The resources are
+	// unavailable while they are recreated.
+	ForceRecreate bool
+	// ApplyOnly limits the resources applied to those matching one of these
+	// selectors, of the form "Kind/name" or a glob matching the paths of the
+	// templates in the chart, such as "charts/database/templates/*.yaml".
+	// The names of the resources and the paths can be globs, and a path
+	// matching a directory, such as "charts/database", selects the
+	// templates in it. Hooks are run as for a complete apply. The manifest of
+	// the release records the selectors and holds the resources applied,
+	// along with the resources of the current release not selected, which
+	// are left unchanged. It requires the FeatureGatePartialApply gate:
+	// applying a part of a chart may leave the release in a state the chart
+	// does not expect.
+	ApplyOnly []string
+	// WaitFor lists expressions, such as
+	// "deployment/web:.status.availableReplicas>=3", that must all hold for
+	// the resources of the release namespace they name before the upgrade
+	// succeeds, after the wait of WaitStrategy. They are checked by polling
+	// the resources for what is left of Timeout after that wait, as
+	// described by kube.WaitExpression.
+	WaitFor []string
 }
 
 type resultMessage struct {
@@ -130,7 +200,8 @@ type resultMessage struct {
 // NewUpgrade creates a new Upgrade object with the given configuration.
 func NewUpgrade(cfg *Configuration) *Upgrade {
 	up := &Upgrade{
-		cfg: cfg,
+		cfg:        cfg,
+		MaxHistory: cfg.MaxHistory,
 	}
 	up.registryClient = cfg.RegistryClient
 
@@ -142,6 +213,12 @@ func (u *Upgrade) SetRegistryClient(client *registry.Client) {
 	u.registryClient = client
 }
 
+// HookRuns returns the runs of the hooks by the last run, in their order. A
+// hook failing is the last run, and its run is recorded.
+func (u *Upgrade) HookRuns() []HookRun {
+	return u.hookRuns.all()
+}
+
 // Run executes the upgrade on the given release.
 func (u *Upgrade) Run(name string, chart *chart.Chart, vals map[string]interface{}) (*release.Release, error) {
 	ctx := context.Background()
@@ -150,9 +227,30 @@ func (u *Upgrade) Run(name string, chart *chart.Chart, vals map[string]interface
 
 // RunWithContext executes the upgrade on the given release with context.
 func (u *Upgrade) RunWithContext(ctx context.Context, name string, chart *chart.Chart, vals map[string]interface{}) (*release.Release, error) {
+	u.hookRuns = &hookRecorder{}
 	if err := u.cfg.KubeClient.IsReachable(); err != nil {
 		return nil, err
 	}
+	resetFieldManager, err := u.cfg.setFieldManager(u.FieldManager)
+	if err != nil {
+		return nil, err
+	}
+	defer resetFieldManager()
+	resetParallelism, err := u.cfg.setParallelism(u.Parallelism)
+	if err != nil {
+		return nil, err
+	}
+	defer resetParallelism()
+	resetForceRecreate, err := u.cfg.setForceRecreate(u.ForceRecreate)
+	if err != nil {
+		return nil, err
+	}
+	defer resetForceRecreate()
+	waitExpressions, err := parseWaitExpressions(u.WaitFor)
+	if err != nil {
+		return nil, err
+	}
+	u.waitExpressions = waitExpressions
 
 	// Make sure if Atomic is set, that wait is set as well. This makes it so
 	// the user doesn't have to specify both
@@ -184,6 +282,7 @@ func (u *Upgrade) RunWithContext(ctx context.Context, name string, chart *chart.
 		if err := u.cfg.Releases.Update(upgradedRelease); err != nil {
 			return res, err
 		}
+		u.cfg.pruneHistory(name)
 	}
 
 	return res, nil
@@ -208,6 +307,19 @@ func (u *Upgrade) prepareUpgrade(name string, chart *chart.Chart, vals map[strin
 		return nil, nil, errors.New("hiding Kubernetes secrets requires a dry-run mode")
 	}
 
+	// ShowDiff needs the cluster to perform the dry run.
+	if u.ShowDiff && u.DryRunOption != "server" {
+		return nil, nil, errors.New("showing a diff requires the server dry-run mode")
+	}
+
+	var applySelectors []applySelector
+	if len(u.ApplyOnly) > 0 {
+		var err error
+		if applySelectors, err = parseApplySelectors(u.ApplyOnly); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	// finds the last non-deleted release with the given name
 	lastRelease, err := u.cfg.Releases.Last(name)
 	if err != nil {
@@ -255,17 +367,22 @@ func (u *Upgrade) prepareUpgrade(name string, chart *chart.Chart, vals map[strin
 	revision := lastRelease.Version + 1
 
 	options := chartutil.ReleaseOptions{
-		Name:      name,
-		Namespace: currentRelease.Namespace,
-		Revision:  revision,
-		IsUpgrade: true,
+		Name:          name,
+		Namespace:     currentRelease.Namespace,
+		Revision:      revision,
+		IsUpgrade:     true,
+		ListMergeKeys: u.ListMergeKeys,
 	}
 
 	caps, err := u.cfg.getCapabilities()
 	if err != nil {
 		return nil, nil, err
 	}
-	valuesToRender, err := chartutil.ToRenderValuesWithSchemaValidation(chart, vals, options, caps, u.SkipSchemaValidation)
+	withDefaults, err := u.cfg.computeDefaults(chart, vals, options, caps, u.EnableDNS)
+	if err != nil {
+		return nil, nil, err
+	}
+	valuesToRender, err := chartutil.ToRenderValuesWithSchemaValidation(chart, withDefaults, options, caps, u.SkipSchemaValidation)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -276,14 +393,24 @@ func (u *Upgrade) prepareUpgrade(name string, chart *chart.Chart, vals map[strin
 		interactWithRemote = true
 	}
 
-	hooks, manifestDoc, notesTxt, err := u.cfg.renderResources(chart, valuesToRender, "", "", u.SubNotes, false, false, u.PostRenderer, interactWithRemote, u.EnableDNS, u.HideSecret)
+	hooks, manifestDoc, notesTxt, err := u.cfg.renderResources(chart, valuesToRender, "", "", u.SubNotes, false, false, u.PostRenderer, interactWithRemote, u.EnableDNS, u.HideSecret, nil)
 	if err != nil {
 		return nil, nil, err
 	}
+	manifest := manifestDoc.String()
+	if len(applySelectors) > 0 {
+		// The resources not selected are kept as in the current release.
+		if manifest, err = partialManifest(currentRelease.Manifest, manifest, applySelectors); err != nil {
+			return nil, nil, err
+		}
+	}
 
 	if driver.ContainsSystemLabels(u.Labels) {
 		return nil, nil, fmt.Errorf("user supplied labels contains system reserved label name. System labels: %+v", driver.GetSystemLabels())
 	}
+	if driver.ContainsNonReleaseAnnotations(u.Annotations) {
+		return nil, nil, fmt.Errorf("user supplied annotations must be prefixed with %q", driver.ReleaseAnnotationPrefix)
+	}
 
 	// Store an upgraded release.
 	upgradedRelease := &release.Release{
@@ -297,16 +424,17 @@ func (u *Upgrade) prepareUpgrade(name string, chart *chart.Chart, vals map[strin
 			Status:        release.StatusPendingUpgrade,
 			Description:   "Preparing upgrade", // This should be overwritten later.
 		},
-		Version:  revision,
-		Manifest: manifestDoc.String(),
-		Hooks:    hooks,
-		Labels:   mergeCustomLabels(lastRelease.Labels, u.Labels),
+		Version:     revision,
+		Manifest:    manifest,
+		Hooks:       hooks,
+		Labels:      mergeCustomLabels(lastRelease.Labels, u.Labels),
+		Annotations: mergeCustomLabels(lastRelease.Annotations, u.Annotations),
 	}
 
 	if len(notesTxt) > 0 {
 		upgradedRelease.Info.Notes = notesTxt
 	}
-	err = validateManifest(u.cfg.KubeClient, manifestDoc.Bytes(), !u.DisableOpenAPIValidation)
+	err = validateManifest(u.cfg.KubeClient, []byte(manifest), !u.DisableOpenAPIValidation)
 	return currentRelease, upgradedRelease, err
 }
 
@@ -356,6 +484,12 @@ func (u *Upgrade) performUpgrade(ctx context.Context, originalRelease, upgradedR
 		return nil, fmt.Errorf("unable to continue with update: %w", err)
 	}
 
+	if u.Preflight || u.PreflightStrict {
+		if err := u.cfg.preflight(upgradedRelease.Manifest, upgradedRelease.Namespace, u.PreflightStrict); err != nil {
+			return upgradedRelease, err
+		}
+	}
+
 	toBeUpdated.Visit(func(r *resource.Info, err error) error {
 		if err != nil {
 			return err
@@ -372,6 +506,13 @@ func (u *Upgrade) performUpgrade(ctx context.Context, originalRelease, upgradedR
 		} else {
 			upgradedRelease.Info.Description = "Dry run complete"
 		}
+		if u.ShowDiff {
+			diff, err := serverDryRunDiff(u.cfg.KubeClient, current, target)
+			if err != nil {
+				return upgradedRelease, fmt.Errorf("unable to compute diff: %w", err)
+			}
+			upgradedRelease.Info.Diff = diff
+		}
 		return upgradedRelease, nil
 	}
 
@@ -421,7 +562,7 @@ func (u *Upgrade) releasingUpgrade(c chan<- resultMessage, upgradedRelease *rele
 	// pre-upgrade hooks
 
 	if !u.DisableHooks {
-		if err := u.cfg.execHook(upgradedRelease, release.HookPreUpgrade, u.WaitStrategy, u.Timeout); err != nil {
+		if err := u.cfg.execHook(upgradedRelease, release.HookPreUpgrade, u.WaitStrategy, u.Timeout, u.hookRuns); err != nil {
 			u.reportToPerformUpgrade(c, upgradedRelease, kube.ResourceList{}, fmt.Errorf("pre-upgrade hooks failed: %s", err))
 			return
 		}
@@ -451,6 +592,8 @@ func (u *Upgrade) releasingUpgrade(c chan<- resultMessage, upgradedRelease *rele
 		u.reportToPerformUpgrade(c, upgradedRelease, results.Created, err)
 		return
 	}
+	// The wait for expressions shares the timeout of the wait of the resources.
+	deadline := time.Now().Add(u.Timeout)
 	if u.WaitForJobs {
 		if err := waiter.WaitWithJobs(target, u.Timeout); err != nil {
 			u.cfg.recordRelease(originalRelease)
@@ -464,11 +607,16 @@ func (u *Upgrade) releasingUpgrade(c chan<- resultMessage, upgradedRelease *rele
 			return
 		}
 	}
+	if err := u.cfg.waitForExpressions(upgradedRelease.Namespace, u.waitExpressions, time.Until(deadline)); err != nil {
+		u.cfg.recordRelease(originalRelease)
+		u.reportToPerformUpgrade(c, upgradedRelease, results.Created, err)
+		return
+	}
 
 	// post-upgrade hooks
 	if !u.DisableHooks {
-		if err := u.cfg.execHook(upgradedRelease, release.HookPostUpgrade, u.WaitStrategy, u.Timeout); err != nil {
-			u.reportToPerformUpgrade(c, upgradedRelease, results.Created, fmt.Errorf("post-upgrade hooks failed: %s", err))
+		if err := u.cfg.execHook(upgradedRelease, release.HookPostUpgrade, u.WaitStrategy, u.Timeout, u.hookRuns); err != nil {
+			u.reportToPerformUpgrade(c, upgradedRelease, results.Created, fmt.Errorf("%w: %s", ErrPostUpgradeHooksFailed, err))
 			return
 		}
 	}
@@ -492,7 +640,11 @@ func (u *Upgrade) failRelease(rel *release.Release, created kube.ResourceList, e
 	rel.Info.Status = release.StatusFailed
 	rel.Info.Description = msg
 	u.cfg.recordRelease(rel)
-	if u.CleanupOnFail && len(created) > 0 {
+	hookFailure := errors.Is(err, ErrPostUpgradeHooksFailed)
+	// The release is kept as applied when only its advisory hooks failed,
+	// along with the resources it created.
+	keep := u.Atomic && hookFailure && u.NoRollbackOnHookFailure
+	if u.CleanupOnFail && len(created) > 0 && !keep {
 		slog.Debug("cleanup on fail set", "cleaning_resources", len(created))
 		_, errs := u.cfg.KubeClient.Delete(created)
 		if errs != nil {
@@ -507,6 +659,10 @@ func (u *Upgrade) failRelease(rel *release.Release, created kube.ResourceList, e
 		}
 		slog.Debug("resource cleanup complete")
 	}
+	if keep {
+		slog.Debug("post-upgrade hooks failed and rolling back on hook failures is disabled, not rolling back", "name", rel.Name)
+		return rel, err
+	}
 	if u.Atomic {
 		slog.Debug("upgrade failed and atomic is set, rolling back to last successful release")
 
@@ -540,9 +696,15 @@ func (u *Upgrade) failRelease(rel *release.Release, created kube.ResourceList, e
 		rollin.Recreate = u.Recreate
 		rollin.Force = u.Force
 		rollin.Timeout = u.Timeout
+		rollin.FieldManager = u.FieldManager
+		rollin.Parallelism = u.Parallelism
+		rollin.ForceRecreate = u.ForceRecreate
 		if rollErr := rollin.Run(rel.Name); rollErr != nil {
 			return rel, fmt.Errorf("an error occurred while rolling back the release. original upgrade error: %w: %w", err, rollErr)
 		}
+		if hookFailure {
+			return rel, fmt.Errorf("release %s was applied but its post-upgrade hooks failed, and it has been rolled back due to atomic being set: %w", rel.Name, err)
+		}
 		return rel, fmt.Errorf("release %s failed, and has been rolled back due to atomic being set: %w", rel.Name, err)
 	}
 
@@ -557,7 +719,21 @@ func (u *Upgrade) failRelease(rel *release.Release, created kube.ResourceList, e
 //
 // This is skipped if the u.ResetValues flag is set, in which case the
 // request values are not altered.
+//
+// If u.ReuseValuesKeys is set, only the values at those paths are copied.
 func (u *Upgrade) reuseValues(chart *chart.Chart, current *release.Release, newVals map[string]interface{}) (map[string]interface{}, error) {
+	if len(u.ReuseValuesKeys) > 0 && !u.ReuseValues && !u.ResetThenReuseValues {
+		slog.Debug("reusing selected values from the old release", "keys", u.ReuseValuesKeys)
+		reused, err := selectValues(current.Config, u.ReuseValuesKeys)
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy old values: %w", err)
+		}
+		if newVals == nil {
+			newVals = map[string]interface{}{}
+		}
+		return chartutil.CoalesceTables(newVals, reused), nil
+	}
+
 	if u.ResetValues {
 		// If ResetValues is set, we completely ignore current.Config.
 		slog.Debug("resetting values to the chart's original version")
@@ -638,6 +814,45 @@ func recreate(cfg *Configuration, resources kube.ResourceList) error {
 	return nil
 }
 
+// selectValues returns a copy of the values at the given dotted paths. Paths
+// that do not exist in vals are skipped.
+func selectValues(vals map[string]interface{}, paths []string) (map[string]interface{}, error) {
+	selected := map[string]interface{}{}
+	for _, path := range paths {
+		keys := strings.Split(path, ".")
+		var v interface{} = vals
+		for _, k := range keys {
+			table, ok := v.(map[string]interface{})
+			if !ok {
+				v = nil
+				break
+			}
+			if v, ok = table[k]; !ok {
+				break
+			}
+		}
+		if v == nil {
+			continue
+		}
+		v, err := copystructure.Copy(v)
+		if err != nil {
+			return nil, err
+		}
+
+		dst := selected
+		for _, k := range keys[:len(keys)-1] {
+			next, ok := dst[k].(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				dst[k] = next
+			}
+			dst = next
+		}
+		dst[keys[len(keys)-1]] = v
+	}
+	return selected, nil
+}
+
 func objectKey(r *resource.Info) string {
 	gvk := r.Object.GetObjectKind().GroupVersionKind()
 	return fmt.Sprintf("%s/%s/%s/%s", gvk.GroupVersion().String(), gvk.Kind, r.Namespace, r.Name)
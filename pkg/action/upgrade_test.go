@@ -71,6 +71,41 @@ func TestUpgradeRelease_Success(t *testing.T) {
 	is.Equal(lastRelease.Info.Status, release.StatusDeployed)
 }
 
+func TestUpgradeRelease_MaxHistory(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	config := actionConfigFixture(t)
+	config.MaxHistory = 2
+	upAction := NewUpgrade(config)
+	upAction.Namespace = "spaced"
+	is.Equal(2, upAction.MaxHistory)
+
+	// The history was saved without any limit.
+	for i := 1; i <= 4; i++ {
+		rel := releaseStub()
+		rel.Name = "history-limited"
+		rel.Version = i
+		rel.Info.Status = release.StatusSuperseded
+		if i == 4 {
+			rel.Info.Status = release.StatusDeployed
+		}
+		req.NoError(config.Releases.Create(rel))
+	}
+
+	res, err := upAction.Run("history-limited", buildChart(), map[string]interface{}{})
+	req.NoError(err)
+	is.Equal(5, res.Version)
+
+	hist, err := config.Releases.History("history-limited")
+	req.NoError(err)
+	var versions []int
+	for _, rel := range hist {
+		versions = append(versions, rel.Version)
+	}
+	is.ElementsMatch([]int{4, 5}, versions)
+}
+
 func TestUpgradeRelease_Wait(t *testing.T) {
 	is := assert.New(t)
 	req := require.New(t)
@@ -164,6 +199,8 @@ func TestUpgradeRelease_Atomic(t *testing.T) {
 		req.Error(err)
 		is.Contains(err.Error(), "arming key removed")
 		is.Contains(err.Error(), "atomic")
+		is.Contains(err.Error(), "post-upgrade hooks failed")
+		is.ErrorIs(err, ErrPostUpgradeHooksFailed)
 
 		// Now make sure it is actually upgraded
 		updatedRes, err := upAction.cfg.Releases.Get(res.Name, 3)
@@ -172,6 +209,35 @@ func TestUpgradeRelease_Atomic(t *testing.T) {
 		is.Equal(updatedRes.Info.Status, release.StatusDeployed)
 	})
 
+	t.Run("atomic without rollback on hook failure", func(t *testing.T) {
+		upAction := upgradeAction(t)
+
+		rel := releaseStub()
+		rel.Name = "nuketown"
+		rel.Info.Status = release.StatusDeployed
+		upAction.cfg.Releases.Create(rel)
+
+		failer := upAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+		failer.WatchUntilReadyError = fmt.Errorf("arming key removed")
+		client := &createdClient{FailingKubeClient: failer, created: kube.ResourceList{{Name: "created"}}}
+		upAction.cfg.KubeClient = client
+		upAction.Atomic = true
+		upAction.NoRollbackOnHookFailure = true
+		upAction.CleanupOnFail = true
+
+		res, err := upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+		req.Error(err)
+		is.ErrorIs(err, ErrPostUpgradeHooksFailed)
+		is.NotContains(err.Error(), "rolled back")
+		// The created resources are kept along with the release.
+		is.False(client.cleanedUp)
+		is.Equal(release.StatusFailed, res.Info.Status)
+
+		// No rollback revision is created
+		_, err = upAction.cfg.Releases.Get(res.Name, 3)
+		is.Error(err)
+	})
+
 	t.Run("atomic uninstall fails", func(t *testing.T) {
 		upAction := upgradeAction(t)
 		rel := releaseStub()
@@ -189,6 +255,7 @@ func TestUpgradeRelease_Atomic(t *testing.T) {
 		req.Error(err)
 		is.Contains(err.Error(), "update fail")
 		is.Contains(err.Error(), "an error occurred while rolling back the release")
+		is.NotErrorIs(err, ErrPostUpgradeHooksFailed)
 	})
 }
 
@@ -310,6 +377,80 @@ func TestUpgradeRelease_ReuseValues(t *testing.T) {
 	})
 }
 
+func TestUpgradeRelease_ReuseValuesKeys(t *testing.T) {
+	existingValues := map[string]interface{}{
+		"auth": map[string]interface{}{
+			"password": "generated",
+			"user":     "admin",
+		},
+		"image": map[string]interface{}{
+			"tag": "1.0",
+		},
+		"replicas": 2,
+	}
+
+	tests := []struct {
+		name        string
+		resetValues bool
+		newValues   map[string]interface{}
+		expected    map[string]interface{}
+	}{
+		{
+			name:      "only the named paths are reused",
+			newValues: map[string]interface{}{"replicas": 3},
+			expected: map[string]interface{}{
+				"auth":     map[string]interface{}{"password": "generated"},
+				"image":    map[string]interface{}{"tag": "1.0"},
+				"replicas": 3,
+			},
+		},
+		{
+			name: "new values take precedence",
+			newValues: map[string]interface{}{
+				"image": map[string]interface{}{"tag": "2.0", "pullPolicy": "Always"},
+			},
+			expected: map[string]interface{}{
+				"auth":  map[string]interface{}{"password": "generated"},
+				"image": map[string]interface{}{"tag": "2.0", "pullPolicy": "Always"},
+			},
+		},
+		{
+			name:        "named paths survive reset values",
+			resetValues: true,
+			newValues:   map[string]interface{}{},
+			expected: map[string]interface{}{
+				"auth":  map[string]interface{}{"password": "generated"},
+				"image": map[string]interface{}{"tag": "1.0"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := require.New(t)
+			upAction := upgradeAction(t)
+
+			rel := releaseStub()
+			rel.Name = "nuketown"
+			rel.Info.Status = release.StatusDeployed
+			rel.Config = existingValues
+			req.NoError(upAction.cfg.Releases.Create(rel))
+
+			upAction.ResetValues = tt.resetValues
+			upAction.ReuseValuesKeys = []string{"auth.password", "image.tag", "missing.path", "replicas.nested"}
+			res, err := upAction.Run(rel.Name, buildChart(), tt.newValues)
+			req.NoError(err)
+
+			updatedRes, err := upAction.cfg.Releases.Get(res.Name, 2)
+			req.NoError(err)
+			assert.Equal(t, tt.expected, updatedRes.Config)
+		})
+	}
+
+	// The reused values are copies of those stored with the old release.
+	assert.Equal(t, "generated", existingValues["auth"].(map[string]interface{})["password"])
+}
+
 func TestUpgradeRelease_ResetThenReuseValues(t *testing.T) {
 	is := assert.New(t)
 
@@ -508,6 +649,36 @@ func TestUpgradeRelease_Labels(t *testing.T) {
 	is.Equal(initialRes.Labels, rel.Labels)
 }
 
+func TestUpgradeRelease_Annotations(t *testing.T) {
+	is := assert.New(t)
+	upAction := upgradeAction(t)
+
+	rel := releaseStub()
+	rel.Name = "annotations"
+	rel.Annotations = map[string]string{
+		"helm.sh/team":  "payments",
+		"helm.sh/owner": "alice",
+	}
+	rel.Info.Status = release.StatusDeployed
+
+	err := upAction.cfg.Releases.Create(rel)
+	is.NoError(err)
+
+	upAction.Annotations = map[string]string{
+		"helm.sh/owner": "null",
+		"helm.sh/tier":  "backend",
+	}
+	res, err := upAction.Run(rel.Name, buildChart(), nil)
+	is.NoError(err)
+
+	updatedRes, err := upAction.cfg.Releases.Get(res.Name, 2)
+	is.NoError(err)
+	is.Equal(map[string]string{
+		"helm.sh/team": "payments",
+		"helm.sh/tier": "backend",
+	}, updatedRes.Annotations)
+}
+
 func TestUpgradeRelease_SystemLabels(t *testing.T) {
 	is := assert.New(t)
 	upAction := upgradeAction(t)
@@ -538,6 +709,28 @@ func TestUpgradeRelease_SystemLabels(t *testing.T) {
 	is.Equal(fmt.Errorf("user supplied labels contains system reserved label name. System labels: %+v", driver.GetSystemLabels()), err)
 }
 
+func TestUpgradeRelease_NonReleaseAnnotations(t *testing.T) {
+	is := assert.New(t)
+	upAction := upgradeAction(t)
+
+	rel := releaseStub()
+	rel.Name = "annotations"
+	rel.Info.Status = release.StatusDeployed
+
+	err := upAction.cfg.Releases.Create(rel)
+	is.NoError(err)
+
+	upAction.Annotations = map[string]string{
+		"example.com/team": "payments",
+	}
+	_, err = upAction.Run(rel.Name, buildChart(), nil)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	is.Equal(fmt.Errorf("user supplied annotations must be prefixed with %q", driver.ReleaseAnnotationPrefix), err)
+}
+
 func TestUpgradeRelease_DryRun(t *testing.T) {
 	is := assert.New(t)
 	req := require.New(t)
@@ -588,3 +781,195 @@ func TestUpgradeRelease_DryRun(t *testing.T) {
 	done()
 	req.Error(err)
 }
+
+func TestUpgradeRelease_ShowDiff(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	upAction := upgradeAction(t)
+	rel := releaseStub()
+	rel.Name = "previous-release"
+	rel.Info.Status = release.StatusDeployed
+	req.NoError(upAction.cfg.Releases.Create(rel))
+
+	info := diffTestInfo("v1", "ConfigMap", "settings")
+	upAction.cfg.KubeClient.(*kubefake.FailingKubeClient).DryRunApplyResults = []kube.DryRunApplyResult{{
+		Info:    info,
+		Live:    map[string]interface{}{"data": map[string]interface{}{"mode": "a"}},
+		Applied: map[string]interface{}{"data": map[string]interface{}{"mode": "b"}},
+	}}
+
+	// A diff needs the cluster.
+	upAction.ShowDiff = true
+	upAction.DryRunOption = "client"
+	_, err := upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+	req.ErrorContains(err, "requires the server dry-run mode")
+
+	upAction.DryRunOption = "server"
+	res, err := upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+	req.NoError(err)
+	req.Len(res.Info.Diff, 1)
+	is.Equal(release.DiffUpdate, res.Info.Diff[0].Action)
+	is.Equal([]string{"data.mode"}, res.Info.Diff[0].Changed)
+
+	lastRelease, err := upAction.cfg.Releases.Last(rel.Name)
+	req.NoError(err)
+	is.Equal(1, lastRelease.Version)
+	is.Empty(lastRelease.Info.Diff)
+
+	upAction.cfg.KubeClient.(*kubefake.FailingKubeClient).DryRunApplyError = fmt.Errorf("connection refused")
+	_, err = upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+	req.ErrorContains(err, "unable to compute diff")
+}
+
+func TestUpgradeRelease_FieldManager(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	upAction := upgradeAction(t)
+	rel := releaseStub()
+	rel.Name = "previous-release"
+	rel.Info.Status = release.StatusDeployed
+	req.NoError(upAction.cfg.Releases.Create(rel))
+
+	client := &settingsClient{FailingKubeClient: upAction.cfg.KubeClient.(*kubefake.FailingKubeClient)}
+	upAction.cfg.KubeClient = client
+	upAction.FieldManager = "helm-gitops"
+	_, err := upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+	req.NoError(err)
+	req.NotEmpty(client.fieldManagers)
+	for _, name := range client.fieldManagers {
+		is.Equal("helm-gitops", name)
+	}
+
+	// A later action on the same configuration has the default field manager.
+	client.fieldManagers = nil
+	req.NoError(NewRollback(upAction.cfg).Run(rel.Name))
+	is.Equal([]string{""}, client.fieldManagers)
+	is.Equal("", client.FieldManager)
+}
+
+func TestUpgradeRelease_Parallelism(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	upAction := upgradeAction(t)
+	rel := releaseStub()
+	rel.Name = "previous-release"
+	rel.Info.Status = release.StatusDeployed
+	req.NoError(upAction.cfg.Releases.Create(rel))
+
+	client := &settingsClient{FailingKubeClient: upAction.cfg.KubeClient.(*kubefake.FailingKubeClient)}
+	upAction.cfg.KubeClient = client
+	upAction.Parallelism = 4
+	_, err := upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+	req.NoError(err)
+	req.NotEmpty(client.parallelisms)
+	for _, n := range client.parallelisms {
+		is.Equal(4, n)
+	}
+
+	// A later action on the same configuration has the default parallelism.
+	client.parallelisms = nil
+	rollAction := NewRollback(upAction.cfg)
+	req.NoError(rollAction.Run(rel.Name))
+	is.Equal([]int{0}, client.parallelisms)
+	is.Equal(0, client.Parallelism)
+}
+
+func TestUpgradeRelease_ForceRecreate(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	upAction := upgradeAction(t)
+	rel := releaseStub()
+	rel.Name = "previous-release"
+	rel.Info.Status = release.StatusDeployed
+	req.NoError(upAction.cfg.Releases.Create(rel))
+
+	client := &forceRecreateClient{FailingKubeClient: upAction.cfg.KubeClient.(*kubefake.FailingKubeClient)}
+	upAction.cfg.KubeClient = client
+	_, err := upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+	req.NoError(err)
+	is.Equal([]bool{false}, client.updates)
+
+	// The client recreates the resources during the upgrade only.
+	client.updates = nil
+	upAction.ForceRecreate = true
+	_, err = upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+	req.NoError(err)
+	is.Equal([]bool{true}, client.updates)
+	is.False(client.ForceRecreate)
+
+	// The rollback of a failed atomic upgrade recreates them as well.
+	client.updates = nil
+	client.failUpdates = 1
+	upAction.Atomic = true
+	_, err = upAction.Run(rel.Name, buildChart(), map[string]interface{}{})
+	req.ErrorContains(err, "has been rolled back due to atomic being set")
+	is.Equal([]bool{true, true}, client.updates)
+	is.False(client.ForceRecreate)
+}
+
+// createdClient reports the resources created as part of each update, and
+// records whether they are deleted.
+type createdClient struct {
+	*kubefake.FailingKubeClient
+	created   kube.ResourceList
+	cleanedUp bool
+}
+
+func (c *createdClient) Update(original, target kube.ResourceList, force bool) (*kube.Result, error) {
+	res, err := c.FailingKubeClient.Update(original, target, force)
+	if res != nil {
+		res.Created = c.created
+	}
+	return res, err
+}
+
+func (c *createdClient) Delete(resources kube.ResourceList) (*kube.Result, []error) {
+	if len(resources) > 0 && len(c.created) > 0 && resources[0] == c.created[0] {
+		c.cleanedUp = true
+	}
+	return c.FailingKubeClient.Delete(resources)
+}
+
+// forceRecreateClient records whether the resources are recreated on each
+// update, and fails the first failUpdates updates.
+type forceRecreateClient struct {
+	*kubefake.FailingKubeClient
+	updates     []bool
+	failUpdates int
+}
+
+func (c *forceRecreateClient) Update(original, target kube.ResourceList, force bool) (*kube.Result, error) {
+	c.updates = append(c.updates, c.ForceRecreate)
+	if c.failUpdates > 0 {
+		c.failUpdates--
+		return &kube.Result{}, fmt.Errorf("update fail")
+	}
+	return c.FailingKubeClient.Update(original, target, force)
+}
+
+func TestUpgradeRelease_PreflightStrict(t *testing.T) {
+	req := require.New(t)
+
+	upAction := upgradeAction(t)
+	rel := releaseStub()
+	rel.Name = "previous-release"
+	rel.Namespace = "spaced"
+	rel.Info.Status = release.StatusDeployed
+	req.NoError(upAction.cfg.Releases.Create(rel))
+
+	upAction.PreflightStrict = true
+	upAction.cfg.KubeClient.(*kubefake.FailingKubeClient).ExistingObjects = []string{"Secret/spaced/migrations", "Secret/spaced/db", "Secret/jobs/db"}
+	chrt := buildChartWithTemplates([]*chart.File{
+		{Name: "templates/workloads.yaml", Data: []byte(preflightManifest)},
+	})
+	_, err := upAction.Run(rel.Name, chrt, map[string]interface{}{})
+	req.ErrorContains(err, `Deployment/web references ConfigMap "ca-bundle" in namespace "spaced"`)
+
+	last, err := upAction.cfg.Releases.Last(rel.Name)
+	req.NoError(err)
+	req.Equal(rel.Version, last.Version, "the upgrade is not recorded")
+}
@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"helm.sh/helm/v4/pkg/downloader"
+	"helm.sh/helm/v4/pkg/provenance"
 )
 
 // Verify is the action for building a given chart's Verify tree.
@@ -29,6 +30,15 @@ import (
 type Verify struct {
 	Keyring string
 	Out     string
+	// MinSignatures is the number of distinct keys from the keyring that
+	// must have signed the chart. Values below 1 are treated as 1.
+	MinSignatures int
+
+	// Signer selects the kind of signature to verify: SignerPGP, the
+	// default, or SignerSigstore.
+	Signer string
+	// SigstoreOptions configures the verification of sigstore bundles.
+	SigstoreOptions provenance.SigstoreVerifyOptions
 }
 
 // NewVerify creates a new Verify object with the given configuration.
@@ -38,16 +48,26 @@ func NewVerify() *Verify {
 
 // Run executes 'helm verify'.
 func (v *Verify) Run(chartfile string) error {
+	switch v.Signer {
+	case "", SignerPGP:
+	case SignerSigstore:
+		return v.runSigstore(chartfile)
+	default:
+		return fmt.Errorf("unknown signer %q", v.Signer)
+	}
+
 	var out strings.Builder
-	p, err := downloader.VerifyChart(chartfile, v.Keyring)
+	p, err := downloader.VerifyChartThreshold(chartfile, v.Keyring, max(v.MinSignatures, 1))
 	if err != nil {
 		return err
 	}
 
-	for name := range p.SignedBy.Identities {
-		fmt.Fprintf(&out, "Signed by: %v\n", name)
+	for _, signer := range p.Signers {
+		for name := range signer.Identities {
+			fmt.Fprintf(&out, "Signed by: %v\n", name)
+		}
+		fmt.Fprintf(&out, "Using Key With Fingerprint: %X\n", signer.PrimaryKey.Fingerprint)
 	}
-	fmt.Fprintf(&out, "Using Key With Fingerprint: %X\n", p.SignedBy.PrimaryKey.Fingerprint)
 	fmt.Fprintf(&out, "Chart Hash Verified: %s\n", p.FileHash)
 
 	// TODO(mattfarina): The output is set as a property rather than returned
@@ -57,3 +77,19 @@ func (v *Verify) Run(chartfile string) error {
 
 	return nil
 }
+
+// runSigstore verifies chartfile against the sigstore bundle next to it.
+func (v *Verify) runSigstore(chartfile string) error {
+	var out strings.Builder
+	p, err := provenance.VerifySigstore(chartfile, chartfile+provenance.SigstoreBundleExt, v.SigstoreOptions)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(&out, "Signed by: %s\n", p.Identity)
+	fmt.Fprintf(&out, "Certificate Issuer: %s\n", p.Issuer)
+	fmt.Fprintf(&out, "Chart Hash Verified: %s\n", p.FileHash)
+	v.Out = out.String()
+
+	return nil
+}
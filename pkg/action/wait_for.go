@@ -0,0 +1,50 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"errors"
+	"time"
+
+	"helm.sh/helm/v4/pkg/kube"
+)
+
+// parseWaitExpressions parses the wait expressions of an install or upgrade.
+func parseWaitExpressions(expressions []string) ([]*kube.WaitExpression, error) {
+	var parsed []*kube.WaitExpression
+	for _, s := range expressions {
+		e, err := kube.ParseWaitExpression(s)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, e)
+	}
+	return parsed, nil
+}
+
+// waitForExpressions waits until each of the expressions holds for the
+// resource of namespace it names, or until timeout.
+func (cfg *Configuration) waitForExpressions(namespace string, expressions []*kube.WaitExpression, timeout time.Duration) error {
+	if len(expressions) == 0 {
+		return nil
+	}
+	w, ok := cfg.KubeClient.(kube.InterfaceWaitExpressions)
+	if !ok {
+		return errors.New("the Kubernetes client does not support waiting for expressions")
+	}
+	return w.WaitForExpressions(namespace, expressions, timeout)
+}
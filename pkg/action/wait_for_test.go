@@ -0,0 +1,87 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	kubefake "helm.sh/helm/v4/pkg/kube/fake"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+func TestInstallReleaseWaitFor(t *testing.T) {
+	instAction := installAction(t)
+	instAction.WaitFor = []string{"deployment/web:.status.availableReplicas>=3"}
+	failer := instAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+
+	rel, err := instAction.RunWithContext(context.Background(), buildChart(), map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, release.StatusDeployed, rel.Info.Status)
+	require.Len(t, failer.WaitExpressions, 1)
+	assert.Equal(t, instAction.WaitFor[0], failer.WaitExpressions[0].String())
+
+	instAction.ReleaseName = "wait-for-fails"
+	failer.WaitForExpressionsError = errors.New("wait expression deployment/web:.status.availableReplicas>=3 not met, last observed value: 1")
+	rel, err = instAction.RunWithContext(context.Background(), buildChart(), map[string]interface{}{})
+	assert.ErrorContains(t, err, "not met, last observed value: 1")
+	assert.Equal(t, release.StatusFailed, rel.Info.Status)
+}
+
+func TestInstallReleaseWaitForTimeout(t *testing.T) {
+	instAction := installAction(t)
+	instAction.WaitFor = []string{"deployment/web:.status.availableReplicas>=3"}
+	instAction.Timeout = time.Second
+	failer := instAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+	failer.WaitDuration = 200 * time.Millisecond
+
+	_, err := instAction.RunWithContext(context.Background(), buildChart(), map[string]interface{}{})
+	require.NoError(t, err)
+	// The expressions are waited for with what is left of the timeout.
+	assert.Greater(t, failer.WaitExpressionsTimeout, time.Duration(0))
+	assert.LessOrEqual(t, failer.WaitExpressionsTimeout, 800*time.Millisecond)
+}
+
+func TestInstallReleaseWaitForInvalid(t *testing.T) {
+	instAction := installAction(t)
+	instAction.WaitFor = []string{"deployment/web"}
+
+	_, err := instAction.RunWithContext(context.Background(), buildChart(), map[string]interface{}{})
+	assert.ErrorContains(t, err, `invalid wait expression "deployment/web"`)
+	_, err = instAction.cfg.Releases.Get(instAction.ReleaseName, 1)
+	assert.Error(t, err, "expected no release to be recorded")
+}
+
+func TestUpgradeReleaseWaitFor(t *testing.T) {
+	upAction := upgradeAction(t)
+	rel := releaseStub()
+	rel.Info.Status = release.StatusDeployed
+	require.NoError(t, upAction.cfg.Releases.Create(rel))
+
+	upAction.WaitFor = []string{"job/migrate:.status.succeeded==1"}
+	failer := upAction.cfg.KubeClient.(*kubefake.FailingKubeClient)
+	failer.WaitForExpressionsError = errors.New("wait expression job/migrate:.status.succeeded==1 not met, last observed value: <not found>")
+
+	res, err := upAction.RunWithContext(context.Background(), rel.Name, buildChart(), map[string]interface{}{})
+	assert.ErrorContains(t, err, "last observed value: <not found>")
+	assert.Equal(t, release.StatusFailed, res.Info.Status)
+}
@@ -41,7 +41,7 @@ func (l DirLoader) Load() (*chart.Chart, error) {
 // LoadDir loads from a directory.
 //
 // This loads charts only from directories.
-func LoadDir(dir string) (*chart.Chart, error) {
+func LoadDir(dir string, opts ...LoadOption) (*chart.Chart, error) {
 	topdir, err := filepath.Abs(dir)
 	if err != nil {
 		return nil, err
@@ -59,9 +59,7 @@ func LoadDir(dir string) (*chart.Chart, error) {
 		}
 		rules = r
 	}
-	rules.AddDefaults()
-
-	files := []*BufferedFile{}
+	files := newChartFiles(rules)
 	topdir += string(filepath.Separator)
 
 	walk := func(name string, fi os.FileInfo, err error) error {
@@ -78,44 +76,65 @@ func LoadDir(dir string) (*chart.Chart, error) {
 		if err != nil {
 			return err
 		}
-		if fi.IsDir() {
-			// Directory-based ignore rules should involve skipping the entire
-			// contents of that directory.
-			if rules.Ignore(n, fi) {
-				return filepath.SkipDir
-			}
-			return nil
-		}
+		return files.add(n, name, fi, func() ([]byte, error) {
+			return os.ReadFile(name)
+		})
+	}
+	if err = sympath.Walk(topdir, walk); err != nil {
+		return c, err
+	}
 
-		// If a .helmignore file matches, skip this file.
-		if rules.Ignore(n, fi) {
-			return nil
-		}
+	return LoadFiles(files.files, opts...)
+}
 
-		// Irregular files include devices, sockets, and other uses of files that
-		// are not regular files. In Go they have a file mode type bit set.
-		// See https://golang.org/pkg/os/#FileMode for examples.
-		if !fi.Mode().IsRegular() {
-			return fmt.Errorf("cannot load irregular file %s as it has file mode type bits set", name)
-		}
+// chartFiles collects the files of a chart directory as it is walked,
+// skipping the files matched by its .helmignore rules.
+type chartFiles struct {
+	rules *ignore.Rules
+	files []*BufferedFile
+}
 
-		if fi.Size() > MaxDecompressedFileSize {
-			return fmt.Errorf("chart file %q is larger than the maximum file size %d", fi.Name(), MaxDecompressedFileSize)
-		}
+func newChartFiles(rules *ignore.Rules) *chartFiles {
+	rules.AddDefaults()
+	return &chartFiles{rules: rules}
+}
 
-		data, err := os.ReadFile(name)
-		if err != nil {
-			return fmt.Errorf("error reading %s: %w", n, err)
+// add adds the file, or skips the directory, named n relative to the chart
+// directory and normalized to /. The file or directory is named name in
+// errors, and the content of files is read with read.
+func (c *chartFiles) add(n, name string, fi os.FileInfo, read func() ([]byte, error)) error {
+	if fi.IsDir() {
+		// Directory-based ignore rules should involve skipping the entire
+		// contents of that directory.
+		if c.rules.Ignore(n, fi) {
+			return filepath.SkipDir
 		}
+		return nil
+	}
 
-		data = bytes.TrimPrefix(data, utf8bom)
-
-		files = append(files, &BufferedFile{Name: n, Data: data})
+	// If a .helmignore file matches, skip this file.
+	if c.rules.Ignore(n, fi) {
 		return nil
 	}
-	if err = sympath.Walk(topdir, walk); err != nil {
-		return c, err
+
+	// Irregular files include devices, sockets, and other uses of files that
+	// are not regular files. In Go they have a file mode type bit set.
+	// See https://golang.org/pkg/os/#FileMode for examples.
+	if !fi.Mode().IsRegular() {
+		return fmt.Errorf("cannot load irregular file %s as it has file mode type bits set", name)
+	}
+
+	if fi.Size() > MaxDecompressedFileSize {
+		return fmt.Errorf("chart file %q is larger than the maximum file size %d", fi.Name(), MaxDecompressedFileSize)
 	}
 
-	return LoadFiles(files)
+	data, err := read()
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", n, err)
+	}
+
+	data = bytes.TrimPrefix(data, utf8bom)
+
+	c.files = append(c.files, &BufferedFile{Name: n, Data: data})
+	return nil
 }
@@ -0,0 +1,90 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loader
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	"helm.sh/helm/v4/pkg/ignore"
+)
+
+// FSLoader loads a chart from a directory of a file system
+type FSLoader struct {
+	FS   fs.FS
+	Root string
+}
+
+// Load loads the chart
+func (l FSLoader) Load() (*chart.Chart, error) {
+	return LoadFS(l.FS, l.Root)
+}
+
+// LoadFS loads a chart from the directory root of fsys, such as a chart
+// embedded in a program with an embed.FS. A root of "." stands for the top
+// directory of fsys.
+//
+// The files of the chart are loaded as by LoadDir, honoring its .helmignore
+// file. Symbolic links are not followed, as file systems such as embed.FS do
+// not hold them.
+func LoadFS(fsys fs.FS, root string, opts ...LoadOption) (*chart.Chart, error) {
+	// Just used for errors.
+	c := &chart.Chart{}
+
+	root = path.Clean(root)
+	rules := ignore.Empty()
+	data, err := fs.ReadFile(fsys, path.Join(root, ignore.HelmIgnore))
+	if err == nil {
+		if rules, err = ignore.Parse(bytes.NewReader(data)); err != nil {
+			return c, err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return c, err
+	}
+	files := newChartFiles(rules)
+
+	walk := func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		n := strings.TrimPrefix(name, root+"/")
+		if root == "." {
+			n = name
+		}
+		if name == root {
+			// No need to process top level. Avoid bug with helmignore .* matching
+			// empty names. See issue 1779.
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return files.add(n, name, fi, func() ([]byte, error) {
+			return fs.ReadFile(fsys, name)
+		})
+	}
+	if err := fs.WalkDir(fsys, root, walk); err != nil {
+		return c, err
+	}
+
+	return LoadFiles(files.files, opts...)
+}
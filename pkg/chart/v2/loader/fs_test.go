@@ -0,0 +1,103 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loader
+
+import (
+	"embed"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+//go:embed all:testdata/frobnitz
+var frobnitzFS embed.FS
+
+func TestLoadFS(t *testing.T) {
+	c, err := FSLoader{FS: frobnitzFS, Root: "testdata/frobnitz"}.Load()
+	if err != nil {
+		t.Fatalf("Failed to load testdata: %s", err)
+	}
+	verifyFrobnitz(t, c)
+	verifyChart(t, c)
+	verifyDependencies(t, c)
+	verifyDependenciesLock(t, c)
+
+	// The chart loads the same as from its directory.
+	dir, err := LoadDir("testdata/frobnitz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Raw) != len(dir.Raw) {
+		t.Errorf("expected %d files, got %d", len(dir.Raw), len(c.Raw))
+	}
+	for i := range dir.Raw {
+		if c.Raw[i].Name != dir.Raw[i].Name {
+			t.Errorf("expected file %q, got %q", dir.Raw[i].Name, c.Raw[i].Name)
+		}
+	}
+}
+
+func TestLoadFSRoot(t *testing.T) {
+	fsys := fstest.MapFS{
+		"Chart.yaml":                 {Data: []byte("\xEF\xBB\xBFapiVersion: v2\nname: embedded\nversion: 0.1.0\n")},
+		"values.yaml":                {Data: []byte("replicas: 2\n")},
+		".helmignore":                {Data: []byte("*.bak\nsecret/\n")},
+		"notes.bak":                  {Data: []byte("ignored")},
+		"secret/key":                 {Data: []byte("ignored")},
+		"templates/deployment.yaml":  {Data: []byte("kind: Deployment\n")},
+		"templates/nested/svc.yaml":  {Data: []byte("kind: Service\n")},
+		"crds/crontab.yaml":          {Data: []byte("kind: CustomResourceDefinition\n")},
+		"charts/sub/Chart.yaml":      {Data: []byte("apiVersion: v2\nname: sub\nversion: 0.2.0\n")},
+		"charts/sub/templates/a.txt": {Data: []byte("sub")},
+	}
+	c, err := LoadFS(fsys, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name() != "embedded" {
+		t.Errorf("expected chart name embedded, got %q", c.Name())
+	}
+	if c.Values["replicas"] != float64(2) {
+		t.Errorf("unexpected values: %v", c.Values)
+	}
+	if len(c.Templates) != 2 || c.Templates[0].Name != "templates/deployment.yaml" || c.Templates[1].Name != "templates/nested/svc.yaml" {
+		t.Errorf("unexpected templates: %v", c.Templates)
+	}
+	if len(c.CRDObjects()) != 1 {
+		t.Errorf("expected 1 CRD, got %d", len(c.CRDObjects()))
+	}
+	if len(c.Dependencies()) != 1 || c.Dependencies()[0].Name() != "sub" {
+		t.Errorf("expected the subchart to be loaded, got %v", c.Dependencies())
+	}
+	for _, f := range c.Raw {
+		if f.Name == "notes.bak" || f.Name == "secret/key" {
+			t.Errorf("expected %s to be ignored", f.Name)
+		}
+	}
+
+	sub, err := fs.Sub(fsys, "charts/sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, err := LoadFS(sub, "."); err != nil || c.Name() != "sub" {
+		t.Errorf("expected the subchart to load on its own, got %v, %v", c, err)
+	}
+
+	if _, err := LoadFS(fsys, "missing"); err == nil {
+		t.Error("expected an error loading a missing directory")
+	}
+}
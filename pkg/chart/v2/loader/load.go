@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"maps"
 	"os"
 	"path/filepath"
@@ -58,12 +59,39 @@ func Loader(name string) (ChartLoader, error) {
 //
 // If a .helmignore file is present, the directory loader will skip loading any files
 // matching it. But .helmignore is not evaluated when reading out of an archive.
-func Load(name string) (*chart.Chart, error) {
-	l, err := Loader(name)
+func Load(name string, opts ...LoadOption) (*chart.Chart, error) {
+	fi, err := os.Stat(name)
 	if err != nil {
 		return nil, err
 	}
-	return l.Load()
+	if fi.IsDir() {
+		return LoadDir(name, opts...)
+	}
+	return LoadFile(name, opts...)
+}
+
+// LoadOption configures how a chart is loaded.
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	validateChartfile func(data []byte) ([]string, error)
+}
+
+// WithChartfileValidator validates the content of the Chart.yaml files of the
+// chart and of its subcharts with validate before they are loaded, such as
+// chartutil.ValidateChartfile. The warnings it returns are logged.
+func WithChartfileValidator(validate func(data []byte) ([]string, error)) LoadOption {
+	return func(o *loadOptions) {
+		o.validateChartfile = validate
+	}
+}
+
+func newLoadOptions(opts []LoadOption) loadOptions {
+	var o loadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
 }
 
 // BufferedFile represents an archive file buffered for later processing.
@@ -73,7 +101,8 @@ type BufferedFile struct {
 }
 
 // LoadFiles loads from in-memory files.
-func LoadFiles(files []*BufferedFile) (*chart.Chart, error) {
+func LoadFiles(files []*BufferedFile, opts ...LoadOption) (*chart.Chart, error) {
+	o := newLoadOptions(opts)
 	c := new(chart.Chart)
 	subcharts := make(map[string][]*BufferedFile)
 
@@ -85,6 +114,15 @@ func LoadFiles(files []*BufferedFile) (*chart.Chart, error) {
 			if c.Metadata == nil {
 				c.Metadata = new(chart.Metadata)
 			}
+			if o.validateChartfile != nil {
+				warnings, err := o.validateChartfile(f.Data)
+				for _, w := range warnings {
+					slog.Warn("invalid Chart.yaml", "warning", w)
+				}
+				if err != nil {
+					return c, fmt.Errorf("cannot load Chart.yaml: %w", err)
+				}
+			}
 			if err := yaml.Unmarshal(f.Data, c.Metadata); err != nil {
 				return c, fmt.Errorf("cannot load Chart.yaml: %w", err)
 			}
@@ -182,7 +220,7 @@ func LoadFiles(files []*BufferedFile) (*chart.Chart, error) {
 				return c, fmt.Errorf("error unpacking subchart tar in %s: expected %s, got %s", c.Name(), n, file.Name)
 			}
 			// Untar the chart and add to c.Dependencies
-			sc, err = LoadArchive(bytes.NewBuffer(file.Data))
+			sc, err = LoadArchive(bytes.NewBuffer(file.Data), opts...)
 		default:
 			// We have to trim the prefix off of every file, and ignore any file
 			// that is in charts/, but isn't actually a chart.
@@ -195,7 +233,7 @@ func LoadFiles(files []*BufferedFile) (*chart.Chart, error) {
 				f.Name = parts[1]
 				buff = append(buff, f)
 			}
-			sc, err = LoadFiles(buff)
+			sc, err = LoadFiles(buff, opts...)
 		}
 
 		if err != nil {
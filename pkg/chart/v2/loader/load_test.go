@@ -20,6 +20,7 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"errors"
 	"io"
 	"log"
 	"os"
@@ -233,6 +234,45 @@ func TestLoadFiles_BadCases(t *testing.T) {
 	}
 }
 
+func TestLoadFilesChartfileValidator(t *testing.T) {
+	var validated [][]byte
+	validate := WithChartfileValidator(func(data []byte) ([]string, error) {
+		validated = append(validated, data)
+		if bytes.Contains(data, []byte("version: 1.0\n")) {
+			return nil, errors.New("invalid Chart.yaml:\n- /version: got number, want string")
+		}
+		return []string{"unknown field"}, nil
+	})
+
+	// The Chart.yaml files of the subcharts are validated as well.
+	chartfile := []byte("apiVersion: v2\nname: frobnitz\nversion: 1.0.0\n")
+	subchartfile := []byte("apiVersion: v2\nname: sub\nversion: 1.0.0\n")
+	_, err := LoadFiles([]*BufferedFile{
+		{Name: "Chart.yaml", Data: chartfile},
+		{Name: "charts/sub/Chart.yaml", Data: subchartfile},
+	}, validate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(validated) != 2 || !bytes.Equal(validated[0], chartfile) || !bytes.Equal(validated[1], subchartfile) {
+		t.Errorf("expected the Chart.yaml files to be validated, got %q", validated)
+	}
+
+	_, err = LoadFiles([]*BufferedFile{{Name: "Chart.yaml", Data: []byte("apiVersion: v2\nname: frobnitz\nversion: 1.0\n")}}, validate)
+	if err == nil || !strings.Contains(err.Error(), "cannot load Chart.yaml: invalid Chart.yaml:") {
+		t.Errorf("expected the validation error, got %v", err)
+	}
+
+	// Charts are not validated by default.
+	validated = nil
+	if _, err := LoadFiles([]*BufferedFile{{Name: "Chart.yaml", Data: chartfile}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(validated) != 0 {
+		t.Errorf("expected no validation, got %q", validated)
+	}
+}
+
 func TestLoadFiles(t *testing.T) {
 	goodFiles := []*BufferedFile{
 		{
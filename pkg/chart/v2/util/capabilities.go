@@ -106,6 +106,33 @@ func (v VersionSet) Has(apiVersion string) bool {
 	return slices.Contains(v, apiVersion)
 }
 
+// Merge returns the versions of v followed by those of other that are not in
+// v.
+func (v VersionSet) Merge(other VersionSet) VersionSet {
+	merged := slices.Clone(v)
+	for _, apiVersion := range other {
+		if !merged.Has(apiVersion) {
+			merged = append(merged, apiVersion)
+		}
+	}
+	return merged
+}
+
+// CRDVersions returns the API versions served by the custom resources defined
+// by crd, as "<group>/<version>" and "<group>/<version>/<kind>" for each
+// served version, the form of the versions discovered for other APIs.
+func CRDVersions(crd *apiextensionsv1.CustomResourceDefinition) VersionSet {
+	var vs VersionSet
+	for _, version := range crd.Spec.Versions {
+		if !version.Served {
+			continue
+		}
+		gv := crd.Spec.Group + "/" + version.Name
+		vs = vs.Merge(VersionSet{gv, gv + "/" + crd.Spec.Names.Kind})
+	}
+	return vs
+}
+
 func allKnownVersions() VersionSet {
 	// We should register the built in extension APIs as well so CRDs are
 	// supported in the default version set. This has caused problems with `helm
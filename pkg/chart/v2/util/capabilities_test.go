@@ -16,7 +16,10 @@ limitations under the License.
 package util
 
 import (
+	"reflect"
 	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 )
 
 func TestVersionSet(t *testing.T) {
@@ -34,6 +37,34 @@ func TestVersionSet(t *testing.T) {
 	}
 }
 
+func TestVersionSetMerge(t *testing.T) {
+	vs := VersionSet{"v1", "apps/v1"}
+	merged := vs.Merge(VersionSet{"apps/v1", "batch/v1"})
+	if expected := (VersionSet{"v1", "apps/v1", "batch/v1"}); !reflect.DeepEqual(merged, expected) {
+		t.Errorf("Expected %v, got %v", expected, merged)
+	}
+	if len(vs) != 2 {
+		t.Errorf("Expected the version set not to be modified, got %v", vs)
+	}
+}
+
+func TestCRDVersions(t *testing.T) {
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: "cert-manager.io",
+			Names: apiextensionsv1.CustomResourceDefinitionNames{Kind: "Certificate"},
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{Name: "v1", Served: true},
+				{Name: "v1alpha1", Served: false},
+			},
+		},
+	}
+	expected := VersionSet{"cert-manager.io/v1", "cert-manager.io/v1/Certificate"}
+	if vs := CRDVersions(crd); !reflect.DeepEqual(vs, expected) {
+		t.Errorf("Expected %v, got %v", expected, vs)
+	}
+}
+
 func TestDefaultVersionSet(t *testing.T) {
 	if !DefaultVersionSet.Has("v1") {
 		t.Error("Expected core v1 version set")
@@ -0,0 +1,125 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"sigs.k8s.io/yaml"
+)
+
+// ChartfileSchema is the JSON schema of the content of Chart.yaml files.
+//
+//go:embed chartfile.schema.json
+var ChartfileSchema []byte
+
+// ValidateChartfile validates the content of a Chart.yaml file against
+// ChartfileSchema.
+//
+// Every violation of the schema is listed in the returned error, which is a
+// *ChartfileValidationError. Top-level fields unknown to the schema are not
+// errors, for forward compatibility, and are returned as warnings instead.
+func ValidateChartfile(data []byte) ([]string, error) {
+	raw, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", ChartfileName, err)
+	}
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", ChartfileName, err)
+	}
+
+	validator, known, err := compileChartfileSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	if fields, ok := instance.(map[string]interface{}); ok {
+		var unknown []string
+		for field := range fields {
+			if !known[field] {
+				unknown = append(unknown, field)
+			}
+		}
+		sort.Strings(unknown)
+		for _, field := range unknown {
+			warnings = append(warnings, fmt.Sprintf("unknown field %q in %s", field, ChartfileName))
+		}
+	}
+
+	if err := validator.Validate(instance); err != nil {
+		return warnings, &ChartfileValidationError{Violations: JSONSchemaValidationError{err}.Violations()}
+	}
+	return warnings, nil
+}
+
+// compileChartfileSchema compiles ChartfileSchema, and returns the set of
+// its top-level fields.
+func compileChartfileSchema() (*jsonschema.Schema, map[string]bool, error) {
+	schema, err := jsonschema.UnmarshalJSON(bytes.NewReader(ChartfileSchema))
+	if err != nil {
+		return nil, nil, err
+	}
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("file:///chartfile.schema.json", schema); err != nil {
+		return nil, nil, err
+	}
+	validator, err := compiler.Compile("file:///chartfile.schema.json")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var s struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal(ChartfileSchema, &s); err != nil {
+		return nil, nil, err
+	}
+	known := make(map[string]bool, len(s.Properties))
+	for field := range s.Properties {
+		known[field] = true
+	}
+	return validator, known, nil
+}
+
+// ChartfileValidationError is the error returned when a Chart.yaml file does
+// not match ChartfileSchema.
+type ChartfileValidationError struct {
+	// Violations lists every way in which the file does not match the schema.
+	Violations []SchemaViolation
+}
+
+// Error lists the violations, one per line.
+func (e *ChartfileValidationError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "invalid %s:", ChartfileName)
+	for _, v := range e.Violations {
+		p := v.Path
+		if p == "" {
+			p = "/"
+		}
+		fmt.Fprintf(&sb, "\n- %s: %s", p, v.Message)
+	}
+	return sb.String()
+}
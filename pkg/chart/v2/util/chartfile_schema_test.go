@@ -0,0 +1,86 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateChartfile(t *testing.T) {
+	data, err := os.ReadFile("testdata/chartfiletest.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	warnings, err := ValidateChartfile(data)
+	if err != nil {
+		t.Errorf("expected a valid Chart.yaml, got %s", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", warnings)
+	}
+}
+
+func TestValidateChartfileErrors(t *testing.T) {
+	data := `apiVersion: v2
+description: every problem is reported
+version: 1.0
+type: operator
+futureField: true
+dependencies:
+  - version: 1.2.3
+    repository: https://example.com/charts
+  - name: redis
+    enabled: "yes"
+`
+	warnings, err := ValidateChartfile([]byte(data))
+	if !reflect.DeepEqual(warnings, []string{`unknown field "futureField" in Chart.yaml`}) {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+
+	var verr *ChartfileValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected a ChartfileValidationError, got %v", err)
+	}
+	var paths []string
+	for _, v := range verr.Violations {
+		paths = append(paths, v.Path)
+	}
+	for _, path := range []string{"", "/version", "/type", "/dependencies/0", "/dependencies/1/enabled"} {
+		found := false
+		for _, p := range paths {
+			found = found || p == path
+		}
+		if !found {
+			t.Errorf("expected a violation at %q, got %v", path, paths)
+		}
+	}
+	for _, expect := range []string{"invalid Chart.yaml:", "- /: missing property 'name'", "- /version: got number, want string"} {
+		if !strings.Contains(err.Error(), expect) {
+			t.Errorf("expected the error to contain %q, got:\n%s", expect, err)
+		}
+	}
+}
+
+func TestValidateChartfileUnparsable(t *testing.T) {
+	if _, err := ValidateChartfile([]byte("name: [")); err == nil || !strings.Contains(err.Error(), "unable to parse Chart.yaml") {
+		t.Errorf("expected a parse error, got %v", err)
+	}
+}
@@ -47,7 +47,33 @@ func CoalesceValues(chrt *chart.Chart, vals map[string]interface{}) (Values, err
 	if err != nil {
 		return vals, err
 	}
-	return coalesce(log.Printf, chrt, valsCopy, "", false)
+	return coalesce(log.Printf, chrt, valsCopy, "", false, nil)
+}
+
+// CoalesceValuesWithListMerge coalesces all of the values in a chart (and its
+// subcharts) as CoalesceValues does, except for the lists at the paths in
+// mergeKeys.
+//
+// mergeKeys maps the dot separated paths of lists in the values, such as
+// "subchart.ports", to the name of the field identifying their elements, such
+// as "name". The elements of such a list in vals are coalesced into the
+// element of the chart values with the same identifier, in the manner of a
+// Kubernetes strategic merge patch, and the other elements are appended.
+// Paths within list elements are given as if the list was a table, as in
+// "containers.ports". Lists whose elements are not all tables holding the
+// identifying field are replaced.
+func CoalesceValuesWithListMerge(chrt *chart.Chart, vals map[string]interface{}, mergeKeys map[string]string) (Values, error) {
+	valsCopy, err := copyValues(vals)
+	if err != nil {
+		return vals, err
+	}
+	// The values of a chart are coalesced with the name of the chart as
+	// prefix.
+	keys := make(map[string]string, len(mergeKeys))
+	for path, key := range mergeKeys {
+		keys[concatPrefix(chrt.Metadata.Name, path)] = key
+	}
+	return coalesce(log.Printf, chrt, valsCopy, "", false, keys)
 }
 
 // MergeValues is used to merge the values in a chart and its subcharts. This
@@ -69,7 +95,7 @@ func MergeValues(chrt *chart.Chart, vals map[string]interface{}) (Values, error)
 	if err != nil {
 		return vals, err
 	}
-	return coalesce(log.Printf, chrt, valsCopy, "", true)
+	return coalesce(log.Printf, chrt, valsCopy, "", true, nil)
 }
 
 func copyValues(vals map[string]interface{}) (Values, error) {
@@ -96,13 +122,16 @@ type printFn func(format string, v ...interface{})
 // Note, the merge argument specifies whether this is being used by MergeValues
 // or CoalesceValues. Coalescing removes null values and their keys in some
 // situations while merging keeps the null values.
-func coalesce(printf printFn, ch *chart.Chart, dest map[string]interface{}, prefix string, merge bool) (map[string]interface{}, error) {
-	coalesceValues(printf, ch, dest, prefix, merge)
-	return coalesceDeps(printf, ch, dest, prefix, merge)
+//
+// The mergeKeys argument maps the full keys of the lists that are merged by
+// key to the name of the identifying field of their elements.
+func coalesce(printf printFn, ch *chart.Chart, dest map[string]interface{}, prefix string, merge bool, mergeKeys map[string]string) (map[string]interface{}, error) {
+	coalesceValues(printf, ch, dest, prefix, merge, mergeKeys)
+	return coalesceDeps(printf, ch, dest, prefix, merge, mergeKeys)
 }
 
 // coalesceDeps coalesces the dependencies of the given chart.
-func coalesceDeps(printf printFn, chrt *chart.Chart, dest map[string]interface{}, prefix string, merge bool) (map[string]interface{}, error) {
+func coalesceDeps(printf printFn, chrt *chart.Chart, dest map[string]interface{}, prefix string, merge bool, mergeKeys map[string]string) (map[string]interface{}, error) {
 	for _, subchart := range chrt.Dependencies() {
 		if c, ok := dest[subchart.Name()]; !ok {
 			// If dest doesn't already have the key, create it.
@@ -117,7 +146,7 @@ func coalesceDeps(printf printFn, chrt *chart.Chart, dest map[string]interface{}
 			coalesceGlobals(printf, dvmap, dest, subPrefix, merge)
 			// Now coalesce the rest of the values.
 			var err error
-			dest[subchart.Name()], err = coalesce(printf, subchart, dvmap, subPrefix, merge)
+			dest[subchart.Name()], err = coalesce(printf, subchart, dvmap, subPrefix, merge, mergeKeys)
 			if err != nil {
 				return dest, err
 			}
@@ -166,7 +195,7 @@ func coalesceGlobals(printf printFn, dest, src map[string]interface{}, prefix st
 					// In this location coalesceTablesFullKey should always have
 					// merge set to true. The output of coalesceGlobals is run
 					// through coalesce where any nils will be removed.
-					coalesceTablesFullKey(printf, vv, destvmap, subPrefix, true)
+					coalesceTablesFullKey(printf, vv, destvmap, subPrefix, true, nil)
 					dg[key] = vv
 				}
 			}
@@ -190,7 +219,7 @@ func copyMap(src map[string]interface{}) map[string]interface{} {
 // coalesceValues builds up a values map for a particular chart.
 //
 // Values in v will override the values in the chart.
-func coalesceValues(printf printFn, c *chart.Chart, v map[string]interface{}, prefix string, merge bool) {
+func coalesceValues(printf printFn, c *chart.Chart, v map[string]interface{}, prefix string, merge bool, mergeKeys map[string]string) {
 	subPrefix := concatPrefix(prefix, c.Metadata.Name)
 
 	// Using c.Values directly when coalescing a table can cause problems where
@@ -240,8 +269,10 @@ func coalesceValues(printf printFn, c *chart.Chart, v map[string]interface{}, pr
 
 					// Because v has higher precedence than nv, dest values override src
 					// values.
-					coalesceTablesFullKey(printf, dest, src, concatPrefix(subPrefix, key), merge)
+					coalesceTablesFullKey(printf, dest, src, concatPrefix(subPrefix, key), merge, mergeKeys)
 				}
+			} else if fullkey := concatPrefix(subPrefix, key); mergeKeys[fullkey] != "" {
+				v[key] = coalesceListsByKey(printf, value, val, fullkey, merge, mergeKeys)
 			}
 		} else {
 			// If the key is not in v, copy it from nv.
@@ -263,17 +294,27 @@ func childChartMergeTrue(chrt *chart.Chart, key string, merge bool) bool {
 //
 // dest is considered authoritative.
 func CoalesceTables(dst, src map[string]interface{}) map[string]interface{} {
-	return coalesceTablesFullKey(log.Printf, dst, src, "", false)
+	return coalesceTablesFullKey(log.Printf, dst, src, "", false, nil)
+}
+
+// CoalesceTablesWithListMerge merges a source map into a destination map as
+// CoalesceTables does, except for the lists at the dot separated paths in
+// mergeKeys, whose elements are merged by the field named in mergeKeys. See
+// CoalesceValuesWithListMerge.
+//
+// dest is considered authoritative.
+func CoalesceTablesWithListMerge(dst, src map[string]interface{}, mergeKeys map[string]string) map[string]interface{} {
+	return coalesceTablesFullKey(log.Printf, dst, src, "", false, mergeKeys)
 }
 
 func MergeTables(dst, src map[string]interface{}) map[string]interface{} {
-	return coalesceTablesFullKey(log.Printf, dst, src, "", true)
+	return coalesceTablesFullKey(log.Printf, dst, src, "", true, nil)
 }
 
 // coalesceTablesFullKey merges a source map into a destination map.
 //
 // dest is considered authoritative.
-func coalesceTablesFullKey(printf printFn, dst, src map[string]interface{}, prefix string, merge bool) map[string]interface{} {
+func coalesceTablesFullKey(printf printFn, dst, src map[string]interface{}, prefix string, merge bool, mergeKeys map[string]string) map[string]interface{} {
 	// When --reuse-values is set but there are no modifications yet, return new values
 	if src == nil {
 		return dst
@@ -296,13 +337,89 @@ func coalesceTablesFullKey(printf printFn, dst, src map[string]interface{}, pref
 			dst[key] = val
 		} else if istable(val) {
 			if istable(dv) {
-				coalesceTablesFullKey(printf, dv.(map[string]interface{}), val.(map[string]interface{}), fullkey, merge)
+				coalesceTablesFullKey(printf, dv.(map[string]interface{}), val.(map[string]interface{}), fullkey, merge, mergeKeys)
 			} else {
 				printf("warning: cannot overwrite table with non table for %s (%v)", fullkey, val)
 			}
 		} else if istable(dv) && val != nil {
 			printf("warning: destination for %s is a table. Ignoring non-table value (%v)", fullkey, val)
+		} else if mergeKeys[fullkey] != "" {
+			dst[key] = coalesceListsByKey(printf, dv, val, fullkey, merge, mergeKeys)
 		}
 	}
 	return dst
 }
+
+// coalesceListsByKey merges the elements of the list src into those of the
+// list dst with the same value of the field mergeKeys[fullkey], and returns
+// the merged list. The elements of src come first, in their order, followed
+// by the other elements of dst.
+//
+// dst is returned as is when either value is not a list of tables holding the
+// field.
+func coalesceListsByKey(printf printFn, dst, src interface{}, fullkey string, merge bool, mergeKeys map[string]string) interface{} {
+	key := mergeKeys[fullkey]
+	dstElems, ok := listElementsByKey(dst, key)
+	if !ok {
+		if dst != nil {
+			printf("warning: cannot merge %s by %q: not a list of tables with the key. Replacing the list", fullkey, key)
+		}
+		return dst
+	}
+	srcElems, ok := listElementsByKey(src, key)
+	if !ok {
+		if src != nil {
+			printf("warning: cannot merge %s by %q: not a list of tables with the key. Replacing the list", fullkey, key)
+		}
+		return dst
+	}
+
+	merged := make([]interface{}, 0, len(srcElems)+len(dstElems))
+	matched := map[int]bool{}
+	for _, s := range srcElems {
+		elem := s.table
+		for i, d := range dstElems {
+			if !matched[i] && d.id == s.id {
+				matched[i] = true
+				elem = coalesceTablesFullKey(printf, d.table, s.table, fullkey, merge, mergeKeys)
+				break
+			}
+		}
+		merged = append(merged, elem)
+	}
+	for i, d := range dstElems {
+		if !matched[i] {
+			merged = append(merged, d.table)
+		}
+	}
+	return merged
+}
+
+type keyedTable struct {
+	id    string
+	table map[string]interface{}
+}
+
+// listElementsByKey returns the elements of the list v along with the value
+// of their field key, or false if v is not a list of tables holding the field.
+func listElementsByKey(v interface{}, key string) ([]keyedTable, bool) {
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	elems := make([]keyedTable, 0, len(list))
+	for _, e := range list {
+		table, ok := e.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		id, ok := table[key]
+		if !ok || id == nil {
+			return nil, false
+		}
+		// Identifiers are compared as strings, as numbers may be decoded to
+		// different types depending on where the values come from.
+		elems = append(elems, keyedTable{id: fmt.Sprint(id), table: table})
+	}
+	return elems, true
+}
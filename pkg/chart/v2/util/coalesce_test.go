@@ -705,7 +705,7 @@ func TestCoalesceValuesWarnings(t *testing.T) {
 		warnings = append(warnings, fmt.Sprintf(format, v...))
 	}
 
-	_, err := coalesce(printf, c, vals, "", false)
+	_, err := coalesce(printf, c, vals, "", false, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -717,6 +717,121 @@ func TestCoalesceValuesWarnings(t *testing.T) {
 
 }
 
+func TestCoalesceValuesWithListMerge(t *testing.T) {
+	c := withDeps(&chart.Chart{
+		Metadata: &chart.Metadata{Name: "moby"},
+		Values: map[string]interface{}{
+			"ports": []interface{}{
+				map[string]interface{}{"name": "http", "port": 80, "protocol": "TCP"},
+				map[string]interface{}{"name": "metrics", "port": 9090},
+			},
+			"args": []interface{}{"--verbose"},
+		},
+	},
+		&chart.Chart{
+			Metadata: &chart.Metadata{Name: "pequod"},
+			Values: map[string]interface{}{
+				"containers": []interface{}{
+					map[string]interface{}{
+						"name": "ahab",
+						"env": []interface{}{
+							map[string]interface{}{"name": "SCOPE", "value": "whale"},
+						},
+					},
+				},
+			},
+		},
+	)
+
+	vals := map[string]interface{}{
+		"ports": []interface{}{
+			map[string]interface{}{"name": "https", "port": 443},
+			map[string]interface{}{"name": "http", "port": 8080},
+			map[string]interface{}{"name": "metrics", "port": nil},
+		},
+		"args": []interface{}{"--quiet"},
+		"pequod": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{
+					"name": "ahab",
+					"env": []interface{}{
+						map[string]interface{}{"name": "BOAT", "value": "pequod"},
+					},
+				},
+			},
+		},
+	}
+	mergeKeys := map[string]string{
+		"ports":                 "name",
+		"pequod.containers":     "name",
+		"pequod.containers.env": "name",
+	}
+
+	v, err := CoalesceValuesWithListMerge(c, vals, mergeKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "http", "port": 8080, "protocol": "TCP"},
+		map[string]interface{}{"name": "metrics"},
+		map[string]interface{}{"name": "https", "port": 443},
+	}, v["ports"])
+	// Lists without a merge key are replaced.
+	assert.Equal(t, []interface{}{"--quiet"}, v["args"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"name": "ahab",
+			"env": []interface{}{
+				map[string]interface{}{"name": "SCOPE", "value": "whale"},
+				map[string]interface{}{"name": "BOAT", "value": "pequod"},
+			},
+		},
+	}, v["pequod"].(map[string]interface{})["containers"])
+
+	// The lists are replaced without merge keys.
+	v, err = CoalesceValues(c, vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, vals["ports"], v["ports"])
+}
+
+func TestCoalesceTablesWithListMerge(t *testing.T) {
+	dst := map[string]interface{}{
+		"service": map[string]interface{}{
+			"ports": []interface{}{
+				map[string]interface{}{"name": "http", "port": 8080},
+			},
+			"hosts": []interface{}{"example.com"},
+		},
+	}
+	src := map[string]interface{}{
+		"service": map[string]interface{}{
+			"ports": []interface{}{
+				map[string]interface{}{"name": "http", "port": 80, "protocol": "TCP"},
+				map[string]interface{}{"name": "grpc", "port": 9000},
+			},
+			"hosts": []interface{}{
+				map[string]interface{}{"name": "example.org"},
+			},
+		},
+	}
+
+	CoalesceTablesWithListMerge(dst, src, map[string]string{
+		"service.ports": "name",
+		"service.hosts": "name",
+	})
+
+	service := dst["service"].(map[string]interface{})
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "http", "port": 8080, "protocol": "TCP"},
+		map[string]interface{}{"name": "grpc", "port": 9000},
+	}, service["ports"])
+	// Lists whose elements cannot be merged by key are replaced.
+	assert.Equal(t, []interface{}{"example.com"}, service["hosts"])
+}
+
 func TestConcatPrefix(t *testing.T) {
 	assert.Equal(t, "b", concatPrefix("", "b"))
 	assert.Equal(t, "a.b", concatPrefix("a", "b"))
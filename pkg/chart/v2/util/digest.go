@@ -0,0 +1,67 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"path/filepath"
+	"sort"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+)
+
+// Digest returns the hex encoded SHA256 digest of the content of a chart and
+// its dependencies.
+//
+// The digest covers the files of the archive written by Save, so it does not
+// depend on the compression, file order or modification times of the archive
+// the chart was loaded from: two charts packaged from the same sources have
+// the same digest.
+func Digest(ch *chart.Chart) (string, error) {
+	files := map[string][]byte{}
+	err := walkArchiveFiles(ch, "", func(name string, data []byte) error {
+		files[filepath.ToSlash(name)] = data
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Names and contents are length prefixed for the boundaries between
+	// files not to be ambiguous.
+	h := sha256.New()
+	for _, name := range names {
+		writeDigestField(h, []byte(name))
+		writeDigestField(h, files[name])
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func writeDigestField(w io.Writer, b []byte) {
+	var size [8]byte
+	binary.BigEndian.PutUint64(size[:], uint64(len(b)))
+	w.Write(size[:])
+	w.Write(b)
+}
@@ -0,0 +1,135 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"helm.sh/helm/v4/pkg/chart/v2/loader"
+)
+
+func TestDigest(t *testing.T) {
+	c, err := loader.Load("testdata/frobnitz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := Digest(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expected) != 64 {
+		t.Fatalf("expected a hex encoded SHA256 digest, got %q", expected)
+	}
+
+	where, err := Save(c, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Repackage the archive with its files in reverse order, other
+	// modification times and another compression level.
+	repackaged := filepath.Join(t.TempDir(), filepath.Base(where))
+	if err := repackage(where, repackaged); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, archive := range []string{where, repackaged} {
+		loaded, err := loader.Load(archive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		digest, err := Digest(loaded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if digest != expected {
+			t.Errorf("expected the digest of %s to be %s, got %s", archive, expected, digest)
+		}
+	}
+
+	c.Templates[0].Data = append(c.Templates[0].Data, '\n')
+	digest, err := Digest(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest == expected {
+		t.Error("expected the digest to change along with a template")
+	}
+}
+
+func repackage(src, dest string) error {
+	raw, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	type entry struct {
+		header *tar.Header
+		data   []byte
+	}
+	var entries []entry
+	tr := tar.NewReader(zr)
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{hd, data})
+	}
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	zw, err := gzip.NewWriterLevel(out, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	zw.ModTime = time.Unix(0, 0)
+	tw := tar.NewWriter(zw)
+	for i := len(entries) - 1; i >= 0; i-- {
+		hd := entries[i].header
+		hd.ModTime = hd.ModTime.Add(-48 * time.Hour)
+		if err := tw.WriteHeader(hd); err != nil {
+			return err
+		}
+		if _, err := tw.Write(entries[i].data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
@@ -0,0 +1,110 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// containerListKeys are the keys of the lists of containers whose images are
+// collected, in the pod specs of the workloads and in custom resources such
+// as Tekton tasks.
+var containerListKeys = map[string]bool{
+	"containers":          true,
+	"initContainers":      true,
+	"ephemeralContainers": true,
+	"sidecars":            true,
+	"steps":               true,
+}
+
+// containerKeys are the keys of single containers whose images are
+// collected, as in the templates of Argo workflows.
+var containerKeys = map[string]bool{
+	"container":     true,
+	"initContainer": true,
+}
+
+var manifestSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+// ContainerImages returns the container images referenced by the rendered
+// manifests of a chart, deduplicated and sorted.
+//
+// The images are those of the containers, init containers and ephemeral
+// containers of the pod specs found at any depth in the documents, which
+// covers the workload kinds as well as the custom resources embedding pod
+// templates. The images of custom resources whose "spec.image" names the
+// image they run, such as those managed by operators, are returned too.
+// Documents that are not valid YAML or that have no container are skipped.
+func ContainerImages(manifest string) []string {
+	found := map[string]bool{}
+	for _, doc := range manifestSeparator.Split(manifest, -1) {
+		var obj map[string]interface{}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil || obj == nil {
+			continue
+		}
+		if spec, ok := obj["spec"].(map[string]interface{}); ok {
+			addImage(found, spec)
+		}
+		collectImages(found, obj)
+	}
+
+	images := make([]string, 0, len(found))
+	for image := range found {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+	return images
+}
+
+// collectImages adds the images of the containers found in v to found.
+func collectImages(found map[string]bool, v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			switch {
+			case containerListKeys[key]:
+				if list, ok := value.([]interface{}); ok {
+					for _, container := range list {
+						if container, ok := container.(map[string]interface{}); ok {
+							addImage(found, container)
+						}
+					}
+				}
+			case containerKeys[key]:
+				if container, ok := value.(map[string]interface{}); ok {
+					addImage(found, container)
+				}
+			}
+			collectImages(found, value)
+		}
+	case []interface{}:
+		for _, value := range v {
+			collectImages(found, value)
+		}
+	}
+}
+
+// addImage adds the image of container to found, if set to a string.
+func addImage(found map[string]bool, container map[string]interface{}) {
+	if image, ok := container["image"].(string); ok && strings.TrimSpace(image) != "" {
+		found[strings.TrimSpace(image)] = true
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestContainerImages(t *testing.T) {
+	manifest := `---
+# Source: app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: migrate
+        image: registry.example.com/web-migrate:1.2.0
+      containers:
+      - name: web
+        image: registry.example.com/web:1.2.0
+      - name: proxy
+        image: nginx:1.27
+---
+# Source: app/templates/cronjob.yaml
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: backup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: backup
+            image: "  nginx:1.27 "
+---
+# Source: app/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  containers: "image: ignored"
+  image: ignored
+---
+# Source: app/templates/prometheus.yaml
+apiVersion: monitoring.coreos.com/v1
+kind: Prometheus
+metadata:
+  name: prometheus
+spec:
+  image: quay.io/prometheus/prometheus:v3.0.0
+---
+# Source: app/templates/workflow.yaml
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  name: hello
+spec:
+  templates:
+  - name: hello
+    container:
+      image: busybox@sha256:0123456789abcdef
+---
+# Source: app/templates/task.yaml
+apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  steps:
+  - name: build
+    image: golang:1.24
+---
+# Source: app/templates/invalid.yaml
+this: is: not valid
+---
+- a list
+---
+`
+	expected := []string{
+		"busybox@sha256:0123456789abcdef",
+		"golang:1.24",
+		"nginx:1.27",
+		"quay.io/prometheus/prometheus:v3.0.0",
+		"registry.example.com/web-migrate:1.2.0",
+		"registry.example.com/web:1.2.0",
+	}
+	if images := ContainerImages(manifest); !reflect.DeepEqual(images, expected) {
+		t.Errorf("expected images %v, got %v", expected, images)
+	}
+
+	if images := ContainerImages(""); len(images) != 0 {
+		t.Errorf("expected no images, got %v", images)
+	}
+}
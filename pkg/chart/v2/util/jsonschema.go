@@ -24,6 +24,8 @@ import (
 	"strings"
 
 	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
 
 	chart "helm.sh/helm/v4/pkg/chart/v2"
 )
@@ -111,3 +113,50 @@ func (e JSONSchemaValidationError) Error() string {
 	// The extra new line is needed for when there are sub-charts.
 	return errStr + "\n"
 }
+
+// SchemaViolation is a single way in which values do not match a schema.
+type SchemaViolation struct {
+	// Path is the JSON pointer of the offending value, for example
+	// "/image/tag". It is empty for the values as a whole.
+	Path string
+	// Message describes the violation.
+	Message string
+}
+
+// Violations lists the individual schema violations that make up the error,
+// in the order they were found.
+func (e JSONSchemaValidationError) Violations() []SchemaViolation {
+	var verr *jsonschema.ValidationError
+	if !errors.As(e.embeddedErr, &verr) {
+		return []SchemaViolation{{Message: e.Error()}}
+	}
+
+	printer := message.NewPrinter(language.English)
+	var violations []SchemaViolation
+	var walk func(*jsonschema.ValidationError)
+	walk = func(ve *jsonschema.ValidationError) {
+		if len(ve.Causes) == 0 {
+			violations = append(violations, SchemaViolation{
+				Path:    jsonPointer(ve.InstanceLocation),
+				Message: ve.ErrorKind.LocalizedString(printer),
+			})
+			return
+		}
+		for _, cause := range ve.Causes {
+			walk(cause)
+		}
+	}
+	walk(verr)
+	return violations
+}
+
+// jsonPointer formats the tokens of an instance location as a JSON pointer.
+func jsonPointer(tokens []string) string {
+	var sb strings.Builder
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+	for _, tok := range tokens {
+		sb.WriteByte('/')
+		sb.WriteString(escaper.Replace(tok))
+	}
+	return sb.String()
+}
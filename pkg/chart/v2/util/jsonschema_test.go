@@ -17,7 +17,10 @@ limitations under the License.
 package util
 
 import (
+	"errors"
 	"os"
+	"reflect"
+	"sort"
 	"testing"
 
 	chart "helm.sh/helm/v4/pkg/chart/v2"
@@ -245,3 +248,42 @@ func TestValidateAgainstSchema2020Negative(t *testing.T) {
 		t.Errorf("Error string :\n`%s`\ndoes not match expected\n`%s`", errString, expectedErrString)
 	}
 }
+
+func TestJSONSchemaValidationErrorViolations(t *testing.T) {
+	schema := []byte(`{
+  "type": "object",
+  "required": ["name"],
+  "properties": {
+    "image": {
+      "type": "object",
+      "properties": {
+        "tag": {"type": "string"},
+        "a/b": {"type": "string"}
+      }
+    }
+  }
+}`)
+	vals := map[string]interface{}{
+		"image": map[string]interface{}{
+			"tag": 1,
+			"a/b": true,
+		},
+	}
+
+	err := ValidateAgainstSingleSchema(vals, schema)
+	var verr JSONSchemaValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected a JSONSchemaValidationError, got %v", err)
+	}
+
+	violations := verr.Violations()
+	sort.Slice(violations, func(i, j int) bool { return violations[i].Path < violations[j].Path })
+	expected := []SchemaViolation{
+		{Path: "", Message: "missing property 'name'"},
+		{Path: "/image/a~1b", Message: "got boolean, want string"},
+		{Path: "/image/tag", Message: "got number, want string"},
+	}
+	if !reflect.DeepEqual(violations, expected) {
+		t.Errorf("expected violations %v, got %v", expected, violations)
+	}
+}
@@ -0,0 +1,152 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"reflect"
+	"strings"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+)
+
+// ValueSources maps the dot separated path of each leaf value, such as
+// "image.tag", to the source the value comes from, such as the path of a
+// values file or a --set flag. Leaf values are those that are not tables,
+// including lists.
+type ValueSources map[string]string
+
+// Record records source as the source of the leaf values of after that are
+// set by set, missing from before or differ from it, and forgets the leaf
+// values that are missing from after. It is called with the values before
+// and after the values set by source are merged in, so that the last source
+// setting a value is recorded even when it sets it to the same value.
+func (s ValueSources) Record(before, after, set map[string]interface{}, source string) {
+	beforeLeaves, afterLeaves, setLeaves := leaves(before, ""), leaves(after, ""), leaves(set, "")
+	for path := range beforeLeaves {
+		if _, ok := afterLeaves[path]; !ok {
+			delete(s, path)
+		}
+	}
+	for path, v := range afterLeaves {
+		_, isSet := setLeaves[path]
+		if old, ok := beforeLeaves[path]; isSet || !ok || !reflect.DeepEqual(old, v) {
+			s[path] = source
+		}
+	}
+}
+
+// CoalesceValuesWithSources coalesces the values of a chart and vals as
+// CoalesceValues does, and returns the source of each leaf value of the
+// result.
+//
+// The sources of the values of vals are looked up in sources, and the
+// global values of vals also give the sources of their copies within the
+// values of dependencies. The other values come from the values file of the
+// chart or of one of its dependencies, and are attributed to the chart whose
+// value wins, as in "values.yaml of chart mychart".
+func CoalesceValuesWithSources(chrt *chart.Chart, vals map[string]interface{}, sources ValueSources) (Values, ValueSources, error) {
+	coalesced, err := CoalesceValues(chrt, vals)
+	if err != nil {
+		return coalesced, nil, err
+	}
+
+	result := ValueSources{}
+	for path := range leaves(coalesced, "") {
+		if source, ok := userSource(sources, path); ok {
+			result[path] = source
+			continue
+		}
+		if c := defaultSource(chrt, strings.Split(path, ".")); c != nil {
+			result[path] = "values.yaml of chart " + c.Name()
+		}
+	}
+	return coalesced, result, nil
+}
+
+// userSource returns the source of the user supplied value at path, which
+// is either given at path or, within the global values of a dependency, as
+// a global value of the parent chart.
+func userSource(sources ValueSources, path string) (string, bool) {
+	if source, ok := sources[path]; ok {
+		return source, true
+	}
+	segments := strings.Split(path, ".")
+	for i := 1; i < len(segments); i++ {
+		if segments[i] == GlobalKey {
+			if source, ok := sources[strings.Join(segments[i:], ".")]; ok {
+				return source, true
+			}
+		}
+	}
+	return "", false
+}
+
+// defaultSource returns the chart whose values file gives the value at path
+// of the values of c, if any. The values of a chart win over those of its
+// dependencies, and so do its global values.
+func defaultSource(c *chart.Chart, path []string) *chart.Chart {
+	if hasLeaf(c.Values, path) {
+		return c
+	}
+	if len(path) < 2 {
+		return nil
+	}
+	for _, dep := range c.Dependencies() {
+		if dep.Name() != path[0] {
+			continue
+		}
+		if path[1] == GlobalKey && hasLeaf(c.Values, path[1:]) {
+			return c
+		}
+		return defaultSource(dep, path[1:])
+	}
+	return nil
+}
+
+// hasLeaf returns whether vals holds a leaf value at path.
+func hasLeaf(vals map[string]interface{}, path []string) bool {
+	for i, key := range path {
+		v, ok := vals[key]
+		if !ok || v == nil {
+			return false
+		}
+		if i == len(path)-1 {
+			_, isTable := v.(map[string]interface{})
+			return !isTable
+		}
+		if vals, ok = v.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return false
+}
+
+// leaves returns the leaf values of vals by their dot separated path.
+func leaves(vals map[string]interface{}, prefix string) map[string]interface{} {
+	result := map[string]interface{}{}
+	for key, v := range vals {
+		path := concatPrefix(prefix, key)
+		if table, ok := v.(map[string]interface{}); ok && len(table) > 0 {
+			for p, leaf := range leaves(table, path) {
+				result[p] = leaf
+			}
+			continue
+		}
+		result[path] = v
+	}
+	return result
+}
@@ -0,0 +1,99 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+)
+
+func TestValueSourcesRecord(t *testing.T) {
+	sources := ValueSources{}
+	sources.Record(map[string]interface{}{}, map[string]interface{}{
+		"image":    map[string]interface{}{"repository": "nginx", "tag": "1.0"},
+		"replicas": 1,
+		"name":     "web",
+	}, nil, "values.yaml")
+	sources.Record(map[string]interface{}{
+		"image":    map[string]interface{}{"repository": "nginx", "tag": "1.0"},
+		"replicas": 1,
+		"name":     "web",
+	}, map[string]interface{}{
+		"image": map[string]interface{}{"repository": "nginx", "tag": "1.1"},
+		"ports": []interface{}{80},
+		"name":  "web",
+	}, map[string]interface{}{
+		"name": "web",
+	}, "override.yaml")
+
+	assert.Equal(t, ValueSources{
+		"image.repository": "values.yaml",
+		"image.tag":        "override.yaml",
+		"ports":            "override.yaml",
+		// The last source setting a value is recorded, even when it sets
+		// it to the same value.
+		"name": "override.yaml",
+	}, sources)
+}
+
+func TestCoalesceValuesWithSources(t *testing.T) {
+	c := withDeps(&chart.Chart{
+		Metadata: &chart.Metadata{Name: "parent"},
+		Values: map[string]interface{}{
+			"name": "parent",
+			"sub": map[string]interface{}{
+				"port": 8080,
+			},
+			"global": map[string]interface{}{
+				"region": "eu",
+			},
+		},
+	}, &chart.Chart{
+		Metadata: &chart.Metadata{Name: "sub"},
+		Values: map[string]interface{}{
+			"port":    80,
+			"replica": 1,
+			"global": map[string]interface{}{
+				"region": "us",
+				"zone":   "a",
+			},
+		},
+	})
+
+	vals := map[string]interface{}{
+		"name":   "mine",
+		"global": map[string]interface{}{"env": "prod"},
+	}
+	sources := ValueSources{"name": "my-values.yaml", "global.env": "--set global.env=prod"}
+
+	coalesced, result, err := CoalesceValuesWithSources(c, vals, sources)
+	assert.NoError(t, err)
+	assert.Equal(t, "mine", coalesced["name"])
+	assert.Equal(t, ValueSources{
+		"name":              "my-values.yaml",
+		"global.env":        "--set global.env=prod",
+		"global.region":     "values.yaml of chart parent",
+		"sub.port":          "values.yaml of chart parent",
+		"sub.replica":       "values.yaml of chart sub",
+		"sub.global.env":    "--set global.env=prod",
+		"sub.global.region": "values.yaml of chart parent",
+		"sub.global.zone":   "values.yaml of chart sub",
+	}, result)
+}
@@ -154,6 +154,14 @@ func Save(c *chart.Chart, outDir string) (string, error) {
 }
 
 func writeTarContents(out *tar.Writer, c *chart.Chart, prefix string) error {
+	return walkArchiveFiles(c, prefix, func(name string, data []byte) error {
+		return writeToTar(out, name, data)
+	})
+}
+
+// walkArchiveFiles calls fn with the path and content of each file of the
+// archive of c, as written by Save, with the paths prefixed by prefix.
+func walkArchiveFiles(c *chart.Chart, prefix string, fn func(name string, data []byte) error) error {
 	err := validateName(c.Name())
 	if err != nil {
 		return err
@@ -174,7 +182,7 @@ func writeTarContents(out *tar.Writer, c *chart.Chart, prefix string) error {
 	if err != nil {
 		return err
 	}
-	if err := writeToTar(out, filepath.Join(base, ChartfileName), cdata); err != nil {
+	if err := fn(filepath.Join(base, ChartfileName), cdata); err != nil {
 		return err
 	}
 
@@ -186,7 +194,7 @@ func writeTarContents(out *tar.Writer, c *chart.Chart, prefix string) error {
 			if err != nil {
 				return err
 			}
-			if err := writeToTar(out, filepath.Join(base, "Chart.lock"), ldata); err != nil {
+			if err := fn(filepath.Join(base, "Chart.lock"), ldata); err != nil {
 				return err
 			}
 		}
@@ -195,7 +203,7 @@ func writeTarContents(out *tar.Writer, c *chart.Chart, prefix string) error {
 	// Save values.yaml
 	for _, f := range c.Raw {
 		if f.Name == ValuesfileName {
-			if err := writeToTar(out, filepath.Join(base, ValuesfileName), f.Data); err != nil {
+			if err := fn(filepath.Join(base, ValuesfileName), f.Data); err != nil {
 				return err
 			}
 		}
@@ -206,7 +214,7 @@ func writeTarContents(out *tar.Writer, c *chart.Chart, prefix string) error {
 		if !json.Valid(c.Schema) {
 			return errors.New("invalid JSON in " + SchemafileName)
 		}
-		if err := writeToTar(out, filepath.Join(base, SchemafileName), c.Schema); err != nil {
+		if err := fn(filepath.Join(base, SchemafileName), c.Schema); err != nil {
 			return err
 		}
 	}
@@ -214,7 +222,7 @@ func writeTarContents(out *tar.Writer, c *chart.Chart, prefix string) error {
 	// Save templates
 	for _, f := range c.Templates {
 		n := filepath.Join(base, f.Name)
-		if err := writeToTar(out, n, f.Data); err != nil {
+		if err := fn(n, f.Data); err != nil {
 			return err
 		}
 	}
@@ -222,14 +230,14 @@ func writeTarContents(out *tar.Writer, c *chart.Chart, prefix string) error {
 	// Save files
 	for _, f := range c.Files {
 		n := filepath.Join(base, f.Name)
-		if err := writeToTar(out, n, f.Data); err != nil {
+		if err := fn(n, f.Data); err != nil {
 			return err
 		}
 	}
 
 	// Save dependencies
 	for _, dep := range c.Dependencies() {
-		if err := writeTarContents(out, dep, filepath.Join(base, ChartsDir)); err != nil {
+		if err := walkArchiveFiles(dep, filepath.Join(base, ChartsDir), fn); err != nil {
 			return err
 		}
 	}
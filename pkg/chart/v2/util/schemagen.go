@@ -0,0 +1,175 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaAnnotation is the marker of the comments refining the schema
+// generated for a value, as in "# @schema required: true; minimum: 1".
+const SchemaAnnotation = "@schema"
+
+// schemaDraft is the JSON schema version of generated schemas.
+const schemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// GenerateValuesSchema generates a JSON schema from the content of a
+// values.yaml file.
+//
+// The type of each value is inferred from its default, and maps are described
+// along with their properties. A comment starting with SchemaAnnotation, on
+// the line of a key or the lines above it, holds semicolon separated
+// "keyword: value" pairs that are added to the schema of the value, replacing
+// the inferred ones. The "required" keyword is a boolean marking the key as
+// required within its map.
+func GenerateValuesSchema(data []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("unable to parse values: %w", err)
+	}
+
+	schema := map[string]interface{}{"type": "object"}
+	if len(doc.Content) > 0 {
+		root := doc.Content[0]
+		if root.Kind != yaml.MappingNode {
+			if root.Tag != "!!null" {
+				return nil, errors.New("values must be a map")
+			}
+		} else {
+			var err error
+			if schema, err = nodeSchema(root); err != nil {
+				return nil, err
+			}
+		}
+	}
+	schema["$schema"] = schemaDraft
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(schema); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// nodeSchema returns the schema inferred from the YAML node n.
+func nodeSchema(n *yaml.Node) (map[string]interface{}, error) {
+	schema := map[string]interface{}{}
+	switch n.Kind {
+	case yaml.AliasNode:
+		return nodeSchema(n.Alias)
+	case yaml.MappingNode:
+		schema["type"] = "object"
+		properties := map[string]interface{}{}
+		var required []interface{}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if key.Tag == "!!merge" {
+				continue
+			}
+			property, err := nodeSchema(value)
+			if err != nil {
+				return nil, err
+			}
+			isRequired, err := annotate(property, key, value)
+			if err != nil {
+				return nil, err
+			}
+			if isRequired {
+				required = append(required, key.Value)
+			}
+			properties[key.Value] = property
+		}
+		if len(properties) > 0 {
+			schema["properties"] = properties
+		}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+	case yaml.SequenceNode:
+		schema["type"] = "array"
+		if len(n.Content) > 0 {
+			items, err := nodeSchema(n.Content[0])
+			if err != nil {
+				return nil, err
+			}
+			schema["items"] = items
+		}
+	case yaml.ScalarNode:
+		switch n.ShortTag() {
+		case "!!str", "!!binary", "!!timestamp":
+			schema["type"] = "string"
+		case "!!int":
+			schema["type"] = "integer"
+		case "!!float":
+			schema["type"] = "number"
+		case "!!bool":
+			schema["type"] = "boolean"
+		}
+		// Null defaults do not tell the type of the value.
+	}
+	return schema, nil
+}
+
+// annotate adds the keywords of the schema annotations in the comments of the
+// key and value nodes of a map entry to schema. It returns whether the key is
+// required.
+func annotate(schema map[string]interface{}, key, value *yaml.Node) (bool, error) {
+	required := false
+	comments := []string{key.HeadComment, key.LineComment, value.LineComment}
+	for _, comment := range comments {
+		for _, line := range strings.Split(comment, "\n") {
+			line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#"))
+			annotation, ok := strings.CutPrefix(line, SchemaAnnotation)
+			if !ok {
+				continue
+			}
+			for _, pair := range strings.Split(annotation, ";") {
+				if strings.TrimSpace(pair) == "" {
+					continue
+				}
+				keyword, raw, ok := strings.Cut(pair, ":")
+				keyword = strings.TrimSpace(keyword)
+				if !ok || keyword == "" {
+					return false, fmt.Errorf("invalid schema annotation for %q on line %d: %q is not a \"keyword: value\" pair", key.Value, key.Line, strings.TrimSpace(pair))
+				}
+				var v interface{}
+				if err := yaml.Unmarshal([]byte(raw), &v); err != nil {
+					return false, fmt.Errorf("invalid schema annotation for %q on line %d: %w", key.Value, key.Line, err)
+				}
+				if keyword == "required" {
+					b, ok := v.(bool)
+					if !ok {
+						return false, fmt.Errorf("invalid schema annotation for %q on line %d: required must be true or false", key.Value, key.Line)
+					}
+					required = b
+					continue
+				}
+				schema[keyword] = v
+			}
+		}
+	}
+	return required, nil
+}
@@ -0,0 +1,146 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGenerateValuesSchema(t *testing.T) {
+	values := `# @schema required: true; minimum: 1
+replicaCount: 1
+
+image:
+  # The image to run.
+  # @schema required: true
+  repository: nginx
+  pullPolicy: IfNotPresent # @schema enum: [Always, IfNotPresent, Never]
+  tag: ""
+
+ratio: 0.5
+enabled: false
+nameOverride:
+
+ports:
+  - name: http
+    port: 80
+tolerations: []
+`
+	expected := `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "required": ["replicaCount"],
+  "properties": {
+    "replicaCount": {"type": "integer", "minimum": 1},
+    "image": {
+      "type": "object",
+      "required": ["repository"],
+      "properties": {
+        "repository": {"type": "string"},
+        "pullPolicy": {"type": "string", "enum": ["Always", "IfNotPresent", "Never"]},
+        "tag": {"type": "string"}
+      }
+    },
+    "ratio": {"type": "number"},
+    "enabled": {"type": "boolean"},
+    "nameOverride": {},
+    "ports": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "name": {"type": "string"},
+          "port": {"type": "integer"}
+        }
+      }
+    },
+    "tolerations": {"type": "array"}
+  }
+}`
+
+	schema, err := GenerateValuesSchema([]byte(values))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, want interface{}
+	if err := json.Unmarshal(schema, &got); err != nil {
+		t.Fatalf("invalid schema generated: %s\n%s", err, schema)
+	}
+	if err := json.Unmarshal([]byte(expected), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected schema generated:\n%s", schema)
+	}
+
+	// The values validate against the generated schema.
+	vals, err := ReadValues([]byte(values))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateAgainstSingleSchema(vals, schema); err != nil {
+		t.Errorf("expected the values to be valid: %s", err)
+	}
+	vals["replicaCount"] = 0
+	if err := ValidateAgainstSingleSchema(vals, schema); err == nil {
+		t.Error("expected the values to be invalid")
+	}
+}
+
+func TestGenerateValuesSchemaEmpty(t *testing.T) {
+	schema, err := GenerateValuesSchema(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "{\n  \"$schema\": \"http://json-schema.org/draft-07/schema#\",\n  \"type\": \"object\"\n}\n"
+	if string(schema) != expected {
+		t.Errorf("expected %q, got %q", expected, schema)
+	}
+}
+
+func TestGenerateValuesSchemaErrors(t *testing.T) {
+	tests := []struct {
+		values string
+		expect string
+	}{
+		{
+			values: "- a\n- b\n",
+			expect: "values must be a map",
+		},
+		{
+			values: "# @schema minimum\nreplicaCount: 1\n",
+			expect: `invalid schema annotation for "replicaCount" on line 2: "minimum" is not a "keyword: value" pair`,
+		},
+		{
+			values: "# @schema required: yes please\nreplicaCount: 1\n",
+			expect: `invalid schema annotation for "replicaCount" on line 2: required must be true or false`,
+		},
+		{
+			values: "a: [\n",
+			expect: "unable to parse values",
+		},
+	}
+	for _, tt := range tests {
+		_, err := GenerateValuesSchema([]byte(tt.values))
+		if err == nil || !strings.Contains(err.Error(), tt.expect) {
+			t.Errorf("expected error containing %q, got %v", tt.expect, err)
+		}
+	}
+}
@@ -129,6 +129,9 @@ type ReleaseOptions struct {
 	Revision  int
 	IsUpgrade bool
 	IsInstall bool
+	// ListMergeKeys maps the dotted paths of lists in the values to the field
+	// their elements are merged by. See CoalesceValuesWithListMerge.
+	ListMergeKeys map[string]string
 }
 
 // ToRenderValues composes the struct from the data coming from the Releases, Charts and Values files
@@ -158,7 +161,13 @@ func ToRenderValuesWithSchemaValidation(chrt *chart.Chart, chrtVals map[string]i
 		},
 	}
 
-	vals, err := CoalesceValues(chrt, chrtVals)
+	var vals Values
+	var err error
+	if len(options.ListMergeKeys) > 0 {
+		vals, err = CoalesceValuesWithListMerge(chrt, chrtVals, options.ListMergeKeys)
+	} else {
+		vals, err = CoalesceValues(chrt, chrtVals)
+	}
 	if err != nil {
 		return top, err
 	}
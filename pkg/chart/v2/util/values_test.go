@@ -152,6 +152,39 @@ func TestToRenderValues(t *testing.T) {
 	}
 }
 
+func TestToRenderValuesWithListMerge(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "test"},
+		Values: map[string]interface{}{
+			"voyages": []interface{}{
+				map[string]interface{}{"number": 1, "island": "whale"},
+				map[string]interface{}{"number": 2, "island": "valley of diamonds"},
+			},
+		},
+	}
+	overrideValues := map[string]interface{}{
+		"voyages": []interface{}{
+			map[string]interface{}{"number": 2, "island": "roc"},
+		},
+	}
+	o := ReleaseOptions{
+		Name:          "Seven Voyages",
+		ListMergeKeys: map[string]string{"voyages": "number"},
+	}
+
+	res, err := ToRenderValuesWithSchemaValidation(c, overrideValues, o, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	voyages := res["Values"].(Values)["voyages"].([]interface{})
+	if len(voyages) != 2 {
+		t.Fatalf("Expected 2 voyages, got %v", voyages)
+	}
+	if island := voyages[1].(map[string]interface{})["island"]; island != "roc" {
+		t.Errorf("Expected the second voyage to be overridden, got %v", island)
+	}
+}
+
 func TestReadValuesFile(t *testing.T) {
 	data, err := ReadValuesFile("./testdata/coleridge.yaml")
 	if err != nil {
@@ -81,6 +81,18 @@ type EnvSettings struct {
 	RepositoryConfig string
 	// RepositoryCache is the path to the repository cache directory.
 	RepositoryCache string
+	// CredentialHelper is the credential helper program run to get the
+	// credentials of the chart repositories without credentials or a
+	// credential helper of their own. It is set with HELM_CREDENTIAL_HELPER.
+	CredentialHelper string
+	// RepositoryRateLimit is the number of requests per second made to each
+	// chart repository host, without limit when zero. It is set with
+	// HELM_REPOSITORY_RATE_LIMIT.
+	RepositoryRateLimit float32
+	// RepositoryRateBurst is the number of requests made to a chart
+	// repository host at once within RepositoryRateLimit. It is set with
+	// HELM_REPOSITORY_RATE_BURST.
+	RepositoryRateBurst int
 	// PluginsDirectory is the path to the plugins directory.
 	PluginsDirectory string
 	// MaxHistory is the max release history maintained.
@@ -107,6 +119,9 @@ func New() *EnvSettings {
 		RegistryConfig:            envOr("HELM_REGISTRY_CONFIG", helmpath.ConfigPath("registry/config.json")),
 		RepositoryConfig:          envOr("HELM_REPOSITORY_CONFIG", helmpath.ConfigPath("repositories.yaml")),
 		RepositoryCache:           envOr("HELM_REPOSITORY_CACHE", helmpath.CachePath("repository")),
+		CredentialHelper:          os.Getenv("HELM_CREDENTIAL_HELPER"),
+		RepositoryRateLimit:       envFloat32Or("HELM_REPOSITORY_RATE_LIMIT", 0),
+		RepositoryRateBurst:       envIntOr("HELM_REPOSITORY_RATE_BURST", 1),
 		BurstLimit:                envIntOr("HELM_BURST_LIMIT", defaultBurstLimit),
 		QPS:                       envFloat32Or("HELM_QPS", defaultQPS),
 	}
@@ -215,19 +230,22 @@ func envCSV(name string) (ls []string) {
 
 func (s *EnvSettings) EnvVars() map[string]string {
 	envvars := map[string]string{
-		"HELM_BIN":               os.Args[0],
-		"HELM_CACHE_HOME":        helmpath.CachePath(""),
-		"HELM_CONFIG_HOME":       helmpath.ConfigPath(""),
-		"HELM_DATA_HOME":         helmpath.DataPath(""),
-		"HELM_DEBUG":             fmt.Sprint(s.Debug),
-		"HELM_PLUGINS":           s.PluginsDirectory,
-		"HELM_REGISTRY_CONFIG":   s.RegistryConfig,
-		"HELM_REPOSITORY_CACHE":  s.RepositoryCache,
-		"HELM_REPOSITORY_CONFIG": s.RepositoryConfig,
-		"HELM_NAMESPACE":         s.Namespace(),
-		"HELM_MAX_HISTORY":       strconv.Itoa(s.MaxHistory),
-		"HELM_BURST_LIMIT":       strconv.Itoa(s.BurstLimit),
-		"HELM_QPS":               strconv.FormatFloat(float64(s.QPS), 'f', 2, 32),
+		"HELM_BIN":                   os.Args[0],
+		"HELM_CACHE_HOME":            helmpath.CachePath(""),
+		"HELM_CONFIG_HOME":           helmpath.ConfigPath(""),
+		"HELM_DATA_HOME":             helmpath.DataPath(""),
+		"HELM_DEBUG":                 fmt.Sprint(s.Debug),
+		"HELM_PLUGINS":               s.PluginsDirectory,
+		"HELM_REGISTRY_CONFIG":       s.RegistryConfig,
+		"HELM_REPOSITORY_CACHE":      s.RepositoryCache,
+		"HELM_REPOSITORY_CONFIG":     s.RepositoryConfig,
+		"HELM_CREDENTIAL_HELPER":     s.CredentialHelper,
+		"HELM_REPOSITORY_RATE_LIMIT": strconv.FormatFloat(float64(s.RepositoryRateLimit), 'f', 2, 32),
+		"HELM_REPOSITORY_RATE_BURST": strconv.Itoa(s.RepositoryRateBurst),
+		"HELM_NAMESPACE":             s.Namespace(),
+		"HELM_MAX_HISTORY":           strconv.Itoa(s.MaxHistory),
+		"HELM_BURST_LIMIT":           strconv.Itoa(s.BurstLimit),
+		"HELM_QPS":                   strconv.FormatFloat(float64(s.QPS), 'f', 2, 32),
 
 		// broken, these are populated from helm flags and not kubeconfig.
 		"HELM_KUBECONTEXT":                  s.KubeContext,
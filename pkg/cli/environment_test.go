@@ -169,6 +169,22 @@ func TestEnvSettings(t *testing.T) {
 	}
 }
 
+func TestEnvSettingsRepositoryRateLimit(t *testing.T) {
+	defer resetEnv()()
+
+	settings := New()
+	if settings.RepositoryRateLimit != 0 || settings.RepositoryRateBurst != 1 {
+		t.Errorf("expected no repository rate limit by default, got %v with bursts of %d", settings.RepositoryRateLimit, settings.RepositoryRateBurst)
+	}
+
+	os.Setenv("HELM_REPOSITORY_RATE_LIMIT", "2.5")
+	os.Setenv("HELM_REPOSITORY_RATE_BURST", "4")
+	settings = New()
+	if settings.RepositoryRateLimit != 2.5 || settings.RepositoryRateBurst != 4 {
+		t.Errorf("expected a repository rate limit of 2.5 with bursts of 4, got %v with bursts of %d", settings.RepositoryRateLimit, settings.RepositoryRateBurst)
+	}
+}
+
 func TestEnvOrBool(t *testing.T) {
 	const envName = "TEST_ENV_OR_BOOL"
 	tests := []struct {
@@ -25,7 +25,10 @@ import (
 	"os"
 	"strings"
 
+	"github.com/mitchellh/copystructure"
+
 	"helm.sh/helm/v4/pkg/chart/v2/loader"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
 	"helm.sh/helm/v4/pkg/getter"
 	"helm.sh/helm/v4/pkg/strvals"
 )
@@ -38,15 +41,70 @@ type Options struct {
 	FileValues    []string // --set-file
 	JSONValues    []string // --set-json
 	LiteralValues []string // --set-literal
+	UnsetValues   []string // --unset
 }
 
 // MergeValues merges values from files specified via -f/--values and directly
-// via --set-json, --set, --set-string, or --set-file, marshaling them to YAML
+// via --set-json, --set, --set-string, or --set-file, marshaling them to YAML.
+// The values named with --unset are removed last, winning over the others.
 func (opts *Options) MergeValues(p getter.Providers) (map[string]interface{}, error) {
+	return opts.mergeValues(p, nil)
+}
+
+// MergeValuesWithSources merges values as MergeValues does, and also returns
+// the source of each leaf value: the path of the values file, or the flag
+// setting it, as in "--set image.tag=1.2.3". A value set more than once has
+// the source setting it last, even when it sets it to the same value.
+func (opts *Options) MergeValuesWithSources(p getter.Providers) (map[string]interface{}, chartutil.ValueSources, error) {
+	sources := chartutil.ValueSources{}
+	vals, err := opts.mergeValues(p, sources)
+	if err != nil {
+		return nil, nil, err
+	}
+	return vals, sources, nil
+}
+
+// mergeValues merges the values, recording their sources in sources unless
+// it is nil.
+func (opts *Options) mergeValues(p getter.Providers, sources chartutil.ValueSources) (map[string]interface{}, error) {
 	base := map[string]interface{}{}
+	// snapshot saves the values before each source is merged in, for trace
+	// to record the source of the values it changes.
+	var before map[string]interface{}
+	snapshot := func() error {
+		if sources == nil {
+			return nil
+		}
+		c, err := copystructure.Copy(base)
+		if err != nil {
+			return err
+		}
+		before = c.(map[string]interface{})
+		return nil
+	}
+	trace := func(source string, set map[string]interface{}) {
+		if sources != nil {
+			sources.Record(before, base, set, source)
+		}
+	}
+	// setBy returns the values parse sets in empty values, for trace to
+	// record the source of the values it sets again to the same value.
+	setBy := func(parse func(map[string]interface{}) error) map[string]interface{} {
+		if sources == nil {
+			return nil
+		}
+		set := map[string]interface{}{}
+		if err := parse(set); err != nil {
+			return nil
+		}
+		return set
+	}
 
 	// User specified a values files via -f/--values
 	for _, filePath := range opts.ValueFiles {
+		if err := snapshot(); err != nil {
+			return nil, err
+		}
 		raw, err := readFile(filePath, p)
 		if err != nil {
 			return nil, err
@@ -57,10 +115,14 @@ func (opts *Options) MergeValues(p getter.Providers) (map[string]interface{}, er
 		}
 		// Merge with the previous map
 		base = loader.MergeMaps(base, currentMap)
+		trace(filePath, currentMap)
 	}
 
 	// User specified a value via --set-json
 	for _, value := range opts.JSONValues {
+		if err := snapshot(); err != nil {
+			return nil, err
+		}
 		trimmedValue := strings.TrimSpace(value)
 		if len(trimmedValue) > 0 && trimmedValue[0] == '{' {
 			// If value is JSON object format, parse it as map
@@ -69,30 +131,49 @@ func (opts *Options) MergeValues(p getter.Providers) (map[string]interface{}, er
 				return nil, fmt.Errorf("failed parsing --set-json data JSON: %s", value)
 			}
 			base = loader.MergeMaps(base, jsonMap)
+			trace("--set-json "+value, jsonMap)
 		} else {
 			// Otherwise, parse it as key=value format
-			if err := strvals.ParseJSON(value, base); err != nil {
+			if err := strvals.ParseIntoJSON(value, base); err != nil {
 				return nil, fmt.Errorf("failed parsing --set-json data %s", value)
 			}
+			trace("--set-json "+value, setBy(func(set map[string]interface{}) error {
+				return strvals.ParseIntoJSON(value, set)
+			}))
 		}
 	}
 
 	// User specified a value via --set
 	for _, value := range opts.Values {
+		if err := snapshot(); err != nil {
+			return nil, err
+		}
 		if err := strvals.ParseInto(value, base); err != nil {
 			return nil, fmt.Errorf("failed parsing --set data: %w", err)
 		}
+		trace("--set "+value, setBy(func(set map[string]interface{}) error {
+			return strvals.ParseInto(value, set)
+		}))
 	}
 
 	// User specified a value via --set-string
 	for _, value := range opts.StringValues {
+		if err := snapshot(); err != nil {
+			return nil, err
+		}
 		if err := strvals.ParseIntoString(value, base); err != nil {
 			return nil, fmt.Errorf("failed parsing --set-string data: %w", err)
 		}
+		trace("--set-string "+value, setBy(func(set map[string]interface{}) error {
+			return strvals.ParseIntoString(value, set)
+		}))
 	}
 
 	// User specified a value via --set-file
 	for _, value := range opts.FileValues {
+		if err := snapshot(); err != nil {
+			return nil, err
+		}
 		reader := func(rs []rune) (interface{}, error) {
 			bytes, err := readFile(string(rs), p)
 			if err != nil {
@@ -103,13 +184,35 @@ func (opts *Options) MergeValues(p getter.Providers) (map[string]interface{}, er
 		if err := strvals.ParseIntoFile(value, base, reader); err != nil {
 			return nil, fmt.Errorf("failed parsing --set-file data: %w", err)
 		}
+		// The file is not read again, only the paths of the values it sets
+		// matter.
+		trace("--set-file "+value, setBy(func(set map[string]interface{}) error {
+			return strvals.ParseIntoFile(value, set, func([]rune) (interface{}, error) { return "", nil })
+		}))
 	}
 
 	// User specified a value via --set-literal
 	for _, value := range opts.LiteralValues {
+		if err := snapshot(); err != nil {
+			return nil, err
+		}
 		if err := strvals.ParseLiteralInto(value, base); err != nil {
 			return nil, fmt.Errorf("failed parsing --set-literal data: %w", err)
 		}
+		trace("--set-literal "+value, setBy(func(set map[string]interface{}) error {
+			return strvals.ParseLiteralInto(value, set)
+		}))
+	}
+
+	// User removed a value via --unset
+	for _, value := range opts.UnsetValues {
+		if err := snapshot(); err != nil {
+			return nil, err
+		}
+		if err := strvals.ParseDelete(value, base); err != nil {
+			return nil, fmt.Errorf("failed parsing --unset data: %w", err)
+		}
+		trace("--unset "+value, nil)
 	}
 
 	return base, nil
@@ -17,9 +17,12 @@ limitations under the License.
 package values
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
 	"helm.sh/helm/v4/pkg/getter"
 )
 
@@ -61,6 +64,18 @@ func TestMergeValues(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "set-json merges objects",
+			opts: Options{
+				JSONValues: []string{`foo={"bar":1,"baz":2}`, `foo={"baz":3}`},
+			},
+			expected: map[string]interface{}{
+				"foo": map[string]interface{}{
+					"bar": 1.0,
+					"baz": 3.0,
+				},
+			},
+		},
 		{
 			name: "set regular value",
 			opts: Options{
@@ -103,6 +118,27 @@ func TestMergeValues(t *testing.T) {
 				"d": "bar1",
 			},
 		},
+		{
+			name: "unset wins over set values",
+			opts: Options{
+				Values:      []string{"a.b=foo,a.c=bar,list[0]=x,list[1]=y"},
+				UnsetValues: []string{"a.b", "list[0]"},
+			},
+			expected: map[string]interface{}{
+				"a": map[string]interface{}{
+					"b": nil,
+					"c": "bar",
+				},
+				"list": []interface{}{"y"},
+			},
+		},
+		{
+			name: "invalid unset",
+			opts: Options{
+				UnsetValues: []string{"a..b"},
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalid json",
 			opts: Options{
@@ -125,3 +161,40 @@ func TestMergeValues(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeValuesWithSources(t *testing.T) {
+	valuesFile := filepath.Join(t.TempDir(), "values.yaml")
+	if err := os.WriteFile(valuesFile, []byte("image:\n  repository: nginx\n  tag: \"1.0\"\nname: web\nports: [80]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{
+		ValueFiles:   []string{valuesFile},
+		Values:       []string{"image.tag=1.1"},
+		StringValues: []string{"name=web"},
+		UnsetValues:  []string{"ports"},
+	}
+
+	vals, sources, err := opts.MergeValuesWithSources(getter.Providers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := opts.MergeValues(getter.Providers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(vals, expected) {
+		t.Errorf("expected the values merged by MergeValues %v, got %v", expected, vals)
+	}
+
+	expectedSources := chartutil.ValueSources{
+		"image.repository": valuesFile,
+		"image.tag":        "--set image.tag=1.1",
+		// The last source setting a value wins, even with the same value.
+		"name": "--set-string name=web",
+		// Unset values are nulls removing the defaults of the chart.
+		"ports": "--unset ports",
+	}
+	if !reflect.DeepEqual(sources, expectedSources) {
+		t.Errorf("expected sources %v, got %v", expectedSources, sources)
+	}
+}
@@ -0,0 +1,232 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v4/pkg/action"
+	"helm.sh/helm/v4/pkg/chart/v2/loader"
+	"helm.sh/helm/v4/pkg/cli/output"
+	"helm.sh/helm/v4/pkg/cli/values"
+	"helm.sh/helm/v4/pkg/cmd/require"
+	"helm.sh/helm/v4/pkg/getter"
+)
+
+var diffHelp = `
+This command consists of subcommands showing how the resources of a release
+change, resource by resource:
+
+- between two revisions of the release
+- with an upgrade of the release, without performing it
+
+The diffs are printed as unified diffs of the manifests of the resources, or
+as structured JSON or YAML with '--output'. The values of the data of Secrets
+are hidden with '--redact-secrets'.
+
+A plugin named 'diff', such as the helm-diff plugin, takes the place of this
+command when it is installed.
+`
+
+var diffRevisionHelp = `
+This command compares two revisions of a release. When the second revision is
+omitted, the first one is compared with the last revision of the release.
+
+    $ helm diff revision angry-bird 2 4
+`
+
+var diffUpgradeHelp = `
+This command compares the last revision of a release with the revision an
+upgrade to a chart would create. The upgrade is rendered as a dry run, so
+neither the release nor its resources are changed. The chart and the values
+are given as for 'helm upgrade'.
+
+    $ helm diff upgrade angry-bird ./angry-bird --set image.tag=1.2.0
+`
+
+func newDiffCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "show the changes of the resources of a release",
+		Long:  diffHelp,
+		Args:  require.NoArgs,
+		Annotations: map[string]string{
+			overriddenByPluginAnnotation: "true",
+		},
+	}
+
+	cmd.AddCommand(newDiffRevisionCmd(cfg, out))
+	cmd.AddCommand(newDiffUpgradeCmd(cfg, out))
+
+	return cmd
+}
+
+func newDiffRevisionCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewDiff(cfg)
+	var outfmt output.Format
+
+	cmd := &cobra.Command{
+		Use:   "revision RELEASE_NAME REVISION [REVISION]",
+		Short: "show the changes between two revisions of a release",
+		Long:  diffRevisionHelp,
+		Args:  require.MinimumNArgs(2),
+		ValidArgsFunction: func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			switch len(args) {
+			case 0:
+				return compListReleases(toComplete, args, cfg)
+			case 1, 2:
+				return compListRevisions(toComplete, cfg, args[0])
+			}
+			return noMoreArgsComp()
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			if len(args) > 3 {
+				return fmt.Errorf("%q accepts at most 3 arguments", "helm diff revision")
+			}
+			from, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("could not convert revision to a number: %v", err)
+			}
+			to := 0
+			if len(args) == 3 {
+				if to, err = strconv.Atoi(args[2]); err != nil {
+					return fmt.Errorf("could not convert revision to a number: %v", err)
+				}
+			}
+			diff, err := client.RunRevisions(args[0], from, to)
+			if err != nil {
+				return err
+			}
+			return outfmt.Write(out, &diffWriter{diff})
+		},
+	}
+
+	addDiffFlags(cmd, client)
+	bindOutputFlag(cmd, &outfmt)
+
+	return cmd
+}
+
+func newDiffUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewDiff(cfg)
+	upgrade := action.NewUpgrade(cfg)
+	valueOpts := &values.Options{}
+	var outfmt output.Format
+
+	cmd := &cobra.Command{
+		Use:   "upgrade RELEASE_NAME CHART",
+		Short: "show the changes an upgrade of a release would make",
+		Long:  diffUpgradeHelp,
+		Args:  require.ExactArgs(2),
+		ValidArgsFunction: func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return compListReleases(toComplete, args, cfg)
+			}
+			if len(args) == 1 {
+				return compListCharts(toComplete, true)
+			}
+			return noMoreArgsComp()
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			upgrade.Namespace = settings.Namespace()
+
+			registryClient, err := newRegistryClient(upgrade.CertFile, upgrade.KeyFile, upgrade.CaFile,
+				upgrade.InsecureSkipTLSverify, upgrade.PlainHTTP, upgrade.Username, upgrade.Password)
+			if err != nil {
+				return fmt.Errorf("missing registry client: %w", err)
+			}
+			upgrade.SetRegistryClient(registryClient)
+
+			if upgrade.Version == "" && upgrade.Devel {
+				slog.Debug("setting version to >0.0.0-0")
+				upgrade.Version = ">0.0.0-0"
+			}
+			chartPath, err := upgrade.LocateChart(args[1], settings)
+			if err != nil {
+				return err
+			}
+			vals, err := valueOpts.MergeValues(getter.All(settings))
+			if err != nil {
+				return err
+			}
+			ch, err := loader.Load(chartPath)
+			if err != nil {
+				return err
+			}
+			if req := ch.Metadata.Dependencies; req != nil {
+				if err := action.CheckDependencies(ch, req); err != nil {
+					return fmt.Errorf("an error occurred while checking for chart dependencies. You may need to run `helm dependency build` to fetch missing dependencies: %w", err)
+				}
+			}
+
+			diff, err := client.RunUpgrade(context.Background(), upgrade, args[0], ch, vals)
+			if err != nil {
+				return err
+			}
+			return outfmt.Write(out, &diffWriter{diff})
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVar(&upgrade.Devel, "devel", false, "use development versions, too. Equivalent to version '>0.0.0-0'. If --version is set, this is ignored")
+	f.StringVar(&upgrade.DryRunOption, "dry-run", "client", "how the upgrade is rendered: 'client' does not attempt cluster connections, while 'server' allows them, as for lookups")
+	f.BoolVar(&upgrade.ResetValues, "reset-values", false, "reset the values to the ones built into the chart")
+	f.BoolVar(&upgrade.ReuseValues, "reuse-values", false, "reuse the last release's values and merge in any overrides from the command line via --set and -f. If '--reset-values' is specified, this is ignored")
+	f.BoolVar(&upgrade.ResetThenReuseValues, "reset-then-reuse-values", false, "reset the values to the ones built into the chart, apply the last release's values and merge in any overrides from the command line via --set and -f. If '--reset-values' or '--reuse-values' is specified, this is ignored")
+	f.BoolVar(&upgrade.SkipSchemaValidation, "skip-schema-validation", false, "if set, disables JSON schema validation")
+	f.BoolVar(&upgrade.EnableDNS, "enable-dns", false, "enable DNS lookups when rendering templates")
+	addDiffFlags(cmd, client)
+	addChartPathOptionsFlags(f, &upgrade.ChartPathOptions)
+	addValueOptionsFlags(f, valueOpts)
+	bindOutputFlag(cmd, &outfmt)
+	bindPostRenderFlag(cmd, &upgrade.PostRenderer)
+
+	return cmd
+}
+
+func addDiffFlags(cmd *cobra.Command, client *action.Diff) {
+	f := cmd.Flags()
+	f.BoolVar(&client.RedactSecrets, "redact-secrets", false, "hide the values of the data of Secrets, only showing which of them change")
+	f.IntVar(&client.Context, "context", 3, "number of unchanged lines shown around the changes")
+}
+
+type diffWriter struct {
+	diff *action.ReleaseDiff
+}
+
+func (w *diffWriter) WriteTable(out io.Writer) error {
+	for _, r := range w.diff.Resources {
+		if _, err := io.WriteString(out, r.Diff); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *diffWriter) WriteJSON(out io.Writer) error {
+	return output.EncodeJSON(out, w.diff)
+}
+
+func (w *diffWriter) WriteYAML(out io.Writer) error {
+	return output.EncodeYAML(out, w.diff)
+}
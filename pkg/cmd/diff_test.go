@@ -0,0 +1,70 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"testing"
+
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+func TestDiffRevisionCmd(t *testing.T) {
+	mk := func(vers int, color string) *release.Release {
+		rel := release.Mock(&release.MockReleaseOptions{
+			Name:    "angry-bird",
+			Version: vers,
+			Status:  release.StatusSuperseded,
+		})
+		rel.Manifest = "---\n# Source: angry-bird/templates/config.yaml\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: angry-bird\ndata:\n  color: " + color + "\n"
+		return rel
+	}
+	rels := []*release.Release{mk(1, "red"), mk(2, "red"), mk(3, "blue")}
+
+	tests := []cmdTestCase{{
+		name:   "diff a revision with the last one",
+		cmd:    "diff revision angry-bird 1",
+		rels:   rels,
+		golden: "output/diff-revision.txt",
+	}, {
+		name:   "diff unchanged revisions",
+		cmd:    "diff revision angry-bird 1 2",
+		rels:   rels,
+		golden: "output/diff-revision-unchanged.txt",
+	}, {
+		name:   "diff revisions with json output format",
+		cmd:    "diff revision angry-bird 2 3 --output json",
+		rels:   rels,
+		golden: "output/diff-revision.json",
+	}, {
+		name:      "diff a missing revision",
+		cmd:       "diff revision angry-bird 1 4",
+		rels:      rels,
+		wantError: true,
+	}, {
+		name:      "diff an invalid revision",
+		cmd:       "diff revision angry-bird one",
+		rels:      rels,
+		wantError: true,
+	}}
+	runTestCmd(t, tests)
+}
+
+func TestDiffRevisionCompletion(t *testing.T) {
+	checkFileCompletion(t, "diff", false)
+	checkFileCompletion(t, "diff revision", false)
+	checkFileCompletion(t, "diff revision myrelease", false)
+}
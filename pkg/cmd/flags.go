@@ -51,6 +51,7 @@ func addValueOptionsFlags(f *pflag.FlagSet, v *values.Options) {
 	f.StringArrayVar(&v.FileValues, "set-file", []string{}, "set values from respective files specified via the command line (can specify multiple or separate values with commas: key1=path1,key2=path2)")
 	f.StringArrayVar(&v.JSONValues, "set-json", []string{}, "set JSON values on the command line (can specify multiple or separate values with commas: key1=jsonval1,key2=jsonval2 or using json format: {\"key1\": jsonval1, \"key2\": \"jsonval2\"})")
 	f.StringArrayVar(&v.LiteralValues, "set-literal", []string{}, "set a literal STRING value on the command line")
+	f.StringArrayVar(&v.UnsetValues, "unset", []string{}, "remove values, including the chart's defaults, after applying the other values (can specify multiple or separate names with commas: key1,key2.subkey,list[0])")
 }
 
 func AddWaitFlag(cmd *cobra.Command, wait *kube.WaitStrategy) {
@@ -102,8 +103,10 @@ func (ws *waitValue) Type() string {
 func addChartPathOptionsFlags(f *pflag.FlagSet, c *action.ChartPathOptions) {
 	f.StringVar(&c.Version, "version", "", "specify a version constraint for the chart version to use. This constraint can be a specific tag (e.g. 1.1.1) or it may reference a valid range (e.g. ^2.0.0). If this is not specified, the latest version is used")
 	f.BoolVar(&c.Verify, "verify", false, "verify the package before using it")
+	f.BoolVar(&c.SkipDigestVerify, "skip-digest-verify", false, "skip checking the downloaded chart against the digest recorded in the repository index")
 	f.StringVar(&c.Keyring, "keyring", defaultKeyring(), "location of public keys used for verification")
 	f.StringVar(&c.RepoURL, "repo", "", "chart repository url where to locate the requested chart")
+	f.DurationVar(&c.RepoMaxAge, "repo-max-age", 0, "download again the index of the repository of the chart if it is older than this duration (e.g. 1h). Defaults to never refreshing it")
 	f.StringVar(&c.Username, "username", "", "chart repository username where to locate the requested chart")
 	f.StringVar(&c.Password, "password", "", "chart repository password where to locate the requested chart")
 	f.StringVar(&c.CertFile, "cert-file", "", "identify HTTPS client using this SSL certificate file")
@@ -112,6 +115,8 @@ func addChartPathOptionsFlags(f *pflag.FlagSet, c *action.ChartPathOptions) {
 	f.BoolVar(&c.PlainHTTP, "plain-http", false, "use insecure HTTP connections for the chart download")
 	f.StringVar(&c.CaFile, "ca-file", "", "verify certificates of HTTPS-enabled servers using this CA bundle")
 	f.BoolVar(&c.PassCredentialsAll, "pass-credentials", false, "pass credentials to all domains")
+	f.IntVar(&c.Retries, "retries", 0, "number of times to retry the chart download on network errors and 5xx or 429 responses")
+	f.StringVar(&c.DownloadCache, "download-cache", "", "directory caching the downloaded charts, to serve the later downloads of the same charts from it while they are fresh")
 }
 
 // bindOutputFlag will add the output flag to the given command and bind the
@@ -164,23 +169,76 @@ func (o *outputValue) Set(s string) error {
 }
 
 func bindPostRenderFlag(cmd *cobra.Command, varRef *postrender.PostRenderer) {
-	p := &postRendererOptions{varRef, "", []string{}}
-	cmd.Flags().Var(&postRendererString{p}, postRenderFlag, "the path to an executable to be used for post rendering. If it exists in $PATH, the binary will be used, otherwise it will try to look for the executable at the given path")
-	cmd.Flags().Var(&postRendererArgsSlice{p}, postRenderArgsFlag, "an argument to the post-renderer (can specify multiple)")
+	p := &postRendererOptions{renderer: varRef}
+	cmd.Flags().Var(&postRendererString{p}, postRenderFlag, "the path to an executable to be used for post rendering. If it exists in $PATH, the binary will be used, otherwise it will try to look for the executable at the given path. Use kustomize://path/to/overlay to apply a kustomize overlay instead. Can be specified multiple times to run post-renderers in order, each receiving the output of the previous one")
+	cmd.Flags().Var(&postRendererArgsSlice{p}, postRenderArgsFlag, "an argument to the post-renderer (can specify multiple). When multiple post-renderers are given, arguments apply to the last post-renderer specified before them")
 }
 
 type postRendererOptions struct {
-	renderer   *postrender.PostRenderer
+	renderer *postrender.PostRenderer
+	stages   []postRendererStage
+	// args holds the arguments given before any post-renderer, which belong
+	// to the first one.
+	args []string
+}
+
+type postRendererStage struct {
 	binaryPath string
 	args       []string
 }
 
+// currentArgs returns the arguments that --post-renderer-args applies to.
+func (o *postRendererOptions) currentArgs() *[]string {
+	if len(o.stages) == 0 {
+		return &o.args
+	}
+	return &o.stages[len(o.stages)-1].args
+}
+
+// update sets the renderer to run the post-renderers in order.
+func (o *postRendererOptions) update() error {
+	if len(o.stages) == 0 {
+		return nil
+	}
+	renderers := make([]postrender.PostRenderer, len(o.stages))
+	for i, stage := range o.stages {
+		pr, err := newPostRenderer(stage)
+		if err != nil {
+			return err
+		}
+		renderers[i] = pr
+	}
+	if len(renderers) == 1 {
+		*o.renderer = renderers[0]
+	} else {
+		*o.renderer = postrender.NewChain(renderers...)
+	}
+	return nil
+}
+
+// newPostRenderer returns the post-renderer of a stage: a kustomize overlay
+// for kustomize:// references, an executable otherwise.
+func newPostRenderer(stage postRendererStage) (postrender.PostRenderer, error) {
+	overlay, ok := strings.CutPrefix(stage.binaryPath, postrender.KustomizeScheme+"://")
+	if !ok {
+		return postrender.NewExec(stage.binaryPath, stage.args...)
+	}
+	if len(stage.args) > 0 {
+		return nil, fmt.Errorf("--%s is not supported by the kustomize post-renderer %s", postRenderArgsFlag, stage.binaryPath)
+	}
+	return postrender.NewKustomize(overlay)
+}
+
 type postRendererString struct {
 	options *postRendererOptions
 }
 
 func (p *postRendererString) String() string {
-	return p.options.binaryPath
+	paths := make([]string, len(p.options.stages))
+	for i, stage := range p.options.stages {
+		paths[i] = stage.binaryPath
+	}
+	return strings.Join(paths, ",")
 }
 
 func (p *postRendererString) Type() string {
@@ -191,16 +249,12 @@ func (p *postRendererString) Set(val string) error {
 	if val == "" {
 		return nil
 	}
-	if p.options.binaryPath != "" {
-		return fmt.Errorf("cannot specify --post-renderer flag more than once")
+	stage := postRendererStage{binaryPath: val}
+	if len(p.options.stages) == 0 {
+		stage.args = p.options.args
 	}
-	p.options.binaryPath = val
-	pr, err := postrender.NewExec(p.options.binaryPath, p.options.args...)
-	if err != nil {
-		return err
-	}
-	*p.options.renderer = pr
-	return nil
+	p.options.stages = append(p.options.stages, stage)
+	return p.options.update()
 }
 
 type postRendererArgsSlice struct {
@@ -208,7 +262,7 @@ type postRendererArgsSlice struct {
 }
 
 func (p *postRendererArgsSlice) String() string {
-	return "[" + strings.Join(p.options.args, ",") + "]"
+	return "[" + strings.Join(*p.options.currentArgs(), ",") + "]"
 }
 
 func (p *postRendererArgsSlice) Type() string {
@@ -216,34 +270,27 @@ func (p *postRendererArgsSlice) Type() string {
 }
 
 func (p *postRendererArgsSlice) Set(val string) error {
-
 	// a post-renderer defined by a user may accept empty arguments
-	p.options.args = append(p.options.args, val)
+	args := p.options.currentArgs()
+	*args = append(*args, val)
 
-	if p.options.binaryPath == "" {
-		return nil
-	}
 	// overwrite if already create PostRenderer by `post-renderer` flags
-	pr, err := postrender.NewExec(p.options.binaryPath, p.options.args...)
-	if err != nil {
-		return err
-	}
-	*p.options.renderer = pr
-	return nil
+	return p.options.update()
 }
 
 func (p *postRendererArgsSlice) Append(val string) error {
-	p.options.args = append(p.options.args, val)
+	args := p.options.currentArgs()
+	*args = append(*args, val)
 	return nil
 }
 
 func (p *postRendererArgsSlice) Replace(val []string) error {
-	p.options.args = val
+	*p.options.currentArgs() = val
 	return nil
 }
 
 func (p *postRendererArgsSlice) GetSlice() []string {
-	return p.options.args
+	return *p.options.currentArgs()
 }
 
 func compVersionFlag(chartRef string, _ string) ([]string, cobra.ShellCompDirective) {
@@ -17,7 +17,10 @@ limitations under the License.
 package cmd
 
 import (
+	"bytes"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -98,23 +101,60 @@ func outputFlagCompletionTest(t *testing.T, cmdName string) {
 	runTestCmd(t, tests)
 }
 
-func TestPostRendererFlagSetOnce(t *testing.T) {
+func TestPostRendererFlagMultiple(t *testing.T) {
 	cfg := action.Configuration{}
 	client := action.NewInstall(&cfg)
-	str := postRendererString{
-		options: &postRendererOptions{
-			renderer: &client.PostRenderer,
-		},
+	opts := &postRendererOptions{
+		renderer: &client.PostRenderer,
 	}
-	// Set the binary once
-	err := str.Set("echo")
+	str := postRendererString{options: opts}
+	args := postRendererArgsSlice{options: opts}
+
+	// Arguments given before any post-renderer belong to the first one
+	require.NoError(t, args.Set("--first"))
+	require.NoError(t, str.Set("echo"))
+	require.NoError(t, args.Set("--second"))
+
+	out, err := client.PostRenderer.Run(bytes.NewBufferString("ignored"))
+	require.NoError(t, err)
+	require.Equal(t, "--first --second\n", out.String())
+
+	// Set the binary again to chain it
+	require.NoError(t, str.Set("sed"))
+	require.NoError(t, args.Set("s/first/third/"))
+	out, err = client.PostRenderer.Run(bytes.NewBufferString("ignored"))
+	require.NoError(t, err)
+	require.Equal(t, "--third --second\n", out.String())
+
+	require.Equal(t, "echo,sed", str.String())
+	require.Equal(t, []postRendererStage{
+		{binaryPath: "echo", args: []string{"--first", "--second"}},
+		{binaryPath: "sed", args: []string{"s/first/third/"}},
+	}, opts.stages)
+
+	// An unknown binary is an error
+	require.Error(t, str.Set("no-such-post-renderer-binary"))
+}
+
+func TestPostRendererFlagKustomize(t *testing.T) {
+	overlay := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(overlay, "kustomization.yaml"), []byte("namePrefix: prod-\n"), 0644))
+
+	cfg := action.Configuration{}
+	client := action.NewInstall(&cfg)
+	opts := &postRendererOptions{
+		renderer: &client.PostRenderer,
+	}
+	str := postRendererString{options: opts}
+	args := postRendererArgsSlice{options: opts}
+
+	require.NoError(t, str.Set("kustomize://"+overlay))
+	out, err := client.PostRenderer.Run(bytes.NewBufferString("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n"))
 	require.NoError(t, err)
+	require.Contains(t, out.String(), "name: prod-config")
 
-	// Set the binary again to the same value is not ok
-	err = str.Set("echo")
-	require.Error(t, err)
+	// Arguments are not supported
+	require.Error(t, args.Set("--arg"))
 
-	// Set the binary again to a different value is not ok
-	err = str.Set("cat")
-	require.Error(t, err)
+	require.Error(t, str.Set("kustomize://"+filepath.Join(overlay, "missing")))
 }
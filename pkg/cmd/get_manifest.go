@@ -33,6 +33,11 @@ This command fetches the generated manifest for a given release.
 A manifest is a YAML-encoded representation of the Kubernetes resources that
 were generated from this release's chart(s). If a chart is dependent on other
 charts, those resources will also be included in the manifest.
+
+The manifest can be limited to the resources of some kinds with '--kind', given
+once per kind, and to the resources with a given name with '--name':
+
+    $ helm get manifest RELEASE_NAME --kind Deployment --name web
 `
 
 func newGetManifestCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
@@ -60,6 +65,8 @@ func newGetManifestCmd(cfg *action.Configuration, out io.Writer) *cobra.Command
 	}
 
 	cmd.Flags().IntVar(&client.Version, "revision", 0, "get the named release with revision")
+	cmd.Flags().StringArrayVar(&client.Kinds, "kind", nil, "only output the resources of this kind (can specify multiple)")
+	cmd.Flags().StringVar(&client.Name, "name", "", "only output the resources with this name")
 	err := cmd.RegisterFlagCompletionFunc("revision", func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		if len(args) == 1 {
 			return compListRevisions(toComplete, cfg, args[0])
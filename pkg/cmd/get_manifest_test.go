@@ -23,11 +23,36 @@ import (
 )
 
 func TestGetManifest(t *testing.T) {
+	multi := release.Mock(&release.MockReleaseOptions{Name: "multi"})
+	multi.Manifest = `---
+# Source: web/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+# Source: web/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+---
+# Source: web/templates/job.yaml
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+`
 	tests := []cmdTestCase{{
 		name:   "get manifest with release",
 		cmd:    "get manifest juno",
 		golden: "output/get-manifest.txt",
 		rels:   []*release.Release{release.Mock(&release.MockReleaseOptions{Name: "juno"})},
+	}, {
+		name:   "get manifest filtered by kind and name",
+		cmd:    "get manifest multi --kind Deployment --kind Job --name web",
+		golden: "output/get-manifest-filtered.txt",
+		rels:   []*release.Release{multi},
 	}, {
 		name:      "get manifest without args",
 		cmd:       "get manifest",
@@ -30,11 +30,22 @@ import (
 
 var getValuesHelp = `
 This command downloads a values file for a given release.
+
+By default, only the values supplied by the user are shown. With '--all', the
+effective values of the release are shown: the user-supplied values coalesced
+with the defaults of the chart and its dependencies. With '--delta', only the
+effective values that differ from those defaults are shown, with the defaults
+removed by the user set to null, so that installing the chart with them
+reproduces the release:
+
+    $ helm get values --delta myrelease > myrelease-values.yaml
+
+The keys are sorted, so the output of two revisions can be diffed.
 `
 
 type valuesWriter struct {
-	vals      map[string]interface{}
-	allValues bool
+	vals   map[string]interface{}
+	header string
 }
 
 func newGetValuesCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
@@ -57,7 +68,14 @@ func newGetValuesCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 			if err != nil {
 				return err
 			}
-			return outfmt.Write(out, &valuesWriter{vals, client.AllValues})
+			header := "USER-SUPPLIED VALUES:"
+			switch {
+			case client.Delta:
+				header = "VALUES DIFFERING FROM THE CHART DEFAULTS:"
+			case client.AllValues:
+				header = "COMPUTED VALUES:"
+			}
+			return outfmt.Write(out, &valuesWriter{vals, header})
 		},
 	}
 
@@ -75,17 +93,14 @@ func newGetValuesCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	}
 
 	f.BoolVarP(&client.AllValues, "all", "a", false, "dump all (computed) values")
+	f.BoolVar(&client.Delta, "delta", false, "dump only the computed values that differ from the defaults of the chart")
 	bindOutputFlag(cmd, &outfmt)
 
 	return cmd
 }
 
 func (v valuesWriter) WriteTable(out io.Writer) error {
-	if v.allValues {
-		fmt.Fprintln(out, "COMPUTED VALUES:")
-	} else {
-		fmt.Fprintln(out, "USER-SUPPLIED VALUES:")
-	}
+	fmt.Fprintln(out, v.header)
 	return output.EncodeYAML(out, v.vals)
 }
 
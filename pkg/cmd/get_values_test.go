@@ -39,6 +39,17 @@ func TestGetValuesCmd(t *testing.T) {
 		cmd:    "get values thomas-guide --all",
 		golden: "output/get-values-all.txt",
 		rels:   []*release.Release{release.Mock(&release.MockReleaseOptions{Name: "thomas-guide"})},
+	}, {
+		name:   "get values thomas-guide (delta)",
+		cmd:    "get values thomas-guide --delta",
+		golden: "output/get-values-delta.txt",
+		rels:   []*release.Release{release.Mock(&release.MockReleaseOptions{Name: "thomas-guide"})},
+	}, {
+		name:      "get values with both all and delta",
+		cmd:       "get values thomas-guide --all --delta",
+		golden:    "output/get-values-all-delta.txt",
+		rels:      []*release.Release{release.Mock(&release.MockReleaseOptions{Name: "thomas-guide"})},
+		wantError: true,
 	}, {
 		name:   "get values to json",
 		cmd:    "get values thomas-guide --output json",
@@ -44,10 +44,16 @@ The historical release set is printed as a formatted table, e.g:
 
     $ helm history angry-bird
     REVISION    UPDATED                     STATUS          CHART             APP VERSION     DESCRIPTION
-    1           Mon Oct 3 10:15:13 2016     superseded      alpine-0.1.0      1.0             Initial install
-    2           Mon Oct 3 10:15:13 2016     superseded      alpine-0.1.0      1.0             Upgraded successfully
-    3           Mon Oct 3 10:15:13 2016     superseded      alpine-0.1.0      1.0             Rolled back to 2
-    4           Mon Oct 3 10:15:13 2016     deployed        alpine-0.1.0      1.0             Upgraded successfully
+    1           Mon Oct 3 10:15:13 2016     superseded      alpine-0.1.0      1.0             Install of alpine-0.1.0
+    2           Mon Oct 3 10:15:13 2016     superseded      alpine-0.2.0      1.0             Upgrade from alpine-0.1.0 to alpine-0.2.0
+    3           Mon Oct 3 10:15:13 2016     superseded      alpine-0.2.0      1.0             Values changed, chart alpine-0.2.0 unchanged
+    4           Mon Oct 3 10:15:13 2016     deployed        alpine-0.2.0      1.0             Rollback to 2
+
+The description of a revision is the one given with '--description', its
+failure if any, or a summary of the change from the previous revision. The
+JSON and YAML output also report the cause of each revision: install,
+upgrade, config (values only) or rollback, along with the revision rolled
+back to.
 `
 
 func newHistoryCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
@@ -90,6 +96,11 @@ type releaseInfo struct {
 	Chart       string        `json:"chart"`
 	AppVersion  string        `json:"app_version"`
 	Description string        `json:"description"`
+
+	// Cause is the kind of change that made the revision, such as an
+	// upgrade or a rollback.
+	Cause      action.RevisionCause `json:"cause"`
+	RollbackTo int                  `json:"rollback_to,omitempty"`
 }
 
 type releaseHistory []releaseInfo
@@ -128,12 +139,12 @@ func getHistory(client *action.History, name string) (releaseHistory, error) {
 		return releaseHistory{}, nil
 	}
 
-	releaseHistory := getReleaseHistory(rels)
+	releaseHistory := getReleaseHistory(rels, client.Changes(rels))
 
 	return releaseHistory, nil
 }
 
-func getReleaseHistory(rls []*release.Release) (history releaseHistory) {
+func getReleaseHistory(rls []*release.Release, changes map[int]action.RevisionChange) (history releaseHistory) {
 	for i := len(rls) - 1; i >= 0; i-- {
 		r := rls[i]
 		c := formatChartName(r.Chart)
@@ -149,6 +160,11 @@ func getReleaseHistory(rls []*release.Release) (history releaseHistory) {
 			AppVersion:  a,
 			Description: d,
 		}
+		if change, ok := changes[v]; ok {
+			rInfo.Description = change.Description
+			rInfo.Cause = change.Cause
+			rInfo.RollbackTo = change.RollbackTo
+		}
 		if !r.Info.LastDeployed.IsZero() {
 			rInfo.Updated = r.Info.LastDeployed
 
@@ -0,0 +1,41 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gosuri/uitable"
+
+	"helm.sh/helm/v4/pkg/action"
+)
+
+// printHookRuns writes the timeline of the hooks run by a release operation
+// to out, if any ran.
+func printHookRuns(out io.Writer, runs []action.HookRun) {
+	if len(runs) == 0 {
+		return
+	}
+	tbl := uitable.New()
+	tbl.AddRow("STARTED", "DURATION", "EVENT", "KIND", "NAME", "WEIGHT", "PHASE")
+	for _, r := range runs {
+		tbl.AddRow(r.StartedAt.Format("15:04:05.000"), r.Duration().Round(time.Millisecond), r.Event, r.Kind, r.Name, r.Weight, r.Phase)
+	}
+	fmt.Fprintf(out, "HOOK TIMELINE:\n%s\n", tbl)
+}
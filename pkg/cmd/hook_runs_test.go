@@ -0,0 +1,66 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"helm.sh/helm/v4/pkg/action"
+	release "helm.sh/helm/v4/pkg/release/v1"
+)
+
+func TestPrintHookRuns(t *testing.T) {
+	start := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	runs := []action.HookRun{
+		{
+			Name:        "migrate",
+			Kind:        "Job",
+			Weight:      -5,
+			Event:       release.HookPreUpgrade,
+			StartedAt:   start,
+			CompletedAt: start.Add(90 * time.Second),
+			Phase:       release.HookPhaseSucceeded,
+		},
+		{
+			Name:        "smoke",
+			Kind:        "Pod",
+			Event:       release.HookPostUpgrade,
+			StartedAt:   start.Add(2 * time.Minute),
+			CompletedAt: start.Add(2*time.Minute + 1500*time.Millisecond),
+			Phase:       release.HookPhaseFailed,
+		},
+	}
+
+	var out bytes.Buffer
+	printHookRuns(&out, runs)
+	expect := `HOOK TIMELINE:
+STARTED     	DURATION	EVENT       	KIND	NAME   	WEIGHT	PHASE    
+15:04:05.000	1m30s   	pre-upgrade 	Job 	migrate	-5    	Succeeded
+15:06:05.000	1.5s    	post-upgrade	Pod 	smoke  	0     	Failed   
+`
+	if out.String() != expect {
+		t.Errorf("expected:\n%s\ngot:\n%s", expect, out.String())
+	}
+
+	out.Reset()
+	printHookRuns(&out, nil)
+	if out.Len() != 0 {
+		t.Errorf("expected no output without hook runs, got %q", out.String())
+	}
+}
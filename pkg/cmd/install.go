@@ -35,6 +35,7 @@ import (
 	"helm.sh/helm/v4/pkg/action"
 	chart "helm.sh/helm/v4/pkg/chart/v2"
 	"helm.sh/helm/v4/pkg/chart/v2/loader"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
 	"helm.sh/helm/v4/pkg/cli/output"
 	"helm.sh/helm/v4/pkg/cli/values"
 	"helm.sh/helm/v4/pkg/cmd/require"
@@ -98,9 +99,44 @@ And in the following example, 'foo' is set to '{"key1":"value1","key2":"bar"}':
 
     $ helm install --set-json='foo={"key1":"value1","key2":"value2"}' --set-json='foo.key2="bar"' myredis ./redis
 
+JSON objects are merged into the objects already set for the key, so in the
+following example 'foo' is also set to '{"key1":"value1","key2":"bar"}':
+
+    $ helm install --set-json='foo={"key1":"value1","key2":"value2"}' --set-json='foo={"key2":"bar"}' myredis ./redis
+
+The '--unset' flag removes values after all the others are applied, so it wins
+over any value set for the same key. It also removes the chart's defaults,
+including those of subcharts, while indexes remove an element from a list set
+with the other flags:
+
+    $ helm install --set-json='ports=[80,443]' --unset 'ingress.annotations,ports[0]' myredis ./redis
+
+A chart may compute some of its defaults from the other values in a
+'defaults.tpl' file next to its 'values.yaml'. The computed defaults take
+precedence over the chart's values files, but not over the values given with
+the flags above, and are computed again on every upgrade.
+
+To find out where a value comes from, the --trace-values flag lists the values
+on stderr before installing the chart, each with the values file or the flag
+that set it, or else the chart whose values file gives its default. Defaults
+computed in 'defaults.tpl' are not listed.
+
 To check the generated manifests of a release without installing the chart,
 the --debug and --dry-run flags can be combined.
 
+With --debug, the hooks run by the installation are listed on stderr once it
+completes or fails, with the time each one started, how long it ran and whether
+it succeeded, to find the hooks slowing down or failing the installation.
+
+The --wait-for flag waits, after the resources are ready, until a field of a
+resource of the release namespace satisfies a comparison, given as
+'<kind>/<name>:<jsonpath><operator><value>'. The operator is one of ==, !=, >=,
+<=, > and <, and numbers are compared as such. All the expressions given must
+hold before --timeout, otherwise the installation fails and the error lists those
+that do not with the value last observed:
+
+    $ helm install --wait-for 'deployment/web:.status.availableReplicas>=3' myweb ./web
+
 The --dry-run flag will output all generated chart manifests, including Secrets
 which can contain sensitive values. To hide Kubernetes Secrets use the
 --hide-secret flag. Please carefully consider how and when these flags are used.
@@ -108,6 +144,22 @@ which can contain sensitive values. To hide Kubernetes Secrets use the
 If --verify is set, the chart MUST have a provenance file, and the provenance
 file MUST pass all verification steps.
 
+The experimental --apply-only flag installs only the resources matching one of
+its selectors: a 'Kind/name' pair, or a glob matching the paths of templates in
+the chart, or a directory of templates. The release holds only those resources,
+and its manifest records the selectors. Hooks run as usual. Applying a part of a
+chart may leave the release broken, so the flag requires
+HELM_EXPERIMENTAL_PARTIAL_APPLY=1 in the environment:
+
+    $ HELM_EXPERIMENTAL_PARTIAL_APPLY=1 helm install --apply-only charts/postgresql myapp ./myapp
+
+With --generate-name, a template given with --name-template generates the name
+of the release. It has access to the name of the chart as '.Chart' and to a
+random token as '.Token', and is rendered again with a new token when the name
+is already in use:
+
+    $ helm install --generate-name --name-template '{{.Chart}}-{{now | date "20060102"}}-{{.Token}}' ./redis
+
 There are six different ways you can express the chart you want to install:
 
 1. By chart reference: helm install mymaria example/mariadb
@@ -135,6 +187,7 @@ func newInstallCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	client := action.NewInstall(cfg)
 	valueOpts := &values.Options{}
 	var outfmt output.Format
+	var traceValues bool
 
 	cmd := &cobra.Command{
 		Use:   "install [NAME] [CHART]",
@@ -144,7 +197,7 @@ func newInstallCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 		ValidArgsFunction: func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			return compInstall(args, toComplete, client)
 		},
-		RunE: func(_ *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			registryClient, err := newRegistryClient(client.CertFile, client.KeyFile, client.CaFile,
 				client.InsecureSkipTLSverify, client.PlainHTTP, client.Username, client.Password)
 			if err != nil {
@@ -158,7 +211,14 @@ func newInstallCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 			if client.DryRunOption == "" {
 				client.DryRunOption = "none"
 			}
-			rel, err := runInstall(args, client, valueOpts, out)
+			var traceOut io.Writer
+			if traceValues {
+				traceOut = cmd.ErrOrStderr()
+			}
+			rel, err := runInstall(args, client, valueOpts, out, traceOut)
+			if settings.Debug {
+				printHookRuns(cmd.ErrOrStderr(), client.HookRuns())
+			}
 			if err != nil {
 				return fmt.Errorf("INSTALLATION FAILED: %w", err)
 			}
@@ -177,6 +237,8 @@ func newInstallCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	// it is added separately
 	f := cmd.Flags()
 	f.BoolVar(&client.HideSecret, "hide-secret", false, "hide Kubernetes Secrets when also using the --dry-run flag")
+	f.IntVar(&client.MaxHistory, "history-max", settings.MaxHistory, "limit the maximum number of revisions saved per release. Use 0 for no limit")
+	f.BoolVar(&traceValues, "trace-values", false, "print the source of each value, such as a values file, a --set flag or the values of a chart, to stderr")
 	bindOutputFlag(cmd, &outfmt)
 	bindPostRenderFlag(cmd, &client.PostRenderer)
 
@@ -195,10 +257,11 @@ func addInstallFlags(cmd *cobra.Command, f *pflag.FlagSet, client *action.Instal
 	f.BoolVar(&client.Force, "force", false, "force resource updates through a replacement strategy")
 	f.BoolVar(&client.DisableHooks, "no-hooks", false, "prevent hooks from running during install")
 	f.BoolVar(&client.Replace, "replace", false, "reuse the given name, only if that name is a deleted release which remains in the history. This is unsafe in production")
-	f.DurationVar(&client.Timeout, "timeout", 300*time.Second, "time to wait for any individual Kubernetes operation (like Jobs for hooks)")
+	f.DurationVar(&client.Timeout, "timeout", 300*time.Second, "time to wait for any individual Kubernetes operation (like Jobs for hooks), unless overridden for a resource by its helm.sh/timeout annotation")
 	f.BoolVar(&client.WaitForJobs, "wait-for-jobs", false, "if set and --wait enabled, will wait until all Jobs have been completed before marking the release as successful. It will wait for as long as --timeout")
+	f.StringArrayVar(&client.WaitFor, "wait-for", []string{}, "wait until the given expression of the form '<kind>/<name>:<jsonpath><operator><value>' holds for a resource of the release namespace. Can be specified multiple times, and all expressions must hold at once. It will wait for what is left of --timeout after the wait of --wait")
 	f.BoolVarP(&client.GenerateName, "generate-name", "g", false, "generate the name (and omit the NAME parameter)")
-	f.StringVar(&client.NameTemplate, "name-template", "", "specify template used to name the release")
+	f.StringVar(&client.NameTemplate, "name-template", "", "specify template used to name the release. With --generate-name, it is given the chart name as .Chart and a random token as .Token")
 	f.StringVar(&client.Description, "description", "", "add a custom description")
 	f.BoolVar(&client.Devel, "devel", false, "use development versions, too. Equivalent to version '>0.0.0-0'. If --version is set, this is ignored")
 	f.BoolVar(&client.DependencyUpdate, "dependency-update", false, "update dependencies if they are missing before installing the chart")
@@ -208,9 +271,16 @@ func addInstallFlags(cmd *cobra.Command, f *pflag.FlagSet, client *action.Instal
 	f.BoolVar(&client.SubNotes, "render-subchart-notes", false, "if set, render subchart notes along with the parent")
 	f.BoolVar(&client.SkipSchemaValidation, "skip-schema-validation", false, "if set, disables JSON schema validation")
 	f.StringToStringVarP(&client.Labels, "labels", "l", nil, "Labels that would be added to release metadata. Should be divided by comma.")
+	f.StringToStringVar(&client.Annotations, "annotations", nil, "Annotations that would be added to release metadata. Should be divided by comma. Their keys must be prefixed with 'helm.sh/'.")
+	f.StringToStringVar(&client.ListMergeKeys, "merge-lists", nil, "merge the lists of the chart values at the given dotted paths with the given ones by the given element key, instead of replacing them (e.g. --merge-lists ports=name,subchart.env=name)")
 	f.BoolVar(&client.EnableDNS, "enable-dns", false, "enable DNS lookups when rendering templates")
 	f.BoolVar(&client.HideNotes, "hide-notes", false, "if set, do not show notes in install output. Does not affect presence in chart metadata")
 	f.BoolVar(&client.TakeOwnership, "take-ownership", false, "if set, install will ignore the check for helm annotations and take ownership of the existing resources")
+	f.StringVar(&client.FieldManager, "field-manager", "", "name of the field manager recorded for the changes made to resources. Defaults to the name of the Helm binary")
+	f.IntVar(&client.Parallelism, "parallelism", 0, "maximum number of resources of the same kind applied at once. Resources are created all at once and updated one at a time if zero")
+	f.BoolVar(&client.Preflight, "preflight", false, "check that the ConfigMaps and Secrets referenced by the workloads of the release are created by the release or present in the cluster, and warn about those missing")
+	f.BoolVar(&client.PreflightStrict, "preflight-strict", false, "fail on the missing references found by the preflight check. Implies --preflight")
+	f.StringArrayVar(&client.ApplyOnly, "apply-only", []string{}, "(experimental) only apply the resources matching the given 'Kind/name' or template path globs. Can be specified multiple times")
 	addValueOptionsFlags(f, valueOpts)
 	addChartPathOptionsFlags(f, &client.ChartPathOptions)
 	AddWaitFlag(cmd, &client.WaitStrategy)
@@ -230,7 +300,9 @@ func addInstallFlags(cmd *cobra.Command, f *pflag.FlagSet, client *action.Instal
 	}
 }
 
-func runInstall(args []string, client *action.Install, valueOpts *values.Options, out io.Writer) (*release.Release, error) {
+// runInstall locates and loads the chart and installs it with the given
+// values. The source of each value is written to traceValues, if set.
+func runInstall(args []string, client *action.Install, valueOpts *values.Options, out, traceValues io.Writer) (*release.Release, error) {
 	slog.Debug("Original chart version", "version", client.Version)
 	if client.Version == "" && client.Devel {
 		slog.Debug("setting version to >0.0.0-0")
@@ -251,7 +323,13 @@ func runInstall(args []string, client *action.Install, valueOpts *values.Options
 	slog.Debug("Chart path", "path", cp)
 
 	p := getter.All(settings)
-	vals, err := valueOpts.MergeValues(p)
+	var vals map[string]interface{}
+	var sources chartutil.ValueSources
+	if traceValues != nil {
+		vals, sources, err = valueOpts.MergeValuesWithSources(p)
+	} else {
+		vals, err = valueOpts.MergeValues(p)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -300,6 +378,13 @@ func runInstall(args []string, client *action.Install, valueOpts *values.Options
 		}
 	}
 
+	if traceValues != nil {
+		if _, sources, err = chartutil.CoalesceValuesWithSources(chartRequested, vals, sources); err != nil {
+			return nil, err
+		}
+		printValueSources(traceValues, sources)
+	}
+
 	client.Namespace = settings.Namespace()
 
 	// Validate DryRunOption member is one of the allowed values
@@ -20,16 +20,20 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/spf13/cobra"
 
+	"helm.sh/helm/v4/internal/version"
 	"helm.sh/helm/v4/pkg/action"
 	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
 	"helm.sh/helm/v4/pkg/cli/values"
 	"helm.sh/helm/v4/pkg/getter"
+	"helm.sh/helm/v4/pkg/lint/rules"
 	"helm.sh/helm/v4/pkg/lint/support"
 )
 
@@ -40,18 +44,49 @@ the chart is well-formed.
 If the linter encounters things that will cause the chart to fail installation,
 it will emit [ERROR] messages. If it encounters issues that break with convention
 or recommendation, it will emit [WARNING] messages.
+
+With '--output sarif' the messages are printed as a SARIF 2.1.0 document, which
+code scanning tools in CI systems can consume directly.
+
+Resources using Kubernetes APIs that are deprecated or removed in the version
+given by '--kube-version' are reported as [WARNING] messages. Use
+'--skip-deprecations' for charts that deliberately target older clusters.
+
+References in templates to values that have no default in values.yaml, or in
+the values of a subchart, are reported as [WARNING] messages. The check is
+static and may be disabled with '--skip-values-references'.
+
+Organizations may require charts to carry some annotations in Chart.yaml by
+giving a policy file with '--policy', such as:
+
+    annotations:
+    - name: org.example/owner
+    - name: org.example/tier
+      pattern: "frontend|backend"
+
+Annotations that are missing, empty, or whose whole value does not match their
+optional regular expression pattern are reported as [ERROR] messages.
 `
 
+// lintOutputFormats are the formats accepted by 'helm lint --output'.
+var lintOutputFormats = []string{"text", "sarif"}
+
 func newLintCmd(out io.Writer) *cobra.Command {
 	client := action.NewLint()
 	valueOpts := &values.Options{}
 	var kubeVersion string
+	var policyFile string
+	var outfmt string
 
 	cmd := &cobra.Command{
 		Use:   "lint PATH",
 		Short: "examine a chart for possible issues",
 		Long:  longLintHelp,
 		RunE: func(_ *cobra.Command, args []string) error {
+			if !slices.Contains(lintOutputFormats, outfmt) {
+				return fmt.Errorf("invalid format type %q, allowed values: %s", outfmt, strings.Join(lintOutputFormats, ", "))
+			}
+
 			paths := []string{"."}
 			if len(args) > 0 {
 				paths = args
@@ -65,6 +100,14 @@ func newLintCmd(out io.Writer) *cobra.Command {
 				client.KubeVersion = parsedKubeVersion
 			}
 
+			if policyFile != "" {
+				policy, err := rules.LoadPolicy(policyFile)
+				if err != nil {
+					return err
+				}
+				client.Policy = policy
+			}
+
 			if client.WithSubcharts {
 				for _, p := range paths {
 					filepath.Walk(filepath.Join(p, "charts"), func(path string, info os.FileInfo, _ error) error {
@@ -87,11 +130,20 @@ func newLintCmd(out io.Writer) *cobra.Command {
 			}
 
 			var message strings.Builder
+			var linters []support.Linter
 			failed := 0
 			errorsOrWarnings := 0
 
 			for _, path := range paths {
 				result := client.Run([]string{path}, vals)
+				if len(result.Errors) != 0 {
+					failed++
+				}
+
+				if outfmt == "sarif" {
+					linters = append(linters, sarifLinter(path, result, client.Quiet))
+					continue
+				}
 
 				// If there is no errors/warnings and quiet flag is set
 				// go to the next chart
@@ -121,19 +173,25 @@ func newLintCmd(out io.Writer) *cobra.Command {
 					}
 				}
 
-				if len(result.Errors) != 0 {
-					failed++
-				}
-
 				// Adding extra new line here to break up the
 				// results, stops this from being a big wall of
 				// text and makes it easier to follow.
 				fmt.Fprint(&message, "\n")
 			}
 
+			summary := fmt.Sprintf("%d chart(s) linted, %d chart(s) failed", len(paths), failed)
+			if outfmt == "sarif" {
+				if err := support.WriteSARIF(out, version.GetVersion(), linters); err != nil {
+					return err
+				}
+				if failed > 0 {
+					return errors.New(summary)
+				}
+				return nil
+			}
+
 			fmt.Fprint(out, message.String())
 
-			summary := fmt.Sprintf("%d chart(s) linted, %d chart(s) failed", len(paths), failed)
 			if failed > 0 {
 				return errors.New(summary)
 			}
@@ -150,7 +208,36 @@ func newLintCmd(out io.Writer) *cobra.Command {
 	f.BoolVar(&client.Quiet, "quiet", false, "print only warnings and errors")
 	f.BoolVar(&client.SkipSchemaValidation, "skip-schema-validation", false, "if set, disables JSON schema validation")
 	f.StringVar(&kubeVersion, "kube-version", "", "Kubernetes version used for capabilities and deprecation checks")
+	f.BoolVar(&client.SkipDeprecations, "skip-deprecations", false, "if set, does not check for deprecated or removed Kubernetes APIs")
+	f.BoolVar(&client.SkipValuesReferences, "skip-values-references", false, "if set, does not check templates for references to values without a default")
+	f.StringVar(&policyFile, "policy", "", "path to a policy file listing the annotations required in Chart.yaml")
+	f.StringVarP(&outfmt, "output", "o", "text", fmt.Sprintf("prints the output in the specified format. Allowed values: %s", strings.Join(lintOutputFormats, ", ")))
 	addValueOptionsFlags(f, valueOpts)
 
+	err := cmd.RegisterFlagCompletionFunc("output", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return lintOutputFormats, cobra.ShellCompDirectiveNoFileComp
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	return cmd
 }
+
+// sarifLinter collects the messages of a lint result for SARIF output.
+// Errors that prevented the chart from being linted at all are reported
+// against the chart itself.
+func sarifLinter(path string, result *action.LintResult, quiet bool) support.Linter {
+	linter := support.Linter{ChartDir: path}
+	if len(result.Messages) == 0 {
+		for _, err := range result.Errors {
+			linter.Messages = append(linter.Messages, support.NewMessage(support.ErrorSev, "", err))
+		}
+	}
+	for _, msg := range result.Messages {
+		if !quiet || msg.Severity > support.InfoSev {
+			linter.Messages = append(linter.Messages, msg)
+		}
+	}
+	return linter
+}
@@ -87,6 +87,52 @@ func TestLintCmdWithKubeVersionFlag(t *testing.T) {
 		cmd:       fmt.Sprintf("lint --kube-version 1.21.0 --strict %s", testChart),
 		golden:    "output/lint-chart-with-deprecated-api-old-k8s.txt",
 		wantError: false,
+	}, {
+		name:      "lint chart with deprecated api version using kube version, strict and skip deprecations flags",
+		cmd:       fmt.Sprintf("lint --kube-version 1.22.0 --strict --skip-deprecations %s", testChart),
+		golden:    "output/lint-chart-with-deprecated-api-old-k8s.txt",
+		wantError: false,
+	}}
+	runTestCmd(t, tests)
+}
+
+func TestLintCmdWithSARIFOutput(t *testing.T) {
+	tests := []cmdTestCase{{
+		name:      "lint chart with bad subcharts using sarif output",
+		cmd:       "lint --output sarif --with-subcharts testdata/testcharts/chart-with-bad-subcharts",
+		golden:    "output/lint-chart-with-bad-subcharts-sarif.txt",
+		wantError: true,
+	}, {
+		name:   "lint chart with deprecated api using sarif output and --quiet flag",
+		cmd:    "lint -o sarif --quiet --kube-version 1.22.0 testdata/testcharts/chart-with-deprecated-api",
+		golden: "output/lint-chart-with-deprecated-api-sarif.txt",
+	}, {
+		name:      "lint with unknown output format",
+		cmd:       "lint -o xml testdata/testcharts/alpine",
+		wantError: true,
+	}}
+	runTestCmd(t, tests)
+}
+
+func TestLintOutputCompletion(t *testing.T) {
+	tests := []cmdTestCase{{
+		name:   "completion for lint output flag",
+		cmd:    "__complete lint --output ''",
+		golden: "output/lint-output-comp.txt",
+	}}
+	runTestCmd(t, tests)
+}
+
+func TestLintCmdWithSkipValuesReferencesFlag(t *testing.T) {
+	testChart := "testdata/testcharts/chart-with-undefined-values"
+	tests := []cmdTestCase{{
+		name:   "lint chart referencing values without defaults",
+		cmd:    fmt.Sprintf("lint %s", testChart),
+		golden: "output/lint-chart-with-undefined-values.txt",
+	}, {
+		name:   "lint chart referencing values without defaults using --skip-values-references flag",
+		cmd:    fmt.Sprintf("lint --skip-values-references %s", testChart),
+		golden: "output/lint-chart-with-undefined-values-skip.txt",
 	}}
 	runTestCmd(t, tests)
 }
@@ -95,3 +141,19 @@ func TestLintFileCompletion(t *testing.T) {
 	checkFileCompletion(t, "lint", true)
 	checkFileCompletion(t, "lint mypath", true) // Multiple paths can be given
 }
+
+func TestLintCmdWithPolicyFlag(t *testing.T) {
+	testChart := "testdata/testcharts/alpine"
+	tests := []cmdTestCase{{
+		name:      "lint chart missing annotations required by the policy",
+		cmd:       fmt.Sprintf("lint --policy testdata/lint-policy.yaml %s", testChart),
+		golden:    "output/lint-policy.txt",
+		wantError: true,
+	}, {
+		name:      "lint chart with an invalid policy",
+		cmd:       fmt.Sprintf("lint --policy testdata/lint-policy-invalid.yaml %s", testChart),
+		golden:    "output/lint-policy-invalid.txt",
+		wantError: true,
+	}}
+	runTestCmd(t, tests)
+}
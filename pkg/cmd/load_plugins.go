@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -39,6 +40,11 @@ const (
 	pluginDynamicCompletionExecutable = "plugin.complete"
 )
 
+// overriddenByPluginAnnotation marks the commands a plugin of the same name
+// replaces, as plugins predating them may already be installed under their
+// name.
+const overriddenByPluginAnnotation = "helm.sh/overridden-by-plugin"
+
 type PluginError struct {
 	error
 	Code int
@@ -95,7 +101,13 @@ func loadPlugins(baseCmd *cobra.Command, out io.Writer) {
 			DisableFlagParsing: true,
 		}
 
+		// The built-in commands marked with overriddenByPluginAnnotation
+		// give way to the plugin. The user is warned when installing it.
 		// TODO: Make sure a command with this name does not already exist.
+		if existing := overriddenByPlugin(baseCmd, md.Name); existing != nil {
+			slog.Debug("plugin replaces the built-in command", "name", md.Name)
+			baseCmd.RemoveCommand(existing)
+		}
 		baseCmd.AddCommand(c)
 
 		// For completion, we try to load more details about the plugins so as to allow for command and
@@ -111,6 +123,17 @@ func loadPlugins(baseCmd *cobra.Command, out io.Writer) {
 	}
 }
 
+// overriddenByPlugin returns the built-in command of root named name that
+// gives way to a plugin of the same name, or nil if there is none.
+func overriddenByPlugin(root *cobra.Command, name string) *cobra.Command {
+	for _, c := range root.Commands() {
+		if c.Name() == name && c.Annotations[overriddenByPluginAnnotation] == "true" {
+			return c
+		}
+	}
+	return nil
+}
+
 func processParent(cmd *cobra.Command, args []string) ([]string, error) {
 	k, u := manuallyProcessArgs(args)
 	if err := cmd.Parent().ParseFlags(k); err != nil {
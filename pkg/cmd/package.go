@@ -29,6 +29,7 @@ import (
 	"helm.sh/helm/v4/pkg/cli/values"
 	"helm.sh/helm/v4/pkg/downloader"
 	"helm.sh/helm/v4/pkg/getter"
+	"helm.sh/helm/v4/pkg/provenance"
 )
 
 const packageDesc = `
@@ -45,6 +46,23 @@ provide '--keyring path/to/secret/keys' and '--key keyname'.
 
 If '--keyring' is not specified, Helm usually defaults to the public keyring
 unless your environment is otherwise configured.
+
+A chart can be signed by several keys. With '--append-signature', the chart is
+not packaged again: the signature is added to the provenance file of the chart
+archive already in the destination directory, which must be for that archive.
+'helm verify --min-signatures' then requires several of the keys:
+
+  $ helm package --sign ./mychart --key first --keyring ~/.gnupg/secring.gpg
+  $ helm package --sign --append-signature ./mychart --key second --keyring ~/.gnupg/secring.gpg
+
+To sign a chart with sigstore keyless signing instead, use '--signer sigstore'.
+The chart is signed with an ephemeral key, certified by Fulcio for the identity
+of an OIDC token, and the signature is recorded in the Rekor transparency log.
+The sigstore bundle is saved next to the chart archive with a '.sigstore.json'
+extension. The token is read from '--sigstore-id-token', or from the
+SIGSTORE_ID_TOKEN environment variable.
+
+  $ helm package --sign --signer sigstore ./mychart
 `
 
 func newPackageCmd(out io.Writer) *cobra.Command {
@@ -59,7 +77,17 @@ func newPackageCmd(out io.Writer) *cobra.Command {
 			if len(args) == 0 {
 				return fmt.Errorf("need at least one argument, the path to the chart")
 			}
-			if client.Sign {
+			if client.AppendSignature && !client.Sign {
+				return errors.New("--append-signature requires --sign")
+			}
+			if client.Sign && client.Signer == action.SignerSigstore {
+				if client.SigstoreIDToken == "" {
+					client.SigstoreIDToken = os.Getenv("SIGSTORE_ID_TOKEN")
+				}
+				if client.SigstoreIDToken == "" {
+					return errors.New("--sigstore-id-token or SIGSTORE_ID_TOKEN is required for signing a package with sigstore")
+				}
+			} else if client.Sign {
 				if client.Key == "" {
 					return errors.New("--key is required for signing a package")
 				}
@@ -110,6 +138,10 @@ func newPackageCmd(out io.Writer) *cobra.Command {
 				if err != nil {
 					return err
 				}
+				if client.AppendSignature {
+					fmt.Fprintf(out, "Successfully added a signature to the provenance file of: %s\n", p)
+					continue
+				}
 				fmt.Fprintf(out, "Successfully packaged chart and saved it to: %s\n", p)
 			}
 			return nil
@@ -117,8 +149,13 @@ func newPackageCmd(out io.Writer) *cobra.Command {
 	}
 
 	f := cmd.Flags()
-	f.BoolVar(&client.Sign, "sign", false, "use a PGP private key to sign this package")
+	f.BoolVar(&client.Sign, "sign", false, "sign this package, with a PGP private key unless --signer is set")
+	f.StringVar(&client.Signer, "signer", action.SignerPGP, `how to sign the package: "pgp" or "sigstore". Used if --sign is true`)
+	f.StringVar(&client.SigstoreIDToken, "sigstore-id-token", "", "OIDC identity token used for sigstore signing")
+	f.StringVar(&client.FulcioURL, "fulcio-url", provenance.DefaultFulcioURL, "address of the Fulcio certificate authority used for sigstore signing")
+	f.StringVar(&client.RekorURL, "rekor-url", provenance.DefaultRekorURL, "address of the Rekor transparency log used for sigstore signing")
 	f.StringVar(&client.Key, "key", "", "name of the key to use when signing. Used if --sign is true")
+	f.BoolVar(&client.AppendSignature, "append-signature", false, "with --sign, add the signature to the provenance file of the chart archive in the destination directory instead of packaging the chart again")
 	f.StringVar(&client.Keyring, "keyring", defaultKeyring(), "location of a public keyring")
 	f.StringVar(&client.PassphraseFile, "passphrase-file", "", `location of a file which contains the passphrase for the signing key. Use "-" in order to read from stdin.`)
 	f.StringVar(&client.Version, "version", "", "set the version on the chart to this semver version")
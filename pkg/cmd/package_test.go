@@ -16,6 +16,7 @@ limitations under the License.
 package cmd
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -25,6 +26,7 @@ import (
 
 	chart "helm.sh/helm/v4/pkg/chart/v2"
 	"helm.sh/helm/v4/pkg/chart/v2/loader"
+	"helm.sh/helm/v4/pkg/provenance"
 )
 
 func TestPackage(t *testing.T) {
@@ -57,6 +59,13 @@ func TestPackage(t *testing.T) {
 			expect: "keyring is required for signing a package",
 			err:    true,
 		},
+		{
+			name:   "package --sign --signer sigstore, no identity token",
+			args:   []string{"testdata/testcharts/alpine"},
+			flags:  map[string]string{"sign": "1", "signer": "sigstore"},
+			expect: "SIGSTORE_ID_TOKEN is required for signing a package with sigstore",
+			err:    true,
+		},
 		{
 			name:    "package testdata/testcharts/alpine, no save",
 			args:    []string{"testdata/testcharts/alpine"},
@@ -191,6 +200,63 @@ func TestSetAppVersion(t *testing.T) {
 	}
 }
 
+func TestPackageAppendSignature(t *testing.T) {
+	dir := t.TempDir()
+	passphrase := filepath.Join(dir, "passphrase")
+	if err := os.WriteFile(passphrase, []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	secondKeyring := "../provenance/testdata/helm-password-key.secret"
+
+	cmd := fmt.Sprintf("package testdata/testcharts/alpine --destination=%s --append-signature --key=helm-test --keyring=testdata/helm-test-key.secret", dir)
+	if _, _, err := executeActionCommand(cmd); err == nil || !strings.Contains(err.Error(), "--append-signature requires --sign") {
+		t.Fatalf("expected --append-signature to require --sign, got %v", err)
+	}
+	cmd = fmt.Sprintf("package testdata/testcharts/alpine --destination=%s --sign --append-signature --key=helm-test --keyring=testdata/helm-test-key.secret", dir)
+	if _, _, err := executeActionCommand(cmd); err == nil {
+		t.Fatal("expected an error appending a signature without a provenance file")
+	}
+
+	cmd = fmt.Sprintf("package testdata/testcharts/alpine --destination=%s --sign --key=helm-test --keyring=testdata/helm-test-key.secret", dir)
+	if _, output, err := executeActionCommand(cmd); err != nil {
+		t.Fatalf("%s: %s", err, output)
+	}
+	chartPath := filepath.Join(dir, "alpine-0.1.0.tgz")
+	archive, err := os.ReadFile(chartPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd = fmt.Sprintf("package testdata/testcharts/alpine --destination=%s --sign --append-signature --key='password key' --keyring=%s --passphrase-file=%s", dir, secondKeyring, passphrase)
+	_, output, err := executeActionCommand(cmd)
+	if err != nil {
+		t.Fatalf("%s: %s", err, output)
+	}
+	if !strings.Contains(output, "Successfully added a signature to the provenance file of: "+chartPath) {
+		t.Errorf("unexpected output: %s", output)
+	}
+	if after, err := os.ReadFile(chartPath); err != nil || !bytes.Equal(after, archive) {
+		t.Errorf("expected the chart archive to be left as is (%v)", err)
+	}
+
+	signer, err := provenance.NewFromKeyring("testdata/helm-test-key.pub", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := provenance.NewFromKeyring(secondKeyring, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer.KeyRing = append(signer.KeyRing, second.KeyRing...)
+	ver, err := signer.VerifyThreshold(chartPath, chartPath+".prov", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ver.Signers) != 2 {
+		t.Errorf("expected 2 signers, got %d", len(ver.Signers))
+	}
+}
+
 func TestPackageFileCompletion(t *testing.T) {
 	checkFileCompletion(t, "package", true)
 	checkFileCompletion(t, "package mypath", true) // Multiple paths can be given
@@ -30,10 +30,20 @@ import (
 type pluginInstallOptions struct {
 	source  string
 	version string
+	noDeps  bool
 }
 
 const pluginInstallDesc = `
 This command allows you to install a plugin from a url to a VCS repo or a local path.
+
+Plugins stored in an OCI registry are installed from an 'oci://' reference, such
+as 'oci://registry.example.com/plugins/helm-diff:3.9.0', using the registry
+credentials configured with 'helm registry login'. The '--version' flag sets the
+tag of references without one.
+
+Plugins listed in the 'dependencies' section of the plugin.yaml file that are
+not installed yet are installed from their source, along with their own
+dependencies. Use '--no-deps' to skip this.
 `
 
 func newPluginInstallCmd(out io.Writer) *cobra.Command {
@@ -55,11 +65,12 @@ func newPluginInstallCmd(out io.Writer) *cobra.Command {
 		PreRunE: func(_ *cobra.Command, args []string) error {
 			return o.complete(args)
 		},
-		RunE: func(_ *cobra.Command, _ []string) error {
-			return o.run(out)
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return o.run(out, cmd.Root())
 		},
 	}
 	cmd.Flags().StringVar(&o.version, "version", "", "specify a version constraint. If this is not specified, the latest version is installed")
+	cmd.Flags().BoolVar(&o.noDeps, "no-deps", false, "do not install the plugins the plugin depends on")
 	return cmd
 }
 
@@ -68,7 +79,7 @@ func (o *pluginInstallOptions) complete(args []string) error {
 	return nil
 }
 
-func (o *pluginInstallOptions) run(out io.Writer) error {
+func (o *pluginInstallOptions) run(out io.Writer, root *cobra.Command) error {
 	installer.Debug = settings.Debug
 
 	i, err := installer.NewForSource(o.source, o.version)
@@ -85,10 +96,26 @@ func (o *pluginInstallOptions) run(out io.Writer) error {
 		return fmt.Errorf("plugin is installed but unusable: %w", err)
 	}
 
+	if !o.noDeps {
+		deps, err := installer.InstallDependencies(p, settings.PluginsDirectory)
+		for _, dep := range deps {
+			if err := runHook(dep, plugin.Install); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Installed plugin dependency: %s\n", dep.Metadata.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("plugin %s is installed but its dependencies are not: %w", p.Metadata.Name, err)
+		}
+	}
+
 	if err := runHook(p, plugin.Install); err != nil {
 		return err
 	}
 
 	fmt.Fprintf(out, "Installed plugin: %s\n", p.Metadata.Name)
+	if overriddenByPlugin(root, p.Metadata.Name) != nil {
+		fmt.Fprintf(out, "WARNING: the %q plugin replaces the built-in command of the same name\n", p.Metadata.Name)
+	}
 	return nil
 }
@@ -18,6 +18,7 @@ package cmd
 import (
 	"bytes"
 	"os"
+	"path/filepath"
 	"runtime"
 	"sort"
 	"strings"
@@ -26,6 +27,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
+	"helm.sh/helm/v4/pkg/action"
 	release "helm.sh/helm/v4/pkg/release/v1"
 )
 
@@ -360,6 +362,54 @@ func TestPluginDynamicCompletion(t *testing.T) {
 	}
 }
 
+func TestLoadPluginsOverridingBuiltin(t *testing.T) {
+	pluginDir := filepath.Join(t.TempDir(), "diff")
+	if err := os.MkdirAll(pluginDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	metadata := "name: diff\nusage: \"diff plugin\"\ncommand: \"echo diff\"\n"
+	if err := os.WriteFile(filepath.Join(pluginDir, "plugin.yaml"), []byte(metadata), 0644); err != nil {
+		t.Fatal(err)
+	}
+	settings.PluginsDirectory = filepath.Dir(pluginDir)
+
+	out := bytes.NewBuffer(nil)
+	cmd := &cobra.Command{}
+	cmd.AddCommand(newDiffCmd(&action.Configuration{}, out))
+	loadPlugins(cmd, out)
+
+	commands := cmd.Commands()
+	if len(commands) != 1 {
+		t.Fatalf("Expected 1 command, got %d", len(commands))
+	}
+	if commands[0].Short != "diff plugin" {
+		t.Errorf("Expected the plugin to replace the built-in command, got %q", commands[0].Short)
+	}
+}
+
+func TestPluginInstallOverridingBuiltin(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "diff")
+	if err := os.MkdirAll(source, 0755); err != nil {
+		t.Fatal(err)
+	}
+	metadata := "name: diff\nusage: \"diff plugin\"\ncommand: \"echo diff\"\n"
+	if err := os.WriteFile(filepath.Join(source, "plugin.yaml"), []byte(metadata), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HELM_PLUGINS", t.TempDir())
+
+	out := bytes.NewBuffer(nil)
+	root := &cobra.Command{}
+	root.AddCommand(newDiffCmd(&action.Configuration{}, out))
+	o := &pluginInstallOptions{source: source, noDeps: true}
+	if err := o.run(out, root); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `WARNING: the "diff" plugin replaces the built-in command of the same name`) {
+		t.Errorf("Expected a warning about the built-in command, got %q", out.String())
+	}
+}
+
 func TestLoadPlugins_HelmNoPlugins(t *testing.T) {
 	settings.PluginsDirectory = "testdata/helmhome/helm/plugins"
 	settings.RepositoryConfig = "testdata/helmhome/helm/repository"
@@ -21,8 +21,11 @@ import (
 	"io"
 	"log"
 	"log/slog"
+	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 
 	"helm.sh/helm/v4/pkg/action"
 	"helm.sh/helm/v4/pkg/cmd/require"
@@ -41,6 +44,9 @@ directory for the chart and uncompress into that directory.
 If the --verify flag is specified, the requested chart MUST have a provenance
 file, and MUST pass the verification process. Failure in any part of this will
 result in an error, and the chart will not be saved locally.
+
+When the standard error is a terminal, the progress of the download of charts
+larger than 1 MiB from HTTP repositories is rendered on it.
 `
 
 func newPullCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
@@ -72,8 +78,15 @@ func newPullCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 			}
 			client.SetRegistryClient(registryClient)
 
+			var progress *progressBar
+			if term.IsTerminal(int(os.Stderr.Fd())) {
+				progress = &progressBar{out: os.Stderr, threshold: pullProgressThreshold}
+				client.Progress = progress.update
+			}
+
 			for i := 0; i < len(args); i++ {
 				output, err := client.Run(args[i])
+				progress.finish()
 				if err != nil {
 					return err
 				}
@@ -104,3 +117,62 @@ func newPullCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 
 	return cmd
 }
+
+// pullProgressThreshold is the size of the charts above which a progress bar
+// is rendered while they are pulled.
+const pullProgressThreshold = 1 << 20
+
+// progressBarWidth is the number of characters of the bar itself.
+const progressBarWidth = 30
+
+// progressBar renders the progress of downloads on a single line of out,
+// once more than threshold bytes are downloaded or expected.
+type progressBar struct {
+	out       io.Writer
+	threshold int64
+	shown     bool
+}
+
+// update renders the progress of the current download, a new one starting
+// when nothing is downloaded yet.
+func (p *progressBar) update(downloaded, total int64) {
+	if downloaded == 0 {
+		p.finish()
+	}
+	if !p.shown && max(downloaded, total) <= p.threshold {
+		return
+	}
+	p.shown = true
+	if total <= 0 {
+		// The size is unknown, only the downloaded bytes are rendered.
+		fmt.Fprintf(p.out, "\rDownloading... %s", formatBytes(downloaded))
+		return
+	}
+	done := int(min(downloaded, total) * progressBarWidth / total)
+	fmt.Fprintf(p.out, "\r[%s%s] %3d%% %s/%s", strings.Repeat("=", done), strings.Repeat(" ", progressBarWidth-done),
+		min(downloaded, total)*100/total, formatBytes(downloaded), formatBytes(total))
+}
+
+// finish ends the line of the progress bar, if it was rendered. It is a no-op
+// on a nil progressBar.
+func (p *progressBar) finish() {
+	if p == nil || !p.shown {
+		return
+	}
+	fmt.Fprintln(p.out)
+	p.shown = false
+}
+
+// formatBytes formats a number of bytes with a binary unit.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit && exp < 4; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTP"[exp])
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package cmd
 
 import (
+	"bytes"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -393,3 +394,38 @@ func TestPullFileCompletion(t *testing.T) {
 	checkFileCompletion(t, "pull", false)
 	checkFileCompletion(t, "pull repo/chart", false)
 }
+
+func TestPullProgressBar(t *testing.T) {
+	var out bytes.Buffer
+	p := &progressBar{out: &out, threshold: 1024}
+
+	// Small downloads are not rendered.
+	p.update(0, 512)
+	p.update(512, 512)
+	p.finish()
+	if out.Len() != 0 {
+		t.Errorf("expected no progress for a small download, got %q", out.String())
+	}
+
+	p.update(0, 4096)
+	p.update(1024, 4096)
+	p.update(4096, 4096)
+	p.finish()
+	expected := "\r[                              ]   0% 0 B/4.0 KiB" +
+		"\r[=======                       ]  25% 1.0 KiB/4.0 KiB" +
+		"\r[==============================] 100% 4.0 KiB/4.0 KiB\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+
+	// Without a size, the downloaded bytes are rendered past the threshold.
+	out.Reset()
+	p.update(0, -1)
+	p.update(512, -1)
+	p.update(3*1024*1024, -1)
+	p.finish()
+	expected = "\rDownloading... 3.0 MiB\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+}
@@ -32,6 +32,10 @@ Upload a chart to a registry.
 
 If the chart has an associated provenance file,
 it will also be uploaded.
+
+Blobs already in the repository are not uploaded again. With '--mount-from',
+blobs in other repositories of the same registry, such as 'charts/other', are
+mounted from them where the registry allows it, rather than uploaded.
 `
 
 type registryPushOptions struct {
@@ -42,6 +46,7 @@ type registryPushOptions struct {
 	plainHTTP             bool
 	password              string
 	username              string
+	mountFrom             []string
 }
 
 func newPushCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
@@ -84,6 +89,7 @@ func newPushCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 				action.WithTLSClientConfig(o.certFile, o.keyFile, o.caFile),
 				action.WithInsecureSkipTLSVerify(o.insecureSkipTLSverify),
 				action.WithPlainHTTP(o.plainHTTP),
+				action.WithMountFrom(o.mountFrom...),
 				action.WithPushOptWriter(out))
 			client.Settings = settings
 			output, err := client.Run(chartRef, remote)
@@ -103,6 +109,7 @@ func newPushCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	f.BoolVar(&o.plainHTTP, "plain-http", false, "use insecure HTTP connections for the chart upload")
 	f.StringVar(&o.username, "username", "", "chart repository username where to locate the requested chart")
 	f.StringVar(&o.password, "password", "", "chart repository password where to locate the requested chart")
+	f.StringSliceVar(&o.mountFrom, "mount-from", []string{}, "repositories of the registry to mount the blobs of the chart from rather than uploading them (can specify multiple or separate values with commas: charts/a,charts/b)")
 
 	return cmd
 }
@@ -35,12 +35,22 @@ The test command runs the tests for a release.
 
 The argument this command takes is the name of a deployed release.
 The tests to be run are defined in the chart that was installed.
+
+Tests run one at a time, in the order of their weights, and stop at the first
+failure. With --parallel, up to the given number of tests of the same weight run
+at the same time, and every test runs even if others fail. The logs of each test
+are printed separately.
+
+With --logs, the logs of the test pods are streamed as they run, and each line
+is prefixed with the name of its pod. With --batch-logs as well, the logs of
+each pod are printed as a whole once all tests are complete instead.
 `
 
 func newReleaseTestCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	client := action.NewReleaseTesting(cfg)
 	outfmt := output.Table
 	var outputLogs bool
+	var batchLogs bool
 	var filter []string
 
 	cmd := &cobra.Command{
@@ -64,6 +74,9 @@ func newReleaseTestCmd(cfg *action.Configuration, out io.Writer) *cobra.Command
 					client.Filters[action.ExcludeNameFilter] = append(client.Filters[action.ExcludeNameFilter], notName.ReplaceAllLiteralString(f, ""))
 				}
 			}
+			if outputLogs && !batchLogs {
+				client.LogOutput = out
+			}
 			rel, runErr := client.Run(args[0])
 			// We only return an error if we weren't even able to get the
 			// release, otherwise we keep going so we can print status and logs
@@ -80,8 +93,10 @@ func newReleaseTestCmd(cfg *action.Configuration, out io.Writer) *cobra.Command
 			}); err != nil {
 				return err
 			}
+			results := client.Results()
+			fmt.Fprintf(out, "TEST RESULTS:   %d passed, %d failed\n", results.Passed, results.Failed)
 
-			if outputLogs {
+			if outputLogs && batchLogs {
 				// Print a newline to stdout to separate the output
 				fmt.Fprintln(out)
 				if err := client.GetPodLogs(out, rel); err != nil {
@@ -95,9 +110,11 @@ func newReleaseTestCmd(cfg *action.Configuration, out io.Writer) *cobra.Command
 
 	f := cmd.Flags()
 	f.DurationVar(&client.Timeout, "timeout", 300*time.Second, "time to wait for any individual Kubernetes operation (like Jobs for hooks)")
-	f.BoolVar(&outputLogs, "logs", false, "dump the logs from test pods (this runs after all tests are complete, but before any cleanup)")
+	f.BoolVar(&outputLogs, "logs", false, "stream the logs from test pods as they run, each line prefixed with the name of its pod")
+	f.BoolVar(&batchLogs, "batch-logs", false, "with --logs, dump the logs from test pods after all tests are complete, but before any cleanup, instead of streaming them")
 	f.StringSliceVar(&filter, "filter", []string{}, "specify tests by attribute (currently \"name\") using attribute=value syntax or '!attribute=value' to exclude a test (can specify multiple or separate values with commas: name=test1,name=test2)")
 	f.BoolVar(&client.HideNotes, "hide-notes", false, "if set, do not show notes in test output. Does not affect presence in chart metadata")
+	f.IntVar(&client.Parallel, "parallel", 1, "number of tests of the same weight to run at the same time. A failed test does not stop the others when greater than 1")
 
 	return cmd
 }
@@ -24,6 +24,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"time"
 
@@ -50,6 +51,7 @@ type repoAddOptions struct {
 	password             string
 	passwordFromStdinOpt bool
 	passCredentialsAll   bool
+	credentialHelper     string
 	forceUpdate          bool
 	allowDeprecatedRepos bool
 
@@ -58,6 +60,8 @@ type repoAddOptions struct {
 	caFile                string
 	insecureSkipTLSverify bool
 
+	mirrors []string
+
 	repoFile  string
 	repoCache string
 }
@@ -96,6 +100,8 @@ func newRepoAddCmd(out io.Writer) *cobra.Command {
 	f.BoolVar(&o.insecureSkipTLSverify, "insecure-skip-tls-verify", false, "skip tls certificate checks for the repository")
 	f.BoolVar(&o.allowDeprecatedRepos, "allow-deprecated-repos", false, "by default, this command will not allow adding official repos that have been permanently deleted. This disables that behavior")
 	f.BoolVar(&o.passCredentialsAll, "pass-credentials", false, "pass credentials to all domains")
+	f.StringVar(&o.credentialHelper, "credential-helper", "", "credential helper program run to get the credentials of the repository, instead of storing them")
+	f.StringSliceVar(&o.mirrors, "mirror", nil, "URL of a mirror of the repository, used when the repository is unavailable. Mirrors are tried in the order given (can specify multiple or separate values with commas)")
 
 	return cmd
 }
@@ -166,16 +172,31 @@ func (o *repoAddOptions) run(out io.Writer) error {
 		}
 	}
 
+	// Store absolute paths so that later commands find the TLS files no
+	// matter which directory they are run from.
+	for _, file := range []*string{&o.certFile, &o.keyFile, &o.caFile} {
+		if *file == "" {
+			continue
+		}
+		abs, err := filepath.Abs(*file)
+		if err != nil {
+			return err
+		}
+		*file = abs
+	}
+
 	c := repo.Entry{
 		Name:                  o.name,
 		URL:                   o.url,
 		Username:              o.username,
 		Password:              o.password,
 		PassCredentialsAll:    o.passCredentialsAll,
+		CredentialHelper:      o.credentialHelper,
 		CertFile:              o.certFile,
 		KeyFile:               o.keyFile,
 		CAFile:                o.caFile,
 		InsecureSkipTLSverify: o.insecureSkipTLSverify,
+		Mirrors:               o.mirrors,
 	}
 
 	// Check if the repo name is legal
@@ -188,7 +209,7 @@ func (o *repoAddOptions) run(out io.Writer) error {
 	// 2. When the config is different require --force-update
 	if !o.forceUpdate && f.Has(o.name) {
 		existing := f.Get(o.name)
-		if c != *existing {
+		if !reflect.DeepEqual(c, *existing) {
 			// The input coming in for the name is different from what is already
 			// configured. Return an error.
 			return fmt.Errorf("repository name (%s) already exists, please specify a different name", o.name)
@@ -17,12 +17,16 @@ limitations under the License.
 package cmd
 
 import (
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"sync"
 	"testing"
@@ -274,3 +278,150 @@ func TestRepoAddWithPasswordFromStdin(t *testing.T) {
 		t.Errorf("Repo was not successfully added. Output: %s", result)
 	}
 }
+
+func TestRepoAddWithClientCertificate(t *testing.T) {
+	tlsConf := repotest.MakeTestTLSConfig(t, "../../testdata")
+	tlsConf.ClientCAs = tlsConf.RootCAs
+	tlsConf.ClientAuth = tls.RequireAndVerifyClientCert
+	ts := repotest.NewTempServer(
+		t,
+		repotest.WithChartSourceGlob("testdata/testserver/*.*"),
+		repotest.WithTLSConfig(tlsConf),
+	)
+	defer ts.Stop()
+
+	rootDir := t.TempDir()
+	repoFile := filepath.Join(rootDir, "repositories.yaml")
+	os.Setenv(xdg.CacheHomeEnvVar, rootDir)
+
+	o := &repoAddOptions{
+		name:     "mtls",
+		url:      ts.URL(),
+		repoFile: repoFile,
+		caFile:   "../../testdata/rootca.crt",
+	}
+	err := o.run(io.Discard)
+	if err == nil {
+		t.Fatal("Expected repo add to fail without a client certificate")
+	}
+
+	o.certFile = "../../testdata/crt.pem"
+	o.keyFile = "../../testdata/key.pem"
+	if err := o.run(io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := repo.LoadFile(repoFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := f.Get("mtls")
+	for _, file := range []string{entry.CertFile, entry.KeyFile, entry.CAFile} {
+		if !filepath.IsAbs(file) {
+			t.Errorf("Expected TLS file %q to be stored as an absolute path", file)
+		}
+	}
+
+	// repo update uses the stored client certificate.
+	u := &repoUpdateOptions{update: updateCharts, repoFile: repoFile, repoCache: rootDir}
+	if err := u.run(io.Discard); err != nil {
+		t.Errorf("Expected repo update to use the stored client certificate: %s", err)
+	}
+
+	o.name = "mtls-bad"
+	o.keyFile = "../../testdata/rootca.crt"
+	err = o.run(io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "unable to load client certificate") {
+		t.Errorf("Expected a clear error for an invalid key pair, got %v", err)
+	}
+}
+
+func TestRepoAddWithMirrors(t *testing.T) {
+	ts := repotest.NewTempServer(
+		t,
+		repotest.WithChartSourceGlob("testdata/testserver/*.*"),
+	)
+	defer ts.Stop()
+
+	rootDir := t.TempDir()
+	repoFile := filepath.Join(rootDir, "repositories.yaml")
+	os.Setenv(xdg.CacheHomeEnvVar, rootDir)
+
+	// The repository is added while unavailable, as its mirror serves it.
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	o := &repoAddOptions{
+		name:     "mirrored",
+		url:      unavailable.URL,
+		mirrors:  []string{ts.URL()},
+		repoFile: repoFile,
+	}
+	if err := o.run(io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := repo.LoadFile(repoFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := f.Get("mirrored"); e == nil || len(e.Mirrors) != 1 || e.Mirrors[0] != ts.URL() {
+		t.Errorf("expected the mirrors to be persisted, got %v", e)
+	}
+
+	// Adding the same repository again is idempotent, while changing its
+	// mirrors requires --force-update.
+	if err := o.run(io.Discard); err != nil {
+		t.Errorf("expected the same repository to be added again: %s", err)
+	}
+	o.mirrors = nil
+	if err := o.run(io.Discard); err == nil {
+		t.Error("expected an error changing the mirrors without --force-update")
+	}
+}
+
+func TestRepoAddWithCredentialHelper(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("TODO: refactor this test to work on windows")
+	}
+	ts := repotest.NewTempServer(
+		t,
+		repotest.WithChartSourceGlob("testdata/testserver/*.*"),
+		repotest.WithMiddleware(repotest.BasicAuthMiddleware(t)),
+	)
+	defer ts.Stop()
+
+	rootDir := t.TempDir()
+	repoFile := filepath.Join(rootDir, "repositories.yaml")
+	os.Setenv(xdg.CacheHomeEnvVar, rootDir)
+
+	helper := filepath.Join(rootDir, "helm-credential-test")
+	script := "#!/bin/sh\necho '{\"Username\": \"username\", \"Secret\": \"password\"}'\n"
+	if err := os.WriteFile(helper, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	o := &repoAddOptions{
+		name:             "helped",
+		url:              ts.URL(),
+		credentialHelper: helper,
+		repoFile:         repoFile,
+	}
+	if err := o.run(io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := repo.LoadFile(repoFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := f.Get("helped")
+	if e == nil || e.CredentialHelper != helper {
+		t.Fatalf("expected the credential helper to be persisted, got %v", e)
+	}
+	if e.Username != "" || e.Password != "" {
+		t.Errorf("expected no credentials to be persisted, got %q and %q", e.Username, e.Password)
+	}
+}
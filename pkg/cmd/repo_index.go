@@ -17,16 +17,24 @@ limitations under the License.
 package cmd
 
 import (
+	"bytes"
+	"compress/gzip"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"helm.sh/helm/v4/internal/fileutil"
+	"helm.sh/helm/v4/pkg/action"
 	"helm.sh/helm/v4/pkg/cmd/require"
+	"helm.sh/helm/v4/pkg/provenance"
 	"helm.sh/helm/v4/pkg/repo"
 )
 
@@ -37,17 +45,95 @@ and write the result to 'index.yaml' in the current directory.
 This tool is used for creating an 'index.yaml' file for a chart repository. To
 set an absolute URL to the charts, use '--url' flag.
 
+To write the index somewhere else, use '--output'. Passing '-' writes the index
+to stdout, which is useful for piping it into another tool. Passing '-' as the
+directory does the same for the charts of the current directory:
+
+    $ helm repo index --output - . | gzip > index.yaml.gz
+    $ helm repo index - | gzip > index.yaml.gz
+
 To merge the generated index with an existing index file, use the '--merge'
 flag. In this case, the charts found in the current directory will be merged
 into the index passed in with --merge, with local charts taking priority over
-existing charts.
+existing charts. Add '--prune' to drop entries from the merged index whose chart
+archive is no longer present in the directory. Only entries that point inside
+the repository are pruned; charts hosted elsewhere are kept. With
+'--mark-removed', the pruned entries are kept in the index instead, marked as
+removed along with the time of their removal in the 'helm.sh/removed'
+annotation, so that mirrors can track deletions. Helm skips the entries marked
+as removed when looking up and searching charts.
+
+Each entry records in 'created' the modification time of its chart archive.
+
+By default only the given directory and its immediate subdirectories are
+searched for packaged charts. Use '--recursive' to index charts found anywhere
+below the directory; hidden directories such as '.git' are skipped.
+
+To leave some packaged charts out of the index, use '--exclude' with a glob
+pattern. The pattern is matched against the path of each chart archive relative
+to the directory, as well as its file name. The flag may be repeated.
+
+    $ helm repo index --exclude '*-test-*.tgz' .
+
+Re-indexing a large repository can be sped up with '--cache', which records the
+size, modification time, digest and metadata of every chart archive in the given
+file. On the next run, archives whose size and modification time are unchanged
+are not read again. Use '--no-cache' to force a full rescan; the cache file is
+still rewritten afterwards.
+
+With '--checksums', a SHA256SUMS file is written next to the index. It holds the
+SHA-256 digest of the index and of every chart archive found in the directory,
+and can be checked with 'sha256sum -c SHA256SUMS' from the index directory.
+
+With '--gzip', a compressed copy of the index is written alongside it as
+'index.yaml.gz', for servers and CDNs that serve precompressed files. The
+compressed file is reproducible, so regenerating an unchanged index does not
+change it. It cannot be used when writing the index to stdout.
+
+With '--verify', every chart archive is checked against the provenance file next
+to it (for example 'mychart-0.1.0.tgz.prov') using the keys in '--keyring'. The
+verification status, signer, key fingerprint and provenance file digest are
+stored in the annotations of each entry. The command fails, naming the archive,
+if any chart cannot be verified.
+
+With '--sign', every chart archive is signed as it is indexed, with the key named
+by '--key' in '--keyring', and its provenance file is written next to it. The
+archives that already have a provenance file verified by '--keyring' are not
+signed again unless '--resign' is given. The entries are then verified and
+annotated as with '--verify', so that a fully signed repository is published in
+one step:
+
+    $ helm repo index --sign --key 'My Key' --keyring ~/.gnupg/secring.gpg .
+
+With '--validate', the index is checked for chart versions listed more than
+once, such as those left by a faulty publishing pipeline in the index passed
+with '--merge'. Each duplicate is reported, along with the digests of its
+entries when they point at different chart archives, and the index is not
+written.
 `
 
 type repoIndexOptions struct {
-	dir   string
-	url   string
-	merge string
-	json  bool
+	dir         string
+	url         string
+	merge       string
+	json        bool
+	recursive   bool
+	exclude     []string
+	workers     int
+	prune       bool
+	markRemoved bool
+	output      string
+	cache       string
+	noCache     bool
+	checksums   bool
+	gzip        bool
+	verify      bool
+	keyring     string
+	sign        bool
+	key         string
+	resign      bool
+	passphrase  string
+	validate    bool
 }
 
 func newRepoIndexCmd(out io.Writer) *cobra.Command {
@@ -76,26 +162,136 @@ func newRepoIndexCmd(out io.Writer) *cobra.Command {
 	f.StringVar(&o.url, "url", "", "url of chart repository")
 	f.StringVar(&o.merge, "merge", "", "merge the generated index into the given index")
 	f.BoolVar(&o.json, "json", false, "output in JSON format")
+	f.StringVarP(&o.output, "output", "o", "", "write the index to the given file instead of DIR/index.yaml, or to stdout if '-'")
+	f.BoolVar(&o.prune, "prune", false, "remove entries whose chart archive no longer exists in the directory")
+	f.BoolVar(&o.markRemoved, "mark-removed", false, "with --prune, keep the pruned entries in the index, marked as removed")
+	f.BoolVar(&o.recursive, "recursive", false, "index charts in all nested subdirectories")
+	f.IntVar(&o.workers, "workers", runtime.NumCPU(), "number of chart archives to load concurrently")
+	f.BoolVar(&o.checksums, "checksums", false, "write a SHA256SUMS file for the index and chart archives next to the index")
+	f.BoolVar(&o.gzip, "gzip", false, "also write a gzip-compressed copy of the index with a .gz suffix")
+	f.BoolVar(&o.verify, "verify", false, "verify every chart archive against its provenance file and record the result in the index")
+	f.StringVar(&o.keyring, "keyring", defaultKeyring(), "keyring containing the public keys used with --verify, or the signing key used with --sign")
+	f.BoolVar(&o.sign, "sign", false, "sign every chart archive without a valid provenance file with a PGP private key and record the result in the index")
+	f.StringVar(&o.key, "key", "", "name of the key to use when signing. Used if --sign is true")
+	f.BoolVar(&o.resign, "resign", false, "with --sign, sign again the chart archives that already have a valid provenance file")
+	f.StringVar(&o.passphrase, "passphrase-file", "", `location of a file which contains the passphrase for the signing key. Use "-" in order to read from stdin.`)
+	f.BoolVar(&o.validate, "validate", false, "fail without writing the index if it lists any chart version more than once")
+	f.StringVar(&o.cache, "cache", "", "path to a cache file used to skip re-reading unchanged chart archives")
+	f.BoolVar(&o.noCache, "no-cache", false, "ignore the contents of the --cache file and rescan every chart archive")
+	f.StringArrayVar(&o.exclude, "exclude", []string{}, "skip chart archives matching the given glob pattern (can specify multiple)")
 
 	return cmd
 }
 
-func (i *repoIndexOptions) run(_ io.Writer) error {
+func (i *repoIndexOptions) run(out io.Writer) error {
+	if i.dir == "-" {
+		if i.output != "" && i.output != "-" {
+			return errors.New("the directory '-' writes the index to stdout and cannot be combined with --output")
+		}
+		i.dir, i.output = ".", "-"
+	}
 	path, err := filepath.Abs(i.dir)
 	if err != nil {
 		return err
 	}
 
-	return index(path, i.url, i.merge, i.json)
-}
+	dest := i.output
+	if dest == "" {
+		dest = filepath.Join(path, "index.yaml")
+	}
+
+	options := []repo.IndexDirectoryOption{
+		repo.WithRecursive(i.recursive),
+		repo.WithExclude(i.exclude...),
+		repo.WithWorkers(i.workers),
+	}
+	if i.resign && !i.sign {
+		return errors.New("--resign requires --sign")
+	}
+	switch {
+	case i.sign:
+		if i.key == "" {
+			return errors.New("--key is required for signing chart archives")
+		}
+		signer, err := (&action.Package{Keyring: i.keyring, Key: i.key, PassphraseFile: i.passphrase}).Signatory()
+		if err != nil {
+			return fmt.Errorf("failed to load signing key %q: %w", i.key, err)
+		}
+		options = append(options, repo.WithSign(signer, i.resign))
+	case i.verify:
+		options = append(options, repo.WithVerify(i.keyring))
+	}
+	var cache *repo.IndexCache
+	if i.cache != "" {
+		cache = repo.NewIndexCache()
+		if !i.noCache {
+			if cache, err = repo.LoadIndexCache(i.cache); err != nil {
+				return err
+			}
+		}
+		options = append(options, repo.WithIndexCache(cache))
+	}
 
-func index(dir, url, mergeTo string, json bool) error {
-	out := filepath.Join(dir, "index.yaml")
+	if i.markRemoved && !i.prune {
+		return errors.New("--mark-removed requires --prune")
+	}
+	if i.checksums && dest == "-" {
+		return errors.New("--checksums cannot be used when writing the index to stdout")
+	}
+	if i.gzip && dest == "-" {
+		return errors.New("--gzip cannot be used when writing the index to stdout")
+	}
 
-	i, err := repo.IndexDirectory(dir, url)
+	idx, err := repo.IndexDirectory(path, i.url, options...)
 	if err != nil {
 		return err
 	}
+	// Only the charts found in the directory are checksummed. Merged entries
+	// may carry digests that no longer match what is on disk.
+	var digests map[string]string
+	if i.checksums {
+		digests = idx.ArchiveDigests(i.url)
+	}
+	if i.validate {
+		if err := validateIndex(idx, "generated index"); err != nil {
+			return err
+		}
+	}
+	if err := mergeIndex(idx, path, i.url, i.merge, i.json, i.prune, i.markRemoved, i.validate); err != nil {
+		return err
+	}
+	if cache != nil {
+		if err := cache.WriteFile(i.cache, 0o644); err != nil {
+			return fmt.Errorf("failed to write index cache: %w", err)
+		}
+	}
+	if dest == "-" {
+		if i.json {
+			return idx.WriteJSON(out)
+		}
+		return idx.Write(out)
+	}
+	if err := writeIndexFile(idx, dest, i.json); err != nil {
+		return err
+	}
+	var extras []string
+	if i.gzip {
+		if err := writeGzipFile(dest, dest+".gz"); err != nil {
+			return fmt.Errorf("failed to write compressed index: %w", err)
+		}
+		extras = append(extras, dest+".gz")
+	}
+	if i.checksums {
+		return writeChecksumsFile(path, dest, digests, extras...)
+	}
+	return nil
+}
+
+// mergeIndex merges the index at mergeTo into i and prunes entries for missing
+// archives if requested, or marks them as removed if markRemoved is set. The
+// index at mergeTo is validated first if requested. The entries are sorted
+// afterwards.
+func mergeIndex(i *repo.IndexFile, dir, url, mergeTo string, json, prune, markRemoved, validate bool) error {
 	if mergeTo != "" {
 		// if index.yaml is missing then create an empty one to merge into
 		var i2 *repo.IndexFile
@@ -107,11 +303,88 @@ func index(dir, url, mergeTo string, json bool) error {
 			if err != nil {
 				return fmt.Errorf("merge failed: %w", err)
 			}
+			if validate {
+				if err := validateIndex(i2, mergeTo); err != nil {
+					return err
+				}
+			}
 		}
 		i.Merge(i2)
 	}
+	switch {
+	case prune && markRemoved:
+		i.MarkRemoved(dir, url, time.Now())
+	case prune:
+		i.Prune(dir, url)
+	}
 	i.SortEntries()
-	return writeIndexFile(i, out, json)
+	return nil
+}
+
+// validateIndex returns an error reporting every chart version listed more
+// than once in i. The source names the index in the error.
+func validateIndex(i *repo.IndexFile, source string) error {
+	dups := i.Duplicates()
+	if len(dups) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s contains %d duplicate chart version(s):", source, len(dups))
+	for _, dup := range dups {
+		fmt.Fprintf(&b, "\n  - %s", dup)
+	}
+	return errors.New(b.String())
+}
+
+// writeGzipFile writes a gzip-compressed copy of src to dest.
+//
+// The output is reproducible: the compression level is fixed and the gzip
+// header carries neither a file name nor a modification time.
+func writeGzipFile(src, dest string) error {
+	b, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	if _, err := zw.Write(b); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return fileutil.AtomicWriteFile(dest, &buf, 0o644)
+}
+
+// writeChecksumsFile writes a SHA256SUMS file next to the index at dest. It
+// lists the index itself, any extra files and the chart archives below dir
+// with the given digests, using paths relative to the SHA256SUMS file.
+func writeChecksumsFile(dir, dest string, digests map[string]string, extras ...string) error {
+	destDir := filepath.Dir(dest)
+	sums := map[string]string{}
+	for name, digest := range digests {
+		rel, err := filepath.Rel(destDir, filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			return err
+		}
+		sums[filepath.ToSlash(rel)] = digest
+	}
+	for _, file := range append([]string{dest}, extras...) {
+		digest, err := provenance.DigestFile(file)
+		if err != nil {
+			return err
+		}
+		sums[filepath.Base(file)] = digest
+	}
+
+	var b bytes.Buffer
+	if err := repo.WriteChecksums(&b, sums); err != nil {
+		return err
+	}
+	return fileutil.AtomicWriteFile(filepath.Join(destDir, repo.ChecksumsFile), &b, 0o644)
 }
 
 func writeIndexFile(i *repo.IndexFile, out string, json bool) error {
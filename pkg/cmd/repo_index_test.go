@@ -18,12 +18,17 @@ package cmd
 
 import (
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	"helm.sh/helm/v4/pkg/provenance"
 	"helm.sh/helm/v4/pkg/repo"
 )
 
@@ -162,6 +167,586 @@ func TestRepoIndexCmd(t *testing.T) {
 	}
 }
 
+func TestRepoIndexCmdRecursive(t *testing.T) {
+	dir := t.TempDir()
+
+	nested := filepath.Join(dir, "team", "charts")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := linkOrCopy("testdata/testcharts/compressedchart-0.1.0.tgz", filepath.Join(nested, "compressedchart-0.1.0.tgz")); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newRepoIndexCmd(io.Discard)
+	c.ParseFlags([]string{"--recursive", "--url", "https://example.com/charts"})
+	if err := c.RunE(c, []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := repo.LoadIndexFile(filepath.Join(dir, "index.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vs := index.Entries["compressedchart"]
+	if len(vs) != 1 {
+		t.Fatalf("expected 1 version, got %d: %#v", len(vs), vs)
+	}
+	expectedURL := "https://example.com/charts/team/charts/compressedchart-0.1.0.tgz"
+	if vs[0].URLs[0] != expectedURL {
+		t.Errorf("expected %q, got %q", expectedURL, vs[0].URLs[0])
+	}
+}
+
+func TestRepoIndexCmdExclude(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"compressedchart-0.1.0.tgz", "compressedchart-0.2.0.tgz", "reqtest-0.1.0.tgz"} {
+		if err := linkOrCopy(filepath.Join("testdata/testcharts", name), filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := newRepoIndexCmd(io.Discard)
+	c.ParseFlags([]string{"--exclude", "reqtest-*", "--exclude", "*-0.1.0.tgz"})
+	if err := c.RunE(c, []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := repo.LoadIndexFile(filepath.Join(dir, "index.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Entries) != 1 {
+		t.Errorf("expected 1 entry, got %d: %#v", len(index.Entries), index.Entries)
+	}
+	if vs := index.Entries["compressedchart"]; len(vs) != 1 || vs[0].Version != "0.2.0" {
+		t.Errorf("expected only compressedchart 0.2.0, got %#v", vs)
+	}
+
+	c = newRepoIndexCmd(io.Discard)
+	c.ParseFlags([]string{"--exclude", "[-"})
+	if err := c.RunE(c, []string{dir}); err == nil {
+		t.Error("expected error for invalid exclude pattern")
+	}
+}
+
+func TestRepoIndexCmdPrune(t *testing.T) {
+	dir := t.TempDir()
+	destIndex := filepath.Join(dir, "index.yaml")
+
+	comp := filepath.Join(dir, "compressedchart-0.1.0.tgz")
+	if err := linkOrCopy("testdata/testcharts/compressedchart-0.1.0.tgz", comp); err != nil {
+		t.Fatal(err)
+	}
+	if err := linkOrCopy("testdata/testcharts/compressedchart-0.2.0.tgz", filepath.Join(dir, "compressedchart-0.2.0.tgz")); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newRepoIndexCmd(io.Discard)
+	if err := c.RunE(c, []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Add an externally hosted chart that must survive pruning.
+	index, err := repo.LoadIndexFile(destIndex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := index.MustAdd(&chart.Metadata{APIVersion: "v2", Name: "external", Version: "1.0.0"}, "external-1.0.0.tgz", "https://example.com/charts", "sha256:1234567890"); err != nil {
+		t.Fatal(err)
+	}
+	if err := index.WriteFile(destIndex, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Remove(comp); err != nil {
+		t.Fatal(err)
+	}
+
+	c = newRepoIndexCmd(io.Discard)
+	c.ParseFlags([]string{"--merge", destIndex, "--prune"})
+	if err := c.RunE(c, []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err = repo.LoadIndexFile(destIndex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index.Has("compressedchart", "0.1.0") {
+		t.Error("expected compressedchart 0.1.0 to be pruned")
+	}
+	if !index.Has("compressedchart", "0.2.0") {
+		t.Error("expected compressedchart 0.2.0 to be kept")
+	}
+	if !index.Has("external", "1.0.0") {
+		t.Error("expected externally hosted chart to be kept")
+	}
+}
+
+func TestRepoIndexCmdMarkRemoved(t *testing.T) {
+	dir := t.TempDir()
+	destIndex := filepath.Join(dir, "index.yaml")
+
+	comp := filepath.Join(dir, "compressedchart-0.1.0.tgz")
+	if err := linkOrCopy("testdata/testcharts/compressedchart-0.1.0.tgz", comp); err != nil {
+		t.Fatal(err)
+	}
+	if err := linkOrCopy("testdata/testcharts/compressedchart-0.2.0.tgz", filepath.Join(dir, "compressedchart-0.2.0.tgz")); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newRepoIndexCmd(io.Discard)
+	if err := c.RunE(c, []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(comp); err != nil {
+		t.Fatal(err)
+	}
+
+	c = newRepoIndexCmd(io.Discard)
+	c.ParseFlags([]string{"--merge", destIndex, "--mark-removed"})
+	if err := c.RunE(c, []string{dir}); err == nil || err.Error() != "--mark-removed requires --prune" {
+		t.Errorf("expected an error for --mark-removed without --prune, got %v", err)
+	}
+
+	c = newRepoIndexCmd(io.Discard)
+	c.ParseFlags([]string{"--merge", destIndex, "--prune", "--mark-removed"})
+	if err := c.RunE(c, []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := repo.LoadIndexFile(destIndex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var removed *repo.ChartVersion
+	for _, cv := range index.Entries["compressedchart"] {
+		if cv.Version == "0.1.0" {
+			removed = cv
+		}
+	}
+	if removed == nil {
+		t.Fatal("expected compressedchart 0.1.0 to be kept")
+	}
+	if !removed.Removed || removed.Annotations[repo.AnnotationRemoved] == "" {
+		t.Errorf("expected compressedchart 0.1.0 to be marked as removed, got removed %t, annotations %v", removed.Removed, removed.Annotations)
+	}
+	if cv, err := index.Get("compressedchart", ""); err != nil || cv.Version != "0.2.0" {
+		t.Errorf("expected compressedchart 0.2.0 to be the latest version, got %v, %v", cv, err)
+	}
+}
+
+func TestRepoIndexCmdValidate(t *testing.T) {
+	dir := t.TempDir()
+	if err := linkOrCopy("testdata/testcharts/compressedchart-0.1.0.tgz", filepath.Join(dir, "compressedchart-0.1.0.tgz")); err != nil {
+		t.Fatal(err)
+	}
+
+	// A merged index listing the same external chart twice.
+	merge := filepath.Join(t.TempDir(), "index.yaml")
+	index := repo.NewIndexFile()
+	for _, digest := range []string{"sha256:1234567890", "sha256:0987654321"} {
+		if err := index.MustAdd(&chart.Metadata{APIVersion: "v2", Name: "external", Version: "1.0.0"}, "external-1.0.0.tgz", "https://example.com/charts", digest); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := index.WriteFile(merge, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newRepoIndexCmd(io.Discard)
+	c.ParseFlags([]string{"--merge", merge, "--validate"})
+	err := c.RunE(c, []string{dir})
+	expect := merge + " contains 1 duplicate chart version(s):\n  - chart \"external\" version \"1.0.0\" has 2 entries with conflicting digests sha256:1234567890, sha256:0987654321"
+	if err == nil || err.Error() != expect {
+		t.Fatalf("expected error %q, got %v", expect, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "index.yaml")); !os.IsNotExist(err) {
+		t.Error("expected the index not to be written")
+	}
+
+	// Without duplicates the index is written.
+	c = newRepoIndexCmd(io.Discard)
+	c.ParseFlags([]string{"--validate"})
+	if err := c.RunE(c, []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "index.yaml")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRepoIndexCmdOutput(t *testing.T) {
+	dir := t.TempDir()
+	if err := linkOrCopy("testdata/testcharts/compressedchart-0.1.0.tgz", filepath.Join(dir, "compressedchart-0.1.0.tgz")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name string
+		json bool
+	}{
+		{"yaml", false},
+		{"json", true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			c := newRepoIndexCmd(buf)
+			args := []string{"--output", "-"}
+			if tt.json {
+				args = append(args, "--json")
+			}
+			c.ParseFlags(args)
+			if err := c.RunE(c, []string{dir}); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := os.Stat(filepath.Join(dir, "index.yaml")); !os.IsNotExist(err) {
+				t.Errorf("expected no index.yaml to be written, got %v", err)
+			}
+			if json.Valid(buf.Bytes()) != tt.json {
+				t.Errorf("expected valid JSON to be %t, got:\n%s", tt.json, buf.String())
+			}
+
+			f := filepath.Join(t.TempDir(), "index.yaml")
+			if err := os.WriteFile(f, buf.Bytes(), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			index, err := repo.LoadIndexFile(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !index.Has("compressedchart", "0.1.0") {
+				t.Errorf("expected compressedchart 0.1.0 in output, got:\n%s", buf.String())
+			}
+		})
+	}
+
+	dest := filepath.Join(t.TempDir(), "custom.yaml")
+	buf := bytes.NewBuffer(nil)
+	c := newRepoIndexCmd(buf)
+	c.ParseFlags([]string{"-o", dest})
+	if err := c.RunE(c, []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no output, got %q", buf.String())
+	}
+	if _, err := repo.LoadIndexFile(dest); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRepoIndexCmdDirStdout(t *testing.T) {
+	dir := t.TempDir()
+	if err := linkOrCopy("testdata/testcharts/compressedchart-0.1.0.tgz", filepath.Join(dir, "compressedchart-0.1.0.tgz")); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	buf := bytes.NewBuffer(nil)
+	c := newRepoIndexCmd(buf)
+	if err := c.RunE(c, []string{"-"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat("-"); !os.IsNotExist(err) {
+		t.Errorf("expected no directory named '-' to be created, got %v", err)
+	}
+	if _, err := os.Stat("index.yaml"); !os.IsNotExist(err) {
+		t.Errorf("expected no index.yaml to be written, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "compressedchart") {
+		t.Errorf("expected compressedchart in output, got:\n%s", buf.String())
+	}
+
+	c = newRepoIndexCmd(bytes.NewBuffer(nil))
+	c.ParseFlags([]string{"--output", "index.yaml"})
+	if err := c.RunE(c, []string{"-"}); err == nil {
+		t.Error("expected an error combining the directory '-' with --output")
+	}
+}
+
+func TestRepoIndexCmdCache(t *testing.T) {
+	dir := t.TempDir()
+	cacheFile := filepath.Join(t.TempDir(), "index-cache.json")
+	if err := linkOrCopy("testdata/testcharts/compressedchart-0.1.0.tgz", filepath.Join(dir, "compressedchart-0.1.0.tgz")); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newRepoIndexCmd(io.Discard)
+	c.ParseFlags([]string{"--cache", cacheFile})
+	if err := c.RunE(c, []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+	cache, err := repo.LoadIndexCache(cacheFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, ok := cache.Entries["compressedchart-0.1.0.tgz"]
+	if !ok {
+		t.Fatalf("expected cache entry, got %#v", cache.Entries)
+	}
+
+	// A cached digest is reused as long as the archive is unchanged...
+	e.Digest = "sha256:cached"
+	if err := cache.WriteFile(cacheFile, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RunE(c, []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+	index, err := repo.LoadIndexFile(filepath.Join(dir, "index.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := index.Entries["compressedchart"][0].Digest; got != "sha256:cached" {
+		t.Errorf("expected cached digest, got %q", got)
+	}
+
+	// ...unless --no-cache forces a rescan.
+	c.ParseFlags([]string{"--no-cache"})
+	if err := c.RunE(c, []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+	index, err = repo.LoadIndexFile(filepath.Join(dir, "index.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := index.Entries["compressedchart"][0].Digest; got == "sha256:cached" {
+		t.Error("expected digest to be recomputed with --no-cache")
+	}
+}
+
+func TestRepoIndexCmdChecksums(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "nested"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := linkOrCopy("testdata/testcharts/compressedchart-0.1.0.tgz", filepath.Join(dir, "compressedchart-0.1.0.tgz")); err != nil {
+		t.Fatal(err)
+	}
+	if err := linkOrCopy("testdata/testcharts/reqtest-0.1.0.tgz", filepath.Join(dir, "nested", "reqtest-0.1.0.tgz")); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newRepoIndexCmd(io.Discard)
+	c.ParseFlags([]string{"--checksums"})
+	if err := c.RunE(c, []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "SHA256SUMS"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	expectedFiles := []string{"compressedchart-0.1.0.tgz", "index.yaml", "nested/reqtest-0.1.0.tgz"}
+	if len(lines) != len(expectedFiles) {
+		t.Fatalf("expected %d lines, got:\n%s", len(expectedFiles), b)
+	}
+	for n, file := range expectedFiles {
+		fields := strings.SplitN(lines[n], "  ", 2)
+		if len(fields) != 2 || fields[1] != file {
+			t.Fatalf("unexpected line %q, expected checksum of %s", lines[n], file)
+		}
+		digest, err := provenance.DigestFile(filepath.Join(dir, filepath.FromSlash(file)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fields[0] != digest {
+			t.Errorf("expected digest %s for %s, got %s", digest, file, fields[0])
+		}
+	}
+
+	if sha256sum, err := exec.LookPath("sha256sum"); err == nil {
+		cmd := exec.Command(sha256sum, "--check", "--strict", "SHA256SUMS")
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("sha256sum could not verify the checksums: %s\n%s", err, out)
+		}
+	}
+
+	c = newRepoIndexCmd(io.Discard)
+	c.ParseFlags([]string{"--checksums", "--output", "-"})
+	if err := c.RunE(c, []string{dir}); err == nil {
+		t.Error("expected error when combining --checksums with stdout output")
+	}
+}
+
+func TestRepoIndexCmdGzip(t *testing.T) {
+	dir := t.TempDir()
+	if err := linkOrCopy("testdata/testcharts/compressedchart-0.1.0.tgz", filepath.Join(dir, "compressedchart-0.1.0.tgz")); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newRepoIndexCmd(io.Discard)
+	c.ParseFlags([]string{"--gzip", "--checksums"})
+	if err := c.RunE(c, []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+
+	destIndex := filepath.Join(dir, "index.yaml")
+	expected, err := os.ReadFile(destIndex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compressed, err := os.ReadFile(destIndex + ".gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(expected, got) {
+		t.Error("decompressed index does not match index.yaml")
+	}
+	if !zr.ModTime.IsZero() || zr.Name != "" {
+		t.Errorf("expected no modification time or name in gzip header, got %v and %q", zr.ModTime, zr.Name)
+	}
+
+	// Compressing the same index again must produce identical bytes.
+	again := filepath.Join(t.TempDir(), "index.yaml.gz")
+	if err := writeGzipFile(destIndex, again); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(again)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(compressed, b) {
+		t.Error("expected compressed index to be reproducible")
+	}
+
+	sums, err := os.ReadFile(filepath.Join(dir, "SHA256SUMS"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(sums), "  index.yaml.gz\n") {
+		t.Errorf("expected SHA256SUMS to list index.yaml.gz, got:\n%s", sums)
+	}
+
+	c = newRepoIndexCmd(io.Discard)
+	c.ParseFlags([]string{"--gzip", "--output", "-"})
+	if err := c.RunE(c, []string{dir}); err == nil || !strings.Contains(err.Error(), "--gzip cannot be used") {
+		t.Errorf("expected error when combining --gzip with stdout output, got %v", err)
+	}
+}
+
+func TestRepoIndexCmdVerify(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"signtest-0.1.0.tgz", "signtest-0.1.0.tgz.prov"} {
+		if err := linkOrCopy(filepath.Join("testdata/testcharts", name), filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := newRepoIndexCmd(io.Discard)
+	c.ParseFlags([]string{"--verify", "--keyring", "testdata/helm-test-key.pub"})
+	if err := c.RunE(c, []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+	index, err := repo.LoadIndexFile(filepath.Join(dir, "index.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cv, err := index.Get("signtest", "0.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cv.Annotations[repo.AnnotationProvenanceVerified] != "true" {
+		t.Errorf("expected chart to be marked as verified, got %v", cv.Annotations)
+	}
+
+	if err := linkOrCopy("testdata/testcharts/compressedchart-0.1.0.tgz", filepath.Join(dir, "compressedchart-0.1.0.tgz")); err != nil {
+		t.Fatal(err)
+	}
+	err = c.RunE(c, []string{dir})
+	if err == nil {
+		t.Fatal("expected error for unsigned chart")
+	}
+	if !strings.Contains(err.Error(), "compressedchart-0.1.0.tgz") {
+		t.Errorf("expected error to name the unsigned chart, got %s", err)
+	}
+}
+
+func TestRepoIndexCmdSign(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"signtest-0.1.0.tgz", "compressedchart-0.1.0.tgz"} {
+		if err := linkOrCopy(filepath.Join("testdata/testcharts", name), filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The provenance file is copied for the testdata not to be signed again.
+	if err := copyFile("testdata/testcharts/signtest-0.1.0.tgz.prov", filepath.Join(dir, "signtest-0.1.0.tgz.prov")); err != nil {
+		t.Fatal(err)
+	}
+	signed, err := os.ReadFile(filepath.Join(dir, "signtest-0.1.0.tgz.prov"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := newRepoIndexCmd(io.Discard)
+	c.ParseFlags([]string{"--sign", "--key", "helm-test", "--keyring", "testdata/helm-test-key.secret"})
+	if err := c.RunE(c, []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+	index, err := repo.LoadIndexFile(filepath.Join(dir, "index.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"signtest", "compressedchart"} {
+		cv, err := index.Get(name, "0.1.0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cv.Annotations[repo.AnnotationProvenanceVerified] != "true" {
+			t.Errorf("expected %s to be marked as verified, got %v", name, cv.Annotations)
+		}
+		digest, err := provenance.DigestFile(filepath.Join(dir, name+"-0.1.0.tgz.prov"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cv.Annotations[repo.AnnotationProvenanceDigest] != digest {
+			t.Errorf("expected the provenance digest of %s to be %s, got %v", name, digest, cv.Annotations)
+		}
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "signtest-0.1.0.tgz.prov")); !bytes.Equal(b, signed) {
+		t.Error("expected the valid provenance file not to be signed again")
+	}
+
+	c = newRepoIndexCmd(io.Discard)
+	c.ParseFlags([]string{"--sign", "--resign", "--key", "helm-test", "--keyring", "testdata/helm-test-key.secret"})
+	if err := c.RunE(c, []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "signtest-0.1.0.tgz.prov")); bytes.Equal(b, signed) {
+		t.Error("expected the provenance file to be signed again with --resign")
+	}
+
+	for _, tt := range []struct {
+		flags  []string
+		expect string
+	}{
+		{[]string{"--sign", "--keyring", "testdata/helm-test-key.secret"}, "--key is required"},
+		{[]string{"--sign", "--key", "nobody", "--keyring", "testdata/helm-test-key.secret"}, `failed to load signing key "nobody"`},
+		{[]string{"--sign", "--key", "helm-test", "--keyring", "testdata/helm-test-key.pub"}, `failed to load signing key "helm-test"`},
+		{[]string{"--resign"}, "--resign requires --sign"},
+	} {
+		c := newRepoIndexCmd(io.Discard)
+		c.ParseFlags(tt.flags)
+		if err := c.RunE(c, []string{dir}); err == nil || !strings.Contains(err.Error(), tt.expect) {
+			t.Errorf("%v: expected error containing %q, got %v", tt.flags, tt.expect, err)
+		}
+	}
+}
+
 func linkOrCopy(source, target string) error {
 	if err := os.Link(source, target); err != nil {
 		return copyFile(source, target)
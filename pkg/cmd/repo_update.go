@@ -33,6 +33,8 @@ import (
 const updateDesc = `
 Update gets the latest information about charts from the respective chart repositories.
 Information is cached locally, where it is used by commands like 'helm search'.
+The index of a repository whose server sent an ETag or Last-Modified header is
+only downloaded again when it was modified.
 
 You can optionally specify a list of repositories you want to update.
 	$ helm repo update <repo_name> ...
@@ -46,6 +48,7 @@ type repoUpdateOptions struct {
 	repoFile  string
 	repoCache string
 	names     []string
+	retries   int
 }
 
 func newRepoUpdateCmd(out io.Writer) *cobra.Command {
@@ -68,6 +71,9 @@ func newRepoUpdateCmd(out io.Writer) *cobra.Command {
 		},
 	}
 
+	f := cmd.Flags()
+	f.IntVar(&o.retries, "retries", 0, "number of times to retry downloading an index on network errors and 5xx or 429 responses")
+
 	return cmd
 }
 
@@ -101,6 +107,7 @@ func (o *repoUpdateOptions) run(out io.Writer) error {
 			if o.repoCache != "" {
 				r.CachePath = o.repoCache
 			}
+			r.Options = append(r.Options, getter.WithRetries(o.retries))
 			repos = append(repos, r)
 		}
 	}
@@ -19,6 +19,8 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -210,3 +212,42 @@ func TestUpdateChartsFailWithError(t *testing.T) {
 		t.Error("Update was not successful and should return error message because 'fail-on-repo-update-fail' flag set")
 	}
 }
+
+func TestUpdateCmdRetries(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "apiVersion: v1\nentries: {}")
+	}))
+	defer srv.Close()
+
+	rootDir := t.TempDir()
+	repoFile := filepath.Join(rootDir, "repositories.yaml")
+	f := repo.NewFile()
+	f.Add(&repo.Entry{Name: "flaky", URL: srv.URL})
+	if err := f.WriteFile(repoFile, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	o := &repoUpdateOptions{
+		update:    updateCharts,
+		repoFile:  repoFile,
+		repoCache: rootDir,
+	}
+	if err := o.run(io.Discard); err == nil {
+		t.Fatal("Expected the update to fail without retries")
+	}
+
+	requests = 0
+	o.retries = 1
+	if err := o.run(io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected 2 requests, got %d", requests)
+	}
+}
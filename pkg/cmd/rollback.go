@@ -17,8 +17,10 @@ limitations under the License.
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"strconv"
 	"time"
 
@@ -26,6 +28,7 @@ import (
 
 	"helm.sh/helm/v4/pkg/action"
 	"helm.sh/helm/v4/pkg/cmd/require"
+	release "helm.sh/helm/v4/pkg/release/v1"
 )
 
 const rollbackDesc = `
@@ -36,10 +39,17 @@ second is a revision (version) number. If this argument is omitted or set to
 0, it will roll back to the previous release.
 
 To see revision numbers, run 'helm history RELEASE'.
+
+To preview a rollback, combine '--dry-run' with '--show-diff'. Resources added
+since the target revision are listed as deletions, and resources removed since
+are listed as creations:
+
+    $ helm rollback --dry-run --show-diff my-release 2
 `
 
 func newRollbackCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	client := action.NewRollback(cfg)
+	var showDiff bool
 
 	cmd := &cobra.Command{
 		Use:   "rollback <RELEASE> [REVISION]",
@@ -66,6 +76,26 @@ func newRollbackCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 				client.Version = ver
 			}
 
+			if showDiff {
+				if !client.DryRun {
+					return errors.New("--show-diff requires --dry-run")
+				}
+				diffs, err := client.Diff(args[0])
+				if err != nil {
+					return err
+				}
+				unchanged := !slices.ContainsFunc(diffs, func(d release.ResourceDiff) bool {
+					return d.Action != release.DiffUnchanged
+				})
+				if unchanged {
+					fmt.Fprintln(out, "No resources would change.")
+					return nil
+				}
+				fmt.Fprintln(out, "DIFF:")
+				writeDiff(out, diffs)
+				return nil
+			}
+
 			if err := client.Run(args[0]); err != nil {
 				return err
 			}
@@ -77,12 +107,15 @@ func newRollbackCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 
 	f := cmd.Flags()
 	f.BoolVar(&client.DryRun, "dry-run", false, "simulate a rollback")
+	f.BoolVar(&showDiff, "show-diff", false, "show which resources and fields the rollback would change. Requires --dry-run")
 	f.BoolVar(&client.Recreate, "recreate-pods", false, "performs pods restart for the resource if applicable")
 	f.BoolVar(&client.Force, "force", false, "force resource update through delete/recreate if needed")
 	f.BoolVar(&client.DisableHooks, "no-hooks", false, "prevent hooks from running during rollback")
 	f.DurationVar(&client.Timeout, "timeout", 300*time.Second, "time to wait for any individual Kubernetes operation (like Jobs for hooks)")
 	f.BoolVar(&client.WaitForJobs, "wait-for-jobs", false, "if set and --wait enabled, will wait until all Jobs have been completed before marking the release as successful. It will wait for as long as --timeout")
 	f.BoolVar(&client.CleanupOnFail, "cleanup-on-fail", false, "allow deletion of new resources created in this rollback when rollback fails")
+	f.StringVar(&client.FieldManager, "field-manager", "", "name of the field manager recorded for the changes made to resources. Defaults to the name of the Helm binary")
+	f.IntVar(&client.Parallelism, "parallelism", 0, "maximum number of resources of the same kind applied at once. Resources are created all at once and updated one at a time if zero")
 	f.IntVar(&client.MaxHistory, "history-max", settings.MaxHistory, "limit the maximum number of revisions saved per release. Use 0 for no limit")
 	AddWaitFlag(cmd, &client.WaitStrategy)
 
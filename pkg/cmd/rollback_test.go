@@ -82,6 +82,47 @@ func TestRollbackCmd(t *testing.T) {
 	runTestCmd(t, tests)
 }
 
+func TestRollbackShowDiff(t *testing.T) {
+	rels := []*release.Release{
+		{
+			Name:      "funny-honey",
+			Namespace: "default",
+			Info:      &release.Info{Status: release.StatusSuperseded},
+			Chart:     &chart.Chart{},
+			Version:   1,
+			Manifest:  "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\ndata:\n  mode: a\n",
+		},
+		{
+			Name:      "funny-honey",
+			Namespace: "default",
+			Info:      &release.Info{Status: release.StatusDeployed},
+			Chart:     &chart.Chart{},
+			Version:   2,
+			Manifest: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\ndata:\n  mode: b\n" +
+				"---\napiVersion: v1\nkind: Secret\nmetadata:\n  name: added\n",
+		},
+	}
+
+	tests := []cmdTestCase{{
+		name:   "show the diff of a rollback",
+		cmd:    "rollback funny-honey 1 --dry-run --show-diff",
+		golden: "output/rollback-show-diff.txt",
+		rels:   rels,
+	}, {
+		name:   "show the diff of a rollback without changes",
+		cmd:    "rollback funny-honey 2 --dry-run --show-diff",
+		golden: "output/rollback-show-diff-no-changes.txt",
+		rels:   rels,
+	}, {
+		name:      "show diff requires dry run",
+		cmd:       "rollback funny-honey 1 --show-diff",
+		golden:    "output/rollback-show-diff-no-dry-run.txt",
+		rels:      rels,
+		wantError: true,
+	}}
+	runTestCmd(t, tests)
+}
+
 func TestRollbackRevisionCompletion(t *testing.T) {
 	mk := func(name string, vers int, status release.Status) *release.Release {
 		return release.Mock(&release.MockReleaseOptions{
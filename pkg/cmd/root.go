@@ -58,10 +58,12 @@ Environment variables:
 |------------------------------------|------------------------------------------------------------------------------------------------------------|
 | $HELM_CACHE_HOME                   | set an alternative location for storing cached files.                                                      |
 | $HELM_CONFIG_HOME                  | set an alternative location for storing Helm configuration.                                                |
+| $HELM_CREDENTIAL_HELPER            | set the credential helper run for the credentials of chart repositories without credentials of their own.  |
 | $HELM_DATA_HOME                    | set an alternative location for storing Helm data.                                                         |
 | $HELM_DEBUG                        | indicate whether or not Helm is running in Debug mode                                                      |
-| $HELM_DRIVER                       | set the backend storage driver. Values are: configmap, secret, memory, sql.                                |
-| $HELM_DRIVER_SQL_CONNECTION_STRING | set the connection string the SQL storage driver should use.                                               |
+| $HELM_DRIVER                       | set the backend storage driver. Values are: configmap, secret, memory, sql (or its alias postgres).        |
+| $HELM_DRIVER_COMPRESSION_LEVEL     | set the gzip level (-2 to 9, default 9) the Secret storage driver compresses releases with.                |
+| $HELM_DRIVER_SQL_CONNECTION_STRING | set the PostgreSQL connection string the SQL storage driver should use.                                    |
 | $HELM_MAX_HISTORY                  | set the maximum number of helm release history.                                                            |
 | $HELM_NAMESPACE                    | set the namespace used for the helm operations.                                                            |
 | $HELM_NO_PLUGINS                   | disable plugins. Set HELM_NO_PLUGINS=1 to disable plugins.                                                 |
@@ -69,6 +71,8 @@ Environment variables:
 | $HELM_REGISTRY_CONFIG              | set the path to the registry config file.                                                                  |
 | $HELM_REPOSITORY_CACHE             | set the path to the repository cache directory                                                             |
 | $HELM_REPOSITORY_CONFIG            | set the path to the repositories file.                                                                     |
+| $HELM_REPOSITORY_RATE_LIMIT        | limit the requests to each chart repository host to this many per second (default 0, no limit).            |
+| $HELM_REPOSITORY_RATE_BURST        | set the number of requests to a chart repository host allowed at once within its rate limit (default 1).   |
 | $KUBECONFIG                        | set an alternative Kubernetes configuration file (default "~/.kube/config")                                |
 | $HELM_KUBEAPISERVER                | set the Kubernetes API Server Endpoint for authentication                                                  |
 | $HELM_KUBECAFILE                   | set the Kubernetes certificate authority file.                                                             |
@@ -202,6 +206,7 @@ func newRootCmdWithConfig(actionConfig *action.Configuration, out io.Writer, arg
 		return nil, err
 	}
 	actionConfig.RegistryClient = registryClient
+	actionConfig.MaxHistory = settings.MaxHistory
 
 	// Add subcommands
 	cmd.AddCommand(
@@ -213,10 +218,12 @@ func newRootCmdWithConfig(actionConfig *action.Configuration, out io.Writer, arg
 		newLintCmd(out),
 		newPackageCmd(out),
 		newRepoCmd(out),
+		newSchemaCmd(out),
 		newSearchCmd(out),
 		newVerifyCmd(out),
 
 		// release commands
+		newDiffCmd(actionConfig, out),
 		newGetCmd(actionConfig, out),
 		newHistoryCmd(actionConfig, out),
 		newInstallCmd(actionConfig, out),
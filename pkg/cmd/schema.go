@@ -0,0 +1,104 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	"helm.sh/helm/v4/pkg/cmd/require"
+)
+
+const schemaDesc = `
+Generate a JSON schema for the values of a chart from its values.yaml file.
+
+The type of each value is inferred from its default. Comments starting with
+'@schema', on the line of a key or the lines above it, refine the schema of
+the value with semicolon separated 'keyword: value' pairs of JSON schema
+keywords. The 'required' keyword marks the key as required:
+
+    # @schema required: true; type: integer; minimum: 1
+    replicaCount: 1
+
+The schema is printed, or written to the values.schema.json file of the chart
+with '--write', where it is used to validate the values of the chart.
+`
+
+type schemaOptions struct {
+	write     bool
+	chartPath string
+}
+
+func newSchemaCmd(out io.Writer) *cobra.Command {
+	o := &schemaOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "schema [CHART_PATH]",
+		Short: "generate a values schema from the values.yaml file of a chart",
+		Long:  schemaDesc,
+		Args:  require.MaximumNArgs(1),
+		ValidArgsFunction: func(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				// Allow file completion when completing the argument for the path
+				return nil, cobra.ShellCompDirectiveDefault
+			}
+			// No more completions, so disable file completion
+			return noMoreArgsComp()
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			o.chartPath = "."
+			if len(args) > 0 {
+				o.chartPath = args[0]
+			}
+			return o.run(out)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&o.write, "write", "w", false, fmt.Sprintf("write the schema to the %s file of the chart", chartutil.SchemafileName))
+
+	return cmd
+}
+
+func (o *schemaOptions) run(out io.Writer) error {
+	if ok, err := chartutil.IsChartDir(o.chartPath); !ok {
+		return err
+	}
+	data, err := os.ReadFile(filepath.Join(o.chartPath, chartutil.ValuesfileName))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	schema, err := chartutil.GenerateValuesSchema(data)
+	if err != nil {
+		return fmt.Errorf("unable to generate a schema for %s: %w", o.chartPath, err)
+	}
+
+	if !o.write {
+		_, err := out.Write(schema)
+		return err
+	}
+	filename := filepath.Join(o.chartPath, chartutil.SchemafileName)
+	if err := os.WriteFile(filename, schema, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Wrote %s\n", filename)
+	return nil
+}
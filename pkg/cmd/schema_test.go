@@ -0,0 +1,62 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"helm.sh/helm/v4/internal/test"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+)
+
+func TestSchemaCmd(t *testing.T) {
+	tests := []cmdTestCase{{
+		name:   "generate schema",
+		cmd:    "schema testdata/testcharts/chart-with-schema-annotations",
+		golden: "output/schema-generate.txt",
+	}, {
+		name:      "generate schema for a directory without a chart",
+		cmd:       "schema testdata",
+		golden:    "output/schema-generate-no-chart.txt",
+		wantError: true,
+	}}
+	runTestCmd(t, tests)
+}
+
+func TestSchemaCmdWrite(t *testing.T) {
+	dir := t.TempDir()
+	chartPath := filepath.Join(dir, "chart")
+	if err := os.CopyFS(chartPath, os.DirFS("testdata/testcharts/chart-with-schema-annotations")); err != nil {
+		t.Fatal(err)
+	}
+
+	_, out, err := executeActionCommand("schema --write " + chartPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(chartPath, chartutil.SchemafileName)
+	if out != "Wrote "+filename+"\n" {
+		t.Errorf("unexpected output: %q", out)
+	}
+	schema, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertGoldenString(t, string(schema), "output/schema-generate.txt")
+}
@@ -26,6 +26,7 @@ package search
 import (
 	"path"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 
@@ -64,6 +65,7 @@ const verSep = "$$"
 func (i *Index) AddRepo(rname string, ind *repo.IndexFile, all bool) {
 	ind.SortEntries()
 	for name, ref := range ind.Entries {
+		ref = slices.DeleteFunc(slices.Clone(ref), func(cv *repo.ChartVersion) bool { return cv.Removed })
 		if len(ref) == 0 {
 			// Skip chart names that have zero releases.
 			continue
@@ -47,6 +47,11 @@ The status consists of:
 - list of resources that this release consists of
 - details on last test suite run, if applicable
 - additional notes provided by the chart
+
+With '--show-health', the readiness of the resources is checked as 'helm install --wait'
+checks it, and a health verdict is displayed along with the resources that are not ready
+or have failing pods, such as crash looping ones. The verdict is part of the JSON and YAML
+output as "health".
 `
 
 func newStatusCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
@@ -91,6 +96,7 @@ func newStatusCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	f := cmd.Flags()
 
 	f.IntVar(&client.Version, "revision", 0, "if set, display the status of the named release with revision")
+	f.BoolVar(&client.ShowHealth, "show-health", false, "check the readiness of the resources of the release and display its health")
 
 	err := cmd.RegisterFlagCompletionFunc("revision", func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		if len(args) == 1 {
@@ -139,6 +145,9 @@ func (s statusPrinter) WriteTable(out io.Writer) error {
 		_, _ = fmt.Fprintf(out, "APP_VERSION: %s\n", s.release.Chart.Metadata.AppVersion)
 	}
 	_, _ = fmt.Fprintf(out, "DESCRIPTION: %s\n", s.release.Info.Description)
+	if h := s.release.Info.Health; h != nil {
+		writeHealth(out, h)
+	}
 
 	if len(s.release.Info.Resources) > 0 {
 		buf := new(bytes.Buffer)
@@ -167,6 +176,12 @@ func (s statusPrinter) WriteTable(out io.Writer) error {
 		_, _ = fmt.Fprintf(out, "RESOURCES:\n%s\n", buf.String())
 	}
 
+	if len(s.release.Info.Diff) > 0 {
+		_, _ = fmt.Fprintln(out, "DIFF:")
+		writeDiff(out, s.release.Info.Diff)
+		_, _ = fmt.Fprintln(out)
+	}
+
 	executions := executionsByHookEvent(s.release)
 	if tests, ok := executions[release.HookTest]; !ok || len(tests) == 0 {
 		_, _ = fmt.Fprintln(out, "TEST SUITE: None")
@@ -223,6 +238,70 @@ func (s statusPrinter) WriteTable(out io.Writer) error {
 	return nil
 }
 
+// writeHealth writes the health verdict of a release followed by its
+// unhealthy resources.
+func writeHealth(out io.Writer, h *release.Health) {
+	verdict := "healthy"
+	if !h.Healthy {
+		verdict = "unhealthy"
+	}
+	details := []string{fmt.Sprintf("%d/%d resources ready", h.Ready, h.Ready+h.NotReady)}
+	if h.PendingVolumeClaims > 0 {
+		details = append(details, fmt.Sprintf("%d pending volume claims", h.PendingVolumeClaims))
+	}
+	if h.FailingPods > 0 {
+		details = append(details, fmt.Sprintf("%d failing pods", h.FailingPods))
+	}
+	_, _ = fmt.Fprintf(out, "HEALTH: %s (%s)\n", verdict, strings.Join(details, ", "))
+	for _, r := range h.Unhealthy {
+		name := r.Kind + "/" + r.Name
+		if r.Namespace != "" {
+			name = r.Namespace + "/" + name
+		}
+		var problems []string
+		switch {
+		case r.Missing:
+			problems = append(problems, "missing")
+		case !r.Ready:
+			problems = append(problems, "not ready")
+		}
+		if len(r.FailingPods) > 0 {
+			problems = append(problems, "failing pods: "+strings.Join(r.FailingPods, ", "))
+		}
+		_, _ = fmt.Fprintf(out, "  %s: %s\n", name, strings.Join(problems, "; "))
+	}
+}
+
+// diffSymbols prefix each resource and field in the diff output.
+var diffSymbols = map[release.DiffAction]string{
+	release.DiffCreate:    "+",
+	release.DiffUpdate:    "~",
+	release.DiffDelete:    "-",
+	release.DiffUnchanged: "=",
+}
+
+func writeDiff(out io.Writer, diffs []release.ResourceDiff) {
+	for _, d := range diffs {
+		name := d.Name
+		if d.Namespace != "" {
+			name = d.Namespace + "/" + name
+		}
+		_, _ = fmt.Fprintf(out, "%s %s/%s %s (%s)\n", diffSymbols[d.Action], d.APIVersion, d.Kind, name, d.Action)
+		if d.RequiresRecreate {
+			_, _ = fmt.Fprintf(out, "    ! requires recreation: %s\n", d.Message)
+		}
+		for _, f := range d.Added {
+			_, _ = fmt.Fprintf(out, "    + %s\n", f)
+		}
+		for _, f := range d.Changed {
+			_, _ = fmt.Fprintf(out, "    ~ %s\n", f)
+		}
+		for _, f := range d.Removed {
+			_, _ = fmt.Fprintf(out, "    - %s\n", f)
+		}
+	}
+}
+
 func executionsByHookEvent(rel *release.Release) map[release.HookEvent][]*release.Hook {
 	result := make(map[release.HookEvent][]*release.Hook)
 	for _, h := range rel.Hooks {
@@ -86,6 +86,46 @@ func TestStatusCmd(t *testing.T) {
 				Status: release.StatusDeployed,
 			},
 		),
+	}, {
+		name:   "get status of a deployed release with a diff",
+		cmd:    "status flummoxed-chickadee",
+		golden: "output/status-with-diff.txt",
+		rels: releasesMockWithStatus(&release.Info{
+			Status: release.StatusDeployed,
+			Diff: []release.ResourceDiff{{
+				APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "web",
+				Action: release.DiffUpdate, Added: []string{"spec.paused"}, Changed: []string{"spec.replicas"}, Removed: []string{"spec.strategy"},
+			}, {
+				APIVersion: "batch/v1", Kind: "Job", Namespace: "default", Name: "migrate",
+				Action: release.DiffUpdate, RequiresRecreate: true, Message: "field is immutable", Changed: []string{"spec.template"},
+			}, {
+				APIVersion: "v1", Kind: "ConfigMap", Namespace: "default", Name: "settings", Action: release.DiffCreate,
+			}, {
+				APIVersion: "v1", Kind: "Secret", Namespace: "default", Name: "old", Action: release.DiffDelete,
+			}},
+		}),
+	}, {
+		name:   "get status of a deployed release with its health",
+		cmd:    "status flummoxed-chickadee",
+		golden: "output/status-with-health.txt",
+		rels: releasesMockWithStatus(&release.Info{
+			Status: release.StatusDeployed,
+			Health: &release.Health{
+				Ready: 3, NotReady: 3, PendingVolumeClaims: 1, FailingPods: 1,
+				Unhealthy: []release.UnhealthyResource{
+					{Kind: "Deployment", Namespace: "default", Name: "web", FailingPods: []string{"web-6d4cf56db6-x7kqp"}},
+					{Kind: "PersistentVolumeClaim", Namespace: "default", Name: "data"},
+					{Kind: "Service", Namespace: "default", Name: "web", Missing: true},
+				},
+			},
+		}),
+	}, {
+		name:   "check the health of a deployed release in json",
+		cmd:    "status flummoxed-chickadee --show-health -o json",
+		golden: "output/status-with-health.json",
+		rels: releasesMockWithStatus(&release.Info{
+			Status: release.StatusDeployed,
+		}),
 	}, {
 		name:   "get status of a deployed release with test suite",
 		cmd:    "status flummoxed-chickadee",
@@ -47,6 +47,23 @@ Render chart templates locally and display the output.
 Any values that would normally be looked up or retrieved in-cluster will be
 faked locally. Additionally, none of the server-side testing of chart validity
 (e.g. whether an API is supported) is done.
+
+'.Capabilities.APIVersions' only holds the built-in APIs and those given with
+'--api-versions', such as 'cert-manager.io/v1/Certificate' for a custom
+resource. With '--validate', it holds the APIs of the cluster, including the
+custom resources of its CRDs.
+
+With '--list-images', the container images the chart would deploy are listed,
+one per line, instead of the manifests. They include the images of the init
+containers of the workloads, of their hooks unless '--no-hooks' is set, and of
+the custom resources embedding pod templates or naming an image in
+'spec.image'. Combined with '--show-only', only the images of the given
+templates are listed.
+
+To find out where a value comes from, the --trace-values flag lists the values
+on stderr before rendering the chart, each with the values file or the flag
+that set it, or else the chart whose values file gives its default. Defaults
+computed in 'defaults.tpl' are not listed.
 `
 
 func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
@@ -58,6 +75,8 @@ func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	var kubeVersion string
 	var extraAPIs []string
 	var showFiles []string
+	var listImages bool
+	var traceValues bool
 
 	cmd := &cobra.Command{
 		Use:   "template [NAME] [CHART]",
@@ -67,7 +86,7 @@ func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 		ValidArgsFunction: func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			return compInstall(args, toComplete, client)
 		},
-		RunE: func(_ *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			if kubeVersion != "" {
 				parsedKubeVersion, err := chartutil.ParseKubeVersion(kubeVersion)
 				if err != nil {
@@ -89,13 +108,20 @@ func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 			if client.DryRunOption == "" {
 				client.DryRunOption = "true"
 			}
+			if listImages && client.OutputDir != "" {
+				return errors.New("listing images cannot be combined with --output-dir")
+			}
 			client.DryRun = true
 			client.ReleaseName = "release-name"
 			client.Replace = true // Skip the name check
 			client.ClientOnly = !validate
 			client.APIVersions = chartutil.VersionSet(extraAPIs)
 			client.IncludeCRDs = includeCrds
-			rel, err := runInstall(args, client, valueOpts, out)
+			var traceOut io.Writer
+			if traceValues {
+				traceOut = cmd.ErrOrStderr()
+			}
+			rel, err := runInstall(args, client, valueOpts, out, traceOut)
 
 			if err != nil && !settings.Debug {
 				if rel != nil {
@@ -136,6 +162,7 @@ func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 					}
 				}
 
+				output := manifests.String()
 				// if we have a list of files to render, then check that each of the
 				// provided files exists in the chart.
 				if len(showFiles) > 0 {
@@ -180,11 +207,18 @@ func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 							return fmt.Errorf("could not find template %s in chart", f)
 						}
 					}
+					var rendered strings.Builder
 					for _, m := range manifestsToRender {
-						fmt.Fprintf(out, "---\n%s\n", m)
+						fmt.Fprintf(&rendered, "---\n%s\n", m)
+					}
+					output = rendered.String()
+				}
+				if listImages {
+					for _, image := range chartutil.ContainerImages(output) {
+						fmt.Fprintln(out, image)
 					}
 				} else {
-					fmt.Fprintf(out, "%s", manifests.String())
+					fmt.Fprintf(out, "%s", output)
 				}
 			}
 
@@ -199,6 +233,8 @@ func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	f.BoolVar(&validate, "validate", false, "validate your manifests against the Kubernetes cluster you are currently pointing at. This is the same validation performed on an install")
 	f.BoolVar(&includeCrds, "include-crds", false, "include CRDs in the templated output")
 	f.BoolVar(&skipTests, "skip-tests", false, "skip tests from templated output")
+	f.BoolVar(&listImages, "list-images", false, "list the container images referenced by the rendered manifests instead of the manifests")
+	f.BoolVar(&traceValues, "trace-values", false, "print the source of each value, such as a values file, a --set flag or the values of a chart, to stderr")
 	f.BoolVar(&client.IsUpgrade, "is-upgrade", false, "set .Release.IsUpgrade instead of .Release.IsInstall")
 	f.StringVar(&kubeVersion, "kube-version", "", "Kubernetes version used for Capabilities.KubeVersion")
 	f.StringSliceVarP(&extraAPIs, "api-versions", "a", []string{}, "Kubernetes api versions used for Capabilities.APIVersions")
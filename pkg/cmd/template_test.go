@@ -19,6 +19,8 @@ package cmd
 import (
 	"fmt"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -133,6 +135,22 @@ func TestTemplateCmd(t *testing.T) {
 			cmd:    fmt.Sprintf(`template '%s' --skip-tests`, chartPath),
 			golden: "output/template-skip-tests.txt",
 		},
+		{
+			name:   "template list-images",
+			cmd:    fmt.Sprintf(`template '%s' --list-images`, "testdata/testcharts/chart-with-lib-dep"),
+			golden: "output/template-list-images.txt",
+		},
+		{
+			name:   "template list-images with hooks",
+			cmd:    fmt.Sprintf(`template '%s' --list-images --show-only templates/tests/test-nothing.yaml`, chartPath),
+			golden: "output/template-list-images-hooks.txt",
+		},
+		{
+			name:      "template list-images with output-dir",
+			cmd:       fmt.Sprintf(`template '%s' --list-images --output-dir '%s'`, chartPath, t.TempDir()),
+			wantError: true,
+			golden:    "output/template-list-images-output-dir.txt",
+		},
 		{
 			// This test case is to ensure the case where specified dependencies
 			// in the Chart.yaml and those where the Chart.yaml don't have them
@@ -165,6 +183,20 @@ func TestTemplateCmd(t *testing.T) {
 	runTestCmd(t, tests)
 }
 
+func TestTemplateTraceValues(t *testing.T) {
+	cmd := fmt.Sprintf("template '%s' --set service.name=apache --trace-values", chartPath)
+	_, out, err := executeActionCommand(cmd)
+	if err != nil {
+		t.Fatalf("unexpected error, got '%v'", err)
+	}
+	if !strings.Contains(out, "VALUE SOURCES:") {
+		t.Errorf("expected the value sources to be listed, got:\n%s", out)
+	}
+	if !regexp.MustCompile(`(?m)^service\.name\s+--set service\.name=apache\s*$`).MatchString(out) {
+		t.Errorf("expected the source of service.name to be the --set flag, got:\n%s", out)
+	}
+}
+
 func TestTemplateVersionCompletion(t *testing.T) {
 	repoFile := "testdata/helmhome/helm/repositories.yaml"
 	repoCache := "testdata/helmhome/helm/repository"
@@ -35,6 +35,23 @@ as well as the release history, freeing it up for future use.
 
 Use the '--dry-run' flag to see which releases will be uninstalled without actually
 uninstalling them.
+
+Resources annotated with 'helm.sh/resource-policy: keep' are never deleted. The
+'--keep-selector' and '--keep-kinds' flags keep additional resources for a
+single uninstall, for example to preserve data:
+
+    $ helm uninstall --keep-kinds PersistentVolumeClaim my-release
+
+Resources are deleted in tiers, dependents first: custom resources before their
+CustomResourceDefinitions and workloads, workloads before their RBAC and
+configuration, and the resources of a namespace before the Namespace. The
+'--wait-for-tiers' flag waits, within '--timeout', for each tier to be deleted
+before deleting the next one, so that finalizers can run.
+
+With '--wait', the command returns once every deleted resource is gone from the
+API server, including those blocked on finalizers, so that the release can be
+installed again right away. The resources still present when '--timeout' is
+hit are reported along with their finalizers.
 `
 
 func newUninstallCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
@@ -79,6 +96,9 @@ func newUninstallCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	f.StringVar(&client.DeletionPropagation, "cascade", "background", "Must be \"background\", \"orphan\", or \"foreground\". Selects the deletion cascading strategy for the dependents. Defaults to background.")
 	f.DurationVar(&client.Timeout, "timeout", 300*time.Second, "time to wait for any individual Kubernetes operation (like Jobs for hooks)")
 	f.StringVar(&client.Description, "description", "", "add a custom description")
+	f.StringVar(&client.KeepSelector, "keep-selector", "", "label selector (e.g. app=db,tier!=cache) of resources to keep instead of deleting, regardless of their resource policy")
+	f.BoolVar(&client.WaitForTiers, "wait-for-tiers", false, "wait for each tier of resources, such as custom resources before their definitions or workloads before their RBAC, to be deleted before deleting the next tier")
+	f.StringSliceVar(&client.KeepKinds, "keep-kinds", []string{}, "kinds of resources to keep instead of deleting, regardless of their resource policy (can specify multiple or separate values with commas: PersistentVolumeClaim,Secret)")
 	AddWaitFlag(cmd, &client.WaitStrategy)
 
 	return cmd
@@ -31,6 +31,7 @@ import (
 
 	"helm.sh/helm/v4/pkg/action"
 	"helm.sh/helm/v4/pkg/chart/v2/loader"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
 	"helm.sh/helm/v4/pkg/cli/output"
 	"helm.sh/helm/v4/pkg/cli/values"
 	"helm.sh/helm/v4/pkg/cmd/require"
@@ -74,9 +75,52 @@ or '--set' flags. Priority is given to new values.
 
     $ helm upgrade --reuse-values --set foo=bar --set foo=newbar redis ./redis
 
+To carry over only some of the last release's values, such as a generated
+password, name them with the '--reuse-values-keys' flag:
+
+    $ helm upgrade --reuse-values-keys auth.password redis ./redis
+
+To find out where a value comes from, the --trace-values flag lists the values
+on stderr before upgrading the release, each with the values file or the flag
+that set it, or else the chart whose values file gives its default. Defaults
+computed in 'defaults.tpl' and the values reused from the last release are not
+listed.
+
+The --wait-for flag waits, after the resources are ready, until a field of a
+resource of the release namespace satisfies a comparison, given as
+'<kind>/<name>:<jsonpath><operator><value>'. The operator is one of ==, !=, >=,
+<=, > and <, and numbers are compared as such. All the expressions given must
+hold before --timeout, otherwise the upgrade fails and the error lists those
+that do not with the value last observed:
+
+    $ helm upgrade --wait-for 'deployment/web:.status.availableReplicas>=3' myweb ./web
+
 The --dry-run flag will output all generated chart manifests, including Secrets
 which can contain sensitive values. To hide Kubernetes Secrets use the
 --hide-secret flag. Please carefully consider how and when these flags are used.
+
+Combined with '--dry-run=server', the --show-diff flag performs a server-side
+apply dry run against the live objects and reports, per resource, which fields
+would be added, changed or removed:
+
+    $ helm upgrade --dry-run=server --show-diff redis ./redis
+
+Resources are upgraded with a three-way merge of their previous manifest, their
+new manifest and their live object. The 'helm.sh/merge' annotation of a resource
+changes this: with 'client', only the changes between its manifests are applied,
+leaving the fields set by others on the live object untouched; with 'ignore', the
+resource is not updated at all while its manifest is unchanged. The --show-diff
+flag reports such resources from their manifests.
+
+The experimental --apply-only flag upgrades only the resources matching one of
+its selectors: a 'Kind/name' pair, or a glob matching the paths of templates in
+the chart, or a directory of templates. The other resources of the release are
+left as they are, neither updated, created nor deleted, and the manifest of the
+new revision records the selectors. Hooks run as usual. Applying a part of a
+chart may leave the release broken, so the flag requires
+HELM_EXPERIMENTAL_PARTIAL_APPLY=1 in the environment:
+
+    $ HELM_EXPERIMENTAL_PARTIAL_APPLY=1 helm upgrade --apply-only StatefulSet/myapp-postgresql myapp ./myapp
 `
 
 func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
@@ -84,6 +128,7 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	valueOpts := &values.Options{}
 	var outfmt output.Format
 	var createNamespace bool
+	var traceValues bool
 
 	cmd := &cobra.Command{
 		Use:   "upgrade [RELEASE] [CHART]",
@@ -99,9 +144,14 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 			}
 			return noMoreArgsComp()
 		},
-		RunE: func(_ *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			client.Namespace = settings.Namespace()
 
+			var traceOut io.Writer
+			if traceValues {
+				traceOut = cmd.ErrOrStderr()
+			}
+
 			registryClient, err := newRegistryClient(client.CertFile, client.KeyFile, client.CaFile,
 				client.InsecureSkipTLSverify, client.PlainHTTP, client.Username, client.Password)
 			if err != nil {
@@ -149,15 +199,27 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 					instClient.Description = client.Description
 					instClient.DependencyUpdate = client.DependencyUpdate
 					instClient.Labels = client.Labels
+					instClient.Annotations = client.Annotations
+					instClient.ListMergeKeys = client.ListMergeKeys
 					instClient.EnableDNS = client.EnableDNS
 					instClient.HideSecret = client.HideSecret
 					instClient.TakeOwnership = client.TakeOwnership
+					instClient.FieldManager = client.FieldManager
+					instClient.Parallelism = client.Parallelism
+					instClient.Preflight = client.Preflight
+					instClient.PreflightStrict = client.PreflightStrict
+					instClient.ApplyOnly = client.ApplyOnly
+					instClient.WaitFor = client.WaitFor
+					instClient.MaxHistory = client.MaxHistory
 
 					if isReleaseUninstalled(versions) {
 						instClient.Replace = true
 					}
 
-					rel, err := runInstall(args, instClient, valueOpts, out)
+					rel, err := runInstall(args, instClient, valueOpts, out, traceOut)
+					if settings.Debug {
+						printHookRuns(cmd.ErrOrStderr(), instClient.HookRuns())
+					}
 					if err != nil {
 						return err
 					}
@@ -187,7 +249,13 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 			}
 
 			p := getter.All(settings)
-			vals, err := valueOpts.MergeValues(p)
+			var vals map[string]interface{}
+			var sources chartutil.ValueSources
+			if traceOut != nil {
+				vals, sources, err = valueOpts.MergeValuesWithSources(p)
+			} else {
+				vals, err = valueOpts.MergeValues(p)
+			}
 			if err != nil {
 				return err
 			}
@@ -228,6 +296,13 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 				slog.Warn("this chart is deprecated")
 			}
 
+			if traceOut != nil {
+				if _, sources, err = chartutil.CoalesceValuesWithSources(ch, vals, sources); err != nil {
+					return err
+				}
+				printValueSources(traceOut, sources)
+			}
+
 			// Create context and prepare the handle of SIGTERM
 			ctx := context.Background()
 			ctx, cancel := context.WithCancel(ctx)
@@ -244,6 +319,9 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 			}()
 
 			rel, err := client.RunWithContext(ctx, args[0], ch, vals)
+			if settings.Debug {
+				printHookRuns(cmd.ErrOrStderr(), client.HookRuns())
+			}
 			if err != nil {
 				return fmt.Errorf("UPGRADE FAILED: %w", err)
 			}
@@ -267,29 +345,42 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	f.BoolVar(&client.Devel, "devel", false, "use development versions, too. Equivalent to version '>0.0.0-0'. If --version is set, this is ignored")
 	f.StringVar(&client.DryRunOption, "dry-run", "", "simulate an install. If --dry-run is set with no option being specified or as '--dry-run=client', it will not attempt cluster connections. Setting '--dry-run=server' allows attempting cluster connections.")
 	f.BoolVar(&client.HideSecret, "hide-secret", false, "hide Kubernetes Secrets when also using the --dry-run flag")
+	f.BoolVar(&client.ShowDiff, "show-diff", false, "show how the upgrade would change the resources in the cluster. Requires '--dry-run=server'")
 	f.Lookup("dry-run").NoOptDefVal = "client"
 	f.BoolVar(&client.Recreate, "recreate-pods", false, "performs pods restart for the resource if applicable")
 	f.MarkDeprecated("recreate-pods", "functionality will no longer be updated. Consult the documentation for other methods to recreate pods")
 	f.BoolVar(&client.Force, "force", false, "force resource updates through a replacement strategy")
+	f.BoolVar(&client.ForceRecreate, "force-recreate", false, "delete and recreate the resources whose update is rejected because it changes an immutable field, such as the pod template of a Job. Each recreation is logged, as the resource is unavailable in between")
 	f.BoolVar(&client.DisableHooks, "no-hooks", false, "disable pre/post upgrade hooks")
 	f.BoolVar(&client.DisableOpenAPIValidation, "disable-openapi-validation", false, "if set, the upgrade process will not validate rendered templates against the Kubernetes OpenAPI Schema")
 	f.BoolVar(&client.SkipCRDs, "skip-crds", false, "if set, no CRDs will be installed when an upgrade is performed with install flag enabled. By default, CRDs are installed if not already present, when an upgrade is performed with install flag enabled")
-	f.DurationVar(&client.Timeout, "timeout", 300*time.Second, "time to wait for any individual Kubernetes operation (like Jobs for hooks)")
+	f.DurationVar(&client.Timeout, "timeout", 300*time.Second, "time to wait for any individual Kubernetes operation (like Jobs for hooks), unless overridden for a resource by its helm.sh/timeout annotation")
 	f.BoolVar(&client.ResetValues, "reset-values", false, "when upgrading, reset the values to the ones built into the chart")
 	f.BoolVar(&client.ReuseValues, "reuse-values", false, "when upgrading, reuse the last release's values and merge in any overrides from the command line via --set and -f. If '--reset-values' is specified, this is ignored")
+	f.StringSliceVar(&client.ReuseValuesKeys, "reuse-values-keys", []string{}, "when upgrading, reuse only the last release's values at the given dotted paths (can specify multiple or separate values with commas: auth.password,image.tag). Overrides from the command line via --set and -f take precedence. If '--reuse-values' or '--reset-then-reuse-values' is specified, this is ignored")
 	f.BoolVar(&client.ResetThenReuseValues, "reset-then-reuse-values", false, "when upgrading, reset the values to the ones built into the chart, apply the last release's values and merge in any overrides from the command line via --set and -f. If '--reset-values' or '--reuse-values' is specified, this is ignored")
 	f.BoolVar(&client.WaitForJobs, "wait-for-jobs", false, "if set and --wait enabled, will wait until all Jobs have been completed before marking the release as successful. It will wait for as long as --timeout")
+	f.StringArrayVar(&client.WaitFor, "wait-for", []string{}, "wait until the given expression of the form '<kind>/<name>:<jsonpath><operator><value>' holds for a resource of the release namespace. Can be specified multiple times, and all expressions must hold at once. It will wait for what is left of --timeout after the wait of --wait")
 	f.BoolVar(&client.Atomic, "atomic", false, "if set, upgrade process rolls back changes made in case of failed upgrade. The --wait flag will be set automatically to \"watcher\" if --atomic is used")
+	f.BoolVar(&client.NoRollbackOnHookFailure, "no-rollback-on-hook-failure", false, "if set with --atomic, the upgrade is not rolled back when its resources are applied but its post-upgrade hooks fail")
 	f.IntVar(&client.MaxHistory, "history-max", settings.MaxHistory, "limit the maximum number of revisions saved per release. Use 0 for no limit")
+	f.BoolVar(&traceValues, "trace-values", false, "print the source of each value, such as a values file, a --set flag or the values of a chart, to stderr")
 	f.BoolVar(&client.CleanupOnFail, "cleanup-on-fail", false, "allow deletion of new resources created in this upgrade when upgrade fails")
 	f.BoolVar(&client.SubNotes, "render-subchart-notes", false, "if set, render subchart notes along with the parent")
 	f.BoolVar(&client.HideNotes, "hide-notes", false, "if set, do not show notes in upgrade output. Does not affect presence in chart metadata")
 	f.BoolVar(&client.SkipSchemaValidation, "skip-schema-validation", false, "if set, disables JSON schema validation")
 	f.StringToStringVarP(&client.Labels, "labels", "l", nil, "Labels that would be added to release metadata. Should be separated by comma. Original release labels will be merged with upgrade labels. You can unset label using null.")
+	f.StringToStringVar(&client.Annotations, "annotations", nil, "Annotations that would be added to release metadata. Should be separated by comma. Original release annotations will be merged with upgrade annotations. You can unset annotation using null. Their keys must be prefixed with 'helm.sh/'.")
+	f.StringToStringVar(&client.ListMergeKeys, "merge-lists", nil, "merge the lists of the chart values at the given dotted paths with the given ones by the given element key, instead of replacing them (e.g. --merge-lists ports=name,subchart.env=name)")
 	f.StringVar(&client.Description, "description", "", "add a custom description")
 	f.BoolVar(&client.DependencyUpdate, "dependency-update", false, "update dependencies if they are missing before installing the chart")
 	f.BoolVar(&client.EnableDNS, "enable-dns", false, "enable DNS lookups when rendering templates")
 	f.BoolVar(&client.TakeOwnership, "take-ownership", false, "if set, upgrade will ignore the check for helm annotations and take ownership of the existing resources")
+	f.StringVar(&client.FieldManager, "field-manager", "", "name of the field manager recorded for the changes made to resources. Defaults to the name of the Helm binary")
+	f.IntVar(&client.Parallelism, "parallelism", 0, "maximum number of resources of the same kind applied at once. Resources are created all at once and updated one at a time if zero")
+	f.BoolVar(&client.Preflight, "preflight", false, "check that the ConfigMaps and Secrets referenced by the workloads of the release are created by the release or present in the cluster, and warn about those missing")
+	f.BoolVar(&client.PreflightStrict, "preflight-strict", false, "fail on the missing references found by the preflight check. Implies --preflight")
+	f.StringArrayVar(&client.ApplyOnly, "apply-only", []string{}, "(experimental) only apply the resources matching the given 'Kind/name' or template path globs. Can be specified multiple times")
 	addChartPathOptionsFlags(f, &client.ChartPathOptions)
 	addValueOptionsFlags(f, valueOpts)
 	bindOutputFlag(cmd, &outfmt)
@@ -21,6 +21,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -218,6 +219,55 @@ func TestUpgradeWithValue(t *testing.T) {
 
 }
 
+func TestUpgradeWithTraceValues(t *testing.T) {
+	releaseName := "funny-bunny-trace"
+	relMock, ch, chartPath := prepareMockRelease(t, releaseName)
+
+	defer resetEnv()()
+
+	store := storageFixture()
+
+	store.Create(relMock(releaseName, 3, ch))
+
+	cmd := fmt.Sprintf("upgrade %s --set favoriteDrink=tea --trace-values '%s'", releaseName, chartPath)
+	_, out, err := executeActionCommandC(store, cmd)
+	if err != nil {
+		t.Fatalf("unexpected error, got '%v'", err)
+	}
+	if !regexp.MustCompile(`(?m)^favoriteDrink\s+--set favoriteDrink=tea\s*$`).MatchString(out) {
+		t.Errorf("expected the source of favoriteDrink to be the --set flag, got:\n%s", out)
+	}
+}
+
+func TestUpgradeWithReuseValuesKeys(t *testing.T) {
+	releaseName := "funny-bunny-keys"
+	relMock, ch, chartPath := prepareMockRelease(t, releaseName)
+
+	defer resetEnv()()
+
+	store := storageFixture()
+
+	rel := relMock(releaseName, 3, ch)
+	rel.Config = map[string]interface{}{"favoriteDrink": "coffee", "name": "value"}
+	store.Create(rel)
+
+	cmd := fmt.Sprintf("upgrade %s --reuse-values-keys favoriteDrink '%s'", releaseName, chartPath)
+	_, _, err := executeActionCommandC(store, cmd)
+	if err != nil {
+		t.Errorf("unexpected error, got '%v'", err)
+	}
+
+	updatedRel, err := store.Get(releaseName, 4)
+	if err != nil {
+		t.Errorf("unexpected error, got '%v'", err)
+	}
+
+	expected := map[string]interface{}{"favoriteDrink": "coffee"}
+	if !reflect.DeepEqual(updatedRel.Config, expected) {
+		t.Errorf("Expected values %v, got %v", expected, updatedRel.Config)
+	}
+}
+
 func TestUpgradeWithStringValue(t *testing.T) {
 	releaseName := "funny-bunny-v3"
 	relMock, ch, chartPath := prepareMockRelease(t, releaseName)
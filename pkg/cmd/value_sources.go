@@ -0,0 +1,39 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/gosuri/uitable"
+
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+)
+
+// printValueSources writes the source of each value to out, sorted by the
+// path of the values.
+func printValueSources(out io.Writer, sources chartutil.ValueSources) {
+	tbl := uitable.New()
+	tbl.AddRow("VALUE", "SOURCE")
+	for _, path := range slices.Sorted(maps.Keys(sources)) {
+		tbl.AddRow(path, sources[path])
+	}
+	fmt.Fprintf(out, "VALUE SOURCES:\n%s\n", tbl)
+}
@@ -0,0 +1,42 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+)
+
+func TestPrintValueSources(t *testing.T) {
+	var out bytes.Buffer
+	printValueSources(&out, chartutil.ValueSources{
+		"replicaCount": "values-prod.yaml",
+		"image.tag":    "--set image.tag=1.2.3",
+		"service.port": "values.yaml of chart nginx",
+	})
+	expect := `VALUE SOURCES:
+VALUE       	SOURCE                    
+image.tag   	--set image.tag=1.2.3     
+replicaCount	values-prod.yaml          
+service.port	values.yaml of chart nginx
+`
+	if out.String() != expect {
+		t.Errorf("expected:\n%s\ngot:\n%s", expect, out.String())
+	}
+}
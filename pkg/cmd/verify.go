@@ -34,6 +34,18 @@ tampered with, and was packaged by a trusted provider.
 This command can be used to verify a local chart. Several other commands provide
 '--verify' flags that run the same validation. To generate a signed package, use
 the 'helm package --sign' command.
+
+A provenance file may carry signatures from several keys, added with
+'helm package --sign --append-signature'. Use '--min-signatures' to require that
+a number of distinct keys from the keyring signed the chart.
+
+Charts signed with sigstore are verified with '--signer sigstore', against the
+'.sigstore.json' bundle next to the chart. The identity the signing certificate
+was issued to and the issuer of its OIDC token must be given:
+
+  $ helm verify --signer sigstore \
+      --certificate-identity me@example.com \
+      --certificate-oidc-issuer https://accounts.google.com mychart-0.1.0.tgz
 `
 
 func newVerifyCmd(out io.Writer) *cobra.Command {
@@ -64,7 +76,15 @@ func newVerifyCmd(out io.Writer) *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVar(&client.Keyring, "keyring", defaultKeyring(), "keyring containing public keys")
+	f := cmd.Flags()
+	f.StringVar(&client.Keyring, "keyring", defaultKeyring(), "keyring containing public keys")
+	f.IntVar(&client.MinSignatures, "min-signatures", 1, "minimum number of distinct keys from the keyring that must have signed the chart")
+	f.StringVar(&client.Signer, "signer", action.SignerPGP, `kind of signature to verify: "pgp" or "sigstore"`)
+	f.StringVar(&client.SigstoreOptions.CertificateIdentity, "certificate-identity", "", "identity expected in the sigstore signing certificate")
+	f.StringVar(&client.SigstoreOptions.CertificateIdentityRegexp, "certificate-identity-regexp", "", "regular expression the identity in the sigstore signing certificate must match")
+	f.StringVar(&client.SigstoreOptions.CertificateOIDCIssuer, "certificate-oidc-issuer", "", "OIDC issuer expected in the sigstore signing certificate")
+	f.StringVar(&client.SigstoreOptions.CertificateOIDCIssuerRegexp, "certificate-oidc-issuer-regexp", "", "regular expression the OIDC issuer in the sigstore signing certificate must match")
+	f.StringVar(&client.SigstoreOptions.TrustedRootPath, "trusted-root", "", "path to a sigstore trusted root. Defaults to the root of the public sigstore instance")
 
 	return cmd
 }
@@ -62,6 +62,24 @@ func TestVerifyCmd(t *testing.T) {
 			expect:    fmt.Sprintf("could not load provenance file testdata/testcharts/compressedchart-0.1.0.tgz.prov: %s testdata/testcharts/compressedchart-0.1.0.tgz.prov: %s", statExe, statFileMsg),
 			wantError: true,
 		},
+		{
+			name:      "verify --min-signatures requires enough trusted signers",
+			cmd:       "verify testdata/testcharts/signtest-0.1.0.tgz --keyring testdata/helm-test-key.pub --min-signatures 2",
+			expect:    "chart is signed by 1 trusted key(s), but 2 are required",
+			wantError: true,
+		},
+		{
+			name:      "verify --signer sigstore requires an identity",
+			cmd:       "verify testdata/testcharts/signtest-0.1.0.tgz --signer sigstore --certificate-oidc-issuer https://accounts.google.com",
+			expect:    "a certificate identity is required to verify sigstore signatures",
+			wantError: true,
+		},
+		{
+			name:      "verify rejects unknown signers",
+			cmd:       "verify testdata/testcharts/signtest-0.1.0.tgz --signer x509",
+			expect:    `unknown signer "x509"`,
+			wantError: true,
+		},
 		{
 			name:      "verify validates a properly signed chart",
 			cmd:       "verify testdata/testcharts/signtest-0.1.0.tgz --keyring testdata/helm-test-key.pub",
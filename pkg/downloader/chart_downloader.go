@@ -16,6 +16,7 @@ limitations under the License.
 package downloader
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -72,6 +73,17 @@ type ChartDownloader struct {
 	RegistryClient   *registry.Client
 	RepositoryConfig string
 	RepositoryCache  string
+	// SkipDigestVerify disables the check of a downloaded chart against the
+	// digest recorded for it in the index of its repository.
+	SkipDigestVerify bool
+
+	// digest is the digest recorded in the index of the repository for the
+	// chart resolved, if any.
+	digest string
+
+	// repository is the repository the chart is resolved in, if any, whose
+	// mirrors are used when it is unavailable.
+	repository *repo.ChartRepository
 }
 
 // DownloadTo retrieves a chart. Depending on the settings, it may also download a provenance file.
@@ -98,10 +110,19 @@ func (c *ChartDownloader) DownloadTo(ref, version, dest string) (string, *proven
 
 	c.Options = append(c.Options, getter.WithAcceptHeader("application/gzip,application/octet-stream"))
 
-	data, err := g.Get(u.String(), c.Options...)
+	get := g.Get
+	if c.repository != nil {
+		get = c.repository.Get
+	}
+	data, err := get(u.String(), c.Options...)
 	if err != nil {
 		return "", nil, err
 	}
+	if c.digest != "" && !c.SkipDigestVerify {
+		if err := verifyDigest(data.Bytes(), c.digest); err != nil {
+			return "", nil, fmt.Errorf("failed to verify %s: %w", u, err)
+		}
+	}
 
 	name := filepath.Base(u.Path)
 	if u.Scheme == registry.OCIScheme {
@@ -117,7 +138,7 @@ func (c *ChartDownloader) DownloadTo(ref, version, dest string) (string, *proven
 	// If provenance is requested, verify it.
 	ver := &provenance.Verification{}
 	if c.Verify > VerifyNever {
-		body, err := g.Get(u.String() + ".prov")
+		body, err := get(u.String()+".prov", c.Options...)
 		if err != nil {
 			if c.Verify == VerifyAlways {
 				return destfile, ver, fmt.Errorf("failed to fetch provenance %q", u.String()+".prov")
@@ -157,7 +178,11 @@ func (c *ChartDownloader) DownloadTo(ref, version, dest string) (string, *proven
 //   - If version is non-empty, this will return the URL for that version
 //   - If version is empty, this will return the URL for the latest version
 //   - If no version can be found, an error is returned
+//
+// The digest of the chart in the index of its repository, if any, is recorded
+// for DownloadTo to verify the chart against.
 func (c *ChartDownloader) ResolveChartVersion(ref, version string) (*url.URL, error) {
+	c.digest = ""
 	u, err := url.Parse(ref)
 	if err != nil {
 		return nil, fmt.Errorf("invalid chart URL format: %s", ref)
@@ -179,7 +204,7 @@ func (c *ChartDownloader) ResolveChartVersion(ref, version string) (*url.URL, er
 		// we want to find the repo in case we have special SSL cert config
 		// for that repo.
 
-		rc, err := c.scanReposForURL(ref, rf)
+		rc, cv, err := c.scanReposForURL(ref, rf)
 		if err != nil {
 			// If there is no special config, return the default HTTP client and
 			// swallow the error.
@@ -193,6 +218,10 @@ func (c *ChartDownloader) ResolveChartVersion(ref, version string) (*url.URL, er
 
 		// If we get here, we don't need to go through the next phase of looking
 		// up the URL. We have it already. So we just set the parameters and return.
+		c.digest = cv.Digest
+		if c.repository, err = repo.NewChartRepository(rc, c.Getters); err != nil {
+			return u, err
+		}
 		c.Options = append(
 			c.Options,
 			getter.WithURL(rc.URL),
@@ -207,6 +236,9 @@ func (c *ChartDownloader) ResolveChartVersion(ref, version string) (*url.URL, er
 				getter.WithPassCredentialsAll(rc.PassCredentialsAll),
 			)
 		}
+		if rc.CredentialHelper != "" {
+			c.Options = append(c.Options, getter.WithCredentialHelper(rc.CredentialHelper))
+		}
 		return u, nil
 	}
 
@@ -231,6 +263,7 @@ func (c *ChartDownloader) ResolveChartVersion(ref, version string) (*url.URL, er
 	if err != nil {
 		return u, err
 	}
+	c.repository = r
 
 	if r != nil && r.Config != nil {
 		if r.Config.CertFile != "" || r.Config.KeyFile != "" || r.Config.CAFile != "" {
@@ -242,6 +275,9 @@ func (c *ChartDownloader) ResolveChartVersion(ref, version string) (*url.URL, er
 				getter.WithPassCredentialsAll(r.Config.PassCredentialsAll),
 			)
 		}
+		if r.Config.CredentialHelper != "" {
+			c.Options = append(c.Options, getter.WithCredentialHelper(r.Config.CredentialHelper))
+		}
 	}
 
 	// Next, we need to load the index, and actually look up the chart.
@@ -259,6 +295,7 @@ func (c *ChartDownloader) ResolveChartVersion(ref, version string) (*url.URL, er
 	if len(cv.URLs) == 0 {
 		return u, fmt.Errorf("chart %q has no downloadable URLs", ref)
 	}
+	c.digest = cv.Digest
 
 	// TODO: Seems that picking first URL is not fully correct
 	resolvedURL, err := repo.ResolveReferenceURL(rc.URL, cv.URLs[0])
@@ -274,6 +311,12 @@ func (c *ChartDownloader) ResolveChartVersion(ref, version string) (*url.URL, er
 // It assumes that a chart archive file is accompanied by a provenance file whose
 // name is the archive file name plus the ".prov" extension.
 func VerifyChart(path, keyring string) (*provenance.Verification, error) {
+	return VerifyChartThreshold(path, keyring, 1)
+}
+
+// VerifyChartThreshold verifies a chart like VerifyChart, and additionally
+// requires it to be signed by at least threshold distinct keys in keyring.
+func VerifyChartThreshold(path, keyring string, threshold int) (*provenance.Verification, error) {
 	// For now, error out if it's not a tar file.
 	switch fi, err := os.Stat(path); {
 	case err != nil:
@@ -293,7 +336,20 @@ func VerifyChart(path, keyring string) (*provenance.Verification, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to load keyring: %w", err)
 	}
-	return sig.Verify(path, provfile)
+	return sig.VerifyThreshold(path, provfile, threshold)
+}
+
+// verifyDigest checks that the SHA-256 digest of data is the digest recorded in
+// a repository index, with or without a "sha256:" prefix.
+func verifyDigest(data []byte, expected string) error {
+	digest, err := provenance.Digest(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(strings.TrimPrefix(expected, "sha256:"), digest) {
+		return fmt.Errorf("digest sha256:%s does not match the digest %s recorded in the repository index", digest, expected)
+	}
+	return nil
 }
 
 // isTar tests whether the given file is a tar file.
@@ -320,7 +376,8 @@ func pickChartRepositoryConfigByName(name string, cfgs []*repo.Entry) (*repo.Ent
 //
 // This will attempt to find the given URL in all of the known repositories files.
 //
-// If the URL is found, this will return the repo entry that contained that URL.
+// If the URL is found, this will return the repo entry that contained that URL,
+// along with the chart version it belongs to.
 //
 // If all of the repos are checked, but the URL is not found, an ErrNoOwnerRepo
 // error is returned.
@@ -334,33 +391,33 @@ func pickChartRepositoryConfigByName(name string, cfgs []*repo.Entry) (*repo.Ent
 // The same URL can technically exist in two or more repositories. This algorithm
 // will return the first one it finds. Order is determined by the order of repositories
 // in the repositories.yaml file.
-func (c *ChartDownloader) scanReposForURL(u string, rf *repo.File) (*repo.Entry, error) {
+func (c *ChartDownloader) scanReposForURL(u string, rf *repo.File) (*repo.Entry, *repo.ChartVersion, error) {
 	// FIXME: This is far from optimal. Larger installations and index files will
 	// incur a performance hit for this type of scanning.
 	for _, rc := range rf.Repositories {
 		r, err := repo.NewChartRepository(rc, c.Getters)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		idxFile := filepath.Join(c.RepositoryCache, helmpath.CacheIndexFile(r.Config.Name))
 		i, err := repo.LoadIndexFile(idxFile)
 		if err != nil {
-			return nil, fmt.Errorf("no cached repo found. (try 'helm repo update'): %w", err)
+			return nil, nil, fmt.Errorf("no cached repo found. (try 'helm repo update'): %w", err)
 		}
 
 		for _, entry := range i.Entries {
 			for _, ver := range entry {
 				for _, dl := range ver.URLs {
 					if urlutil.Equal(u, dl) {
-						return rc, nil
+						return rc, ver, nil
 					}
 				}
 			}
 		}
 	}
 	// This means that there is no repo file for the given URL.
-	return nil, ErrNoOwnerRepo
+	return nil, nil, ErrNoOwnerRepo
 }
 
 func loadRepoConfig(file string) (*repo.File, error) {
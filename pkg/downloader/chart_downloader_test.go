@@ -18,6 +18,8 @@ package downloader
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"helm.sh/helm/v4/internal/test/ensure"
@@ -140,7 +142,7 @@ func TestResolveChartOpts(t *testing.T) {
 			continue
 		}
 
-		if *(got.(*getter.HTTPGetter)) != *(expect.(*getter.HTTPGetter)) {
+		if !reflect.DeepEqual(got.(*getter.HTTPGetter), expect.(*getter.HTTPGetter)) {
 			t.Errorf("%s: expected %s, got %s", tt.name, expect, got)
 		}
 	}
@@ -325,6 +327,83 @@ func TestDownloadTo_VerifyLater(t *testing.T) {
 	}
 }
 
+func TestDownloadTo_Digest(t *testing.T) {
+	srv := repotest.NewTempServer(
+		t,
+		repotest.WithChartSourceGlob("testdata/*.tgz*"),
+	)
+	defer srv.Stop()
+	if err := srv.CreateIndex(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Configure the server as a repository with a tampered digest for the
+	// chart in its cached index.
+	dir := t.TempDir()
+	cfgFile := filepath.Join(dir, "repositories.yaml")
+	rf := repo.NewFile()
+	rf.Add(&repo.Entry{Name: "test", URL: srv.URL()})
+	if err := rf.WriteFile(cfgFile, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cache := filepath.Join(dir, "cache")
+	if err := os.MkdirAll(cache, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := repo.LoadIndexFile(filepath.Join(srv.Root(), "index.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cv, err := idx.Get("signtest", "0.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := cv.Digest
+	cv.Digest = "sha256:0123456789abcdef"
+	if err := idx.WriteFile(filepath.Join(cache, "test-index.yaml"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	newDownloader := func(skip bool) *ChartDownloader {
+		return &ChartDownloader{
+			Out:              os.Stderr,
+			RepositoryConfig: cfgFile,
+			RepositoryCache:  cache,
+			SkipDigestVerify: skip,
+			Getters: getter.All(&cli.EnvSettings{
+				RepositoryConfig: cfgFile,
+				RepositoryCache:  cache,
+			}),
+		}
+	}
+
+	// The chart is referenced both by name and by URL.
+	for _, ref := range []string{"test/signtest", srv.URL() + "/signtest-0.1.0.tgz"} {
+		dest := t.TempDir()
+		_, _, err := newDownloader(false).DownloadTo(ref, "0.1.0", dest)
+		expect := "does not match the digest sha256:0123456789abcdef recorded in the repository index"
+		if err == nil || !strings.Contains(err.Error(), expect) {
+			t.Errorf("%s: expected error containing %q, got %v", ref, expect, err)
+		}
+		if _, err := os.Stat(filepath.Join(dest, "signtest-0.1.0.tgz")); !os.IsNotExist(err) {
+			t.Errorf("%s: expected the chart not to be written", ref)
+		}
+
+		if _, _, err := newDownloader(true).DownloadTo(ref, "0.1.0", dest); err != nil {
+			t.Errorf("%s: expected the digest check to be skipped: %s", ref, err)
+		}
+	}
+
+	// The recorded digest matches the chart.
+	cv.Digest = "sha256:" + digest
+	if err := idx.WriteFile(filepath.Join(cache, "test-index.yaml"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := newDownloader(false).DownloadTo("test/signtest", "0.1.0", t.TempDir()); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestScanReposForURL(t *testing.T) {
 	c := ChartDownloader{
 		Out:              os.Stderr,
@@ -343,7 +422,7 @@ func TestScanReposForURL(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	entry, err := c.scanReposForURL(u, rf)
+	entry, _, err := c.scanReposForURL(u, rf)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -354,7 +433,7 @@ func TestScanReposForURL(t *testing.T) {
 
 	// A lookup failure should produce an ErrNoOwnerRepo
 	u = "https://no.such.repo/foo/bar-1.23.4.tgz"
-	if _, err = c.scanReposForURL(u, rf); err != ErrNoOwnerRepo {
+	if _, _, err = c.scanReposForURL(u, rf); err != ErrNoOwnerRepo {
 		t.Fatalf("expected ErrNoOwnerRepo, got %v", err)
 	}
 }
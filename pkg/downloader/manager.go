@@ -23,10 +23,12 @@ import (
 	"io"
 	stdfs "io/fs"
 	"log"
+	"maps"
 	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
 
@@ -41,6 +43,7 @@ import (
 	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
 	"helm.sh/helm/v4/pkg/getter"
 	"helm.sh/helm/v4/pkg/helmpath"
+	"helm.sh/helm/v4/pkg/provenance"
 	"helm.sh/helm/v4/pkg/registry"
 	"helm.sh/helm/v4/pkg/repo"
 )
@@ -62,7 +65,8 @@ type Manager struct {
 	Out io.Writer
 	// ChartPath is the path to the unpacked base chart upon which this operates.
 	ChartPath string
-	// Verification indicates whether the chart should be verified.
+	// Verify is the verification strategy applied to each dependency that is
+	// downloaded from a repository. Local dependencies are not verified.
 	Verify VerificationStrategy
 	// Debug is the global "--debug" flag
 	Debug bool
@@ -70,13 +74,25 @@ type Manager struct {
 	Keyring string
 	// SkipUpdate indicates that the repository should not be updated first.
 	SkipUpdate bool
+	// Offline forbids any network access. Dependencies are resolved with the
+	// cached repository indexes, and the charts of the dependencies from
+	// repositories are taken from the charts directory or the repository
+	// cache instead of being downloaded.
+	Offline bool
 	// Getter collection for the operation
 	Getters          []getter.Provider
 	RegistryClient   *registry.Client
 	RepositoryConfig string
 	RepositoryCache  string
+	// Workers is the maximum number of dependencies downloaded concurrently.
+	// If less than 1, defaultDownloadWorkers is used.
+	Workers int
 }
 
+// defaultDownloadWorkers is the number of concurrent dependency downloads
+// used when Manager.Workers is not set.
+const defaultDownloadWorkers = 4
+
 // Build rebuilds a local charts directory from a lockfile.
 //
 // If the lockfile is not present, this will run a Manager.Update()
@@ -133,7 +149,7 @@ func (m *Manager) Build() error {
 		return err
 	}
 
-	if !m.SkipUpdate {
+	if !m.SkipUpdate && !m.Offline {
 		// For each repo in the file, update the cached copy of that repo
 		if err := m.UpdateRepositories(); err != nil {
 			return err
@@ -183,12 +199,18 @@ func (m *Manager) Update() error {
 
 	// For each of the repositories Helm is configured to know about, update
 	// the index information locally.
-	if !m.SkipUpdate {
+	if !m.SkipUpdate && !m.Offline {
 		if err := m.UpdateRepositories(); err != nil {
 			return err
 		}
 	}
 
+	if m.Offline {
+		if err := checkOfflineResolvable(req); err != nil {
+			return err
+		}
+	}
+
 	// Now we need to find out which version of a chart best satisfies the
 	// dependencies in the Chart.yaml
 	lock, err := m.resolve(req, repoNames)
@@ -267,9 +289,13 @@ func (m *Manager) downloadAll(deps []*chart.Dependency) error {
 	}
 	defer os.RemoveAll(tmpPath)
 
+	// Downloads run concurrently and may write to m.Out.
+	out := &syncWriter{w: m.Out}
+
 	fmt.Fprintf(m.Out, "Saving %d charts\n", len(deps))
 	var saveError error
 	churls := make(map[string]struct{})
+	var downloads []dependencyDownload
 	for _, dep := range deps {
 		// No repository means the chart is in charts directory
 		if dep.Repository == "" {
@@ -309,6 +335,13 @@ func (m *Manager) downloadAll(deps []*chart.Dependency) error {
 			dep.Version = ver
 			continue
 		}
+		if m.Offline {
+			if err := m.copyCachedChart(dep, destPath, tmpPath); err != nil {
+				saveError = err
+				break
+			}
+			continue
+		}
 
 		// Any failure to resolve/download a chart should fail:
 		// https://github.com/helm/helm/issues/1439
@@ -326,7 +359,7 @@ func (m *Manager) downloadAll(deps []*chart.Dependency) error {
 		fmt.Fprintf(m.Out, "Downloading %s from repo %s\n", dep.Name, dep.Repository)
 
 		dl := ChartDownloader{
-			Out:              m.Out,
+			Out:              out,
 			Verify:           m.Verify,
 			Keyring:          m.Keyring,
 			RepositoryConfig: m.RepositoryConfig,
@@ -352,15 +385,14 @@ func (m *Manager) downloadAll(deps []*chart.Dependency) error {
 				getter.WithTagName(version))
 		}
 
-		if _, _, err = dl.DownloadTo(churl, version, tmpPath); err != nil {
-			saveError = fmt.Errorf("could not download %s: %w", churl, err)
-			break
-		}
-
+		downloads = append(downloads, dependencyDownload{name: dep.Name, url: churl, version: version, downloader: dl})
 		churls[churl] = struct{}{}
 	}
 
-	// TODO: this should probably be refactored to be a []error, so we can capture and provide more information rather than "last error wins".
+	if saveError == nil {
+		saveError = m.downloadConcurrently(downloads, tmpPath, out)
+	}
+
 	if saveError == nil {
 		// now we can move all downloaded charts to destPath and delete outdated dependencies
 		if err := m.safeMoveDeps(deps, tmpPath, destPath); err != nil {
@@ -373,6 +405,115 @@ func (m *Manager) downloadAll(deps []*chart.Dependency) error {
 	return nil
 }
 
+// checkOfflineResolvable returns an error for the dependencies whose version
+// cannot be resolved without network access: those of OCI registries with a
+// version range, which requires listing the tags of the registry.
+func checkOfflineResolvable(deps []*chart.Dependency) error {
+	for _, dep := range deps {
+		if !registry.IsOCI(dep.Repository) {
+			continue
+		}
+		if _, err := semver.NewVersion(dep.Version); err != nil {
+			return fmt.Errorf("cannot resolve chart %s version %q from %s offline: an exact version is required", dep.Name, dep.Version, dep.Repository)
+		}
+	}
+	return nil
+}
+
+// copyCachedChart copies the archive of the chart of dep, at its exact
+// version, from the charts directory or the repository cache to dest.
+func (m *Manager) copyCachedChart(dep *chart.Dependency, chartsDir, dest string) error {
+	filename := fmt.Sprintf("%s-%s.tgz", dep.Name, dep.Version)
+	for _, dir := range []string{chartsDir, m.RepositoryCache} {
+		if dir == "" {
+			continue
+		}
+		src := filepath.Join(dir, filename)
+		ch, err := loader.LoadFile(src)
+		if err != nil || ch.Name() != dep.Name || !versionEquals(ch.Metadata.Version, dep.Version) {
+			continue
+		}
+		if m.Verify == VerifyAlways {
+			if _, err := VerifyChart(src, m.Keyring); err != nil {
+				return fmt.Errorf("could not verify cached chart %s version %s: %w", dep.Name, dep.Version, err)
+			}
+		}
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(m.Out, "Using cached %s from %s\n", dep.Name, src)
+		return os.WriteFile(filepath.Join(dest, filename), data, 0644)
+	}
+	return fmt.Errorf("chart %s version %s from %s is not available offline: it is neither in %s nor in %s", dep.Name, dep.Version, dep.Repository, chartsDir, m.RepositoryCache)
+}
+
+// dependencyDownload is a remote dependency that still needs to be fetched.
+type dependencyDownload struct {
+	name       string
+	url        string
+	version    string
+	downloader ChartDownloader
+}
+
+// downloadConcurrently fetches downloads into dest using up to m.Workers
+// goroutines. Every download is attempted; failures, including failed
+// provenance verifications, are reported together in the order of the
+// dependencies. The signer of each verified dependency is written to out.
+func (m *Manager) downloadConcurrently(downloads []dependencyDownload, dest string, out io.Writer) error {
+	workers := m.Workers
+	if workers < 1 {
+		workers = defaultDownloadWorkers
+	}
+
+	errs := make([]error, len(downloads))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, len(downloads)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range jobs {
+				d := downloads[n]
+				_, v, err := d.downloader.DownloadTo(d.url, d.version, dest)
+				if err != nil {
+					errs[n] = fmt.Errorf("could not download %s from %s: %w", d.name, d.url, err)
+					continue
+				}
+				if v != nil && v.SignedBy != nil {
+					fmt.Fprint(out, verificationSummary(d.name, v))
+				}
+			}
+		}()
+	}
+	for n := range downloads {
+		jobs <- n
+	}
+	close(jobs)
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// verificationSummary describes who signed the dependency name.
+func verificationSummary(name string, v *provenance.Verification) string {
+	signers := slices.Sorted(maps.Keys(v.SignedBy.Identities))
+	return fmt.Sprintf("Verified %s: signed by %s using key with fingerprint %X\n",
+		name, strings.Join(signers, ", "), v.SignedBy.PrimaryKey.Fingerprint)
+}
+
+// syncWriter serializes writes to w.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
 func parseOCIRef(chartRef string) (string, string, error) {
 	refTagRegexp := regexp.MustCompile(`^(oci://[^:]+(:[0-9]{1,5})?[^:]+):(.*)$`)
 	caps := refTagRegexp.FindStringSubmatch(chartRef)
@@ -544,7 +685,7 @@ func (m *Manager) ensureMissingRepos(repoNames map[string]string, deps []*chart.
 	// repositories configured by the user. Here we update repos found in
 	// the dependencies that are not known to the user if update skipping
 	// is not configured.
-	if !m.SkipUpdate && len(ru) > 0 {
+	if !m.SkipUpdate && !m.Offline && len(ru) > 0 {
 		fmt.Fprintln(m.Out, "Getting updates for unmanaged Helm repositories...")
 		if err := m.parallelRepoUpdate(ru); err != nil {
 			return repoNames, err
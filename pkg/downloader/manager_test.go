@@ -22,6 +22,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -29,6 +30,7 @@ import (
 	chart "helm.sh/helm/v4/pkg/chart/v2"
 	"helm.sh/helm/v4/pkg/chart/v2/loader"
 	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	"helm.sh/helm/v4/pkg/cli"
 	"helm.sh/helm/v4/pkg/getter"
 	"helm.sh/helm/v4/pkg/repo"
 	"helm.sh/helm/v4/pkg/repo/repotest"
@@ -672,3 +674,183 @@ func TestDedupeRepos(t *testing.T) {
 		})
 	}
 }
+
+func TestDownloadAllConcurrent(t *testing.T) {
+	srv := repotest.NewTempServer(
+		t,
+		repotest.WithChartSourceGlob("testdata/*.tgz*"),
+	)
+	defer srv.Stop()
+	if err := srv.LinkIndices(); err != nil {
+		t.Fatal(err)
+	}
+
+	deps := func() []*chart.Dependency {
+		return []*chart.Dependency{
+			{Name: "signtest", Version: "0.1.0", Repository: srv.URL()},
+			{Name: "local-subchart", Version: "0.1.0", Repository: srv.URL()},
+		}
+	}
+	newManager := func(t *testing.T) *Manager {
+		t.Helper()
+		return &Manager{
+			Out:              new(bytes.Buffer),
+			ChartPath:        t.TempDir(),
+			Getters:          getter.All(&cli.EnvSettings{}),
+			RepositoryConfig: filepath.Join(srv.Root(), "repositories.yaml"),
+			RepositoryCache:  srv.Root(),
+			Workers:          2,
+		}
+	}
+
+	m := newManager(t)
+	if err := m.downloadAll(deps()); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"signtest-0.1.0.tgz", "local-subchart-0.1.0.tgz"} {
+		if _, err := os.Stat(filepath.Join(m.ChartPath, "charts", name)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// Every failed download is reported, not just the first one.
+	for _, name := range []string{"signtest-0.1.0.tgz", "local-subchart-0.1.0.tgz"} {
+		if err := os.Remove(filepath.Join(srv.Root(), name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m = newManager(t)
+	err := m.downloadAll(deps())
+	if err == nil {
+		t.Fatal("Expected an error for missing archives")
+	}
+	for _, name := range []string{"signtest", "local-subchart"} {
+		if !strings.Contains(err.Error(), "could not download "+name+" from ") {
+			t.Errorf("Expected error to name dependency %s, got %q", name, err)
+		}
+	}
+	if entries, _ := os.ReadDir(filepath.Join(m.ChartPath, "charts")); len(entries) != 0 {
+		t.Errorf("Expected no charts to be saved after a failure, got %d", len(entries))
+	}
+}
+
+func TestDownloadAllOffline(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "signtest-0.1.0.tgz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newManager := func(t *testing.T) *Manager {
+		t.Helper()
+		return &Manager{
+			Out:              new(bytes.Buffer),
+			ChartPath:        t.TempDir(),
+			RepositoryConfig: repoConfig,
+			RepositoryCache:  repoCache,
+			Offline:          true,
+		}
+	}
+
+	// The chart already in the charts directory is kept, without any getter.
+	m := newManager(t)
+	if err := os.MkdirAll(filepath.Join(m.ChartPath, "charts"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(m.ChartPath, "charts", "signtest-0.1.0.tgz"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	dep := &chart.Dependency{Name: "signtest", Version: "0.1.0", Repository: "https://example.com/charts"}
+	if err := m.downloadAll([]*chart.Dependency{dep}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(m.ChartPath, "charts", "signtest-0.1.0.tgz")); err != nil {
+		t.Error(err)
+	}
+
+	// A missing archive is reported with the chart and its version.
+	m = newManager(t)
+	dep = &chart.Dependency{Name: "signtest", Version: "0.2.0", Repository: "https://example.com/charts"}
+	err = m.downloadAll([]*chart.Dependency{dep})
+	if err == nil || !strings.Contains(err.Error(), "chart signtest version 0.2.0 from https://example.com/charts is not available offline") {
+		t.Fatalf("Expected an error for the missing archive, got %v", err)
+	}
+}
+
+func TestCheckOfflineResolvable(t *testing.T) {
+	tests := []struct {
+		dep     *chart.Dependency
+		wantErr bool
+	}{
+		{dep: &chart.Dependency{Name: "a", Version: "1.2.3", Repository: "oci://example.com/charts"}},
+		{dep: &chart.Dependency{Name: "a", Version: "^1.2.0", Repository: "https://example.com/charts"}},
+		{dep: &chart.Dependency{Name: "a", Version: "^1.2.0", Repository: "oci://example.com/charts"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		err := checkOfflineResolvable([]*chart.Dependency{tt.dep})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s %s: expected error %t, got %v", tt.dep.Repository, tt.dep.Version, tt.wantErr, err)
+		}
+	}
+}
+
+func TestDownloadAllVerify(t *testing.T) {
+	srv := repotest.NewTempServer(
+		t,
+		repotest.WithChartSourceGlob("testdata/*.tgz*"),
+	)
+	defer srv.Stop()
+	if err := srv.LinkIndices(); err != nil {
+		t.Fatal(err)
+	}
+
+	newManager := func(t *testing.T) *Manager {
+		t.Helper()
+		return &Manager{
+			Out:              new(bytes.Buffer),
+			ChartPath:        t.TempDir(),
+			Verify:           VerifyAlways,
+			Keyring:          "testdata/helm-test-key.pub",
+			Getters:          getter.All(&cli.EnvSettings{}),
+			RepositoryConfig: filepath.Join(srv.Root(), "repositories.yaml"),
+			RepositoryCache:  srv.Root(),
+		}
+	}
+
+	m := newManager(t)
+	if err := m.downloadAll([]*chart.Dependency{{Name: "signtest", Version: "0.1.0", Repository: srv.URL()}}); err != nil {
+		t.Fatal(err)
+	}
+	out := m.Out.(*bytes.Buffer).String()
+	if !strings.Contains(out, "Verified signtest: signed by Helm Testing (This key should only be used for testing. DO NOT TRUST.) <helm-testing@helm.sh> using key with fingerprint") {
+		t.Errorf("Expected the signer to be logged, got %q", out)
+	}
+	if _, err := os.Stat(filepath.Join(m.ChartPath, "charts", "signtest-0.1.0.tgz")); err != nil {
+		t.Error(err)
+	}
+
+	// local-subchart has no provenance file, so the whole update fails.
+	m = newManager(t)
+	err := m.downloadAll([]*chart.Dependency{
+		{Name: "signtest", Version: "0.1.0", Repository: srv.URL()},
+		{Name: "local-subchart", Version: "0.1.0", Repository: srv.URL()},
+	})
+	if err == nil || !strings.Contains(err.Error(), "could not download local-subchart") {
+		t.Fatalf("Expected verification of local-subchart to fail, got %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(m.ChartPath, "charts")); len(entries) != 0 {
+		t.Errorf("Expected no charts to be saved after a failed verification, got %d", len(entries))
+	}
+
+	// A tampered archive fails verification.
+	tampered, err := os.ReadFile(filepath.Join("testdata", "local-subchart-0.1.0.tgz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srv.Root(), "signtest-0.1.0.tgz"), tampered, 0644); err != nil {
+		t.Fatal(err)
+	}
+	m = newManager(t)
+	err = m.downloadAll([]*chart.Dependency{{Name: "signtest", Version: "0.1.0", Repository: srv.URL()}})
+	if err == nil || !strings.Contains(err.Error(), "could not download signtest") {
+		t.Errorf("Expected verification of the tampered signtest to fail, got %v", err)
+	}
+}
@@ -0,0 +1,246 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/copystructure"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+)
+
+// DefaultsFile is the name of the optional file, next to values.yaml, whose
+// rendered output provides the defaults of values computed from other values,
+// such as resource requests derived from a tier.
+const DefaultsFile = "defaults.tpl"
+
+// maxDefaultsPasses limits the renders of the defaults files while waiting
+// for the computed defaults to settle.
+const maxDefaultsPasses = 10
+
+// ComputeDefaults returns vals, the values supplied by the user, with the
+// defaults computed by the DefaultsFile of chrt and of its dependencies
+// beneath them.
+//
+// A DefaultsFile is rendered like a template, with access to the named
+// templates of the chart, and must render to YAML. Its .Values are those of
+// the chart coalesced as for rendering, including the computed defaults, so
+// that computed defaults may depend on each other. The files are rendered
+// again until the computed defaults no longer change, and an error is
+// returned if they do not settle, as when two values are computed from each
+// other.
+//
+// The values supplied by the user take precedence over the computed defaults,
+// which take precedence over the values files of the charts. vals is not
+// modified.
+func (e Engine) ComputeDefaults(chrt *chart.Chart, vals map[string]interface{}, options chartutil.ReleaseOptions, caps *chartutil.Capabilities) (map[string]interface{}, error) {
+	if !hasDefaults(chrt) {
+		return vals, nil
+	}
+	// The validators are run on the values to render, once the defaults
+	// are computed.
+	e.Validators = nil
+
+	computed := map[string]interface{}{}
+	for pass := 0; pass < maxDefaultsPasses; pass++ {
+		merged, err := mergeDefaults(vals, computed)
+		if err != nil {
+			return nil, err
+		}
+		top, err := chartutil.ToRenderValuesWithSchemaValidation(chrt, merged, options, caps, true)
+		if err != nil {
+			return nil, err
+		}
+		rendered, err := e.render(defaultsTemplates(chrt, top))
+		if err != nil {
+			return nil, err
+		}
+		next := map[string]interface{}{}
+		if err := collectDefaults(chrt, rendered, next); err != nil {
+			return nil, err
+		}
+		if reflect.DeepEqual(next, computed) {
+			return merged, nil
+		}
+		if pass == maxDefaultsPasses-1 {
+			return nil, fmt.Errorf("the values computed by %s keep changing after %d renders, are they computed from each other? %s", DefaultsFile, maxDefaultsPasses, strings.Join(changedPaths(computed, next, ""), ", "))
+		}
+		computed = next
+	}
+	return vals, nil
+}
+
+// hasDefaults returns true if c or any of its dependencies has a DefaultsFile.
+func hasDefaults(c *chart.Chart) bool {
+	for _, f := range c.Files {
+		if f != nil && f.Name == DefaultsFile {
+			return true
+		}
+	}
+	for _, child := range c.Dependencies() {
+		if hasDefaults(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeDefaults returns a copy of vals with the values of computed beneath
+// them. The keys of vals are kept even when nil, as a nil value unsets the
+// value of the chart.
+func mergeDefaults(vals, computed map[string]interface{}) (map[string]interface{}, error) {
+	v, err := copystructure.Copy(vals)
+	if err != nil {
+		return nil, err
+	}
+	c, err := copystructure.Copy(computed)
+	if err != nil {
+		return nil, err
+	}
+	dst, _ := v.(map[string]interface{})
+	if dst == nil {
+		dst = map[string]interface{}{}
+	}
+	mergeComputed(dst, c.(map[string]interface{}))
+	return dst, nil
+}
+
+// mergeComputed sets the values of computed missing from dst, merging the
+// tables present in both.
+func mergeComputed(dst, computed map[string]interface{}) {
+	for key, val := range computed {
+		dv, ok := dst[key]
+		if !ok {
+			dst[key] = val
+			continue
+		}
+		dt, dok := dv.(map[string]interface{})
+		ct, cok := val.(map[string]interface{})
+		if dok && cok {
+			mergeComputed(dt, ct)
+		}
+	}
+}
+
+// defaultsTemplates returns the templates of chrt and its dependencies, to be
+// included, along with their defaults files, to be rendered.
+func defaultsTemplates(chrt *chart.Chart, vals chartutil.Values) map[string]renderable {
+	tpls := allTemplates(chrt, vals)
+	for name, r := range tpls {
+		r.parseOnly = true
+		tpls[name] = r
+	}
+	recDefaultsTemplates(chrt, tpls, vals)
+	return tpls
+}
+
+// recDefaultsTemplates adds the defaults files of c and its dependencies to
+// tpls, scoping the values as recAllTpls does.
+func recDefaultsTemplates(c *chart.Chart, tpls map[string]renderable, vals chartutil.Values) {
+	chartMetaData := struct {
+		chart.Metadata
+		IsRoot bool
+	}{*c.Metadata, c.IsRoot()}
+
+	next := chartutil.Values{
+		"Chart":        chartMetaData,
+		"Files":        newFiles(c.Files),
+		"Release":      vals["Release"],
+		"Capabilities": vals["Capabilities"],
+		"Values":       make(chartutil.Values),
+	}
+	if c.IsRoot() {
+		next["Values"] = vals["Values"]
+	} else if vs, err := vals.Table("Values." + c.Name()); err == nil {
+		next["Values"] = vs
+	}
+
+	for _, child := range c.Dependencies() {
+		recDefaultsTemplates(child, tpls, next)
+	}
+	for _, f := range c.Files {
+		if f != nil && f.Name == DefaultsFile {
+			tpls[path.Join(c.ChartFullPath(), DefaultsFile)] = renderable{
+				tpl:      string(f.Data),
+				vals:     next,
+				basePath: path.Join(c.ChartFullPath(), "templates"),
+			}
+		}
+	}
+}
+
+// collectDefaults adds the rendered defaults files of c and its dependencies
+// to dest, scoping those of the dependencies to their name. The defaults
+// computed by a chart take precedence over those of its dependencies.
+func collectDefaults(c *chart.Chart, rendered map[string]string, dest map[string]interface{}) error {
+	name := path.Join(c.ChartFullPath(), DefaultsFile)
+	if out, ok := rendered[name]; ok {
+		vals, err := chartutil.ReadValues([]byte(out))
+		if err != nil {
+			return fmt.Errorf("invalid output of %s: %w", name, err)
+		}
+		chartutil.CoalesceTables(dest, vals)
+	}
+	for _, child := range c.Dependencies() {
+		sub := map[string]interface{}{}
+		if err := collectDefaults(child, rendered, sub); err != nil {
+			return err
+		}
+		if len(sub) == 0 {
+			continue
+		}
+		if parent, ok := dest[child.Name()].(map[string]interface{}); ok {
+			chartutil.CoalesceTables(parent, sub)
+		} else if _, ok := dest[child.Name()]; !ok {
+			dest[child.Name()] = sub
+		}
+	}
+	return nil
+}
+
+// changedPaths returns the sorted dot separated paths of the values differing
+// between a and b.
+func changedPaths(a, b map[string]interface{}, prefix string) []string {
+	var paths []string
+	for key := range a {
+		if _, ok := b[key]; !ok {
+			paths = append(paths, prefix+key)
+		}
+	}
+	for key, bv := range b {
+		av, ok := a[key]
+		if !ok {
+			paths = append(paths, prefix+key)
+			continue
+		}
+		am, aok := av.(map[string]interface{})
+		bm, bok := bv.(map[string]interface{})
+		if aok && bok {
+			paths = append(paths, changedPaths(am, bm, prefix+key+".")...)
+		} else if !reflect.DeepEqual(av, bv) {
+			paths = append(paths, prefix+key)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
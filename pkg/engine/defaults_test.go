@@ -0,0 +1,183 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+)
+
+func defaultsChart(defaults string) *chart.Chart {
+	return &chart.Chart{
+		Metadata: &chart.Metadata{Name: "tiered"},
+		Values: map[string]interface{}{
+			"tier":      "small",
+			"resources": map[string]interface{}{"cpu": "50m"},
+		},
+		Templates: []*chart.File{
+			{Name: "templates/_helpers.tpl", Data: []byte(`{{- define "tiered.cpu" -}}{{ if eq . "large" }}2{{ else }}500m{{ end }}{{- end -}}`)},
+			{Name: "templates/deployment", Data: []byte(`cpu: {{ .Values.resources.cpu }}`)},
+		},
+		Files: []*chart.File{{Name: DefaultsFile, Data: []byte(defaults)}},
+	}
+}
+
+func TestComputeDefaults(t *testing.T) {
+	c := defaultsChart(`resources:
+  cpu: {{ include "tiered.cpu" .Values.tier }}
+  memory: {{ .Values.resources.cpu | quote }}
+replicas: {{ if eq .Values.tier "large" }}3{{ else }}1{{ end }}
+`)
+	vals := map[string]interface{}{
+		"tier":     "large",
+		"replicas": 5,
+	}
+
+	got, err := new(Engine).ComputeDefaults(c, vals, chartutil.ReleaseOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The computed defaults override the values file, are computed from
+	// each other, and are overridden by the values of the user.
+	expect := map[string]interface{}{
+		"tier":     "large",
+		"replicas": 5,
+		"resources": map[string]interface{}{
+			"cpu":    float64(2),
+			"memory": "2",
+		},
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("expected %v, got %v", expect, got)
+	}
+	if _, ok := vals["resources"]; ok {
+		t.Error("expected the values of the user not to be modified")
+	}
+
+	// The computed defaults are rendered.
+	top, err := chartutil.ToRenderValues(c, got, chartutil.ReleaseOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := new(Engine).Render(c, top)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["tiered/templates/deployment"] != "cpu: 2" {
+		t.Errorf("unexpected render %q", out["tiered/templates/deployment"])
+	}
+	if _, ok := out["tiered/"+DefaultsFile]; ok {
+		t.Error("expected the defaults file not to be rendered with the templates")
+	}
+}
+
+func TestComputeDefaultsCycle(t *testing.T) {
+	c := defaultsChart(`a: {{ add .Values.b 1 }}
+b: {{ add .Values.a 1 }}
+`)
+	_, err := new(Engine).ComputeDefaults(c, map[string]interface{}{}, chartutil.ReleaseOptions{}, nil)
+	expect := "the values computed by defaults.tpl keep changing after 10 renders, are they computed from each other? a, b"
+	if err == nil || err.Error() != expect {
+		t.Errorf("expected error %q, got %v", expect, err)
+	}
+
+	// Values supplied by the user break the cycle.
+	if _, err := new(Engine).ComputeDefaults(c, map[string]interface{}{"a": 1}, chartutil.ReleaseOptions{}, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestComputeDefaultsDependencies(t *testing.T) {
+	sub := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "sub"},
+		Values:   map[string]interface{}{"size": "small"},
+		Files: []*chart.File{{Name: DefaultsFile, Data: []byte(`replicas: {{ if eq .Values.size "large" }}3{{ else }}1{{ end }}
+port: 80
+`)}},
+	}
+	parent := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "parent"},
+		Files:    []*chart.File{{Name: DefaultsFile, Data: []byte("sub:\n  port: 8080\n")}},
+	}
+	parent.AddDependency(sub)
+
+	got, err := new(Engine).ComputeDefaults(parent, map[string]interface{}{"sub": map[string]interface{}{"size": "large"}}, chartutil.ReleaseOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The defaults computed by the parent override those of the dependency.
+	expect := map[string]interface{}{
+		"sub": map[string]interface{}{"size": "large", "replicas": float64(3), "port": float64(8080)},
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("expected %v, got %v", expect, got)
+	}
+}
+
+func TestComputeDefaultsNull(t *testing.T) {
+	c := defaultsChart(`resources:
+  cpu: {{ include "tiered.cpu" .Values.tier }}
+`)
+	vals := map[string]interface{}{
+		"resources": map[string]interface{}{"cpu": nil},
+	}
+
+	got, err := new(Engine).ComputeDefaults(c, vals, chartutil.ReleaseOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A null value of the user unsets the computed default as well.
+	if !reflect.DeepEqual(got, vals) {
+		t.Errorf("expected %v, got %v", vals, got)
+	}
+	top, err := chartutil.ToRenderValues(c, got, chartutil.ReleaseOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := new(Engine).Render(c, top)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["tiered/templates/deployment"] != "cpu: " {
+		t.Errorf("unexpected render %q", out["tiered/templates/deployment"])
+	}
+}
+
+func TestComputeDefaultsNone(t *testing.T) {
+	c := defaultsChart("")
+	c.Files = nil
+	vals := map[string]interface{}{"tier": "large"}
+	got, err := new(Engine).ComputeDefaults(c, vals, chartutil.ReleaseOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, vals) {
+		t.Errorf("expected %v, got %v", vals, got)
+	}
+}
+
+func TestComputeDefaultsInvalid(t *testing.T) {
+	c := defaultsChart("- not\n- a map\n")
+	_, err := new(Engine).ComputeDefaults(c, nil, chartutil.ReleaseOptions{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "invalid output of tiered/defaults.tpl") {
+		t.Errorf("expected an invalid output error, got %v", err)
+	}
+}
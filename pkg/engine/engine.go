@@ -23,7 +23,9 @@ import (
 	"maps"
 	"path"
 	"path/filepath"
+	"reflect"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"text/template"
@@ -45,8 +47,22 @@ type Engine struct {
 	clientProvider *ClientProvider
 	// EnableDNS tells the engine to allow DNS lookups when rendering templates
 	EnableDNS bool
-	// CustomTemplateFuncs is defined by users to provide custom template funcs
+	// DisableLookupCache disables the caching of the results of the lookup
+	// function, making every call retrieve the objects from the cluster. The
+	// results are otherwise cached for the duration of a single render.
+	DisableLookupCache bool
+	// MaxIncludeDepth limits the nesting of include and tpl calls while
+	// rendering a template, which catches endless recursions. It defaults to
+	// DefaultMaxIncludeDepth when zero.
+	MaxIncludeDepth int
+	// CustomTemplateFuncs is defined by users to provide custom template funcs.
+	// These are added to the built-in functions, replacing those with the same
+	// name. See WithFuncs to add functions without replacing built-ins.
 	CustomTemplateFuncs template.FuncMap
+	// Validators validate the values before rendering. Their failures are
+	// reported together, along with those of the validation functions of
+	// the templates, such as mustBeInt. See Validator.
+	Validators []Validator
 }
 
 // New creates a new instance of Engine using the passed in rest config.
@@ -57,6 +73,53 @@ func New(config *rest.Config) Engine {
 	}
 }
 
+// WithFuncs returns a copy of the engine that adds the functions in funcs to
+// those available to all of the templates of the rendered charts, including
+// partials such as _helpers.tpl.
+//
+// An error is returned if a function has the name of a built-in function, or
+// of a function that was added already, or is not a valid template function.
+// Built-in functions can be replaced through CustomTemplateFuncs instead.
+func (e Engine) WithFuncs(funcs template.FuncMap) (Engine, error) {
+	builtins := funcMap()
+	names := slices.Sorted(maps.Keys(funcs))
+	for _, name := range names {
+		if _, ok := builtins[name]; ok {
+			return e, fmt.Errorf("cannot register template function %q: a built-in function has this name", name)
+		}
+		if _, ok := e.CustomTemplateFuncs[name]; ok {
+			return e, fmt.Errorf("cannot register template function %q: a function has been registered with this name already", name)
+		}
+		if err := checkTemplateFunc(funcs[name]); err != nil {
+			return e, fmt.Errorf("cannot register template function %q: %w", name, err)
+		}
+	}
+
+	custom := make(template.FuncMap, len(e.CustomTemplateFuncs)+len(funcs))
+	maps.Copy(custom, e.CustomTemplateFuncs)
+	maps.Copy(custom, funcs)
+	e.CustomTemplateFuncs = custom
+	return e, nil
+}
+
+// checkTemplateFunc checks that fn can be called from templates, which
+// requires it to be a function returning a value, optionally followed by an
+// error.
+func checkTemplateFunc(fn interface{}) error {
+	t := reflect.TypeOf(fn)
+	if t == nil || t.Kind() != reflect.Func {
+		return errors.New("not a function")
+	}
+	errorType := reflect.TypeFor[error]()
+	switch {
+	case t.NumOut() == 1:
+		return nil
+	case t.NumOut() == 2 && t.Out(1) == errorType:
+		return nil
+	}
+	return errors.New("a template function must return a value, optionally followed by an error")
+}
+
 // Render takes a chart, optional values, and value overrides, and attempts to render the Go templates.
 //
 // Render can be called repeatedly on the same engine.
@@ -77,6 +140,9 @@ func New(config *rest.Config) Engine {
 // section contains a value named "bar", that value will be passed on to the
 // bar chart during render time.
 func (e Engine) Render(chrt *chart.Chart, values chartutil.Values) (map[string]string, error) {
+	if err := e.validate(values); err != nil {
+		return map[string]string{}, err
+	}
 	tmap := allTemplates(chrt, values)
 	return e.render(tmap)
 }
@@ -115,11 +181,16 @@ type renderable struct {
 	vals chartutil.Values
 	// namespace prefix to the templates of the current chart
 	basePath string
+	// parseOnly templates are only available to include, like partials.
+	parseOnly bool
 }
 
 const warnStartDelim = "HELM_ERR_START"
 const warnEndDelim = "HELM_ERR_END"
-const recursionMaxNums = 1000
+
+// DefaultMaxIncludeDepth is the default limit of nested include and tpl calls
+// while rendering a template.
+const DefaultMaxIncludeDepth = 1000
 
 var warnRegex = regexp.MustCompile(warnStartDelim + `((?s).*)` + warnEndDelim)
 
@@ -129,29 +200,33 @@ func warnWrap(warn string) string {
 
 // 'include' needs to be defined in the scope of a 'tpl' template as
 // well as regular file-loaded templates.
-func includeFun(t *template.Template, includedNames map[string]int) func(string, interface{}) (string, error) {
+func includeFun(t *template.Template, stack *includeStack) func(string, interface{}) (string, error) {
 	return func(name string, data interface{}) (string, error) {
-		var buf strings.Builder
-		if v, ok := includedNames[name]; ok {
-			if v > recursionMaxNums {
-				return "", fmt.Errorf(
-					"rendering template has a nested reference name: %s: %w",
-					name, errors.New("unable to execute template"))
-			}
-			includedNames[name]++
-		} else {
-			includedNames[name] = 1
+		if err := stack.push(name, name); err != nil {
+			return "", err
 		}
+		defer stack.pop()
+
+		var buf strings.Builder
 		err := t.ExecuteTemplate(&buf, name, data)
-		includedNames[name]--
+		if rerr := (*RecursionError)(nil); errors.As(err, &rerr) {
+			// Report the recursion once, rather than wrapped by every
+			// nested call.
+			return "", rerr
+		}
 		return buf.String(), err
 	}
 }
 
 // As does 'tpl', so that nested calls to 'tpl' see the templates
 // defined by their enclosing contexts.
-func tplFun(parent *template.Template, includedNames map[string]int, strict bool) func(string, interface{}) (string, error) {
+func tplFun(parent *template.Template, stack *includeStack, strict bool) func(string, interface{}) (string, error) {
 	return func(tpl string, vals interface{}) (string, error) {
+		if err := stack.push("tpl:"+tpl, fmt.Sprintf("tpl %.40q", tpl)); err != nil {
+			return "", err
+		}
+		defer stack.pop()
+
 		t, err := parent.Clone()
 		if err != nil {
 			return "", fmt.Errorf("cannot clone template: %w", err)
@@ -169,24 +244,27 @@ func tplFun(parent *template.Template, includedNames map[string]int, strict bool
 		// Re-inject 'include' so that it can close over our clone of t;
 		// this lets any 'define's inside tpl be 'include'd.
 		t.Funcs(template.FuncMap{
-			"include": includeFun(t, includedNames),
-			"tpl":     tplFun(t, includedNames, strict),
+			"include": includeFun(t, stack),
+			"tpl":     tplFun(t, stack, strict),
 		})
 
 		// We need a .New template, as template text which is just blanks
 		// or comments after parsing out defines just adds new named
 		// template definitions without changing the main template.
 		// https://pkg.go.dev/text/template#Template.Parse
-		// Use the parent's name for lack of a better way to identify the tpl
-		// text string. (Maybe we could use a hash appended to the name?)
-		t, err = t.New(parent.Name()).Parse(tpl)
+		// The text is named so that the errors it raises are not mistaken for
+		// errors of a chart file.
+		t, err = t.New(tplTemplateName).Parse(tpl)
 		if err != nil {
-			return "", fmt.Errorf("cannot parse template %q: %w", tpl, err)
+			return "", &tplError{op: "cannot parse template", tpl: tpl, err: err}
 		}
 
 		var buf strings.Builder
 		if err := t.Execute(&buf, vals); err != nil {
-			return "", fmt.Errorf("error during tpl function execution for %q: %w", tpl, err)
+			if rerr := (*RecursionError)(nil); errors.As(err, &rerr) {
+				return "", rerr
+			}
+			return "", &tplError{op: "error during tpl function execution for", tpl: tpl, err: err}
 		}
 
 		// See comment in renderWithReferences explaining the <no value> hack.
@@ -194,14 +272,114 @@ func tplFun(parent *template.Template, includedNames map[string]int, strict bool
 	}
 }
 
+// tplTemplateName is the name of the templates parsed from the text given to
+// the tpl function.
+const tplTemplateName = "tpl"
+
+// tplError is an error raised by the text given to the tpl function. Such text
+// usually comes from values rather than a chart file, which the error states
+// along with the position of the error within the text.
+type tplError struct {
+	op  string
+	tpl string
+	err error
+}
+
+func (e *tplError) Error() string {
+	msg := e.err.Error()
+	// Errors of text/template start with "template: name:line[:col]: ".
+	rest, ok := strings.CutPrefix(msg, "template: "+tplTemplateName+":")
+	if !ok {
+		// The error comes from a chart file included by the text.
+		return fmt.Sprintf("%s %q: %s", e.op, e.tpl, msg)
+	}
+	location, rest, _ := strings.Cut(rest, ": ")
+	line, col, hasCol := strings.Cut(location, ":")
+	position := "line " + line
+	if hasCol {
+		position += ", column " + col
+	}
+	return fmt.Sprintf("%s %q (text given to tpl, such as a value, not a chart file) at %s: %s", e.op, e.tpl, position, rest)
+}
+
+func (e *tplError) Unwrap() error {
+	return e.err
+}
+
+// RecursionError is returned when the nesting of include and tpl calls
+// exceeds the limit of the engine.
+type RecursionError struct {
+	// Name is the name of the template or tpl call exceeding the limit.
+	Name string
+	// Chain lists the calls leading to it, starting with the rendered
+	// template, up to the first call repeating an earlier one.
+	Chain []string
+	// Limit is the limit of nested calls.
+	Limit int
+}
+
+func (e *RecursionError) Error() string {
+	return fmt.Sprintf("rendering template has a nested reference name: %s: include depth exceeds the limit of %d through %s: unable to execute template",
+		e.Name, e.Limit, strings.Join(e.Chain, " -> "))
+}
+
+// includeStack tracks the nested include and tpl calls of the template being
+// rendered.
+type includeStack struct {
+	limit int
+	// keys identify the calls, and names describe them.
+	keys, names []string
+}
+
+func newIncludeStack(limit int) *includeStack {
+	if limit <= 0 {
+		limit = DefaultMaxIncludeDepth
+	}
+	return &includeStack{limit: limit}
+}
+
+// reset starts tracking the calls made by the template root.
+func (s *includeStack) reset(root string) {
+	s.keys = append(s.keys[:0], root)
+	s.names = append(s.names[:0], root)
+}
+
+func (s *includeStack) push(key, name string) error {
+	// The rendered template is at the bottom of the stack.
+	if len(s.keys) > s.limit {
+		chain := append([]string{}, s.names...)
+		seen := map[string]bool{}
+		for i, k := range s.keys {
+			if seen[k] {
+				chain = append(chain[:i+1], "...")
+				break
+			}
+			seen[k] = true
+		}
+		return &RecursionError{Name: name, Chain: chain, Limit: s.limit}
+	}
+	s.keys = append(s.keys, key)
+	s.names = append(s.names, name)
+	return nil
+}
+
+func (s *includeStack) pop() {
+	s.keys = s.keys[:len(s.keys)-1]
+	s.names = s.names[:len(s.names)-1]
+}
+
 // initFunMap creates the Engine's FuncMap and adds context-specific functions.
-func (e Engine) initFunMap(t *template.Template) {
+// It returns the stack tracking the include and tpl calls, and the collector
+// of the failures of the validation functions.
+func (e Engine) initFunMap(t *template.Template) (*includeStack, *validations) {
 	funcMap := funcMap()
-	includedNames := make(map[string]int)
+	stack := newIncludeStack(e.MaxIncludeDepth)
+	checks := newValidations(e.LintMode)
+	maps.Copy(funcMap, validationFuncs(checks))
 
 	// Add the template-rendering functions here so we can close over t.
-	funcMap["include"] = includeFun(t, includedNames)
-	funcMap["tpl"] = tplFun(t, includedNames, e.Strict)
+	funcMap["include"] = includeFun(t, stack)
+	funcMap["tpl"] = tplFun(t, stack, e.Strict)
 
 	// Add the `required` function here so we can use lintMode
 	funcMap["required"] = func(warn string, val interface{}) (interface{}, error) {
@@ -238,7 +416,12 @@ func (e Engine) initFunMap(t *template.Template) {
 	// If we are not linting and have a cluster connection, provide a Kubernetes-backed
 	// implementation.
 	if !e.LintMode && e.clientProvider != nil {
-		funcMap["lookup"] = newLookupFunction(*e.clientProvider)
+		lookup := newLookupFunction(*e.clientProvider)
+		if !e.DisableLookupCache {
+			// The results are cached for the duration of this render.
+			lookup = newCachedLookupFunction(lookup)
+		}
+		funcMap["lookup"] = lookup
 	}
 
 	// When DNS lookups are not enabled override the sprig function and return
@@ -253,6 +436,7 @@ func (e Engine) initFunMap(t *template.Template) {
 	maps.Copy(funcMap, e.CustomTemplateFuncs)
 
 	t.Funcs(funcMap)
+	return stack, checks
 }
 
 // render takes a map of templates/values and renders them.
@@ -264,8 +448,13 @@ func (e Engine) render(tpls map[string]renderable) (rendered map[string]string,
 	// The idea with this process is to make it possible for more complex templates
 	// to share common blocks, but to make the entire thing feel like a file-based
 	// template engine.
+	var filename string
 	defer func() {
 		if r := recover(); r != nil {
+			if filename != "" {
+				err = fmt.Errorf("rendering template %s failed: %v", filename, r)
+				return
+			}
 			err = fmt.Errorf("rendering template failed: %v", r)
 		}
 	}()
@@ -278,13 +467,13 @@ func (e Engine) render(tpls map[string]renderable) (rendered map[string]string,
 		t.Option("missingkey=zero")
 	}
 
-	e.initFunMap(t)
+	stack, checks := e.initFunMap(t)
 
 	// We want to parse the templates in a predictable order. The order favors
 	// higher-level (in file system) templates over deeply nested templates.
 	keys := sortTemplates(tpls)
 
-	for _, filename := range keys {
+	for _, filename = range keys {
 		r := tpls[filename]
 		if _, err := t.New(filename).Parse(r.tpl); err != nil {
 			return map[string]string{}, cleanupParseError(filename, err)
@@ -292,18 +481,25 @@ func (e Engine) render(tpls map[string]renderable) (rendered map[string]string,
 	}
 
 	rendered = make(map[string]string, len(keys))
-	for _, filename := range keys {
+	for _, filename = range keys {
 		// Don't render partials. We don't care out the direct output of partials.
 		// They are only included from other templates.
-		if strings.HasPrefix(path.Base(filename), "_") {
+		if strings.HasPrefix(path.Base(filename), "_") || tpls[filename].parseOnly {
 			continue
 		}
 		// At render time, add information about the template that is being rendered.
 		vals := tpls[filename].vals
 		vals["Template"] = chartutil.Values{"Name": filename, "BasePath": tpls[filename].basePath}
 		var buf strings.Builder
+		stack.reset(filename)
+		checks.template = filename
 		if err := t.ExecuteTemplate(&buf, filename, vals); err != nil {
-			return map[string]string{}, cleanupExecError(filename, err)
+			err = cleanupExecError(filename, err)
+			if len(checks.errs) > 0 {
+				// The invalid values may explain the error.
+				err = errors.Join(checks.errs, err)
+			}
+			return map[string]string{}, err
 		}
 
 		// Work around the issue where Go will emit "<no value>" even if Options(missing=zero)
@@ -312,6 +508,9 @@ func (e Engine) render(tpls map[string]renderable) (rendered map[string]string,
 		rendered[filename] = strings.ReplaceAll(buf.String(), "<no value>", "")
 	}
 
+	if len(checks.errs) > 0 {
+		return map[string]string{}, checks.errs
+	}
 	return rendered, nil
 }
 
@@ -17,8 +17,10 @@ limitations under the License.
 package engine
 
 import (
+	"errors"
 	"fmt"
 	"path"
+	"reflect"
 	"strings"
 	"sync"
 	"testing"
@@ -418,6 +420,78 @@ func TestRenderWithClientProvider_error(t *testing.T) {
 	}
 }
 
+// countingClientProvider counts the clients requested from a ClientProvider.
+type countingClientProvider struct {
+	ClientProvider
+	calls int
+}
+
+func (p *countingClientProvider) GetClientFor(apiVersion, kind string) (dynamic.NamespaceableResourceInterface, bool, error) {
+	p.calls++
+	return p.ClientProvider.GetClientFor(apiVersion, kind)
+}
+
+func TestRenderLookupCache(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "moby", Version: "1.2.3"},
+		Templates: []*chart.File{
+			{Name: "templates/first", Data: []byte(`{{ (lookup "v1" "Pod" "default" "pod1").metadata.name }}{{ $_ := set (lookup "v1" "Pod" "default" "pod1") "kind" "Changed" }}`)},
+			{Name: "templates/second", Data: []byte(`{{ (lookup "v1" "Pod" "default" "pod1").kind }} {{ tpl "{{ (lookup \"v1\" \"Pod\" \"default\" \"pod1\").kind }}" . }}`)},
+			{Name: "templates/other", Data: []byte(`{{ (lookup "v1" "Pod" "default" "pod2") }}`)},
+		},
+		Values: map[string]interface{}{},
+	}
+	v, err := chartutil.CoalesceValues(c, map[string]interface{}{"Values": map[string]interface{}{}})
+	if err != nil {
+		t.Fatalf("Failed to coalesce values: %s", err)
+	}
+
+	var provider ClientProvider = &testClientProvider{
+		t: t,
+		scheme: map[string]kindProps{
+			"v1/Pod": {
+				gvr:        schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+				namespaced: true,
+			},
+		},
+		objects: []runtime.Object{makeUnstructured("v1", "Pod", "pod1", "default")},
+	}
+
+	counter := &countingClientProvider{ClientProvider: provider}
+	var counted ClientProvider = counter
+	e := Engine{clientProvider: &counted}
+	out, err := e.Render(c, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// pod1 is looked up once, and the missing pod2 once.
+	if counter.calls != 2 {
+		t.Errorf("Expected 2 lookups, got %d", counter.calls)
+	}
+	// Changes made by templates to the results do not leak through the
+	// cache.
+	if got := out["moby/templates/second"]; got != "Pod Pod" {
+		t.Errorf("Expected %q, got %q", "Pod Pod", got)
+	}
+
+	// Each render has its own cache.
+	if _, err := e.Render(c, v); err != nil {
+		t.Fatal(err)
+	}
+	if counter.calls != 4 {
+		t.Errorf("Expected 4 lookups, got %d", counter.calls)
+	}
+
+	counter.calls = 0
+	e.DisableLookupCache = true
+	if _, err := e.Render(c, v); err != nil {
+		t.Fatal(err)
+	}
+	if counter.calls != 5 {
+		t.Errorf("Expected 5 lookups without a cache, got %d", counter.calls)
+	}
+}
+
 func TestParallelRenderInternals(t *testing.T) {
 	// Make sure that we can use one Engine to run parallel template renders.
 	e := new(Engine)
@@ -750,6 +824,47 @@ func TestRenderNestedValues(t *testing.T) {
 	}
 }
 
+func TestRenderDeterministic(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "stable"},
+		Templates: []*chart.File{
+			{Name: "templates/yaml", Data: []byte(`{{ toYaml .Values }}`)},
+			{Name: "templates/pretty", Data: []byte(`{{ toYamlPretty .Values }}`)},
+			{Name: "templates/json", Data: []byte(`{{ toJson .Values }}`)},
+			{Name: "templates/toml", Data: []byte(`{{ toToml .Values }}`)},
+			{Name: "templates/keys", Data: []byte(`{{ keys .Values.nested .Values.other | join "," }} {{ values .Values.other | join "," }}`)},
+			{Name: "templates/range", Data: []byte(`{{ range $k, $v := .Values.nested }}{{ $k }}={{ toJson $v }};{{ end }}`)},
+		},
+	}
+	nested := map[string]interface{}{}
+	other := map[string]interface{}{}
+	for i := 0; i < 32; i++ {
+		key := fmt.Sprintf("key%02d", (i*7)%32)
+		nested[key] = map[string]interface{}{"b": i, "a": map[string]interface{}{"z": key, "y": i}}
+		other[fmt.Sprintf("other%02d", (i*11)%32)] = key
+	}
+	vals := map[string]interface{}{
+		"Values": map[string]interface{}{"nested": nested, "other": other},
+	}
+
+	first, err := Render(c, vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		out, err := Render(c, vals)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(out, first) {
+			t.Fatalf("expected repeated renders to be identical, got:\n%v\nand:\n%v", first, out)
+		}
+	}
+	if !strings.HasPrefix(first["stable/templates/keys"], "key00,key01,key02,") {
+		t.Errorf("expected the keys to be sorted, got %q", first["stable/templates/keys"])
+	}
+}
+
 func TestRenderBuiltinValues(t *testing.T) {
 	inner := &chart.Chart{
 		Metadata: &chart.Metadata{Name: "Latium"},
@@ -801,6 +916,32 @@ func TestRenderBuiltinValues(t *testing.T) {
 
 }
 
+func TestRenderFilesGlobInfo(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "bundle"},
+		Templates: []*chart.File{
+			{Name: "templates/manifest", Data: []byte(`{{ range .Files.GlobInfo "**" }}{{ .Name }} {{ .Size }} {{ .Content | quote }}
+{{ end }}`)},
+		},
+		Files: []*chart.File{
+			{Name: "files/b.conf", Data: []byte("b=2")},
+			{Name: "files/a.conf", Data: []byte("a=1")},
+			{Name: "README.md", Data: []byte("# bundle")},
+		},
+	}
+	vals := chartutil.Values{"Values": map[string]interface{}{}, "Chart": c.Metadata}
+
+	out, err := Render(c, vals)
+	if err != nil {
+		t.Fatalf("failed to render templates: %s", err)
+	}
+	// The templates of the chart are not files of the chart.
+	expect := "README.md 8 \"# bundle\"\nfiles/a.conf 3 \"a=1\"\nfiles/b.conf 3 \"b=2\"\n"
+	if got := out["bundle/templates/manifest"]; got != expect {
+		t.Errorf("Expected %q, got %q", expect, got)
+	}
+}
+
 func TestAlterFuncMap_include(t *testing.T) {
 	c := &chart.Chart{
 		Metadata: &chart.Metadata{Name: "conrad"},
@@ -1010,7 +1151,7 @@ func TestRenderRecursionLimit(t *testing.T) {
 			"Name": "TestRelease",
 		},
 	}
-	expectErr := "rendering template has a nested reference name: recursion: unable to execute template"
+	expectErr := "rendering template has a nested reference name: recursion: include depth exceeds the limit of 1000 through bad/templates/base -> recursion -> recursion -> ...: unable to execute template"
 
 	_, err := Render(c, v)
 	if err == nil || !strings.HasSuffix(err.Error(), expectErr) {
@@ -1049,6 +1190,60 @@ func TestRenderRecursionLimit(t *testing.T) {
 
 }
 
+func TestRenderRecursionLimitTpl(t *testing.T) {
+	// endless recursion through tpl and include should produce an error
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "bad"},
+		Templates: []*chart.File{
+			{Name: "templates/base", Data: []byte(`{{ tpl .Values.loop . }}`)},
+			{Name: "templates/_helpers", Data: []byte(`{{define "loop"}}{{ tpl .Values.loop . }}{{end}}`)},
+		},
+	}
+	v := chartutil.Values{
+		"Values": chartutil.Values{"loop": `{{ include "loop" . }}`},
+		"Chart":  c.Metadata,
+	}
+
+	e := Engine{MaxIncludeDepth: 10}
+	_, err := e.Render(c, v)
+	var rerr *RecursionError
+	if !errors.As(err, &rerr) {
+		t.Fatalf("Expected a recursion error, got %v", err)
+	}
+	if rerr.Limit != 10 {
+		t.Errorf("Expected a limit of 10, got %d", rerr.Limit)
+	}
+	expectChain := []string{"bad/templates/base", `tpl "{{ include \"loop\" . }}"`, "loop", `tpl "{{ include \"loop\" . }}"`, "..."}
+	if !reflect.DeepEqual(rerr.Chain, expectChain) {
+		t.Errorf("Expected chain %q, got %q", expectChain, rerr.Chain)
+	}
+	// The error is reported once.
+	if n := strings.Count(err.Error(), "include depth exceeds"); n != 1 {
+		t.Errorf("Expected the recursion to be reported once, got %d times: %s", n, err)
+	}
+
+	// Deeper nesting is allowed with a higher limit.
+	d := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "deep"},
+		Templates: []*chart.File{
+			{Name: "templates/base", Data: []byte(`{{ include "nest" 1200 }}`)},
+			{Name: "templates/_helpers", Data: []byte(`{{define "nest"}}{{ if gt . 0 }}{{ include "nest" (sub . 1) }}{{ else }}done{{ end }}{{end}}`)},
+		},
+	}
+	v["Chart"] = d.Metadata
+	if _, err := Render(d, v); err == nil {
+		t.Error("Expected the default limit to be exceeded")
+	}
+	e.MaxIncludeDepth = 2000
+	out, err := e.Render(d, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out["deep/templates/base"]; got != "done" {
+		t.Errorf("Expected %q, got %q", "done", got)
+	}
+}
+
 func TestRenderLoadTemplateForTplFromFile(t *testing.T) {
 	c := &chart.Chart{
 		Metadata: &chart.Metadata{Name: "TplLoadFromFile"},
@@ -1164,6 +1359,60 @@ func TestRenderTplTemplateNames(t *testing.T) {
 	}
 }
 
+func TestRenderTplErrors(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "TplErrors"},
+		Templates: []*chart.File{
+			{Name: "templates/exec", Data: []byte("a: 1\nb: {{ tpl .Values.exec . }}")},
+			{Name: "templates/parse", Data: []byte(`{{ tpl .Values.parse . }}`)},
+			{Name: "templates/include", Data: []byte(`{{ tpl .Values.include . }}`)},
+			{Name: "templates/_helpers.tpl", Data: []byte("{{ define \"helper\" }}\n{{ index .Values.list 3 }}{{ end }}")},
+		},
+	}
+	v := chartutil.Values{
+		"Values": chartutil.Values{
+			"exec":    "line: 1\n{{ index .Values.list 3 }}",
+			"parse":   "{{ foo }}",
+			"include": `{{ include "helper" . }}`,
+			"list":    []interface{}{1},
+		},
+		"Chart": c.Metadata,
+	}
+
+	tests := []struct {
+		template string
+		expect   string
+	}{
+		{
+			template: "templates/exec",
+			expect:   `template: TplErrors/templates/exec:2:6: executing "TplErrors/templates/exec" at <tpl .Values.exec .>: error calling tpl: error during tpl function execution for "line: 1\n{{ index .Values.list 3 }}" (text given to tpl, such as a value, not a chart file) at line 2, column 3: executing "tpl" at <index .Values.list 3>: error calling index: index out of range: 3`,
+		},
+		{
+			template: "templates/parse",
+			expect:   `template: TplErrors/templates/parse:1:3: executing "TplErrors/templates/parse" at <tpl .Values.parse .>: error calling tpl: cannot parse template "{{ foo }}" (text given to tpl, such as a value, not a chart file) at line 1: function "foo" not defined`,
+		},
+		{
+			// Errors in chart files included by the text keep their location.
+			template: "templates/include",
+			expect:   `error calling tpl: error during tpl function execution for "{{ include \"helper\" . }}" (text given to tpl, such as a value, not a chart file) at line 1, column 3: executing "tpl" at <include "helper" .>: error calling include: template: TplErrors/templates/_helpers.tpl:2:3: executing "helper" at <index .Values.list 3>: error calling index: index out of range: 3`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			tpls := allTemplates(c, v)
+			for name := range tpls {
+				if name != "TplErrors/"+tt.template && name != "TplErrors/templates/_helpers.tpl" {
+					delete(tpls, name)
+				}
+			}
+			_, err := new(Engine).render(tpls)
+			if err == nil || !strings.HasSuffix(err.Error(), tt.expect) {
+				t.Errorf("Expected error ending with %q, got %v", tt.expect, err)
+			}
+		})
+	}
+}
+
 func TestRenderTplRedefines(t *testing.T) {
 	// Redefining a template inside 'tpl' does not affect the outer definition
 	c := &chart.Chart{
@@ -1360,3 +1609,85 @@ func TestRenderCustomTemplateFuncs(t *testing.T) {
 		t.Errorf("Expected %q, got %q", expected, rendered)
 	}
 }
+
+func TestRenderWithFuncs(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "WithFuncs"},
+		Templates: []*chart.File{
+			{Name: "templates/manifest", Data: []byte(`{{ include "secret" . }}`)},
+			{Name: "templates/_helpers.tpl", Data: []byte(`{{ define "secret" }}{{ secretLookup .Values.key }}{{ end }}`)},
+		},
+	}
+	v := chartutil.Values{
+		"Values": chartutil.Values{"key": "password"},
+		"Chart":  c.Metadata,
+	}
+
+	e, err := new(Engine).WithFuncs(template.FuncMap{
+		"secretLookup": func(key string) (string, error) {
+			return "secret:" + key, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := e.Render(c, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rendered := out["WithFuncs/templates/manifest"]; rendered != "secret:password" {
+		t.Errorf("Expected %q, got %q", "secret:password", rendered)
+	}
+}
+
+func TestWithFuncsErrors(t *testing.T) {
+	e, err := new(Engine).WithFuncs(template.FuncMap{"exclaim": func(s string) string { return s + "!" }})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		funcs  template.FuncMap
+		expect string
+	}{
+		{
+			name:   "built-in function",
+			funcs:  template.FuncMap{"toYaml": func(interface{}) string { return "" }},
+			expect: `cannot register template function "toYaml": a built-in function has this name`,
+		},
+		{
+			name:   "late-bound built-in function",
+			funcs:  template.FuncMap{"include": func(string, interface{}) string { return "" }},
+			expect: `cannot register template function "include": a built-in function has this name`,
+		},
+		{
+			name:   "registered function",
+			funcs:  template.FuncMap{"exclaim": func(s string) string { return s }},
+			expect: `cannot register template function "exclaim": a function has been registered with this name already`,
+		},
+		{
+			name:   "not a function",
+			funcs:  template.FuncMap{"answer": 42},
+			expect: `cannot register template function "answer": not a function`,
+		},
+		{
+			name:   "invalid results",
+			funcs:  template.FuncMap{"pair": func() (string, string) { return "", "" }},
+			expect: `cannot register template function "pair": a template function must return a value, optionally followed by an error`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := e.WithFuncs(tt.funcs)
+			if err == nil || err.Error() != tt.expect {
+				t.Errorf("Expected error %q, got %v", tt.expect, err)
+			}
+		})
+	}
+
+	// The engine is left unchanged.
+	if _, ok := e.CustomTemplateFuncs["answer"]; ok || len(e.CustomTemplateFuncs) != 1 {
+		t.Errorf("Expected the engine functions to be unchanged, got %v", e.CustomTemplateFuncs)
+	}
+}
@@ -19,6 +19,7 @@ package engine
 import (
 	"encoding/base64"
 	"path"
+	"sort"
 	"strings"
 
 	"github.com/gobwas/glob"
@@ -87,6 +88,46 @@ func (f files) Glob(pattern string) files {
 	return nf
 }
 
+// FileInfo describes a file of a chart to templates. Its content is only
+// converted when asked for.
+type FileInfo struct {
+	name string
+	data []byte
+}
+
+// Name returns the path of the file relative to the chart, such as
+// "config/app.yaml".
+func (f FileInfo) Name() string { return f.name }
+
+// Base returns the name of the file without its directory.
+func (f FileInfo) Base() string { return path.Base(f.name) }
+
+// Size returns the size of the file in bytes.
+func (f FileInfo) Size() int { return len(f.data) }
+
+// Content returns the content of the file as a string.
+func (f FileInfo) Content() string { return string(f.data) }
+
+// Bytes returns the raw content of the file.
+func (f FileInfo) Bytes() []byte { return f.data }
+
+// GlobInfo returns the descriptions of the files matching a glob pattern,
+// as Glob does, sorted by name.
+//
+// This is designed to be called from a template.
+//
+// {{ range .Files.GlobInfo "config/**" }}
+// {{ .Name }}: {{ .Size }} bytes{{ end }}
+func (f files) GlobInfo(pattern string) []FileInfo {
+	matched := f.Glob(pattern)
+	infos := make([]FileInfo, 0, len(matched))
+	for name, data := range matched {
+		infos = append(infos, FileInfo{name: name, data: data})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].name < infos[j].name })
+	return infos
+}
+
 // AsConfig turns a Files group and flattens it to a YAML map suitable for
 // including in the 'data' section of a Kubernetes ConfigMap definition.
 // Duplicate keys will be overwritten, so be aware that your file names
@@ -67,6 +67,23 @@ func TestFileGlob(t *testing.T) {
 	as.Equal("Joseph Conrad", matched.Get("story/author.txt"))
 }
 
+func TestFileGlobInfo(t *testing.T) {
+	as := assert.New(t)
+
+	f := getTestFiles()
+
+	infos := f.GlobInfo("ship/**")
+	as.Len(infos, 2, "Should be two files in glob ship/**")
+	as.Equal("ship/captain.txt", infos[0].Name())
+	as.Equal("captain.txt", infos[0].Base())
+	as.Equal(len("The Captain"), infos[0].Size())
+	as.Equal("The Captain", infos[0].Content())
+	as.Equal("ship/stowaway.txt", infos[1].Name())
+	as.Equal([]byte("Legatt"), infos[1].Bytes())
+
+	as.Empty(f.GlobInfo("nothing/**"))
+}
+
 func TestToConfig(t *testing.T) {
 	as := assert.New(t)
 
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"maps"
+	"slices"
 	"strings"
 	"text/template"
 
@@ -39,6 +40,7 @@ import (
 //
 //   - "include"
 //   - "tpl"
+//   - the validation functions, such as "mustBeInt"
 //
 // These are late-bound in Engine.Render().  The
 // version included in the FuncMap is a placeholder.
@@ -61,6 +63,12 @@ func funcMap() template.FuncMap {
 		"fromJson":      fromJSON,
 		"fromJsonArray": fromJSONArray,
 
+		// Unlike the sprig functions they replace, these return the content
+		// of maps ordered by key, for renders of the same input to be
+		// identical.
+		"keys":   keys,
+		"values": values,
+
 		// This is a placeholder for the "include" function, which is
 		// late-bound to a template. By declaring it here, we preserve the
 		// integrity of the linter.
@@ -75,10 +83,32 @@ func funcMap() template.FuncMap {
 	}
 
 	maps.Copy(f, extra)
+	// The validation functions are late-bound to collect their failures;
+	// these do not record any.
+	maps.Copy(f, validationFuncs(nil))
 
 	return f
 }
 
+// keys returns the keys of the given maps, sorted.
+func keys(dicts ...map[string]interface{}) []string {
+	var k []string
+	for _, dict := range dicts {
+		k = append(k, slices.Collect(maps.Keys(dict))...)
+	}
+	slices.Sort(k)
+	return k
+}
+
+// values returns the values of dict, ordered by their key.
+func values(dict map[string]interface{}) []interface{} {
+	v := make([]interface{}, 0, len(dict))
+	for _, k := range slices.Sorted(maps.Keys(dict)) {
+		v = append(v, dict[k])
+	}
+	return v
+}
+
 // toYAML takes an interface, marshals it to yaml, and returns a string. It will
 // always return a string, even on marshal error (empty string).
 //
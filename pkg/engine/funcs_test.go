@@ -37,6 +37,14 @@ func TestFuncs(t *testing.T) {
 		tpl:    `{{ toYamlPretty . }}`,
 		expect: "baz:\n  - 1\n  - 2\n  - 3",
 		vars:   map[string]interface{}{"baz": []int{1, 2, 3}},
+	}, {
+		tpl:    `{{ keys .one .two | join "," }}`,
+		expect: "a,b,c,d",
+		vars:   map[string]interface{}{"one": map[string]interface{}{"d": 1, "b": 2}, "two": map[string]interface{}{"c": 3, "a": 4}},
+	}, {
+		tpl:    `{{ values . | join "," }}`,
+		expect: "1,2,3",
+		vars:   map[string]interface{}{"c": 3, "a": 1, "b": 2},
 	}, {
 		tpl:    `{{ toToml . }}`,
 		expect: "foo = \"bar\"\n",
@@ -24,6 +24,7 @@ import (
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
@@ -93,6 +94,31 @@ func newLookupFunction(clientProvider ClientProvider) lookupFunc {
 	}
 }
 
+type lookupKey struct {
+	apiVersion, kind, namespace, name string
+}
+
+// newCachedLookupFunction returns a function memoizing the results of lookup,
+// so that the same objects are not retrieved repeatedly from the cluster. Errors
+// are not cached.
+//
+// Copies of the results are returned, as templates may modify them.
+func newCachedLookupFunction(lookup lookupFunc) lookupFunc {
+	cache := map[lookupKey]map[string]interface{}{}
+	return func(apiversion string, kind string, namespace string, name string) (map[string]interface{}, error) {
+		key := lookupKey{apiversion, kind, namespace, name}
+		if obj, ok := cache[key]; ok {
+			return runtime.DeepCopyJSON(obj), nil
+		}
+		obj, err := lookup(apiversion, kind, namespace, name)
+		if err != nil {
+			return obj, err
+		}
+		cache[key] = obj
+		return runtime.DeepCopyJSON(obj), nil
+	}
+}
+
 // getDynamicClientOnKind returns a dynamic client on an Unstructured type. This client can be further namespaced.
 func getDynamicClientOnKind(apiversion string, kind string, config *rest.Config) (dynamic.NamespaceableResourceInterface, bool, error) {
 	gvk := schema.FromAPIVersionAndKind(apiversion, kind)
@@ -0,0 +1,89 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+)
+
+// NotesFileSuffix is the suffix of the templates rendered as the notes of a
+// release. They go through the templating engine but are not YAML files
+// (resources), hence can't have hooks, etc. And the user actually wants to see
+// them after rendering in the status command. However, it must be a suffix
+// since there can be filepath in front of it.
+const NotesFileSuffix = "NOTES.txt"
+
+// RenderNotes renders the notes of a release of chrt, as shown after an
+// install or upgrade, with the values given to the release and the release
+// options. The default capabilities are used if caps is nil.
+//
+// The notes of the subcharts are added to those of chrt if subNotes is set,
+// as with the --render-subchart-notes flag.
+func RenderNotes(chrt *chart.Chart, vals map[string]interface{}, options chartutil.ReleaseOptions, caps *chartutil.Capabilities, subNotes bool) (string, error) {
+	values, err := chartutil.ToRenderValues(chrt, vals, options, caps)
+	if err != nil {
+		return "", err
+	}
+	return new(Engine).RenderNotes(chrt, values, subNotes)
+}
+
+// RenderNotes renders the notes of chrt with the render values, the notes of
+// the subcharts being added to those of chrt if subNotes is set.
+func (e Engine) RenderNotes(chrt *chart.Chart, values chartutil.Values, subNotes bool) (string, error) {
+	files, err := e.Render(chrt, values)
+	if err != nil {
+		return "", err
+	}
+	return ExtractNotes(files, chrt.Name(), subNotes), nil
+}
+
+// ExtractNotes removes the rendered notes from files, and returns the notes
+// of the chart named chartName, followed by those of its subcharts if
+// subNotes is set. The notes of the subcharts are ordered by path.
+func ExtractNotes(files map[string]string, chartName string, subNotes bool) string {
+	top := path.Join(chartName, "templates", NotesFileSuffix)
+	var names []string
+	for name := range files {
+		if strings.HasSuffix(name, NotesFileSuffix) {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if names[i] == top || names[j] == top {
+			return names[i] == top
+		}
+		return names[i] < names[j]
+	})
+
+	var notes strings.Builder
+	for _, name := range names {
+		if subNotes || name == top {
+			// If the notes contain data, add newline before adding more
+			if notes.Len() > 0 {
+				notes.WriteString("\n")
+			}
+			notes.WriteString(files[name])
+		}
+		delete(files, name)
+	}
+	return notes.String()
+}
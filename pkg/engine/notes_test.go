@@ -0,0 +1,75 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"testing"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+)
+
+func TestRenderNotes(t *testing.T) {
+	sub := &chart.Chart{
+		Metadata:  &chart.Metadata{Name: "sub", Version: "0.1.0"},
+		Templates: []*chart.File{{Name: "templates/NOTES.txt", Data: []byte("sub notes for {{ .Release.Name }}")}},
+	}
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "moby", Version: "1.2.3"},
+		Templates: []*chart.File{
+			{Name: "templates/NOTES.txt", Data: []byte(`{{ include "moby.greeting" . }} {{ .Values.who }} in {{ .Release.Namespace }} on {{ .Capabilities.KubeVersion.Major }}`)},
+			{Name: "templates/_helpers.tpl", Data: []byte(`{{ define "moby.greeting" }}hello{{ end }}`)},
+			{Name: "templates/cm.yaml", Data: []byte("kind: ConfigMap")},
+		},
+		Values: map[string]interface{}{"who": "DEFAULT"},
+	}
+	c.AddDependency(sub)
+
+	options := chartutil.ReleaseOptions{Name: "whale", Namespace: "sea"}
+	vals := map[string]interface{}{"who": "ishmael"}
+
+	notes, err := RenderNotes(c, vals, options, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := "hello ishmael in sea on 1"; notes != expect {
+		t.Errorf("Expected %q, got %q", expect, notes)
+	}
+
+	notes, err = RenderNotes(c, vals, options, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := "hello ishmael in sea on 1\nsub notes for whale"; notes != expect {
+		t.Errorf("Expected %q, got %q", expect, notes)
+	}
+}
+
+func TestExtractNotes(t *testing.T) {
+	files := map[string]string{
+		"moby/charts/b/templates/NOTES.txt": "b",
+		"moby/charts/a/templates/NOTES.txt": "a",
+		"moby/templates/NOTES.txt":          "moby",
+		"moby/templates/cm.yaml":            "kind: ConfigMap",
+	}
+	if notes := ExtractNotes(files, "moby", true); notes != "moby\na\nb" {
+		t.Errorf("Expected the notes of the chart first, got %q", notes)
+	}
+	if len(files) != 1 || files["moby/templates/cm.yaml"] == "" {
+		t.Errorf("Expected the notes to be removed from the files, got %v", files)
+	}
+}
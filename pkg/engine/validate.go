@@ -0,0 +1,192 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/template"
+
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+)
+
+// ValidationError is a value failing a validation.
+type ValidationError struct {
+	// Path names the value, such as "service.port".
+	Path string
+	// Reason tells why the value is invalid.
+	Reason string
+	// Template is the template validating the value, if any.
+	Template string
+}
+
+func (e ValidationError) Error() string {
+	if e.Template != "" {
+		return fmt.Sprintf("%s: %s (%s)", e.Path, e.Reason, e.Template)
+	}
+	return e.Path + ": " + e.Reason
+}
+
+// ValidationErrors lists all of the values failing validation during a
+// render.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d value(s) failed validation:", len(e))
+	for _, err := range e {
+		b.WriteString("\n  - " + err.Error())
+	}
+	return b.String()
+}
+
+// A Validator validates the values of a render, the map passed to Render with
+// the Values, Release and Capabilities, before any template is rendered. It
+// returns the values failing validation.
+type Validator func(vals chartutil.Values) []ValidationError
+
+// validate runs the validators of the engine on vals.
+func (e Engine) validate(vals chartutil.Values) error {
+	var errs ValidationErrors
+	for _, validator := range e.Validators {
+		errs = append(errs, validator(vals)...)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	if e.LintMode {
+		// Don't fail on invalid values when linting
+		slog.Warn("invalid values", slog.Any("error", errs))
+		return nil
+	}
+	return errs
+}
+
+// validations collects the values failing the validation functions of the
+// templates while rendering, for all of them to be reported at once.
+//
+// A nil validations records nothing, as the functions of funcMap.
+type validations struct {
+	lintMode bool
+	// template is the name of the template being rendered.
+	template string
+	errs     ValidationErrors
+	// failed are the paths of the values that failed already, which are not
+	// checked again.
+	failed map[string]bool
+}
+
+func newValidations(lintMode bool) *validations {
+	return &validations{lintMode: lintMode, failed: map[string]bool{}}
+}
+
+// check records the value at path as invalid for reason unless ok.
+func (v *validations) check(path string, val interface{}, ok bool, reason string) interface{} {
+	if ok || v == nil || v.failed[path] {
+		return val
+	}
+	v.failed[path] = true
+	if v.lintMode {
+		// Don't fail on invalid values when linting
+		slog.Warn("invalid value", "path", path, "reason", reason, "template", v.template)
+		return val
+	}
+	v.errs = append(v.errs, ValidationError{Path: path, Reason: reason, Template: v.template})
+	return val
+}
+
+// validationFuncs returns the template functions validating values. They
+// return the value they are given, for validations to be piped into each
+// other:
+//
+//	{{ .Values.port | mustBeInt "port" | mustBeBetween "port" 1 65535 }}
+//
+// The failures are collected by v, and only the first failure of a value is
+// reported.
+func validationFuncs(v *validations) template.FuncMap {
+	return template.FuncMap{
+		"mustBe": func(path string, ok bool, reason string, val interface{}) interface{} {
+			return v.check(path, val, ok, reason)
+		},
+		"mustBeInt": func(path string, val interface{}) interface{} {
+			f, ok := toNumber(val)
+			return v.check(path, val, ok && f == float64(int64(f)), "must be an integer, got "+describeValue(val))
+		},
+		"mustBeBetween": func(path string, minimum, maximum, val interface{}) (interface{}, error) {
+			lo, ok := toNumber(minimum)
+			if !ok {
+				return val, fmt.Errorf("mustBeBetween: minimum %v is not a number", minimum)
+			}
+			hi, ok := toNumber(maximum)
+			if !ok {
+				return val, fmt.Errorf("mustBeBetween: maximum %v is not a number", maximum)
+			}
+			f, ok := toNumber(val)
+			return v.check(path, val, ok && lo <= f && f <= hi, fmt.Sprintf("must be between %v and %v, got %s", minimum, maximum, describeValue(val))), nil
+		},
+		"mustMatch": func(path, pattern string, val interface{}) (interface{}, error) {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return val, fmt.Errorf("mustMatch: %w", err)
+			}
+			s, ok := val.(string)
+			return v.check(path, val, ok && re.MatchString(s), fmt.Sprintf("must match %q, got %s", pattern, describeValue(val))), nil
+		},
+		"mustBeOneOf": func(path string, allowed []interface{}, val interface{}) interface{} {
+			ok := false
+			for _, a := range allowed {
+				if fmt.Sprint(a) == fmt.Sprint(val) {
+					ok = true
+					break
+				}
+			}
+			choices := make([]string, 0, len(allowed))
+			for _, a := range allowed {
+				choices = append(choices, describeValue(a))
+			}
+			return v.check(path, val, ok, fmt.Sprintf("must be one of %s, got %s", strings.Join(choices, ", "), describeValue(val)))
+		},
+	}
+}
+
+// toNumber returns the value of val if it is a number.
+func toNumber(val interface{}) (float64, bool) {
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// describeValue formats val for validation errors, quoting strings.
+func describeValue(val interface{}) string {
+	switch val := val.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("%q", val)
+	}
+	return fmt.Sprintf("%v", val)
+}
@@ -0,0 +1,139 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+)
+
+func validationChart() *chart.Chart {
+	return &chart.Chart{
+		Metadata: &chart.Metadata{Name: "validated"},
+		Templates: []*chart.File{
+			{
+				Name: "templates/service",
+				Data: []byte(`port: {{ .Values.port | mustBeInt "port" | mustBeBetween "port" 1 65535 }}
+host: {{ .Values.host | mustMatch "host" "^[a-z.]+$" }}
+type: {{ .Values.type | mustBeOneOf "type" (list "ClusterIP" "NodePort") }}`),
+			},
+			{
+				Name: "templates/deployment",
+				Data: []byte(`replicas: {{ mustBe "replicas" (gt (int .Values.replicas) 0) "must be positive" .Values.replicas }}
+port: {{ .Values.port | mustBeInt "port" }}`),
+			},
+		},
+	}
+}
+
+func validationValues(values map[string]interface{}) chartutil.Values {
+	return chartutil.Values{"Values": values, "Release": chartutil.Values{"Name": "validated"}}
+}
+
+func TestRenderValidationFuncs(t *testing.T) {
+	valid := map[string]interface{}{"port": int64(8080), "host": "example.com", "type": "NodePort", "replicas": 2.0}
+	out, err := new(Engine).Render(validationChart(), validationValues(valid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "port: 8080\nhost: example.com\ntype: NodePort"
+	if got := out["validated/templates/service"]; got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	invalid := map[string]interface{}{"port": int64(70000), "host": "Example.com", "type": "LoadBalancer", "replicas": int64(0)}
+	_, err = new(Engine).Render(validationChart(), validationValues(invalid))
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected validation errors, got %v", err)
+	}
+	// All of the failures are reported, once per value.
+	expected = `4 value(s) failed validation:
+  - port: must be between 1 and 65535, got 70000 (validated/templates/service)
+  - host: must match "^[a-z.]+$", got "Example.com" (validated/templates/service)
+  - type: must be one of "ClusterIP", "NodePort", got "LoadBalancer" (validated/templates/service)
+  - replicas: must be positive (validated/templates/deployment)`
+	if err.Error() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, err)
+	}
+
+	// The failures of composed functions are reported once.
+	invalid["port"] = "http"
+	_, err = new(Engine).Render(validationChart(), validationValues(invalid))
+	if err == nil || strings.Count(err.Error(), "port:") != 1 || !strings.Contains(err.Error(), `port: must be an integer, got "http"`) {
+		t.Errorf("expected a single failure of port, got %v", err)
+	}
+
+	// Linting does not fail on invalid values.
+	if _, err := (Engine{LintMode: true}).Render(validationChart(), validationValues(invalid)); err != nil {
+		t.Errorf("expected no error when linting, got %s", err)
+	}
+}
+
+func TestRenderValidationFuncErrors(t *testing.T) {
+	c := &chart.Chart{
+		Metadata:  &chart.Metadata{Name: "broken"},
+		Templates: []*chart.File{{Name: "templates/broken", Data: []byte(`{{ .Values.host | mustMatch "host" "[" }}`)}},
+	}
+	_, err := new(Engine).Render(c, validationValues(map[string]interface{}{"host": "example.com"}))
+	if err == nil || !strings.Contains(err.Error(), "mustMatch: error parsing regexp") {
+		t.Errorf("expected an invalid pattern to fail the render, got %v", err)
+	}
+}
+
+func TestRenderValidators(t *testing.T) {
+	rendered := false
+	c := &chart.Chart{
+		Metadata:  &chart.Metadata{Name: "validated"},
+		Templates: []*chart.File{{Name: "templates/cm", Data: []byte(`{{ .Values.name }}`)}},
+	}
+	e := Engine{
+		Validators: []Validator{
+			func(vals chartutil.Values) []ValidationError {
+				rendered = true
+				if name, _ := vals.PathValue("Values.name"); name == "" {
+					return []ValidationError{{Path: "name", Reason: "must not be empty"}}
+				}
+				return nil
+			},
+			func(vals chartutil.Values) []ValidationError {
+				if _, err := vals.PathValue("Values.namespace"); err != nil {
+					return []ValidationError{{Path: "namespace", Reason: "is required"}}
+				}
+				return nil
+			},
+		},
+	}
+
+	_, err := e.Render(c, validationValues(map[string]interface{}{"name": ""}))
+	expected := "2 value(s) failed validation:\n  - name: must not be empty\n  - namespace: is required"
+	if !rendered || err == nil || err.Error() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%v", expected, err)
+	}
+
+	out, err := e.Render(c, validationValues(map[string]interface{}{"name": "settings", "namespace": "default"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out["validated/templates/cm"]; got != "settings" {
+		t.Errorf("expected the chart to be rendered, got %q", got)
+	}
+}
@@ -0,0 +1,225 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package getter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"oras.land/oras-go/v2/registry/remote/errcode"
+
+	"helm.sh/helm/v4/internal/fileutil"
+)
+
+// DefaultCacheMaxSize is the size past which the least recently used content
+// is evicted from a cache set with WithCacheDir.
+const DefaultCacheMaxSize = 1 << 30
+
+// contentCache is a cache of the content got, on disk.
+//
+// The content of each key is saved in a data file, along with a metadata file
+// describing it, both named after the hash of the key.
+type contentCache struct {
+	dir     string
+	maxSize int64
+	ttl     time.Duration
+}
+
+// cacheEntry describes the content cached for a key.
+type cacheEntry struct {
+	Key string `json:"key"`
+	// ETag and LastModified are the validators of HTTP responses.
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	// Expires is the time the content stops being fresh.
+	Expires time.Time `json:"expires"`
+	// Used is the last time the content was served.
+	Used time.Time `json:"used"`
+	Size int64     `json:"size"`
+	// Digest is the SHA-256 digest of the content, to detect corruption.
+	Digest string `json:"digest"`
+}
+
+// newContentCache returns the cache set in opts, or nil if there is none.
+func newContentCache(opts *options) *contentCache {
+	if opts.cacheDir == "" {
+		return nil
+	}
+	maxSize := opts.cacheMaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultCacheMaxSize
+	}
+	return &contentCache{dir: opts.cacheDir, maxSize: maxSize, ttl: opts.cacheTTL}
+}
+
+// cacheKey returns the cache key of the content got from href with the
+// credentials of identity, such as "username=admin", if any, so that content
+// got with some credentials is not served to requests with others.
+func cacheKey(href, identity string) string {
+	if identity == "" {
+		return href
+	}
+	return href + " " + identity
+}
+
+func (c *contentCache) path(key, ext string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+ext)
+}
+
+// load returns the entry and the content cached for key, or nil if there is
+// none or it is corrupted.
+func (c *contentCache) load(key string) (*cacheEntry, []byte) {
+	raw, err := os.ReadFile(c.path(key, ".json"))
+	if err != nil {
+		return nil, nil
+	}
+	e := &cacheEntry{}
+	if err := json.Unmarshal(raw, e); err != nil || e.Key != key {
+		return nil, nil
+	}
+	data, err := os.ReadFile(c.path(key, ".data"))
+	if err != nil || contentDigest(data) != e.Digest {
+		return nil, nil
+	}
+	return e, data
+}
+
+// store caches data as the content of e, and evicts the least recently used
+// content past the maximum size of the cache.
+func (c *contentCache) store(e *cacheEntry, data []byte) error {
+	e.Size = int64(len(data))
+	if e.Size > c.maxSize {
+		return nil
+	}
+	e.Digest = contentDigest(data)
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+	if err := fileutil.AtomicWriteFile(c.path(e.Key, ".data"), bytes.NewReader(data), 0644); err != nil {
+		return err
+	}
+	if err := c.use(e); err != nil {
+		return err
+	}
+	return c.evict()
+}
+
+// use records that the content of e is served, saving its metadata.
+func (c *contentCache) use(e *cacheEntry) error {
+	e.Used = time.Now()
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return fileutil.AtomicWriteFile(c.path(e.Key, ".json"), bytes.NewReader(raw), 0644)
+}
+
+// evict removes the least recently used content until the cache fits within
+// its maximum size.
+func (c *contentCache) evict() error {
+	files, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	var entries []*cacheEntry
+	var size int64
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		e := &cacheEntry{}
+		if err := json.Unmarshal(raw, e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+		size += e.Size
+	}
+	slices.SortFunc(entries, func(a, b *cacheEntry) int { return a.Used.Compare(b.Used) })
+
+	var errs []error
+	for _, e := range entries {
+		if size <= c.maxSize {
+			break
+		}
+		for _, ext := range []string{".json", ".data"} {
+			if err := os.Remove(c.path(e.Key, ext)); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, err)
+			}
+		}
+		size -= e.Size
+	}
+	return errors.Join(errs...)
+}
+
+func contentDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// expires returns the time a response with header stops being fresh, from
+// its Cache-Control and Expires headers or else the TTL of the cache. It
+// returns false if the response must not be cached.
+func (c *contentCache) expires(header http.Header, now time.Time) (time.Time, bool) {
+	maxAge := -1
+	noCache := false
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-store":
+			return time.Time{}, false
+		case directive == "no-cache":
+			noCache = true
+		case strings.HasPrefix(directive, "max-age="):
+			if age, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil && age >= 0 {
+				maxAge = age
+			}
+		}
+	}
+	switch {
+	case noCache:
+		return now, true
+	case maxAge >= 0:
+		return now.Add(time.Duration(maxAge) * time.Second), true
+	}
+	if t, err := http.ParseTime(header.Get("Expires")); err == nil {
+		return t, true
+	}
+	return now.Add(c.ttl), true
+}
+
+// isRegistryUnavailable reports whether err is a failure to pull from a
+// registry that is likely transient, such as a network error or a 5xx
+// response.
+func isRegistryUnavailable(err error) bool {
+	var errResp *errcode.ErrorResponse
+	if errors.As(err, &errResp) {
+		return errResp.StatusCode >= http.StatusInternalServerError || errResp.StatusCode == http.StatusTooManyRequests
+	}
+	return IsUnavailable(err)
+}
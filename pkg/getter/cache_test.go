@@ -0,0 +1,259 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package getter
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"oras.land/oras-go/v2/registry/remote/errcode"
+
+	"helm.sh/helm/v4/pkg/registry"
+)
+
+func TestHTTPGetterCache(t *testing.T) {
+	var requests, revalidations atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/fresh":
+			w.Header().Set("Cache-Control", "max-age=3600")
+		case "/etag":
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				revalidations.Add(1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		fmt.Fprint(w, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	get := func(path string) string {
+		t.Helper()
+		g, err := NewHTTPGetter(WithURL(srv.URL), WithCacheDir(dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf, err := g.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	tests := []struct {
+		path          string
+		requests      int32
+		revalidations int32
+	}{
+		// Fresh content is served from the cache.
+		{path: "/fresh", requests: 1},
+		// Stale content is revalidated.
+		{path: "/etag", requests: 2, revalidations: 1},
+		// Content that must not be stored is always requested.
+		{path: "/no-store", requests: 2},
+	}
+	for _, tt := range tests {
+		requests.Store(0)
+		revalidations.Store(0)
+		for range 2 {
+			if got := get(tt.path); got != tt.path {
+				t.Errorf("expected %q, got %q", tt.path, got)
+			}
+		}
+		if got := requests.Load(); got != tt.requests {
+			t.Errorf("%s: expected %d requests, got %d", tt.path, tt.requests, got)
+		}
+		if got := revalidations.Load(); got != tt.revalidations {
+			t.Errorf("%s: expected %d revalidations, got %d", tt.path, tt.revalidations, got)
+		}
+	}
+}
+
+func TestHTTPGetterCacheCredentials(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if username, password, ok := r.BasicAuth(); !ok || username != "alice" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=3600")
+		fmt.Fprint(w, "private")
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	get := func(opts ...Option) (string, error) {
+		t.Helper()
+		g, err := NewHTTPGetter(append([]Option{WithURL(srv.URL), WithCacheDir(dir)}, opts...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf, err := g.Get(srv.URL + "/index.yaml")
+		if err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+
+	for range 2 {
+		if got, err := get(WithBasicAuth("alice", "secret")); err != nil || got != "private" {
+			t.Fatalf("expected the content for alice, got %q, %v", got, err)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("expected the content for alice to be cached, got %d requests", got)
+	}
+
+	// The content got with some credentials is not served to others.
+	if _, err := get(WithBasicAuth("bob", "guess")); err == nil {
+		t.Error("expected the content for alice not to be served to bob")
+	}
+	if _, err := get(); err == nil {
+		t.Error("expected the content for alice not to be served without credentials")
+	}
+}
+
+func TestOCIGetterCacheFallback(t *testing.T) {
+	// The registry is unavailable once its server is closed.
+	srv := httptest.NewServer(http.NotFoundHandler())
+	host := strings.TrimPrefix(srv.URL, "http://")
+	srv.Close()
+
+	client, err := registry.NewClient(registry.ClientOptPlainHTTP(), registry.ClientOptCredentialsFile(filepath.Join(t.TempDir(), "config.json")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	g, err := NewOCIGetter(WithRegistryClient(client), WithCacheDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	href := fmt.Sprintf("oci://%s/charts/nginx:1.0.0", host)
+
+	if _, err := g.Get(href); err == nil {
+		t.Fatal("expected an error without cached content")
+	}
+
+	// Stale content is served while the registry is unavailable.
+	c := &contentCache{dir: dir, maxSize: DefaultCacheMaxSize}
+	if err := c.store(&cacheEntry{Key: href}, []byte("chart")); err != nil {
+		t.Fatal(err)
+	}
+	buf, err := g.Get(href)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "chart" {
+		t.Errorf("expected the cached content, got %q", buf)
+	}
+}
+
+func TestContentCacheEvict(t *testing.T) {
+	c := &contentCache{dir: t.TempDir(), maxSize: 10}
+	for _, key := range []string{"a", "b", "c"} {
+		if err := c.store(&cacheEntry{Key: key}, []byte("1234")); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+		// The content of a is used again, so b is the least recently used.
+		if key == "b" {
+			e, _ := c.load("a")
+			if err := c.use(e); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for key, cached := range map[string]bool{"a": true, "b": false, "c": true} {
+		if e, _ := c.load(key); (e != nil) != cached {
+			t.Errorf("expected %s to be cached: %t", key, cached)
+		}
+	}
+
+	// Corrupted content is ignored.
+	if err := os.WriteFile(c.path("a", ".data"), []byte("4321"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := c.load("a"); e != nil {
+		t.Error("expected corrupted content not to be served")
+	}
+	if matches, _ := filepath.Glob(filepath.Join(c.dir, "*.data")); len(matches) != 2 {
+		t.Errorf("expected 2 cached files, got %d", len(matches))
+	}
+}
+
+func TestContentCacheExpires(t *testing.T) {
+	c := &contentCache{ttl: time.Minute}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header  http.Header
+		expires time.Time
+		ok      bool
+	}{
+		{header: http.Header{}, expires: now.Add(time.Minute), ok: true},
+		{header: http.Header{"Cache-Control": {"public, max-age=60"}}, expires: now.Add(time.Minute), ok: true},
+		{header: http.Header{"Cache-Control": {"max-age=60, no-cache"}}, expires: now, ok: true},
+		{header: http.Header{"Cache-Control": {"no-store"}}},
+		{header: http.Header{"Expires": {"Mon, 01 Jan 2024 01:00:00 GMT"}}, expires: now.Add(time.Hour), ok: true},
+	}
+	for _, tt := range tests {
+		expires, ok := c.expires(tt.header, now)
+		if !expires.Equal(tt.expires) || ok != tt.ok {
+			t.Errorf("%v: expected %s, %t, got %s, %t", tt.header, tt.expires, tt.ok, expires, ok)
+		}
+	}
+}
+
+func TestOCIDigestKey(t *testing.T) {
+	tests := []struct {
+		ref  string
+		prov bool
+		key  string
+	}{
+		{ref: "registry.example.com/charts/nginx:1.0.0", key: "oci://registry.example.com/charts/nginx@sha256:abc"},
+		{ref: "localhost:5000/nginx:1.0.0", prov: true, key: "oci://localhost:5000/nginx@sha256:abc.prov"},
+		{ref: "localhost:5000/nginx", key: "oci://localhost:5000/nginx@sha256:abc"},
+	}
+	for _, tt := range tests {
+		if key := ociDigestKey(tt.ref, "sha256:abc", tt.prov); key != tt.key {
+			t.Errorf("%s: expected %q, got %q", tt.ref, tt.key, key)
+		}
+	}
+}
+
+func TestIsRegistryUnavailable(t *testing.T) {
+	if !isRegistryUnavailable(fmt.Errorf("pull: %w", &errcode.ErrorResponse{StatusCode: http.StatusBadGateway})) {
+		t.Error("expected a 502 response to be unavailable")
+	}
+	if isRegistryUnavailable(&errcode.ErrorResponse{StatusCode: http.StatusNotFound}) {
+		t.Error("expected a 404 response not to be unavailable")
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package getter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+)
+
+// credentialsNotFound is the output of a credential helper that has no
+// credentials for a server, as with Docker credential helpers.
+const credentialsNotFound = "credentials not found"
+
+// credentialHelperResponse is the output of a credential helper.
+type credentialHelperResponse struct {
+	Username string `json:"Username"`
+	Secret   string `json:"Secret"`
+}
+
+// WithCredentialHelper sets the credential helper program run to get the
+// credentials of requests to the server set with WithURL, unless
+// credentials are set with WithBasicAuth.
+//
+// The helper follows the protocol of Docker credential helpers: it is run
+// with the "get" argument and the URL of the server on its standard input,
+// and writes a JSON object with the "Username" and "Secret" of the server to
+// its standard output. The secret is a password, or a bearer token if the
+// username is empty. A helper without credentials for the server exits with
+// an error after writing "credentials not found", and the request is sent
+// without credentials. The credentials are only used for the request, and
+// are never stored. Getters that cannot authenticate their requests this way
+// ignore it.
+func WithCredentialHelper(helper string) Option {
+	return func(opts *options) {
+		opts.credentialHelper = helper
+	}
+}
+
+// setHelperCredentials sets the Authorization header of req to the
+// credentials returned by the credential helper for serverURL, if any.
+func setHelperCredentials(ctx context.Context, req *http.Request, helper, serverURL string) error {
+	creds, err := runCredentialHelper(ctx, helper, serverURL)
+	if err != nil || creds == nil {
+		return err
+	}
+	if creds.Username == "" {
+		req.Header.Set("Authorization", "Bearer "+creds.Secret)
+		return nil
+	}
+	req.SetBasicAuth(creds.Username, creds.Secret)
+	return nil
+}
+
+// runCredentialHelper runs helper for the credentials of serverURL. It
+// returns nil if the helper has none.
+func runCredentialHelper(ctx context.Context, helper, serverURL string) (*credentialHelperResponse, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, helper, "get")
+	cmd.Stdin = strings.NewReader(serverURL)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if strings.TrimSpace(stdout.String()) == credentialsNotFound {
+			return nil, nil
+		}
+		var exitErr *exec.ExitError
+		if msg := strings.TrimSpace(stderr.String() + stdout.String()); errors.As(err, &exitErr) && msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("credential helper %s failed for %s: %w", helper, serverURL, err)
+	}
+
+	var creds credentialHelperResponse
+	if err := json.Unmarshal(stdout.Bytes(), &creds); err != nil {
+		return nil, fmt.Errorf("invalid output of credential helper %s for %s: %w", helper, serverURL, err)
+	}
+	if creds.Secret == "" {
+		return nil, fmt.Errorf("invalid output of credential helper %s for %s: no secret", helper, serverURL)
+	}
+	return &creds, nil
+}
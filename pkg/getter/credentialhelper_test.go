@@ -0,0 +1,163 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package getter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v4/pkg/cli"
+)
+
+// writeCredentialHelper writes a credential helper that runs script, with the
+// server URL read from its standard input in $url.
+func writeCredentialHelper(t *testing.T, script string) string {
+	t.Helper()
+	helper := filepath.Join(t.TempDir(), "helm-credential-test")
+	content := "#!/bin/sh\n[ \"$1\" = get ] || exit 3\nread -r url\n" + script + "\n"
+	if err := os.WriteFile(helper, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return helper
+}
+
+func TestHTTPGetterCredentialHelper(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("TODO: refactor this test to work on windows")
+	}
+
+	var authorization string
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		script string
+		opts   []Option
+		expect string
+		err    string
+	}{
+		{
+			name:   "basic auth",
+			script: `[ "$url" = "` + srv.URL + `" ] && echo '{"ServerURL": "'$url'", "Username": "user", "Secret": "pass"}'`,
+			expect: "Basic dXNlcjpwYXNz",
+		},
+		{
+			name:   "bearer token",
+			script: `echo '{"Secret": "token"}'`,
+			expect: "Bearer token",
+		},
+		{
+			name:   "credentials not found",
+			script: "echo 'credentials not found'; exit 1",
+		},
+		{
+			name:   "basic auth set",
+			script: "exit 1",
+			opts:   []Option{WithBasicAuth("admin", "secret")},
+			expect: "Basic YWRtaW46c2VjcmV0",
+		},
+		{
+			name:   "failure",
+			script: "echo 'vault is sealed' >&2; exit 1",
+			err:    "vault is sealed",
+		},
+		{
+			name:   "invalid output",
+			script: "echo 'user:pass'",
+			err:    "invalid output of credential helper",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authorization = ""
+			helper := writeCredentialHelper(t, tt.script)
+			g, err := NewHTTPGetter(append([]Option{WithURL(srv.URL), WithCredentialHelper(helper)}, tt.opts...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = g.Get(srv.URL + "/index.yaml")
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if authorization != tt.expect {
+				t.Errorf("expected the authorization %q, got %q", tt.expect, authorization)
+			}
+		})
+	}
+
+	// The helper is not run for other servers.
+	helper := writeCredentialHelper(t, "exit 1")
+	g, err := NewHTTPGetter(WithURL("https://charts.example.com"), WithCredentialHelper(helper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Get(srv.URL + "/index.yaml"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAllCredentialHelper(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("TODO: refactor this test to work on windows")
+	}
+
+	var authorization string
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+	}))
+	defer srv.Close()
+
+	env := cli.New()
+	env.CredentialHelper = writeCredentialHelper(t, `echo '{"Secret": "global"}'`)
+	g, err := All(env).ByScheme("http")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Get(srv.URL+"/index.yaml", WithURL(srv.URL)); err != nil {
+		t.Fatal(err)
+	}
+	if authorization != "Bearer global" {
+		t.Errorf("expected the credentials of the global helper, got %q", authorization)
+	}
+
+	// The helper of a repository overrides the global one.
+	g, err = All(env).ByScheme("http")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repoHelper := writeCredentialHelper(t, `echo '{"Secret": "repo"}'`)
+	if _, err := g.Get(srv.URL+"/index.yaml", WithURL(srv.URL), WithCredentialHelper(repoHelper)); err != nil {
+		t.Fatal(err)
+	}
+	if authorization != "Bearer repo" {
+		t.Errorf("expected the credentials of the repository helper, got %q", authorization)
+	}
+}
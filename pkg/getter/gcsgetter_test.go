@@ -0,0 +1,92 @@
+//go:build gcs
+
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package getter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"helm.sh/helm/v4/pkg/cli"
+)
+
+func TestGCSGetter(t *testing.T) {
+	objects := map[string]string{
+		"/charts/stable/index.yaml":            "apiVersion: v1\n",
+		"/charts/stable/alpine-0.1.0.tgz":      "tarball",
+		"/charts/stable/alpine-0.1.0.tgz.prov": "provenance",
+	}
+	var userAgent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		if r.URL.Path == "/charts/stable/slow.tgz" {
+			time.Sleep(time.Second)
+		}
+		body, ok := objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	t.Setenv("STORAGE_EMULATOR_HOST", u.Host)
+
+	g, err := NewGCSGetter(WithUserAgent("Groot"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, expected := range objects {
+		buf, err := g.Get("gs:/" + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != expected {
+			t.Errorf("Expected %q for %s, got %q", expected, path, buf.String())
+		}
+	}
+	if !strings.Contains(userAgent, "Groot") {
+		t.Errorf("Expected user agent to contain %q, got %q", "Groot", userAgent)
+	}
+
+	if _, err := g.Get("gs://charts/stable/missing.tgz"); err == nil {
+		t.Error("Expected error for missing object")
+	}
+	if _, err := g.Get("gs://charts"); err == nil {
+		t.Error("Expected error for URL without an object")
+	}
+	if _, err := g.Get("gs://charts/stable/slow.tgz", WithTimeout(50*time.Millisecond)); err == nil {
+		t.Error("Expected error when the timeout expires")
+	}
+}
+
+func TestGCSProvider(t *testing.T) {
+	p, err := All(cli.New()).ByScheme(GCSScheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.(*GCSGetter); !ok {
+		t.Errorf("Expected the gs scheme to be handled by *GCSGetter, got %T", p)
+	}
+}
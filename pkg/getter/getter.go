@@ -18,6 +18,8 @@ package getter
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"slices"
@@ -42,11 +44,26 @@ type options struct {
 	username              string
 	password              string
 	passCredentialsAll    bool
+	credentialHelper      string
 	userAgent             string
 	version               string
 	registryClient        *registry.Client
 	timeout               time.Duration
 	transport             *http.Transport
+	proxy                 string
+	region                string
+	endpoint              string
+	retries               int
+	retryBackoff          time.Duration
+	retryMaxBackoff       time.Duration
+	progress              func(downloaded, total int64)
+	rateLimit             float64
+	rateBurst             int
+	cacheDir              string
+	cacheMaxSize          int64
+	cacheTTL              time.Duration
+	validators            *Validators
+	ctx                   context.Context
 }
 
 // Option allows specifying various settings configurable by the user for overriding the defaults
@@ -143,6 +160,139 @@ func WithTransport(transport *http.Transport) Option {
 	}
 }
 
+// WithProxy routes requests through the proxy at proxyURL instead of the one
+// configured in the environment. HTTP, HTTPS and SOCKS5 (socks5:// or
+// socks5h://) proxies are supported.
+func WithProxy(proxyURL string) Option {
+	return func(opts *options) {
+		opts.proxy = proxyURL
+	}
+}
+
+// WithRegion sets the region used by getters for cloud object stores, such as
+// the S3 getter. Getters that have no notion of regions ignore it.
+func WithRegion(region string) Option {
+	return func(opts *options) {
+		opts.region = region
+	}
+}
+
+// WithEndpoint overrides the service endpoint used by getters for cloud object
+// stores, for example to point the S3 getter at an S3-compatible store such as
+// MinIO.
+func WithEndpoint(endpoint string) Option {
+	return func(opts *options) {
+		opts.endpoint = endpoint
+	}
+}
+
+// WithRetries sets how many times a failed request is retried. Only failures
+// that are likely to be transient, such as network errors and 5xx or 429
+// responses, are retried. The default of 0 disables retries.
+func WithRetries(retries int) Option {
+	return func(opts *options) {
+		opts.retries = retries
+	}
+}
+
+// WithRetryBackoff sets the delay before the first retry and the upper bound
+// of the delay between retries. The delay doubles after every attempt and is
+// randomized to spread out retries from concurrent clients. Zero values keep
+// the defaults of DefaultRetryBackoff and DefaultRetryMaxBackoff.
+func WithRetryBackoff(base, maxBackoff time.Duration) Option {
+	return func(opts *options) {
+		opts.retryBackoff = base
+		opts.retryMaxBackoff = maxBackoff
+	}
+}
+
+// WithProgress sets a function called as the content of a response is read,
+// with the number of bytes downloaded so far and the total size of the
+// content. The total is taken from the Content-Length header, and is -1 when
+// the size is unknown. A retried request reports its progress from zero
+// again. Getters that cannot report their progress ignore it.
+func WithProgress(fn func(downloaded, total int64)) Option {
+	return func(opts *options) {
+		opts.progress = fn
+	}
+}
+
+// WithRateLimit limits the rate of requests to rps requests per second, with
+// bursts of up to burst requests. The limit applies to the requests to each
+// host, and is shared by all the getters limiting the rate of their requests,
+// so that concurrent downloads from a repository are limited together.
+// Requests wait for the limit to allow them, or for the context set with
+// WithContext to be done. Getters that cannot limit their rate ignore it.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(opts *options) {
+		opts.rateLimit = rps
+		opts.rateBurst = burst
+	}
+}
+
+// WithCacheDir caches the content got in the directory dir, and serves the
+// requests for the same content from it while it is fresh.
+//
+// HTTP responses are kept by URL and by the username or credential helper of
+// the credentials sent, if any, for the lifetime given by their
+// Cache-Control or Expires headers, and revalidated with their ETag or
+// Last-Modified headers once stale. OCI artifacts are kept by reference and
+// by digest: those pulled by digest never change, and those pulled by tag are
+// served from the cache when the registry is unavailable. Getters that
+// cannot cache their content ignore it.
+func WithCacheDir(dir string) Option {
+	return func(opts *options) {
+		opts.cacheDir = dir
+	}
+}
+
+// WithCachePolicy bounds the cache set with WithCacheDir to maxSize bytes,
+// evicting the least recently used content first, and keeps the content
+// whose freshness is not given by its server fresh for ttl. A zero maxSize
+// keeps DefaultCacheMaxSize, and a zero ttl revalidates such content on
+// every request.
+func WithCachePolicy(maxSize int64, ttl time.Duration) Option {
+	return func(opts *options) {
+		opts.cacheMaxSize = maxSize
+		opts.cacheTTL = ttl
+	}
+}
+
+// WithContext sets the context of requests, cancelling them, and the waits
+// for their rate limit, when it is done.
+func WithContext(ctx context.Context) Option {
+	return func(opts *options) {
+		opts.ctx = ctx
+	}
+}
+
+// ErrNotModified is returned by getters asked for content with
+// WithValidators when it was not modified.
+var ErrNotModified = errors.New("content not modified")
+
+// Validators identify a version of content got over HTTP, from the ETag and
+// Last-Modified headers of its response.
+type Validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// IsZero returns true if there are no validators.
+func (v Validators) IsZero() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+// WithValidators makes a conditional request for content with the
+// validators of the version got before, if any, so that ErrNotModified is
+// returned when it was not modified. The validators are then set to those of
+// the version got, or reset when the server sends none. Getters that cannot
+// make conditional requests ignore it, always getting the content.
+func WithValidators(v *Validators) Option {
+	return func(opts *options) {
+		opts.validators = v
+	}
+}
+
 // Getter is an interface to support GET to the specified URL.
 type Getter interface {
 	// Get file content by url string
@@ -189,6 +339,13 @@ const (
 	DefaultHTTPTimeout = 120
 )
 
+const (
+	// DefaultRetryBackoff is the delay before the first retry of a failed request.
+	DefaultRetryBackoff = 500 * time.Millisecond
+	// DefaultRetryMaxBackoff is the upper bound of the delay between retries.
+	DefaultRetryMaxBackoff = 30 * time.Second
+)
+
 var defaultOptions = []Option{WithTimeout(time.Second * DefaultHTTPTimeout)}
 
 var httpProvider = Provider{
@@ -204,11 +361,33 @@ var ociProvider = Provider{
 	New:     NewOCIGetter,
 }
 
+// optionalProviders holds the getters that are only compiled in when their
+// build tag is set, such as the S3 getter. They register themselves from
+// init so that builds without the tag do not link their SDKs.
+var optionalProviders []Provider
+
 // All finds all of the registered getters as a list of Provider instances.
-// Currently, the built-in getters and the discovered plugins with downloader
-// notations are collected.
+// Currently, the built-in getters, the optional getters enabled at build time
+// and the discovered plugins with downloader notations are collected.
+//
+// The HTTP getters run the credential helper of settings, if any, for the
+// credentials of the servers that have none of their own.
 func All(settings *cli.EnvSettings) Providers {
-	result := Providers{httpProvider, ociProvider}
+	provider := httpProvider
+	var defaults []Option
+	if helper := settings.CredentialHelper; helper != "" {
+		defaults = append(defaults, WithCredentialHelper(helper))
+	}
+	if settings.RepositoryRateLimit > 0 {
+		defaults = append(defaults, WithRateLimit(float64(settings.RepositoryRateLimit), settings.RepositoryRateBurst))
+	}
+	if len(defaults) > 0 {
+		provider.New = func(options ...Option) (Getter, error) {
+			return httpProvider.New(append(slices.Clone(defaults), options...)...)
+		}
+	}
+	result := Providers{provider, ociProvider}
+	result = append(result, optionalProviders...)
 	pluginDownloaders, _ := collectPlugins(settings)
 	result = append(result, pluginDownloaders...)
 	return result
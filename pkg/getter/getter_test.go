@@ -57,8 +57,8 @@ func TestAll(t *testing.T) {
 	env.PluginsDirectory = pluginDir
 
 	all := All(env)
-	if len(all) != 4 {
-		t.Errorf("expected 4 providers (default plus three plugins), got %d", len(all))
+	if len(all) != 4+len(optionalProviders) {
+		t.Errorf("expected %d providers (default, optional plus three plugins), got %d", 4+len(optionalProviders), len(all))
 	}
 
 	if _, err := all.ByScheme("test2"); err != nil {
@@ -17,12 +17,23 @@ package getter
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
 
 	"helm.sh/helm/v4/internal/tlsutil"
 	"helm.sh/helm/v4/internal/version"
@@ -37,6 +48,8 @@ type HTTPGetter struct {
 
 // Get performs a Get from repo.Getter and returns the body.
 func (g *HTTPGetter) Get(href string, options ...Option) (*bytes.Buffer, error) {
+	// Validators only apply to the request they are given for.
+	g.opts.validators = nil
 	for _, opt := range options {
 		opt(&g.opts)
 	}
@@ -46,7 +59,11 @@ func (g *HTTPGetter) Get(href string, options ...Option) (*bytes.Buffer, error)
 func (g *HTTPGetter) get(href string) (*bytes.Buffer, error) {
 	// Set a helm specific user agent so that a repo server and metrics can
 	// separate helm calls from other tools interacting with repos.
-	req, err := http.NewRequest(http.MethodGet, href, nil)
+	ctx := g.opts.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, href, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -71,12 +88,25 @@ func (g *HTTPGetter) get(href string) (*bytes.Buffer, error) {
 		return nil, fmt.Errorf("unable to parse URL getting from: %w", err)
 	}
 
+	// identity identifies the credentials sent, if any, for the cache.
+	var identity string
+
 	// Host on URL (returned from url.Parse) contains the port if present.
 	// This check ensures credentials are not passed between different
 	// services on different ports.
 	if g.opts.passCredentialsAll || (u1.Scheme == u2.Scheme && u1.Host == u2.Host) {
 		if g.opts.username != "" && g.opts.password != "" {
 			req.SetBasicAuth(g.opts.username, g.opts.password)
+			identity = "username=" + g.opts.username
+		} else if g.opts.credentialHelper != "" {
+			identity = "helper=" + g.opts.credentialHelper
+			serverURL := g.opts.url
+			if serverURL == "" {
+				serverURL = href
+			}
+			if err := setHelperCredentials(ctx, req, g.opts.credentialHelper, serverURL); err != nil {
+				return nil, err
+			}
 		}
 	}
 
@@ -85,18 +115,219 @@ func (g *HTTPGetter) get(href string) (*bytes.Buffer, error) {
 		return nil, err
 	}
 
+	validators := g.opts.validators
+	if validators != nil {
+		if validators.ETag != "" {
+			req.Header.Set("If-None-Match", validators.ETag)
+		}
+		if validators.LastModified != "" {
+			req.Header.Set("If-Modified-Since", validators.LastModified)
+		}
+	}
+
+	cache := newContentCache(&g.opts)
+	key := cacheKey(href, identity)
+	var cached *cacheEntry
+	var cachedData []byte
+	if cache != nil {
+		if cached, cachedData = cache.load(key); cached != nil {
+			if time.Now().Before(cached.Expires) {
+				if err := cache.use(cached); err != nil {
+					slog.Debug("unable to update the cache", "url", href, slog.Any("error", err))
+				}
+				return bytes.NewBuffer(cachedData), nil
+			}
+			if cached.ETag != "" {
+				req.Header.Set("If-None-Match", cached.ETag)
+			}
+			if cached.LastModified != "" {
+				req.Header.Set("If-Modified-Since", cached.LastModified)
+			}
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := waitRateLimit(ctx, &g.opts, req.URL.Host); err != nil {
+			return nil, fmt.Errorf("waiting for the rate limit of %s: %w", req.URL.Host, err)
+		}
+		buf, header, err := g.do(client, req)
+		if err == nil && validators != nil {
+			// A response without content means the version got before, or
+			// cached, was not modified, and may leave out its validators.
+			got := Validators{ETag: header.Get("ETag"), LastModified: header.Get("Last-Modified")}
+			if buf != nil || !got.IsZero() {
+				*validators = got
+			}
+			if buf == nil && cached == nil {
+				return nil, ErrNotModified
+			}
+		}
+		if err == nil && cache != nil {
+			return cacheResponse(cache, key, cached, cachedData, buf, header), nil
+		}
+		var re *retryableError
+		if err == nil || !errors.As(err, &re) {
+			return buf, err
+		}
+		if attempt >= g.opts.retries {
+			return nil, re.err
+		}
+		delay := g.retryDelay(attempt, re.retryAfter)
+		slog.Debug("retrying request", "url", href, "attempt", attempt+1, "delay", delay, slog.Any("error", re.err))
+		time.Sleep(delay)
+	}
+}
+
+// retryableError marks a failure that is likely to be transient.
+type retryableError struct {
+	err error
+	// retryAfter is the delay requested by the server, if any.
+	retryAfter time.Duration
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+
+func (e *retryableError) Unwrap() error { return e.err }
+
+// statusError is the error of an unexpected response status.
+type statusError struct {
+	url    string
+	status string
+	code   int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("failed to fetch %s : %s", e.url, e.status)
+}
+
+// IsUnavailable reports whether err is a failure to get content that is
+// likely specific to the server and transient, such as a network error or a
+// 5xx response, so that the content may rather be got from a mirror.
+func IsUnavailable(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code >= http.StatusInternalServerError || se.code == http.StatusTooManyRequests
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// do performs a single attempt of req, returning the content and the header
+// of the response. Failures worth retrying are returned as a
+// *retryableError. The content is nil if a conditional request finds that
+// the cached content was not modified.
+func (g *HTTPGetter) do(client *http.Client, req *http.Request) (*bytes.Buffer, http.Header, error) {
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		var certErr *tls.CertificateVerificationError
+		if errors.As(err, &certErr) {
+			return nil, nil, err
+		}
+		return nil, nil, &retryableError{err: err}
 	}
 	defer resp.Body.Close()
+	conditional := req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != ""
+	if resp.StatusCode == http.StatusNotModified && conditional {
+		return nil, resp.Header, nil
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to fetch %s : %s", href, resp.Status)
+		err := &statusError{url: req.URL.String(), status: resp.Status, code: resp.StatusCode}
+		if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+			return nil, nil, &retryableError{err: err, retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+		}
+		return nil, nil, err
+	}
+
+	var body io.Reader = resp.Body
+	if g.opts.progress != nil {
+		g.opts.progress(0, resp.ContentLength)
+		body = &progressReader{r: resp.Body, total: resp.ContentLength, report: g.opts.progress}
 	}
 
 	buf := bytes.NewBuffer(nil)
-	_, err = io.Copy(buf, resp.Body)
-	return buf, err
+	if _, err := io.Copy(buf, body); err != nil {
+		return nil, nil, &retryableError{err: err}
+	}
+	return buf, resp.Header, nil
+}
+
+// cacheResponse caches the content of a response with header under key, and
+// returns it. A nil buf means the content cached in cached, cachedData, was
+// not modified.
+func cacheResponse(cache *contentCache, key string, cached *cacheEntry, cachedData []byte, buf *bytes.Buffer, header http.Header) *bytes.Buffer {
+	expires, ok := cache.expires(header, time.Now())
+	e := &cacheEntry{Key: key, ETag: header.Get("ETag"), LastModified: header.Get("Last-Modified"), Expires: expires}
+	if buf == nil {
+		buf = bytes.NewBuffer(cachedData)
+		if e.ETag == "" {
+			e.ETag = cached.ETag
+		}
+		if e.LastModified == "" {
+			e.LastModified = cached.LastModified
+		}
+	}
+	if !ok {
+		return buf
+	}
+	if err := cache.store(e, buf.Bytes()); err != nil {
+		slog.Warn("unable to cache the content", "key", key, slog.Any("error", err))
+	}
+	return buf
+}
+
+// progressReader reports the number of bytes read from r.
+type progressReader struct {
+	r          io.Reader
+	downloaded int64
+	// total is the expected size of the content, or -1 if it is unknown.
+	total  int64
+	report func(downloaded, total int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.downloaded += int64(n)
+		p.report(p.downloaded, p.total)
+	}
+	return n, err
+}
+
+// retryDelay returns how long to wait before retrying after the given
+// attempt. The delay grows exponentially with equal jitter, but a longer
+// delay requested by the server is honored. Either way it is capped at the
+// maximum backoff.
+func (g *HTTPGetter) retryDelay(attempt int, retryAfter time.Duration) time.Duration {
+	base, maxBackoff := g.opts.retryBackoff, g.opts.retryMaxBackoff
+	if base <= 0 {
+		base = DefaultRetryBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultRetryMaxBackoff
+	}
+
+	delay := maxBackoff
+	if attempt < 32 && base<<attempt > 0 && base<<attempt < maxBackoff {
+		delay = base << attempt
+	}
+	delay = delay/2 + rand.N(delay/2+1)
+	return min(max(delay, retryAfter), maxBackoff)
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either
+// a number of seconds or an HTTP date. Zero is returned if it is missing or
+// invalid.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
 }
 
 // NewHTTPGetter constructs a valid http/https client as a Getter
@@ -121,11 +352,19 @@ func (g *HTTPGetter) httpClient() (*http.Client, error) {
 	g.once.Do(func() {
 		g.transport = &http.Transport{
 			DisableCompression: true,
-			Proxy:              http.ProxyFromEnvironment,
+			Proxy:              proxyFromEnvironment,
 		}
 	})
 
-	if (g.opts.certFile != "" && g.opts.keyFile != "") || g.opts.caFile != "" || g.opts.insecureSkipVerifyTLS {
+	if g.opts.proxy != "" {
+		proxyURL, err := url.Parse(g.opts.proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL %q: %w", g.opts.proxy, err)
+		}
+		g.transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if g.opts.certFile != "" || g.opts.keyFile != "" || g.opts.caFile != "" || g.opts.insecureSkipVerifyTLS {
 		tlsConf, err := tlsutil.NewTLSConfig(
 			tlsutil.WithInsecureSkipVerify(g.opts.insecureSkipVerifyTLS),
 			tlsutil.WithCertKeyPairFiles(g.opts.certFile, g.opts.keyFile),
@@ -155,3 +394,33 @@ func (g *HTTPGetter) httpClient() (*http.Client, error) {
 
 	return client, nil
 }
+
+// proxyFromEnvironment extends http.ProxyFromEnvironment with support for
+// SOCKS5 proxies configured with ALL_PROXY (or all_proxy), which is used
+// when HTTP_PROXY or HTTPS_PROXY do not apply. NO_PROXY is honored.
+func proxyFromEnvironment(req *http.Request) (*url.URL, error) {
+	proxyURL, err := http.ProxyFromEnvironment(req)
+	if err != nil || proxyURL != nil {
+		return proxyURL, err
+	}
+
+	allProxy := getEnvAny("ALL_PROXY", "all_proxy")
+	if !strings.HasPrefix(allProxy, "socks5://") && !strings.HasPrefix(allProxy, "socks5h://") {
+		return nil, nil
+	}
+	cfg := httpproxy.Config{
+		HTTPProxy:  allProxy,
+		HTTPSProxy: allProxy,
+		NoProxy:    getEnvAny("NO_PROXY", "no_proxy"),
+	}
+	return cfg.ProxyFunc()(req.URL)
+}
+
+func getEnvAny(names ...string) string {
+	for _, n := range names {
+		if val := os.Getenv(n); val != "" {
+			return val
+		}
+	}
+	return ""
+}
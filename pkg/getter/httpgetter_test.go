@@ -16,8 +16,11 @@ limitations under the License.
 package getter
 
 import (
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -25,6 +28,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -678,3 +682,482 @@ func TestHTTPTransportOption(t *testing.T) {
 		t.Fatal("transport.TLSClientConfig should not be set")
 	}
 }
+
+func TestDownloadRetries(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/flaky":
+			if requests < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			fmt.Fprint(w, "ok")
+		case "/throttled":
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	backoff := WithRetryBackoff(time.Millisecond, 5*time.Millisecond)
+
+	g, err := NewHTTPGetter(WithRetries(3), backoff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := g.Get(srv.URL + "/flaky")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != "ok" || requests != 3 {
+		t.Errorf("Expected success after 3 requests, got %q after %d", got.String(), requests)
+	}
+
+	requests = 0
+	_, err = g.Get(srv.URL + "/throttled")
+	if err == nil || !strings.Contains(err.Error(), "429") {
+		t.Errorf("Expected 429 error, got %v", err)
+	}
+	if requests != 4 {
+		t.Errorf("Expected 4 requests for 3 retries, got %d", requests)
+	}
+
+	requests = 0
+	if _, err := g.Get(srv.URL + "/missing"); err == nil {
+		t.Error("Expected error for missing file")
+	}
+	if requests != 1 {
+		t.Errorf("Expected 4xx responses not to be retried, got %d requests", requests)
+	}
+
+	requests = 0
+	g, err = NewHTTPGetter(backoff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Get(srv.URL + "/flaky"); err == nil {
+		t.Error("Expected error without retries")
+	}
+	if requests != 1 {
+		t.Errorf("Expected no retries by default, got %d requests", requests)
+	}
+}
+
+func TestDownloadRetriesNetworkError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	addr := srv.URL
+	srv.Close()
+
+	g, err := NewHTTPGetter(WithRetries(2), WithRetryBackoff(time.Millisecond, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = g.Get(addr)
+	if err == nil {
+		t.Fatal("Expected error for closed server")
+	}
+	var re *retryableError
+	if errors.As(err, &re) {
+		t.Errorf("Expected the underlying error to be returned, got %T", err)
+	}
+}
+
+func TestDownloadProgress(t *testing.T) {
+	content := strings.Repeat("x", 64*1024)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/chunked" {
+			// Flushing before writing the content sends it without a
+			// Content-Length header.
+			w.(http.Flusher).Flush()
+		} else {
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		}
+		fmt.Fprint(w, content)
+	}))
+	defer srv.Close()
+
+	for path, wantTotal := range map[string]int64{"/sized": int64(len(content)), "/chunked": -1} {
+		var calls int
+		var downloaded, total int64
+		g, err := NewHTTPGetter(WithProgress(func(d, t int64) {
+			calls++
+			downloaded, total = d, t
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := g.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Len() != len(content) {
+			t.Errorf("%s: expected %d bytes, got %d", path, len(content), got.Len())
+		}
+		if calls < 2 {
+			t.Errorf("%s: expected the progress to be reported as the content is read, got %d calls", path, calls)
+		}
+		if downloaded != int64(len(content)) || total != wantTotal {
+			t.Errorf("%s: expected a final progress of %d/%d, got %d/%d", path, len(content), wantTotal, downloaded, total)
+		}
+	}
+}
+
+func TestDownloadValidators(t *testing.T) {
+	lastModified := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC).Format(http.TimeFormat)
+	var conditional int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/plain" {
+			fmt.Fprint(w, "plain")
+			return
+		}
+		if r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != "" {
+			conditional++
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` && r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", lastModified)
+		fmt.Fprint(w, "content")
+	}))
+	defer srv.Close()
+
+	g, err := NewHTTPGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v Validators
+	got, err := g.Get(srv.URL, WithValidators(&v))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != "content" || conditional != 0 {
+		t.Errorf("expected an unconditional download of the content, got %q", got)
+	}
+	if v != (Validators{ETag: `"v1"`, LastModified: lastModified}) {
+		t.Errorf("unexpected validators %+v", v)
+	}
+
+	if _, err := g.Get(srv.URL, WithValidators(&v)); !errors.Is(err, ErrNotModified) {
+		t.Errorf("expected the content not to be modified, got %v", err)
+	}
+	if v.ETag != `"v1"` || conditional != 1 {
+		t.Errorf("expected a conditional request keeping the validators, got %+v", v)
+	}
+
+	// The validators are not sent with the next requests.
+	if _, err := g.Get(srv.URL); err != nil || conditional != 1 {
+		t.Errorf("expected an unconditional request, got %v", err)
+	}
+
+	// The validators are reset when the server sends none.
+	if _, err := g.Get(srv.URL+"/plain", WithValidators(&v)); err != nil {
+		t.Fatal(err)
+	}
+	if !v.IsZero() {
+		t.Errorf("expected the validators to be reset, got %+v", v)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	g := HTTPGetter{}
+	g.opts.retryBackoff = 100 * time.Millisecond
+	g.opts.retryMaxBackoff = time.Second
+
+	for attempt, limit := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		limit *= time.Millisecond
+		d := g.retryDelay(attempt, 0)
+		if d < limit/2 || d > limit {
+			t.Errorf("attempt %d: expected delay between %s and %s, got %s", attempt, limit/2, limit, d)
+		}
+	}
+	if d := g.retryDelay(100, 0); d < 500*time.Millisecond || d > time.Second {
+		t.Errorf("Expected large attempts to be capped, got %s", d)
+	}
+	if d := g.retryDelay(0, 700*time.Millisecond); d != 700*time.Millisecond {
+		t.Errorf("Expected Retry-After to be honored, got %s", d)
+	}
+	if d := g.retryDelay(0, time.Hour); d != time.Second {
+		t.Errorf("Expected Retry-After to be capped, got %s", d)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if d := parseRetryAfter("5"); d != 5*time.Second {
+		t.Errorf("Expected 5s, got %s", d)
+	}
+	if d := parseRetryAfter(""); d != 0 {
+		t.Errorf("Expected 0 for empty header, got %s", d)
+	}
+	if d := parseRetryAfter("soon"); d != 0 {
+		t.Errorf("Expected 0 for invalid header, got %s", d)
+	}
+	if d := parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)); d <= 50*time.Second || d > time.Minute {
+		t.Errorf("Expected about a minute for HTTP date, got %s", d)
+	}
+	if d := parseRetryAfter(time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)); d != 0 {
+		t.Errorf("Expected 0 for a date in the past, got %s", d)
+	}
+}
+
+// startSOCKS5Proxy starts a minimal SOCKS5 proxy that supports the CONNECT
+// command without authentication. It returns the proxy address and a
+// counter of proxied connections.
+func startSOCKS5Proxy(t *testing.T) (string, *atomic.Int32) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	var connections atomic.Int32
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				target, err := socks5Handshake(conn)
+				if err != nil {
+					return
+				}
+				upstream, err := net.Dial("tcp", target)
+				if err != nil {
+					conn.Write([]byte{5, 1, 0, 1, 0, 0, 0, 0, 0, 0})
+					return
+				}
+				defer upstream.Close()
+				connections.Add(1)
+				conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+				go io.Copy(upstream, conn)
+				io.Copy(conn, upstream)
+			}()
+		}
+	}()
+	return l.Addr().String(), &connections
+}
+
+func socks5Handshake(conn net.Conn) (string, error) {
+	buf := make([]byte, 256)
+	// Greeting: version, number of methods, methods.
+	if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+		return "", err
+	}
+	if _, err := io.ReadFull(conn, buf[:buf[1]]); err != nil {
+		return "", err
+	}
+	if _, err := conn.Write([]byte{5, 0}); err != nil {
+		return "", err
+	}
+	// Request: version, command, reserved, address type.
+	if _, err := io.ReadFull(conn, buf[:4]); err != nil {
+		return "", err
+	}
+	if buf[1] != 1 {
+		return "", fmt.Errorf("unsupported command %d", buf[1])
+	}
+	var host string
+	switch buf[3] {
+	case 1:
+		if _, err := io.ReadFull(conn, buf[:4]); err != nil {
+			return "", err
+		}
+		host = net.IP(buf[:4]).String()
+	case 3:
+		if _, err := io.ReadFull(conn, buf[:1]); err != nil {
+			return "", err
+		}
+		n := int(buf[0])
+		if _, err := io.ReadFull(conn, buf[:n]); err != nil {
+			return "", err
+		}
+		host = string(buf[:n])
+	default:
+		return "", fmt.Errorf("unsupported address type %d", buf[3])
+	}
+	if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+		return "", err
+	}
+	port := int(buf[0])<<8 | int(buf[1])
+	return net.JoinHostPort(host, strconv.Itoa(port)), nil
+}
+
+func TestDownloadSOCKS5Proxy(t *testing.T) {
+	proxyAddr, connections := startSOCKS5Proxy(t)
+
+	expect := "Call me Ishmael"
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, expect)
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	tlsSrv := httptest.NewTLSServer(handler)
+	defer tlsSrv.Close()
+
+	for _, scheme := range []string{"socks5", "socks5h"} {
+		for _, u := range []string{srv.URL, tlsSrv.URL} {
+			before := connections.Load()
+			g, err := NewHTTPGetter(
+				WithProxy(scheme+"://"+proxyAddr),
+				WithInsecureSkipVerifyTLS(true),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := g.Get(u)
+			if err != nil {
+				t.Fatalf("%s via %s: %v", u, scheme, err)
+			}
+			if got.String() != expect {
+				t.Errorf("Expected %q, got %q", expect, got.String())
+			}
+			if connections.Load() == before {
+				t.Errorf("Expected %s to be fetched through the proxy", u)
+			}
+		}
+	}
+
+	g, err := NewHTTPGetter(WithProxy("socks5://%zz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Get(srv.URL); err == nil || !strings.Contains(err.Error(), "invalid proxy URL") {
+		t.Errorf("Expected invalid proxy URL error, got %v", err)
+	}
+}
+
+func TestProxyFromEnvironment(t *testing.T) {
+	for _, name := range []string{"HTTP_PROXY", "http_proxy", "HTTPS_PROXY", "https_proxy", "NO_PROXY", "no_proxy", "ALL_PROXY", "all_proxy"} {
+		t.Setenv(name, "")
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://charts.example.com/index.yaml", nil)
+
+	if u, err := proxyFromEnvironment(req); err != nil || u != nil {
+		t.Errorf("Expected no proxy, got %v, %v", u, err)
+	}
+
+	t.Setenv("all_proxy", "socks5://proxy.example.com:1080")
+	u, err := proxyFromEnvironment(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u == nil || u.String() != "socks5://proxy.example.com:1080" {
+		t.Errorf("Expected SOCKS5 proxy from all_proxy, got %v", u)
+	}
+
+	t.Setenv("NO_PROXY", "example.com")
+	if u, err := proxyFromEnvironment(req); err != nil || u != nil {
+		t.Errorf("Expected NO_PROXY to be honored, got %v, %v", u, err)
+	}
+
+	t.Setenv("NO_PROXY", "")
+	t.Setenv("ALL_PROXY", "http://proxy.example.com:3128")
+	if u, err := proxyFromEnvironment(req); err != nil || u != nil {
+		t.Errorf("Expected non-SOCKS ALL_PROXY to be ignored, got %v, %v", u, err)
+	}
+}
+
+func TestDownloadMutualTLS(t *testing.T) {
+	cd := "../../testdata"
+	ca, pub, priv := filepath.Join(cd, "rootca.crt"), filepath.Join(cd, "crt.pem"), filepath.Join(cd, "key.pem")
+
+	tlsSrv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			t.Error("Expected a client certificate")
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	tlsConf, err := tlsutil.NewTLSConfig(
+		tlsutil.WithCertKeyPairFiles(pub, priv),
+		tlsutil.WithCAFile(ca),
+	)
+	if err != nil {
+		t.Fatal(fmt.Errorf("can't create TLS config for server: %w", err))
+	}
+	tlsConf.ClientCAs = tlsConf.RootCAs
+	tlsConf.ClientAuth = tls.RequireAndVerifyClientCert
+	tlsSrv.TLS = tlsConf
+	tlsSrv.StartTLS()
+	defer tlsSrv.Close()
+
+	g, err := NewHTTPGetter(WithURL(tlsSrv.URL), WithTLSClientConfig(pub, priv, ca))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := g.Get(tlsSrv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != "ok" {
+		t.Errorf("Expected %q, got %q", "ok", got.String())
+	}
+
+	// Without a client certificate the server refuses the handshake.
+	g, err = NewHTTPGetter(WithURL(tlsSrv.URL), WithTLSClientConfig("", "", ca))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Get(tlsSrv.URL); err == nil {
+		t.Error("Expected the server to require a client certificate")
+	}
+
+	for _, tt := range []struct {
+		name                  string
+		certFile, keyFile, ca string
+		expect                string
+	}{
+		{"cert without key", pub, "", ca, "both a client certificate file and a key file are required"},
+		{"mismatched pair", pub, ca, ca, "unable to load client certificate"},
+		{"invalid CA", pub, priv, priv, "no valid PEM certificates found in CA file"},
+	} {
+		g, err := NewHTTPGetter(WithURL(tlsSrv.URL), WithTLSClientConfig(tt.certFile, tt.keyFile, tt.ca))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = g.Get(tlsSrv.URL)
+		if err == nil || !strings.Contains(err.Error(), tt.expect) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.expect, err)
+		}
+	}
+}
+
+func TestIsUnavailable(t *testing.T) {
+	status := http.StatusBadGateway
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	g, err := NewHTTPGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = g.Get(srv.URL)
+	if !IsUnavailable(err) {
+		t.Errorf("expected a 502 response to be unavailable, got %v", err)
+	}
+	if err == nil || err.Error() != fmt.Sprintf("failed to fetch %s : 502 Bad Gateway", srv.URL) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	status = http.StatusForbidden
+	if _, err := g.Get(srv.URL); err == nil || IsUnavailable(err) {
+		t.Errorf("expected a 403 response not to be unavailable, got %v", err)
+	}
+
+	srv.Close()
+	if _, err := g.Get(srv.URL); !IsUnavailable(err) {
+		t.Errorf("expected a network error to be unavailable, got %v", err)
+	}
+	if IsUnavailable(errors.New("invalid index")) {
+		t.Error("expected other errors not to be unavailable")
+	}
+}
@@ -18,6 +18,7 @@ package getter
 import (
 	"bytes"
 	"fmt"
+	"log/slog"
 	"net"
 	"net/http"
 	"path"
@@ -62,6 +63,19 @@ func (g *OCIGetter) get(href string) (*bytes.Buffer, error) {
 	if version := g.opts.version; version != "" && !strings.Contains(path.Base(ref), ":") {
 		ref = fmt.Sprintf("%s:%s", ref, version)
 	}
+	cache := newContentCache(&g.opts)
+	key := fmt.Sprintf("%s://%s", registry.OCIScheme, ref)
+	// Artifacts pulled by digest never change.
+	byDigest := strings.Contains(path.Base(ref), "@")
+	if cache != nil {
+		if e, data := cache.load(key); e != nil && (byDigest || time.Now().Before(e.Expires)) {
+			if err := cache.use(e); err != nil {
+				slog.Debug("unable to update the cache", "ref", ref, slog.Any("error", err))
+			}
+			return bytes.NewBuffer(data), nil
+		}
+	}
+
 	var pullOpts []registry.PullOption
 	requestingProv := strings.HasSuffix(ref, ".prov")
 	if requestingProv {
@@ -73,13 +87,45 @@ func (g *OCIGetter) get(href string) (*bytes.Buffer, error) {
 
 	result, err := client.Pull(ref, pullOpts...)
 	if err != nil {
+		if cache != nil && isRegistryUnavailable(err) {
+			if e, data := cache.load(key); e != nil {
+				slog.Warn("the registry is unavailable, using the cached content", "ref", ref, slog.Any("error", err))
+				return bytes.NewBuffer(data), nil
+			}
+		}
 		return nil, err
 	}
 
+	data := result.Chart.Data
 	if requestingProv {
-		return bytes.NewBuffer(result.Prov.Data), nil
+		data = result.Prov.Data
+	}
+	if cache != nil {
+		keys := []string{key}
+		if !byDigest && result.Manifest != nil && result.Manifest.Digest != "" {
+			keys = append(keys, ociDigestKey(ref, result.Manifest.Digest, requestingProv))
+		}
+		for _, k := range keys {
+			if err := cache.store(&cacheEntry{Key: k, Expires: time.Now().Add(cache.ttl)}, data); err != nil {
+				slog.Warn("unable to cache the content", "ref", ref, slog.Any("error", err))
+			}
+		}
+	}
+	return bytes.NewBuffer(data), nil
+}
+
+// ociDigestKey returns the cache key of the artifact with the given manifest
+// digest in the repository of the tagged reference ref.
+func ociDigestKey(ref, digest string, prov bool) string {
+	repo := ref
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		repo = ref[:i]
+	}
+	key := fmt.Sprintf("%s://%s@%s", registry.OCIScheme, repo, digest)
+	if prov {
+		key += ".prov"
 	}
-	return bytes.NewBuffer(result.Chart.Data), nil
+	return key
 }
 
 // NewOCIGetter constructs a valid http/https client as a Getter
@@ -127,7 +173,7 @@ func (g *OCIGetter) newRegistryClient() (*registry.Client, error) {
 		}
 	})
 
-	if (g.opts.certFile != "" && g.opts.keyFile != "") || g.opts.caFile != "" || g.opts.insecureSkipVerifyTLS {
+	if g.opts.certFile != "" || g.opts.keyFile != "" || g.opts.caFile != "" || g.opts.insecureSkipVerifyTLS {
 		tlsConf, err := tlsutil.NewTLSConfig(
 			tlsutil.WithInsecureSkipVerify(g.opts.insecureSkipVerifyTLS),
 			tlsutil.WithCertKeyPairFiles(g.opts.certFile, g.opts.keyFile),
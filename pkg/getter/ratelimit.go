@@ -0,0 +1,65 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package getter
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// hostLimiters are the rate limiters of requests by host, shared by all the
+// getters limiting their rate.
+var hostLimiters = struct {
+	sync.Mutex
+	limiters map[string]*rate.Limiter
+}{limiters: map[string]*rate.Limiter{}}
+
+// hostLimiter returns the limiter of requests to host, allowing rps requests
+// per second with bursts of burst requests. The limit of an existing limiter
+// is updated to the given one.
+func hostLimiter(host string, rps float64, burst int) *rate.Limiter {
+	hostLimiters.Lock()
+	defer hostLimiters.Unlock()
+	limit := rate.Limit(rps)
+	if burst < 1 {
+		burst = 1
+	}
+	l, ok := hostLimiters.limiters[host]
+	if !ok {
+		l = rate.NewLimiter(limit, burst)
+		hostLimiters.limiters[host] = l
+		return l
+	}
+	if l.Limit() != limit {
+		l.SetLimit(limit)
+	}
+	if l.Burst() != burst {
+		l.SetBurst(burst)
+	}
+	return l
+}
+
+// waitRateLimit blocks until a request to host is allowed by the rate limit
+// of opts, if any, or ctx is done.
+func waitRateLimit(ctx context.Context, opts *options, host string) error {
+	if opts.rateLimit <= 0 {
+		return nil
+	}
+	return hostLimiter(host, opts.rateLimit, opts.rateBurst).Wait(ctx)
+}
@@ -0,0 +1,141 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package getter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"helm.sh/helm/v4/pkg/cli"
+)
+
+func TestHTTPGetterRateLimit(t *testing.T) {
+	var mu sync.Mutex
+	var requests []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		requests = append(requests, time.Now())
+		mu.Unlock()
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	// Concurrent getters share the limit of the host.
+	const getters = 4
+	start := time.Now()
+	var wg sync.WaitGroup
+	errs := make(chan error, getters)
+	for range getters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g, err := NewHTTPGetter(WithRateLimit(20, 1))
+			if err != nil {
+				errs <- err
+				return
+			}
+			_, err = g.Get(srv.URL)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(requests) != getters {
+		t.Fatalf("expected %d requests, got %d", getters, len(requests))
+	}
+	// The first request is allowed right away, the others every 50ms.
+	if elapsed := time.Since(start); elapsed < 140*time.Millisecond {
+		t.Errorf("expected the requests to be limited to 20 per second, they took %s", elapsed)
+	}
+}
+
+func TestHTTPGetterRateLimitContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	g, err := NewHTTPGetter(WithRateLimit(0.01, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Get(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	// The next request waits 100s for its token, unless its context is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = g.Get(srv.URL, WithContext(ctx))
+	if err == nil {
+		t.Fatal("expected an error waiting for the rate limit")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the wait to end with its context, it took %s", elapsed)
+	}
+
+	// Requests are cancelled with their context.
+	<-ctx.Done()
+	unlimited, err := NewHTTPGetter(WithContext(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := unlimited.Get(srv.URL); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the request to be cancelled with its context, got %v", err)
+	}
+	// Getters without a rate limit are not limited by others.
+	unlimited, _ = NewHTTPGetter()
+	if _, err := unlimited.Get(srv.URL); err != nil {
+		t.Errorf("expected a getter without a rate limit not to wait: %s", err)
+	}
+}
+
+func TestAllRateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	env := cli.New()
+	env.RepositoryRateLimit = 10
+	env.RepositoryRateBurst = 1
+	start := time.Now()
+	for range 3 {
+		g, err := All(env).ByScheme("http")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := g.Get(srv.URL); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The first request is allowed right away, the others every 100ms.
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Errorf("expected the requests to be limited to 10 per second, they took %s", elapsed)
+	}
+}
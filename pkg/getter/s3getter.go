@@ -0,0 +1,169 @@
+//go:build s3
+
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package getter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// S3Scheme is the URL scheme handled by the S3 getter.
+const S3Scheme = "s3"
+
+// s3DefaultRegion is used when neither the options, the bucket nor the AWS
+// configuration provide a region.
+const s3DefaultRegion = "us-east-1"
+
+var s3Provider = Provider{
+	Schemes: []string{S3Scheme},
+	New: func(options ...Option) (Getter, error) {
+		options = append(options, defaultOptions...)
+		return NewS3Getter(options...)
+	},
+}
+
+func init() {
+	optionalProviders = append(optionalProviders, s3Provider)
+}
+
+// S3Getter is the backend handler for s3://bucket/key URLs.
+//
+// Credentials are resolved with the AWS SDK default credential chain:
+// environment variables, the shared configuration and credentials files, and
+// IAM roles. The region is taken from WithRegion if set, then from the
+// bucket itself, then from the AWS configuration. WithEndpoint selects an
+// S3-compatible store, which is then addressed with path-style requests.
+//
+// The S3 getter is only available when Helm is built with the "s3" build tag.
+type S3Getter struct {
+	opts options
+}
+
+// NewS3Getter constructs a Getter for s3:// URLs.
+func NewS3Getter(options ...Option) (Getter, error) {
+	var g S3Getter
+	for _, opt := range options {
+		opt(&g.opts)
+	}
+	return &g, nil
+}
+
+// Get fetches the object referenced by href.
+func (g *S3Getter) Get(href string, options ...Option) (*bytes.Buffer, error) {
+	for _, opt := range options {
+		opt(&g.opts)
+	}
+
+	u, err := url.Parse(href)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse URL getting from: %w", err)
+	}
+	if u.Scheme != S3Scheme || u.Host == "" {
+		return nil, fmt.Errorf("invalid S3 URL %q: expected s3://bucket/key", href)
+	}
+	bucket, key := u.Host, strings.TrimPrefix(u.Path, "/")
+
+	ctx := context.Background()
+	if g.opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.opts.timeout)
+		defer cancel()
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load AWS configuration: %w", err)
+	}
+
+	region := g.opts.region
+	if region == "" {
+		region = g.bucketRegion(ctx, cfg, bucket)
+	}
+	if region == "" {
+		region = cfg.Region
+	}
+	if region == "" {
+		region = s3DefaultRegion
+	}
+
+	out, err := g.client(cfg, region).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s : %w", href, err)
+	}
+	defer out.Body.Close()
+
+	buf := bytes.NewBuffer(nil)
+	_, err = io.Copy(buf, out.Body)
+	return buf, err
+}
+
+func (g *S3Getter) client(cfg aws.Config, region string) *s3.Client {
+	return s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.Region = region
+		// Objects uploaded without checksums are common; don't log about them.
+		o.DisableLogOutputChecksumValidationSkipped = true
+		if g.opts.endpoint != "" {
+			o.BaseEndpoint = aws.String(g.opts.endpoint)
+		}
+		if o.BaseEndpoint != nil {
+			// S3-compatible stores rarely support virtual-hosted buckets.
+			o.UsePathStyle = true
+		}
+	})
+}
+
+// bucketRegion asks S3 which region bucket lives in. S3 reports the region
+// in a response header even when the request is refused, so errors are only
+// fatal to the lookup if the header is missing. An empty string is returned
+// if the region cannot be determined.
+func (g *S3Getter) bucketRegion(ctx context.Context, cfg aws.Config, bucket string) string {
+	const header = "X-Amz-Bucket-Region"
+
+	out, err := g.client(cfg, s3DefaultRegion).HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		var re *smithyhttp.ResponseError
+		if errors.As(err, &re) && re.Response != nil {
+			return re.Response.Header.Get(header)
+		}
+		return ""
+	}
+	if out.BucketRegion != nil {
+		return *out.BucketRegion
+	}
+	if raw, ok := awsmiddleware.GetRawResponse(out.ResultMetadata).(*smithyhttp.Response); ok {
+		return raw.Header.Get(header)
+	}
+	return ""
+}
@@ -0,0 +1,109 @@
+//go:build s3
+
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package getter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v4/pkg/cli"
+)
+
+// setupS3Env isolates the AWS SDK from the environment of the test runner
+// and provides static credentials.
+func setupS3Env(t *testing.T) {
+	t.Helper()
+	empty := filepath.Join(t.TempDir(), "empty")
+	if err := os.WriteFile(empty, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_CONFIG_FILE", empty)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", empty)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("AWS_ENDPOINT_URL", "")
+	t.Setenv("AWS_ENDPOINT_URL_S3", "")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+}
+
+func TestS3Getter(t *testing.T) {
+	setupS3Env(t)
+
+	var authorization string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodHead && r.URL.Path == "/charts":
+			w.Header().Set("X-Amz-Bucket-Region", "eu-west-1")
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodGet && r.URL.Path == "/charts/stable/index.yaml":
+			authorization = r.Header.Get("Authorization")
+			w.Write([]byte("apiVersion: v1\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	g, err := NewS3Getter(WithEndpoint(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err := g.Get("s3://charts/stable/index.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "apiVersion: v1\n" {
+		t.Errorf("Unexpected body %q", buf.String())
+	}
+	if !strings.Contains(authorization, "AKIDEXAMPLE/") || !strings.Contains(authorization, "/eu-west-1/s3/aws4_request") {
+		t.Errorf("Expected request to be signed for the bucket region, got %q", authorization)
+	}
+
+	// An explicit region takes precedence over the bucket lookup.
+	buf, err = g.Get("s3://charts/stable/index.yaml", WithRegion("ap-south-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(authorization, "/ap-south-1/s3/aws4_request") {
+		t.Errorf("Expected request to be signed for the given region, got %q", authorization)
+	}
+
+	if _, err := g.Get("s3://charts/stable/missing.tgz"); err == nil {
+		t.Error("Expected error for missing object")
+	}
+	if _, err := g.Get("s3:///no-bucket.tgz"); err == nil {
+		t.Error("Expected error for URL without a bucket")
+	}
+}
+
+func TestS3Provider(t *testing.T) {
+	p, err := All(cli.New()).ByScheme(S3Scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.(*S3Getter); !ok {
+		t.Errorf("Expected the s3 scheme to be handled by *S3Getter, got %T", p)
+	}
+}
@@ -34,6 +34,15 @@ func CacheIndexFile(name string) string {
 	return name + "index.yaml"
 }
 
+// CacheIndexValidatorsFile returns the path to the validators of the version
+// of the index cached for the given named repository.
+func CacheIndexValidatorsFile(name string) string {
+	if name != "" {
+		name += "-"
+	}
+	return name + "index-validators.json"
+}
+
 // CacheChartsFile returns the path to a text file listing all the charts
 // within the given named repository.
 func CacheChartsFile(name string) string {
@@ -29,6 +29,8 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	jsonpatch "github.com/evanphx/json-patch/v5"
 	v1 "k8s.io/api/core/v1"
@@ -47,6 +49,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
 	"k8s.io/apimachinery/pkg/util/mergepatch"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/client-go/kubernetes"
@@ -78,6 +81,22 @@ type Client struct {
 	Factory Factory
 	// Namespace allows to bypass the kubeconfig file for the choice of the namespace
 	Namespace string
+	// FieldManager is the name of the field manager recorded in managedFields
+	// for the changes made by this client. If it is empty, ManagedFieldsManager
+	// or the name of the running binary is used.
+	FieldManager string
+	// Parallelism limits the resources created or updated at once by Create
+	// and Update. The resources are applied in tiers of consecutive
+	// resources of the same kind, in the order of the list, and every
+	// resource of a tier is done before the next tier starts. If zero,
+	// Create applies all the resources of a tier at once and Update applies
+	// one resource at a time.
+	Parallelism int
+	// ForceRecreate makes Update delete and create again the resources whose
+	// update is rejected because it changes an immutable field, such as the
+	// pod template of a Job. Each recreation is logged, since the resource
+	// is unavailable in between. It is off by default.
+	ForceRecreate bool
 
 	Waiter
 	kubeClient kubernetes.Interface
@@ -197,7 +216,10 @@ func (c *Client) IsReachable() error {
 // Create creates Kubernetes resources specified in the resource list.
 func (c *Client) Create(resources ResourceList) (*Result, error) {
 	slog.Debug("creating resource(s)", "resources", len(resources))
-	if err := perform(resources, createResource); err != nil {
+	fieldManager := c.fieldManager()
+	if err := performLimited(resources, c.Parallelism, func(info *resource.Info) error {
+		return createResource(info, fieldManager)
+	}); err != nil {
 		return nil, err
 	}
 	return &Result{Created: resources}, nil
@@ -398,53 +420,40 @@ func (c *Client) BuildTable(reader io.Reader, validate bool) (ResourceList, erro
 }
 
 func (c *Client) update(original, target ResourceList, force, threeWayMerge bool) (*Result, error) {
-	updateErrors := []error{}
 	res := &Result{}
 
 	slog.Debug("checking resources for changes", "resources", len(target))
-	err := target.Visit(func(info *resource.Info, err error) error {
-		if err != nil {
-			return err
-		}
-
-		helper := resource.NewHelper(info.Client, info.Mapping).WithFieldManager(getManagedFieldsManager())
-		if _, err := helper.Get(info.Namespace, info.Name); err != nil {
-			if !apierrors.IsNotFound(err) {
-				return fmt.Errorf("could not get information about the resource: %w", err)
-			}
-
-			// Append the created resource to the results, even if something fails
-			res.Created = append(res.Created, info)
-
-			// Since the resource does not exist, create it.
-			if err := createResource(info); err != nil {
-				return fmt.Errorf("failed to create resource: %w", err)
-			}
-
-			kind := info.Mapping.GroupVersionKind.Kind
-			slog.Debug("created a new resource", "namespace", info.Namespace, "name", info.Name, "kind", kind)
-			return nil
+	outcomes := make([]applyOutcome, len(target))
+	var failed atomic.Bool
+	forEachInTiers(target, c.updateParallelism(), func(i int) {
+		outcomes[i] = c.apply(original, target[i], force, threeWayMerge)
+		if outcomes[i].fatal {
+			failed.Store(true)
 		}
-
-		originalInfo := original.Get(info)
-		if originalInfo == nil {
-			kind := info.Mapping.GroupVersionKind.Kind
-			return fmt.Errorf("no %s with the name %q found", kind, info.Name)
+	}, failed.Load)
+
+	// The results follow the order of target whatever the order the
+	// resources were applied in.
+	var fatalErrors, updateErrors []error
+	for i, o := range outcomes {
+		switch {
+		case o.created:
+			res.Created = append(res.Created, target[i])
+		case o.updated:
+			res.Updated = append(res.Updated, target[i])
 		}
-
-		if err := updateResource(c, info, originalInfo.Object, force, threeWayMerge); err != nil {
-			slog.Debug("error updating the resource", "namespace", info.Namespace, "name", info.Name, "kind", info.Mapping.GroupVersionKind.Kind, slog.Any("error", err))
-			updateErrors = append(updateErrors, err)
+		switch {
+		case o.err == nil:
+		case o.fatal:
+			fatalErrors = append(fatalErrors, o.err)
+		default:
+			updateErrors = append(updateErrors, o.err)
 		}
-		// Because we check for errors later, append the info regardless
-		res.Updated = append(res.Updated, info)
-
-		return nil
-	})
+	}
 
 	switch {
-	case err != nil:
-		return res, err
+	case len(fatalErrors) != 0:
+		return res, errors.Join(fatalErrors...)
 	case len(updateErrors) != 0:
 		return res, joinErrors(updateErrors, " && ")
 	}
@@ -473,6 +482,56 @@ func (c *Client) update(original, target ResourceList, force, threeWayMerge bool
 	return res, nil
 }
 
+// applyOutcome is the outcome of applying a resource during an update.
+type applyOutcome struct {
+	// created and updated tell whether the resource was created or updated,
+	// even if this failed.
+	created, updated bool
+	err              error
+	// fatal errors stop the update.
+	fatal bool
+}
+
+// apply creates the resource info if it does not exist and updates it from
+// its original state otherwise.
+func (c *Client) apply(original ResourceList, info *resource.Info, force, threeWayMerge bool) applyOutcome {
+	helper := resource.NewHelper(info.Client, info.Mapping).WithFieldManager(getManagedFieldsManager())
+	if _, err := helper.Get(info.Namespace, info.Name); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return applyOutcome{err: fmt.Errorf("could not get information about the resource: %w", err), fatal: true}
+		}
+
+		// Since the resource does not exist, create it. It is part of the
+		// results, even if something fails.
+		if err := createResource(info, c.fieldManager()); err != nil {
+			return applyOutcome{created: true, err: fmt.Errorf("failed to create resource: %w", err), fatal: true}
+		}
+
+		kind := info.Mapping.GroupVersionKind.Kind
+		slog.Debug("created a new resource", "namespace", info.Namespace, "name", info.Name, "kind", kind)
+		return applyOutcome{created: true}
+	}
+
+	originalInfo := original.Get(info)
+	if originalInfo == nil {
+		kind := info.Mapping.GroupVersionKind.Kind
+		return applyOutcome{err: fmt.Errorf("no %s with the name %q found", kind, info.Name), fatal: true}
+	}
+
+	// Because errors are checked later, the resource is part of the results
+	// regardless.
+	if err := updateResource(c, info, originalInfo.Object, force, threeWayMerge); err != nil {
+		slog.Debug("error updating the resource", "namespace", info.Namespace, "name", info.Name, "kind", info.Mapping.GroupVersionKind.Kind, slog.Any("error", err))
+		if !c.ForceRecreate || !isImmutableFieldError(err) {
+			return applyOutcome{updated: true, err: err}
+		}
+		if err := c.recreate(info); err != nil {
+			return applyOutcome{updated: true, err: err}
+		}
+	}
+	return applyOutcome{updated: true}
+}
+
 // Update takes the current list of objects and target list of objects and
 // creates resources that don't already exist, updates resources that have been
 // modified in the target configuration, and deletes resources from the current
@@ -499,11 +558,11 @@ func (c *Client) Update(original, target ResourceList, force bool) (*Result, err
 }
 
 // Delete deletes Kubernetes resources specified in the resources list with
-// background cascade deletion. It will attempt to delete all resources even
+// background cascade deletion, in the order of DeletionTiers. It will attempt to delete all resources even
 // if one or more fail and collect any errors. All successfully deleted items
 // will be returned in the `Deleted` ResourceList that is part of the result.
 func (c *Client) Delete(resources ResourceList) (*Result, []error) {
-	return rdelete(c, resources, metav1.DeletePropagationBackground)
+	return rdelete(c, resources, metav1.DeletePropagationBackground, nil, 0)
 }
 
 // Delete deletes Kubernetes resources specified in the resources list with
@@ -511,12 +570,53 @@ func (c *Client) Delete(resources ResourceList) (*Result, []error) {
 // if one or more fail and collect any errors. All successfully deleted items
 // will be returned in the `Deleted` ResourceList that is part of the result.
 func (c *Client) DeleteWithPropagationPolicy(resources ResourceList, policy metav1.DeletionPropagation) (*Result, []error) {
-	return rdelete(c, resources, policy)
+	return rdelete(c, resources, policy, nil, 0)
+}
+
+// DeleteInTiers deletes Kubernetes resources tier by tier, in the order of
+// DeletionTiers, with the given deletion propagation policy. When waiter is
+// set, the resources of each tier are waited for to be deleted before the
+// next tier is deleted, within timeout overall. As with Delete, it attempts
+// to delete all resources even if one or more fail and collects any errors.
+func (c *Client) DeleteInTiers(resources ResourceList, policy metav1.DeletionPropagation, waiter Waiter, timeout time.Duration) (*Result, []error) {
+	return rdelete(c, resources, policy, waiter, timeout)
 }
 
-func rdelete(_ *Client, resources ResourceList, propagation metav1.DeletionPropagation) (*Result, []error) {
+func rdelete(_ *Client, resources ResourceList, propagation metav1.DeletionPropagation, waiter Waiter, timeout time.Duration) (*Result, []error) {
+	tiers := DeletionTiers(resources)
+	if len(tiers) == 0 {
+		// Report that there was nothing to delete.
+		tiers = []ResourceList{nil}
+	}
+
 	var errs []error
 	res := &Result{}
+	deadline := time.Now().Add(timeout)
+	for i, tier := range tiers {
+		deleted, tierErrs := deleteTier(tier, propagation)
+		res.Deleted = append(res.Deleted, deleted...)
+		errs = append(errs, tierErrs...)
+		if waiter == nil || i == len(tiers)-1 || len(deleted) == 0 {
+			continue
+		}
+		slog.Debug("waiting for the deletion of a tier of resources", "resources", len(deleted))
+		if err := waiter.WaitForDelete(deleted, time.Until(deadline)); err != nil {
+			// Keep deleting the remaining resources without waiting.
+			errs = append(errs, err)
+			waiter = nil
+		}
+	}
+	if errs != nil {
+		return nil, errs
+	}
+	return res, nil
+}
+
+// deleteTier deletes resources concurrently, by kind, and returns those that
+// were deleted.
+func deleteTier(resources ResourceList, propagation metav1.DeletionPropagation) (ResourceList, []error) {
+	var errs []error
+	var deleted ResourceList
 	mtx := sync.Mutex{}
 	err := perform(resources, func(info *resource.Info) error {
 		slog.Debug("starting delete resource", "namespace", info.Namespace, "name", info.Name, "kind", info.Mapping.GroupVersionKind.Kind)
@@ -527,7 +627,7 @@ func rdelete(_ *Client, resources ResourceList, propagation metav1.DeletionPropa
 			}
 			mtx.Lock()
 			defer mtx.Unlock()
-			res.Deleted = append(res.Deleted, info)
+			deleted = append(deleted, info)
 			return nil
 		}
 		mtx.Lock()
@@ -542,10 +642,23 @@ func rdelete(_ *Client, resources ResourceList, propagation metav1.DeletionPropa
 		}
 		errs = append(errs, err)
 	}
-	if errs != nil {
-		return nil, errs
+	return deleted, errs
+}
+
+// SetFieldManager sets the name of the field manager used for the changes
+// made by the client, and returns the previous one.
+func (c *Client) SetFieldManager(name string) (previous string) {
+	previous, c.FieldManager = c.FieldManager, name
+	return previous
+}
+
+// fieldManager returns the field manager of the client, falling back to
+// getManagedFieldsManager.
+func (c *Client) fieldManager() string {
+	if c.FieldManager != "" {
+		return c.FieldManager
 	}
-	return res, nil
+	return getManagedFieldsManager()
 }
 
 // getManagedFieldsManager returns the manager string. If one was set it will be returned.
@@ -568,28 +681,17 @@ func getManagedFieldsManager() string {
 	return filepath.Base(os.Args[0])
 }
 
-func batchPerform(infos ResourceList, fn func(*resource.Info) error, errs chan<- error) {
-	var kind string
-	var wg sync.WaitGroup
-	for _, info := range infos {
-		currentKind := info.Object.GetObjectKind().GroupVersionKind().Kind
-		if kind != currentKind {
-			wg.Wait()
-			kind = currentKind
-		}
-		wg.Add(1)
-		go func(i *resource.Info) {
-			errs <- fn(i)
-			wg.Done()
-		}(info)
-	}
+func batchPerform(infos ResourceList, limit int, fn func(*resource.Info) error, errs chan<- error) {
+	forEachInTiers(infos, limit, func(i int) {
+		errs <- fn(infos[i])
+	}, nil)
 }
 
-func createResource(info *resource.Info) error {
+func createResource(info *resource.Info, fieldManager string) error {
 	return retry.RetryOnConflict(
 		retry.DefaultRetry,
 		func() error {
-			obj, err := resource.NewHelper(info.Client, info.Mapping).WithFieldManager(getManagedFieldsManager()).Create(info.Namespace, true, info.Object)
+			obj, err := resource.NewHelper(info.Client, info.Mapping).WithFieldManager(fieldManager).Create(info.Namespace, true, info.Object)
 			if err != nil {
 				return err
 			}
@@ -670,23 +772,40 @@ func createPatch(target *resource.Info, current runtime.Object, threeWayMergeFor
 	return patch, types.StrategicMergePatchType, err
 }
 
-func updateResource(_ *Client, target *resource.Info, currentObj runtime.Object, force, threeWayMergeForUnstructured bool) error {
+func updateResource(c *Client, target *resource.Info, currentObj runtime.Object, force, threeWayMergeForUnstructured bool) error {
 	var (
 		obj    runtime.Object
-		helper = resource.NewHelper(target.Client, target.Mapping).WithFieldManager(getManagedFieldsManager())
+		helper = resource.NewHelper(target.Client, target.Mapping).WithFieldManager(c.fieldManager())
 		kind   = target.Mapping.GroupVersionKind.Kind
 	)
 
+	merge, err := ResourceMerge(target.Object)
+	if err != nil {
+		return fmt.Errorf("cannot update %q with kind %s: %w", target.Name, kind, err)
+	}
+	if merge == MergeIgnore && ManifestUnchanged(currentObj, target.Object) {
+		slog.Debug("skipping update of unchanged resource due to annotation", "kind", kind, "name", target.Name, "annotation", MergeAnno, "value", MergeIgnore)
+		if err := target.Get(); err != nil {
+			return fmt.Errorf("failed to refresh resource information: %w", err)
+		}
+		return nil
+	}
+
 	// if --force is applied, attempt to replace the existing resource with the new object.
 	if force {
-		var err error
 		obj, err = helper.Replace(target.Namespace, target.Name, true, target.Object)
 		if err != nil {
 			return fmt.Errorf("failed to replace object: %w", err)
 		}
 		slog.Debug("replace succeeded", "name", target.Name, "initialKind", currentObj.GetObjectKind().GroupVersionKind().Kind, "kind", kind)
 	} else {
-		patch, patchType, err := createPatch(target, currentObj, threeWayMergeForUnstructured)
+		var patch []byte
+		var patchType types.PatchType
+		if merge == MergeClient {
+			patch, patchType, err = createClientPatch(target, currentObj)
+		} else {
+			patch, patchType, err = createPatch(target, currentObj, threeWayMergeForUnstructured)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to create patch: %w", err)
 		}
@@ -729,7 +848,7 @@ func (c *Client) OutputContainerLogsForPodList(podList *v1.PodList, namespace st
 				Container: container.Name,
 			}
 			request := c.kubeClient.CoreV1().Pods(namespace).GetLogs(pod.Name, options)
-			err2 := copyRequestStreamToWriter(request, pod.Name, container.Name, writerFunc(namespace, pod.Name, container.Name))
+			err2 := copyRequestStreamToWriter(context.Background(), request, pod.Name, container.Name, writerFunc(namespace, pod.Name, container.Name))
 			if err2 != nil {
 				return err2
 			}
@@ -738,8 +857,45 @@ func (c *Client) OutputContainerLogsForPodList(podList *v1.PodList, namespace st
 	return nil
 }
 
-func copyRequestStreamToWriter(request *rest.Request, podName, containerName string, writer io.Writer) error {
-	readCloser, err := request.Stream(context.Background())
+// StreamPodLogs waits for the pod to start, then follows the logs of each of
+// its containers as they are written, until the containers terminate or ctx is
+// done.
+func (c *Client) StreamPodLogs(ctx context.Context, namespace, name string, writerFunc func(namespace, pod, container string) io.Writer) error {
+	var pod *v1.Pod
+	err := wait.PollUntilContextCancel(ctx, time.Second, true, func(ctx context.Context) (bool, error) {
+		p, err := c.kubeClient.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		pod = p
+		return p.Status.Phase != v1.PodPending, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to wait for pod %s to start: %w", name, err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(pod.Spec.Containers))
+	for i, container := range pod.Spec.Containers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			request := c.kubeClient.CoreV1().Pods(namespace).GetLogs(name, &v1.PodLogOptions{
+				Container: container.Name,
+				Follow:    true,
+			})
+			errs[i] = copyRequestStreamToWriter(ctx, request, name, container.Name, writerFunc(namespace, name, container.Name))
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func copyRequestStreamToWriter(ctx context.Context, request *rest.Request, podName, containerName string, writer io.Writer) error {
+	readCloser, err := request.Stream(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to stream pod logs for pod: %s, container: %s", podName, containerName)
 	}
@@ -18,9 +18,11 @@ package kube
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -118,6 +120,38 @@ func newTestClient(t *testing.T) *Client {
 	}
 }
 
+func TestClientFieldManager(t *testing.T) {
+	list := newPodList("starfish")
+
+	var fieldManager string
+	c := newTestClient(t)
+	c.Factory.(*cmdtesting.TestFactory).UnstructuredClient = &fake.RESTClient{
+		NegotiatedSerializer: unstructuredSerializer,
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			fieldManager = req.URL.Query().Get("fieldManager")
+			return newResponse(http.StatusOK, &list.Items[0])
+		}),
+	}
+
+	if got := c.fieldManager(); got != getManagedFieldsManager() {
+		t.Errorf("expected the default field manager %q, got %q", getManagedFieldsManager(), got)
+	}
+
+	if previous := c.SetFieldManager("helm-gitops"); previous != "" {
+		t.Errorf("expected no previous field manager, got %q", previous)
+	}
+	resources, err := c.Build(objBody(&list), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Create(resources); err != nil {
+		t.Fatal(err)
+	}
+	if fieldManager != "helm-gitops" {
+		t.Errorf("expected field manager %q, got %q", "helm-gitops", fieldManager)
+	}
+}
+
 func TestCreate(t *testing.T) {
 	// Note: c.Create with the fake client can currently only test creation of a single pod in the same list. When testing
 	// with more than one pod, c.Create will run into a data race as it calls perform->batchPerform which performs creation
@@ -752,6 +786,36 @@ func TestOutputContainerLogsForPodList(t *testing.T) {
 	clientAssertions.Equal("fake logsfake logsfake logs", outBuffer.String())
 }
 
+func TestStreamPodLogs(t *testing.T) {
+	namespace := "some-namespace"
+	pod := newPodWithStatus("jimmy", v1.PodStatus{Phase: v1.PodRunning}, namespace)
+	pod.Spec.Containers = append(pod.Spec.Containers, v1.Container{Name: "sidecar"})
+
+	kubeClient := k8sfake.NewSimpleClientset(&pod)
+	c := Client{Namespace: namespace, kubeClient: kubeClient}
+	var mu sync.Mutex
+	out := map[string]*bytes.Buffer{}
+	writerFunc := func(_, pod, container string) io.Writer {
+		mu.Lock()
+		defer mu.Unlock()
+		out[pod+"/"+container] = &bytes.Buffer{}
+		return out[pod+"/"+container]
+	}
+	err := c.StreamPodLogs(context.Background(), namespace, "jimmy", writerFunc)
+	clientAssertions := assert.New(t)
+	clientAssertions.NoError(err)
+	clientAssertions.Len(out, 2)
+	for container, buf := range out {
+		clientAssertions.Equal("fake logs", buf.String(), container)
+	}
+
+	// Pods that never start are waited for until the context is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err = c.StreamPodLogs(ctx, namespace, "missing", writerFunc)
+	clientAssertions.ErrorContains(err, "failed to wait for pod missing to start")
+}
+
 const testServiceManifest = `
 kind: Service
 apiVersion: v1
@@ -0,0 +1,108 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v4/pkg/kube"
+
+import (
+	"sort"
+
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// deletionOrder lists the tiers in which resources are deleted, by kind. The
+// kinds of a tier are deleted in the listed order, and the resources of a tier
+// are deleted before those of the next tier. It mirrors the install order,
+// reversed, with resources deleted before what they depend on.
+var deletionOrder = [][]string{
+	// Admission webhooks and aggregated APIs could block the deletion of the
+	// other resources.
+	{"ValidatingWebhookConfiguration", "MutatingWebhookConfiguration", "APIService"},
+	// Custom resources, and any other kinds not listed, are deleted here,
+	// while their controllers and definitions are still around to handle
+	// their finalizers.
+	nil,
+	// Workloads and the services exposing them.
+	{
+		"Ingress", "IngressClass", "Service", "CronJob", "Job", "StatefulSet", "HorizontalPodAutoscaler",
+		"Deployment", "ReplicaSet", "ReplicationController", "Pod", "DaemonSet",
+	},
+	// The RBAC, configuration and storage used by the workloads.
+	{
+		"RoleBindingList", "RoleBinding", "RoleList", "Role",
+		"ClusterRoleBindingList", "ClusterRoleBinding", "ClusterRoleList", "ClusterRole",
+		"PersistentVolumeClaim", "PersistentVolume", "StorageClass", "ConfigMap", "SecretList", "Secret",
+		"ServiceAccount", "PodDisruptionBudget", "PodSecurityPolicy", "LimitRange", "ResourceQuota", "NetworkPolicy",
+	},
+	{"CustomResourceDefinition"},
+	// Namespaces go after the resources they contain.
+	{"Namespace"},
+	{"PriorityClass"},
+}
+
+// deletionRank is the position of a kind in deletionOrder: its tier and its
+// index within the tier.
+type deletionRank struct {
+	tier, index int
+}
+
+// unlistedTier is the tier of the kinds not listed in deletionOrder.
+const unlistedTier = 1
+
+var deletionRanks = func() map[string]deletionRank {
+	ranks := map[string]deletionRank{}
+	for tier, kinds := range deletionOrder {
+		for i, kind := range kinds {
+			ranks[kind] = deletionRank{tier, i}
+		}
+	}
+	return ranks
+}()
+
+func rankForDeletion(info *resource.Info) deletionRank {
+	if info.Object == nil {
+		return deletionRank{tier: unlistedTier}
+	}
+	if rank, ok := deletionRanks[info.Object.GetObjectKind().GroupVersionKind().Kind]; ok {
+		return rank
+	}
+	return deletionRank{tier: unlistedTier}
+}
+
+// DeletionTiers sorts resources into the tiers in which they are deleted:
+// custom resources before their CustomResourceDefinition, the resources of a
+// namespace before the Namespace, and workloads before their RBAC and
+// configuration. Within a tier, resources are sorted by kind, keeping the
+// order of the resources of a same kind. Empty tiers are omitted.
+func DeletionTiers(resources ResourceList) []ResourceList {
+	sorted := make(ResourceList, len(resources))
+	copy(sorted, resources)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := rankForDeletion(sorted[i]), rankForDeletion(sorted[j])
+		if a.tier != b.tier {
+			return a.tier < b.tier
+		}
+		return a.index < b.index
+	})
+
+	var tiers []ResourceList
+	for i, info := range sorted {
+		if i == 0 || rankForDeletion(sorted[i-1]).tier != rankForDeletion(info).tier {
+			tiers = append(tiers, nil)
+		}
+		tiers[len(tiers)-1] = append(tiers[len(tiers)-1], info)
+	}
+	return tiers
+}
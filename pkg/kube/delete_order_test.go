@@ -0,0 +1,142 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/rest/fake"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+)
+
+func deletionTestInfo(kind, name string) *resource.Info {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind(kind)
+	obj.SetName(name)
+	return &resource.Info{Name: name, Object: obj}
+}
+
+func TestDeletionTiers(t *testing.T) {
+	resources := ResourceList{
+		deletionTestInfo("Namespace", "space"),
+		deletionTestInfo("ConfigMap", "settings"),
+		deletionTestInfo("CustomResourceDefinition", "widgets.example.com"),
+		deletionTestInfo("Deployment", "app"),
+		deletionTestInfo("Widget", "first"),
+		deletionTestInfo("ClusterRole", "reader"),
+		deletionTestInfo("Service", "app"),
+		deletionTestInfo("ValidatingWebhookConfiguration", "check"),
+		deletionTestInfo("Widget", "second"),
+		deletionTestInfo("Deployment", "worker"),
+	}
+
+	var got [][]string
+	for _, tier := range DeletionTiers(resources) {
+		var names []string
+		for _, info := range tier {
+			names = append(names, info.Object.GetObjectKind().GroupVersionKind().Kind+"/"+info.Name)
+		}
+		got = append(got, names)
+	}
+	assert.Equal(t, [][]string{
+		{"ValidatingWebhookConfiguration/check"},
+		{"Widget/first", "Widget/second"},
+		{"Service/app", "Deployment/app", "Deployment/worker"},
+		{"ClusterRole/reader", "ConfigMap/settings"},
+		{"CustomResourceDefinition/widgets.example.com"},
+		{"Namespace/space"},
+	}, got)
+
+	assert.Empty(t, DeletionTiers(nil))
+}
+
+// recordingWaiter records the resources it waits for the deletion of.
+type recordingWaiter struct {
+	Waiter
+	record func(string)
+}
+
+func (w *recordingWaiter) WaitForDelete(resources ResourceList, _ time.Duration) error {
+	for _, info := range resources {
+		w.record("wait " + info.Name)
+	}
+	return nil
+}
+
+func TestDeleteInTiers(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+
+	c := newTestClient(t)
+	c.Factory.(*cmdtesting.TestFactory).UnstructuredClient = &fake.RESTClient{
+		NegotiatedSerializer: unstructuredSerializer,
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			if req.Method != http.MethodDelete {
+				t.Errorf("unexpected request: %s %s", req.Method, req.URL.Path)
+			}
+			record("delete " + req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:])
+			return newResponse(http.StatusOK, &metav1.Status{Status: metav1.StatusSuccess})
+		}),
+	}
+	resources, err := c.Build(strings.NewReader(`
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: space
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: runner
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: starfish
+`), false)
+	require.NoError(t, err)
+
+	res, errs := c.DeleteInTiers(resources, metav1.DeletePropagationBackground, &recordingWaiter{record: record}, time.Minute)
+	require.Empty(t, errs)
+	assert.Len(t, res.Deleted, 3)
+	assert.Equal(t, []string{
+		"delete starfish", "wait starfish",
+		"delete runner", "wait runner",
+		"delete space",
+	}, events)
+
+	// Without a waiter, the tiers are deleted in order without waiting.
+	events = nil
+	_, errs = c.Delete(resources)
+	require.Empty(t, errs)
+	assert.Equal(t, []string{"delete starfish", "delete runner", "delete space"}, events)
+}
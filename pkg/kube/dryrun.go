@@ -0,0 +1,125 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v4/pkg/kube"
+
+import (
+	"encoding/json"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// DryRunApplyResult is the outcome of a server-side apply dry run of a
+// single resource.
+type DryRunApplyResult struct {
+	// Info is the resource that was applied.
+	Info *resource.Info
+	// Live is the object currently stored in the cluster, or nil if the
+	// resource does not exist yet.
+	Live map[string]interface{}
+	// Applied is the object as it would be stored after the apply. It is nil
+	// if Err is set.
+	Applied map[string]interface{}
+	// Err is the error returned by the API server when it rejected the
+	// resource as invalid, for example because an immutable field would
+	// change. Other errors abort the dry run.
+	Err error
+}
+
+// DryRunApply performs a server-side apply dry run of each resource in target.
+//
+// Conflicts with other field managers are forced, as they would be for Helm
+// owned resources.
+func (c *Client) DryRunApply(target ResourceList) ([]DryRunApplyResult, error) {
+	var results []DryRunApplyResult
+	err := target.Visit(func(info *resource.Info, err error) error {
+		if err != nil {
+			return err
+		}
+		res, err := dryRunApply(info, c.fieldManager())
+		if err != nil {
+			return err
+		}
+		results = append(results, res)
+		return nil
+	})
+	return results, err
+}
+
+// DryRunApplyResources performs a server-side apply dry run of each resource
+// in target and returns the resources as the API server would store them,
+// without persisting any change. This lets tools diff them against the live
+// objects.
+//
+// Unlike DryRunApply, the resources rejected by the API server, whether as
+// invalid or by an admission webhook, make it fail.
+func (c *Client) DryRunApplyResources(target ResourceList) (ResourceList, error) {
+	results, err := c.DryRunApply(target)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(ResourceList, 0, len(results))
+	for _, res := range results {
+		if res.Err != nil {
+			return nil, fmt.Errorf("dry run of %q with kind %s failed: %w", res.Info.Name, res.Info.Mapping.GroupVersionKind.Kind, res.Err)
+		}
+		info := *res.Info
+		info.Object = &unstructured.Unstructured{Object: res.Applied}
+		applied = append(applied, &info)
+	}
+	return applied, nil
+}
+
+func dryRunApply(info *resource.Info, fieldManager string) (DryRunApplyResult, error) {
+	res := DryRunApplyResult{Info: info}
+	helper := resource.NewHelper(info.Client, info.Mapping).
+		DryRun(true).
+		WithFieldManager(fieldManager)
+	kind := info.Mapping.GroupVersionKind.Kind
+
+	live, err := helper.Get(info.Namespace, info.Name)
+	switch {
+	case apierrors.IsNotFound(err):
+	case err != nil:
+		return res, fmt.Errorf("could not get %q with kind %s: %w", info.Name, kind, err)
+	default:
+		if res.Live, err = runtime.DefaultUnstructuredConverter.ToUnstructured(live); err != nil {
+			return res, err
+		}
+	}
+
+	data, err := json.Marshal(info.Object)
+	if err != nil {
+		return res, fmt.Errorf("serializing target configuration: %w", err)
+	}
+	force := true
+	applied, err := helper.Patch(info.Namespace, info.Name, types.ApplyPatchType, data, &metav1.PatchOptions{Force: &force})
+	if apierrors.IsInvalid(err) {
+		res.Err = err
+		return res, nil
+	}
+	if err != nil {
+		return res, fmt.Errorf("dry run of %q with kind %s failed: %w", info.Name, kind, err)
+	}
+	res.Applied, err = runtime.DefaultUnstructuredConverter.ToUnstructured(applied)
+	return res, err
+}
@@ -0,0 +1,162 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/rest/fake"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+)
+
+func TestDryRunApply(t *testing.T) {
+	list := newPodList("starfish", "dolphin", "whale")
+	applied := newPod("starfish")
+	applied.Spec.Containers[0].Image = "abc/app:v5"
+
+	immutable := apierrors.NewInvalid(schema.GroupKind{Kind: "Pod"}, "whale", field.ErrorList{
+		field.Invalid(field.NewPath("spec", "containers"), nil, "field is immutable"),
+	})
+
+	c := newTestClient(t)
+	c.Factory.(*cmdtesting.TestFactory).UnstructuredClient = &fake.RESTClient{
+		NegotiatedSerializer: unstructuredSerializer,
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			path, method := req.URL.Path, req.Method
+			t.Logf("got request %s %s", path, method)
+			name := path[strings.LastIndex(path, "/")+1:]
+			switch method {
+			case http.MethodGet:
+				if name == "dolphin" {
+					return newResponse(http.StatusNotFound, notFoundBody())
+				}
+				return newResponse(http.StatusOK, &list.Items[0])
+			case http.MethodPatch:
+				assert.Equal(t, string(types.ApplyPatchType), req.Header.Get("Content-Type"))
+				assert.Equal(t, "All", req.URL.Query().Get("dryRun"))
+				assert.Equal(t, "true", req.URL.Query().Get("force"))
+				assert.NotEmpty(t, req.URL.Query().Get("fieldManager"))
+				switch name {
+				case "whale":
+					return newResponse(http.StatusUnprocessableEntity, &immutable.ErrStatus)
+				case "dolphin":
+					return newResponse(http.StatusOK, &list.Items[1])
+				}
+				return newResponse(http.StatusOK, &applied)
+			}
+			t.Fatalf("unexpected request: %s %s", method, path)
+			return nil, nil
+		}),
+	}
+
+	resources, err := c.Build(objBody(&list), false)
+	require.NoError(t, err)
+
+	results, err := c.DryRunApply(resources)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	starfish := results[0]
+	assert.Equal(t, "starfish", starfish.Info.Name)
+	assert.NoError(t, starfish.Err)
+	assert.NotNil(t, starfish.Live)
+	containers := starfish.Applied["spec"].(map[string]interface{})["containers"].([]interface{})
+	assert.Equal(t, "abc/app:v5", containers[0].(map[string]interface{})["image"])
+
+	dolphin := results[1]
+	assert.Nil(t, dolphin.Live, "missing resources should have no live object")
+	assert.NotNil(t, dolphin.Applied)
+
+	whale := results[2]
+	assert.True(t, apierrors.IsInvalid(whale.Err))
+	assert.Nil(t, whale.Applied)
+}
+
+func TestDryRunApplyError(t *testing.T) {
+	list := newPodList("starfish")
+
+	c := newTestClient(t)
+	c.Factory.(*cmdtesting.TestFactory).UnstructuredClient = &fake.RESTClient{
+		NegotiatedSerializer: unstructuredSerializer,
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			if req.Method == http.MethodGet {
+				return newResponse(http.StatusOK, &list.Items[0])
+			}
+			return newResponse(http.StatusForbidden, &apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "starfish", nil).ErrStatus)
+		}),
+	}
+
+	resources, err := c.Build(objBody(&list), false)
+	require.NoError(t, err)
+	_, err = c.DryRunApply(resources)
+	assert.ErrorContains(t, err, `dry run of "starfish" with kind Pod failed`)
+}
+
+func TestDryRunApplyResources(t *testing.T) {
+	list := newPodList("starfish", "whale")
+	applied := newPod("starfish")
+	applied.Spec.Containers[0].Image = "abc/app:v5"
+	denied := apierrors.NewBadRequest(`admission webhook "policy.example.com" denied the request: image is not allowed`)
+
+	newClient := func(deny bool) *Client {
+		c := newTestClient(t)
+		c.Factory.(*cmdtesting.TestFactory).UnstructuredClient = &fake.RESTClient{
+			NegotiatedSerializer: unstructuredSerializer,
+			Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+				name := req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:]
+				switch {
+				case req.Method == http.MethodGet:
+					return newResponse(http.StatusOK, &list.Items[0])
+				case deny && name == "whale":
+					return newResponse(http.StatusBadRequest, &denied.ErrStatus)
+				case name == "whale":
+					return newResponse(http.StatusOK, &list.Items[1])
+				}
+				return newResponse(http.StatusOK, &applied)
+			}),
+		}
+		return c
+	}
+
+	c := newClient(false)
+	resources, err := c.Build(objBody(&list), false)
+	require.NoError(t, err)
+	results, err := c.DryRunApplyResources(resources)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "starfish", results[0].Name)
+	obj := results[0].Object.(*unstructured.Unstructured)
+	containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "containers")
+	assert.Equal(t, "abc/app:v5", containers[0].(map[string]interface{})["image"])
+	assert.NotSame(t, resources[0], results[0], "the target resources should be left untouched")
+
+	c = newClient(true)
+	resources, err = c.Build(objBody(&list), false)
+	require.NoError(t, err)
+	_, err = c.DryRunApplyResources(resources)
+	assert.ErrorContains(t, err, `dry run of "whale" with kind Pod failed`)
+	assert.ErrorContains(t, err, "denied the request")
+}
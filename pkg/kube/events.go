@@ -0,0 +1,314 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v4/pkg/kube"
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// maxResourceEvents is the number of the most recent events reported for
+	// a resource that is not ready.
+	maxResourceEvents = 5
+	// eventsWindow is how long before the end of the wait the events
+	// reported occurred.
+	eventsWindow = time.Hour
+	// eventsTimeout bounds the listing of events, the wait having expired.
+	eventsTimeout = 10 * time.Second
+)
+
+// eventsGVR is the resource of the core events listed with a dynamic client.
+var eventsGVR = corev1.SchemeGroupVersion.WithResource("events")
+
+// podOwnerKinds are the kinds of the workloads owning pods, directly or
+// through the replica sets of deployments and the jobs of cron jobs.
+var podOwnerKinds = map[string]bool{
+	"Deployment":            true,
+	"StatefulSet":           true,
+	"DaemonSet":             true,
+	"ReplicaSet":            true,
+	"ReplicationController": true,
+	"Job":                   true,
+	"CronJob":               true,
+}
+
+// maxOwnerDepth bounds the chain of owners followed from the object of an
+// event, as from a pod to its replica set and then its deployment.
+const maxOwnerDepth = 3
+
+// ownedGVRs are the resources of the objects owned by workloads, listed with
+// a dynamic client to tell which workload the object of an event belongs to.
+var ownedGVRs = []schema.GroupVersionResource{
+	corev1.SchemeGroupVersion.WithResource("pods"),
+	appsv1.SchemeGroupVersion.WithResource("replicasets"),
+	batchv1.SchemeGroupVersion.WithResource("jobs"),
+}
+
+// eventLister lists the warning events of a namespace.
+type eventLister func(ctx context.Context, namespace string) ([]corev1.Event, error)
+
+// objectOwners maps the UIDs of the pods, replica sets and jobs of a
+// namespace to their owners.
+type objectOwners map[types.UID][]metav1.OwnerReference
+
+// ownerLister lists the owners of the pods, replica sets and jobs of a
+// namespace.
+type ownerLister func(ctx context.Context, namespace string) (objectOwners, error)
+
+// warningEventsSelector selects the events reporting a problem, such as an
+// image that cannot be pulled or a pod that cannot be scheduled.
+var warningEventsSelector = fields.OneTermEqualSelector("type", corev1.EventTypeWarning).String()
+
+// dynamicEventLister lists the warning events of a namespace with client.
+func dynamicEventLister(client dynamic.Interface) eventLister {
+	return func(ctx context.Context, namespace string) ([]corev1.Event, error) {
+		list, err := client.Resource(eventsGVR).Namespace(namespace).List(ctx, metav1.ListOptions{FieldSelector: warningEventsSelector})
+		if err != nil {
+			return nil, err
+		}
+		events := make([]corev1.Event, 0, len(list.Items))
+		for _, item := range list.Items {
+			var event corev1.Event
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &event); err != nil {
+				return nil, err
+			}
+			events = append(events, event)
+		}
+		return events, nil
+	}
+}
+
+// clientsetEventLister lists the warning events of a namespace with client.
+func clientsetEventLister(client kubernetes.Interface) eventLister {
+	return func(ctx context.Context, namespace string) ([]corev1.Event, error) {
+		list, err := client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{FieldSelector: warningEventsSelector})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	}
+}
+
+// dynamicOwnerLister lists the owners of the objects of a namespace with
+// client.
+func dynamicOwnerLister(client dynamic.Interface) ownerLister {
+	return func(ctx context.Context, namespace string) (objectOwners, error) {
+		owners := objectOwners{}
+		for _, gvr := range ownedGVRs {
+			list, err := client.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			for _, item := range list.Items {
+				owners[item.GetUID()] = item.GetOwnerReferences()
+			}
+		}
+		return owners, nil
+	}
+}
+
+// clientsetOwnerLister lists the owners of the objects of a namespace with
+// client.
+func clientsetOwnerLister(client kubernetes.Interface) ownerLister {
+	return func(ctx context.Context, namespace string) (objectOwners, error) {
+		owners := objectOwners{}
+		pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, pod := range pods.Items {
+			owners[pod.UID] = pod.OwnerReferences
+		}
+		replicaSets, err := client.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, rs := range replicaSets.Items {
+			owners[rs.UID] = rs.OwnerReferences
+		}
+		jobs, err := client.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, job := range jobs.Items {
+			owners[job.UID] = job.OwnerReferences
+		}
+		return owners, nil
+	}
+}
+
+// resourceEvents collects the recent warning events of the resources that
+// are not ready, listing the events and the owners of the objects of each
+// namespace once.
+type resourceEvents struct {
+	list       eventLister
+	listOwners ownerLister
+	until      time.Time
+	// namespaces caches the events listed by namespace, nil if they could
+	// not be listed.
+	namespaces map[string][]corev1.Event
+	// owners caches the owners listed by namespace, nil if they could not
+	// be listed.
+	owners map[string]objectOwners
+}
+
+func newResourceEvents(list eventLister, listOwners ownerLister) *resourceEvents {
+	return &resourceEvents{
+		list:       list,
+		listOwners: listOwners,
+		until:      time.Now(),
+		namespaces: map[string][]corev1.Event{},
+		owners:     map[string]objectOwners{},
+	}
+}
+
+// wrap adds the recent warning events of the resource, and of its pods if it
+// is a workload, to err. Events that cannot be listed are left out, not to
+// hide the failure of the wait.
+func (r *resourceEvents) wrap(err error, namespace, kind, name string) error {
+	events := r.get(namespace, kind, name)
+	if len(events) == 0 {
+		return err
+	}
+	lines := make([]string, 0, len(events))
+	for _, event := range events {
+		lines = append(lines, "  "+formatEvent(event))
+	}
+	return fmt.Errorf("%w, recent events:\n%s", err, strings.Join(lines, "\n"))
+}
+
+// get returns the maxResourceEvents most recent warning events of the
+// resource which occurred in the eventsWindow, oldest first.
+func (r *resourceEvents) get(namespace, kind, name string) []corev1.Event {
+	all, ok := r.namespaces[namespace]
+	if !ok {
+		ctx, cancel := context.WithTimeout(context.Background(), eventsTimeout)
+		defer cancel()
+		var err error
+		if all, err = r.list(ctx, namespace); err != nil {
+			all = nil
+		}
+		r.namespaces[namespace] = all
+	}
+
+	var owners objectOwners
+	if podOwnerKinds[kind] {
+		owners = r.getOwners(namespace)
+	}
+
+	since := r.until.Add(-eventsWindow)
+	var events []corev1.Event
+	for _, event := range all {
+		if event.Type != corev1.EventTypeWarning || !involves(owners, event.InvolvedObject, kind, name) {
+			continue
+		}
+		if eventTime(event).Before(since) {
+			continue
+		}
+		events = append(events, event)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return eventTime(events[i]).Before(eventTime(events[j]))
+	})
+	if len(events) > maxResourceEvents {
+		events = events[len(events)-maxResourceEvents:]
+	}
+	return events
+}
+
+// getOwners returns the owners of the objects of namespace, listing them
+// once. The owners that cannot be listed are left out, the events of the
+// objects of workloads being then left out as well.
+func (r *resourceEvents) getOwners(namespace string) objectOwners {
+	owners, ok := r.owners[namespace]
+	if !ok {
+		ctx, cancel := context.WithTimeout(context.Background(), eventsTimeout)
+		defer cancel()
+		var err error
+		if owners, err = r.listOwners(ctx, namespace); err != nil {
+			owners = nil
+		}
+		r.owners[namespace] = owners
+	}
+	return owners
+}
+
+// involves returns true if the object of an event is the resource, or is
+// owned by it, as the pods and replica sets of a deployment, following the
+// owner references of owners.
+func involves(owners objectOwners, obj corev1.ObjectReference, kind, name string) bool {
+	if obj.Kind == kind && obj.Name == name {
+		return true
+	}
+	return ownedBy(owners, obj.UID, kind, name, maxOwnerDepth)
+}
+
+// ownedBy returns true if the object with uid is owned by the resource,
+// directly or through at most depth owners.
+func ownedBy(owners objectOwners, uid types.UID, kind, name string, depth int) bool {
+	if uid == "" || depth == 0 {
+		return false
+	}
+	for _, ref := range owners[uid] {
+		if ref.Kind == kind && ref.Name == name {
+			return true
+		}
+		if ownedBy(owners, ref.UID, kind, name, depth-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// eventTime returns the last time an event occurred.
+func eventTime(event corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	case !event.FirstTimestamp.IsZero():
+		return event.FirstTimestamp.Time
+	}
+	return event.CreationTimestamp.Time
+}
+
+// formatEvent describes an event on a line, as in
+// "Warning Failed Pod/web-5d9c7-x2x8q: Error: ImagePullBackOff (x4)".
+func formatEvent(event corev1.Event) string {
+	message := strings.Join(strings.Fields(event.Message), " ")
+	s := fmt.Sprintf("%s %s %s/%s: %s", event.Type, event.Reason, event.InvolvedObject.Kind, event.InvolvedObject.Name, message)
+	if event.Count > 1 {
+		s += fmt.Sprintf(" (x%d)", event.Count)
+	}
+	return s
+}
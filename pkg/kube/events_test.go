@@ -0,0 +1,183 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v4/pkg/kube"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/cli-utils/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/kubectl/pkg/scheme"
+)
+
+// newTestEvent returns a warning event of the object, whose UID is its name.
+func newTestEvent(name, kind, object, reason string, count int32, last time.Time) corev1.Event {
+	return corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "ns"},
+		InvolvedObject: corev1.ObjectReference{Kind: kind, Name: object, Namespace: "ns", UID: types.UID(object)},
+		Type:           corev1.EventTypeWarning,
+		Reason:         reason,
+		Message:        reason + " of " + object,
+		Count:          count,
+		LastTimestamp:  metav1.NewTime(last),
+	}
+}
+
+func TestResourceEvents(t *testing.T) {
+	now := time.Now()
+	events := []corev1.Event{
+		newTestEvent("a", "Pod", "web-5d9c7-x2x8q", "Failed", 4, now.Add(-time.Minute)),
+		newTestEvent("b", "Deployment", "web", "ProgressDeadlineExceeded", 1, now.Add(-2*time.Minute)),
+		newTestEvent("c", "ReplicaSet", "web-5d9c7", "FailedCreate", 1, now.Add(-3*time.Minute)),
+		newTestEvent("d", "Pod", "web-5d9c7-x2x8q", "FailedScheduling", 1, now.Add(-2*eventsWindow)),
+		newTestEvent("e", "Pod", "webhook-0", "Failed", 1, now),
+		newTestEvent("f", "Service", "web-svc", "Failed", 1, now),
+		newTestEvent("g", "Pod", "db-0", "Failed", 1, now),
+		newTestEvent("i", "Pod", "web-canary-6f8d-k2p4z", "Failed", 1, now),
+	}
+	normal := newTestEvent("h", "Pod", "web-5d9c7-x2x8q", "Pulling", 1, now)
+	normal.Type = corev1.EventTypeNormal
+	events = append(events, normal)
+
+	owner := func(kind, name string) []metav1.OwnerReference {
+		return []metav1.OwnerReference{{Kind: kind, Name: name, UID: types.UID(name)}}
+	}
+	owners := objectOwners{
+		"web-5d9c7":             owner("Deployment", "web"),
+		"web-5d9c7-x2x8q":       owner("ReplicaSet", "web-5d9c7"),
+		"webhook-0":             owner("StatefulSet", "webhook"),
+		"db-0":                  owner("StatefulSet", "db"),
+		"web-canary-6f8d":       owner("Deployment", "web-canary"),
+		"web-canary-6f8d-k2p4z": owner("ReplicaSet", "web-canary-6f8d"),
+	}
+
+	lists, ownerLists := 0, 0
+	r := newResourceEvents(func(_ context.Context, namespace string) ([]corev1.Event, error) {
+		lists++
+		if namespace != "ns" {
+			return nil, errors.New("forbidden")
+		}
+		return events, nil
+	}, func(_ context.Context, namespace string) (objectOwners, error) {
+		ownerLists++
+		if namespace != "ns" {
+			return nil, errors.New("forbidden")
+		}
+		return owners, nil
+	})
+
+	err := r.wrap(errors.New("resource not ready, name: web, kind: Deployment, status: InProgress"), "ns", "Deployment", "web")
+	expected := `resource not ready, name: web, kind: Deployment, status: InProgress, recent events:
+  Warning FailedCreate ReplicaSet/web-5d9c7: FailedCreate of web-5d9c7
+  Warning ProgressDeadlineExceeded Deployment/web: ProgressDeadlineExceeded of web
+  Warning Failed Pod/web-5d9c7-x2x8q: Failed of web-5d9c7-x2x8q (x4)`
+	assert.EqualError(t, err, expected)
+
+	// A service does not own pods named after it, and the pods of a workload
+	// are told apart from those of another workload sharing its prefix.
+	assert.Empty(t, r.get("ns", "Service", "web"))
+	assert.Len(t, r.get("ns", "StatefulSet", "db"), 1)
+	assert.Len(t, r.get("ns", "Deployment", "web-canary"), 1)
+	assert.Equal(t, 1, lists, "expected the events of the namespace to be listed once")
+	assert.Equal(t, 1, ownerLists, "expected the owners of the namespace to be listed once")
+
+	// The events that cannot be listed are left out.
+	notReady := errors.New("resource not ready")
+	assert.Equal(t, notReady, r.wrap(notReady, "other", "Deployment", "web"))
+}
+
+func TestResourceEventsLimit(t *testing.T) {
+	now := time.Now()
+	var events []corev1.Event
+	for i := range maxResourceEvents + 3 {
+		events = append(events, newTestEvent(fmt.Sprintf("e%d", i), "Pod", "web", fmt.Sprintf("Reason%d", i), 1, now.Add(time.Duration(i-20)*time.Second)))
+	}
+	r := newResourceEvents(func(_ context.Context, _ string) ([]corev1.Event, error) {
+		return events, nil
+	}, func(_ context.Context, _ string) (objectOwners, error) {
+		return nil, nil
+	})
+	got := r.get("ns", "Pod", "web")
+	require.Len(t, got, maxResourceEvents)
+	assert.Equal(t, "Reason3", got[0].Reason)
+	assert.Equal(t, fmt.Sprintf("Reason%d", maxResourceEvents+2), got[len(got)-1].Reason)
+}
+
+func TestStatusWaitEvents(t *testing.T) {
+	c := newTestClient(t)
+	fakeClient := dynamicfake.NewSimpleDynamicClient(scheme.Scheme)
+	fakeMapper := testutil.NewFakeRESTMapper(
+		corev1.SchemeGroupVersion.WithKind("Pod"),
+		appsv1.SchemeGroupVersion.WithKind("Deployment"),
+		appsv1.SchemeGroupVersion.WithKind("ReplicaSet"),
+	)
+	statusWaiter := statusWaiter{
+		client:     fakeClient,
+		restMapper: fakeMapper,
+	}
+	objs := getRuntimeObjFromManifests(t, []string{notReadyDeploymentManifest})
+	for _, obj := range objs {
+		u := obj.(*unstructured.Unstructured)
+		require.NoError(t, fakeClient.Tracker().Create(getGVR(t, fakeMapper, u), u, u.GetNamespace()))
+	}
+	// The pod of the event belongs to the deployment through its replica set.
+	for _, obj := range []runtime.Object{
+		&appsv1.ReplicaSet{
+			TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "ReplicaSet"},
+			ObjectMeta: metav1.ObjectMeta{Name: "not-ready-7c5f8", Namespace: "ns-1", UID: "not-ready-7c5f8", OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "apps/v1", Kind: "Deployment", Name: "not-ready", UID: "not-ready"},
+			}},
+		},
+		&corev1.Pod{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+			ObjectMeta: metav1.ObjectMeta{Name: "not-ready-7c5f8-abcde", Namespace: "ns-1", UID: "not-ready-7c5f8-abcde", OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "not-ready-7c5f8", UID: "not-ready-7c5f8"},
+			}},
+		},
+	} {
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		require.NoError(t, err)
+		owned := &unstructured.Unstructured{Object: u}
+		require.NoError(t, fakeClient.Tracker().Create(getGVR(t, fakeMapper, owned), owned, "ns-1"))
+	}
+	event := newTestEvent("pull", "Pod", "not-ready-7c5f8-abcde", "Failed", 3, time.Now())
+	event.Namespace = "ns-1"
+	event.Message = "Failed to pull image \"nginx:1.19.6\": not found"
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&event)
+	require.NoError(t, err)
+	require.NoError(t, fakeClient.Tracker().Create(eventsGVR, &unstructured.Unstructured{Object: u}, "ns-1"))
+
+	err = statusWaiter.Wait(getResourceListFromRuntimeObjs(t, c, objs), time.Second*3)
+	expected := []error{
+		errors.New(`resource not ready, name: not-ready, kind: Deployment, status: InProgress, recent events:
+  Warning Failed Pod/not-ready-7c5f8-abcde: Failed to pull image "nginx:1.19.6": not found (x3)`),
+		context.DeadlineExceeded,
+	}
+	assert.EqualError(t, err, errors.Join(expected...).Error())
+}
@@ -0,0 +1,51 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v4/pkg/kube"
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Exists tells whether the object of kind, which is either ConfigMap or
+// Secret, named name exists in namespace.
+func (c *Client) Exists(kind, namespace, name string) (bool, error) {
+	cs, err := c.getKubeClient()
+	if err != nil {
+		return false, err
+	}
+	ctx := context.Background()
+
+	switch kind {
+	case "ConfigMap":
+		_, err = cs.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "Secret":
+		_, err = cs.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	default:
+		return false, fmt.Errorf("unable to look up objects of kind %s", kind)
+	}
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to get %s %q in namespace %q: %w", kind, name, namespace, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,53 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v4/pkg/kube"
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestClientExists(t *testing.T) {
+	c := newTestClient(t)
+	c.kubeClient = fake.NewClientset(
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: defaultNamespace}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "credentials", Namespace: defaultNamespace}},
+	)
+
+	tests := []struct {
+		kind, namespace, name string
+		exists                bool
+	}{
+		{kind: "ConfigMap", namespace: defaultNamespace, name: "config", exists: true},
+		{kind: "ConfigMap", namespace: defaultNamespace, name: "credentials"},
+		{kind: "Secret", namespace: defaultNamespace, name: "credentials", exists: true},
+		{kind: "Secret", namespace: "other", name: "credentials"},
+	}
+	for _, tt := range tests {
+		exists, err := c.Exists(tt.kind, tt.namespace, tt.name)
+		require.NoError(t, err)
+		assert.Equal(t, tt.exists, exists, "%s %s/%s", tt.kind, tt.namespace, tt.name)
+	}
+
+	_, err := c.Exists("Deployment", defaultNamespace, "web")
+	assert.Error(t, err)
+}
@@ -19,6 +19,7 @@ package fake
 
 import (
 	"io"
+	"slices"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -47,6 +48,21 @@ type FailingKubeClient struct {
 	WaitForDeleteError         error
 	WatchUntilReadyError       error
 	WaitDuration               time.Duration
+	DryRunApplyError           error
+	// DryRunApplyResults, if set, is returned by DryRunApply.
+	DryRunApplyResults []kube.DryRunApplyResult
+	HealthError        error
+	// HealthResults, if set, is returned by Health.
+	HealthResults []kube.ResourceHealth
+	ExistsError   error
+	// ExistingObjects, if set, lists the objects Exists reports as
+	// existing, as "Kind/namespace/name".
+	ExistingObjects []string
+	RemainingError  error
+	// RemainingResults, if set, is returned by Remaining.
+	RemainingResults []kube.RemainingResource
+	// WaitForExpressionsError, if set, is returned by WaitForExpressions.
+	WaitForExpressionsError error
 }
 
 // FailingKubeWaiter implements kube.Waiter for testing purposes.
@@ -75,6 +91,49 @@ func (f *FailingKubeClient) Get(resources kube.ResourceList, related bool) (map[
 	return f.PrintingKubeClient.Get(resources, related)
 }
 
+// Health returns the configured error or results if set or prints
+func (f *FailingKubeClient) Health(resources kube.ResourceList) ([]kube.ResourceHealth, error) {
+	if f.HealthError != nil {
+		return nil, f.HealthError
+	}
+	if f.HealthResults != nil {
+		return f.HealthResults, nil
+	}
+	return f.PrintingKubeClient.Health(resources)
+}
+
+// Exists returns the configured error if set, and whether the object is
+// listed in ExistingObjects if set, or prints
+func (f *FailingKubeClient) Exists(kind, namespace, name string) (bool, error) {
+	if f.ExistsError != nil {
+		return false, f.ExistsError
+	}
+	if f.ExistingObjects != nil {
+		return slices.Contains(f.ExistingObjects, kind+"/"+namespace+"/"+name), nil
+	}
+	return f.PrintingKubeClient.Exists(kind, namespace, name)
+}
+
+// WaitForExpressions returns the configured error if set or records the
+// expressions.
+func (f *FailingKubeClient) WaitForExpressions(namespace string, expressions []*kube.WaitExpression, timeout time.Duration) error {
+	if f.WaitForExpressionsError != nil {
+		return f.WaitForExpressionsError
+	}
+	return f.PrintingKubeClient.WaitForExpressions(namespace, expressions, timeout)
+}
+
+// Remaining returns the configured error or results if set or prints
+func (f *FailingKubeClient) Remaining(resources kube.ResourceList) ([]kube.RemainingResource, error) {
+	if f.RemainingError != nil {
+		return nil, f.RemainingError
+	}
+	if f.RemainingResults != nil {
+		return f.RemainingResults, nil
+	}
+	return f.PrintingKubeClient.Remaining(resources)
+}
+
 // Waits the amount of time defined on f.WaitDuration, then returns the configured error if set or prints.
 func (f *FailingKubeWaiter) Wait(resources kube.ResourceList, d time.Duration) error {
 	time.Sleep(f.waitDuration)
@@ -162,6 +221,25 @@ func (f *FailingKubeClient) DeleteWithPropagationPolicy(resources kube.ResourceL
 	return f.PrintingKubeClient.DeleteWithPropagationPolicy(resources, policy)
 }
 
+// DeleteInTiers returns the configured error if set or prints
+func (f *FailingKubeClient) DeleteInTiers(resources kube.ResourceList, policy metav1.DeletionPropagation, waiter kube.Waiter, timeout time.Duration) (*kube.Result, []error) {
+	if f.DeleteWithPropagationError != nil {
+		return nil, []error{f.DeleteWithPropagationError}
+	}
+	return f.PrintingKubeClient.DeleteInTiers(resources, policy, waiter, timeout)
+}
+
+// DryRunApply returns the configured error or results if set or prints
+func (f *FailingKubeClient) DryRunApply(resources kube.ResourceList) ([]kube.DryRunApplyResult, error) {
+	if f.DryRunApplyError != nil {
+		return nil, f.DryRunApplyError
+	}
+	if f.DryRunApplyResults != nil {
+		return f.DryRunApplyResults, nil
+	}
+	return f.PrintingKubeClient.DryRunApply(resources)
+}
+
 func (f *FailingKubeClient) GetWaiter(ws kube.WaitStrategy) (kube.Waiter, error) {
 	waiter, _ := f.PrintingKubeClient.GetWaiter(ws)
 	printingKubeWaiter, _ := waiter.(*PrintingKubeWaiter)
@@ -35,6 +35,16 @@ import (
 type PrintingKubeClient struct {
 	Out       io.Writer
 	LogOutput io.Writer
+	// FieldManager records the name given to SetFieldManager.
+	FieldManager string
+	// Parallelism records the value given to SetParallelism.
+	Parallelism int
+	// ForceRecreate records the value given to SetForceRecreate.
+	ForceRecreate bool
+	// WaitExpressions records the expressions given to WaitForExpressions.
+	WaitExpressions []*kube.WaitExpression
+	// WaitExpressionsTimeout records the timeout given to WaitForExpressions.
+	WaitExpressionsTimeout time.Duration
 }
 
 // PrintingKubeWaiter implements kube.Waiter, but simply prints the reader to the given output
@@ -65,6 +75,34 @@ func (p *PrintingKubeClient) Get(resources kube.ResourceList, _ bool) (map[strin
 	return make(map[string][]runtime.Object), nil
 }
 
+// Health prints the resources and reports them as ready.
+func (p *PrintingKubeClient) Health(resources kube.ResourceList) ([]kube.ResourceHealth, error) {
+	if _, err := io.Copy(p.Out, bufferize(resources)); err != nil {
+		return nil, err
+	}
+	health := make([]kube.ResourceHealth, 0, len(resources))
+	for _, info := range resources {
+		health = append(health, kube.ResourceHealth{
+			Kind:      info.Object.GetObjectKind().GroupVersionKind().Kind,
+			Namespace: info.Namespace,
+			Name:      info.Name,
+			Ready:     true,
+		})
+	}
+	return health, nil
+}
+
+// Remaining prints the resources and reports them as deleted.
+func (p *PrintingKubeClient) Remaining(resources kube.ResourceList) ([]kube.RemainingResource, error) {
+	_, err := io.Copy(p.Out, bufferize(resources))
+	return nil, err
+}
+
+// Exists reports every object as existing.
+func (p *PrintingKubeClient) Exists(_, _, _ string) (bool, error) {
+	return true, nil
+}
+
 func (p *PrintingKubeWaiter) Wait(resources kube.ResourceList, _ time.Duration) error {
 	_, err := io.Copy(p.Out, bufferize(resources))
 	return err
@@ -146,6 +184,72 @@ func (p *PrintingKubeClient) DeleteWithPropagationPolicy(resources kube.Resource
 	return &kube.Result{Deleted: resources}, nil
 }
 
+// DeleteInTiers implements KubeClient DeleteInTiers.
+//
+// It only prints out the content to be deleted, waiting with waiter for each
+// tier of resources but the last.
+func (p *PrintingKubeClient) DeleteInTiers(resources kube.ResourceList, _ metav1.DeletionPropagation, waiter kube.Waiter, timeout time.Duration) (*kube.Result, []error) {
+	_, err := io.Copy(p.Out, bufferize(resources))
+	if err != nil {
+		return nil, []error{err}
+	}
+	if waiter != nil {
+		tiers := kube.DeletionTiers(resources)
+		for i := 0; i+1 < len(tiers); i++ {
+			if err := waiter.WaitForDelete(tiers[i], timeout); err != nil {
+				return nil, []error{err}
+			}
+		}
+	}
+	return &kube.Result{Deleted: resources}, nil
+}
+
+// DryRunApply implements KubeClient DryRunApply.
+//
+// It prints the resources and reports each of them as a resource that does
+// not exist yet.
+func (p *PrintingKubeClient) DryRunApply(resources kube.ResourceList) ([]kube.DryRunApplyResult, error) {
+	_, err := io.Copy(p.Out, bufferize(resources))
+	if err != nil {
+		return nil, err
+	}
+	results := make([]kube.DryRunApplyResult, 0, len(resources))
+	for _, info := range resources {
+		res := kube.DryRunApplyResult{Info: info}
+		if info.Object != nil {
+			if res.Applied, err = runtime.DefaultUnstructuredConverter.ToUnstructured(info.Object); err != nil {
+				return nil, err
+			}
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// SetFieldManager implements KubeClient SetFieldManager.
+func (p *PrintingKubeClient) SetFieldManager(name string) (previous string) {
+	previous, p.FieldManager = p.FieldManager, name
+	return previous
+}
+
+// SetParallelism implements KubeClient SetParallelism.
+func (p *PrintingKubeClient) SetParallelism(n int) (previous int) {
+	previous, p.Parallelism = p.Parallelism, n
+	return previous
+}
+
+// SetForceRecreate implements KubeClient SetForceRecreate.
+func (p *PrintingKubeClient) SetForceRecreate(enabled bool) {
+	p.ForceRecreate = enabled
+}
+
+// WaitForExpressions records the expressions and reports them as holding.
+func (p *PrintingKubeClient) WaitForExpressions(_ string, expressions []*kube.WaitExpression, timeout time.Duration) error {
+	p.WaitExpressions = append(p.WaitExpressions, expressions...)
+	p.WaitExpressionsTimeout = timeout
+	return nil
+}
+
 func (p *PrintingKubeClient) GetWaiter(_ kube.WaitStrategy) (kube.Waiter, error) {
 	return &PrintingKubeWaiter{Out: p.Out, LogOutput: p.LogOutput}, nil
 }
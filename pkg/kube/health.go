@@ -0,0 +1,140 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v4/pkg/kube"
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// ResourceHealth describes the health of a resource.
+type ResourceHealth struct {
+	Kind      string
+	Namespace string
+	Name      string
+	// Ready tells whether the resource is ready, as the wait for it checks.
+	Ready bool
+	// Missing tells whether the resource is not found in the cluster. A
+	// missing resource is not ready.
+	Missing bool
+	// FailingPods names the pods of the resource that are failing, such as
+	// those crash looping or unable to pull their image.
+	FailingPods []string
+}
+
+// failingWaitingReasons are the reasons of waiting containers that are not
+// expected to start without a change.
+var failingWaitingReasons = map[string]bool{
+	"CrashLoopBackOff":           true,
+	"ImagePullBackOff":           true,
+	"ErrImagePull":               true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+}
+
+// Health checks the readiness of each of the resources, with the checks of
+// the wait for them, along with the pods of the workloads that are failing.
+// The resources that are not found are reported as missing.
+func (c *Client) Health(resources ResourceList) ([]ResourceHealth, error) {
+	cs, err := c.getKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	checker := NewReadyChecker(cs, PausedAsReady(true), CheckJobs(true))
+	ctx := context.Background()
+
+	var health []ResourceHealth
+	for _, info := range resources {
+		h := ResourceHealth{
+			Kind:      info.Mapping.GroupVersionKind.Kind,
+			Namespace: info.Namespace,
+			Name:      info.Name,
+		}
+		ready, err := checker.IsReady(ctx, info)
+		if apierrors.IsNotFound(err) {
+			h.Missing = true
+			health = append(health, h)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to check the readiness of %s %q: %w", h.Kind, info.Name, err)
+		}
+		failing, err := checker.failingPods(ctx, info)
+		if apierrors.IsNotFound(err) {
+			h.Missing = true
+			health = append(health, h)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to get the pods of %s %q: %w", h.Kind, info.Name, err)
+		}
+		h.Ready = ready
+		h.FailingPods = failing
+		health = append(health, h)
+	}
+	return health, nil
+}
+
+// failingPods returns the names of the failing pods of v, if it is a pod or
+// a workload managing pods.
+func (c *ReadyChecker) failingPods(ctx context.Context, v *resource.Info) ([]string, error) {
+	var pods []corev1.Pod
+	switch value := AsVersioned(v).(type) {
+	case *corev1.Pod:
+		pod, err := c.client.CoreV1().Pods(v.Namespace).Get(ctx, v.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		pods = []corev1.Pod{*pod}
+	case *appsv1.Deployment, *appsv1.StatefulSet, *appsv1.DaemonSet, *appsv1.ReplicaSet, *corev1.ReplicationController, *batchv1.Job:
+		var err error
+		if pods, err = c.podsforObject(ctx, v.Namespace, value); err != nil {
+			return nil, err
+		}
+	}
+
+	var failing []string
+	for _, pod := range pods {
+		if podFailing(&pod) {
+			failing = append(failing, pod.Name)
+		}
+	}
+	return failing, nil
+}
+
+// podFailing returns true if a pod failed or has a container that is not
+// expected to start.
+func podFailing(pod *corev1.Pod) bool {
+	if pod.Status.Phase == corev1.PodFailed {
+		return true
+	}
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, s := range statuses {
+		if s.State.Waiting != nil && failingWaitingReasons[s.State.Waiting.Reason] {
+			return true
+		}
+	}
+	return false
+}
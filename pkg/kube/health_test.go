@@ -0,0 +1,99 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v4/pkg/kube"
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestClientHealth(t *testing.T) {
+	crashing := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "crashing", Namespace: defaultNamespace},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			}},
+		},
+	}
+	running := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "running", Namespace: defaultNamespace},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	}
+	claim := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: defaultNamespace},
+		Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+	}
+
+	c := newTestClient(t)
+	c.kubeClient = fake.NewClientset(crashing, running, claim)
+
+	info := func(kind string, obj runtime.Object, name string) *resource.Info {
+		gvk := corev1.SchemeGroupVersion.WithKind(kind)
+		return &resource.Info{Object: obj, Name: name, Namespace: defaultNamespace, Mapping: &meta.RESTMapping{GroupVersionKind: gvk}}
+	}
+	health, err := c.Health(ResourceList{
+		info("Pod", &corev1.Pod{}, "crashing"),
+		info("Pod", &corev1.Pod{}, "running"),
+		info("PersistentVolumeClaim", &corev1.PersistentVolumeClaim{}, "data"),
+		info("ConfigMap", &corev1.ConfigMap{}, "config"),
+		info("Pod", &corev1.Pod{}, "deleted"),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []ResourceHealth{
+		{Kind: "Pod", Namespace: defaultNamespace, Name: "crashing", FailingPods: []string{"crashing"}},
+		{Kind: "Pod", Namespace: defaultNamespace, Name: "running", Ready: true},
+		{Kind: "PersistentVolumeClaim", Namespace: defaultNamespace, Name: "data"},
+		{Kind: "ConfigMap", Namespace: defaultNamespace, Name: "config", Ready: true},
+		{Kind: "Pod", Namespace: defaultNamespace, Name: "deleted", Missing: true},
+	}, health)
+}
+
+func TestPodFailing(t *testing.T) {
+	waiting := func(reason string) corev1.ContainerStatus {
+		return corev1.ContainerStatus{State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason}}}
+	}
+	tests := []struct {
+		name    string
+		status  corev1.PodStatus
+		failing bool
+	}{
+		{name: "running", status: corev1.PodStatus{Phase: corev1.PodRunning}},
+		{name: "failed", status: corev1.PodStatus{Phase: corev1.PodFailed}, failing: true},
+		{name: "creating", status: corev1.PodStatus{Phase: corev1.PodPending, ContainerStatuses: []corev1.ContainerStatus{waiting("ContainerCreating")}}},
+		{name: "image pull", status: corev1.PodStatus{Phase: corev1.PodPending, ContainerStatuses: []corev1.ContainerStatus{waiting("ImagePullBackOff")}}, failing: true},
+		{name: "init crash loop", status: corev1.PodStatus{Phase: corev1.PodPending, InitContainerStatuses: []corev1.ContainerStatus{waiting("CrashLoopBackOff")}}, failing: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.failing, podFailing(&corev1.Pod{Status: tt.status}))
+		})
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package kube
 
 import (
+	"context"
 	"io"
 	"time"
 
@@ -94,6 +95,15 @@ type InterfaceLogs interface {
 	OutputContainerLogsForPodList(podList *v1.PodList, namespace string, writerFunc func(namespace, pod, container string) io.Writer) error
 }
 
+// InterfaceLogStream is introduced to avoid breaking backwards compatibility for Interface implementers.
+//
+// TODO Helm 4: Remove InterfaceLogStream and integrate its method(s) into the Interface.
+type InterfaceLogStream interface {
+	// StreamPodLogs waits for a pod to start and follows the logs of its
+	// containers until they terminate or ctx is done.
+	StreamPodLogs(ctx context.Context, namespace, name string, writerFunc func(namespace, pod, container string) io.Writer) error
+}
+
 // InterfaceDeletionPropagation is introduced to avoid breaking backwards compatibility for Interface implementers.
 //
 // TODO Helm 4: Remove InterfaceDeletionPropagation and integrate its method(s) into the Interface.
@@ -124,8 +134,82 @@ type InterfaceResources interface {
 	BuildTable(reader io.Reader, validate bool) (ResourceList, error)
 }
 
+// InterfaceDryRunApply is introduced to avoid breaking backwards compatibility for Interface implementers.
+//
+// TODO Helm 4: Remove InterfaceDryRunApply and integrate its method(s) into the Interface.
+type InterfaceDryRunApply interface {
+	// DryRunApply performs a server-side apply dry run of each target
+	// resource and returns the live and the resulting objects, without
+	// persisting any change.
+	DryRunApply(target ResourceList) ([]DryRunApplyResult, error)
+}
+
+// InterfaceFieldManager is introduced to avoid breaking backwards compatibility for Interface implementers.
+//
+// TODO Helm 4: Remove InterfaceFieldManager and integrate its method(s) into the Interface.
+type InterfaceFieldManager interface {
+	// SetFieldManager sets the name of the field manager recorded in
+	// managedFields for the changes made by the client, and returns the
+	// previous one.
+	SetFieldManager(name string) (previous string)
+}
+
+// InterfaceTieredDeletion is introduced to avoid breaking backwards compatibility for Interface implementers.
+//
+// TODO Helm 4: Remove InterfaceTieredDeletion and integrate its method(s) into the Interface.
+type InterfaceTieredDeletion interface {
+	// DeleteInTiers destroys one or more resources tier by tier, dependents
+	// first, waiting with waiter, if set, for each tier to be deleted before
+	// deleting the next one.
+	DeleteInTiers(resources ResourceList, policy metav1.DeletionPropagation, waiter Waiter, timeout time.Duration) (*Result, []error)
+}
+
+// InterfaceHealth is introduced to avoid breaking backwards compatibility for Interface implementers.
+//
+// TODO Helm 4: Remove InterfaceHealth and integrate its method(s) into the Interface.
+type InterfaceHealth interface {
+	// Health checks the readiness of resources, as the wait for them does,
+	// along with their failing pods.
+	Health(resources ResourceList) ([]ResourceHealth, error)
+}
+
+// InterfaceExists is introduced to avoid breaking backwards compatibility for Interface implementers.
+//
+// TODO Helm 4: Remove InterfaceExists and integrate its method(s) into the Interface.
+type InterfaceExists interface {
+	// Exists tells whether the ConfigMap or Secret, as given by kind, named
+	// name exists in namespace.
+	Exists(kind, namespace, name string) (bool, error)
+}
+
+// InterfaceRemaining is introduced to avoid breaking backwards compatibility for Interface implementers.
+//
+// TODO Helm 4: Remove InterfaceRemaining and integrate its method(s) into the Interface.
+type InterfaceRemaining interface {
+	// Remaining returns the resources that still exist on the API server,
+	// such as deleted resources blocked on their finalizers.
+	Remaining(resources ResourceList) ([]RemainingResource, error)
+}
+
+// InterfaceWaitExpressions is introduced to avoid breaking backwards compatibility for Interface implementers.
+//
+// TODO Helm 4: Remove InterfaceWaitExpressions and integrate its method(s) into the Interface.
+type InterfaceWaitExpressions interface {
+	// WaitForExpressions waits until each of the expressions holds for the
+	// resource of namespace it names, or until timeout.
+	WaitForExpressions(namespace string, expressions []*WaitExpression, timeout time.Duration) error
+}
+
 var _ Interface = (*Client)(nil)
 var _ InterfaceThreeWayMerge = (*Client)(nil)
 var _ InterfaceLogs = (*Client)(nil)
+var _ InterfaceLogStream = (*Client)(nil)
 var _ InterfaceDeletionPropagation = (*Client)(nil)
 var _ InterfaceResources = (*Client)(nil)
+var _ InterfaceDryRunApply = (*Client)(nil)
+var _ InterfaceFieldManager = (*Client)(nil)
+var _ InterfaceTieredDeletion = (*Client)(nil)
+var _ InterfaceHealth = (*Client)(nil)
+var _ InterfaceExists = (*Client)(nil)
+var _ InterfaceRemaining = (*Client)(nil)
+var _ InterfaceWaitExpressions = (*Client)(nil)
@@ -0,0 +1,101 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v4/pkg/kube"
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	apiextv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// MergeAnno is the annotation choosing how the changes of an upgrade are
+// merged into a resource whose fields are partly managed by controllers.
+// Resources without it are updated with a three-way merge of their previous
+// manifest, their new manifest and their live object.
+const MergeAnno = "helm.sh/merge"
+
+const (
+	// MergeClient updates a resource with the changes between its previous
+	// and new manifests only, as merged on the client. Fields changed in the
+	// cluster are left as they are unless the chart changes them.
+	MergeClient = "client"
+	// MergeIgnore skips updating a resource whose manifest is unchanged,
+	// leaving the changes made in the cluster in place. Resources whose
+	// manifest changes are updated with a three-way merge.
+	MergeIgnore = "ignore"
+)
+
+// ResourceMerge returns the value of the MergeAnno annotation of obj, or ""
+// if it has none.
+func ResourceMerge(obj runtime.Object) (string, error) {
+	annotations, err := metadataAccessor.Annotations(obj)
+	if err != nil {
+		return "", nil
+	}
+	switch merge := annotations[MergeAnno]; merge {
+	case "", MergeClient, MergeIgnore:
+		return merge, nil
+	default:
+		return "", fmt.Errorf("invalid %s annotation %q: expected %q or %q", MergeAnno, merge, MergeClient, MergeIgnore)
+	}
+}
+
+// ManifestUnchanged reports whether the manifests of the original and target
+// objects of a resource are the same.
+func ManifestUnchanged(original, target runtime.Object) bool {
+	originalContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(original)
+	if err != nil {
+		return false
+	}
+	targetContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(target)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(originalContent, targetContent)
+}
+
+// createClientPatch creates the patch of the changes between the original
+// manifest of a resource and its target manifest, ignoring its live object.
+func createClientPatch(target *resource.Info, original runtime.Object) ([]byte, types.PatchType, error) {
+	oldData, err := json.Marshal(original)
+	if err != nil {
+		return nil, types.StrategicMergePatchType, fmt.Errorf("serializing current configuration: %w", err)
+	}
+	newData, err := json.Marshal(target.Object)
+	if err != nil {
+		return nil, types.StrategicMergePatchType, fmt.Errorf("serializing target configuration: %w", err)
+	}
+
+	// Strategic Merge Patch is not supported on unstructured objects, as in
+	// createPatch.
+	versionedObject := AsVersioned(target)
+	_, isUnstructured := versionedObject.(runtime.Unstructured)
+	_, isCRD := versionedObject.(*apiextv1beta1.CustomResourceDefinition)
+	if isUnstructured || isCRD {
+		patch, err := jsonpatch.CreateMergePatch(oldData, newData)
+		return patch, types.MergePatchType, err
+	}
+	patch, err := strategicpatch.CreateTwoWayMergePatch(oldData, newData, versionedObject)
+	return patch, types.StrategicMergePatchType, err
+}
@@ -0,0 +1,129 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest/fake"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+)
+
+func TestUpdateMerge(t *testing.T) {
+	original := newPodList("starfish", "otter")
+	original.Items[0].Annotations = map[string]string{MergeAnno: MergeIgnore}
+	original.Items[1].Annotations = map[string]string{MergeAnno: MergeClient}
+	target := original.DeepCopy()
+	target.Items[1].Spec.Containers[0].Ports = []v1.ContainerPort{{Name: "https", ContainerPort: 443}}
+
+	// Controllers changed the images of the live pods.
+	live := original.DeepCopy()
+	for i := range live.Items {
+		live.Items[i].Spec.Containers[0].Image = "abc/app:v5"
+	}
+
+	var actions []string
+	c := newTestClient(t)
+	c.Factory.(*cmdtesting.TestFactory).UnstructuredClient = &fake.RESTClient{
+		NegotiatedSerializer: unstructuredSerializer,
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			p, m := req.URL.Path, req.Method
+			actions = append(actions, p+":"+m)
+			switch {
+			case p == "/namespaces/default/pods/starfish" && m == http.MethodGet:
+				return newResponse(http.StatusOK, &live.Items[0])
+			case p == "/namespaces/default/pods/otter" && m == http.MethodGet:
+				return newResponse(http.StatusOK, &live.Items[1])
+			case p == "/namespaces/default/pods/otter" && m == http.MethodPatch:
+				data, err := io.ReadAll(req.Body)
+				if err != nil {
+					t.Fatalf("could not dump request: %s", err)
+				}
+				req.Body.Close()
+				// The image set by the controller is not reverted.
+				expected := `{"spec":{"$setElementOrder/containers":[{"name":"app:v4"}],"containers":[{"$setElementOrder/ports":[{"containerPort":443}],"name":"app:v4","ports":[{"containerPort":443,"name":"https"},{"$patch":"delete","containerPort":80}]}]}}`
+				if string(data) != expected {
+					t.Errorf("expected patch\n%s\ngot\n%s", expected, string(data))
+				}
+				return newResponse(http.StatusOK, &target.Items[1])
+			default:
+				t.Fatalf("unexpected request: %s %s", req.Method, req.URL.Path)
+				return nil, nil
+			}
+		}),
+	}
+	first, err := c.Build(objBody(&original), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := c.Build(objBody(target), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := c.Update(first, second, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Updated) != 2 {
+		t.Errorf("expected 2 resources updated, got %d", len(result.Updated))
+	}
+	expectedActions := []string{
+		"/namespaces/default/pods/starfish:GET",
+		"/namespaces/default/pods/starfish:GET", // refresh of the unchanged pod
+		"/namespaces/default/pods/otter:GET",
+		"/namespaces/default/pods/otter:PATCH",
+	}
+	if strings.Join(actions, ",") != strings.Join(expectedActions, ",") {
+		t.Errorf("expected requests %v, got %v", expectedActions, actions)
+	}
+
+	// Resources with an invalid merge strategy are not updated.
+	actions = nil
+	target.Items[1].Annotations[MergeAnno] = "server"
+	second, err = c.Build(objBody(target), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Update(first, second, false); err == nil || !strings.Contains(err.Error(), `invalid helm.sh/merge annotation "server"`) {
+		t.Errorf("expected an invalid annotation error, got %v", err)
+	}
+	for _, action := range actions {
+		if strings.HasSuffix(action, ":PATCH") {
+			t.Errorf("unexpected request %s", action)
+		}
+	}
+}
+
+func TestResourceMerge(t *testing.T) {
+	pod := newPod("starfish")
+	if merge, err := ResourceMerge(&pod); err != nil || merge != "" {
+		t.Errorf("expected no merge strategy, got %q, %v", merge, err)
+	}
+	pod.Annotations = map[string]string{MergeAnno: MergeClient}
+	if merge, err := ResourceMerge(&pod); err != nil || merge != MergeClient {
+		t.Errorf("expected the client merge strategy, got %q, %v", merge, err)
+	}
+	pod.Annotations[MergeAnno] = "server"
+	if _, err := ResourceMerge(&pod); err == nil || err.Error() != `invalid helm.sh/merge annotation "server": expected "client" or "ignore"` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
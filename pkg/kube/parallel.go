@@ -0,0 +1,85 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v4/pkg/kube"
+
+import (
+	"sync"
+)
+
+// InterfaceParallelism is introduced to avoid breaking backwards compatibility for Interface implementers.
+//
+// TODO Helm 4: Remove InterfaceParallelism and integrate its method(s) into the Interface.
+type InterfaceParallelism interface {
+	// SetParallelism sets the maximum number of resources created or
+	// updated at once by the client, and returns the previous one.
+	SetParallelism(n int) (previous int)
+}
+
+var _ InterfaceParallelism = (*Client)(nil)
+
+// SetParallelism sets the maximum number of resources created or updated at
+// once by Create and Update, and returns the previous one. See
+// Client.Parallelism.
+func (c *Client) SetParallelism(n int) (previous int) {
+	previous, c.Parallelism = c.Parallelism, n
+	return previous
+}
+
+// updateParallelism returns the maximum number of resources updated at once.
+func (c *Client) updateParallelism() int {
+	if c.Parallelism <= 0 {
+		return 1
+	}
+	return c.Parallelism
+}
+
+// forEachInTiers calls fn with the index of each of the resources, for at
+// most limit resources at once unless limit is zero.
+//
+// The resources are applied in tiers of consecutive resources of the same
+// kind, such as those of a manifest sorted in install order, each tier once
+// the previous one is done. No more resources are applied once stop, if set,
+// returns true.
+func forEachInTiers(resources ResourceList, limit int, fn func(int), stop func() bool) {
+	var sem chan struct{}
+	if limit > 0 {
+		sem = make(chan struct{}, limit)
+	}
+	var wg sync.WaitGroup
+	var kind string
+	for i, info := range resources {
+		if currentKind := info.Object.GetObjectKind().GroupVersionKind().Kind; i == 0 || currentKind != kind {
+			wg.Wait()
+			kind = currentKind
+		}
+		if sem != nil {
+			sem <- struct{}{}
+		}
+		if stop != nil && stop() {
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if sem != nil {
+				defer func() { <-sem }()
+			}
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
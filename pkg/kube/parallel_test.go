@@ -0,0 +1,95 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v4/pkg/kube"
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// tieredResources returns resources of the given kinds, in order.
+func tieredResources(kinds ...string) ResourceList {
+	var resources ResourceList
+	for _, kind := range kinds {
+		obj := &unstructured.Unstructured{}
+		obj.SetKind(kind)
+		resources = append(resources, &resource.Info{Object: obj})
+	}
+	return resources
+}
+
+func TestForEachInTiers(t *testing.T) {
+	resources := tieredResources("ConfigMap", "ConfigMap", "ConfigMap", "ConfigMap", "Deployment", "Deployment", "Service")
+
+	for _, limit := range []int{0, 1, 2} {
+		var mu sync.Mutex
+		var running, maxRunning int32
+		var done []string
+		forEachInTiers(resources, limit, func(i int) {
+			n := atomic.AddInt32(&running, 1)
+			mu.Lock()
+			if n > maxRunning {
+				maxRunning = n
+			}
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+
+			mu.Lock()
+			done = append(done, resources[i].Object.GetObjectKind().GroupVersionKind().Kind)
+			mu.Unlock()
+		}, nil)
+
+		assert.Equal(t, []string{"ConfigMap", "ConfigMap", "ConfigMap", "ConfigMap", "Deployment", "Deployment", "Service"}, done, "limit %d", limit)
+		if limit == 0 {
+			assert.Equal(t, int32(4), maxRunning, "a whole tier is applied at once without a limit")
+		} else {
+			assert.Equal(t, int32(limit), maxRunning)
+		}
+	}
+}
+
+func TestForEachInTiersStop(t *testing.T) {
+	resources := tieredResources("ConfigMap", "ConfigMap", "Deployment", "Service")
+
+	var failed atomic.Bool
+	var calls int32
+	forEachInTiers(resources, 1, func(i int) {
+		atomic.AddInt32(&calls, 1)
+		if i == 1 {
+			failed.Store(true)
+		}
+	}, failed.Load)
+
+	assert.Equal(t, int32(2), calls)
+}
+
+func TestSetParallelism(t *testing.T) {
+	c := newTestClient(t)
+	assert.Equal(t, 1, c.updateParallelism())
+
+	assert.Equal(t, 0, c.SetParallelism(8))
+	assert.Equal(t, 8, c.Parallelism)
+	assert.Equal(t, 8, c.updateParallelism())
+	assert.Equal(t, 8, c.SetParallelism(0))
+}
@@ -78,11 +78,16 @@ type ReadyChecker struct {
 // IsReady checks if v is ready. It supports checking readiness for pods,
 // deployments, persistent volume claims, services, daemon sets, custom
 // resource definitions, stateful sets, replication controllers, jobs (optional),
-// and replica sets. All other resource kinds are always considered ready.
+// and replica sets. All other resource kinds are always considered ready,
+// unless they list the status conditions to wait for in WaitForAnno.
 //
 // IsReady will fetch the latest state of the object from the server prior to
 // performing readiness checks, and it will return any error encountered.
 func (c *ReadyChecker) IsReady(ctx context.Context, v *resource.Info) (bool, error) {
+	if ready, err := c.conditionsReady(v); err != nil || !ready {
+		return false, err
+	}
+
 	switch value := AsVersioned(v).(type) {
 	case *corev1.Pod:
 		pod, err := c.client.CoreV1().Pods(v.Namespace).Get(ctx, v.Name, metav1.GetOptions{})
@@ -197,6 +202,28 @@ func (c *ReadyChecker) IsReady(ctx context.Context, v *resource.Info) (bool, err
 	return true, nil
 }
 
+// conditionsReady checks that the latest state of v reports the status
+// conditions listed in its WaitForAnno annotation, if any.
+func (c *ReadyChecker) conditionsReady(v *resource.Info) (bool, error) {
+	conds, err := waitForConditions(v.Object)
+	if err != nil || len(conds) == 0 {
+		return err == nil, err
+	}
+	obj, err := resource.NewHelper(v.Client, v.Mapping).Get(v.Namespace, v.Name)
+	if err != nil {
+		return false, err
+	}
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return false, err
+	}
+	if cond := unmetCondition(u, conds); cond != nil {
+		slog.Debug("waiting for condition", "namespace", v.Namespace, "name", v.Name, "kind", v.Mapping.GroupVersionKind.Kind, "condition", cond.String())
+		return false, nil
+	}
+	return true, nil
+}
+
 func (c *ReadyChecker) podsReadyForObject(ctx context.Context, namespace string, obj runtime.Object) (bool, error) {
 	pods, err := c.podsforObject(ctx, namespace, obj)
 	if err != nil {
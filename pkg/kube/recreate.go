@@ -0,0 +1,109 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v4/pkg/kube"
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// InterfaceForceRecreate is introduced to avoid breaking backwards compatibility for Interface implementers.
+//
+// TODO Helm 4: Remove InterfaceForceRecreate and integrate its method(s) into the Interface.
+type InterfaceForceRecreate interface {
+	// SetForceRecreate sets whether the client deletes and creates again
+	// the resources whose update changes an immutable field.
+	SetForceRecreate(enabled bool)
+}
+
+var _ InterfaceForceRecreate = (*Client)(nil)
+
+// recreateTimeout is how long a resource being recreated may take to be
+// deleted before it is created again.
+var recreateTimeout = time.Minute
+
+// recreatePollInterval is the interval between the checks of the deletion
+// of a resource being recreated.
+var recreatePollInterval = time.Second
+
+// immutableFieldMessages are the fragments of the messages of the API server
+// rejecting a change to an immutable field, such as the pod template of a
+// Job, the clusterIP of a Service or the volume claim templates of a
+// StatefulSet.
+var immutableFieldMessages = []string{
+	"field is immutable",
+	"may not change once set",
+	"updates to statefulset spec for fields other than",
+}
+
+// SetForceRecreate sets whether Update deletes and creates again the
+// resources whose update changes an immutable field. See
+// Client.ForceRecreate.
+func (c *Client) SetForceRecreate(enabled bool) {
+	c.ForceRecreate = enabled
+}
+
+// isImmutableFieldError tells whether err is the rejection of an update
+// changing an immutable field.
+func isImmutableFieldError(err error) bool {
+	if !apierrors.IsInvalid(err) {
+		return false
+	}
+	msg := err.Error()
+	for _, fragment := range immutableFieldMessages {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// recreate deletes the resource info and creates it again once it is gone.
+func (c *Client) recreate(info *resource.Info) error {
+	kind := info.Mapping.GroupVersionKind.Kind
+	slog.Warn("recreating resource to change an immutable field; this may cause downtime", "namespace", info.Namespace, "name", info.Name, "kind", kind)
+
+	if err := deleteResource(info, metav1.DeletePropagationBackground); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete %s %q to recreate it: %w", kind, info.Name, err)
+	}
+	helper := resource.NewHelper(info.Client, info.Mapping)
+	ctx, cancel := context.WithTimeout(context.Background(), recreateTimeout)
+	defer cancel()
+	err := wait.PollUntilContextCancel(ctx, recreatePollInterval, true, func(context.Context) (bool, error) {
+		_, err := helper.Get(info.Namespace, info.Name)
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+	if err != nil {
+		return fmt.Errorf("%s %q was not deleted within %s to be recreated: %w", kind, info.Name, recreateTimeout, err)
+	}
+	if err := createResource(info, c.fieldManager()); err != nil {
+		return fmt.Errorf("failed to recreate %s %q: %w", kind, info.Name, err)
+	}
+	slog.Warn("recreated resource", "namespace", info.Namespace, "name", info.Name, "kind", kind)
+	return nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v4/pkg/kube"
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/rest/fake"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+)
+
+func immutableFieldError(name string) *apierrors.StatusError {
+	return apierrors.NewInvalid(schema.GroupKind{Kind: "Pod"}, name, field.ErrorList{
+		field.Invalid(field.NewPath("spec", "containers"), "abc/app:v5", "field is immutable"),
+	})
+}
+
+func TestIsImmutableFieldError(t *testing.T) {
+	assert.True(t, isImmutableFieldError(immutableFieldError("starfish")))
+	assert.True(t, isImmutableFieldError(apierrors.NewInvalid(schema.GroupKind{Kind: "Service"}, "web", field.ErrorList{
+		field.Invalid(field.NewPath("spec", "clusterIPs").Index(0), []string{"None"}, "may not change once set"),
+	})))
+	assert.False(t, isImmutableFieldError(apierrors.NewInvalid(schema.GroupKind{Kind: "Pod"}, "starfish", field.ErrorList{
+		field.Required(field.NewPath("spec", "containers"), ""),
+	})))
+	assert.False(t, isImmutableFieldError(apierrors.NewNotFound(schema.GroupResource{Resource: "pods"}, "starfish")))
+	assert.False(t, isImmutableFieldError(errors.New("field is immutable")))
+}
+
+func TestUpdateForceRecreate(t *testing.T) {
+	defer func(interval time.Duration) { recreatePollInterval = interval }(recreatePollInterval)
+	recreatePollInterval = time.Millisecond
+
+	for _, forceRecreate := range []bool{false, true} {
+		listA := newPodList("starfish")
+		listB := newPodList("starfish")
+		listB.Items[0].Spec.Containers[0].Image = "abc/app:v5"
+
+		var actions []string
+		deleted := false
+		c := newTestClient(t)
+		c.SetForceRecreate(forceRecreate)
+		c.Factory.(*cmdtesting.TestFactory).UnstructuredClient = &fake.RESTClient{
+			NegotiatedSerializer: unstructuredSerializer,
+			Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+				p, m := req.URL.Path, req.Method
+				actions = append(actions, p+":"+m)
+				switch {
+				case p == "/namespaces/default/pods/starfish" && m == http.MethodGet:
+					if deleted {
+						return newResponse(http.StatusNotFound, notFoundBody())
+					}
+					return newResponse(http.StatusOK, &listA.Items[0])
+				case p == "/namespaces/default/pods/starfish" && m == http.MethodPatch:
+					return newResponse(http.StatusUnprocessableEntity, &immutableFieldError("starfish").ErrStatus)
+				case p == "/namespaces/default/pods/starfish" && m == http.MethodDelete:
+					deleted = true
+					return newResponse(http.StatusOK, &listA.Items[0])
+				case p == "/namespaces/default/pods" && m == http.MethodPost:
+					deleted = false
+					return newResponse(http.StatusCreated, &listB.Items[0])
+				default:
+					t.Fatalf("unexpected request: %s %s", req.Method, req.URL.Path)
+					return nil, nil
+				}
+			}),
+		}
+		original, err := c.Build(objBody(&listA), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		target, err := c.Build(objBody(&listB), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := c.Update(original, target, false)
+		if !forceRecreate {
+			if err == nil || !isImmutableFieldError(err) {
+				t.Fatalf("expected the immutable field error, got %v", err)
+			}
+			assert.NotContains(t, actions, "/namespaces/default/pods/starfish:DELETE")
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Len(t, result.Updated, 1)
+		assert.Empty(t, result.Created)
+		assert.Equal(t, []string{
+			"/namespaces/default/pods/starfish:GET",
+			"/namespaces/default/pods/starfish:GET",
+			"/namespaces/default/pods/starfish:PATCH",
+			"/namespaces/default/pods/starfish:DELETE",
+			"/namespaces/default/pods/starfish:GET",
+			"/namespaces/default/pods:POST",
+		}, actions)
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v4/pkg/kube"
+
+import (
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// RemainingResource describes a resource that still exists on the API server
+// after its deletion was requested.
+type RemainingResource struct {
+	Kind      string
+	Namespace string
+	Name      string
+	// Terminating tells whether the deletion of the resource has started,
+	// which is the case of a resource blocked on its finalizers.
+	Terminating bool
+	// Finalizers are the finalizers left on the resource.
+	Finalizers []string
+}
+
+func (r RemainingResource) String() string {
+	s := fmt.Sprintf("%s %q", r.Kind, r.Name)
+	if r.Namespace != "" {
+		s += fmt.Sprintf(" in namespace %q", r.Namespace)
+	}
+	switch {
+	case len(r.Finalizers) > 0:
+		s += fmt.Sprintf(" (blocked on finalizers %s)", strings.Join(r.Finalizers, ", "))
+	case r.Terminating:
+		s += " (terminating)"
+	}
+	return s
+}
+
+// Remaining returns the resources that still exist on the API server, in
+// their order, along with their finalizers.
+func (c *Client) Remaining(resources ResourceList) ([]RemainingResource, error) {
+	var remaining []RemainingResource
+	for _, info := range resources {
+		obj, err := resource.NewHelper(info.Client, info.Mapping).Get(info.Namespace, info.Name)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to get %s %q: %w", info.Mapping.GroupVersionKind.Kind, info.Name, err)
+		}
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, err
+		}
+		remaining = append(remaining, RemainingResource{
+			Kind:        info.Mapping.GroupVersionKind.Kind,
+			Namespace:   info.Namespace,
+			Name:        info.Name,
+			Terminating: accessor.GetDeletionTimestamp() != nil,
+			Finalizers:  accessor.GetFinalizers(),
+		})
+	}
+	return remaining, nil
+}
@@ -0,0 +1,63 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest/fake"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+)
+
+func TestRemaining(t *testing.T) {
+	list := newPodList("starfish", "dolphin", "whale")
+	stuck := newPod("whale")
+	now := metav1.Now()
+	stuck.DeletionTimestamp = &now
+	stuck.Finalizers = []string{"example.com/cleanup"}
+
+	c := newTestClient(t)
+	c.Factory.(*cmdtesting.TestFactory).UnstructuredClient = &fake.RESTClient{
+		NegotiatedSerializer: unstructuredSerializer,
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			path := req.URL.Path
+			switch path[strings.LastIndex(path, "/")+1:] {
+			case "starfish":
+				return newResponse(http.StatusNotFound, notFoundBody())
+			case "whale":
+				return newResponse(http.StatusOK, &stuck)
+			}
+			return newResponse(http.StatusOK, &list.Items[1])
+		}),
+	}
+
+	resources, err := c.Build(objBody(&list), false)
+	require.NoError(t, err)
+
+	remaining, err := c.Remaining(resources)
+	require.NoError(t, err)
+	assert.Equal(t, []RemainingResource{
+		{Kind: "Pod", Namespace: "default", Name: "dolphin"},
+		{Kind: "Pod", Namespace: "default", Name: "whale", Terminating: true, Finalizers: []string{"example.com/cleanup"}},
+	}, remaining)
+	assert.Equal(t, `Pod "whale" in namespace "default" (blocked on finalizers example.com/cleanup)`, remaining[1].String())
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"log/slog"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/fluxcd/cli-utils/pkg/kstatus/polling/aggregator"
@@ -53,8 +54,6 @@ func alwaysReady(_ *unstructured.Unstructured) (*status.Result, error) {
 }
 
 func (w *statusWaiter) WatchUntilReady(resourceList ResourceList, timeout time.Duration) error {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
 	slog.Debug("waiting for resources", "count", len(resourceList), "timeout", timeout)
 	sw := watcher.NewDefaultStatusWatcher(w.client, w.restMapper)
 	jobSR := helmStatusReaders.NewCustomJobStatusReader(w.restMapper)
@@ -70,26 +69,22 @@ func (w *statusWaiter) WatchUntilReady(resourceList ResourceList, timeout time.D
 		},
 	}
 	sw.StatusReader = sr
-	return w.wait(ctx, resourceList, sw)
+	return w.wait(context.Background(), resourceList, sw, timeout)
 }
 
 func (w *statusWaiter) Wait(resourceList ResourceList, timeout time.Duration) error {
-	ctx, cancel := context.WithTimeout(context.TODO(), timeout)
-	defer cancel()
 	slog.Debug("waiting for resources", "count", len(resourceList), "timeout", timeout)
 	sw := watcher.NewDefaultStatusWatcher(w.client, w.restMapper)
-	return w.wait(ctx, resourceList, sw)
+	return w.wait(context.TODO(), resourceList, sw, timeout)
 }
 
 func (w *statusWaiter) WaitWithJobs(resourceList ResourceList, timeout time.Duration) error {
-	ctx, cancel := context.WithTimeout(context.TODO(), timeout)
-	defer cancel()
 	slog.Debug("waiting for resources", "count", len(resourceList), "timeout", timeout)
 	sw := watcher.NewDefaultStatusWatcher(w.client, w.restMapper)
 	newCustomJobStatusReader := helmStatusReaders.NewCustomJobStatusReader(w.restMapper)
 	customSR := statusreaders.NewStatusReader(w.restMapper, newCustomJobStatusReader)
 	sw.StatusReader = customSR
-	return w.wait(ctx, resourceList, sw)
+	return w.wait(context.TODO(), resourceList, sw, timeout)
 }
 
 func (w *statusWaiter) WaitForDelete(resourceList ResourceList, timeout time.Duration) error {
@@ -136,10 +131,15 @@ func (w *statusWaiter) waitForDelete(ctx context.Context, resourceList ResourceL
 	return nil
 }
 
-func (w *statusWaiter) wait(ctx context.Context, resourceList ResourceList, sw watcher.StatusWatcher) error {
-	cancelCtx, cancel := context.WithCancel(ctx)
-	defer cancel()
+// wait waits for the resources to be current until timeout, or the timeout
+// set by TimeoutAnno for the resources annotated with it.
+func (w *statusWaiter) wait(ctx context.Context, resourceList ResourceList, sw watcher.StatusWatcher, timeout time.Duration) error {
+	start := time.Now()
 	resources := []object.ObjMetadata{}
+	conditions := map[object.ObjMetadata][]waitForCondition{}
+	timeouts := map[object.ObjMetadata]time.Duration{}
+	annotated := map[object.ObjMetadata]bool{}
+	longest := timeout
 	for _, resource := range resourceList {
 		switch value := AsVersioned(resource).(type) {
 		case *appsv1.Deployment:
@@ -152,28 +152,95 @@ func (w *statusWaiter) wait(ctx context.Context, resourceList ResourceList, sw w
 			return err
 		}
 		resources = append(resources, obj)
+
+		conds, err := waitForConditions(resource.Object)
+		if err != nil {
+			return fmt.Errorf("%s %q: %w", obj.GroupKind.Kind, obj.Name, err)
+		}
+		if len(conds) > 0 {
+			conditions[obj] = conds
+		}
+
+		t, ok, err := resourceTimeout(resource.Object, timeout)
+		if err != nil {
+			return fmt.Errorf("%s %q: %w", obj.GroupKind.Kind, obj.Name, err)
+		}
+		timeouts[obj] = t
+		annotated[obj] = ok
+		longest = max(longest, t)
+	}
+	// The wait lasts until the latest deadline, while the resources with an
+	// earlier deadline are checked on time.
+	ctx, cancelTimeout := context.WithTimeout(ctx, longest)
+	defer cancelTimeout()
+	cancelCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if dsw, ok := sw.(*watcher.DefaultStatusWatcher); ok && len(conditions) > 0 {
+		dsw.StatusReader = newWaitForStatusReader(w.restMapper, dsw.StatusReader, conditions)
 	}
 
 	eventCh := sw.Watch(cancelCtx, resources, watcher.Options{})
 	statusCollector := collector.NewResourceStatusCollector(resources)
 	done := statusCollector.ListenWithObserver(eventCh, statusObserver(cancel, status.CurrentStatus))
+
+	var mu sync.Mutex
+	expired := map[object.ObjMetadata]bool{}
+	for id, t := range timeouts {
+		if t >= longest {
+			continue
+		}
+		timer := time.AfterFunc(t, func() {
+			for _, rs := range statusCollector.LatestObservation().ResourceStatuses {
+				if rs.Identifier == id && rs.Status == status.CurrentStatus {
+					return
+				}
+			}
+			mu.Lock()
+			expired[id] = true
+			mu.Unlock()
+			cancel()
+		})
+		defer timer.Stop()
+	}
 	<-done
 
 	if statusCollector.Error != nil {
 		return statusCollector.Error
 	}
 
-	// Only check parent context error, otherwise we would error when desired status is achieved.
-	if ctx.Err() != nil {
+	mu.Lock()
+	defer mu.Unlock()
+	// Only check parent context error or expired resources, otherwise we
+	// would error when desired status is achieved.
+	if ctx.Err() != nil || len(expired) > 0 {
 		errs := []error{}
+		// The recent events of the resources not ready tell why they are
+		// not, such as an image that cannot be pulled.
+		events := newResourceEvents(dynamicEventLister(w.client), dynamicOwnerLister(w.client))
 		for _, id := range resources {
 			rs := statusCollector.ResourceStatuses[id]
 			if rs.Status == status.CurrentStatus {
 				continue
 			}
-			errs = append(errs, fmt.Errorf("resource not ready, name: %s, kind: %s, status: %s", rs.Identifier.Name, rs.Identifier.GroupKind.Kind, rs.Status))
+			var err error
+			switch {
+			case !expired[id] && ctx.Err() == nil:
+				// The resource has time left, the wait failed on another.
+				continue
+			case annotated[id]:
+				err = resourceTimeoutError(rs.Identifier.Name, rs.Identifier.GroupKind.Kind, rs.Status.String(), timeouts[id], start.Add(timeouts[id]))
+			case len(conditions[id]) > 0 && rs.Message != "":
+				err = fmt.Errorf("resource not ready, name: %s, kind: %s, status: %s, %s", rs.Identifier.Name, rs.Identifier.GroupKind.Kind, rs.Status, rs.Message)
+			default:
+				err = fmt.Errorf("resource not ready, name: %s, kind: %s, status: %s", rs.Identifier.Name, rs.Identifier.GroupKind.Kind, rs.Status)
+			}
+			errs = append(errs, events.wrap(err, rs.Identifier.Namespace, rs.Identifier.GroupKind.Kind, rs.Identifier.Name))
+		}
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+		} else {
+			errs = append(errs, context.DeadlineExceeded)
 		}
-		errs = append(errs, ctx.Err())
 		return errors.Join(errs...)
 	}
 	return nil
@@ -209,7 +276,7 @@ func statusObserver(cancel context.CancelFunc, desired status.Status) collector.
 				return nonDesiredResources[i].Identifier.Name < nonDesiredResources[j].Identifier.Name
 			})
 			first := nonDesiredResources[0]
-			slog.Debug("waiting for resource", "name", first.Identifier.Name, "kind", first.Identifier.GroupKind.Kind, "expectedStatus", desired, "actualStatus", first.Status)
+			slog.Debug("waiting for resource", "name", first.Identifier.Name, "kind", first.Identifier.GroupKind.Kind, "expectedStatus", desired, "actualStatus", first.Status, "message", first.Message)
 		}
 	}
 }
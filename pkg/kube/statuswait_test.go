@@ -32,6 +32,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/cli-runtime/pkg/resource"
 	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/kubectl/pkg/scheme"
 )
@@ -448,3 +449,210 @@ func TestWatchForReady(t *testing.T) {
 		})
 	}
 }
+
+var databaseReadyManifest = `
+apiVersion: example.com/v1
+kind: Database
+metadata:
+  name: ready-db
+  namespace: ns
+  generation: 2
+  annotations:
+    helm.sh/wait-for: condition=Ready
+status:
+  conditions:
+  - type: Ready
+    status: "True"
+    observedGeneration: 2
+`
+
+var databaseNotReadyManifest = `
+apiVersion: example.com/v1
+kind: Database
+metadata:
+  name: db
+  namespace: ns
+  annotations:
+    helm.sh/wait-for: condition=Ready,condition=Degraded=False
+status:
+  conditions:
+  - type: Ready
+    status: "True"
+  - type: Degraded
+    status: "True"
+`
+
+var databaseUnannotatedManifest = `
+apiVersion: example.com/v1
+kind: Database
+metadata:
+  name: plain-db
+  namespace: ns
+`
+
+func TestStatusWaitForConditions(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		objManifests []string
+		expectErrs   []error
+	}{
+		{
+			name:         "annotated conditions are met",
+			objManifests: []string{databaseReadyManifest},
+		},
+		{
+			name:         "annotated condition is not met",
+			objManifests: []string{databaseNotReadyManifest, databaseReadyManifest},
+			expectErrs:   []error{errors.New("resource not ready, name: db, kind: Database, status: InProgress, waiting for condition Degraded=False"), errors.New("context deadline exceeded")},
+		},
+		{
+			name:         "resources without the annotation are unaffected",
+			objManifests: []string{databaseUnannotatedManifest},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			gvk := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Database"}
+			fakeMapper := testutil.NewFakeRESTMapper(gvk)
+			gvr := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "databases"}
+			fakeClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(scheme.Scheme, map[schema.GroupVersionResource]string{gvr: "DatabaseList"})
+			statusWaiter := statusWaiter{
+				client:     fakeClient,
+				restMapper: fakeMapper,
+			}
+			resourceList := ResourceList{}
+			for _, obj := range getRuntimeObjFromManifests(t, tt.objManifests) {
+				u := obj.(*unstructured.Unstructured)
+				err := fakeClient.Tracker().Create(getGVR(t, fakeMapper, u), u, u.GetNamespace())
+				assert.NoError(t, err)
+				resourceList = append(resourceList, &resource.Info{Name: u.GetName(), Namespace: u.GetNamespace(), Object: u})
+			}
+			err := statusWaiter.Wait(resourceList, time.Second*3)
+			if tt.expectErrs != nil {
+				assert.EqualError(t, err, errors.Join(tt.expectErrs...).Error())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+var jobSlowManifest = `
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: slow
+  namespace: default
+  generation: 1
+  annotations:
+    helm.sh/timeout: 1s
+`
+
+var jobCompleteTimeoutManifest = `
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: quick
+  namespace: default
+  generation: 1
+  annotations:
+    helm.sh/timeout: 1s
+status:
+  succeeded: 1
+  active: 0
+  conditions:
+  - type: Complete
+    status: "True"
+`
+
+var jobLongTimeoutManifest = `
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: long
+  namespace: default
+  generation: 1
+  annotations:
+    helm.sh/timeout: 10m
+`
+
+var jobInvalidTimeoutManifest = `
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: invalid
+  namespace: default
+  generation: 1
+  annotations:
+    helm.sh/timeout: soon
+`
+
+func TestWaitForJobTimeoutAnnotation(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		objManifests []string
+		timeout      time.Duration
+		expectErr    string
+	}{
+		{
+			name:         "annotated job completes in time",
+			objManifests: []string{jobCompleteTimeoutManifest, jobCompleteManifest},
+		},
+		{
+			name:         "annotated job times out before the wait",
+			objManifests: []string{jobSlowManifest, jobCompleteManifest},
+			expectErr:    "resource not ready, name: slow, kind: Job, status: InProgress: helm.sh/timeout timeout of 1s exceeded at ",
+		},
+		{
+			name:         "unannotated job times out before an annotated one",
+			objManifests: []string{jobLongTimeoutManifest, jobNoStatusManifest},
+			timeout:      time.Second,
+			expectErr:    "resource not ready, name: test, kind: Job, status: InProgress",
+		},
+		{
+			name:         "invalid annotation",
+			objManifests: []string{jobInvalidTimeoutManifest},
+			expectErr:    `Job "invalid": invalid helm.sh/timeout annotation "soon": expected a positive duration such as 30m`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := newTestClient(t)
+			fakeClient := dynamicfake.NewSimpleDynamicClient(scheme.Scheme)
+			fakeMapper := testutil.NewFakeRESTMapper(
+				batchv1.SchemeGroupVersion.WithKind("Job"),
+			)
+			statusWaiter := statusWaiter{
+				client:     fakeClient,
+				restMapper: fakeMapper,
+			}
+			objs := getRuntimeObjFromManifests(t, tt.objManifests)
+			for _, obj := range objs {
+				u := obj.(*unstructured.Unstructured)
+				gvr := getGVR(t, fakeMapper, u)
+				err := fakeClient.Tracker().Create(gvr, u, u.GetNamespace())
+				assert.NoError(t, err)
+			}
+			resourceList := getResourceListFromRuntimeObjs(t, c, objs)
+			timeout := time.Minute
+			if tt.timeout != 0 {
+				timeout = tt.timeout
+			}
+			start := time.Now()
+			err := statusWaiter.WaitWithJobs(resourceList, timeout)
+			assert.Less(t, time.Since(start), 30*time.Second)
+			if tt.expectErr != "" {
+				assert.ErrorContains(t, err, tt.expectErr)
+				assert.NotContains(t, err.Error(), "name: long,")
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
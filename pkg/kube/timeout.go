@@ -0,0 +1,49 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v4/pkg/kube"
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// TimeoutAnno is the annotation overriding the timeout of the wait for a
+// resource, such as a slow Job, with a duration like "30m". Other resources
+// keep the timeout given to the wait.
+const TimeoutAnno = "helm.sh/timeout"
+
+// resourceTimeout returns the timeout of the wait for obj: the duration of
+// its TimeoutAnno annotation if any, or timeout.
+func resourceTimeout(obj runtime.Object, timeout time.Duration) (time.Duration, bool, error) {
+	annotations, err := metadataAccessor.Annotations(obj)
+	if err != nil || annotations[TimeoutAnno] == "" {
+		return timeout, false, nil
+	}
+	d, err := time.ParseDuration(annotations[TimeoutAnno])
+	if err != nil || d <= 0 {
+		return timeout, false, fmt.Errorf("invalid %s annotation %q: expected a positive duration such as 30m", TimeoutAnno, annotations[TimeoutAnno])
+	}
+	return d, true, nil
+}
+
+// resourceTimeoutError is the error reported for a resource that is not
+// ready by the deadline set with TimeoutAnno.
+func resourceTimeoutError(name, kind, status string, timeout time.Duration, deadline time.Time) error {
+	return fmt.Errorf("resource not ready, name: %s, kind: %s, status: %s: %s timeout of %s exceeded at %s", name, kind, status, TimeoutAnno, timeout, deadline.Format(time.RFC3339))
+}
@@ -67,7 +67,19 @@ func (hw *legacyWaiter) WaitWithJobs(resources ResourceList, timeout time.Durati
 func (hw *legacyWaiter) waitForResources(created ResourceList, timeout time.Duration) error {
 	slog.Debug("beginning wait for resources", "count", len(created), "timeout", timeout)
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	start := time.Now()
+	timeouts := make([]time.Duration, len(created))
+	longest := timeout
+	for i, v := range created {
+		t, _, err := resourceTimeout(v.Object, timeout)
+		if err != nil {
+			return fmt.Errorf("%s %q: %w", v.Mapping.GroupVersionKind.Kind, v.Name, err)
+		}
+		timeouts[i] = t
+		longest = max(longest, t)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), longest)
 	defer cancel()
 
 	numberOfErrors := make([]int, len(created))
@@ -75,8 +87,12 @@ func (hw *legacyWaiter) waitForResources(created ResourceList, timeout time.Dura
 		numberOfErrors[i] = 0
 	}
 
-	return wait.PollUntilContextCancel(ctx, 2*time.Second, true, func(ctx context.Context) (bool, error) {
+	// notReady is the last resource found not ready, whose recent events
+	// tell why the wait failed.
+	var notReady *resource.Info
+	err := wait.PollUntilContextCancel(ctx, 2*time.Second, true, func(ctx context.Context) (bool, error) {
 		waitRetries := 30
+		notReady = nil
 		for i, v := range created {
 			ready, err := hw.c.IsReady(ctx, v)
 
@@ -91,11 +107,20 @@ func (hw *legacyWaiter) waitForResources(created ResourceList, timeout time.Dura
 			}
 			numberOfErrors[i] = 0
 			if !ready {
+				notReady = v
+				if err == nil && timeouts[i] < longest && time.Since(start) >= timeouts[i] {
+					return false, resourceTimeoutError(v.Name, v.Mapping.GroupVersionKind.Kind, "NotReady", timeouts[i], start.Add(timeouts[i]))
+				}
 				return false, err
 			}
 		}
 		return true, nil
 	})
+	if err != nil && notReady != nil {
+		events := newResourceEvents(clientsetEventLister(hw.kubeClient), clientsetOwnerLister(hw.kubeClient))
+		return events.wrap(err, notReady.Namespace, notReady.Mapping.GroupVersionKind.Kind, notReady.Name)
+	}
+	return err
 }
 
 func (hw *legacyWaiter) isRetryableError(err error, resource *resource.Info) bool {
@@ -199,7 +224,11 @@ func SelectorsForObject(object runtime.Object) (selector labels.Selector, err er
 
 func (hw *legacyWaiter) watchTimeout(t time.Duration) func(*resource.Info) error {
 	return func(info *resource.Info) error {
-		return hw.watchUntilReady(t, info)
+		timeout, _, err := resourceTimeout(info.Object, t)
+		if err != nil {
+			return fmt.Errorf("%s %q: %w", info.Mapping.GroupVersionKind.Kind, info.Name, err)
+		}
+		return hw.watchUntilReady(timeout, info)
 	}
 }
 
@@ -224,6 +253,12 @@ func (hw *legacyWaiter) WatchUntilReady(resources ResourceList, timeout time.Dur
 }
 
 func perform(infos ResourceList, fn func(*resource.Info) error) error {
+	return performLimited(infos, 0, fn)
+}
+
+// performLimited is perform, calling fn for at most limit resources at once
+// unless limit is zero.
+func performLimited(infos ResourceList, limit int, fn func(*resource.Info) error) error {
 	var result error
 
 	if len(infos) == 0 {
@@ -231,7 +266,7 @@ func perform(infos ResourceList, fn func(*resource.Info) error) error {
 	}
 
 	errs := make(chan error)
-	go batchPerform(infos, fn, errs)
+	go batchPerform(infos, limit, fn, errs)
 
 	for range infos {
 		err := <-errs
@@ -0,0 +1,324 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v4/pkg/kube"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// waitExpressionInterval is the interval at which the resources named by
+// wait expressions are checked.
+var waitExpressionInterval = 2 * time.Second
+
+// waitExpressionOperators are the comparison operators of wait expressions,
+// the longer first.
+var waitExpressionOperators = []string{"==", "!=", ">=", "<=", "=", ">", "<"}
+
+// WaitExpression is a condition on a field of a resource, of the form
+// "<kind>/<name>:<path><operator><value>", as in
+// "deployment/web:.status.availableReplicas>=3".
+//
+// The kind is a kind or resource name as accepted by kubectl, such as
+// "deployment", "Deployment" or "deployments.apps". The path is a JSONPath
+// expression, with or without braces, such as
+// `.status.conditions[?(@.type=="Ready")].status`. The operator is one of
+// ==, =, !=, >=, <=, > and <. Values that are numbers are compared as such,
+// and the others as strings, with == and != only. A path finding several
+// values holds when each of them does, and a path finding none does not.
+type WaitExpression struct {
+	// Expression is the expression as given.
+	Expression string
+	Resource   string
+	Name       string
+	Path       string
+	Operator   string
+	Value      string
+
+	// path is the parsed Path.
+	path *jsonpath.JSONPath
+}
+
+func (e *WaitExpression) String() string {
+	return e.Expression
+}
+
+// ParseWaitExpression parses a WaitExpression.
+func ParseWaitExpression(s string) (*WaitExpression, error) {
+	e := &WaitExpression{Expression: s}
+	invalid := func(reason string) error {
+		return fmt.Errorf("invalid wait expression %q: %s", s, reason)
+	}
+
+	target, condition, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, invalid("expected <kind>/<name>:<path><operator><value>")
+	}
+	if e.Resource, e.Name, ok = strings.Cut(strings.TrimSpace(target), "/"); !ok || e.Resource == "" || e.Name == "" {
+		return nil, invalid("expected the resource as <kind>/<name>")
+	}
+
+	i, op := findOperator(condition)
+	if i < 0 {
+		return nil, invalid("expected a comparison with one of " + strings.Join(waitExpressionOperators, ", "))
+	}
+	e.Path = strings.TrimSpace(condition[:i])
+	e.Operator = op
+	if e.Operator == "=" {
+		e.Operator = "=="
+	}
+	e.Value = strings.TrimSpace(condition[i+len(op):])
+	if unquoted, err := strconv.Unquote(e.Value); err == nil {
+		e.Value = unquoted
+	}
+	if e.Path == "" {
+		return nil, invalid("expected a JSONPath expression before the operator")
+	}
+
+	if _, err := e.jsonPath(); err != nil {
+		return nil, invalid(err.Error())
+	}
+	return e, nil
+}
+
+// jsonPath returns the parsed JSONPath expression of Path.
+func (e *WaitExpression) jsonPath() (*jsonpath.JSONPath, error) {
+	if e.path != nil {
+		return e.path, nil
+	}
+	template := e.Path
+	if !strings.HasPrefix(template, "{") {
+		template = "{" + template + "}"
+	}
+	path := jsonpath.New(e.Expression).AllowMissingKeys(true)
+	if err := path.Parse(template); err != nil {
+		return nil, err
+	}
+	e.path = path
+	return path, nil
+}
+
+// findOperator returns the index of the first comparison operator of
+// condition outside of the brackets, parentheses and quotes of its path,
+// and the operator, or -1.
+func findOperator(condition string) (int, string) {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(condition); i++ {
+		c := condition[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+			continue
+		case c == '"' || c == '\'':
+			quote = c
+			continue
+		case c == '[' || c == '(' || c == '{':
+			depth++
+			continue
+		case c == ']' || c == ')' || c == '}':
+			depth--
+			continue
+		}
+		if depth > 0 {
+			continue
+		}
+		for _, op := range waitExpressionOperators {
+			if strings.HasPrefix(condition[i:], op) {
+				return i, op
+			}
+		}
+	}
+	return -1, ""
+}
+
+// evaluate tells whether the expression holds for obj, along with the value
+// observed.
+func (e *WaitExpression) evaluate(obj map[string]interface{}) (bool, string) {
+	path, err := e.jsonPath()
+	if err != nil {
+		return false, "error: " + err.Error()
+	}
+	results, err := path.FindResults(obj)
+	if err != nil {
+		return false, "error: " + err.Error()
+	}
+	var values []string
+	for _, result := range results {
+		for _, v := range result {
+			values = append(values, formatWaitValue(v.Interface()))
+		}
+	}
+	if len(values) == 0 {
+		return false, "<none>"
+	}
+	met := true
+	for _, v := range values {
+		met = met && compareWaitValue(v, e.Operator, e.Value)
+	}
+	return met, strings.Join(values, ",")
+}
+
+func formatWaitValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		var b bytes.Buffer
+		if err := json.NewEncoder(&b).Encode(v); err == nil {
+			return strings.TrimSpace(b.String())
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+// compareWaitValue compares the value observed to the value wanted.
+func compareWaitValue(observed, op, wanted string) bool {
+	o, oErr := strconv.ParseFloat(observed, 64)
+	w, wErr := strconv.ParseFloat(wanted, 64)
+	if oErr != nil || wErr != nil {
+		switch op {
+		case "==":
+			return observed == wanted
+		case "!=":
+			return observed != wanted
+		}
+		return false
+	}
+	switch op {
+	case "==":
+		return o == w
+	case "!=":
+		return o != w
+	case ">=":
+		return o >= w
+	case "<=":
+		return o <= w
+	case ">":
+		return o > w
+	case "<":
+		return o < w
+	}
+	return false
+}
+
+// WaitForExpressions waits until each of the expressions holds for the
+// resource of namespace it names, or until timeout. On timeout, the error
+// lists the expressions that do not hold with the values last observed.
+func (c *Client) WaitForExpressions(namespace string, expressions []*WaitExpression, timeout time.Duration) error {
+	dynamicClient, err := c.Factory.DynamicClient()
+	if err != nil {
+		return err
+	}
+	cs, err := c.getKubeClient()
+	if err != nil {
+		return err
+	}
+	if namespace == "" {
+		namespace = c.namespace()
+	}
+	// The kinds are resolved as kubectl does, including their short names.
+	discoveryClient := memory.NewMemCacheClient(cs.Discovery())
+	deferred := restmapper.NewDeferredDiscoveryRESTMapper(discoveryClient)
+	mapper := restmapper.NewShortcutExpander(deferred, discoveryClient, nil)
+
+	get := func(ctx context.Context, e *WaitExpression) (*unstructured.Unstructured, error) {
+		gvr, err := mapper.ResourceFor(schema.ParseGroupResource(e.Resource).WithVersion(""))
+		if meta.IsNoMatchError(err) {
+			// The kind may be defined by a CRD created since the discovery.
+			deferred.Reset()
+		}
+		if err != nil {
+			return nil, err
+		}
+		gvk, err := mapper.KindFor(gvr)
+		if err != nil {
+			return nil, err
+		}
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, err
+		}
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			return dynamicClient.Resource(gvr).Namespace(namespace).Get(ctx, e.Name, metav1.GetOptions{})
+		}
+		return dynamicClient.Resource(gvr).Get(ctx, e.Name, metav1.GetOptions{})
+	}
+	return waitForExpressions(expressions, get, timeout)
+}
+
+// waitForExpressions polls the resources named by the expressions with get
+// until all of them hold in the same poll, or until timeout. Each poll
+// checks every expression again, as one that held may no longer hold.
+func waitForExpressions(expressions []*WaitExpression, get func(context.Context, *WaitExpression) (*unstructured.Unstructured, error), timeout time.Duration) error {
+	slog.Debug("waiting for expressions", "count", len(expressions), "timeout", timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	observed := make([]string, len(expressions))
+	met := make([]bool, len(expressions))
+	err := wait.PollUntilContextCancel(ctx, waitExpressionInterval, true, func(ctx context.Context) (bool, error) {
+		done := true
+		for i, e := range expressions {
+			met[i] = false
+			obj, err := get(ctx, e)
+			switch {
+			case apierrors.IsNotFound(err):
+				observed[i] = "<not found>"
+			case err != nil:
+				observed[i] = "error: " + err.Error()
+			default:
+				met[i], observed[i] = e.evaluate(obj.Object)
+			}
+			if !met[i] {
+				slog.Debug("wait expression not met", "expression", e.String(), "observed", observed[i])
+				done = false
+			}
+		}
+		return done, nil
+	})
+	if err == nil {
+		return nil
+	}
+
+	var errs []error
+	for i, e := range expressions {
+		if !met[i] {
+			errs = append(errs, fmt.Errorf("wait expression %s not met, last observed value: %s", e, observed[i]))
+		}
+	}
+	return errors.Join(append(errs, err)...)
+}
@@ -0,0 +1,175 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v4/pkg/kube"
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestParseWaitExpression(t *testing.T) {
+	tests := []struct {
+		expr                 string
+		resource, name, path string
+		operator, value      string
+		wantErr              string
+	}{
+		{expr: "deployment/web:.status.availableReplicas>=3", resource: "deployment", name: "web", path: ".status.availableReplicas", operator: ">=", value: "3"},
+		{expr: `deployments.apps/web: {.status.conditions[?(@.type=="Available")].status} = "True"`, resource: "deployments.apps", name: "web", path: `{.status.conditions[?(@.type=="Available")].status}`, operator: "==", value: "True"},
+		{expr: "Job/migrate:.status.succeeded!=0", resource: "Job", name: "migrate", path: ".status.succeeded", operator: "!=", value: "0"},
+		{expr: "sts/db:.status.readyReplicas<2", resource: "sts", name: "db", path: ".status.readyReplicas", operator: "<", value: "2"},
+		{expr: "deployment/web", wantErr: "expected <kind>/<name>:<path><operator><value>"},
+		{expr: "web:.status.replicas>1", wantErr: "expected the resource as <kind>/<name>"},
+		{expr: "deployment/web:.status.replicas", wantErr: "expected a comparison"},
+		{expr: "deployment/web:>=3", wantErr: "expected a JSONPath expression"},
+		{expr: "deployment/web:.status[.replicas>=3", wantErr: "expected a comparison"},
+		{expr: "deployment/web:.status..[(>=3", wantErr: "invalid wait expression"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			e, err := ParseWaitExpression(tt.expr)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expr, e.String())
+			assert.Equal(t, []string{tt.resource, tt.name, tt.path, tt.operator, tt.value}, []string{e.Resource, e.Name, e.Path, e.Operator, e.Value})
+		})
+	}
+}
+
+func TestWaitExpressionEvaluate(t *testing.T) {
+	obj := map[string]interface{}{
+		"status": map[string]interface{}{
+			"availableReplicas": int64(2),
+			"phase":             "Running",
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Available", "status": "True"},
+				map[string]interface{}{"type": "Progressing", "status": "False"},
+			},
+		},
+	}
+	tests := []struct {
+		expr     string
+		met      bool
+		observed string
+	}{
+		{expr: "deployment/web:.status.availableReplicas>=2", met: true, observed: "2"},
+		{expr: "deployment/web:.status.availableReplicas>2", met: false, observed: "2"},
+		{expr: "deployment/web:.status.availableReplicas==2.0", met: true, observed: "2"},
+		{expr: "deployment/web:.status.phase==Running", met: true, observed: "Running"},
+		{expr: "deployment/web:.status.phase!=Running", met: false, observed: "Running"},
+		{expr: "deployment/web:.status.phase>=Running", met: false, observed: "Running"},
+		{expr: `deployment/web:.status.conditions[?(@.type=="Available")].status==True`, met: true, observed: "True"},
+		{expr: "deployment/web:.status.conditions[*].status==True", met: false, observed: "True,False"},
+		{expr: "deployment/web:.status.readyReplicas>=1", met: false, observed: "<none>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			e, err := ParseWaitExpression(tt.expr)
+			require.NoError(t, err)
+			met, observed := e.evaluate(obj)
+			assert.Equal(t, tt.met, met)
+			assert.Equal(t, tt.observed, observed)
+		})
+	}
+}
+
+func TestWaitForExpressions(t *testing.T) {
+	interval := waitExpressionInterval
+	waitExpressionInterval = 10 * time.Millisecond
+	t.Cleanup(func() { waitExpressionInterval = interval })
+
+	available, err := ParseWaitExpression("deployment/web:.status.availableReplicas>=3")
+	require.NoError(t, err)
+	succeeded, err := ParseWaitExpression("job/migrate:.status.succeeded==1")
+	require.NoError(t, err)
+
+	var gets int
+	replicas := int64(0)
+	get := func(_ context.Context, e *WaitExpression) (*unstructured.Unstructured, error) {
+		gets++
+		switch e.Name {
+		case "web":
+			replicas++
+			return &unstructured.Unstructured{Object: map[string]interface{}{
+				"status": map[string]interface{}{"availableReplicas": replicas},
+			}}, nil
+		case "migrate":
+			return nil, apierrors.NewNotFound(schema.GroupResource{Group: "batch", Resource: "jobs"}, "migrate")
+		}
+		return nil, errors.New("unexpected resource")
+	}
+
+	require.NoError(t, waitForExpressions([]*WaitExpression{available}, get, time.Second))
+	assert.Equal(t, int64(3), replicas)
+
+	// An expression met is checked again on each poll.
+	replicas, gets = 0, 0
+	err = waitForExpressions([]*WaitExpression{available, succeeded}, get, 200*time.Millisecond)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	msg := err.Error()
+	assert.True(t, strings.HasPrefix(msg, "wait expression job/migrate:.status.succeeded==1 not met, last observed value: <not found>\n"), msg)
+	assert.NotContains(t, msg, "deployment/web")
+	assert.Greater(t, replicas, int64(3))
+	assert.Equal(t, int(2*replicas), gets)
+}
+
+func TestWaitForExpressionsSamePoll(t *testing.T) {
+	interval := waitExpressionInterval
+	waitExpressionInterval = 10 * time.Millisecond
+	t.Cleanup(func() { waitExpressionInterval = interval })
+
+	web, err := ParseWaitExpression("deployment/web:.status.phase==Ready")
+	require.NoError(t, err)
+	db, err := ParseWaitExpression("statefulset/db:.status.phase==Ready")
+	require.NoError(t, err)
+
+	// The expressions hold in turns, but never in the same poll.
+	var polls int
+	get := func(_ context.Context, e *WaitExpression) (*unstructured.Unstructured, error) {
+		phase := "Pending"
+		if e.Name == "web" {
+			polls++
+			if polls%2 == 1 {
+				phase = "Ready"
+			}
+		} else if polls%2 == 0 {
+			phase = "Ready"
+		}
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"status": map[string]interface{}{"phase": phase},
+		}}, nil
+	}
+
+	err = waitForExpressions([]*WaitExpression{web, db}, get, 200*time.Millisecond)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Contains(t, err.Error(), "last observed value: Pending")
+	assert.Greater(t, polls, 2)
+}
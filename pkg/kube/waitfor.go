@@ -0,0 +1,164 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube // import "helm.sh/helm/v4/pkg/kube"
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fluxcd/cli-utils/pkg/kstatus/polling/engine"
+	"github.com/fluxcd/cli-utils/pkg/kstatus/polling/event"
+	"github.com/fluxcd/cli-utils/pkg/kstatus/polling/statusreaders"
+	"github.com/fluxcd/cli-utils/pkg/kstatus/status"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// WaitForAnno is the annotation listing the status conditions a resource must
+// report before it is considered ready when waiting.
+//
+// The value is a comma separated list of conditions of the form
+// "condition=<type>" or "condition=<type>=<status>", for example
+// "condition=Ready" or "condition=Synced,condition=Degraded=False". The status
+// defaults to "True". This lets Helm wait for custom resources whose readiness
+// it does not otherwise understand.
+const WaitForAnno = "helm.sh/wait-for"
+
+// waitForCondition is a status condition required by WaitForAnno.
+type waitForCondition struct {
+	Type   string
+	Status string
+}
+
+func (c waitForCondition) String() string {
+	return c.Type + "=" + c.Status
+}
+
+// parseWaitFor parses the value of WaitForAnno.
+func parseWaitFor(value string) ([]waitForCondition, error) {
+	var conds []waitForCondition
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kind, spec, ok := strings.Cut(entry, "=")
+		if !ok || kind != "condition" || spec == "" {
+			return nil, fmt.Errorf("invalid %s entry %q: expected condition=<type>[=<status>]", WaitForAnno, entry)
+		}
+		cond := waitForCondition{Status: "True"}
+		cond.Type, cond.Status, ok = strings.Cut(spec, "=")
+		if !ok {
+			cond.Status = "True"
+		}
+		if cond.Type == "" || cond.Status == "" {
+			return nil, fmt.Errorf("invalid %s entry %q: expected condition=<type>[=<status>]", WaitForAnno, entry)
+		}
+		conds = append(conds, cond)
+	}
+	return conds, nil
+}
+
+// waitForConditions returns the conditions obj is annotated to wait for, if
+// any.
+func waitForConditions(obj runtime.Object) ([]waitForCondition, error) {
+	annotations, err := metadataAccessor.Annotations(obj)
+	if err != nil || annotations[WaitForAnno] == "" {
+		return nil, nil
+	}
+	return parseWaitFor(annotations[WaitForAnno])
+}
+
+// unmetCondition returns the first of conds that obj does not report in its
+// .status.conditions, or nil if all of them are met. Conditions observed for
+// an older generation of obj are not met.
+func unmetCondition(obj map[string]interface{}, conds []waitForCondition) *waitForCondition {
+	generation, _, _ := unstructured.NestedInt64(obj, "metadata", "generation")
+	list, _, _ := unstructured.NestedSlice(obj, "status", "conditions")
+
+	for i, want := range conds {
+		met := false
+		for _, item := range list {
+			c, ok := item.(map[string]interface{})
+			if !ok || c["type"] != want.Type {
+				continue
+			}
+			observed, found, _ := unstructured.NestedInt64(c, "observedGeneration")
+			stale := found && generation > 0 && observed < generation
+			met = !stale && strings.EqualFold(fmt.Sprint(c["status"]), want.Status)
+			break
+		}
+		if !met {
+			return &conds[i]
+		}
+	}
+	return nil
+}
+
+// waitForStatusReader computes the status of the resources annotated with
+// WaitForAnno from their conditions, and delegates to another reader for all
+// other resources.
+type waitForStatusReader struct {
+	delegate   engine.StatusReader
+	annotated  engine.StatusReader
+	conditions map[object.ObjMetadata][]waitForCondition
+}
+
+var _ engine.StatusReader = &waitForStatusReader{}
+
+func newWaitForStatusReader(mapper meta.RESTMapper, delegate engine.StatusReader, conditions map[object.ObjMetadata][]waitForCondition) *waitForStatusReader {
+	return &waitForStatusReader{
+		delegate: delegate,
+		annotated: statusreaders.NewGenericStatusReader(mapper, func(u *unstructured.Unstructured) (*status.Result, error) {
+			if cond := unmetCondition(u.Object, conditions[object.UnstructuredToObjMetadata(u)]); cond != nil {
+				return &status.Result{
+					Status:  status.InProgressStatus,
+					Message: fmt.Sprintf("waiting for condition %s", cond),
+				}, nil
+			}
+			return &status.Result{Status: status.CurrentStatus, Message: "Resource is current"}, nil
+		}),
+		conditions: conditions,
+	}
+}
+
+func (r *waitForStatusReader) Supports(gk schema.GroupKind) bool {
+	for id := range r.conditions {
+		if id.GroupKind == gk {
+			return true
+		}
+	}
+	return r.delegate.Supports(gk)
+}
+
+func (r *waitForStatusReader) ReadStatus(ctx context.Context, reader engine.ClusterReader, id object.ObjMetadata) (*event.ResourceStatus, error) {
+	if _, ok := r.conditions[id]; ok {
+		return r.annotated.ReadStatus(ctx, reader, id)
+	}
+	return r.delegate.ReadStatus(ctx, reader, id)
+}
+
+func (r *waitForStatusReader) ReadStatusForObject(ctx context.Context, reader engine.ClusterReader, u *unstructured.Unstructured) (*event.ResourceStatus, error) {
+	if _, ok := r.conditions[object.UnstructuredToObjMetadata(u)]; ok {
+		return r.annotated.ReadStatusForObject(ctx, reader, u)
+	}
+	return r.delegate.ReadStatusForObject(ctx, reader, u)
+}
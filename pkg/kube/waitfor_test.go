@@ -0,0 +1,115 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWaitFor(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []waitForCondition
+		wantErr bool
+	}{
+		{value: "condition=Ready", want: []waitForCondition{{Type: "Ready", Status: "True"}}},
+		{
+			value: "condition=Synced, condition=Degraded=False",
+			want:  []waitForCondition{{Type: "Synced", Status: "True"}, {Type: "Degraded", Status: "False"}},
+		},
+		{value: "", want: nil},
+		{value: "Ready", wantErr: true},
+		{value: "jsonpath=.status.phase", wantErr: true},
+		{value: "condition=", wantErr: true},
+		{value: "condition=Ready=", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseWaitFor(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestUnmetCondition(t *testing.T) {
+	obj := func(generation int64, conditions ...interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"metadata": map[string]interface{}{"generation": generation},
+			"status":   map[string]interface{}{"conditions": conditions},
+		}
+	}
+	ready := []waitForCondition{{Type: "Ready", Status: "True"}}
+
+	tests := []struct {
+		name  string
+		obj   map[string]interface{}
+		conds []waitForCondition
+		want  *waitForCondition
+	}{
+		{
+			name:  "met",
+			obj:   obj(1, map[string]interface{}{"type": "Ready", "status": "True"}),
+			conds: ready,
+		},
+		{
+			name:  "status is case insensitive",
+			obj:   obj(1, map[string]interface{}{"type": "Ready", "status": "true"}),
+			conds: ready,
+		},
+		{
+			name:  "wrong status",
+			obj:   obj(1, map[string]interface{}{"type": "Ready", "status": "False"}),
+			conds: ready,
+			want:  &ready[0],
+		},
+		{
+			name:  "missing condition",
+			obj:   obj(1, map[string]interface{}{"type": "Synced", "status": "True"}),
+			conds: ready,
+			want:  &ready[0],
+		},
+		{
+			name:  "no status",
+			obj:   map[string]interface{}{},
+			conds: ready,
+			want:  &ready[0],
+		},
+		{
+			name:  "observed an older generation",
+			obj:   obj(2, map[string]interface{}{"type": "Ready", "status": "True", "observedGeneration": int64(1)}),
+			conds: ready,
+			want:  &ready[0],
+		},
+		{
+			name:  "observed the current generation",
+			obj:   obj(2, map[string]interface{}{"type": "Ready", "status": "True", "observedGeneration": int64(2)}),
+			conds: ready,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, unmetCondition(tt.obj, tt.conds))
+		})
+	}
+}
@@ -27,6 +27,9 @@ import (
 type linterOptions struct {
 	KubeVersion          *chartutil.KubeVersion
 	SkipSchemaValidation bool
+	SkipDeprecations     bool
+	SkipValuesReferences bool
+	Policy               *rules.Policy
 }
 
 type LinterOption func(lo *linterOptions)
@@ -43,6 +46,25 @@ func WithSkipSchemaValidation(skipSchemaValidation bool) LinterOption {
 	}
 }
 
+func WithSkipDeprecations(skipDeprecations bool) LinterOption {
+	return func(lo *linterOptions) {
+		lo.SkipDeprecations = skipDeprecations
+	}
+}
+
+func WithSkipValuesReferences(skipValuesReferences bool) LinterOption {
+	return func(lo *linterOptions) {
+		lo.SkipValuesReferences = skipValuesReferences
+	}
+}
+
+// WithPolicy enforces policy on the chart when it is not nil.
+func WithPolicy(policy *rules.Policy) LinterOption {
+	return func(lo *linterOptions) {
+		lo.Policy = policy
+	}
+}
+
 func RunAll(baseDir string, values map[string]interface{}, namespace string, options ...LinterOption) support.Linter {
 
 	chartDir, _ := filepath.Abs(baseDir)
@@ -56,10 +78,41 @@ func RunAll(baseDir string, values map[string]interface{}, namespace string, opt
 		ChartDir: chartDir,
 	}
 
-	rules.Chartfile(&result)
-	rules.ValuesWithOverrides(&result, values)
-	rules.TemplatesWithSkipSchemaValidation(&result, values, namespace, lo.KubeVersion, lo.SkipSchemaValidation)
-	rules.Dependencies(&result)
+	// Schema violations are reported by the dedicated ValuesSchema rule, which
+	// covers subcharts too, rather than by the values and templates rules.
+	runRule(&result, "Chartfile", rules.Chartfile)
+	runRule(&result, "Values", func(l *support.Linter) {
+		rules.ValuesWithSkipSchemaValidation(l, values, true)
+	})
+	if !lo.SkipSchemaValidation {
+		runRule(&result, "ValuesSchema", func(l *support.Linter) {
+			rules.ValuesSchema(l, values)
+		})
+	}
+	runRule(&result, "Templates", func(l *support.Linter) {
+		rules.TemplatesWithSkipDeprecations(l, values, namespace, lo.KubeVersion, true, lo.SkipDeprecations)
+	})
+	if !lo.SkipValuesReferences {
+		runRule(&result, "ValuesReferences", func(l *support.Linter) {
+			rules.ValuesReferences(l, values)
+		})
+	}
+	runRule(&result, "Dependencies", rules.Dependencies)
+	if lo.Policy != nil {
+		runRule(&result, "ChartPolicy", func(l *support.Linter) {
+			rules.ChartPolicy(l, lo.Policy)
+		})
+	}
 
 	return result
 }
+
+// runRule runs a lint rule, recording id, the name of the rule, in the
+// messages it reports.
+func runRule(linter *support.Linter, id string, rule func(*support.Linter)) {
+	n := len(linter.Messages)
+	rule(linter)
+	for i := n; i < len(linter.Messages); i++ {
+		linter.Messages[i].Rule = id
+	}
+}
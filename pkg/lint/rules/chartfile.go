@@ -67,6 +67,19 @@ func Chartfile(linter *support.Linter) {
 	linter.RunLinterRule(support.ErrorSev, chartFileName, validateChartIconURL(chartFile))
 	linter.RunLinterRule(support.ErrorSev, chartFileName, validateChartType(chartFile))
 	linter.RunLinterRule(support.ErrorSev, chartFileName, validateChartDependencies(chartFile))
+	linter.RunLinterRule(support.ErrorSev, chartFileName, validateChartYamlSchema(chartPath))
+}
+
+// validateChartYamlSchema validates Chart.yaml against chartutil.ChartfileSchema.
+// Unknown fields are not reported, as the strict parsing of the file already
+// warns about them.
+func validateChartYamlSchema(chartPath string) error {
+	data, err := os.ReadFile(chartPath)
+	if err != nil {
+		return err
+	}
+	_, err = chartutil.ValidateChartfile(data)
+	return err
 }
 
 func validateChartVersionType(data map[string]interface{}) error {
@@ -216,7 +216,7 @@ func TestChartfile(t *testing.T) {
 		linter := support.Linter{ChartDir: badChartDir}
 		Chartfile(&linter)
 		msgs := linter.Messages
-		expectedNumberOfErrorMessages := 6
+		expectedNumberOfErrorMessages := 7
 
 		if len(msgs) != expectedNumberOfErrorMessages {
 			t.Errorf("Expected %d errors, got %d", expectedNumberOfErrorMessages, len(msgs))
@@ -246,13 +246,17 @@ func TestChartfile(t *testing.T) {
 		if !strings.Contains(msgs[5].Err.Error(), "dependencies are not valid in the Chart file with apiVersion") {
 			t.Errorf("Unexpected message 5: %s", msgs[5].Err)
 		}
+
+		if !strings.Contains(msgs[6].Err.Error(), "- /: missing property 'name'") {
+			t.Errorf("Unexpected message 6: %s", msgs[6].Err)
+		}
 	})
 
 	t.Run("Chart.yaml validity issues due to type mismatch", func(t *testing.T) {
 		linter := support.Linter{ChartDir: anotherBadChartDir}
 		Chartfile(&linter)
 		msgs := linter.Messages
-		expectedNumberOfErrorMessages := 3
+		expectedNumberOfErrorMessages := 4
 
 		if len(msgs) != expectedNumberOfErrorMessages {
 			t.Errorf("Expected %d errors, got %d", expectedNumberOfErrorMessages, len(msgs))
@@ -270,5 +274,30 @@ func TestChartfile(t *testing.T) {
 		if !strings.Contains(msgs[2].Err.Error(), "appVersion should be of type string") {
 			t.Errorf("Unexpected message 2: %s", msgs[2].Err)
 		}
+
+		// The schema violations are aggregated in a single message.
+		if !strings.Contains(msgs[3].Err.Error(), "- /version: got number, want string") || !strings.Contains(msgs[3].Err.Error(), "- /appVersion: got number, want string") {
+			t.Errorf("Unexpected message 3: %s", msgs[3].Err)
+		}
+	})
+
+	t.Run("Chart.yaml schema issues", func(t *testing.T) {
+		chartDir := t.TempDir()
+		data := "apiVersion: v2\nname: foo\nversion: 0.1.0\nicon: https://example.com/icon.png\ntype: plugin\n"
+		if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		linter := support.Linter{ChartDir: chartDir}
+		Chartfile(&linter)
+		msgs := linter.Messages
+
+		if len(msgs) == 0 {
+			t.Fatal("Expected errors, got none")
+		}
+
+		last := msgs[len(msgs)-1]
+		if last.Severity != support.ErrorSev || !strings.Contains(last.Err.Error(), "- /type:") {
+			t.Errorf("Unexpected message: %s", last.Err)
+		}
 	})
 }
@@ -36,6 +36,33 @@ var (
 	k8sVersionMinor = "20"
 )
 
+// removedAPI describes an API version that has been removed from Kubernetes
+// and is no longer known to the client libraries Helm is built with.
+type removedAPI struct {
+	deprecatedIn [2]int
+	removedIn    [2]int
+	replacement  string
+}
+
+// removedAPIs complements the deprecation information of the types registered
+// with client-go. It lists the APIs that client-go no longer carries, such as
+// those served by the API extensions and aggregation layers, so that charts
+// using them are still flagged.
+var removedAPIs = map[schema.GroupVersionKind]removedAPI{
+	{Group: "extensions", Version: "v1beta1", Kind: "PodSecurityPolicy"}: {
+		deprecatedIn: [2]int{1, 11}, removedIn: [2]int{1, 16}, replacement: "policy/v1beta1 PodSecurityPolicy",
+	},
+	{Group: "policy", Version: "v1beta1", Kind: "PodSecurityPolicy"}: {
+		deprecatedIn: [2]int{1, 21}, removedIn: [2]int{1, 25},
+	},
+	{Group: "apiextensions.k8s.io", Version: "v1beta1", Kind: "CustomResourceDefinition"}: {
+		deprecatedIn: [2]int{1, 16}, removedIn: [2]int{1, 22}, replacement: "apiextensions.k8s.io/v1 CustomResourceDefinition",
+	},
+	{Group: "apiregistration.k8s.io", Version: "v1beta1", Kind: "APIService"}: {
+		deprecatedIn: [2]int{1, 19}, removedIn: [2]int{1, 22}, replacement: "apiregistration.k8s.io/v1 APIService",
+	},
+}
+
 // deprecatedAPIError indicates than an API is deprecated in Kubernetes
 type deprecatedAPIError struct {
 	Deprecated string
@@ -64,21 +91,21 @@ func validateNoDeprecations(resource *K8sYamlStruct, kubeVersion *chartutil.Kube
 		minorVersion = kubeVersion.Minor
 	}
 
-	runtimeObject, err := resourceToRuntimeObject(resource)
+	major, err := strconv.Atoi(majorVersion)
 	if err != nil {
-		// do not error for non-kubernetes resources
-		if runtime.IsNotRegisteredError(err) {
-			return nil
-		}
 		return err
 	}
-
-	major, err := strconv.Atoi(majorVersion)
+	minor, err := strconv.Atoi(minorVersion)
 	if err != nil {
 		return err
 	}
-	minor, err := strconv.Atoi(minorVersion)
+
+	runtimeObject, err := resourceToRuntimeObject(resource)
 	if err != nil {
+		// do not error for non-kubernetes resources
+		if runtime.IsNotRegisteredError(err) {
+			return validateNotRemoved(resource, major, minor)
+		}
 		return err
 	}
 
@@ -92,6 +119,31 @@ func validateNoDeprecations(resource *K8sYamlStruct, kubeVersion *chartutil.Kube
 	}
 }
 
+// validateNotRemoved checks resource against the APIs that are missing from
+// client-go because they have been removed from Kubernetes.
+func validateNotRemoved(resource *K8sYamlStruct, major, minor int) error {
+	gvk := schema.FromAPIVersionAndKind(resource.APIVersion, resource.Kind)
+	api, ok := removedAPIs[gvk]
+	if !ok || !versionAtLeast(major, minor, api.deprecatedIn) {
+		return nil
+	}
+
+	name := fmt.Sprintf("%s %s", resource.APIVersion, resource.Kind)
+	msg := fmt.Sprintf("%s is deprecated in v%d.%d+, unavailable in v%d.%d+", name,
+		api.deprecatedIn[0], api.deprecatedIn[1], api.removedIn[0], api.removedIn[1])
+	if api.replacement != "" {
+		msg += "; use " + api.replacement
+	}
+	return deprecatedAPIError{
+		Deprecated: name,
+		Message:    msg,
+	}
+}
+
+func versionAtLeast(major, minor int, version [2]int) bool {
+	return major > version[0] || (major == version[0] && minor >= version[1])
+}
+
 func resourceToRuntimeObject(resource *K8sYamlStruct) (runtime.Object, error) {
 	scheme := runtime.NewScheme()
 	kscheme.AddToScheme(scheme)
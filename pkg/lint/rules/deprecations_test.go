@@ -16,7 +16,11 @@ limitations under the License.
 
 package rules // import "helm.sh/helm/v4/pkg/lint/rules"
 
-import "testing"
+import (
+	"testing"
+
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+)
 
 func TestValidateNoDeprecations(t *testing.T) {
 	deprecated := &K8sYamlStruct{
@@ -39,3 +43,40 @@ func TestValidateNoDeprecations(t *testing.T) {
 		t.Errorf("Expected a v1 Pod to not be deprecated")
 	}
 }
+
+func TestValidateNoDeprecationsRemovedAPIs(t *testing.T) {
+	crd := &K8sYamlStruct{
+		APIVersion: "apiextensions.k8s.io/v1beta1",
+		Kind:       "CustomResourceDefinition",
+	}
+
+	err := validateNoDeprecations(crd, &chartutil.KubeVersion{Major: "1", Minor: "22"})
+	if err == nil {
+		t.Fatal("Expected removed CustomResourceDefinition API to be flagged")
+	}
+	expected := "apiextensions.k8s.io/v1beta1 CustomResourceDefinition is deprecated in v1.16+, unavailable in v1.22+; use apiextensions.k8s.io/v1 CustomResourceDefinition"
+	if msg := err.(deprecatedAPIError).Message; msg != expected {
+		t.Errorf("Expected message %q, got %q", expected, msg)
+	}
+
+	if err := validateNoDeprecations(crd, &chartutil.KubeVersion{Major: "1", Minor: "15"}); err != nil {
+		t.Errorf("Expected CustomResourceDefinition API to not be deprecated in v1.15, got %v", err)
+	}
+
+	psp := &K8sYamlStruct{
+		APIVersion: "policy/v1beta1",
+		Kind:       "PodSecurityPolicy",
+	}
+	err = validateNoDeprecations(psp, &chartutil.KubeVersion{Major: "1", Minor: "25"})
+	expected = "policy/v1beta1 PodSecurityPolicy is deprecated in v1.21+, unavailable in v1.25+"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected error %q, got %v", expected, err)
+	}
+
+	if err := validateNoDeprecations(&K8sYamlStruct{
+		APIVersion: "example.com/v1",
+		Kind:       "Widget",
+	}, nil); err != nil {
+		t.Errorf("Expected custom resources to not be flagged, got %v", err)
+	}
+}
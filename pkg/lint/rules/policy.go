@@ -0,0 +1,109 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rules // import "helm.sh/helm/v4/pkg/lint/rules"
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"sigs.k8s.io/yaml"
+
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	"helm.sh/helm/v4/pkg/lint/support"
+)
+
+// Policy lists the metadata an organization requires charts to carry, as
+// loaded from a policy file such as:
+//
+//	annotations:
+//	- name: org.example/owner
+//	- name: org.example/tier
+//	  pattern: "frontend|backend"
+type Policy struct {
+	// Annotations are the annotations required in Chart.yaml.
+	Annotations []RequiredAnnotation `json:"annotations,omitempty"`
+}
+
+// RequiredAnnotation is an annotation required by a Policy.
+type RequiredAnnotation struct {
+	Name string `json:"name"`
+	// Pattern is a regular expression the whole value of the annotation
+	// must match, if set.
+	Pattern string `json:"pattern,omitempty"`
+}
+
+func (a RequiredAnnotation) compile() (*regexp.Regexp, error) {
+	if a.Pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile("^(?:" + a.Pattern + ")$")
+}
+
+// LoadPolicy loads the policy file at path. Keys that are not part of a
+// policy are rejected, so that misspelled ones do not go unnoticed.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	policy := &Policy{}
+	if err := yaml.UnmarshalStrict(data, policy); err != nil {
+		return nil, fmt.Errorf("invalid lint policy %s: %w", path, err)
+	}
+	for i, a := range policy.Annotations {
+		if a.Name == "" {
+			return nil, fmt.Errorf("invalid lint policy %s: annotation %d has no name", path, i+1)
+		}
+		if _, err := a.compile(); err != nil {
+			return nil, fmt.Errorf("invalid lint policy %s: pattern of annotation %q: %w", path, a.Name, err)
+		}
+	}
+	return policy, nil
+}
+
+// ChartPolicy runs the linter rules enforcing policy on the Chart.yaml file.
+func ChartPolicy(linter *support.Linter, policy *Policy) {
+	chartFileName := "Chart.yaml"
+	chartFile, err := chartutil.LoadChartfile(filepath.Join(linter.ChartDir, chartFileName))
+	// Unparsable Chart.yaml files are reported by the Chartfile rules.
+	if err != nil {
+		return
+	}
+	for _, a := range policy.Annotations {
+		linter.RunLinterRule(support.ErrorSev, chartFileName, validateRequiredAnnotation(chartFile.Annotations, a))
+	}
+}
+
+func validateRequiredAnnotation(annotations map[string]string, a RequiredAnnotation) error {
+	value, ok := annotations[a.Name]
+	if !ok {
+		return fmt.Errorf("annotation %q is required by the lint policy", a.Name)
+	}
+	if value == "" {
+		return fmt.Errorf("annotation %q required by the lint policy is empty", a.Name)
+	}
+	pattern, err := a.compile()
+	if err != nil {
+		return fmt.Errorf("invalid pattern of annotation %q in the lint policy: %w", a.Name, err)
+	}
+	if pattern != nil && !pattern.MatchString(value) {
+		return fmt.Errorf("annotation %q has value %q, which does not match the pattern %q required by the lint policy", a.Name, value, a.Pattern)
+	}
+	return nil
+}
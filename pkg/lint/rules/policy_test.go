@@ -0,0 +1,105 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v4/pkg/lint/support"
+)
+
+func writePolicy(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPolicy(t *testing.T) {
+	policy, err := LoadPolicy(writePolicy(t, "annotations:\n- name: org.example/owner\n- name: org.example/tier\n  pattern: frontend|backend\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(policy.Annotations) != 2 || policy.Annotations[1].Pattern != "frontend|backend" {
+		t.Errorf("unexpected policy loaded: %+v", policy)
+	}
+
+	tests := []struct {
+		policy string
+		expect string
+	}{
+		{policy: "anotations:\n- name: org.example/owner\n", expect: `unknown field "anotations"`},
+		{policy: "annotations:\n- name: org.example/owner\n  patern: x\n", expect: `unknown field "patern"`},
+		{policy: "annotations:\n- pattern: x\n", expect: "annotation 1 has no name"},
+		{policy: "annotations:\n- name: org.example/owner\n  pattern: \"(\"\n", expect: `pattern of annotation "org.example/owner"`},
+	}
+	for _, tt := range tests {
+		_, err := LoadPolicy(writePolicy(t, tt.policy))
+		if err == nil || !strings.Contains(err.Error(), tt.expect) {
+			t.Errorf("expected error containing %q, got %v", tt.expect, err)
+		}
+	}
+
+	if _, err := LoadPolicy(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected an error loading a missing policy")
+	}
+}
+
+func TestChartPolicy(t *testing.T) {
+	chartDir := t.TempDir()
+	chartfile := `apiVersion: v2
+name: policy
+version: 0.1.0
+annotations:
+  org.example/owner: platform
+  org.example/tier: database
+  org.example/team: ""
+`
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte(chartfile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	policy := &Policy{Annotations: []RequiredAnnotation{
+		{Name: "org.example/owner"},
+		{Name: "org.example/tier", Pattern: "frontend|backend"},
+		{Name: "org.example/team"},
+		{Name: "org.example/cost-center"},
+		{Name: "org.example/owner", Pattern: "plat"},
+	}}
+
+	linter := support.Linter{ChartDir: chartDir}
+	ChartPolicy(&linter, policy)
+
+	expected := []string{
+		`annotation "org.example/tier" has value "database", which does not match the pattern "frontend|backend" required by the lint policy`,
+		`annotation "org.example/team" required by the lint policy is empty`,
+		`annotation "org.example/cost-center" is required by the lint policy`,
+		`annotation "org.example/owner" has value "platform", which does not match the pattern "plat" required by the lint policy`,
+	}
+	if len(linter.Messages) != len(expected) {
+		t.Fatalf("expected %d messages, got %v", len(expected), linter.Messages)
+	}
+	for i, msg := range linter.Messages {
+		if msg.Severity != support.ErrorSev || msg.Path != "Chart.yaml" || msg.Err.Error() != expected[i] {
+			t.Errorf("unexpected message %d: %s", i, msg)
+		}
+	}
+}
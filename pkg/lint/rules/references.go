@@ -0,0 +1,364 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rules
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"strings"
+	"text/template/parse"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	"helm.sh/helm/v4/pkg/chart/v2/loader"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	"helm.sh/helm/v4/pkg/lint/support"
+)
+
+// optionalValueFuncs are the template functions whose arguments are expected
+// to be missing at times. References to values within a pipeline calling one
+// of them are not reported.
+var optionalValueFuncs = map[string]bool{
+	"coalesce": true,
+	"default":  true,
+	"empty":    true,
+	"hasKey":   true,
+	"required": true,
+}
+
+// ValuesReferences statically checks the templates of the chart, and of its
+// enabled subcharts, for references to values that have no default.
+//
+// A reference such as .Values.image.tag is reported when the path cannot be
+// found in the coalesced defaults of the chart and its subcharts. Values with
+// a scalar, list, null or empty map default may have arbitrary content, so
+// paths below them are not reported. The analysis follows with, range and
+// variables well enough to resolve the common patterns; anything it cannot
+// resolve is ignored, as are references to global values and references
+// guarded with functions such as default or required.
+func ValuesReferences(linter *support.Linter, valueOverrides map[string]interface{}) {
+	chrt, err := loader.Load(linter.ChartDir)
+	if err != nil {
+		// Charts that fail to load are reported by the other rules.
+		return
+	}
+
+	// The overrides only decide which subcharts are enabled; references are
+	// checked against the defaults.
+	if err := chartutil.ProcessDependencies(chrt, valueOverrides); err != nil {
+		return
+	}
+	vals, err := chartutil.CoalesceValues(chrt, nil)
+	if err != nil {
+		return
+	}
+
+	validateChartReferences(linter, chrt, vals, "")
+}
+
+// validateChartReferences checks the templates of chrt against vals, then
+// those of each subchart against its own values. dir is the location of chrt
+// relative to the linted chart.
+func validateChartReferences(linter *support.Linter, chrt *chart.Chart, vals map[string]interface{}, dir string) {
+	// The templates of library charts are included by other charts, and
+	// evaluated with their values.
+	if chrt.Metadata != nil && strings.EqualFold(chrt.Metadata.Type, "library") {
+		return
+	}
+	for _, tmpl := range chrt.Templates {
+		file := path.Join(dir, tmpl.Name)
+		for _, err := range validateTemplateReferences(tmpl.Name, string(tmpl.Data), vals) {
+			linter.RunLinterRule(support.WarningSev, file, err)
+		}
+	}
+
+	for _, sub := range chrt.Dependencies() {
+		subVals, _ := vals[sub.Name()].(map[string]interface{})
+		validateChartReferences(linter, sub, subVals, path.Join(dir, "charts", sub.Name()))
+	}
+}
+
+// validateTemplateReferences returns an error for every distinct reference in
+// the template to a value missing from vals. Templates that fail to parse are
+// skipped; parse errors are reported when the templates are rendered.
+func validateTemplateReferences(name, text string, vals map[string]interface{}) []error {
+	tree := parse.New(name)
+	tree.Mode = parse.SkipFuncCheck
+	trees := map[string]*parse.Tree{}
+	if _, err := tree.Parse(text, "", "", trees); err != nil {
+		return nil
+	}
+
+	c := referenceChecker{vals: vals, seen: map[string]bool{}}
+	// Defined templates are assumed to be included with the top-level
+	// context, as they are by convention.
+	for _, t := range trees {
+		if t.Root != nil {
+			c.tree = t
+			c.walk(t.Root, referenceScope{dot: rootRef(), vars: map[string]valueRef{"$": rootRef()}})
+		}
+	}
+	return c.errs
+}
+
+// valueRef describes what a template expression evaluates to, as far as the
+// values are concerned.
+type valueRef struct {
+	// root is set for the top-level template context.
+	root bool
+	// values is set for a location within .Values, given by path.
+	values bool
+	path   []string
+}
+
+func rootRef() valueRef { return valueRef{root: true} }
+
+// field returns the reference obtained by accessing fields of r.
+func (r valueRef) field(fields ...string) valueRef {
+	if len(fields) == 0 {
+		return r
+	}
+	switch {
+	case r.root && fields[0] == "Values":
+		return valueRef{values: true, path: fields[1:]}
+	case r.values:
+		return valueRef{values: true, path: append(append([]string{}, r.path...), fields...)}
+	}
+	return valueRef{}
+}
+
+type referenceScope struct {
+	dot  valueRef
+	vars map[string]valueRef
+	// guarded holds the paths without a default tested by the enclosing if
+	// and with actions. Values at or below them are optional by construction.
+	guarded map[string]bool
+}
+
+func (s referenceScope) with(dot valueRef) referenceScope {
+	return referenceScope{dot: dot, vars: maps.Clone(s.vars), guarded: maps.Clone(s.guarded)}
+}
+
+func (s referenceScope) isGuarded(path []string) bool {
+	for i := range path {
+		if s.guarded[strings.Join(path[:i+1], ".")] {
+			return true
+		}
+	}
+	return false
+}
+
+type referenceChecker struct {
+	vals map[string]interface{}
+	tree *parse.Tree
+	seen map[string]bool
+	errs []error
+	// conditions collects the references in the pipeline of an if or with
+	// action while it is being checked.
+	conditions *[]valueRef
+}
+
+func (c *referenceChecker) walk(node parse.Node, scope referenceScope) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			c.walk(child, scope)
+		}
+	case *parse.ActionNode:
+		c.pipe(n.Pipe, scope, false)
+	case *parse.TemplateNode:
+		c.pipe(n.Pipe, scope, false)
+	case *parse.IfNode:
+		inner := scope.with(scope.dot)
+		c.condition(n.Pipe, &inner)
+		c.walk(n.List, inner)
+		c.walk(n.ElseList, scope.with(scope.dot))
+	case *parse.WithNode:
+		inner := scope.with(scope.dot)
+		inner.dot = c.known(c.condition(n.Pipe, &inner))
+		c.walk(n.List, inner)
+		c.walk(n.ElseList, scope.with(scope.dot))
+	case *parse.RangeNode:
+		inner := scope.with(scope.dot)
+		c.pipe(n.Pipe, inner, false)
+		// The elements being ranged over, and the variables bound to them,
+		// are not tracked.
+		inner.dot = valueRef{}
+		for _, v := range n.Pipe.Decl {
+			inner.vars[v.Ident[0]] = valueRef{}
+		}
+		c.walk(n.List, inner)
+		c.walk(n.ElseList, scope.with(scope.dot))
+	}
+}
+
+// condition checks the pipeline of an if or with action. Testing a value is
+// the idiomatic way of handling an optional one, so references in the
+// pipeline to values without a default are not reported; they are guarded
+// within the action instead.
+func (c *referenceChecker) condition(p *parse.PipeNode, scope *referenceScope) valueRef {
+	var refs []valueRef
+	c.conditions = &refs
+	result := c.pipe(p, *scope, true)
+	c.conditions = nil
+
+	if scope.guarded == nil {
+		scope.guarded = map[string]bool{}
+	}
+	for _, ref := range refs {
+		if ref.values && len(ref.path) > 0 && !hasDefault(c.vals, ref.path) {
+			scope.guarded[strings.Join(ref.path, ".")] = true
+		}
+	}
+	return result
+}
+
+// pipe checks the references in a pipeline, binds the variables it declares,
+// and returns what the pipeline evaluates to.
+func (c *referenceChecker) pipe(p *parse.PipeNode, scope referenceScope, optional bool) valueRef {
+	if p == nil {
+		return scope.dot
+	}
+	for _, cmd := range p.Cmds {
+		if len(cmd.Args) > 0 {
+			if id, ok := cmd.Args[0].(*parse.IdentifierNode); ok && optionalValueFuncs[id.Ident] {
+				optional = true
+			}
+		}
+	}
+
+	var result valueRef
+	for _, cmd := range p.Cmds {
+		result = c.command(cmd, scope, optional)
+	}
+	if len(p.Cmds) > 1 {
+		result = valueRef{}
+	}
+
+	for _, v := range p.Decl {
+		scope.vars[v.Ident[0]] = c.known(result)
+	}
+	return result
+}
+
+// command checks the references in the arguments of a command and returns
+// what it evaluates to.
+func (c *referenceChecker) command(cmd *parse.CommandNode, scope referenceScope, optional bool) valueRef {
+	refs := make([]valueRef, len(cmd.Args))
+	isIndex := false
+	for i, arg := range cmd.Args {
+		switch a := arg.(type) {
+		case *parse.IdentifierNode:
+			isIndex = i == 0 && a.Ident == "index"
+		case *parse.DotNode:
+			refs[i] = scope.dot
+		case *parse.FieldNode:
+			refs[i] = scope.dot.field(a.Ident...)
+		case *parse.VariableNode:
+			refs[i] = scope.vars[a.Ident[0]].field(a.Ident[1:]...)
+		case *parse.PipeNode:
+			refs[i] = c.pipe(a, scope.with(scope.dot), optional)
+		case *parse.ChainNode:
+			if p, ok := a.Node.(*parse.PipeNode); ok {
+				refs[i] = c.pipe(p, scope.with(scope.dot), optional).field(a.Field...)
+			}
+		}
+		// The target of index is checked together with the keys below.
+		if !(isIndex && i == 1) {
+			c.use(arg, refs[i], scope, optional)
+		}
+	}
+
+	if isIndex && len(cmd.Args) > 1 {
+		ref := refs[1]
+		for _, arg := range cmd.Args[2:] {
+			key, ok := arg.(*parse.StringNode)
+			if !ok {
+				// Dynamic keys cannot be resolved.
+				c.use(cmd.Args[1], ref, scope, optional)
+				return valueRef{}
+			}
+			ref = ref.field(key.Text)
+		}
+		c.use(cmd.Args[1], ref, scope, optional)
+		return ref
+	}
+
+	if len(cmd.Args) == 1 {
+		return refs[0]
+	}
+	return valueRef{}
+}
+
+// known returns ref, or an untracked reference if ref is a location within
+// .Values without a default. This avoids reporting every access below a value
+// that has already been reported.
+func (c *referenceChecker) known(ref valueRef) valueRef {
+	if ref.values && !hasDefault(c.vals, ref.path) {
+		return valueRef{}
+	}
+	return ref
+}
+
+// use records an error if ref, found at node, is a location within .Values
+// that has no default and is neither optional nor guarded.
+func (c *referenceChecker) use(node parse.Node, ref valueRef, scope referenceScope, optional bool) {
+	if c.conditions != nil {
+		*c.conditions = append(*c.conditions, ref)
+	}
+	if optional || !ref.values || len(ref.path) == 0 || ref.path[0] == "global" || scope.isGuarded(ref.path) || hasDefault(c.vals, ref.path) {
+		return
+	}
+	name := ".Values." + strings.Join(ref.path, ".")
+	if c.seen[name] {
+		return
+	}
+	c.seen[name] = true
+	// The location has the form "name:line:col".
+	location, _ := c.tree.ErrorContext(node)
+	parts := strings.Split(location, ":")
+	c.errs = append(c.errs, fmt.Errorf("line %s: %s is not defined in the chart's values", parts[len(parts)-2], name))
+}
+
+// hasDefault reports whether vals has a default for the value at path, or a
+// default that leaves the content of that path open.
+func hasDefault(vals map[string]interface{}, path []string) bool {
+	var cur interface{} = vals
+	for i, key := range path {
+		m, ok := asMap(cur)
+		if !ok || (i > 0 && len(m) == 0) {
+			return true
+		}
+		if cur, ok = m[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func asMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case chartutil.Values:
+		return m, true
+	}
+	return nil, false
+}
@@ -0,0 +1,121 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rules
+
+import (
+	"testing"
+
+	"helm.sh/helm/v4/pkg/lint/support"
+)
+
+func TestValidateTemplateReferences(t *testing.T) {
+	vals := map[string]interface{}{
+		"name":        "web",
+		"annotations": map[string]interface{}{},
+		"resources":   nil,
+		"hosts":       []interface{}{"example.com"},
+		"image": map[string]interface{}{
+			"repository": "nginx",
+			"tag":        "latest",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		expected []string
+	}{{
+		name:     "defined values",
+		template: `{{ .Values.name }} {{ .Values.image.tag | quote }} {{ $.Values.image.repository }}`,
+	}, {
+		name:     "undefined values are reported once",
+		template: "{{ .Values.nmae }}\n{{ .Values.image.tga }}\n{{ .Values.nmae }}",
+		expected: []string{
+			"line 1: .Values.nmae is not defined in the chart's values",
+			"line 2: .Values.image.tga is not defined in the chart's values",
+		},
+	}, {
+		name:     "open defaults",
+		template: `{{ .Values.annotations.foo }} {{ .Values.resources.limits.cpu }} {{ .Values.name.whatever }}`,
+	}, {
+		name:     "with changes dot",
+		template: `{{ with .Values.image }}{{ .tag }}{{ .digest }}{{ end }}`,
+		expected: []string{"line 1: .Values.image.digest is not defined in the chart's values"},
+	}, {
+		name:     "with on an undefined value guards its body",
+		template: `{{ with .Values.extra }}{{ .foo }}{{ $.Values.extra.bar }}{{ end }}`,
+	}, {
+		name:     "if guards its body",
+		template: `{{ if and .Values.name .Values.port }}{{ .Values.port }}{{ end }}{{ .Values.port }}`,
+		expected: []string{"line 1: .Values.port is not defined in the chart's values"},
+	}, {
+		name:     "range elements are not tracked",
+		template: `{{ range .Values.hosts }}{{ .host }}{{ end }}{{ range $k, $v := .Values.image }}{{ $v.x }}{{ end }}`,
+	}, {
+		name:     "variables",
+		template: `{{ $img := .Values.image }}{{ $img.tag }}{{ $img.missing }}{{ $root := . }}{{ $root.Values.other }}`,
+		expected: []string{
+			"line 1: .Values.image.missing is not defined in the chart's values",
+			"line 1: .Values.other is not defined in the chart's values",
+		},
+	}, {
+		name:     "index",
+		template: `{{ index .Values "image" "tag" }}{{ index .Values.image "sha" }}{{ index .Values.image $.Values.name }}`,
+		expected: []string{"line 1: .Values.image.sha is not defined in the chart's values"},
+	}, {
+		name:     "optional functions",
+		template: `{{ .Values.port | default 80 }}{{ required "a host is required" .Values.host }}{{ default "x" (.Values.suffix) }}`,
+	}, {
+		name:     "defined templates",
+		template: `{{ define "test.name" }}{{ .Values.fullnameOverride }}{{ end }}`,
+		expected: []string{"line 1: .Values.fullnameOverride is not defined in the chart's values"},
+	}, {
+		name:     "global and other objects",
+		template: `{{ .Values.global.registry }}{{ .Release.Name }}{{ .Chart.Name }}{{ toYaml .Values }}`,
+	}, {
+		name:     "unparsable template",
+		template: `{{ .Values.nmae `,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validateTemplateReferences("test.yaml", tt.template, vals)
+			if len(errs) != len(tt.expected) {
+				t.Fatalf("expected %d errors, got %d: %v", len(tt.expected), len(errs), errs)
+			}
+			for i, err := range errs {
+				if err.Error() != tt.expected[i] {
+					t.Errorf("expected error %q, got %q", tt.expected[i], err)
+				}
+			}
+		})
+	}
+}
+
+func TestValuesReferences(t *testing.T) {
+	linter := support.Linter{ChartDir: "./testdata/withsubchart"}
+	ValuesReferences(&linter, nil)
+	if len(linter.Messages) != 0 {
+		t.Errorf("expected no messages, got %v", linter.Messages)
+	}
+
+	linter = support.Linter{ChartDir: "./testdata/goodone"}
+	ValuesReferences(&linter, nil)
+	if len(linter.Messages) != 0 {
+		t.Errorf("expected no messages, got %v", linter.Messages)
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+	"helm.sh/helm/v4/pkg/chart/v2/loader"
+	chartutil "helm.sh/helm/v4/pkg/chart/v2/util"
+	"helm.sh/helm/v4/pkg/lint/support"
+)
+
+const schemaFile = "values.schema.json"
+
+// ValuesSchema validates the effective values of the chart and of each of its
+// subcharts against their values.schema.json files.
+//
+// The values are coalesced the way they are for rendering: the chart defaults
+// with valueOverrides on top. Every violation is reported as a separate
+// message naming the offending value by its JSON pointer. Schema files that
+// cannot be parsed or compiled are reported as errors as well.
+func ValuesSchema(linter *support.Linter, valueOverrides map[string]interface{}) {
+	chrt, err := loader.Load(linter.ChartDir)
+	if err != nil {
+		// Charts that fail to load are reported by the other rules.
+		return
+	}
+
+	// lint ignores import-values
+	// See https://github.com/helm/helm/issues/9658
+	if err := chartutil.ProcessDependencies(chrt, valueOverrides); err != nil {
+		return
+	}
+	vals, err := chartutil.CoalesceValues(chrt, valueOverrides)
+	if err != nil {
+		return
+	}
+
+	validateChartSchemas(linter, chrt, vals, "")
+}
+
+// validateChartSchemas validates vals against the schema of chrt, then the
+// values of each subchart against its own schema. dir is the location of chrt
+// relative to the linted chart.
+func validateChartSchemas(linter *support.Linter, chrt *chart.Chart, vals map[string]interface{}, dir string) {
+	if chrt.Schema != nil {
+		file := path.Join(dir, schemaFile)
+		for _, err := range validateSchema(chrt.Schema, vals) {
+			linter.RunLinterRule(support.ErrorSev, file, err)
+		}
+	}
+
+	for _, sub := range chrt.Dependencies() {
+		subVals, _ := vals[sub.Name()].(map[string]interface{})
+		if subVals == nil {
+			subVals = map[string]interface{}{}
+		}
+		validateChartSchemas(linter, sub, subVals, path.Join(dir, "charts", sub.Name()))
+	}
+}
+
+// validateSchema returns one error per violation of schema by vals.
+func validateSchema(schema []byte, vals map[string]interface{}) []error {
+	err := chartutil.ValidateAgainstSingleSchema(vals, schema)
+	if err == nil {
+		return nil
+	}
+
+	var verr chartutil.JSONSchemaValidationError
+	if !errors.As(err, &verr) {
+		return []error{fmt.Errorf("invalid schema: %w", err)}
+	}
+
+	var errs []error
+	for _, v := range verr.Violations() {
+		p := v.Path
+		if p == "" {
+			p = "/"
+		}
+		errs = append(errs, fmt.Errorf("%s: %s", p, v.Message))
+	}
+	return errs
+}
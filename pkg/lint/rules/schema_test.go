@@ -0,0 +1,78 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rules
+
+import (
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v4/pkg/lint/support"
+)
+
+func TestValuesSchema(t *testing.T) {
+	tests := []struct {
+		name      string
+		chartDir  string
+		overrides map[string]interface{}
+		expected  []string
+	}{{
+		name:     "valid defaults",
+		chartDir: "./testdata/withschema",
+	}, {
+		name:     "violations in chart and subchart",
+		chartDir: "./testdata/withschema",
+		overrides: map[string]interface{}{
+			"replicaCount": 0,
+			"db": map[string]interface{}{
+				"image": map[string]interface{}{
+					"repository": nil,
+					"tag":        17,
+				},
+			},
+		},
+		expected: []string{
+			"[ERROR] values.schema.json: /replicaCount: minimum: got 0, want 1",
+			"[ERROR] charts/db/values.schema.json: /image: missing property 'repository'",
+			"[ERROR] charts/db/values.schema.json: /image/tag: got number, want string",
+		},
+	}, {
+		name:     "invalid schema",
+		chartDir: "./testdata/invalidschema",
+		expected: []string{
+			"[ERROR] values.schema.json: invalid schema: ",
+		},
+	}, {
+		name:     "chart without schema",
+		chartDir: "./testdata/goodone",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			linter := support.Linter{ChartDir: tt.chartDir}
+			ValuesSchema(&linter, tt.overrides)
+
+			if len(linter.Messages) != len(tt.expected) {
+				t.Fatalf("expected %d messages, got %d: %v", len(tt.expected), len(linter.Messages), linter.Messages)
+			}
+			for i, msg := range linter.Messages {
+				if !strings.HasPrefix(msg.Error(), tt.expected[i]) {
+					t.Errorf("expected message %d to start with %q, got %q", i, tt.expected[i], msg.Error())
+				}
+			}
+		})
+	}
+}
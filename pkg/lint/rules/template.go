@@ -51,6 +51,11 @@ func TemplatesWithKubeVersion(linter *support.Linter, values map[string]interfac
 
 // TemplatesWithSkipSchemaValidation lints the templates in the Linter, allowing to specify the kubernetes version and if schema validation is enabled or not.
 func TemplatesWithSkipSchemaValidation(linter *support.Linter, values map[string]interface{}, namespace string, kubeVersion *chartutil.KubeVersion, skipSchemaValidation bool) {
+	TemplatesWithSkipDeprecations(linter, values, namespace, kubeVersion, skipSchemaValidation, false)
+}
+
+// TemplatesWithSkipDeprecations lints the templates in the Linter, allowing to specify the kubernetes version, if schema validation is enabled or not, and if resources are checked for deprecated APIs or not.
+func TemplatesWithSkipDeprecations(linter *support.Linter, values map[string]interface{}, namespace string, kubeVersion *chartutil.KubeVersion, skipSchemaValidation, skipDeprecations bool) {
 	fpath := "templates/"
 	templatesPath := filepath.Join(linter.ChartDir, fpath)
 
@@ -86,7 +91,14 @@ func TemplatesWithSkipSchemaValidation(linter *support.Linter, values map[string
 		return
 	}
 
-	cvals, err := chartutil.CoalesceValues(chart, values)
+	var e engine.Engine
+	e.LintMode = true
+	withDefaults, err := e.ComputeDefaults(chart, values, options, caps)
+	if !linter.RunLinterRule(support.ErrorSev, engine.DefaultsFile, err) {
+		return
+	}
+
+	cvals, err := chartutil.CoalesceValues(chart, withDefaults)
 	if err != nil {
 		return
 	}
@@ -96,8 +108,6 @@ func TemplatesWithSkipSchemaValidation(linter *support.Linter, values map[string
 		linter.RunLinterRule(support.ErrorSev, fpath, err)
 		return
 	}
-	var e engine.Engine
-	e.LintMode = true
 	renderedContentMap, err := e.Render(chart, valuesToRender)
 
 	renderOk := linter.RunLinterRule(support.ErrorSev, fpath, err)
@@ -157,7 +167,9 @@ func TemplatesWithSkipSchemaValidation(linter *support.Linter, values map[string
 					// NOTE: set to warnings to allow users to support out-of-date kubernetes
 					// Refs https://github.com/helm/helm/issues/8596
 					linter.RunLinterRule(support.WarningSev, fpath, validateMetadataName(yamlStruct))
-					linter.RunLinterRule(support.WarningSev, fpath, validateNoDeprecations(yamlStruct, kubeVersion))
+					if !skipDeprecations {
+						linter.RunLinterRule(support.WarningSev, fpath, validateNoDeprecations(yamlStruct, kubeVersion))
+					}
 
 					linter.RunLinterRule(support.ErrorSev, fpath, validateMatchSelector(yamlStruct, renderedContent))
 					linter.RunLinterRule(support.ErrorSev, fpath, validateListAnnotations(yamlStruct, renderedContent))
@@ -32,6 +32,12 @@ import (
 //
 // If additional values are supplied, they are coalesced into the values in values.yaml.
 func ValuesWithOverrides(linter *support.Linter, valueOverrides map[string]interface{}) {
+	ValuesWithSkipSchemaValidation(linter, valueOverrides, false)
+}
+
+// ValuesWithSkipSchemaValidation tests the values.yaml file, allowing to specify if
+// the values are tested against the schema of the chart or not.
+func ValuesWithSkipSchemaValidation(linter *support.Linter, valueOverrides map[string]interface{}, skipSchemaValidation bool) {
 	file := "values.yaml"
 	vf := filepath.Join(linter.ChartDir, file)
 	fileExists := linter.RunLinterRule(support.InfoSev, file, validateValuesFileExistence(vf))
@@ -40,6 +46,10 @@ func ValuesWithOverrides(linter *support.Linter, valueOverrides map[string]inter
 		return
 	}
 
+	if skipSchemaValidation {
+		linter.RunLinterRule(support.ErrorSev, file, validateValuesFileWellFormed(vf))
+		return
+	}
 	linter.RunLinterRule(support.ErrorSev, file, validateValuesFile(vf, valueOverrides))
 }
 
@@ -51,6 +61,13 @@ func validateValuesFileExistence(valuesPath string) error {
 	return nil
 }
 
+func validateValuesFileWellFormed(valuesPath string) error {
+	if _, err := chartutil.ReadValuesFile(valuesPath); err != nil {
+		return fmt.Errorf("unable to parse YAML: %w", err)
+	}
+	return nil
+}
+
 func validateValuesFile(valuesPath string, overrides map[string]interface{}) error {
 	values, err := chartutil.ReadValuesFile(valuesPath)
 	if err != nil {
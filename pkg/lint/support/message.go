@@ -47,6 +47,8 @@ type Message struct {
 	Severity int
 	Path     string
 	Err      error
+	// Rule is the id of the lint rule that reported the message, if known.
+	Rule string
 }
 
 func (m Message) Error() string {
@@ -0,0 +1,185 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package support
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+
+	// sarifDefaultRule is the rule of the messages not recording one.
+	sarifDefaultRule = "Lint"
+)
+
+// sarifLevels maps the *Sev constants to SARIF result levels.
+var sarifLevels = []string{"none", "note", "warning", "error"}
+
+// lineNumberPatterns extract the line a message refers to from the errors
+// reported by the YAML parser, the template engine and the lint rules.
+var lineNumberPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\bline (\d+)`),
+	regexp.MustCompile(`[\w.-]+\.(?:yaml|yml|json|tpl|txt):(\d+)`),
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Version        string      `json:"version,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// WriteSARIF writes the messages of linters to w as a SARIF 2.1.0 log with a
+// single run, for consumption by code scanning tools.
+//
+// Each message becomes a result located at its path within the ChartDir of
+// its linter, so a relative ChartDir yields relative artifact URIs. The line
+// number is included when it can be recovered from the error. Results are
+// grouped by the Rule of their message, and messages that do not record a
+// rule, such as the errors preventing a chart from being linted, are
+// reported under the "Lint" rule.
+func WriteSARIF(w io.Writer, toolVersion string, linters []Linter) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "helm-lint",
+			InformationURI: "https://helm.sh/docs/helm/helm_lint/",
+			Version:        toolVersion,
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	ruleIndex := map[string]int{}
+
+	for _, l := range linters {
+		for _, m := range l.Messages {
+			id := m.Rule
+			if id == "" {
+				id = sarifDefaultRule
+			}
+			idx, ok := ruleIndex[id]
+			if !ok {
+				idx = len(run.Tool.Driver.Rules)
+				ruleIndex[id] = idx
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+					ID:               id,
+					ShortDescription: sarifMessage{Text: "Issues reported by the " + id + " lint rule"},
+				})
+			}
+
+			level := sarifLevels[UnknownSev]
+			if m.Severity >= 0 && m.Severity < len(sarifLevels) {
+				level = sarifLevels[m.Severity]
+			}
+
+			loc := sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{
+					URI: filepath.ToSlash(filepath.Join(l.ChartDir, m.Path)),
+				},
+			}
+			if line := messageLine(m.Err); line > 0 {
+				loc.Region = &sarifRegion{StartLine: line}
+			}
+
+			run.Results = append(run.Results, sarifResult{
+				RuleID:    id,
+				RuleIndex: idx,
+				Level:     level,
+				Message:   sarifMessage{Text: m.Err.Error()},
+				Locations: []sarifLocation{{PhysicalLocation: loc}},
+			})
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	})
+}
+
+// messageLine returns the line number mentioned in err, or 0 if there is
+// none.
+func messageLine(err error) int {
+	if err == nil {
+		return 0
+	}
+	for _, re := range lineNumberPatterns {
+		if m := re.FindStringSubmatch(err.Error()); m != nil {
+			if n, convErr := strconv.Atoi(m[1]); convErr == nil {
+				return n
+			}
+		}
+	}
+	return 0
+}
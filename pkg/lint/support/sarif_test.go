@@ -0,0 +1,126 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package support
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	linters := []Linter{{
+		ChartDir: "charts/web",
+		Messages: []Message{
+			{Severity: InfoSev, Path: "Chart.yaml", Err: errors.New("icon is recommended"), Rule: "Chartfile"},
+			{Severity: ErrorSev, Path: "templates/deployment.yaml", Err: errors.New("unable to parse YAML: error converting YAML to JSON: yaml: line 7: did not find expected key"), Rule: "Templates"},
+			{Severity: WarningSev, Path: "templates/", Err: errors.New("directory not found"), Rule: "Templates"},
+			{Severity: ErrorSev, Path: "Chart.yaml", Err: errors.New("version is required"), Rule: "Chartfile"},
+		},
+	}, {
+		ChartDir: "charts/db",
+		Messages: []Message{
+			{Severity: ErrorSev, Path: "templates/", Err: errors.New(`template: db/templates/svc.yaml:3:10: executing "db/templates/svc.yaml" at <.Values.x>: nil pointer`), Rule: "Templates"},
+			NewMessage(ErrorSev, "", errors.New("chart not found")),
+		},
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, "v4.0.0", linters); err != nil {
+		t.Fatal(err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected SARIF log: version %q with %d runs", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Version != "v4.0.0" {
+		t.Errorf("expected tool version v4.0.0, got %q", run.Tool.Driver.Version)
+	}
+
+	var ruleIDs []string
+	for _, r := range run.Tool.Driver.Rules {
+		ruleIDs = append(ruleIDs, r.ID)
+	}
+	expectedRules := []string{"Chartfile", "Templates", "Lint"}
+	if len(ruleIDs) != len(expectedRules) {
+		t.Fatalf("expected rules %v, got %v", expectedRules, ruleIDs)
+	}
+	for i := range expectedRules {
+		if ruleIDs[i] != expectedRules[i] {
+			t.Fatalf("expected rules %v, got %v", expectedRules, ruleIDs)
+		}
+	}
+
+	expected := []struct {
+		ruleID string
+		level  string
+		uri    string
+		line   int
+	}{
+		{"Chartfile", "note", "charts/web/Chart.yaml", 0},
+		{"Templates", "error", "charts/web/templates/deployment.yaml", 7},
+		{"Templates", "warning", "charts/web/templates", 0},
+		{"Chartfile", "error", "charts/web/Chart.yaml", 0},
+		{"Templates", "error", "charts/db/templates", 3},
+		{"Lint", "error", "charts/db", 0},
+	}
+	if len(run.Results) != len(expected) {
+		t.Fatalf("expected %d results, got %d", len(expected), len(run.Results))
+	}
+	for i, want := range expected {
+		got := run.Results[i]
+		if got.RuleID != want.ruleID || run.Tool.Driver.Rules[got.RuleIndex].ID != want.ruleID {
+			t.Errorf("result %d: expected rule %q, got %q (index %d)", i, want.ruleID, got.RuleID, got.RuleIndex)
+		}
+		if got.Level != want.level {
+			t.Errorf("result %d: expected level %q, got %q", i, want.level, got.Level)
+		}
+		loc := got.Locations[0].PhysicalLocation
+		if loc.ArtifactLocation.URI != want.uri {
+			t.Errorf("result %d: expected URI %q, got %q", i, want.uri, loc.ArtifactLocation.URI)
+		}
+		line := 0
+		if loc.Region != nil {
+			line = loc.Region.StartLine
+		}
+		if line != want.line {
+			t.Errorf("result %d: expected line %d, got %d", i, want.line, line)
+		}
+	}
+}
+
+func TestWriteSARIFNoMessages(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	var log map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	runs := log["runs"].([]interface{})
+	results := runs[0].(map[string]interface{})["results"].([]interface{})
+	if len(results) != 0 {
+		t.Errorf("expected no results, got %d", len(results))
+	}
+}
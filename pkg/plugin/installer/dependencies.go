@@ -0,0 +1,108 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer // import "helm.sh/helm/v4/pkg/plugin/installer"
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"helm.sh/helm/v4/pkg/plugin"
+)
+
+// InstallDependencies installs the dependencies of p, and their own
+// dependencies in turn, that are missing from the plugin directories
+// pluginsDirs, a list separated as by filepath.SplitList.
+//
+// Dependencies that are already installed must satisfy the version
+// constraints of every plugin requiring them. The installed plugins are
+// returned in the order they were installed, which puts every plugin after
+// its dependencies, including when an error is returned.
+func InstallDependencies(p *plugin.Plugin, pluginsDirs string) ([]*plugin.Plugin, error) {
+	plugins, err := plugin.FindPlugins(pluginsDirs)
+	if err != nil {
+		return nil, err
+	}
+	r := &dependencyResolver{installed: map[string]*plugin.Plugin{}}
+	for _, installed := range plugins {
+		r.installed[installed.Metadata.Name] = installed
+	}
+	r.installed[p.Metadata.Name] = p
+
+	if err := r.resolve(p); err != nil {
+		return r.added, err
+	}
+	return r.added, nil
+}
+
+type dependencyResolver struct {
+	installed map[string]*plugin.Plugin
+	added     []*plugin.Plugin
+}
+
+// resolve installs the missing dependencies of p. Plugins are recorded as
+// installed before their own dependencies are resolved, so that plugins
+// depending on each other are installed once.
+func (r *dependencyResolver) resolve(p *plugin.Plugin) error {
+	for _, dep := range p.Metadata.Dependencies {
+		if installed, ok := r.installed[dep.Name]; ok {
+			if err := dep.Check(installed); err != nil {
+				return fmt.Errorf("plugin %q has conflicting dependencies: %w", p.Metadata.Name, err)
+			}
+			continue
+		}
+
+		installed, err := r.install(p, dep)
+		if err != nil {
+			return err
+		}
+		err = r.resolve(installed)
+		r.added = append(r.added, installed)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// install installs the dependency dep of p from its source.
+func (r *dependencyResolver) install(p *plugin.Plugin, dep plugin.Dependency) (*plugin.Plugin, error) {
+	if dep.Source == "" {
+		return nil, fmt.Errorf("plugin %q requires plugin %q, which is not installed and has no source", p.Metadata.Name, dep.Name)
+	}
+
+	slog.Debug("installing plugin dependency", "plugin", p.Metadata.Name, "dependency", dep.Name, "source", dep.Source)
+	i, err := NewForSource(dep.Source, dep.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to install plugin %q required by %q: %w", dep.Name, p.Metadata.Name, err)
+	}
+	if err := Install(i); err != nil {
+		return nil, fmt.Errorf("failed to install plugin %q required by %q: %w", dep.Name, p.Metadata.Name, err)
+	}
+
+	installed, err := plugin.LoadDir(i.Path())
+	if err == nil {
+		err = dep.Check(installed)
+	}
+	if err != nil {
+		// Leave nothing behind that would conflict on the next attempt.
+		os.RemoveAll(i.Path())
+		return nil, fmt.Errorf("failed to install plugin %q required by %q: %w", dep.Name, p.Metadata.Name, err)
+	}
+	r.installed[dep.Name] = installed
+	return installed, nil
+}
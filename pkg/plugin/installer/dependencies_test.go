@@ -0,0 +1,154 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer // import "helm.sh/helm/v4/pkg/plugin/installer"
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v4/pkg/plugin"
+)
+
+// writeTestPlugin writes a plugin.yaml with the given content to dir/name and
+// returns the path of the plugin.
+func writeTestPlugin(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	pluginDir := filepath.Join(dir, name)
+	if err := os.MkdirAll(pluginDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pluginDir, plugin.PluginFileName), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return pluginDir
+}
+
+func loadTestPlugin(t *testing.T, dir string) *plugin.Plugin {
+	t.Helper()
+	p, err := plugin.LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestInstallDependencies(t *testing.T) {
+	pluginsDir := t.TempDir()
+	t.Setenv("HELM_PLUGINS", pluginsDir)
+	sources := t.TempDir()
+
+	depB := writeTestPlugin(t, sources, "dep-b", "name: dep-b\nversion: 2.0.0\n")
+	depA := writeTestPlugin(t, sources, "dep-a", `name: dep-a
+version: 1.1.0
+dependencies:
+- name: dep-b
+  version: ">=2.0.0"
+  source: `+depB+`
+- name: main
+`)
+	main := writeTestPlugin(t, sources, "main", `name: main
+version: 0.1.0
+dependencies:
+- name: dep-a
+  version: ^1.0.0
+  source: `+depA+`
+- name: dep-b
+  source: `+depB+`
+`)
+
+	installed, err := InstallDependencies(loadTestPlugin(t, main), pluginsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range installed {
+		names = append(names, p.Metadata.Name)
+	}
+	if strings.Join(names, ",") != "dep-b,dep-a" {
+		t.Errorf("expected dep-b and dep-a to be installed in order, got %v", names)
+	}
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(pluginsDir, name, plugin.PluginFileName)); err != nil {
+			t.Errorf("expected %s to be installed: %s", name, err)
+		}
+	}
+
+	// Everything is installed now.
+	installed, err = InstallDependencies(loadTestPlugin(t, main), pluginsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(installed) != 0 {
+		t.Errorf("expected no plugins to be installed, got %d", len(installed))
+	}
+}
+
+func TestInstallDependenciesErrors(t *testing.T) {
+	sources := t.TempDir()
+	depA := writeTestPlugin(t, sources, "dep-a", "name: dep-a\nversion: 1.1.0\n")
+	other := writeTestPlugin(t, sources, "other", "name: other\nversion: 1.0.0\n")
+
+	tests := []struct {
+		name      string
+		installed []string
+		plugin    string
+		expect    string
+	}{
+		{
+			name:      "conflicting installed version",
+			installed: []string{"name: dep-a\nversion: 0.9.0\n"},
+			plugin:    "name: main\ndependencies:\n- name: dep-a\n  version: ^1.0.0\n  source: " + depA + "\n",
+			expect:    `plugin "main" has conflicting dependencies: plugin "dep-a" version 0.9.0 is installed, but ^1.0.0 is required`,
+		},
+		{
+			name:   "missing source",
+			plugin: "name: main\ndependencies:\n- name: dep-a\n",
+			expect: `plugin "main" requires plugin "dep-a", which is not installed and has no source`,
+		},
+		{
+			name:   "source provides another plugin",
+			plugin: "name: main\ndependencies:\n- name: dep-a\n  source: " + other + "\n",
+			expect: `plugin "other" is not the required plugin "dep-a"`,
+		},
+		{
+			name:   "source provides an unsatisfying version",
+			plugin: "name: main\ndependencies:\n- name: dep-a\n  version: ^2.0.0\n  source: " + depA + "\n",
+			expect: `plugin "dep-a" version 1.1.0 is installed, but ^2.0.0 is required`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pluginsDir := t.TempDir()
+			t.Setenv("HELM_PLUGINS", pluginsDir)
+			for i, content := range tt.installed {
+				writeTestPlugin(t, pluginsDir, "installed"+string(rune('0'+i)), content)
+			}
+			main := writeTestPlugin(t, t.TempDir(), "main", tt.plugin)
+
+			_, err := InstallDependencies(loadTestPlugin(t, main), pluginsDir)
+			if err == nil || !strings.Contains(err.Error(), tt.expect) {
+				t.Fatalf("expected error containing %q, got %v", tt.expect, err)
+			}
+			// Plugins that failed to install are removed.
+			if _, err := os.Lstat(filepath.Join(pluginsDir, "other")); !os.IsNotExist(err) {
+				t.Error("expected the mismatched plugin to be removed")
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer // import "helm.sh/helm/v4/pkg/plugin/installer"
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"helm.sh/helm/v4/internal/third_party/dep/fs"
+	"helm.sh/helm/v4/pkg/cli"
+	"helm.sh/helm/v4/pkg/helmpath"
+	"helm.sh/helm/v4/pkg/plugin/cache"
+	"helm.sh/helm/v4/pkg/registry"
+)
+
+// pluginPuller pulls plugin archives from a registry.
+type pluginPuller interface {
+	PullPlugin(ref string) (*registry.PluginPullResult, error)
+}
+
+// OCIInstaller installs plugins from an OCI registry.
+//
+// The plugin is pulled from an oci:// reference as an archive stored in a
+// layer with registry.PluginLayerMediaType. The registry credentials used for
+// charts are used.
+type OCIInstaller struct {
+	CacheDir   string
+	PluginName string
+	base
+	extractor Extractor
+	puller    pluginPuller
+}
+
+// NewOCIInstaller creates a new OCIInstaller. If version is not empty, it is
+// used as the tag of source, which must not have a tag or digest then.
+func NewOCIInstaller(source, version string) (*OCIInstaller, error) {
+	ref := strings.TrimPrefix(source, registry.OCIScheme+"://")
+	name := path.Base(ref)
+	if i := strings.IndexAny(name, ":@"); i >= 0 {
+		if version != "" {
+			return nil, fmt.Errorf("cannot use a version with %s, which already has a tag or digest", source)
+		}
+		name = name[:i]
+	} else if version != "" {
+		source += ":" + version
+	}
+
+	key, err := cache.Key(source)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := cli.New()
+	client, err := registry.NewClient(
+		registry.ClientOptDebug(Debug),
+		registry.ClientOptEnableCache(true),
+		registry.ClientOptWriter(os.Stderr),
+		registry.ClientOptCredentialsFile(settings.RegistryConfig),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OCIInstaller{
+		CacheDir:   helmpath.CachePath("plugins", key),
+		PluginName: name,
+		base:       newBase(source),
+		extractor:  &TarGzExtractor{},
+		puller:     client,
+	}, nil
+}
+
+// Install pulls the plugin archive, extracts it into the cache directory and
+// installs it into the plugin directory.
+//
+// Implements Installer.
+func (i *OCIInstaller) Install() error {
+	slog.Debug("pulling plugin", "source", i.Source)
+	result, err := i.puller.PullPlugin(i.Source)
+	if err != nil {
+		return fmt.Errorf("failed to pull plugin %s: %w", i.Source, err)
+	}
+
+	if err := i.extractor.Extract(bytes.NewBuffer(result.Plugin.Data), i.CacheDir); err != nil {
+		return fmt.Errorf("extracting files from archive: %w", err)
+	}
+
+	if !isPlugin(i.CacheDir) {
+		return ErrMissingMetadata
+	}
+
+	src, err := filepath.Abs(i.CacheDir)
+	if err != nil {
+		return err
+	}
+
+	slog.Debug("copying", "source", src, "path", i.Path())
+	return fs.CopyDir(src, i.Path())
+}
+
+// Update is not implemented, as OCI references normally point at a version
+// of the plugin. Install the new version instead.
+func (i *OCIInstaller) Update() error {
+	return fmt.Errorf("method Update() not implemented for OCIInstaller")
+}
+
+// Path is overridden because we want to join on the plugin name not the reference
+func (i OCIInstaller) Path() string {
+	if i.Source == "" {
+		return ""
+	}
+	return helmpath.DataPath("plugins", i.PluginName)
+}
@@ -0,0 +1,139 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer // import "helm.sh/helm/v4/pkg/plugin/installer"
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"helm.sh/helm/v4/internal/test/ensure"
+	"helm.sh/helm/v4/pkg/helmpath"
+	"helm.sh/helm/v4/pkg/registry"
+)
+
+var _ Installer = new(OCIInstaller)
+
+// Fake registry client
+type testPluginPuller struct {
+	ref  string
+	data []byte
+	err  error
+}
+
+func (p *testPluginPuller) PullPlugin(ref string) (*registry.PluginPullResult, error) {
+	p.ref = ref
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &registry.PluginPullResult{Plugin: &registry.DescriptorPullSummary{Data: p.data}, Ref: ref}, nil
+}
+
+func TestOCIInstaller(t *testing.T) {
+	ensure.HelmHome(t)
+	if err := os.MkdirAll(helmpath.DataPath("plugins"), 0755); err != nil {
+		t.Fatalf("Could not create %s: %s", helmpath.DataPath("plugins"), err)
+	}
+
+	i, err := NewForSource("oci://registry.example.com/plugins/fake-plugin", "0.0.1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// ensure an OCIInstaller was returned
+	ociInstaller, ok := i.(*OCIInstaller)
+	if !ok {
+		t.Fatal("expected an OCIInstaller")
+	}
+
+	// inject fake registry client responding with minimal plugin tarball
+	mockTgz, err := base64.StdEncoding.DecodeString(fakePluginB64)
+	if err != nil {
+		t.Fatalf("Could not decode fake tgz plugin: %s", err)
+	}
+	puller := &testPluginPuller{data: mockTgz}
+	ociInstaller.puller = puller
+
+	if err := Install(i); err != nil {
+		t.Fatal(err)
+	}
+	if puller.ref != "oci://registry.example.com/plugins/fake-plugin:0.0.1" {
+		t.Errorf("unexpected reference pulled: %s", puller.ref)
+	}
+	if i.Path() != helmpath.DataPath("plugins", "fake-plugin") {
+		t.Fatalf("expected path '$XDG_CONFIG_HOME/helm/plugins/fake-plugin', got %q", i.Path())
+	}
+	if _, err := os.Stat(filepath.Join(i.Path(), "plugin.yaml")); err != nil {
+		t.Errorf("expected plugin.yaml to be installed: %s", err)
+	}
+
+	// Install again to test plugin exists error
+	if err := Install(i); err == nil || err.Error() != "plugin already exists" {
+		t.Fatalf("expected error for plugin exists, got (%v)", err)
+	}
+}
+
+func TestOCIInstallerReferences(t *testing.T) {
+	ensure.HelmHome(t)
+
+	tests := []struct {
+		source, version string
+		name            string
+		wantErr         bool
+	}{
+		{source: "oci://registry.example.com/plugins/helm-diff:1.0.0", name: "helm-diff"},
+		{source: "oci://registry.example.com/plugins/helm-diff@sha256:0123456789abcdef", name: "helm-diff"},
+		{source: "oci://registry.example.com:5000/helm-diff", version: "1.0.0", name: "helm-diff"},
+		{source: "oci://registry.example.com/plugins/helm-diff:1.0.0", version: "1.0.0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			i, err := NewOCIInstaller(tt.source, tt.version)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if i.PluginName != tt.name {
+				t.Errorf("expected plugin name %q, got %q", tt.name, i.PluginName)
+			}
+		})
+	}
+}
+
+func TestOCIInstallerPullError(t *testing.T) {
+	ensure.HelmHome(t)
+
+	i, err := NewOCIInstaller("oci://registry.example.com/plugins/fake-plugin:0.0.1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	i.puller = &testPluginPuller{err: errors.New("manifest unknown")}
+
+	if err := Install(i); err == nil {
+		t.Fatal("expected an error")
+	}
+	if _, err := os.Stat(i.Path()); !os.IsNotExist(err) {
+		t.Error("expected the plugin not to be installed")
+	}
+}
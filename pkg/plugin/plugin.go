@@ -24,6 +24,7 @@ import (
 	"strings"
 	"unicode"
 
+	"github.com/Masterminds/semver/v3"
 	"sigs.k8s.io/yaml"
 
 	"helm.sh/helm/v4/pkg/cli"
@@ -49,6 +50,41 @@ type PlatformCommand struct {
 	Args            []string `json:"args"`
 }
 
+// Dependency describes a plugin that another plugin requires.
+type Dependency struct {
+	// Name is the name of the required plugin.
+	Name string `json:"name"`
+	// Version is a SemVer 2 constraint the version of the required plugin
+	// must satisfy, such as "^1.2.0". Any version is accepted if it is empty.
+	Version string `json:"version,omitempty"`
+	// Source is where the required plugin is installed from when it is
+	// missing: a VCS repository URL, an archive URL or a local path, as
+	// accepted by `helm plugin install`.
+	Source string `json:"source,omitempty"`
+}
+
+// Check returns an error if p does not satisfy the dependency.
+func (d *Dependency) Check(p *Plugin) error {
+	if p.Metadata.Name != d.Name {
+		return fmt.Errorf("plugin %q is not the required plugin %q", p.Metadata.Name, d.Name)
+	}
+	if d.Version == "" {
+		return nil
+	}
+	constraint, err := semver.NewConstraint(d.Version)
+	if err != nil {
+		return fmt.Errorf("invalid version constraint %q for plugin %q: %w", d.Version, d.Name, err)
+	}
+	version, err := semver.NewVersion(p.Metadata.Version)
+	if err != nil {
+		return fmt.Errorf("plugin %q has invalid version %q, %s is required", d.Name, p.Metadata.Version, d.Version)
+	}
+	if !constraint.Check(version) {
+		return fmt.Errorf("plugin %q version %s is installed, but %s is required", d.Name, p.Metadata.Version, d.Version)
+	}
+	return nil
+}
+
 // Metadata describes a plugin.
 //
 // This is the plugin equivalent of a chart.Metadata.
@@ -133,6 +169,10 @@ type Metadata struct {
 	// for special protocols.
 	Downloaders []Downloaders `json:"downloaders"`
 
+	// Dependencies are the other plugins this plugin requires. Missing
+	// dependencies are installed along with the plugin.
+	Dependencies []Dependency `json:"dependencies,omitempty"`
+
 	// UseTunnelDeprecated indicates that this command needs a tunnel.
 	// Setting this will cause a number of side effects, such as the
 	// automatic setting of HELM_HOST.
@@ -272,6 +312,20 @@ func validatePluginData(plug *Plugin, filepath string) error {
 		return fmt.Errorf("both platformHooks and hooks are set in %q", filepath)
 	}
 
+	for _, dep := range plug.Metadata.Dependencies {
+		if !validPluginName.MatchString(dep.Name) {
+			return fmt.Errorf("invalid dependency name %q in %q", dep.Name, filepath)
+		}
+		if dep.Name == plug.Metadata.Name {
+			return fmt.Errorf("plugin depends on itself in %q", filepath)
+		}
+		if dep.Version != "" {
+			if _, err := semver.NewConstraint(dep.Version); err != nil {
+				return fmt.Errorf("invalid version constraint %q for dependency %q in %q: %w", dep.Version, dep.Name, filepath, err)
+			}
+		}
+	}
+
 	// We could also validate SemVer, executable, and other fields should we so choose.
 	return nil
 }
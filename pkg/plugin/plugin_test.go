@@ -483,6 +483,16 @@ func TestValidatePluginData(t *testing.T) {
 		Install: "echo installing...",
 	}
 
+	// Mock plugins with dependencies
+	mockWithDeps := mockPlugin("foo")
+	mockWithDeps.Metadata.Dependencies = []Dependency{{Name: "bar", Version: "^1.0.0", Source: "https://example.com/bar"}}
+	mockWithBadDepName := mockPlugin("foo")
+	mockWithBadDepName.Metadata.Dependencies = []Dependency{{Name: "bar baz"}}
+	mockWithBadDepVersion := mockPlugin("foo")
+	mockWithBadDepVersion.Metadata.Dependencies = []Dependency{{Name: "bar", Version: "not-a-version"}}
+	mockWithSelfDep := mockPlugin("foo")
+	mockWithSelfDep.Metadata.Dependencies = []Dependency{{Name: "foo"}}
+
 	for i, item := range []struct {
 		pass bool
 		plug *Plugin
@@ -498,6 +508,10 @@ func TestValidatePluginData(t *testing.T) {
 		{true, mockLegacyCommand},        // Test legacy command metadata works
 		{false, mockWithCommand},         // Test platformCommand and command both set fails
 		{false, mockWithHooks},           // Test platformHooks and hooks both set fails
+		{true, mockWithDeps},             // Test dependencies work
+		{false, mockWithBadDepName},      // Test invalid dependency name fails
+		{false, mockWithBadDepVersion},   // Test invalid dependency version constraint fails
+		{false, mockWithSelfDep},         // Test depending on itself fails
 	} {
 		err := validatePluginData(item.plug, fmt.Sprintf("test-%d", i))
 		if item.pass && err != nil {
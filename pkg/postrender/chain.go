@@ -0,0 +1,58 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postrender
+
+import (
+	"bytes"
+	"fmt"
+)
+
+type chainRender struct {
+	renderers []PostRenderer
+}
+
+// NewChain returns a PostRenderer that runs the given post-renderers in
+// order, each receiving the output of the previous one. The chain stops at
+// the first post-renderer that fails or produces empty output, with an error
+// naming it.
+func NewChain(renderers ...PostRenderer) PostRenderer {
+	return &chainRender{renderers}
+}
+
+// Run the post-renderers of the chain in order
+func (c *chainRender) Run(renderedManifests *bytes.Buffer) (*bytes.Buffer, error) {
+	manifests := renderedManifests
+	for i, r := range c.renderers {
+		out, err := r.Run(manifests)
+		if err != nil {
+			return nil, fmt.Errorf("post-renderer %d of %d (%s) failed: %w", i+1, len(c.renderers), stageName(r), err)
+		}
+		if out == nil || len(bytes.TrimSpace(out.Bytes())) == 0 {
+			return nil, fmt.Errorf("post-renderer %d of %d (%s) produced empty output", i+1, len(c.renderers), stageName(r))
+		}
+		manifests = out
+	}
+	return manifests, nil
+}
+
+// stageName describes r in errors, using its String method if it has one.
+func stageName(r PostRenderer) string {
+	if s, ok := r.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%T", r)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postrender
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type funcRender func(*bytes.Buffer) (*bytes.Buffer, error)
+
+func (f funcRender) Run(in *bytes.Buffer) (*bytes.Buffer, error) {
+	return f(in)
+}
+
+func replaceRender(old, replacement string) PostRenderer {
+	return funcRender(func(in *bytes.Buffer) (*bytes.Buffer, error) {
+		return bytes.NewBufferString(strings.ReplaceAll(in.String(), old, replacement)), nil
+	})
+}
+
+func TestChainRun(t *testing.T) {
+	is := assert.New(t)
+
+	chain := NewChain(replaceRender("FOO", "BAR"), replaceRender("BAR", "BAZ"))
+	out, err := chain.Run(bytes.NewBufferString("FOOTEST"))
+	require.NoError(t, err)
+	is.Equal("BAZTEST", out.String())
+
+	// Stages after a failing one are not run
+	ran := false
+	chain = NewChain(
+		replaceRender("FOO", "BAR"),
+		funcRender(func(*bytes.Buffer) (*bytes.Buffer, error) { return nil, errors.New("boom") }),
+		funcRender(func(in *bytes.Buffer) (*bytes.Buffer, error) { ran = true; return in, nil }),
+	)
+	_, err = chain.Run(bytes.NewBufferString("FOOTEST"))
+	is.ErrorContains(err, "post-renderer 2 of 3 (postrender.funcRender) failed: boom")
+	is.False(ran)
+
+	chain = NewChain(replaceRender("FOOTEST", " \n"), replaceRender("BAR", "BAZ"))
+	_, err = chain.Run(bytes.NewBufferString("FOOTEST"))
+	is.ErrorContains(err, "post-renderer 1 of 2 (postrender.funcRender) produced empty output")
+}
+
+func TestChainExec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping on windows")
+	}
+	is := assert.New(t)
+	testpath := setupTestingScript(t)
+
+	first, err := NewExec(testpath)
+	require.NoError(t, err)
+	second, err := NewExec("sed", "s/BARTEST/QUX/")
+	require.NoError(t, err)
+	failing, err := NewExec("false")
+	require.NoError(t, err)
+
+	out, err := NewChain(first, second).Run(bytes.NewBufferString("FOOTEST"))
+	require.NoError(t, err)
+	is.Contains(out.String(), "QUX")
+
+	_, err = NewChain(first, failing).Run(bytes.NewBufferString("FOOTEST"))
+	is.ErrorContains(err, "post-renderer 2 of 2 (")
+	is.ErrorContains(err, "false) failed")
+}
@@ -41,6 +41,11 @@ func NewExec(binaryPath string, args ...string) (PostRenderer, error) {
 	return &execRender{fullPath, args}, nil
 }
 
+// String returns the path of the binary, naming the post-renderer in errors
+func (p *execRender) String() string {
+	return p.binaryPath
+}
+
 // Run the configured binary for the post render
 func (p *execRender) Run(renderedManifests *bytes.Buffer) (*bytes.Buffer, error) {
 	cmd := exec.Command(p.binaryPath, p.args...)
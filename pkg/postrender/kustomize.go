@@ -0,0 +1,184 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postrender
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/yaml"
+)
+
+// KustomizeScheme is the scheme of post-renderer references naming a
+// kustomize overlay, as in "kustomize://path/to/overlay".
+const KustomizeScheme = "kustomize"
+
+// kustomizeResource is the name the rendered manifests are given within the
+// overlay.
+const kustomizeResource = "helm-rendered-manifests.yaml"
+
+type kustomizeRender struct {
+	overlay string
+}
+
+// NewKustomize returns a PostRenderer that applies the kustomize overlay in
+// the directory overlay to the rendered manifests, without requiring the
+// kustomize binary.
+//
+// The rendered manifests are added to the resources of the overlay, so the
+// overlay does not need to list them. Its patches, transformers and other
+// resources are applied as they would be by `kustomize build`. The overlay
+// must not list the rendered manifests itself, and is not modified on disk.
+func NewKustomize(overlay string) (PostRenderer, error) {
+	abs, err := filepath.Abs(overlay)
+	if err != nil {
+		return nil, err
+	}
+	// Symbolic links are resolved for the paths seen by kustomize to
+	// match those of the rendered manifests.
+	abs, err = filepath.EvalSymlinks(abs)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find kustomize overlay at %s: %w", overlay, err)
+	}
+	fSys := filesys.MakeFsOnDisk()
+	if !fSys.IsDir(abs) {
+		return nil, fmt.Errorf("kustomize overlay %s is not a directory", overlay)
+	}
+	if _, err := findKustomization(fSys, abs); err != nil {
+		return nil, err
+	}
+	return &kustomizeRender{abs}, nil
+}
+
+// String returns the reference to the overlay, naming the post-renderer in
+// errors
+func (k *kustomizeRender) String() string {
+	return KustomizeScheme + "://" + k.overlay
+}
+
+// Run applies the overlay to the rendered manifests
+func (k *kustomizeRender) Run(renderedManifests *bytes.Buffer) (*bytes.Buffer, error) {
+	disk := filesys.MakeFsOnDisk()
+	kustFile, err := findKustomization(disk, k.overlay)
+	if err != nil {
+		return nil, err
+	}
+	data, err := disk.ReadFile(kustFile)
+	if err != nil {
+		return nil, err
+	}
+
+	kustomization := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &kustomization); err != nil {
+		return nil, fmt.Errorf("invalid kustomization %s: %w", kustFile, err)
+	}
+	resources, ok := kustomization["resources"].([]interface{})
+	if kustomization["resources"] != nil && !ok {
+		return nil, fmt.Errorf("invalid kustomization %s: resources must be a list", kustFile)
+	}
+	kustomization["resources"] = append([]interface{}{kustomizeResource}, resources...)
+	data, err = yaml.Marshal(kustomization)
+	if err != nil {
+		return nil, err
+	}
+
+	fSys := &overlayFS{
+		FileSystem: disk,
+		files: map[string][]byte{
+			kustFile: data,
+			filepath.Join(k.overlay, kustomizeResource): renderedManifests.Bytes(),
+		},
+	}
+	resMap, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(fSys, k.overlay)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply kustomize overlay %s: %w", k.overlay, err)
+	}
+	out, err := resMap.AsYaml()
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply kustomize overlay %s: %w", k.overlay, err)
+	}
+	return bytes.NewBuffer(out), nil
+}
+
+// findKustomization returns the path of the kustomization file in dir.
+func findKustomization(fSys filesys.FileSystem, dir string) (string, error) {
+	var found []string
+	for _, name := range konfig.RecognizedKustomizationFileNames() {
+		if path := filepath.Join(dir, name); fSys.Exists(path) {
+			found = append(found, path)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("no kustomization file (%s) found in %s", strings.Join(konfig.RecognizedKustomizationFileNames(), ", "), dir)
+	case 1:
+		return found[0], nil
+	default:
+		return "", fmt.Errorf("multiple kustomization files found in %s", dir)
+	}
+}
+
+// overlayFS serves files from memory in place of, or in addition to, those
+// of a file system.
+type overlayFS struct {
+	filesys.FileSystem
+	// files are indexed by their absolute path, without symbolic links.
+	files map[string][]byte
+}
+
+func (fs *overlayFS) lookup(path string) ([]byte, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, false
+	}
+	data, ok := fs.files[abs]
+	return data, ok
+}
+
+func (fs *overlayFS) Exists(path string) bool {
+	if _, ok := fs.lookup(path); ok {
+		return true
+	}
+	return fs.FileSystem.Exists(path)
+}
+
+func (fs *overlayFS) IsDir(path string) bool {
+	if _, ok := fs.lookup(path); ok {
+		return false
+	}
+	return fs.FileSystem.IsDir(path)
+}
+
+func (fs *overlayFS) ReadFile(path string) ([]byte, error) {
+	if data, ok := fs.lookup(path); ok {
+		return data, nil
+	}
+	return fs.FileSystem.ReadFile(path)
+}
+
+func (fs *overlayFS) CleanedAbs(path string) (filesys.ConfirmedDir, string, error) {
+	if _, ok := fs.lookup(path); ok {
+		abs, _ := filepath.Abs(path)
+		return filesys.ConfirmedDir(filepath.Dir(abs)), filepath.Base(abs), nil
+	}
+	return fs.FileSystem.CleanedAbs(path)
+}
@@ -0,0 +1,116 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postrender
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRenderedManifests = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  key: value
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 80
+`
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestKustomizeRun(t *testing.T) {
+	is := assert.New(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"base/kustomization.yaml": "resources:\n- extra.yaml\n",
+		"base/extra.yaml":         "apiVersion: v1\nkind: Secret\nmetadata:\n  name: extra\n",
+		"overlay/kustomization.yaml": `namePrefix: prod-
+resources:
+- ../base
+patches:
+- path: patch.yaml
+`,
+		"overlay/patch.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\ndata:\n  key: patched\n",
+	})
+
+	pr, err := NewKustomize(filepath.Join(dir, "overlay"))
+	require.NoError(t, err)
+	out, err := pr.Run(bytes.NewBufferString(testRenderedManifests))
+	require.NoError(t, err)
+
+	is.Contains(out.String(), "name: prod-config")
+	is.Contains(out.String(), "key: patched")
+	is.Contains(out.String(), "name: prod-web")
+	is.Contains(out.String(), "name: prod-extra")
+
+	// The overlay is left untouched
+	data, err := os.ReadFile(filepath.Join(dir, "overlay", "kustomization.yaml"))
+	require.NoError(t, err)
+	is.NotContains(string(data), kustomizeResource)
+	is.NoFileExists(filepath.Join(dir, "overlay", kustomizeResource))
+}
+
+func TestKustomizeErrors(t *testing.T) {
+	is := assert.New(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"empty/README.md":              "not an overlay",
+		"malformed/kustomization.yaml": "resources: {not: a list}\n",
+		"badpatch/kustomization.yaml":  "patches:\n- path: missing.yaml\n",
+		"twice/kustomization.yaml":     "namePrefix: a-\n",
+		"twice/kustomization.yml":      "namePrefix: b-\n",
+	})
+
+	_, err := NewKustomize(filepath.Join(dir, "does-not-exist"))
+	is.ErrorContains(err, "unable to find kustomize overlay")
+	_, err = NewKustomize(filepath.Join(dir, "empty/README.md"))
+	is.ErrorContains(err, "is not a directory")
+	_, err = NewKustomize(filepath.Join(dir, "empty"))
+	is.ErrorContains(err, "no kustomization file")
+	_, err = NewKustomize(filepath.Join(dir, "twice"))
+	is.ErrorContains(err, "multiple kustomization files")
+
+	pr, err := NewKustomize(filepath.Join(dir, "malformed"))
+	require.NoError(t, err)
+	_, err = pr.Run(bytes.NewBufferString(testRenderedManifests))
+	is.ErrorContains(err, "resources must be a list")
+
+	pr, err = NewKustomize(filepath.Join(dir, "badpatch"))
+	require.NoError(t, err)
+	_, err = pr.Run(bytes.NewBufferString(testRenderedManifests))
+	is.ErrorContains(err, "failed to apply kustomize overlay")
+	is.ErrorContains(err, "missing.yaml")
+}
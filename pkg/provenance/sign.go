@@ -24,11 +24,14 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
-	"golang.org/x/crypto/openpgp"           //nolint
-	"golang.org/x/crypto/openpgp/clearsign" //nolint
-	"golang.org/x/crypto/openpgp/packet"    //nolint
+	"golang.org/x/crypto/openpgp"                  //nolint
+	"golang.org/x/crypto/openpgp/armor"            //nolint
+	"golang.org/x/crypto/openpgp/clearsign"        //nolint
+	pgperrors "golang.org/x/crypto/openpgp/errors" //nolint
+	"golang.org/x/crypto/openpgp/packet"           //nolint
 	"sigs.k8s.io/yaml"
 
 	hapi "helm.sh/helm/v4/pkg/chart/v2"
@@ -60,6 +63,9 @@ type SumCollection struct {
 type Verification struct {
 	// SignedBy contains the entity that signed a chart.
 	SignedBy *openpgp.Entity
+	// Signers contains every distinct trusted entity with a valid signature
+	// on the chart, starting with SignedBy.
+	Signers []*openpgp.Entity
 	// FileHash is the hash, prepended with the scheme, for the file that was verified.
 	FileHash string
 	// FileName is the name of the file that FileHash verifies.
@@ -265,11 +271,12 @@ func (s *Signatory) Verify(chartpath, sigpath string) (*Verification, error) {
 		return ver, fmt.Errorf("failed to decode signature: %w", err)
 	}
 
-	by, err := s.verifySignature(sig)
+	signers, err := s.verifySignatures(sig)
 	if err != nil {
 		return ver, err
 	}
-	ver.SignedBy = by
+	ver.SignedBy = signers[0]
+	ver.Signers = signers
 
 	// Second, verify the hash of the tarball.
 	sum, err := DigestFile(chartpath)
@@ -311,13 +318,190 @@ func (s *Signatory) decodeSignature(filename string) (*clearsign.Block, error) {
 	return block, nil
 }
 
+// VerifyThreshold verifies a chart like Verify, and additionally requires it
+// to be signed by at least threshold distinct keys from the keyring.
+func (s *Signatory) VerifyThreshold(chartpath, sigpath string, threshold int) (*Verification, error) {
+	ver, err := s.Verify(chartpath, sigpath)
+	if err != nil {
+		return ver, err
+	}
+	if len(ver.Signers) < threshold {
+		return ver, fmt.Errorf("chart is signed by %d trusted key(s), but %d are required", len(ver.Signers), threshold)
+	}
+	return ver, nil
+}
+
+// AddSignature signs the message of an existing provenance file with the key
+// of the Signatory, and returns the provenance with the new signature added
+// to those already present.
+//
+// All signatures are kept in the single signature block of the clear signed
+// message, as OpenPGP allows, so provenance files with several signatures can
+// be verified by any client that trusts one of the signers.
+func (s *Signatory) AddSignature(provenance string) (string, error) {
+	if s.Entity == nil {
+		return "", errors.New("private key not found")
+	} else if s.Entity.PrivateKey == nil {
+		return "", errors.New("provided key is not a private key. Try providing a keyring with secret keys")
+	}
+
+	block, _ := clearsign.Decode([]byte(provenance))
+	if block == nil {
+		return "", errors.New("signature block not found")
+	}
+	existing, err := io.ReadAll(block.ArmoredSignature.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read signatures: %w", err)
+	}
+	sigs, err := readSignatures(existing)
+	if err != nil {
+		return "", err
+	}
+	for _, sig := range sigs {
+		if sig.IssuerKeyId != nil && *sig.IssuerKeyId == s.Entity.PrivateKey.KeyId {
+			return "", fmt.Errorf("provenance is already signed by key %X", s.Entity.PrivateKey.Fingerprint)
+		}
+	}
+
+	// Sign the message again on its own, then merge the signatures.
+	signed := bytes.NewBuffer(nil)
+	w, err := clearsign.Encode(signed, s.Entity.PrivateKey, &defaultPGPConfig)
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(block.Plaintext); err != nil {
+		return "", fmt.Errorf("failed to write to clearsign encoder: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("failed to either sign or armor message block: %w", err)
+	}
+	resigned, _ := clearsign.Decode(signed.Bytes())
+	if resigned == nil || !bytes.Equal(resigned.Bytes, block.Bytes) {
+		return "", errors.New("failed to reproduce the signed message")
+	}
+	added, err := io.ReadAll(resigned.ArmoredSignature.Body)
+	if err != nil {
+		return "", err
+	}
+
+	// Keep the message as encoded, declaring every hash in use.
+	text, _, _ := strings.Cut(signed.String(), "-----BEGIN PGP SIGNATURE-----")
+	hashes := block.Headers.Values("Hash")
+	for _, h := range resigned.Headers.Values("Hash") {
+		if !slices.Contains(hashes, h) {
+			hashes = append(hashes, h)
+		}
+	}
+	header := "Hash: " + strings.Join(resigned.Headers.Values("Hash"), ",") + "\n"
+	text = strings.Replace(text, header, "Hash: "+strings.Join(hashes, ",")+"\n", 1)
+
+	out := bytes.NewBufferString(text)
+	aw, err := armor.Encode(out, "PGP SIGNATURE", nil)
+	if err != nil {
+		return "", err
+	}
+	if _, err := aw.Write(append(existing, added...)); err != nil {
+		return "", err
+	}
+	if err := aw.Close(); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// AppendSign signs the chart at chartpath like ClearSign, adding the signature
+// to those of its existing provenance file at sigpath rather than replacing
+// them. It fails if the provenance file is not for the chart, as it would be
+// if the chart was packaged again after the provenance file was signed.
+func (s *Signatory) AppendSign(chartpath, sigpath string) (string, error) {
+	block, err := s.decodeSignature(sigpath)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode signature: %w", err)
+	}
+	_, sums, err := parseMessageBlock(block.Plaintext)
+	if err != nil {
+		return "", err
+	}
+	sum, err := DigestFile(chartpath)
+	if err != nil {
+		return "", err
+	}
+	basename := filepath.Base(chartpath)
+	if sums.Files[basename] != "sha256:"+sum {
+		return "", fmt.Errorf("provenance file %s is not for the chart archive %s", sigpath, chartpath)
+	}
+
+	data, err := os.ReadFile(sigpath)
+	if err != nil {
+		return "", err
+	}
+	return s.AddSignature(string(data))
+}
+
 // verifySignature verifies that the given block is validly signed, and returns the signer.
 func (s *Signatory) verifySignature(block *clearsign.Block) (*openpgp.Entity, error) {
-	return openpgp.CheckDetachedSignature(
-		s.KeyRing,
-		bytes.NewBuffer(block.Bytes),
-		block.ArmoredSignature.Body,
-	)
+	signers, err := s.verifySignatures(block)
+	if err != nil {
+		return nil, err
+	}
+	return signers[0], nil
+}
+
+// verifySignatures checks every signature of the given block made by a key in
+// the keyring, and returns the distinct signers. It fails if none of the
+// signatures is by a trusted key, or if any of those is invalid.
+func (s *Signatory) verifySignatures(block *clearsign.Block) ([]*openpgp.Entity, error) {
+	raw, err := io.ReadAll(block.ArmoredSignature.Body)
+	if err != nil {
+		return nil, err
+	}
+	sigs, err := readSignatures(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var signers []*openpgp.Entity
+	seen := map[[20]byte]bool{}
+	for _, sig := range sigs {
+		var one bytes.Buffer
+		if err := sig.Serialize(&one); err != nil {
+			return nil, err
+		}
+		by, err := openpgp.CheckDetachedSignature(s.KeyRing, bytes.NewReader(block.Bytes), &one)
+		if errors.Is(err, pgperrors.ErrUnknownIssuer) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !seen[by.PrimaryKey.Fingerprint] {
+			seen[by.PrimaryKey.Fingerprint] = true
+			signers = append(signers, by)
+		}
+	}
+	if len(signers) == 0 {
+		return nil, pgperrors.ErrUnknownIssuer
+	}
+	return signers, nil
+}
+
+// readSignatures parses the signature packets in raw. Packets of unsupported
+// versions or algorithms are skipped.
+func readSignatures(raw []byte) ([]*packet.Signature, error) {
+	var sigs []*packet.Signature
+	packets := packet.NewReader(bytes.NewReader(raw))
+	for {
+		p, err := packets.Next()
+		if err == io.EOF {
+			return sigs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read signatures: %w", err)
+		}
+		if sig, ok := p.(*packet.Signature); ok {
+			sigs = append(sigs, sig)
+		}
+	}
 }
 
 func messageBlock(chartpath string) (*bytes.Buffer, error) {
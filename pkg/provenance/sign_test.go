@@ -24,6 +24,7 @@ import (
 	"strings"
 	"testing"
 
+	"golang.org/x/crypto/openpgp"                  //nolint
 	pgperrors "golang.org/x/crypto/openpgp/errors" //nolint
 )
 
@@ -342,3 +343,133 @@ func readSumFile(sumfile string) (string, error) {
 	parts := strings.SplitN(sig, " ", 2)
 	return parts[0], nil
 }
+
+func TestAddSignature(t *testing.T) {
+	first, err := NewFromFiles(testKeyfile, testPubfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewFromKeyring(testPasswordKeyfile, testPasswordKeyName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := second.DecryptKey(func(_ string) ([]byte, error) {
+		return []byte("secret"), nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	sig, err := first.ClearSign(testChartfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err = second.AddSignature(sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sig, testMessageBlock) {
+		t.Errorf("expected message block to be in sig: %s", sig)
+	}
+	if _, err := second.AddSignature(sig); err == nil {
+		t.Error("expected an error signing twice with the same key")
+	}
+
+	sigpath := filepath.Join(t.TempDir(), "hashtest-1.2.3.tgz.prov")
+	if err := os.WriteFile(sigpath, []byte(sig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A keyring trusting a single signer still verifies the chart.
+	if ver, err := first.Verify(testChartfile, sigpath); err != nil {
+		t.Fatal(err)
+	} else if len(ver.Signers) != 1 {
+		t.Errorf("expected 1 trusted signer, got %d", len(ver.Signers))
+	}
+	if _, err := first.VerifyThreshold(testChartfile, sigpath, 2); err == nil {
+		t.Error("expected a threshold of 2 to fail with a single trusted key")
+	}
+
+	both := &Signatory{KeyRing: openpgp.EntityList{first.Entity, second.Entity}}
+	ver, err := both.VerifyThreshold(testChartfile, sigpath, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ver.Signers) != 2 {
+		t.Fatalf("expected 2 signers, got %d", len(ver.Signers))
+	}
+	if _, ok := ver.SignedBy.Identities[testKeyName]; !ok {
+		t.Errorf("expected the first signer to be %q", testKeyName)
+	}
+	if _, ok := ver.Signers[1].Identities[testPasswordKeyName]; !ok {
+		t.Errorf("expected the second signer to be %q", testPasswordKeyName)
+	}
+}
+
+func TestVerifyThresholdSingleSignature(t *testing.T) {
+	signer, err := NewFromFiles(testKeyfile, testPubfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ver, err := signer.VerifyThreshold(testChartfile, testSigBlock, 1); err != nil {
+		t.Fatal(err)
+	} else if len(ver.Signers) != 1 || ver.Signers[0] != ver.SignedBy {
+		t.Errorf("expected SignedBy to be the only signer, got %v", ver.Signers)
+	}
+	if _, err := signer.VerifyThreshold(testChartfile, testSigBlock, 2); err == nil {
+		t.Error("expected a threshold of 2 to fail")
+	}
+}
+
+func TestAppendSign(t *testing.T) {
+	first, err := NewFromFiles(testKeyfile, testPubfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewFromKeyring(testPasswordKeyfile, testPasswordKeyName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := second.DecryptKey(func(_ string) ([]byte, error) {
+		return []byte("secret"), nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	chartpath := filepath.Join(dir, "hashtest-1.2.3.tgz")
+	data, err := os.ReadFile(testChartfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(chartpath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	sig, err := first.ClearSign(chartpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(chartpath+".prov", []byte(sig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sig, err = second.AppendSign(chartpath, chartpath+".prov")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(chartpath+".prov", []byte(sig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	both := &Signatory{KeyRing: openpgp.EntityList{first.Entity, second.Entity}}
+	if _, err := both.VerifyThreshold(chartpath, chartpath+".prov", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// A provenance file for another archive is not signed.
+	if err := os.WriteFile(chartpath, append(data, 0), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := first.AppendSign(chartpath, chartpath+".prov"); err == nil || !strings.Contains(err.Error(), "is not for the chart archive") {
+		t.Errorf("expected an error for a provenance file of another archive, got %v", err)
+	}
+}
@@ -0,0 +1,217 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provenance
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/sign"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+// SigstoreBundleExt is appended to the name of a chart archive to form the
+// name of the file holding its sigstore bundle.
+const SigstoreBundleExt = ".sigstore.json"
+
+const (
+	// DefaultFulcioURL is the public Fulcio certificate authority.
+	DefaultFulcioURL = "https://fulcio.sigstore.dev"
+	// DefaultRekorURL is the public Rekor transparency log.
+	DefaultRekorURL = "https://rekor.sigstore.dev"
+)
+
+// SigstoreSignOptions configures keyless signing with sigstore.
+type SigstoreSignOptions struct {
+	// IDToken is the OIDC identity token exchanged with Fulcio for a
+	// short-lived signing certificate. It is required.
+	IDToken string
+	// FulcioURL is the certificate authority to use. Defaults to DefaultFulcioURL.
+	FulcioURL string
+	// RekorURL is the transparency log to record the signature in. Defaults
+	// to DefaultRekorURL.
+	RekorURL string
+}
+
+// SigstoreVerifyOptions configures the verification of a sigstore bundle.
+//
+// The identity the signing certificate was issued to, and the issuer of the
+// OIDC token it was issued for, must both be given, either exactly or as
+// regular expressions. Any identity can obtain a certificate from a public
+// certificate authority, so a signature alone proves nothing.
+type SigstoreVerifyOptions struct {
+	// CertificateIdentity is the expected subject alternative name of the
+	// signing certificate, such as an email address or a workflow URL.
+	CertificateIdentity string
+	// CertificateIdentityRegexp is a regular expression the subject
+	// alternative name must match, used instead of CertificateIdentity.
+	CertificateIdentityRegexp string
+	// CertificateOIDCIssuer is the expected issuer of the OIDC token.
+	CertificateOIDCIssuer string
+	// CertificateOIDCIssuerRegexp is a regular expression the issuer must
+	// match, used instead of CertificateOIDCIssuer.
+	CertificateOIDCIssuerRegexp string
+	// TrustedRootPath is the path of a sigstore trusted root JSON file. If it
+	// is empty, the trusted root of the public sigstore instance is fetched
+	// and cached using TUF.
+	TrustedRootPath string
+}
+
+// SigstoreVerification contains information about a verified sigstore
+// signature.
+type SigstoreVerification struct {
+	// Identity is the subject alternative name of the signing certificate.
+	Identity string
+	// Issuer is the issuer of the OIDC token the certificate was issued for.
+	Issuer string
+	// FileHash is the hash, prepended with the scheme, for the file that was verified.
+	FileHash string
+	// FileName is the name of the file that FileHash verifies.
+	FileName string
+}
+
+// SignSigstore signs the chart archive at chartpath with an ephemeral key,
+// certified by Fulcio for the identity of opts.IDToken, and records the
+// signature in Rekor. It returns the JSON encoded sigstore bundle, which is
+// by convention written next to the archive with SigstoreBundleExt appended.
+func SignSigstore(chartpath string, opts SigstoreSignOptions) ([]byte, error) {
+	if opts.IDToken == "" {
+		return nil, errors.New("an OIDC identity token is required for sigstore signing")
+	}
+	fulcioURL := opts.FulcioURL
+	if fulcioURL == "" {
+		fulcioURL = DefaultFulcioURL
+	}
+	rekorURL := opts.RekorURL
+	if rekorURL == "" {
+		rekorURL = DefaultRekorURL
+	}
+
+	return signSigstoreBundle(chartpath, sign.BundleOptions{
+		CertificateProvider:        sign.NewFulcio(&sign.FulcioOptions{BaseURL: fulcioURL}),
+		CertificateProviderOptions: &sign.CertificateProviderOptions{IDToken: opts.IDToken},
+		TransparencyLogs:           []sign.Transparency{sign.NewRekor(&sign.RekorOptions{BaseURL: rekorURL})},
+	})
+}
+
+func signSigstoreBundle(chartpath string, opts sign.BundleOptions) ([]byte, error) {
+	data, err := readChartArchive(chartpath)
+	if err != nil {
+		return nil, err
+	}
+
+	keypair, err := sign.NewEphemeralKeypair(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	pb, err := sign.Bundle(&sign.PlainData{Data: data}, keypair, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign %s: %w", chartpath, err)
+	}
+	b, err := bundle.NewBundle(pb)
+	if err != nil {
+		return nil, err
+	}
+	return b.MarshalJSON()
+}
+
+// VerifySigstore verifies the chart archive at chartpath against the sigstore
+// bundle at bundlepath.
+//
+// The signing certificate must chain to the trusted root, have been issued
+// to the identity described by opts, and the signature must have been
+// recorded in a trusted transparency log while the certificate was valid.
+func VerifySigstore(chartpath, bundlepath string, opts SigstoreVerifyOptions) (*SigstoreVerification, error) {
+	identity, err := opts.certificateIdentity()
+	if err != nil {
+		return nil, err
+	}
+
+	var trustedRoot *root.TrustedRoot
+	if opts.TrustedRootPath != "" {
+		trustedRoot, err = root.NewTrustedRootFromPath(opts.TrustedRootPath)
+	} else {
+		trustedRoot, err = root.FetchTrustedRoot()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sigstore trusted root: %w", err)
+	}
+
+	return verifySigstoreBundle(chartpath, bundlepath, identity, trustedRoot,
+		verify.WithSignedCertificateTimestamps(1),
+		verify.WithTransparencyLog(1),
+		verify.WithObserverTimestamps(1),
+	)
+}
+
+func (o SigstoreVerifyOptions) certificateIdentity() (verify.CertificateIdentity, error) {
+	if o.CertificateIdentity == "" && o.CertificateIdentityRegexp == "" {
+		return verify.CertificateIdentity{}, errors.New("a certificate identity is required to verify sigstore signatures")
+	}
+	if o.CertificateOIDCIssuer == "" && o.CertificateOIDCIssuerRegexp == "" {
+		return verify.CertificateIdentity{}, errors.New("a certificate OIDC issuer is required to verify sigstore signatures")
+	}
+	return verify.NewShortCertificateIdentity(o.CertificateOIDCIssuer, o.CertificateOIDCIssuerRegexp, o.CertificateIdentity, o.CertificateIdentityRegexp)
+}
+
+func verifySigstoreBundle(chartpath, bundlepath string, identity verify.CertificateIdentity, trustedMaterial root.TrustedMaterial, verifierOpts ...verify.VerifierOption) (*SigstoreVerification, error) {
+	data, err := readChartArchive(chartpath)
+	if err != nil {
+		return nil, err
+	}
+	b, err := bundle.LoadJSONFromPath(bundlepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sigstore bundle %s: %w", bundlepath, err)
+	}
+
+	verifier, err := verify.NewVerifier(trustedMaterial, verifierOpts...)
+	if err != nil {
+		return nil, err
+	}
+	result, err := verifier.Verify(b, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(data)), verify.WithCertificateIdentity(identity)))
+	if err != nil {
+		return nil, fmt.Errorf("sigstore verification failed: %w", err)
+	}
+
+	sum, err := Digest(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	ver := &SigstoreVerification{
+		FileHash: "sha256:" + sum,
+		FileName: filepath.Base(chartpath),
+	}
+	if result.Signature != nil && result.Signature.Certificate != nil {
+		ver.Identity = result.Signature.Certificate.SubjectAlternativeName
+		ver.Issuer = result.Signature.Certificate.Issuer
+	}
+	return ver, nil
+}
+
+func readChartArchive(chartpath string) ([]byte, error) {
+	if fi, err := os.Stat(chartpath); err != nil {
+		return nil, err
+	} else if fi.IsDir() {
+		return nil, errors.New("cannot sign a directory")
+	}
+	return os.ReadFile(chartpath)
+}
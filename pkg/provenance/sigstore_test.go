@@ -0,0 +1,236 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provenance
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/sign"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+const (
+	testSigstoreIdentity = "helm-testing@helm.sh"
+	testSigstoreIssuer   = "https://issuer.example.com"
+)
+
+// testCertificateAuthority issues Fulcio style certificates without network
+// access, standing in for Fulcio.
+type testCertificateAuthority struct {
+	root.BaseTrustedMaterial
+	rootCert, intermediate *x509.Certificate
+	intermediateKey        *ecdsa.PrivateKey
+}
+
+func newTestCertificateAuthority(t *testing.T) *testCertificateAuthority {
+	t.Helper()
+	rootCert, rootKey, err := ca.GenerateRootCa()
+	if err != nil {
+		t.Fatal(err)
+	}
+	intermediate, intermediateKey, err := ca.GenerateFulcioIntermediate(rootCert, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCertificateAuthority{rootCert: rootCert, intermediate: intermediate, intermediateKey: intermediateKey}
+}
+
+func (c *testCertificateAuthority) FulcioCertificateAuthorities() []root.CertificateAuthority {
+	return []root.CertificateAuthority{&root.FulcioCertificateAuthority{
+		Root:          c.rootCert,
+		Intermediates: []*x509.Certificate{c.intermediate},
+	}}
+}
+
+func (c *testCertificateAuthority) GetCertificate(_ context.Context, keypair sign.Keypair, _ *sign.CertificateProviderOptions) ([]byte, error) {
+	keyPEM, err := keypair.GetPublicKeyPem()
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode([]byte(keyPEM))
+	if block == nil {
+		return nil, errors.New("invalid public key")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:   big.NewInt(2),
+		EmailAddresses: []string{testSigstoreIdentity},
+		NotBefore:      time.Now().Add(-time.Minute),
+		NotAfter:       time.Now().Add(10 * time.Minute),
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		ExtraExtensions: []pkix.Extension{{
+			// Fulcio OIDC issuer extension
+			Id:    asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1},
+			Value: []byte(testSigstoreIssuer),
+		}},
+	}
+	return x509.CreateCertificate(rand.Reader, tmpl, c.intermediate, pub, c.intermediateKey)
+}
+
+// signTestChart signs a copy of testChartfile and returns the paths of the
+// copy and its bundle.
+func signTestChart(t *testing.T, authority *testCertificateAuthority) (string, string) {
+	t.Helper()
+	data, err := os.ReadFile(testChartfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chartpath := filepath.Join(t.TempDir(), filepath.Base(testChartfile))
+	if err := os.WriteFile(chartpath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sig, err := signSigstoreBundle(chartpath, sign.BundleOptions{CertificateProvider: authority})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundlepath := chartpath + SigstoreBundleExt
+	if err := os.WriteFile(bundlepath, sig, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return chartpath, bundlepath
+}
+
+func verifyTestChart(chartpath, bundlepath string, authority *testCertificateAuthority, opts SigstoreVerifyOptions) (*SigstoreVerification, error) {
+	identity, err := opts.certificateIdentity()
+	if err != nil {
+		return nil, err
+	}
+	return verifySigstoreBundle(chartpath, bundlepath, identity, authority, verify.WithCurrentTime())
+}
+
+func TestSignSigstoreRequiresIDToken(t *testing.T) {
+	if _, err := SignSigstore(testChartfile, SigstoreSignOptions{}); err == nil {
+		t.Fatal("expected an error without an identity token")
+	}
+}
+
+func TestSignSigstoreDirectory(t *testing.T) {
+	if _, err := signSigstoreBundle("testdata/hashtest", sign.BundleOptions{}); err == nil {
+		t.Fatal("expected an error signing a directory")
+	}
+}
+
+func TestVerifySigstore(t *testing.T) {
+	authority := newTestCertificateAuthority(t)
+	chartpath, bundlepath := signTestChart(t, authority)
+
+	sum, err := DigestFile(testChartfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		opts    SigstoreVerifyOptions
+		wantErr string
+	}{
+		{
+			name: "exact identity",
+			opts: SigstoreVerifyOptions{CertificateIdentity: testSigstoreIdentity, CertificateOIDCIssuer: testSigstoreIssuer},
+		},
+		{
+			name: "identity regexp",
+			opts: SigstoreVerifyOptions{CertificateIdentityRegexp: `@helm\.sh$`, CertificateOIDCIssuerRegexp: `example\.com`},
+		},
+		{
+			name:    "wrong identity",
+			opts:    SigstoreVerifyOptions{CertificateIdentity: "someone@example.com", CertificateOIDCIssuer: testSigstoreIssuer},
+			wantErr: "sigstore verification failed",
+		},
+		{
+			name:    "wrong issuer",
+			opts:    SigstoreVerifyOptions{CertificateIdentity: testSigstoreIdentity, CertificateOIDCIssuer: "https://accounts.google.com"},
+			wantErr: "sigstore verification failed",
+		},
+		{
+			name:    "missing identity",
+			opts:    SigstoreVerifyOptions{CertificateOIDCIssuer: testSigstoreIssuer},
+			wantErr: "certificate identity is required",
+		},
+		{
+			name:    "missing issuer",
+			opts:    SigstoreVerifyOptions{CertificateIdentity: testSigstoreIdentity},
+			wantErr: "OIDC issuer is required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ver, err := verifyTestChart(chartpath, bundlepath, authority, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ver.Identity != testSigstoreIdentity {
+				t.Errorf("expected identity %q, got %q", testSigstoreIdentity, ver.Identity)
+			}
+			if ver.Issuer != testSigstoreIssuer {
+				t.Errorf("expected issuer %q, got %q", testSigstoreIssuer, ver.Issuer)
+			}
+			if ver.FileHash != "sha256:"+sum {
+				t.Errorf("expected hash sha256:%s, got %s", sum, ver.FileHash)
+			}
+			if ver.FileName != "hashtest-1.2.3.tgz" {
+				t.Errorf("unexpected file name %s", ver.FileName)
+			}
+		})
+	}
+}
+
+func TestVerifySigstoreTampered(t *testing.T) {
+	authority := newTestCertificateAuthority(t)
+	chartpath, bundlepath := signTestChart(t, authority)
+	opts := SigstoreVerifyOptions{CertificateIdentity: testSigstoreIdentity, CertificateOIDCIssuer: testSigstoreIssuer}
+
+	if err := os.WriteFile(chartpath, []byte("tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifyTestChart(chartpath, bundlepath, authority, opts); err == nil {
+		t.Error("expected verification of a tampered chart to fail")
+	}
+
+	// A certificate from another authority is not trusted.
+	other := newTestCertificateAuthority(t)
+	chartpath, bundlepath = signTestChart(t, other)
+	if _, err := verifyTestChart(chartpath, bundlepath, authority, opts); err == nil {
+		t.Error("expected verification with an untrusted certificate to fail")
+	}
+}
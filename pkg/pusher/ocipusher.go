@@ -86,6 +86,10 @@ func (pusher *OCIPusher) push(chartRef, href string) error {
 		pushOpts = append(pushOpts, registry.PushOptProvData(provBytes))
 	}
 
+	if len(pusher.opts.mountFrom) > 0 {
+		pushOpts = append(pushOpts, registry.PushOptMountFrom(pusher.opts.mountFrom...))
+	}
+
 	ref := fmt.Sprintf("%s:%s",
 		path.Join(strings.TrimPrefix(href, fmt.Sprintf("%s://", registry.OCIScheme)), meta.Metadata.Name),
 		meta.Metadata.Version)
@@ -43,6 +43,7 @@ func TestNewOCIPusher(t *testing.T) {
 		WithTLSClientConfig(pub, priv, ca),
 		WithInsecureSkipTLSVerify(insecureSkipTLSverify),
 		WithPlainHTTP(plainHTTP),
+		WithMountFrom("charts/a", "charts/b"),
 	)
 	if err != nil {
 		t.Fatal(err)
@@ -73,6 +74,10 @@ func TestNewOCIPusher(t *testing.T) {
 		t.Errorf("Expected NewOCIPusher to have insecureSkipVerifyTLS as %t, got %t", insecureSkipTLSverify, op.opts.insecureSkipTLSverify)
 	}
 
+	if len(op.opts.mountFrom) != 2 || op.opts.mountFrom[1] != "charts/b" {
+		t.Errorf("Expected NewOCIPusher to mount from charts/a and charts/b, got %v", op.opts.mountFrom)
+	}
+
 	// Test if setting registryClient is being passed to the ops
 	registryClient, err := registry.NewClient()
 	if err != nil {
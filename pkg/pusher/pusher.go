@@ -34,6 +34,7 @@ type options struct {
 	caFile                string
 	insecureSkipTLSverify bool
 	plainHTTP             bool
+	mountFrom             []string
 }
 
 // Option allows specifying various settings configurable by the user for overriding the defaults
@@ -69,6 +70,14 @@ func WithPlainHTTP(plainHTTP bool) Option {
 	}
 }
 
+// WithMountFrom sets the repositories of the registry to mount the blobs of
+// the chart from rather than uploading them.
+func WithMountFrom(repositories ...string) Option {
+	return func(opts *options) {
+		opts.mountFrom = repositories
+	}
+}
+
 // Pusher is an interface to support upload to the specified URL.
 type Pusher interface {
 	// Push file content by url string
@@ -27,6 +27,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -68,16 +69,21 @@ type (
 		debug       bool
 		enableCache bool
 		// path to repository config file e.g. ~/.docker/config.json
-		credentialsFile    string
-		username           string
-		password           string
-		out                io.Writer
-		authorizer         *auth.Client
-		registryAuthorizer RemoteClient
-		credentialsStore   credentials.Store
-		httpClient         *http.Client
-		plainHTTP          bool
-		err                error // pass any errors from the ClientOption functions
+		credentialsFile string
+		username        string
+		password        string
+		out             io.Writer
+		authorizer      *auth.Client
+		// anonymousAuthorizer retries the pulls rejected with the stored
+		// credentials, unless the authorizer is set with ClientOptAuthorizer.
+		anonymousAuthorizer *auth.Client
+		registryAuthorizer  RemoteClient
+		credentialsStore    credentials.Store
+		httpClient          *http.Client
+		plainHTTP           bool
+		err                 error // pass any errors from the ClientOption functions
+
+		blobs blobRepositories
 	}
 
 	// ClientOption allows specifying various settings configurable by the user for overriding the defaults
@@ -147,13 +153,14 @@ func NewClient(options ...ClientOption) (*Client, error) {
 		}
 		authorizer.SetUserAgent(version.GetUserAgent())
 
-		authorizer.Credential = credentials.Credential(client.credentialsStore)
+		authorizer.Credential = client.credential
 
 		if client.enableCache {
 			authorizer.Cache = auth.NewCache()
 		}
 
 		client.authorizer = &authorizer
+		client.anonymousAuthorizer = client.newAnonymousAuthorizer()
 	}
 
 	return client, nil
@@ -451,7 +458,7 @@ func (c *Client) Pull(ref string, options ...PullOption) (*PullResult, error) {
 		return nil, err
 	}
 	repository.PlainHTTP = c.plainHTTP
-	repository.Client = c.authorizer
+	repository.Client = c.pullClient()
 
 	ctx := context.Background()
 
@@ -476,6 +483,7 @@ func (c *Client) Pull(ref string, options ...PullOption) (*PullResult, error) {
 	if err != nil {
 		return nil, err
 	}
+	c.blobs.record(parsedRef, layers...)
 
 	descriptors = append(descriptors, layers...)
 
@@ -605,7 +613,26 @@ type (
 		Config   *descriptorPushSummary         `json:"config"`
 		Chart    *descriptorPushSummaryWithMeta `json:"chart"`
 		Prov     *descriptorPushSummary         `json:"prov"`
-		Ref      string                         `json:"ref"`
+		// Referrers holds the manifests of the artifacts attached to the
+		// chart, in the order they were given.
+		Referrers []*descriptorPushSummary `json:"referrers,omitempty"`
+		Ref       string                   `json:"ref"`
+	}
+
+	// Referrer is an artifact attached to a chart on push, such as an SBOM or
+	// a signature. It is pushed as an OCI artifact manifest whose subject is
+	// the chart manifest, so that it can be discovered through the referrers
+	// API.
+	Referrer struct {
+		// ArtifactType is the type of the artifact, such as
+		// "application/spdx+json". It is required.
+		ArtifactType string
+		// MediaType is the media type of Data. It defaults to ArtifactType.
+		MediaType string
+		// Data is the content of the artifact.
+		Data []byte
+		// Annotations are set on the artifact manifest.
+		Annotations map[string]string
 	}
 
 	descriptorPushSummary struct {
@@ -622,10 +649,22 @@ type (
 		provData     []byte
 		strictMode   bool
 		creationTime string
+		annotations  map[string]string
+		referrers    []Referrer
+
+		chunkSize int64
+		mountFrom []string
 	}
 )
 
 // Push uploads a chart to a registry.
+//
+// Blobs already present in the repository are not uploaded again, and blobs
+// in other repositories of the registry, given with PushOptMountFrom or that
+// the client pushed or pulled, are mounted from them where the registry
+// allows it. Blobs larger than the chunk size are uploaded in chunks,
+// resuming from the offset reported by the registry when the upload of a
+// chunk is interrupted.
 func (c *Client) Push(data []byte, ref string, options ...PushOption) (*PushResult, error) {
 	parsedRef, err := newReference(ref)
 	if err != nil {
@@ -634,6 +673,7 @@ func (c *Client) Push(data []byte, ref string, options ...PushOption) (*PushResu
 
 	operation := &pushOperation{
 		strictMode: true, // By default, enable strict mode
+		chunkSize:  DefaultPushChunkSize,
 	}
 	for _, option := range options {
 		option(operation)
@@ -684,6 +724,12 @@ func (c *Client) Push(data []byte, ref string, options ...PushOption) (*PushResu
 	})
 
 	ociAnnotations := generateOCIAnnotations(meta, operation.creationTime)
+	for key, value := range operation.annotations {
+		if slices.Contains(immutableOciAnnotations, key) {
+			return nil, fmt.Errorf("annotation %s is set from the chart and cannot be overridden", key)
+		}
+		ociAnnotations[key] = value
+	}
 
 	manifestDescriptor, err := c.tagManifest(ctx, memoryStore, configDescriptor,
 		layers, ociAnnotations, parsedRef)
@@ -691,6 +737,14 @@ func (c *Client) Push(data []byte, ref string, options ...PushOption) (*PushResu
 		return nil, err
 	}
 
+	referrerDescriptors := make([]ocispec.Descriptor, len(operation.referrers))
+	for i, referrer := range operation.referrers {
+		referrerDescriptors[i], err = packReferrer(ctx, memoryStore, manifestDescriptor, referrer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	repository, err := remote.NewRepository(parsedRef.String())
 	if err != nil {
 		return nil, err
@@ -698,10 +752,20 @@ func (c *Client) Push(data []byte, ref string, options ...PushOption) (*PushResu
 	repository.PlainHTTP = c.plainHTTP
 	repository.Client = c.authorizer
 
-	manifestDescriptor, err = oras.ExtendedCopy(ctx, memoryStore, parsedRef.String(), repository, parsedRef.String(), oras.DefaultExtendedCopyOptions)
+	target := &chunkedRepository{
+		Repository: repository,
+		client:     c.authorizer,
+		chunkSize:  operation.chunkSize,
+	}
+	copyOptions := oras.DefaultExtendedCopyOptions
+	copyOptions.MountFrom = func(_ context.Context, desc ocispec.Descriptor) ([]string, error) {
+		return c.blobs.mountFrom(parsedRef, desc, operation.mountFrom), nil
+	}
+	manifestDescriptor, err = oras.ExtendedCopy(ctx, memoryStore, parsedRef.String(), target, parsedRef.String(), copyOptions)
 	if err != nil {
 		return nil, err
 	}
+	c.blobs.record(parsedRef, append(layers, configDescriptor)...)
 
 	chartSummary := &descriptorPushSummaryWithMeta{
 		Meta: meta,
@@ -727,6 +791,12 @@ func (c *Client) Push(data []byte, ref string, options ...PushOption) (*PushResu
 			Size:   provDescriptor.Size,
 		}
 	}
+	for _, desc := range referrerDescriptors {
+		result.Referrers = append(result.Referrers, &descriptorPushSummary{
+			Digest: desc.Digest.String(),
+			Size:   desc.Size,
+		})
+	}
 	fmt.Fprintf(c.out, "Pushed: %s\n", result.Ref)
 	fmt.Fprintf(c.out, "Digest: %s\n", result.Manifest.Digest)
 	if strings.Contains(parsedRef.orasReference.Reference, "_") {
@@ -758,8 +828,81 @@ func PushOptCreationTime(creationTime string) PushOption {
 	}
 }
 
-// Tags provides a sorted list all semver compliant tags for a given repository
-func (c *Client) Tags(ref string) ([]string, error) {
+type (
+	// TagsOption allows specifying various settings on tag listing
+	TagsOption func(*tagsOperation)
+
+	tagsOperation struct {
+		all      bool
+		pageSize int
+	}
+)
+
+// TagsOptAll returns a function that disables filtering and sorting of the
+// listed tags, so that every tag is returned in the order given by the
+// registry
+func TagsOptAll(all bool) TagsOption {
+	return func(operation *tagsOperation) {
+		operation.all = all
+	}
+}
+
+// TagsOptPageSize returns a function that sets the number of tags requested
+// from the registry at a time
+func TagsOptPageSize(pageSize int) TagsOption {
+	return func(operation *tagsOperation) {
+		operation.pageSize = pageSize
+	}
+}
+
+// PushOptAnnotations returns a function that sets additional annotations on
+// the chart manifest. Annotations derived from the chart metadata are
+// overridden, except for its name and version.
+func PushOptAnnotations(annotations map[string]string) PushOption {
+	return func(operation *pushOperation) {
+		operation.annotations = annotations
+	}
+}
+
+// PushOptReferrers returns a function that attaches artifacts to the pushed
+// chart. Registries that do not support the referrers API are updated using
+// the referrers tag schema instead.
+func PushOptReferrers(referrers ...Referrer) PushOption {
+	return func(operation *pushOperation) {
+		operation.referrers = append(operation.referrers, referrers...)
+	}
+}
+
+// packReferrer stores referrer in memoryStore as an artifact manifest with
+// subject as its subject.
+func packReferrer(ctx context.Context, memoryStore *memory.Store, subject ocispec.Descriptor, referrer Referrer) (ocispec.Descriptor, error) {
+	if referrer.ArtifactType == "" {
+		return ocispec.Descriptor{}, errors.New("referrer artifact type is required")
+	}
+	mediaType := referrer.MediaType
+	if mediaType == "" {
+		mediaType = referrer.ArtifactType
+	}
+	layer, err := oras.PushBytes(ctx, memoryStore, mediaType, referrer.Data)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	return oras.PackManifest(ctx, memoryStore, oras.PackManifestVersion1_1, referrer.ArtifactType, oras.PackManifestOptions{
+		Subject:             &subject,
+		Layers:              []ocispec.Descriptor{layer},
+		ManifestAnnotations: referrer.Annotations,
+	})
+}
+
+// Tags provides a sorted list all semver compliant tags for a given repository.
+// The registry is queried page by page, following the Link headers of its
+// responses, until every tag has been listed.
+func (c *Client) Tags(ref string, options ...TagsOption) ([]string, error) {
+	operation := &tagsOperation{}
+	for _, option := range options {
+		option(operation)
+	}
+
 	parsedReference, err := registry.ParseReference(ref)
 	if err != nil {
 		return nil, err
@@ -771,36 +914,129 @@ func (c *Client) Tags(ref string) ([]string, error) {
 		return nil, err
 	}
 	repository.PlainHTTP = c.plainHTTP
-	repository.Client = c.authorizer
-
-	var tagVersions []*semver.Version
-	err = repository.Tags(ctx, "", func(tags []string) error {
-		for _, tag := range tags {
-			// Change underscore (_) back to plus (+) for Helm
-			// See https://github.com/helm/helm/issues/10166
-			tagVersion, err := semver.StrictNewVersion(strings.ReplaceAll(tag, "_", "+"))
-			if err == nil {
-				tagVersions = append(tagVersions, tagVersion)
-			}
-		}
+	repository.Client = c.pullClient()
+	repository.TagListPageSize = operation.pageSize
 
+	var tags []string
+	err = repository.Tags(ctx, "", func(page []string) error {
+		tags = append(tags, page...)
 		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
+	return operation.process(tags), nil
+}
 
-	// Sort the collection
-	sort.Sort(sort.Reverse(semver.Collection(tagVersions)))
+// TagsPage lists a single page of the tags of a given repository, starting
+// after the tag last, or at the beginning if last is empty. Use
+// TagsOptPageSize to set the size of the page; registries may return fewer
+// tags than requested.
+//
+// Unless TagsOptAll is given, the tags of the page are filtered to those that
+// are semver compliant and sorted. The returned next tag, taken from the Link
+// header of the response, is to be passed as last to list the following page.
+// It is empty when there are no more tags.
+func (c *Client) TagsPage(ref string, last string, options ...TagsOption) (tags []string, next string, err error) {
+	operation := &tagsOperation{}
+	for _, option := range options {
+		option(operation)
+	}
 
-	tags := make([]string, len(tagVersions))
+	parsedReference, err := registry.ParseReference(ref)
+	if err != nil {
+		return nil, "", err
+	}
 
-	for iTv, tv := range tagVersions {
-		tags[iTv] = tv.String()
+	scheme := "https"
+	if c.plainHTTP {
+		scheme = "http"
+	}
+	query := url.Values{}
+	if operation.pageSize > 0 {
+		query.Set("n", fmt.Sprint(operation.pageSize))
+	}
+	if last != "" {
+		query.Set("last", last)
+	}
+	u := url.URL{
+		Scheme:   scheme,
+		Host:     parsedReference.Host(),
+		Path:     fmt.Sprintf("/v2/%s/tags/list", parsedReference.Repository),
+		RawQuery: query.Encode(),
+	}
+
+	ctx := auth.AppendRepositoryScope(context.Background(), parsedReference, auth.ActionPull)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := c.pullClient().Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to list tags of %s: %s", ref, resp.Status)
+	}
+	var page struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, "", fmt.Errorf("failed to decode tags of %s: %w", ref, err)
+	}
+
+	next, err = nextTagsPage(resp)
+	if err != nil {
+		return nil, "", err
 	}
+	return operation.process(page.Tags), next, nil
+}
 
-	return tags, nil
+// nextTagsPage returns the last query parameter of the next page linked to by
+// a tag list response, or an empty string if it is the last page.
+func nextTagsPage(resp *http.Response) (string, error) {
+	link := resp.Header.Get("Link")
+	if link == "" {
+		return "", nil
+	}
+	start, end := strings.IndexByte(link, '<'), strings.IndexByte(link, '>')
+	if start != 0 || end == -1 {
+		return "", fmt.Errorf("invalid next link %q", link)
+	}
+	linkURL, err := resp.Request.URL.Parse(link[1:end])
+	if err != nil {
+		return "", fmt.Errorf("invalid next link %q: %w", link, err)
+	}
+	return linkURL.Query().Get("last"), nil
+}
 
+// process filters tags to the semver compliant ones and sorts them, newest
+// first, unless all tags were requested.
+func (operation *tagsOperation) process(tags []string) []string {
+	if operation.all {
+		return tags
+	}
+
+	var tagVersions []*semver.Version
+	for _, tag := range tags {
+		// Change underscore (_) back to plus (+) for Helm
+		// See https://github.com/helm/helm/issues/10166
+		tagVersion, err := semver.StrictNewVersion(strings.ReplaceAll(tag, "_", "+"))
+		if err == nil {
+			tagVersions = append(tagVersions, tagVersion)
+		}
+	}
+
+	// Sort the collection
+	sort.Sort(sort.Reverse(semver.Collection(tagVersions)))
+
+	versions := make([]string, len(tagVersions))
+	for iTv, tv := range tagVersions {
+		versions[iTv] = tv.String()
+	}
+	return versions
 }
 
 // Resolve a reference to a descriptor.
@@ -810,6 +1046,7 @@ func (c *Client) Resolve(ref string) (desc ocispec.Descriptor, err error) {
 		return desc, err
 	}
 	remoteRepository.PlainHTTP = c.plainHTTP
+	remoteRepository.Client = c.pullClient()
 
 	parsedReference, err := newReference(ref)
 	if err != nil {
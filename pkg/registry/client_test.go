@@ -18,7 +18,14 @@ package registry
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
@@ -52,3 +59,65 @@ func TestTagManifestTransformsReferences(t *testing.T) {
 	_, err = memStore.Resolve(ctx, refWithPlus)
 	require.Error(t, err, "Should NOT find the reference with the original +")
 }
+
+// newTagsServer serves the tags of the repository "charts/test" page by page,
+// linking each page to the next as registries do.
+func newTagsServer(t *testing.T, tags []string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/charts/test/tags/list" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		start := 0
+		if last := r.URL.Query().Get("last"); last != "" {
+			start = slices.Index(tags, last) + 1
+		}
+		n := len(tags)
+		if v := r.URL.Query().Get("n"); v != "" {
+			n, _ = strconv.Atoi(v)
+		}
+		end := min(start+n, len(tags))
+		if end < len(tags) {
+			w.Header().Set("Link", fmt.Sprintf(`</v2/charts/test/tags/list?n=%d&last=%s>; rel="next"`, n, tags[end-1]))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"name": "charts/test", "tags": tags[start:end]})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestTagsPagination(t *testing.T) {
+	tags := []string{"0.1.0", "latest", "1.0.0", "0.2.0_build.1", "1.0.0-rc.1", "v2"}
+	srv := newTagsServer(t, tags)
+	client, err := NewClient(ClientOptPlainHTTP(), ClientOptWriter(io.Discard))
+	require.NoError(t, err)
+	ref := strings.TrimPrefix(srv.URL, "http://") + "/charts/test"
+
+	got, err := client.Tags(ref, TagsOptPageSize(2))
+	require.NoError(t, err)
+	require.Equal(t, []string{"1.0.0", "1.0.0-rc.1", "0.2.0+build.1", "0.1.0"}, got)
+
+	got, err = client.Tags(ref, TagsOptPageSize(4), TagsOptAll(true))
+	require.NoError(t, err)
+	require.Equal(t, tags, got)
+
+	var pages [][]string
+	last := ""
+	for {
+		page, next, err := client.TagsPage(ref, last, TagsOptPageSize(4), TagsOptAll(true))
+		require.NoError(t, err)
+		pages = append(pages, page)
+		if next == "" {
+			break
+		}
+		last = next
+	}
+	require.Equal(t, [][]string{tags[:4], tags[4:]}, pages)
+
+	page, next, err := client.TagsPage(ref, "", TagsOptPageSize(3))
+	require.NoError(t, err)
+	require.Equal(t, []string{"1.0.0", "0.1.0"}, page)
+	require.Equal(t, "1.0.0", next)
+}
@@ -32,6 +32,12 @@ const (
 	// ProvLayerMediaType is the reserved media type for Helm chart provenance files
 	ProvLayerMediaType = "application/vnd.cncf.helm.chart.provenance.v1.prov"
 
+	// PluginConfigMediaType is the reserved media type for the Helm plugin manifest config
+	PluginConfigMediaType = "application/vnd.cncf.helm.plugin.config.v1+json"
+
+	// PluginLayerMediaType is the reserved media type for Helm plugin package content
+	PluginLayerMediaType = "application/vnd.cncf.helm.plugin.content.v1.tar+gzip"
+
 	// LegacyChartLayerMediaType is the legacy reserved media type for Helm chart package content.
 	LegacyChartLayerMediaType = "application/tar+gzip"
 )
@@ -0,0 +1,139 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry // import "helm.sh/helm/v4/pkg/registry"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+
+	"helm.sh/helm/v4/internal/version"
+)
+
+// credential returns the credentials to access the registry at hostport
+// with: those set with ClientOptBasicAuth, else those stored for the host,
+// else none for anonymous access. The registries are accessed anonymously
+// until they ask for credentials.
+//
+// A failure to read the stored credentials, such as a missing credential
+// helper, is a *storedCredentialError. Only the pulls fall back to anonymous
+// access then, see pullClient.
+func (c *Client) credential(ctx context.Context, hostport string) (auth.Credential, error) {
+	if c.username != "" || c.password != "" {
+		return auth.Credential{Username: c.username, Password: c.password}, nil
+	}
+	return c.storedCredential(ctx, hostport)
+}
+
+// storedCredential returns the credentials stored for hostport, if any.
+func (c *Client) storedCredential(ctx context.Context, hostport string) (auth.Credential, error) {
+	cred, err := credentials.Credential(c.credentialsStore)(ctx, hostport)
+	if err != nil {
+		return auth.EmptyCredential, &storedCredentialError{host: hostport, err: err}
+	}
+	return cred, nil
+}
+
+// storedCredentialError is the error returned when the stored credentials
+// of a host cannot be read.
+type storedCredentialError struct {
+	host string
+	err  error
+}
+
+func (e *storedCredentialError) Error() string {
+	return fmt.Sprintf("unable to get the stored credentials of %s: %v", e.host, e.err)
+}
+
+func (e *storedCredentialError) Unwrap() error {
+	return e.err
+}
+
+// newAnonymousAuthorizer returns a client accessing registries without any
+// credentials.
+func (c *Client) newAnonymousAuthorizer() *auth.Client {
+	authorizer := &auth.Client{
+		Client: c.httpClient,
+	}
+	authorizer.SetUserAgent(version.GetUserAgent())
+	if c.enableCache {
+		authorizer.Cache = auth.NewCache()
+	}
+	return authorizer
+}
+
+// pullClient returns the client of the requests pulling from registries.
+//
+// When the stored credentials of a host are rejected, as when they expired
+// or belong to another account than the one owning a public repository, or
+// cannot be read, the requests reading from the registry are retried
+// anonymously.
+func (c *Client) pullClient() RemoteClient {
+	if c.anonymousAuthorizer == nil {
+		return c.authorizer
+	}
+	return &credentialFallback{client: c, anonymous: c.anonymousAuthorizer}
+}
+
+// credentialFallback retries the requests rejected with the stored
+// credentials, or failing to read them, anonymously.
+type credentialFallback struct {
+	client    *Client
+	anonymous *auth.Client
+}
+
+func (f *credentialFallback) Do(req *http.Request) (*http.Response, error) {
+	resp, err := f.client.authorizer.Do(req)
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return resp, err
+	}
+	var errStored *storedCredentialError
+	if errors.As(err, &errStored) {
+		slog.Debug("unable to get the stored credentials, accessing the registry anonymously", "host", req.URL.Host, slog.Any("error", errStored.err))
+		return f.anonymous.Do(req)
+	}
+	if !unauthorized(resp, err) {
+		return resp, err
+	}
+	if f.client.username != "" || f.client.password != "" {
+		return resp, err
+	}
+	if cred, errCred := f.client.storedCredential(req.Context(), req.URL.Host); errCred != nil || cred == auth.EmptyCredential {
+		return resp, err
+	}
+	if resp != nil {
+		resp.Body.Close()
+	}
+	slog.Debug("the stored credentials were rejected, retrying anonymously", "host", req.URL.Host, "url", req.URL.String())
+	return f.anonymous.Do(req)
+}
+
+// unauthorized returns whether a request was rejected for its credentials,
+// by the registry or by its token service.
+func unauthorized(resp *http.Response, err error) bool {
+	if err != nil {
+		var errResp *errcode.ErrorResponse
+		return errors.As(err, &errResp) && errResp.StatusCode == http.StatusUnauthorized
+	}
+	return resp.StatusCode == http.StatusUnauthorized
+}
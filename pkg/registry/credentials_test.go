@@ -0,0 +1,144 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+// writeCredentialsFile writes a credentials file storing the given username
+// and password for host, and returns its path.
+func writeCredentialsFile(t *testing.T, host, username, password string) string {
+	t.Helper()
+	config := map[string]interface{}{
+		"auths": map[string]interface{}{
+			host: map[string]string{"auth": base64.StdEncoding.EncodeToString([]byte(username + ":" + password))},
+		},
+	}
+	data, err := json.Marshal(config)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	return path
+}
+
+// writeCredentialsHelperFile writes a credentials file storing the
+// credentials with a credential helper that is not installed, and returns
+// its path.
+func writeCredentialsHelperFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"credsStore": "helm-test-missing"}`), 0600))
+	return path
+}
+
+func TestClientCredential(t *testing.T) {
+	ctx := context.Background()
+	credentialsFile := writeCredentialsFile(t, "registry.example.com", "stored", "secret")
+
+	client, err := NewClient(ClientOptCredentialsFile(credentialsFile), ClientOptWriter(io.Discard))
+	require.NoError(t, err)
+
+	cred, err := client.credential(ctx, "registry.example.com")
+	require.NoError(t, err)
+	require.Equal(t, auth.Credential{Username: "stored", Password: "secret"}, cred)
+
+	cred, err = client.credential(ctx, "public.example.com")
+	require.NoError(t, err)
+	require.Equal(t, auth.EmptyCredential, cred, "hosts without stored credentials are accessed anonymously")
+
+	client, err = NewClient(ClientOptCredentialsFile(credentialsFile), ClientOptBasicAuth("explicit", "password"), ClientOptWriter(io.Discard))
+	require.NoError(t, err)
+	for _, host := range []string{"registry.example.com", "public.example.com"} {
+		cred, err = client.credential(ctx, host)
+		require.NoError(t, err)
+		require.Equal(t, auth.Credential{Username: "explicit", Password: "password"}, cred)
+	}
+
+	// The failures to read the stored credentials are not hidden, for the
+	// pushes and logins to report them.
+	client, err = NewClient(ClientOptCredentialsFile(writeCredentialsHelperFile(t)), ClientOptWriter(io.Discard))
+	require.NoError(t, err)
+	_, err = client.credential(ctx, "registry.example.com")
+	var errStored *storedCredentialError
+	require.ErrorAs(t, err, &errStored)
+	require.ErrorContains(t, client.Login("registry.example.com"), "unable to get the stored credentials of registry.example.com")
+}
+
+func TestClientAnonymousFallback(t *testing.T) {
+	// The registry serves a public repository with anonymous tokens, and
+	// rejects the credentials it does not know.
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			if _, _, ok := r.BasicAuth(); ok {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"token": "anonymous"})
+		case "/v2/charts/test/tags/list":
+			if r.Header.Get("Authorization") != "Bearer anonymous" {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:charts/test:pull"`, srv.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"name": "charts/test", "tags": []string{"1.0.0"}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+	ref := host + "/charts/test"
+
+	// Stale stored credentials are rejected, and the pull is retried
+	// anonymously.
+	client, err := NewClient(ClientOptPlainHTTP(), ClientOptWriter(io.Discard), ClientOptCredentialsFile(writeCredentialsFile(t, host, "stale", "secret")))
+	require.NoError(t, err)
+	tags, err := client.Tags(ref)
+	require.NoError(t, err)
+	require.Equal(t, []string{"1.0.0"}, tags)
+
+	// Pulls fall back to anonymous access when the stored credentials cannot
+	// be read.
+	client, err = NewClient(ClientOptPlainHTTP(), ClientOptWriter(io.Discard), ClientOptCredentialsFile(writeCredentialsHelperFile(t)))
+	require.NoError(t, err)
+	tags, err = client.Tags(ref)
+	require.NoError(t, err)
+	require.Equal(t, []string{"1.0.0"}, tags)
+
+	// Explicit credentials are not replaced by anonymous access.
+	client, err = NewClient(ClientOptPlainHTTP(), ClientOptWriter(io.Discard), ClientOptCredentialsFile(filepath.Join(t.TempDir(), "config.json")), ClientOptBasicAuth("wrong", "secret"))
+	require.NoError(t, err)
+	_, err = client.Tags(ref)
+	require.Error(t, err)
+}
@@ -0,0 +1,157 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry // import "helm.sh/helm/v4/pkg/registry"
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+// PluginPullResult is the result returned upon successful pull of a plugin.
+type PluginPullResult struct {
+	Manifest *DescriptorPullSummary `json:"manifest"`
+	Plugin   *DescriptorPullSummary `json:"plugin"`
+	Ref      string                 `json:"ref"`
+}
+
+// PullPlugin downloads a plugin archive from a registry.
+//
+// The manifest must contain a layer with PluginLayerMediaType, holding the
+// gzip compressed tar archive of the plugin.
+func (c *Client) PullPlugin(ref string) (*PluginPullResult, error) {
+	parsedRef, err := newReference(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	repository, err := c.repository(parsedRef)
+	if err != nil {
+		return nil, err
+	}
+	repository.Client = c.pullClient()
+
+	ctx := context.Background()
+	memoryStore := memory.New()
+	manifestDescriptor, err := oras.Copy(ctx, repository, parsedRef.String(), memoryStore, "", oras.CopyOptions{
+		CopyGraphOptions: oras.CopyGraphOptions{
+			PreCopy: func(_ context.Context, desc ocispec.Descriptor) error {
+				switch desc.MediaType {
+				case ocispec.MediaTypeImageManifest, PluginConfigMediaType, PluginLayerMediaType:
+					return nil
+				}
+				return oras.SkipNode
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if manifestDescriptor.MediaType != ocispec.MediaTypeImageManifest {
+		return nil, fmt.Errorf("%s is not a plugin: unexpected media type %s", ref, manifestDescriptor.MediaType)
+	}
+
+	manifestData, err := content.FetchAll(ctx, memoryStore, manifestDescriptor)
+	if err != nil {
+		return nil, err
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return nil, err
+	}
+
+	var pluginDescriptor *ocispec.Descriptor
+	for _, layer := range manifest.Layers {
+		if layer.MediaType == PluginLayerMediaType {
+			pluginDescriptor = &layer
+			break
+		}
+	}
+	if pluginDescriptor == nil {
+		return nil, fmt.Errorf("%s is not a plugin: manifest does not contain a layer with mediatype %s", ref, PluginLayerMediaType)
+	}
+	pluginData, err := content.FetchAll(ctx, memoryStore, *pluginDescriptor)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PluginPullResult{
+		Manifest: &DescriptorPullSummary{
+			Data:   manifestData,
+			Digest: manifestDescriptor.Digest.String(),
+			Size:   manifestDescriptor.Size,
+		},
+		Plugin: &DescriptorPullSummary{
+			Data:   pluginData,
+			Digest: pluginDescriptor.Digest.String(),
+			Size:   pluginDescriptor.Size,
+		},
+		Ref: parsedRef.String(),
+	}, nil
+}
+
+// PushPlugin uploads a plugin archive, a gzip compressed tar archive holding
+// a plugin.yaml file, to a registry. It returns the digest of the manifest.
+func (c *Client) PushPlugin(data []byte, ref string) (string, error) {
+	parsedRef, err := newReference(ref)
+	if err != nil {
+		return "", err
+	}
+
+	ctx := context.Background()
+	memoryStore := memory.New()
+	pluginDescriptor, err := oras.PushBytes(ctx, memoryStore, PluginLayerMediaType, data)
+	if err != nil {
+		return "", err
+	}
+	configDescriptor, err := oras.PushBytes(ctx, memoryStore, PluginConfigMediaType, []byte("{}"))
+	if err != nil {
+		return "", err
+	}
+	if _, err := c.tagManifest(ctx, memoryStore, configDescriptor,
+		[]ocispec.Descriptor{pluginDescriptor}, nil, parsedRef); err != nil {
+		return "", err
+	}
+
+	repository, err := c.repository(parsedRef)
+	if err != nil {
+		return "", err
+	}
+	manifestDescriptor, err := oras.Copy(ctx, memoryStore, parsedRef.String(), repository, parsedRef.String(), oras.DefaultCopyOptions)
+	if err != nil {
+		return "", err
+	}
+	return manifestDescriptor.Digest.String(), nil
+}
+
+// repository returns the remote repository of ref, accessed with the
+// settings of the client.
+func (c *Client) repository(ref reference) (*remote.Repository, error) {
+	repository, err := remote.NewRepository(ref.String())
+	if err != nil {
+		return nil, err
+	}
+	repository.PlainHTTP = c.plainHTTP
+	repository.Client = c.authorizer
+	return repository, nil
+}
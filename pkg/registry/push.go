@@ -0,0 +1,374 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry // import "helm.sh/helm/v4/pkg/registry"
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+// DefaultPushChunkSize is the size of the chunks blobs are uploaded in on
+// push. Smaller blobs are uploaded in a single request.
+const DefaultPushChunkSize int64 = 5 << 20
+
+// maxChunkAttempts is the number of times the upload of a chunk is attempted,
+// resuming the upload from the offset reported by the registry, before the
+// push fails.
+const maxChunkAttempts = 3
+
+// chunkedRepository uploads the blobs larger than chunkSize to a repository
+// in chunks, resuming interrupted uploads where the registry supports it.
+// Manifests and small blobs are handled by the embedded repository.
+type chunkedRepository struct {
+	*remote.Repository
+	client    RemoteClient
+	chunkSize int64
+}
+
+// Push uploads the blob described by expected, in chunks when it is larger
+// than the chunk size.
+func (r *chunkedRepository) Push(ctx context.Context, expected ocispec.Descriptor, content io.Reader) error {
+	if r.chunkSize <= 0 || expected.Size <= r.chunkSize || isManifest(expected) {
+		return r.Repository.Push(ctx, expected, content)
+	}
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) != expected.Size {
+		return fmt.Errorf("mismatch content length %d: expect %d", len(data), expected.Size)
+	}
+
+	// pushing requires both pull and push actions
+	ctx = auth.AppendRepositoryScope(ctx, r.Reference, auth.ActionPull, auth.ActionPush)
+	location, minChunkSize, err := r.startUpload(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to upload blob %s: %w", expected.Digest, err)
+	}
+	return r.upload(ctx, location, minChunkSize, expected, data)
+}
+
+// Mount mounts the blob described by desc from the repository fromRepo of the
+// same registry. When the registry refuses the mount, the blob is uploaded
+// with the content returned by getContent instead.
+func (r *chunkedRepository) Mount(ctx context.Context, desc ocispec.Descriptor, fromRepo string, getContent func() (io.ReadCloser, error)) error {
+	source := r.Reference
+	source.Repository = fromRepo
+	ctx = auth.AppendRepositoryScope(ctx, r.Reference, auth.ActionPull, auth.ActionPush)
+	ctx = auth.AppendRepositoryScope(ctx, source, auth.ActionPull)
+	location, minChunkSize, err := r.startUpload(ctx, url.Values{
+		"mount": {desc.Digest.String()},
+		"from":  {fromRepo},
+	})
+	if errors.Is(err, errMounted) {
+		return nil
+	}
+	if err != nil {
+		slog.Debug("blob mount refused", "digest", desc.Digest, "from", fromRepo, "error", err)
+		location = nil
+	}
+
+	rc, err := getContent()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) != desc.Size {
+		return fmt.Errorf("mismatch content length %d: expect %d", len(data), desc.Size)
+	}
+	// The registry may have started an upload in place of the mount.
+	if location == nil {
+		location, minChunkSize, err = r.startUpload(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to upload blob %s: %w", desc.Digest, err)
+		}
+	}
+	return r.upload(ctx, location, minChunkSize, desc, data)
+}
+
+// upload uploads data, the content of the blob described by expected, to the
+// upload session at location, in chunks when it is larger than the chunk size.
+func (r *chunkedRepository) upload(ctx context.Context, location *url.URL, minChunkSize int64, expected ocispec.Descriptor, data []byte) error {
+	if r.chunkSize <= 0 || expected.Size <= r.chunkSize {
+		if err := r.completeUpload(ctx, location, expected, data); err != nil {
+			return fmt.Errorf("failed to upload blob %s: %w", expected.Digest, err)
+		}
+		return nil
+	}
+	chunkSize := max(r.chunkSize, minChunkSize)
+
+	offset, attempts := int64(0), 0
+	for offset < expected.Size {
+		end := min(offset+chunkSize, expected.Size)
+		next, err := r.uploadChunk(ctx, location, data[offset:end], offset)
+		if err == nil {
+			location, offset, attempts = next, end, 0
+			continue
+		}
+		attempts++
+		if attempts >= maxChunkAttempts {
+			return fmt.Errorf("failed to upload blob %s: %w", expected.Digest, err)
+		}
+		// Resume from the offset the registry received up to, which may be
+		// before or after the start of the failed chunk.
+		next, uploaded, statusErr := r.uploadStatus(ctx, location)
+		if statusErr != nil {
+			return fmt.Errorf("failed to upload blob %s: %w (unable to resume: %s)", expected.Digest, err, statusErr)
+		}
+		slog.Debug("resuming blob upload", "digest", expected.Digest, "offset", uploaded, "error", err)
+		location, offset = next, uploaded
+	}
+	if err := r.completeUpload(ctx, location, expected, nil); err != nil {
+		return fmt.Errorf("failed to upload blob %s: %w", expected.Digest, err)
+	}
+	return nil
+}
+
+// errMounted is returned by startUpload when the blob was mounted rather
+// than an upload session started.
+var errMounted = errors.New("blob mounted")
+
+// startUpload starts a blob upload session, returning its location and the
+// minimum chunk size required by the registry, if any. The query may request
+// a mount, in which case errMounted is returned if the blob was mounted.
+func (r *chunkedRepository) startUpload(ctx context.Context, query url.Values) (*url.URL, int64, error) {
+	u := &url.URL{
+		Scheme:   "https",
+		Host:     r.Reference.Host(),
+		Path:     fmt.Sprintf("/v2/%s/blobs/uploads/", r.Reference.Repository),
+		RawQuery: query.Encode(),
+	}
+	if r.PlainHTTP {
+		u.Scheme = "http"
+	}
+	resp, err := r.do(ctx, http.MethodPost, u, nil, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusCreated && query.Has("mount") {
+		return nil, 0, errMounted
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		return nil, 0, fmt.Errorf("failed to start upload: %s", resp.Status)
+	}
+	location, err := uploadLocation(resp)
+	if err != nil {
+		return nil, 0, err
+	}
+	minChunkSize, _ := strconv.ParseInt(resp.Header.Get("OCI-Chunk-Min-Length"), 10, 64)
+	return location, minChunkSize, nil
+}
+
+// uploadChunk uploads chunk at offset of the blob, returning the location of
+// the upload session for the next chunk.
+func (r *chunkedRepository) uploadChunk(ctx context.Context, location *url.URL, chunk []byte, offset int64) (*url.URL, error) {
+	resp, err := r.do(ctx, http.MethodPatch, location, chunk, map[string]string{
+		"Content-Type":  "application/octet-stream",
+		"Content-Range": fmt.Sprintf("%d-%d", offset, offset+int64(len(chunk))-1),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return nil, fmt.Errorf("failed to upload chunk at offset %d: %s", offset, resp.Status)
+	}
+	return uploadLocation(resp)
+}
+
+// uploadStatus returns the location of an upload session and the number of
+// bytes received by the registry so far.
+func (r *chunkedRepository) uploadStatus(ctx context.Context, location *url.URL) (*url.URL, int64, error) {
+	resp, err := r.do(ctx, http.MethodGet, location, nil, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		return nil, 0, fmt.Errorf("failed to get upload status: %s", resp.Status)
+	}
+	next, err := uploadLocation(resp)
+	if err != nil {
+		return nil, 0, err
+	}
+	// The Range header is of the form "0-<last byte received>", and may be
+	// missing if nothing has been received.
+	received := resp.Header.Get("Range")
+	if received == "" {
+		return next, 0, nil
+	}
+	_, last, ok := strings.Cut(received, "-")
+	end, err := strconv.ParseInt(last, 10, 64)
+	if !ok || err != nil {
+		return nil, 0, fmt.Errorf("invalid upload range %q", received)
+	}
+	return next, end + 1, nil
+}
+
+// completeUpload closes an upload session with the last bytes of the blob,
+// if any, committing the blob.
+func (r *chunkedRepository) completeUpload(ctx context.Context, location *url.URL, expected ocispec.Descriptor, data []byte) error {
+	u := *location
+	query := u.Query()
+	query.Set("digest", expected.Digest.String())
+	u.RawQuery = query.Encode()
+	resp, err := r.do(ctx, http.MethodPut, &u, data, map[string]string{
+		"Content-Type": "application/octet-stream",
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("failed to complete upload: %s", resp.Status)
+	}
+	return nil
+}
+
+func (r *chunkedRepository) do(ctx context.Context, method string, u *url.URL, body []byte, header map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+	return r.client.Do(req)
+}
+
+// uploadLocation returns the location of the upload session of a response,
+// resolved against the URL of its request.
+func uploadLocation(resp *http.Response) (*url.URL, error) {
+	location, err := resp.Location()
+	if err != nil {
+		return nil, fmt.Errorf("invalid upload location: %w", err)
+	}
+	return location, nil
+}
+
+// isManifest returns whether desc describes a manifest rather than a blob.
+func isManifest(desc ocispec.Descriptor) bool {
+	switch desc.MediaType {
+	case ocispec.MediaTypeImageManifest, ocispec.MediaTypeImageIndex:
+		return true
+	}
+	return false
+}
+
+// PushOptChunkSize returns a function that sets the size of the chunks blobs
+// are uploaded in on push, DefaultPushChunkSize by default. Blobs are uploaded
+// in a single request when size is not positive.
+func PushOptChunkSize(size int64) PushOption {
+	return func(operation *pushOperation) {
+		operation.chunkSize = size
+	}
+}
+
+// PushOptMountFrom returns a function that sets the repositories of the same
+// registry, such as "charts/other", that blobs missing from the target
+// repository are mounted from on push rather than uploaded. They are tried
+// before the repositories the client pushed the blobs to or pulled them from.
+func PushOptMountFrom(repositories ...string) PushOption {
+	return func(operation *pushOperation) {
+		operation.mountFrom = append(operation.mountFrom, repositories...)
+	}
+}
+
+const (
+	// maxRecordedBlobs bounds the number of blobs whose repositories are
+	// recorded by a client. The oldest recorded blobs are forgotten first.
+	maxRecordedBlobs = 1000
+	// maxBlobRepositories bounds the number of repositories recorded per
+	// blob. The repositories recorded last are kept.
+	maxBlobRepositories = 10
+)
+
+// blobRepositories records the repositories of each registry blobs were
+// pushed to or pulled from, to mount them from on later pushes.
+type blobRepositories struct {
+	mu           sync.Mutex
+	repositories map[string][]string
+	// keys are the keys of repositories, oldest first.
+	keys []string
+}
+
+func blobKey(ref reference, desc ocispec.Descriptor) string {
+	return ref.Registry + "@" + desc.Digest.String()
+}
+
+// record records that the blobs of descs are in the repository of ref.
+func (b *blobRepositories) record(ref reference, descs ...ocispec.Descriptor) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.repositories == nil {
+		b.repositories = map[string][]string{}
+	}
+	for _, desc := range descs {
+		if isManifest(desc) {
+			continue
+		}
+		key := blobKey(ref, desc)
+		repositories, ok := b.repositories[key]
+		if !ok {
+			b.keys = append(b.keys, key)
+			if len(b.keys) > maxRecordedBlobs {
+				delete(b.repositories, b.keys[0])
+				b.keys = slices.Delete(b.keys, 0, 1)
+			}
+		}
+		repositories = slices.DeleteFunc(repositories, func(r string) bool { return r == ref.Repository })
+		repositories = append(repositories, ref.Repository)
+		if len(repositories) > maxBlobRepositories {
+			repositories = slices.Delete(repositories, 0, 1)
+		}
+		b.repositories[key] = repositories
+	}
+}
+
+// mountFrom returns the repositories of the registry of ref to mount the blob
+// of desc from: those given, followed by those the blob is known to be in,
+// except for the repository of ref.
+func (b *blobRepositories) mountFrom(ref reference, desc ocispec.Descriptor, given []string) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var repositories []string
+	for _, repository := range slices.Concat(given, b.repositories[blobKey(ref, desc)]) {
+		if repository != ref.Repository && !slices.Contains(repositories, repository) {
+			repositories = append(repositories, repository)
+		}
+	}
+	return repositories
+}
@@ -0,0 +1,277 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+// chunkedUploadServer is a registry of the repositories "charts/<name>"
+// accepting chunked blob uploads and mounts, failing the chunk at interruptAt
+// part way through. Blobs and manifests are indexed by repository and digest
+// or reference, as in "charts/test@sha256:...".
+type chunkedUploadServer struct {
+	mu           sync.Mutex
+	interruptAt  int
+	refuseMounts bool
+	blobs        map[string][]byte
+	manifests    map[string][]byte
+	uploads      map[string][]byte
+	nextUpload   int
+	patches      int
+	resumes      int
+	mounts       int
+}
+
+func newChunkedUploadServer(t *testing.T, interruptAt int) (*chunkedUploadServer, string) {
+	t.Helper()
+	s := &chunkedUploadServer{
+		interruptAt: interruptAt,
+		blobs:       map[string][]byte{},
+		manifests:   map[string][]byte{},
+		uploads:     map[string][]byte{},
+	}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return s, strings.TrimPrefix(srv.URL, "http://")
+}
+
+func (s *chunkedUploadServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name, path, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v2/charts/"), "/")
+	if !ok || !strings.HasPrefix(r.URL.Path, "/v2/charts/") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	repository := "charts/" + name
+	body, _ := io.ReadAll(r.Body)
+	switch {
+	case r.Method == http.MethodHead && strings.HasPrefix(path, "blobs/"):
+		data, ok := s.blobs[repository+"@"+strings.TrimPrefix(path, "blobs/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	case r.Method == http.MethodPost && path == "blobs/uploads/":
+		query := r.URL.Query()
+		if data, ok := s.blobs[query.Get("from")+"@"+query.Get("mount")]; ok && !s.refuseMounts {
+			s.mounts++
+			s.blobs[repository+"@"+query.Get("mount")] = data
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		id := strconv.Itoa(s.nextUpload)
+		s.nextUpload++
+		s.uploads[id] = nil
+		w.Header().Set("Location", "/v2/"+repository+"/blobs/uploads/"+id)
+		w.WriteHeader(http.StatusAccepted)
+	case strings.HasPrefix(path, "blobs/uploads/"):
+		id := strings.TrimPrefix(path, "blobs/uploads/")
+		upload, ok := s.uploads[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case http.MethodPatch:
+			var start, end int
+			if _, err := fmt.Sscanf(r.Header.Get("Content-Range"), "%d-%d", &start, &end); err != nil || start != len(upload) || end-start+1 != len(body) {
+				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+				return
+			}
+			s.patches++
+			if s.patches == s.interruptAt {
+				s.uploads[id] = append(upload, body[:len(body)/2]...)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			s.uploads[id] = append(upload, body...)
+		case http.MethodGet:
+			s.resumes++
+			if len(upload) > 0 {
+				w.Header().Set("Range", fmt.Sprintf("0-%d", len(upload)-1))
+			}
+		case http.MethodPut:
+			upload = append(upload, body...)
+			d := r.URL.Query().Get("digest")
+			if digest.FromBytes(upload).String() != d {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			delete(s.uploads, id)
+			s.blobs[repository+"@"+d] = upload
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.Header().Set("Location", r.URL.Path)
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	case strings.HasPrefix(path, "manifests/"):
+		reference := repository + "@" + strings.TrimPrefix(path, "manifests/")
+		if r.Method == http.MethodPut {
+			s.manifests[reference] = body
+			s.manifests[repository+"@"+digest.FromBytes(body).String()] = body
+			w.Header().Set("Docker-Content-Digest", digest.FromBytes(body).String())
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		data, ok := s.manifests[reference]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+		w.Header().Set("Docker-Content-Digest", digest.FromBytes(data).String())
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(data)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestPushChunked(t *testing.T) {
+	chartData, err := os.ReadFile("../repo/repotest/testdata/examplechart-0.1.0.tgz")
+	require.NoError(t, err)
+	chartDigest := digest.FromBytes(chartData).String()
+
+	tests := []struct {
+		name        string
+		chunkSize   int64
+		interruptAt int
+		resumes     int
+	}{
+		{name: "single request", chunkSize: 0},
+		{name: "chunks", chunkSize: 100},
+		{name: "interrupted", chunkSize: 100, interruptAt: 3, resumes: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, host := newChunkedUploadServer(t, tt.interruptAt)
+			client, err := NewClient(ClientOptPlainHTTP(), ClientOptWriter(io.Discard))
+			require.NoError(t, err)
+			ref := host + "/charts/test:0.1.0"
+
+			result, err := client.Push(chartData, ref, PushOptStrictMode(false), PushOptChunkSize(tt.chunkSize))
+			require.NoError(t, err)
+			require.Equal(t, chartDigest, result.Chart.Digest)
+			require.Equal(t, chartData, s.blobs["charts/test@"+chartDigest])
+			// Each blob larger than the chunk size is uploaded in chunks,
+			// and the interrupted one is resumed with an extra chunk.
+			patches := tt.resumes
+			for _, blob := range s.blobs {
+				if tt.chunkSize > 0 && int64(len(blob)) > tt.chunkSize {
+					patches += int((int64(len(blob)) + tt.chunkSize - 1) / tt.chunkSize)
+				}
+			}
+			require.Equal(t, patches, s.patches)
+			require.Equal(t, tt.resumes, s.resumes)
+
+			// Blobs already present are not uploaded again.
+			patches = s.patches
+			_, err = client.Push(chartData, ref, PushOptStrictMode(false), PushOptChunkSize(tt.chunkSize))
+			require.NoError(t, err)
+			require.Equal(t, patches, s.patches)
+			require.Empty(t, s.uploads)
+		})
+	}
+}
+
+func TestPushMount(t *testing.T) {
+	chartData, err := os.ReadFile("../repo/repotest/testdata/examplechart-0.1.0.tgz")
+	require.NoError(t, err)
+	chartDigest := digest.FromBytes(chartData).String()
+	s, host := newChunkedUploadServer(t, 0)
+	client, err := NewClient(ClientOptPlainHTTP(), ClientOptWriter(io.Discard))
+	require.NoError(t, err)
+
+	_, err = client.Push(chartData, host+"/charts/a:0.1.0", PushOptStrictMode(false))
+	require.NoError(t, err)
+	uploads := s.nextUpload
+
+	// The chart and config blobs pushed by the client are mounted.
+	_, err = client.Push(chartData, host+"/charts/b:0.1.0", PushOptStrictMode(false))
+	require.NoError(t, err)
+	require.Equal(t, 2, s.mounts)
+	require.Equal(t, uploads, s.nextUpload)
+	require.Equal(t, chartData, s.blobs["charts/b@"+chartDigest])
+
+	// Other clients mount the blobs from the repositories given.
+	other, err := NewClient(ClientOptPlainHTTP(), ClientOptWriter(io.Discard))
+	require.NoError(t, err)
+	_, err = other.Push(chartData, host+"/charts/c:0.1.0", PushOptStrictMode(false), PushOptMountFrom("charts/missing", "charts/a"))
+	require.NoError(t, err)
+	require.Equal(t, 4, s.mounts)
+	require.Equal(t, chartData, s.blobs["charts/c@"+chartDigest])
+
+	// Blobs are uploaded, in chunks, when mounts are refused.
+	s.refuseMounts = true
+	_, err = other.Push(chartData, host+"/charts/d:0.1.0", PushOptStrictMode(false), PushOptMountFrom("charts/a"), PushOptChunkSize(100))
+	require.NoError(t, err)
+	require.Equal(t, 4, s.mounts)
+	require.NotZero(t, s.patches)
+	require.Equal(t, chartData, s.blobs["charts/d@"+chartDigest])
+}
+
+func TestBlobRepositoriesBounded(t *testing.T) {
+	var b blobRepositories
+	blob := func(i int) ocispec.Descriptor {
+		return ocispec.Descriptor{
+			MediaType: ChartLayerMediaType,
+			Digest:    digest.FromString(strconv.Itoa(i)),
+		}
+	}
+	ref := func(repository string) reference {
+		return reference{Registry: "example.com", Repository: repository}
+	}
+
+	// The oldest blobs are forgotten.
+	for i := 0; i <= maxRecordedBlobs; i++ {
+		b.record(ref("charts/a"), blob(i))
+	}
+	require.Len(t, b.repositories, maxRecordedBlobs)
+	require.Empty(t, b.mountFrom(ref("charts/b"), blob(0), nil))
+	require.Equal(t, []string{"charts/a"}, b.mountFrom(ref("charts/b"), blob(maxRecordedBlobs), nil))
+
+	// The repositories recorded last are kept.
+	for i := 0; i <= maxBlobRepositories; i++ {
+		b.record(ref(fmt.Sprintf("charts/r%d", i)), blob(maxRecordedBlobs))
+	}
+	repositories := b.mountFrom(ref("charts/b"), blob(maxRecordedBlobs), nil)
+	require.Len(t, repositories, maxBlobRepositories)
+	require.NotContains(t, repositories, "charts/a")
+	require.Contains(t, repositories, fmt.Sprintf("charts/r%d", maxBlobRepositories))
+}
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net"
@@ -36,9 +37,11 @@ import (
 	_ "github.com/distribution/distribution/v3/registry/auth/htpasswd"
 	_ "github.com/distribution/distribution/v3/registry/storage/driver/inmemory"
 	"github.com/foxcpp/go-mockdns"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/phayes/freeport"
 	"github.com/stretchr/testify/suite"
 	"golang.org/x/crypto/bcrypt"
+	"oras.land/oras-go/v2/registry/remote"
 
 	"helm.sh/helm/v4/internal/tlsutil"
 )
@@ -301,6 +304,59 @@ func testPush(suite *TestSuite) {
 	suite.Equal(
 		"sha256:b0a02b7412f78ae93324d48df8fcc316d8482e5ad7827b5b238657a29a22f256",
 		result.Prov.Digest)
+
+	// push with annotations and referrers
+	ref = fmt.Sprintf("%s/testrepo/referred:%s", suite.DockerRegistryHost, meta.Version)
+	_, err = suite.RegistryClient.Push(chartData, ref, PushOptStrictMode(false),
+		PushOptAnnotations(map[string]string{ocispec.AnnotationTitle: "other"}))
+	suite.NotNil(err, "error overriding the chart name annotation")
+
+	sbom := Referrer{ArtifactType: "application/spdx+json", Data: []byte(`{"spdxVersion":"SPDX-2.3"}`)}
+	result, err = suite.RegistryClient.Push(chartData, ref, PushOptStrictMode(false),
+		PushOptCreationTime(testingChartCreationTime),
+		PushOptAnnotations(map[string]string{"org.example.team": "charts"}),
+		PushOptReferrers(sbom))
+	suite.Nil(err, "no error pushing with annotations and referrers")
+	suite.Len(result.Referrers, 1)
+
+	repository, err := remote.NewRepository(ref)
+	suite.Nil(err)
+	repository.PlainHTTP = suite.RegistryClient.plainHTTP
+	repository.Client = suite.RegistryClient.authorizer
+	ctx := context.Background()
+
+	manifestDesc, manifestReader, err := repository.FetchReference(ctx, ref)
+	suite.Nil(err, "no error fetching the chart manifest")
+	suite.Equal(result.Manifest.Digest, manifestDesc.Digest.String())
+	var manifest ocispec.Manifest
+	suite.Nil(json.NewDecoder(manifestReader).Decode(&manifest))
+	manifestReader.Close()
+	suite.Equal("charts", manifest.Annotations["org.example.team"])
+	suite.Equal(meta.Name, manifest.Annotations[ocispec.AnnotationTitle])
+
+	var referrers []ocispec.Descriptor
+	err = repository.Referrers(ctx, manifestDesc, sbom.ArtifactType, func(page []ocispec.Descriptor) error {
+		referrers = append(referrers, page...)
+		return nil
+	})
+	suite.Nil(err, "no error listing referrers")
+	suite.Len(referrers, 1)
+	suite.Equal(result.Referrers[0].Digest, referrers[0].Digest.String())
+
+	// chunked push, and push mounting the blobs from another repository
+	ref = fmt.Sprintf("%s/testrepo/chunked/%s:%s", suite.DockerRegistryHost, meta.Name, meta.Version)
+	result, err = suite.RegistryClient.Push(chartData, ref, PushOptChunkSize(256))
+	suite.Nil(err, "no error pushing in chunks")
+	pulled, err := suite.RegistryClient.Pull(ref)
+	suite.Nil(err, "no error pulling a chart pushed in chunks")
+	suite.Equal(chartData, pulled.Chart.Data)
+
+	ref = fmt.Sprintf("%s/testrepo/mounted/%s:%s", suite.DockerRegistryHost, meta.Name, meta.Version)
+	mounted, err := suite.RegistryClient.Push(chartData, ref, PushOptMountFrom("testrepo/chunked/"+meta.Name))
+	suite.Nil(err, "no error pushing with mounts")
+	suite.Equal(result.Chart.Digest, mounted.Chart.Digest)
+	_, err = suite.RegistryClient.Pull(ref)
+	suite.Nil(err, "no error pulling a chart pushed with mounts")
 }
 
 func testPull(suite *TestSuite) {
@@ -395,3 +451,25 @@ func testTags(suite *TestSuite) {
 	suite.Nil(err, "no error retrieving tags")
 	suite.Equal(1, len(tags))
 }
+
+func testPlugin(suite *TestSuite) {
+	pluginData := []byte("not really a tarball")
+	ref := fmt.Sprintf("%s/testrepo/plugins/helm-test:0.1.0", suite.DockerRegistryHost)
+
+	digest, err := suite.RegistryClient.PushPlugin(pluginData, ref)
+	suite.Nil(err, "no error pushing a plugin")
+
+	result, err := suite.RegistryClient.PullPlugin(fmt.Sprintf("%s://%s", OCIScheme, ref))
+	suite.Nil(err, "no error pulling a plugin")
+	suite.Equal(digest, result.Manifest.Digest)
+	suite.Equal(pluginData, result.Plugin.Data)
+	suite.Equal(ref, result.Ref)
+
+	// A chart is not a plugin
+	chartData, err := os.ReadFile("../downloader/testdata/local-subchart-0.1.0.tgz")
+	suite.Nil(err, "no error loading test chart")
+	meta, err := extractChartMeta(chartData)
+	suite.Nil(err, "no error extracting chart meta")
+	_, err = suite.RegistryClient.PullPlugin(fmt.Sprintf("%s/testrepo/%s:%s", suite.DockerRegistryHost, meta.Name, meta.Version))
+	suite.ErrorContains(err, "does not contain a layer with mediatype "+PluginLayerMediaType)
+}
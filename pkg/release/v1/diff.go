@@ -0,0 +1,55 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+// DiffAction describes what an operation would do to a resource.
+type DiffAction string
+
+const (
+	// DiffCreate indicates that the resource would be created.
+	DiffCreate DiffAction = "create"
+	// DiffUpdate indicates that the resource would be changed.
+	DiffUpdate DiffAction = "update"
+	// DiffDelete indicates that the resource would be deleted.
+	DiffDelete DiffAction = "delete"
+	// DiffUnchanged indicates that the resource would be left as is.
+	DiffUnchanged DiffAction = "unchanged"
+)
+
+// ResourceDiff describes how an operation would change a single resource.
+//
+// Fields are identified by dotted paths, with list indices in brackets, for
+// example "spec.template.spec.containers[0].image".
+type ResourceDiff struct {
+	APIVersion string     `json:"apiVersion"`
+	Kind       string     `json:"kind"`
+	Namespace  string     `json:"namespace,omitempty"`
+	Name       string     `json:"name"`
+	Action     DiffAction `json:"action"`
+	// Added lists the fields that would be set but are currently absent.
+	Added []string `json:"added,omitempty"`
+	// Changed lists the fields whose value would change.
+	Changed []string `json:"changed,omitempty"`
+	// Removed lists the fields that would be removed.
+	Removed []string `json:"removed,omitempty"`
+	// RequiresRecreate is set when the change cannot be applied in place,
+	// for example because it modifies immutable fields, so the resource would
+	// have to be deleted and created again.
+	RequiresRecreate bool `json:"requiresRecreate,omitempty"`
+	// Message explains RequiresRecreate.
+	Message string `json:"message,omitempty"`
+}
@@ -0,0 +1,46 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+// Health summarizes the health of the resources of a release.
+type Health struct {
+	// Healthy is set when all the resources are ready and none of their pods
+	// is failing.
+	Healthy bool `json:"healthy"`
+	// Ready and NotReady count the resources that are ready or not.
+	Ready    int `json:"ready"`
+	NotReady int `json:"notReady"`
+	// PendingVolumeClaims counts the persistent volume claims not bound yet.
+	PendingVolumeClaims int `json:"pendingVolumeClaims"`
+	// FailingPods counts the failing pods of the resources, such as those
+	// crash looping.
+	FailingPods int `json:"failingPods"`
+	// Unhealthy lists the resources that are not ready or have failing pods.
+	Unhealthy []UnhealthyResource `json:"unhealthy,omitempty"`
+}
+
+// UnhealthyResource describes a resource of a release that is not healthy.
+type UnhealthyResource struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Ready     bool   `json:"ready"`
+	// Missing is set when the resource is not found in the cluster.
+	Missing bool `json:"missing,omitempty"`
+	// FailingPods names the failing pods of the resource.
+	FailingPods []string `json:"failingPods,omitempty"`
+}
@@ -37,4 +37,8 @@ type Info struct {
 	Notes string `json:"notes,omitempty"`
 	// Contains the deployed resources information
 	Resources map[string][]runtime.Object `json:"resources,omitempty"`
+	// Diff describes the changes a dry run would make to the resources.
+	Diff []ResourceDiff `json:"diff,omitempty"`
+	// Health summarizes the health of the deployed resources.
+	Health *Health `json:"health,omitempty"`
 }
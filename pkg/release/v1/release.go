@@ -42,6 +42,9 @@ type Release struct {
 	// Labels of the release.
 	// Disabled encoding into Json cause labels are stored in storage driver metadata field.
 	Labels map[string]string `json:"-"`
+	// Annotations of the release.
+	// Disabled encoding into Json cause annotations are stored in storage driver metadata field.
+	Annotations map[string]string `json:"-"`
 }
 
 // SetStatus is a helper for setting the status on a release.
@@ -21,4 +21,16 @@ type UninstallReleaseResponse struct {
 	Release *Release `json:"release,omitempty"`
 	// Info is an uninstall message
 	Info string `json:"info,omitempty"`
+	// Kept lists the resources of the release that were left in the cluster.
+	Kept []ResourceReference `json:"kept,omitempty"`
+	// Deleted lists the resources of the release that were deleted.
+	Deleted []ResourceReference `json:"deleted,omitempty"`
+}
+
+// ResourceReference identifies a Kubernetes resource of a release.
+type ResourceReference struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
 }
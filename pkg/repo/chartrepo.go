@@ -17,9 +17,11 @@ limitations under the License.
 package repo // import "helm.sh/helm/v4/pkg/repo"
 
 import (
+	"bytes"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -43,6 +45,14 @@ type Entry struct {
 	CAFile                string `json:"caFile"`
 	InsecureSkipTLSverify bool   `json:"insecure_skip_tls_verify"`
 	PassCredentialsAll    bool   `json:"pass_credentials_all"`
+	// CredentialHelper is the credential helper program run to get the
+	// credentials of the repository when it has no username and password,
+	// overriding the global one. See getter.WithCredentialHelper.
+	CredentialHelper string `json:"credentialHelper,omitempty"`
+
+	// Mirrors are the URLs of repositories serving the same content as URL,
+	// tried in order when URL is unavailable.
+	Mirrors []string `json:"mirrors,omitempty"`
 }
 
 // ChartRepository represents a chart repository
@@ -51,6 +61,12 @@ type ChartRepository struct {
 	IndexFile *IndexFile
 	Client    getter.Getter
 	CachePath string
+	// Options provide additional parameters to be passed along to the Getter
+	// when downloading the index.
+	Options []getter.Option
+
+	// getters select the getters of the mirrors by the scheme of their URL.
+	getters getter.Providers
 }
 
 // NewChartRepository constructs ChartRepository
@@ -70,23 +86,51 @@ func NewChartRepository(cfg *Entry, getters getter.Providers) (*ChartRepository,
 		IndexFile: NewIndexFile(),
 		Client:    client,
 		CachePath: helmpath.CachePath("repository"),
+		getters:   getters,
 	}, nil
 }
 
+// indexValidators are the validators of the version of an index cached,
+// along with its URL.
+type indexValidators struct {
+	URL string `json:"url"`
+	getter.Validators
+}
+
 // DownloadIndexFile fetches the index from a repository.
+//
+// When the index is cached with the ETag or Last-Modified headers of its
+// response, it is only fetched again if it was modified, and the cached
+// index is kept otherwise.
 func (r *ChartRepository) DownloadIndexFile() (string, error) {
 	indexURL, err := ResolveReferenceURL(r.Config.URL, "index.yaml")
 	if err != nil {
 		return "", err
 	}
+	fname := filepath.Join(r.CachePath, helmpath.CacheIndexFile(r.Config.Name))
+	validatorsFile := filepath.Join(r.CachePath, helmpath.CacheIndexValidatorsFile(r.Config.Name))
+
+	var validators getter.Validators
+	if cached, ok := loadIndexValidators(validatorsFile, fname); ok && cached.URL == indexURL {
+		validators = cached.Validators
+	}
 
-	resp, err := r.Client.Get(indexURL,
+	opts := append([]getter.Option{
 		getter.WithURL(r.Config.URL),
 		getter.WithInsecureSkipVerifyTLS(r.Config.InsecureSkipTLSverify),
 		getter.WithTLSClientConfig(r.Config.CertFile, r.Config.KeyFile, r.Config.CAFile),
 		getter.WithBasicAuth(r.Config.Username, r.Config.Password),
 		getter.WithPassCredentialsAll(r.Config.PassCredentialsAll),
-	)
+	}, r.Options...)
+	if r.Config.CredentialHelper != "" {
+		opts = append(opts, getter.WithCredentialHelper(r.Config.CredentialHelper))
+	}
+	opts = append(opts, getter.WithValidators(&validators))
+	resp, err := r.Get(indexURL, opts...)
+	if errors.Is(err, getter.ErrNotModified) {
+		slog.Debug("repository index not modified", "url", indexURL)
+		return fname, nil
+	}
 	if err != nil {
 		return "", err
 	}
@@ -111,9 +155,106 @@ func (r *ChartRepository) DownloadIndexFile() (string, error) {
 	os.WriteFile(chartsFile, []byte(charts.String()), 0644)
 
 	// Create the index file in the cache directory
-	fname := filepath.Join(r.CachePath, helmpath.CacheIndexFile(r.Config.Name))
 	os.MkdirAll(filepath.Dir(fname), 0755)
-	return fname, os.WriteFile(fname, index, 0644)
+	// The validators of a previous version must not be kept with the new one.
+	os.Remove(validatorsFile)
+	if err := os.WriteFile(fname, index, 0644); err != nil {
+		return fname, err
+	}
+	if !validators.IsZero() {
+		data, err := json.Marshal(indexValidators{URL: indexURL, Validators: validators})
+		if err == nil {
+			err = os.WriteFile(validatorsFile, data, 0644)
+		}
+		if err != nil {
+			slog.Debug("unable to save the validators of the repository index", "url", indexURL, slog.Any("error", err))
+		}
+	}
+	return fname, nil
+}
+
+// loadIndexValidators loads the validators of the cached index file fname
+// from file, if both exist.
+func loadIndexValidators(file, fname string) (indexValidators, bool) {
+	var v indexValidators
+	if _, err := os.Stat(fname); err != nil {
+		return v, false
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return v, false
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return v, false
+	}
+	return v, !v.IsZero()
+}
+
+// Get gets href, failing over to the same content in the mirrors of the
+// repository, in order, when it is unavailable. The getter of each URL is
+// selected by its scheme.
+//
+// Credentials are only passed to the mirrors on the host of the repository,
+// unless the options allow passing them to all domains.
+func (r *ChartRepository) Get(href string, options ...getter.Option) (*bytes.Buffer, error) {
+	urls := append([]string{href}, r.Config.MirrorURLs(href)...)
+	var errs []error
+	for i, u := range urls {
+		client, err := r.client(u)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Get(u, options...)
+		if err == nil {
+			if i > 0 {
+				slog.Info("served by repository mirror", "url", href, "mirror", u)
+			} else {
+				slog.Debug("served by repository", "url", u)
+			}
+			return resp, nil
+		}
+		errs = append(errs, err)
+		if !getter.IsUnavailable(err) || i == len(urls)-1 {
+			break
+		}
+		slog.Warn("repository unavailable, trying the next mirror", "url", u, slog.Any("error", err))
+	}
+	if len(errs) == 1 {
+		return nil, errs[0]
+	}
+	return nil, errors.Join(errs...)
+}
+
+// client returns the getter of u.
+func (r *ChartRepository) client(u string) (getter.Getter, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mirror URL format: %s", u)
+	}
+	primary, err := url.Parse(r.Config.URL)
+	if r.getters == nil || (err == nil && parsed.Scheme == primary.Scheme) {
+		return r.Client, nil
+	}
+	client, err := r.getters.ByScheme(parsed.Scheme)
+	if err != nil {
+		return nil, fmt.Errorf("could not find protocol handler for mirror: %s", parsed.Scheme)
+	}
+	return client, nil
+}
+
+// MirrorURLs returns the URLs of href in the mirrors of the repository, when
+// href is within the repository.
+func (e *Entry) MirrorURLs(href string) []string {
+	base := strings.TrimSuffix(e.URL, "/")
+	rest, ok := strings.CutPrefix(href, base)
+	if !ok || (rest != "" && !strings.HasPrefix(rest, "/") && !strings.HasPrefix(rest, "?")) {
+		return nil
+	}
+	urls := make([]string, 0, len(e.Mirrors))
+	for _, mirror := range e.Mirrors {
+		urls = append(urls, strings.TrimSuffix(mirror, "/")+rest)
+	}
+	return urls
 }
 
 type findChartInRepoURLOptions struct {
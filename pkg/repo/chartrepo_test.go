@@ -22,6 +22,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
@@ -31,6 +32,7 @@ import (
 
 	"helm.sh/helm/v4/pkg/cli"
 	"helm.sh/helm/v4/pkg/getter"
+	"helm.sh/helm/v4/pkg/helmpath"
 )
 
 type CustomGetter struct {
@@ -243,3 +245,188 @@ func TestResolveReferenceURL(t *testing.T) {
 		}
 	}
 }
+
+func TestDownloadIndexFileMirrors(t *testing.T) {
+	var primaryRequests int
+	primaryStatus := http.StatusServiceUnavailable
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		primaryRequests++
+		w.WriteHeader(primaryStatus)
+	}))
+	defer primary.Close()
+	mirror, err := startLocalServerForTests(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mirror.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	// The mirror using a custom scheme is served by its own getter.
+	customGetter := &CustomGetter{}
+	providers := append(getter.All(&cli.EnvSettings{}), getter.Provider{
+		Schemes: []string{"gs"},
+		New: func(_ ...getter.Option) (getter.Getter, error) {
+			return customGetter, nil
+		},
+	})
+	r, err := NewChartRepository(&Entry{
+		Name:    "mirrored",
+		URL:     primary.URL + "/charts",
+		Mirrors: []string{down.URL + "/charts", mirror.URL + "/charts/", "gs://some-gcs-bucket"},
+	}, providers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.CachePath = t.TempDir()
+
+	idx, err := r.DownloadIndexFile()
+	if err != nil {
+		t.Fatalf("expected the index to be served by the mirror: %s", err)
+	}
+	index, err := LoadIndexFile(idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !index.Has("nginx", "0.2.0") {
+		t.Error("expected the index of the mirror to be downloaded")
+	}
+	if primaryRequests != 1 {
+		t.Errorf("expected 1 request to the primary repository, got %d", primaryRequests)
+	}
+	if len(customGetter.repoUrls) != 0 {
+		t.Errorf("expected the mirrors after the one serving the index not to be tried, got %v", customGetter.repoUrls)
+	}
+
+	// A mirror with another scheme is tried with its getter.
+	mirror.Close()
+	if _, err := r.DownloadIndexFile(); err != nil {
+		t.Fatalf("expected the index to be served by the last mirror: %s", err)
+	}
+	if len(customGetter.repoUrls) != 1 || customGetter.repoUrls[0] != "gs://some-gcs-bucket/index.yaml" {
+		t.Errorf("unexpected requests to the last mirror: %v", customGetter.repoUrls)
+	}
+
+	// Errors other than unavailability are not failed over.
+	primaryStatus = http.StatusNotFound
+	if _, err := r.DownloadIndexFile(); err == nil || !strings.Contains(err.Error(), "404 Not Found") {
+		t.Errorf("expected the error of the primary repository, got %v", err)
+	}
+	if len(customGetter.repoUrls) != 1 {
+		t.Errorf("expected no request to the mirrors, got %v", customGetter.repoUrls)
+	}
+}
+
+func TestEntryMirrorURLs(t *testing.T) {
+	e := &Entry{
+		URL:     "https://charts.example.com/stable/",
+		Mirrors: []string{"https://mirror.example.com/stable", "oci://registry.example.com/charts/"},
+	}
+	tests := []struct {
+		href   string
+		expect []string
+	}{
+		{
+			href:   "https://charts.example.com/stable/index.yaml",
+			expect: []string{"https://mirror.example.com/stable/index.yaml", "oci://registry.example.com/charts/index.yaml"},
+		},
+		{
+			href:   "https://charts.example.com/stable/nginx-0.2.0.tgz?token=1",
+			expect: []string{"https://mirror.example.com/stable/nginx-0.2.0.tgz?token=1", "oci://registry.example.com/charts/nginx-0.2.0.tgz?token=1"},
+		},
+		{
+			href: "https://charts.example.com/stable-old/nginx-0.2.0.tgz",
+		},
+		{
+			href: "https://cdn.example.com/nginx-0.2.0.tgz",
+		},
+	}
+	for _, tt := range tests {
+		got := e.MirrorURLs(tt.href)
+		if strings.Join(got, ",") != strings.Join(tt.expect, ",") {
+			t.Errorf("%s: expected %v, got %v", tt.href, tt.expect, got)
+		}
+	}
+}
+
+func TestDownloadIndexFileNotModified(t *testing.T) {
+	index, err := os.ReadFile("testdata/local-index.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag := `"v1"`
+	var full, notModified int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if etag != "" && r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full++
+		if etag != "" {
+			w.Header().Set("ETag", etag)
+		}
+		w.Write(index)
+	}))
+	defer srv.Close()
+
+	r, err := NewChartRepository(&Entry{Name: "conditional", URL: srv.URL}, getter.All(&cli.EnvSettings{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.CachePath = t.TempDir()
+
+	idx, err := r.DownloadIndexFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(r.CachePath, helmpath.CacheIndexValidatorsFile("conditional"))); err != nil {
+		t.Fatalf("expected the validators of the index to be saved: %s", err)
+	}
+	info, err := os.Stat(idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The cached index is kept as it is when not modified.
+	if err := os.Chtimes(idx, time.Time{}, info.ModTime().Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.DownloadIndexFile(); err != nil {
+		t.Fatal(err)
+	}
+	if full != 1 || notModified != 1 {
+		t.Errorf("expected 1 full and 1 conditional download, got %d and %d", full, notModified)
+	}
+	if kept, err := os.Stat(idx); err != nil || !kept.ModTime().Equal(info.ModTime().Add(-time.Hour)) {
+		t.Errorf("expected the cached index not to be written again: %v", err)
+	}
+
+	// The index is downloaded again once removed.
+	if err := os.Remove(idx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.DownloadIndexFile(); err != nil {
+		t.Fatal(err)
+	}
+	if full != 2 {
+		t.Errorf("expected a full download of the removed index, got %d", full)
+	}
+
+	// Servers sending no validators are always downloaded from.
+	etag = ""
+	for range 2 {
+		if _, err := r.DownloadIndexFile(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if full != 4 {
+		t.Errorf("expected full downloads without validators, got %d", full)
+	}
+	if _, err := os.Stat(filepath.Join(r.CachePath, helmpath.CacheIndexValidatorsFile("conditional"))); !os.IsNotExist(err) {
+		t.Errorf("expected the validators of the index to be removed: %v", err)
+	}
+	if _, err := LoadIndexFile(idx); err != nil {
+		t.Errorf("expected the index to be cached: %s", err)
+	}
+}
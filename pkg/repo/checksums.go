@@ -0,0 +1,71 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repo
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ChecksumsFile is the conventional name of the file written by WriteChecksums.
+const ChecksumsFile = "SHA256SUMS"
+
+// ArchiveDigests returns the digest of every entry that points inside the
+// repository, keyed by the slash-separated path of its archive relative to
+// the repository root. See Prune for which entries are considered local.
+func (i IndexFile) ArchiveDigests(baseURL string) map[string]string {
+	digests := map[string]string{}
+	for _, cvs := range i.Entries {
+		for _, cv := range cvs {
+			if cv == nil || cv.Digest == "" {
+				continue
+			}
+			if rel, ok := localArchivePath(cv, baseURL); ok {
+				digests[rel] = cv.Digest
+			}
+		}
+	}
+	return digests
+}
+
+// WriteChecksums writes SHA-256 digests, keyed by file name, to w in the
+// format produced by the coreutils sha256sum tool, so that the result can be
+// checked with 'sha256sum -c'. Lines are sorted by file name.
+func WriteChecksums(w io.Writer, digests map[string]string) error {
+	names := make([]string, 0, len(digests))
+	for name := range digests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		digest := digests[name]
+		// sha256sum escapes backslashes and newlines in file names and
+		// flags such lines with a leading backslash.
+		prefix := ""
+		if strings.ContainsAny(name, "\\\n") {
+			prefix = "\\"
+			name = strings.NewReplacer("\\", "\\\\", "\n", "\\n").Replace(name)
+		}
+		if _, err := fmt.Fprintf(w, "%s%s  %s\n", prefix, digest, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
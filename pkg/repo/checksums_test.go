@@ -0,0 +1,62 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repo
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestArchiveDigests(t *testing.T) {
+	i, err := IndexDirectory("testdata/repository", "http://localhost:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	i.Entries["external"] = ChartVersions{{URLs: []string{"https://example.com/external-1.0.0.tgz"}, Digest: "abc"}}
+
+	digests := i.ArchiveDigests("http://localhost:8080")
+	if len(digests) != 4 {
+		t.Fatalf("Expected 4 digests, got %d: %v", len(digests), digests)
+	}
+	if digests["universe/zarthal-1.0.0.tgz"] != i.Entries["zarthal"][0].Digest {
+		t.Errorf("Expected digest of zarthal to be reused, got %v", digests)
+	}
+	if _, ok := digests["external-1.0.0.tgz"]; ok {
+		t.Error("Expected externally hosted chart to be skipped")
+	}
+}
+
+func TestWriteChecksums(t *testing.T) {
+	var b bytes.Buffer
+	err := WriteChecksums(&b, map[string]string{
+		"index.yaml":        "1111",
+		"b/chart-1.0.0.tgz": "2222",
+		"a-1.0.0.tgz":       "3333",
+		"odd\\name.tgz":     "4444",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "3333  a-1.0.0.tgz\n" +
+		"2222  b/chart-1.0.0.tgz\n" +
+		"1111  index.yaml\n" +
+		"\\4444  odd\\\\name.tgz\n"
+	if b.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
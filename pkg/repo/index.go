@@ -21,12 +21,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log/slog"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Masterminds/semver/v3"
@@ -141,7 +146,7 @@ func (i IndexFile) MustAdd(md *chart.Metadata, filename, baseURL, digest string)
 		URLs:     []string{u},
 		Metadata: md,
 		Digest:   digest,
-		Created:  time.Now(),
+		Created:  indexTime(time.Now()),
 	}
 	ee := i.Entries[md.Name]
 	i.Entries[md.Name] = append(ee, cr)
@@ -158,9 +163,38 @@ func (i IndexFile) Add(md *chart.Metadata, filename, baseURL, digest string) {
 }
 
 // Has returns true if the index has an entry for a chart with the given name and exact version.
+// Entries marked as removed are included.
 func (i IndexFile) Has(name, version string) bool {
-	_, err := i.Get(name, version)
-	return err == nil
+	for _, ver := range i.Entries[name] {
+		if ver.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// SortedByCreated returns the entries of every chart sorted by creation time,
+// newest first. Entries created at the same time are sorted by chart name and
+// version. Entries marked as removed are left out.
+func (i IndexFile) SortedByCreated() ChartVersions {
+	var cvs ChartVersions
+	for _, versions := range i.Entries {
+		for _, cv := range versions {
+			if cv != nil && cv.Metadata != nil && !cv.Removed {
+				cvs = append(cvs, cv)
+			}
+		}
+	}
+	sort.SliceStable(cvs, func(a, b int) bool {
+		if !cvs[a].Created.Equal(cvs[b].Created) {
+			return cvs[a].Created.After(cvs[b].Created)
+		}
+		if cvs[a].Name != cvs[b].Name {
+			return cvs[a].Name < cvs[b].Name
+		}
+		return cvs.Less(b, a)
+	})
+	return cvs
 }
 
 // SortEntries sorts the entries by version in descending order.
@@ -178,7 +212,7 @@ func (i IndexFile) SortEntries() {
 // Get returns the ChartVersion for the given name.
 //
 // If version is empty, this will return the chart with the latest stable version,
-// prerelease versions will be skipped.
+// prerelease versions will be skipped. Entries marked as removed are skipped.
 func (i IndexFile) Get(name, version string) (*ChartVersion, error) {
 	vs, ok := i.Entries[name]
 	if !ok {
@@ -202,13 +236,16 @@ func (i IndexFile) Get(name, version string) (*ChartVersion, error) {
 	// when customer inputs specific version, check whether there's an exact match first
 	if len(version) != 0 {
 		for _, ver := range vs {
-			if version == ver.Version {
+			if version == ver.Version && !ver.Removed {
 				return ver, nil
 			}
 		}
 	}
 
 	for _, ver := range vs {
+		if ver.Removed {
+			continue
+		}
 		test, err := semver.NewVersion(ver.Version)
 		if err != nil {
 			continue
@@ -223,6 +260,10 @@ func (i IndexFile) Get(name, version string) (*ChartVersion, error) {
 
 // WriteFile writes an index file to the given destination path.
 //
+// The file is written atomically: the index is written to a temporary file in
+// the same directory which is then renamed into place, so readers never see a
+// truncated index.
+//
 // The mode on the file is set to 'mode'.
 func (i IndexFile) WriteFile(dest string, mode os.FileMode) error {
 	b, err := yaml.Marshal(i)
@@ -233,7 +274,7 @@ func (i IndexFile) WriteFile(dest string, mode os.FileMode) error {
 }
 
 // WriteJSONFile writes an index file in JSON format to the given destination
-// path.
+// path. Like WriteFile, the file is replaced atomically.
 //
 // The mode on the file is set to 'mode'.
 func (i IndexFile) WriteJSONFile(dest string, mode os.FileMode) error {
@@ -244,6 +285,26 @@ func (i IndexFile) WriteJSONFile(dest string, mode os.FileMode) error {
 	return fileutil.AtomicWriteFile(dest, bytes.NewReader(b), mode)
 }
 
+// Write writes the index file in YAML format to w.
+func (i IndexFile) Write(w io.Writer) error {
+	b, err := yaml.Marshal(i)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// WriteJSON writes the index file in JSON format to w.
+func (i IndexFile) WriteJSON(w io.Writer) error {
+	b, err := json.MarshalIndent(i, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
 // Merge merges the given index file into this index.
 //
 // This merges by name and version.
@@ -263,13 +324,179 @@ func (i *IndexFile) Merge(f *IndexFile) {
 	}
 }
 
+// Prune removes entries whose chart archive is no longer present in dir.
+//
+// Only entries that point inside the repository rooted at dir are considered:
+// those with a relative URL, and those with an absolute URL below baseURL.
+// Entries hosted anywhere else are left untouched.
+//
+// The removed entries are returned.
+func (i *IndexFile) Prune(dir, baseURL string) []*ChartVersion {
+	var removed []*ChartVersion
+	for name, cvs := range i.Entries {
+		kept := cvs[:0]
+		for _, cv := range cvs {
+			if archiveMissing(cv, dir, baseURL) {
+				removed = append(removed, cv)
+				continue
+			}
+			kept = append(kept, cv)
+		}
+		if len(kept) == 0 {
+			delete(i.Entries, name)
+			continue
+		}
+		i.Entries[name] = kept
+	}
+	return removed
+}
+
+// MarkRemoved marks the entries whose chart archive is no longer present in
+// dir as removed, instead of pruning them, so that mirrors of the repository
+// can track deletions. The entries considered are those of Prune.
+//
+// Removed entries are kept in the index with Removed set and the time of
+// their removal in the AnnotationRemoved annotation. Entries already marked
+// as removed keep their time of removal. The newly marked entries are
+// returned.
+func (i *IndexFile) MarkRemoved(dir, baseURL string, now time.Time) []*ChartVersion {
+	var removed []*ChartVersion
+	for _, cvs := range i.Entries {
+		for _, cv := range cvs {
+			if cv.Removed || !archiveMissing(cv, dir, baseURL) {
+				continue
+			}
+			// The metadata may be shared with an IndexCache, so annotate a
+			// copy.
+			md := *cv.Metadata
+			md.Annotations = make(map[string]string, len(cv.Annotations)+1)
+			for k, v := range cv.Annotations {
+				md.Annotations[k] = v
+			}
+			md.Annotations[AnnotationRemoved] = indexTime(now).Format(time.RFC3339)
+			cv.Metadata = &md
+			cv.Removed = true
+			removed = append(removed, cv)
+		}
+	}
+	return removed
+}
+
+// archiveMissing reports whether cv points inside the repository rooted at
+// dir to a chart archive that does not exist.
+func archiveMissing(cv *ChartVersion, dir, baseURL string) bool {
+	rel, ok := localArchivePath(cv, baseURL)
+	if !ok {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(rel)))
+	return errors.Is(err, fs.ErrNotExist)
+}
+
+// indexTime returns t as recorded in an index: in UTC and to the second, for
+// the timestamps to be written in a stable RFC 3339 format.
+func indexTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Second)
+}
+
+// DuplicateEntry describes the entries of an index sharing a chart name and
+// version.
+type DuplicateEntry struct {
+	Name    string
+	Version string
+	// Digests holds the digest of each entry, in the order of the index.
+	Digests []string
+}
+
+// Conflicting returns true if the entries have different digests, and thus
+// refer to different chart archives.
+func (d DuplicateEntry) Conflicting() bool {
+	for _, digest := range d.Digests[1:] {
+		if digest != d.Digests[0] {
+			return true
+		}
+	}
+	return false
+}
+
+func (d DuplicateEntry) String() string {
+	if d.Conflicting() {
+		return fmt.Sprintf("chart %q version %q has %d entries with conflicting digests %s", d.Name, d.Version, len(d.Digests), strings.Join(d.Digests, ", "))
+	}
+	return fmt.Sprintf("chart %q version %q has %d entries", d.Name, d.Version, len(d.Digests))
+}
+
+// Duplicates returns the chart versions listed more than once in the index,
+// sorted by chart name and version. Only the index is inspected; no chart
+// archive is downloaded.
+func (i IndexFile) Duplicates() []DuplicateEntry {
+	var dups []DuplicateEntry
+	for name, cvs := range i.Entries {
+		var versions []string
+		digests := map[string][]string{}
+		for _, cv := range cvs {
+			if cv == nil || cv.Metadata == nil {
+				continue
+			}
+			if _, ok := digests[cv.Version]; !ok {
+				versions = append(versions, cv.Version)
+			}
+			digests[cv.Version] = append(digests[cv.Version], cv.Digest)
+		}
+		for _, version := range versions {
+			if len(digests[version]) > 1 {
+				dups = append(dups, DuplicateEntry{Name: name, Version: version, Digests: digests[version]})
+			}
+		}
+	}
+	sort.Slice(dups, func(a, b int) bool {
+		if dups[a].Name != dups[b].Name {
+			return dups[a].Name < dups[b].Name
+		}
+		return dups[a].Version < dups[b].Version
+	})
+	return dups
+}
+
+// localArchivePath returns the slash-separated path of the chart archive
+// referenced by cv relative to the repository root, and whether cv refers to
+// an archive inside the repository at all.
+func localArchivePath(cv *ChartVersion, baseURL string) (string, bool) {
+	if len(cv.URLs) == 0 {
+		return "", false
+	}
+	ref := cv.URLs[0]
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", false
+	}
+	rel := strings.TrimPrefix(u.Path, "/")
+	if u.IsAbs() {
+		prefix := strings.TrimSuffix(baseURL, "/") + "/"
+		if baseURL == "" || !strings.HasPrefix(ref, prefix) {
+			return "", false
+		}
+		ru, err := url.Parse(strings.TrimPrefix(ref, prefix))
+		if err != nil {
+			return "", false
+		}
+		rel = ru.Path
+	}
+	return rel, rel != ""
+}
+
 // ChartVersion represents a chart entry in the IndexFile
 type ChartVersion struct {
 	*chart.Metadata
-	URLs    []string  `json:"urls"`
+	URLs []string `json:"urls"`
+	// Created is the time the entry was added to the index, which is the
+	// modification time of the chart archive for an index generated by
+	// IndexDirectory.
 	Created time.Time `json:"created,omitempty"`
-	Removed bool      `json:"removed,omitempty"`
-	Digest  string    `json:"digest,omitempty"`
+	// Removed marks an entry whose chart archive was deleted, see
+	// MarkRemoved.
+	Removed bool   `json:"removed,omitempty"`
+	Digest  string `json:"digest,omitempty"`
 
 	// ChecksumDeprecated is deprecated in Helm 3, and therefore ignored. Helm 3 replaced
 	// this with Digest. However, with a strict YAML parser enabled, a field must be
@@ -289,52 +516,392 @@ type ChartVersion struct {
 	URLDeprecated string `json:"url,omitempty"`
 }
 
-// IndexDirectory reads a (flat) directory and generates an index.
+type indexDirectoryOptions struct {
+	recursive bool
+	exclude   []string
+	workers   int
+	cache     *IndexCache
+	keyring   string
+	signer    *provenance.Signatory
+	resign    bool
+}
+
+// IndexDirectoryOption configures how IndexDirectory discovers and loads charts.
+type IndexDirectoryOption func(*indexDirectoryOptions)
+
+// WithRecursive makes IndexDirectory descend into all nested directories
+// rather than only the top level and its immediate subdirectories.
+//
+// Hidden directories (such as .git) are skipped and symbolic links that point
+// back to a directory that has already been visited are not followed again.
+func WithRecursive(recursive bool) IndexDirectoryOption {
+	return func(options *indexDirectoryOptions) {
+		options.recursive = recursive
+	}
+}
+
+// WithExclude skips any chart archive whose path relative to the indexed
+// directory, or whose file name, matches one of the given patterns.
+//
+// Patterns use filepath.Match syntax.
+func WithExclude(patterns ...string) IndexDirectoryOption {
+	return func(options *indexDirectoryOptions) {
+		options.exclude = append(options.exclude, patterns...)
+	}
+}
+
+// WithWorkers sets the number of chart archives IndexDirectory loads
+// concurrently. Values less than one use the number of available CPUs.
+func WithWorkers(workers int) IndexDirectoryOption {
+	return func(options *indexDirectoryOptions) {
+		options.workers = workers
+	}
+}
+
+// WithIndexCache makes IndexDirectory reuse the digest and metadata recorded
+// in cache for archives whose size and modification time have not changed,
+// and record them for archives that had to be loaded.
+func WithIndexCache(cache *IndexCache) IndexDirectoryOption {
+	return func(options *indexDirectoryOptions) {
+		options.cache = cache
+	}
+}
+
+// WithVerify makes IndexDirectory verify every chart archive against the
+// provenance file next to it (the archive name with a .prov suffix) using the
+// public keys in the given keyring.
+//
+// The outcome is recorded in the annotations of each entry (see
+// AnnotationProvenanceVerified, AnnotationProvenanceSigner and
+// AnnotationProvenanceDigest). Indexing fails
+// if any archive cannot be verified.
+func WithVerify(keyring string) IndexDirectoryOption {
+	return func(options *indexDirectoryOptions) {
+		options.keyring = keyring
+	}
+}
+
+// WithSign makes IndexDirectory sign every chart archive with signer, writing
+// the provenance file next to it, unless the archive already has a provenance
+// file that the keyring of signer verifies. With resign, every archive is
+// signed again. The private key of signer must be decrypted.
+//
+// The entries are then verified and annotated as with WithVerify, which
+// WithSign takes precedence over.
+func WithSign(signer *provenance.Signatory, resign bool) IndexDirectoryOption {
+	return func(options *indexDirectoryOptions) {
+		options.signer = signer
+		options.resign = resign
+	}
+}
+
+const (
+	// AnnotationProvenanceVerified is set to "true" on index entries whose
+	// provenance was verified while the index was generated.
+	AnnotationProvenanceVerified = "helm.sh/provenance-verified"
+	// AnnotationProvenanceSigner holds the identity that signed an entry
+	// whose provenance was verified.
+	AnnotationProvenanceSigner = "helm.sh/provenance-signer"
+	// AnnotationProvenanceFingerprint holds the fingerprint of the key that
+	// signed an entry whose provenance was verified.
+	AnnotationProvenanceFingerprint = "helm.sh/provenance-fingerprint"
+	// AnnotationProvenanceDigest holds the SHA256 digest of the provenance
+	// file of an entry whose provenance was verified, so that clients can
+	// tell whether the provenance file they fetch is the one that was.
+	AnnotationProvenanceDigest = "helm.sh/provenance-digest"
+	// AnnotationRemoved holds the time, in RFC 3339 format, at which an entry
+	// marked as removed by MarkRemoved was found to be missing.
+	AnnotationRemoved = "helm.sh/removed"
+)
+
+// IndexDirectory reads a directory and generates an index.
 //
-// It indexes only charts that have been packaged (*.tgz).
+// It indexes only charts that have been packaged (*.tgz). By default only the
+// directory itself and its immediate subdirectories are searched; use
+// WithRecursive to search the whole tree. Each entry is created at the
+// modification time of its archive.
 //
 // The index returned will be in an unsorted state
-func IndexDirectory(dir, baseURL string) (*IndexFile, error) {
-	archives, err := filepath.Glob(filepath.Join(dir, "*.tgz"))
+func IndexDirectory(dir, baseURL string, options ...IndexDirectoryOption) (*IndexFile, error) {
+	opts := indexDirectoryOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+	for _, pattern := range opts.exclude {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)
+		}
+	}
+
+	var archives []string
+	var err error
+	if opts.recursive {
+		archives, err = findArchivesRecursive(dir)
+	} else {
+		archives, err = findArchives(dir)
+	}
 	if err != nil {
 		return nil, err
 	}
-	moreArchives, err := filepath.Glob(filepath.Join(dir, "**/*.tgz"))
+
+	sig := opts.signer
+	if sig == nil && opts.keyring != "" {
+		if sig, err = provenance.NewFromKeyring(opts.keyring, ""); err != nil {
+			return nil, fmt.Errorf("failed to load keyring: %w", err)
+		}
+	}
+
+	workers := opts.workers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	// Archives are loaded concurrently, but the results are collected by
+	// position and added in the original order so that the generated index
+	// does not depend on which worker finished first.
+	results := make([]*indexedArchive, len(archives))
+	errs := make([]error, len(archives))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, len(archives)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range jobs {
+				results[n], errs[n] = indexArchive(dir, archives[n], baseURL, opts)
+				if opts.signer != nil && results[n] != nil && errs[n] == nil {
+					errs[n] = signArchive(opts.signer, archives[n], opts.resign)
+				}
+				if sig != nil && results[n] != nil && errs[n] == nil {
+					errs[n] = verifyArchive(sig, archives[n], results[n])
+				}
+			}
+		}()
+	}
+	for n := range archives {
+		jobs <- n
+	}
+	close(jobs)
+	wg.Wait()
+
+	index := NewIndexFile()
+	for n, res := range results {
+		if errs[n] != nil {
+			return index, errs[n]
+		}
+		if res == nil {
+			continue
+		}
+		if err := index.MustAdd(res.metadata, res.filename, res.parentURL, res.digest); err != nil {
+			return index, fmt.Errorf("failed adding to %s to index: %w", res.filename, err)
+		}
+		cvs := index.Entries[res.metadata.Name]
+		cvs[len(cvs)-1].Created = indexTime(res.created)
+	}
+	return index, nil
+}
+
+// indexedArchive holds what IndexDirectory needs to know about a single chart
+// archive in order to add it to the index.
+type indexedArchive struct {
+	metadata  *chart.Metadata
+	filename  string
+	parentURL string
+	digest    string
+	// created is the modification time of the archive.
+	created time.Time
+}
+
+// indexArchive loads and digests the chart archive at arch, which is located
+// within dir.
+//
+// It returns nil without an error when the archive is excluded or is not a
+// chart.
+func indexArchive(dir, arch, baseURL string, opts indexDirectoryOptions) (*indexedArchive, error) {
+	fname, err := filepath.Rel(dir, arch)
 	if err != nil {
 		return nil, err
 	}
-	archives = append(archives, moreArchives...)
+	if isExcluded(fname, opts.exclude) {
+		return nil, nil
+	}
 
-	index := NewIndexFile()
-	for _, arch := range archives {
-		fname, err := filepath.Rel(dir, arch)
-		if err != nil {
-			return index, err
+	var parentDir string
+	parentDir, fname = filepath.Split(fname)
+	// filepath.Split appends an extra slash to the end of parentDir. We want to strip that out.
+	parentDir = filepath.ToSlash(strings.TrimSuffix(parentDir, string(os.PathSeparator)))
+	parentURL, err := urlutil.URLJoin(baseURL, parentDir)
+	if err != nil {
+		parentURL = path.Join(baseURL, parentDir)
+	}
+
+	fi, err := os.Stat(arch)
+	if err != nil {
+		return nil, err
+	}
+	cacheKey := filepath.ToSlash(filepath.Join(parentDir, fname))
+	if opts.cache != nil {
+		if e, ok := opts.cache.lookup(cacheKey, fi); ok {
+			return &indexedArchive{
+				metadata:  e.Metadata,
+				filename:  fname,
+				parentURL: parentURL,
+				digest:    e.Digest,
+				created:   fi.ModTime(),
+			}, nil
 		}
+	}
 
-		var parentDir string
-		parentDir, fname = filepath.Split(fname)
-		// filepath.Split appends an extra slash to the end of parentDir. We want to strip that out.
-		parentDir = strings.TrimSuffix(parentDir, string(os.PathSeparator))
-		parentURL, err := urlutil.URLJoin(baseURL, parentDir)
-		if err != nil {
-			parentURL = path.Join(baseURL, parentDir)
+	c, err := loader.Load(arch)
+	if err != nil {
+		// Assume this is not a chart.
+		return nil, nil
+	}
+	hash, err := provenance.DigestFile(arch)
+	if err != nil {
+		return nil, err
+	}
+	if opts.cache != nil {
+		opts.cache.store(cacheKey, fi, hash, c.Metadata)
+	}
+	return &indexedArchive{
+		metadata:  c.Metadata,
+		filename:  fname,
+		parentURL: parentURL,
+		digest:    hash,
+		created:   fi.ModTime(),
+	}, nil
+}
+
+// signArchive writes the provenance file of arch, signed by signer, unless
+// resign is false and signer already verifies the existing one.
+func signArchive(signer *provenance.Signatory, arch string, resign bool) error {
+	if !resign {
+		if _, err := signer.Verify(arch, arch+".prov"); err == nil {
+			return nil
+		}
+	}
+	sig, err := signer.ClearSign(arch)
+	if err != nil {
+		return fmt.Errorf("failed to sign %s: %w", arch, err)
+	}
+	return fileutil.AtomicWriteFile(arch+".prov", strings.NewReader(sig), 0644)
+}
+
+// verifyArchive verifies arch against its provenance file and records the
+// outcome in the annotations of res.
+func verifyArchive(sig *provenance.Signatory, arch string, res *indexedArchive) error {
+	ver, err := sig.Verify(arch, arch+".prov")
+	if err != nil {
+		return fmt.Errorf("failed to verify %s: %w", arch, err)
+	}
+	provDigest, err := provenance.DigestFile(arch + ".prov")
+	if err != nil {
+		return err
+	}
+
+	// The metadata may be shared with an IndexCache, so annotate a copy.
+	md := *res.metadata
+	md.Annotations = make(map[string]string, len(res.metadata.Annotations)+4)
+	for k, v := range res.metadata.Annotations {
+		md.Annotations[k] = v
+	}
+	md.Annotations[AnnotationProvenanceVerified] = "true"
+	md.Annotations[AnnotationProvenanceDigest] = provDigest
+	if ver.SignedBy != nil {
+		identities := make([]string, 0, len(ver.SignedBy.Identities))
+		for name := range ver.SignedBy.Identities {
+			identities = append(identities, name)
+		}
+		sort.Strings(identities)
+		if len(identities) > 0 {
+			md.Annotations[AnnotationProvenanceSigner] = identities[0]
 		}
+		md.Annotations[AnnotationProvenanceFingerprint] = fmt.Sprintf("%X", ver.SignedBy.PrimaryKey.Fingerprint)
+	}
+	res.metadata = &md
+	return nil
+}
+
+// isExcluded reports whether the relative archive path matches any of the
+// patterns. The patterns are expected to have been validated already.
+func isExcluded(rel string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// findArchives returns the packaged charts in dir and its immediate subdirectories.
+func findArchives(dir string) ([]string, error) {
+	archives, err := filepath.Glob(filepath.Join(dir, "*.tgz"))
+	if err != nil {
+		return nil, err
+	}
+	moreArchives, err := filepath.Glob(filepath.Join(dir, "**/*.tgz"))
+	if err != nil {
+		return nil, err
+	}
+	return append(archives, moreArchives...), nil
+}
 
-		c, err := loader.Load(arch)
+// findArchivesRecursive returns the packaged charts anywhere below root.
+//
+// Symbolic links are followed, but each directory is only visited once, which
+// guards against symlink loops. Hidden directories are skipped.
+func findArchivesRecursive(root string) ([]string, error) {
+	var archives []string
+	visited := map[string]bool{}
+
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		real, err := filepath.EvalSymlinks(dir)
 		if err != nil {
-			// Assume this is not a chart.
-			continue
+			return err
+		}
+		if visited[real] {
+			slog.Warn("skipping already visited directory", "path", dir, "target", real)
+			return nil
 		}
-		hash, err := provenance.DigestFile(arch)
+		visited[real] = true
+
+		entries, err := os.ReadDir(dir)
 		if err != nil {
-			return index, err
+			return err
 		}
-		if err := index.MustAdd(c.Metadata, fname, parentURL, hash); err != nil {
-			return index, fmt.Errorf("failed adding to %s to index: %w", fname, err)
+		for _, e := range entries {
+			p := filepath.Join(dir, e.Name())
+			fi, err := os.Stat(p)
+			if err != nil {
+				// Dangling symlinks and the like cannot contain charts.
+				slog.Warn("skipping unreadable path", "path", p, slog.Any("error", err))
+				continue
+			}
+			if fi.IsDir() {
+				if strings.HasPrefix(e.Name(), ".") {
+					continue
+				}
+				if err := walk(p); err != nil {
+					return err
+				}
+				continue
+			}
+			if filepath.Ext(p) == ".tgz" {
+				archives = append(archives, p)
+			}
 		}
+		return nil
 	}
-	return index, nil
+
+	if err := walk(root); err != nil {
+		return nil, err
+	}
+	return archives, nil
 }
 
 // loadIndex loads an index file and does minimal validity checking.
@@ -353,33 +920,44 @@ func loadIndex(data []byte, source string) (*IndexFile, error) {
 	}
 
 	for name, cvs := range i.Entries {
-		for idx := len(cvs) - 1; idx >= 0; idx-- {
-			if cvs[idx] == nil {
-				slog.Warn("skipping loading invalid entry for chart %q from %s: empty entry", name, source)
-				continue
-			}
-			// When metadata section missing, initialize with no data
-			if cvs[idx].Metadata == nil {
-				cvs[idx].Metadata = &chart.Metadata{}
-			}
-			if cvs[idx].APIVersion == "" {
-				cvs[idx].APIVersion = chart.APIVersionV1
-			}
-			if err := cvs[idx].Validate(); ignoreSkippableChartValidationError(err) != nil {
-				slog.Warn("skipping loading invalid entry for chart %q %q from %s: %s", name, cvs[idx].Version, source, err)
-				cvs = append(cvs[:idx], cvs[idx+1:]...)
-			}
-		}
 		// adjust slice to only contain a set of valid versions
-		i.Entries[name] = cvs
+		i.Entries[name] = validateChartVersions(name, cvs, source)
 	}
 	i.SortEntries()
 	if i.APIVersion == "" {
 		return i, ErrNoAPIVersion
 	}
+	for _, dup := range i.Duplicates() {
+		slog.Warn("index contains duplicate chart versions", "source", source, "chart", dup.Name, "version", dup.Version, "digests", dup.Digests, "conflicting", dup.Conflicting())
+	}
 	return i, nil
 }
 
+// validateChartVersions fills in defaults for the versions of the named chart
+// and drops those that are invalid.
+//
+// The source parameter is only used for logging.
+func validateChartVersions(name string, cvs ChartVersions, source string) ChartVersions {
+	for idx := len(cvs) - 1; idx >= 0; idx-- {
+		if cvs[idx] == nil {
+			slog.Warn("skipping loading invalid entry for chart %q from %s: empty entry", name, source)
+			continue
+		}
+		// When metadata section missing, initialize with no data
+		if cvs[idx].Metadata == nil {
+			cvs[idx].Metadata = &chart.Metadata{}
+		}
+		if cvs[idx].APIVersion == "" {
+			cvs[idx].APIVersion = chart.APIVersionV1
+		}
+		if err := cvs[idx].Validate(); ignoreSkippableChartValidationError(err) != nil {
+			slog.Warn("skipping loading invalid entry for chart %q %q from %s: %s", name, cvs[idx].Version, source, err)
+			cvs = append(cvs[:idx], cvs[idx+1:]...)
+		}
+	}
+	return cvs
+}
+
 // jsonOrYamlUnmarshal unmarshals the given byte slice containing JSON or YAML
 // into the provided interface.
 //
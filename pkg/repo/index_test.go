@@ -24,14 +24,17 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	chart "helm.sh/helm/v4/pkg/chart/v2"
 	"helm.sh/helm/v4/pkg/cli"
 	"helm.sh/helm/v4/pkg/getter"
 	"helm.sh/helm/v4/pkg/helmpath"
+	"helm.sh/helm/v4/pkg/provenance"
 )
 
 const (
@@ -253,6 +256,172 @@ func TestMerge(t *testing.T) {
 
 }
 
+func TestDuplicates(t *testing.T) {
+	ind := NewIndexFile()
+	for _, x := range []struct {
+		name, version, digest string
+	}{
+		{"frobnitz", "1.2.3", "sha256:1111"},
+		{"frobnitz", "1.2.3", "sha256:2222"},
+		{"frobnitz", "1.2.2", "sha256:3333"},
+		{"zarthal", "1.0.0", "sha256:4444"},
+		{"zarthal", "1.0.0", "sha256:4444"},
+		{"sprocket", "1.1.0", "sha256:5555"},
+	} {
+		ind.Entries[x.name] = append(ind.Entries[x.name], &ChartVersion{
+			Metadata: &chart.Metadata{APIVersion: "v2", Name: x.name, Version: x.version},
+			Digest:   x.digest,
+		})
+	}
+
+	dups := ind.Duplicates()
+	if len(dups) != 2 {
+		t.Fatalf("Expected 2 duplicates, got %d", len(dups))
+	}
+	for i, x := range []struct {
+		msg         string
+		conflicting bool
+	}{
+		{`chart "frobnitz" version "1.2.3" has 2 entries with conflicting digests sha256:1111, sha256:2222`, true},
+		{`chart "zarthal" version "1.0.0" has 2 entries`, false},
+	} {
+		if dups[i].String() != x.msg {
+			t.Errorf("Expected %q, got %q", x.msg, dups[i].String())
+		}
+		if dups[i].Conflicting() != x.conflicting {
+			t.Errorf("Expected %q conflicting to be %t", x.msg, x.conflicting)
+		}
+	}
+
+	if dups := NewIndexFile().Duplicates(); len(dups) != 0 {
+		t.Errorf("Expected no duplicates in an empty index, got %v", dups)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	copyTestFile(t, "testdata/repository/frobnitz-1.2.3.tgz", filepath.Join(dir, "frobnitz-1.2.3.tgz"))
+	copyTestFile(t, "testdata/repository/universe/zarthal-1.0.0.tgz", filepath.Join(dir, "universe", "zarthal-1.0.0.tgz"))
+
+	ind := NewIndexFile()
+	for _, x := range []struct {
+		name, version, url string
+	}{
+		{"frobnitz", "1.2.3", "https://example.com/charts/frobnitz-1.2.3.tgz"},
+		{"frobnitz", "1.2.2", "https://example.com/charts/frobnitz-1.2.2.tgz"},
+		{"zarthal", "1.0.0", "universe/zarthal-1.0.0.tgz"},
+		{"zarthal", "0.9.0", "universe/zarthal-0.9.0.tgz"},
+		{"sprocket", "1.1.0", "sprocket-1.1.0.tgz"},
+		{"external", "1.0.0", "https://elsewhere.example.com/external-1.0.0.tgz"},
+	} {
+		ind.Entries[x.name] = append(ind.Entries[x.name], &ChartVersion{
+			Metadata: &chart.Metadata{APIVersion: "v2", Name: x.name, Version: x.version},
+			URLs:     []string{x.url},
+		})
+	}
+
+	removed := ind.Prune(dir, "https://example.com/charts/")
+	if len(removed) != 3 {
+		t.Errorf("Expected 3 removed entries, got %d", len(removed))
+	}
+
+	for _, x := range []struct {
+		name, version string
+		expect        bool
+	}{
+		{"frobnitz", "1.2.3", true},
+		{"frobnitz", "1.2.2", false},
+		{"zarthal", "1.0.0", true},
+		{"zarthal", "0.9.0", false},
+		{"external", "1.0.0", true},
+	} {
+		if got := ind.Has(x.name, x.version); got != x.expect {
+			t.Errorf("Expected Has(%q, %q) to be %t", x.name, x.version, x.expect)
+		}
+	}
+	if _, ok := ind.Entries["sprocket"]; ok {
+		t.Error("Expected sprocket to be removed from the entries entirely")
+	}
+}
+
+func TestMarkRemoved(t *testing.T) {
+	dir := t.TempDir()
+	copyTestFile(t, "testdata/repository/frobnitz-1.2.3.tgz", filepath.Join(dir, "frobnitz-1.2.3.tgz"))
+
+	ind := NewIndexFile()
+	for _, x := range []struct {
+		name, version, url string
+	}{
+		{"frobnitz", "1.2.3", "frobnitz-1.2.3.tgz"},
+		{"frobnitz", "1.2.4", "frobnitz-1.2.4.tgz"},
+		{"external", "1.0.0", "https://elsewhere.example.com/external-1.0.0.tgz"},
+	} {
+		ind.Entries[x.name] = append(ind.Entries[x.name], &ChartVersion{
+			Metadata: &chart.Metadata{APIVersion: "v2", Name: x.name, Version: x.version},
+			URLs:     []string{x.url},
+		})
+	}
+	ind.SortEntries()
+
+	now := time.Date(2024, 5, 1, 12, 30, 15, 500, time.FixedZone("CEST", 2*60*60))
+	removed := ind.MarkRemoved(dir, "", now)
+	if len(removed) != 1 || removed[0].Version != "1.2.4" {
+		t.Fatalf("Expected frobnitz 1.2.4 to be marked as removed, got %v", removed)
+	}
+	if !removed[0].Removed || removed[0].Annotations[AnnotationRemoved] != "2024-05-01T10:30:15Z" {
+		t.Errorf("Unexpected removal record: removed %t, annotations %v", removed[0].Removed, removed[0].Annotations)
+	}
+	if !ind.Has("frobnitz", "1.2.4") {
+		t.Error("Expected the removed entry to be kept in the index")
+	}
+	if cv, err := ind.Get("frobnitz", ""); err != nil || cv.Version != "1.2.3" {
+		t.Errorf("Expected Get to skip the removed entry, got %v, %v", cv, err)
+	}
+	if _, err := ind.Get("frobnitz", "1.2.4"); err == nil {
+		t.Error("Expected Get to skip the removed entry")
+	}
+
+	// Entries already marked keep their time of removal.
+	if removed := ind.MarkRemoved(dir, "", now.Add(time.Hour)); len(removed) != 0 {
+		t.Errorf("Expected no newly removed entries, got %v", removed)
+	}
+	if cv := ind.Entries["frobnitz"][0]; cv.Annotations[AnnotationRemoved] != "2024-05-01T10:30:15Z" {
+		t.Errorf("Expected the time of removal to be kept, got %q", cv.Annotations[AnnotationRemoved])
+	}
+}
+
+func TestSortedByCreated(t *testing.T) {
+	at := func(day int) time.Time { return time.Date(2024, 5, day, 0, 0, 0, 0, time.UTC) }
+	ind := NewIndexFile()
+	for _, x := range []struct {
+		name, version string
+		created       time.Time
+		removed       bool
+	}{
+		{"alpine", "1.0.0", at(1), false},
+		{"alpine", "1.1.0", at(3), false},
+		{"alpine", "1.2.0", at(4), true},
+		{"nginx", "2.0.0", at(3), false},
+		{"nginx", "1.0.0", at(2), false},
+		{"redis", "1.0.0", time.Time{}, false},
+	} {
+		ind.Entries[x.name] = append(ind.Entries[x.name], &ChartVersion{
+			Metadata: &chart.Metadata{APIVersion: "v2", Name: x.name, Version: x.version},
+			Created:  x.created,
+			Removed:  x.removed,
+		})
+	}
+
+	var got []string
+	for _, cv := range ind.SortedByCreated() {
+		got = append(got, cv.Name+"-"+cv.Version)
+	}
+	expect := []string{"alpine-1.1.0", "nginx-2.0.0", "nginx-1.0.0", "alpine-1.0.0", "redis-1.0.0"}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("Expected %v, got %v", expect, got)
+	}
+}
+
 func TestDownloadIndexFile(t *testing.T) {
 	t.Run("should  download index file", func(t *testing.T) {
 		srv, err := startLocalServerForTests(nil)
@@ -508,6 +677,289 @@ func TestIndexDirectory(t *testing.T) {
 	}
 }
 
+func TestIndexDirectoryCreated(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "frobnitz-1.2.3.tgz")
+	copyTestFile(t, "testdata/repository/frobnitz-1.2.3.tgz", archive)
+	mtime := time.Date(2023, 11, 14, 22, 13, 20, 123456789, time.FixedZone("PST", -8*60*60))
+	if err := os.Chtimes(archive, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := IndexDirectory(dir, "http://localhost:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created := index.Entries["frobnitz"][0].Created; !created.Equal(mtime.Truncate(time.Second)) {
+		t.Errorf("Expected the entry to be created at the modification time of its archive, got %s", created)
+	}
+
+	var buf bytes.Buffer
+	if err := index.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "created: \"2023-11-15T06:13:20Z\"") {
+		t.Errorf("Expected the creation time in RFC 3339 format, got:\n%s", buf.String())
+	}
+}
+
+func TestIndexDirectoryRecursive(t *testing.T) {
+	dir := t.TempDir()
+	for src, dest := range map[string]string{
+		"testdata/repository/frobnitz-1.2.3.tgz":         "frobnitz-1.2.3.tgz",
+		"testdata/repository/sprocket-1.1.0.tgz":         "team-a/sprocket-1.1.0.tgz",
+		"testdata/repository/universe/zarthal-1.0.0.tgz": "team-b/nested/deeper/zarthal-1.0.0.tgz",
+		"testdata/repository/sprocket-1.2.0.tgz":         ".git/sprocket-1.2.0.tgz",
+	} {
+		copyTestFile(t, src, filepath.Join(dir, dest))
+	}
+	// A symlink back to the root must not make the walk loop forever.
+	if err := os.Symlink(dir, filepath.Join(dir, "team-b", "nested", "loop")); err != nil {
+		t.Skipf("symlinks not supported: %s", err)
+	}
+
+	index, err := IndexDirectory(dir, "http://localhost:8080", WithRecursive(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if l := len(index.Entries); l != 3 {
+		t.Fatalf("Expected 3 entries, got %d", l)
+	}
+
+	corpus := []struct{ chartName, downloadLink string }{
+		{"frobnitz", "http://localhost:8080/frobnitz-1.2.3.tgz"},
+		{"sprocket", "http://localhost:8080/team-a/sprocket-1.1.0.tgz"},
+		{"zarthal", "http://localhost:8080/team-b/nested/deeper/zarthal-1.0.0.tgz"},
+	}
+	for _, test := range corpus {
+		versions, ok := index.Entries[test.chartName]
+		if !ok {
+			t.Fatalf("Could not read chart %s", test.chartName)
+		}
+		if len(versions) != 1 {
+			t.Fatalf("Expected 1 version of %s, got %d", test.chartName, len(versions))
+		}
+		if versions[0].URLs[0] != test.downloadLink {
+			t.Errorf("Unexpected URLs for %s: %v", test.chartName, versions[0].URLs)
+		}
+	}
+
+	// Without the option nested charts beyond the first level are not found.
+	index, err = IndexDirectory(dir, "http://localhost:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := index.Entries["zarthal"]; ok {
+		t.Error("Expected zarthal not to be indexed without recursion")
+	}
+}
+
+func TestIndexDirectoryExclude(t *testing.T) {
+	dir := "testdata/repository"
+
+	index, err := IndexDirectory(dir, "http://localhost:8080", WithExclude("sprocket-1.1.*", "universe/*.tgz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := len(index.Entries); l != 2 {
+		t.Fatalf("Expected 2 entries, got %d", l)
+	}
+	if _, ok := index.Entries["zarthal"]; ok {
+		t.Error("Expected zarthal to be excluded")
+	}
+	if !index.Has("sprocket", "1.2.0") || index.Has("sprocket", "1.1.0") {
+		t.Errorf("Expected only sprocket 1.2.0, got %v", index.Entries["sprocket"])
+	}
+
+	// Patterns also match the file name of nested archives.
+	index, err = IndexDirectory(dir, "http://localhost:8080", WithExclude("zarthal-*.tgz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := index.Entries["zarthal"]; ok {
+		t.Error("Expected zarthal to be excluded by file name")
+	}
+
+	if _, err := IndexDirectory(dir, "http://localhost:8080", WithExclude("[")); err == nil {
+		t.Error("Expected error for invalid exclude pattern")
+	} else if !strings.Contains(err.Error(), `invalid exclude pattern "["`) {
+		t.Errorf("Unexpected error: %s", err)
+	}
+}
+
+func TestIndexDirectoryWorkers(t *testing.T) {
+	dir := t.TempDir()
+	for n := range 20 {
+		copyTestFile(t, "testdata/repository/sprocket-1.1.0.tgz", filepath.Join(dir, fmt.Sprintf("a%02d", n), "sprocket-1.1.0.tgz"))
+		copyTestFile(t, "testdata/repository/frobnitz-1.2.3.tgz", filepath.Join(dir, fmt.Sprintf("b%02d", n), "frobnitz-1.2.3.tgz"))
+	}
+
+	serial, err := IndexDirectory(dir, "http://localhost:8080", WithWorkers(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parallel, err := IndexDirectory(dir, "http://localhost:8080", WithWorkers(8))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, versions := range serial.Entries {
+		if len(versions) != 20 {
+			t.Errorf("Expected 20 versions of %s, got %d", name, len(versions))
+		}
+		other := parallel.Entries[name]
+		if len(other) != len(versions) {
+			t.Fatalf("Expected %d versions of %s, got %d", len(versions), name, len(other))
+		}
+		for n := range versions {
+			if versions[n].URLs[0] != other[n].URLs[0] {
+				t.Errorf("Expected %s at position %d, got %s", versions[n].URLs[0], n, other[n].URLs[0])
+			}
+		}
+	}
+}
+
+func BenchmarkIndexDirectory(b *testing.B) {
+	src, err := os.ReadFile("testdata/repository/frobnitz-1.2.3.tgz")
+	if err != nil {
+		b.Fatal(err)
+	}
+	dir := b.TempDir()
+	for n := range 300 {
+		sub := filepath.Join(dir, fmt.Sprintf("d%03d", n))
+		if err := os.Mkdir(sub, 0755); err != nil {
+			b.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(sub, "frobnitz-1.2.3.tgz"), src, 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for b.Loop() {
+				if _, err := IndexDirectory(dir, "http://localhost:8080", WithWorkers(workers)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestIndexDirectoryVerify(t *testing.T) {
+	const keyring = "../provenance/testdata/helm-test-key.pub"
+
+	dir := t.TempDir()
+	copyTestFile(t, "../provenance/testdata/hashtest-1.2.3.tgz", filepath.Join(dir, "hashtest-1.2.3.tgz"))
+	copyTestFile(t, "../provenance/testdata/hashtest-1.2.3.tgz.prov", filepath.Join(dir, "hashtest-1.2.3.tgz.prov"))
+
+	index, err := IndexDirectory(dir, "http://localhost:8080", WithVerify(keyring))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cv, err := index.Get("hashtest", "1.2.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cv.Annotations[AnnotationProvenanceVerified] != "true" {
+		t.Errorf("Expected chart to be marked as verified, got %v", cv.Annotations)
+	}
+	if signer := cv.Annotations[AnnotationProvenanceSigner]; !strings.Contains(signer, "helm-testing@helm.sh") {
+		t.Errorf("Unexpected signer %q", signer)
+	}
+	if cv.Annotations[AnnotationProvenanceFingerprint] == "" {
+		t.Error("Expected key fingerprint to be recorded")
+	}
+	if digest, _ := provenance.DigestFile(filepath.Join(dir, "hashtest-1.2.3.tgz.prov")); cv.Annotations[AnnotationProvenanceDigest] != digest {
+		t.Errorf("Expected the provenance digest %q, got %q", digest, cv.Annotations[AnnotationProvenanceDigest])
+	}
+
+	// An archive without a provenance file fails verification.
+	copyTestFile(t, "testdata/repository/frobnitz-1.2.3.tgz", filepath.Join(dir, "frobnitz-1.2.3.tgz"))
+	_, err = IndexDirectory(dir, "http://localhost:8080", WithVerify(keyring))
+	if err == nil {
+		t.Fatal("Expected verification error for unsigned chart")
+	}
+	if !strings.Contains(err.Error(), "frobnitz-1.2.3.tgz") {
+		t.Errorf("Expected error to name the offending archive, got %s", err)
+	}
+}
+
+func TestIndexDirectorySign(t *testing.T) {
+	signer, err := provenance.NewFromKeyring("../provenance/testdata/helm-test-key.secret", "helm-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := signer.DecryptKey(func(string) ([]byte, error) { return nil, nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	copyTestFile(t, "../provenance/testdata/hashtest-1.2.3.tgz", filepath.Join(dir, "hashtest-1.2.3.tgz"))
+	copyTestFile(t, "../provenance/testdata/hashtest-1.2.3.tgz.prov", filepath.Join(dir, "hashtest-1.2.3.tgz.prov"))
+	copyTestFile(t, "testdata/repository/frobnitz-1.2.3.tgz", filepath.Join(dir, "frobnitz-1.2.3.tgz"))
+	signed, err := os.ReadFile(filepath.Join(dir, "hashtest-1.2.3.tgz.prov"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := IndexDirectory(dir, "http://localhost:8080", WithSign(signer, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"hashtest", "frobnitz"} {
+		cv, err := index.Get(name, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cv.Annotations[AnnotationProvenanceVerified] != "true" {
+			t.Errorf("Expected %s to be marked as verified, got %v", name, cv.Annotations)
+		}
+		if cv.Annotations[AnnotationProvenanceDigest] == "" {
+			t.Errorf("Expected the provenance digest of %s to be recorded", name)
+		}
+	}
+	if _, err := signer.Verify(filepath.Join(dir, "frobnitz-1.2.3.tgz"), filepath.Join(dir, "frobnitz-1.2.3.tgz.prov")); err != nil {
+		t.Errorf("Expected the unsigned chart to be signed: %s", err)
+	}
+	// The valid provenance file is kept, unless resigning.
+	if b, _ := os.ReadFile(filepath.Join(dir, "hashtest-1.2.3.tgz.prov")); !bytes.Equal(b, signed) {
+		t.Error("Expected the valid provenance file not to be signed again")
+	}
+	if _, err := IndexDirectory(dir, "http://localhost:8080", WithSign(signer, true)); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "hashtest-1.2.3.tgz.prov")); bytes.Equal(b, signed) {
+		t.Error("Expected the provenance file to be signed again")
+	}
+
+	// An invalid provenance file is replaced.
+	if err := os.WriteFile(filepath.Join(dir, "frobnitz-1.2.3.tgz.prov"), []byte("invalid"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := IndexDirectory(dir, "http://localhost:8080", WithSign(signer, false)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := signer.Verify(filepath.Join(dir, "frobnitz-1.2.3.tgz"), filepath.Join(dir, "frobnitz-1.2.3.tgz.prov")); err != nil {
+		t.Errorf("Expected the invalid provenance file to be replaced: %s", err)
+	}
+}
+
+func copyTestFile(t *testing.T, src, dest string) {
+	t.Helper()
+	b, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dest, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestIndexAdd(t *testing.T) {
 	i := NewIndexFile()
 
@@ -559,6 +1011,21 @@ func TestIndexWrite(t *testing.T) {
 	if !strings.Contains(string(got), "clipper-0.1.0.tgz") {
 		t.Fatal("Index files doesn't contain expected content")
 	}
+
+	fi, err := os.Stat(testpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := fi.Mode().Perm(); perm != 0600 {
+		t.Errorf("Expected mode 0600, got %o", perm)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected no temporary files to be left behind, found %d entries", len(entries))
+	}
 }
 
 func TestIndexJSONWrite(t *testing.T) {
@@ -582,6 +1049,37 @@ func TestIndexJSONWrite(t *testing.T) {
 	}
 }
 
+func TestIndexWriteToWriter(t *testing.T) {
+	i := NewIndexFile()
+	if err := i.MustAdd(&chart.Metadata{APIVersion: "v2", Name: "clipper", Version: "0.1.0"}, "clipper-0.1.0.tgz", "http://example.com/charts", "sha256:1234567890"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var yamlBuf, jsonBuf bytes.Buffer
+	if err := i.Write(&yamlBuf); err != nil {
+		t.Fatal(err)
+	}
+	if err := i.WriteJSON(&jsonBuf); err != nil {
+		t.Fatal(err)
+	}
+
+	if json.Valid(yamlBuf.Bytes()) {
+		t.Error("Expected YAML output not to be valid JSON")
+	}
+	if !json.Valid(jsonBuf.Bytes()) {
+		t.Error("Expected valid JSON output")
+	}
+	for _, b := range [][]byte{yamlBuf.Bytes(), jsonBuf.Bytes()} {
+		loaded, err := loadIndex(b, "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !loaded.Has("clipper", "0.1.0") {
+			t.Errorf("Expected clipper 0.1.0 in output:\n%s", b)
+		}
+	}
+}
+
 func TestAddFileIndexEntriesNil(t *testing.T) {
 	i := NewIndexFile()
 	i.APIVersion = chart.APIVersionV1
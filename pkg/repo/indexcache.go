@@ -0,0 +1,135 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"helm.sh/helm/v4/internal/fileutil"
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+)
+
+// IndexCache records the chart archives seen by a previous run of
+// IndexDirectory so that unchanged archives need not be loaded and digested
+// again.
+//
+// An archive is considered unchanged when both its size and modification time
+// match the cached record.
+type IndexCache struct {
+	// Entries is keyed by the slash-separated path of each archive relative
+	// to the indexed directory.
+	Entries map[string]*IndexCacheEntry `json:"entries"`
+
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+// IndexCacheEntry is the cached record for a single chart archive.
+type IndexCacheEntry struct {
+	Size     int64           `json:"size"`
+	ModTime  time.Time       `json:"modTime"`
+	Digest   string          `json:"digest"`
+	Metadata *chart.Metadata `json:"metadata"`
+}
+
+// NewIndexCache returns an empty IndexCache.
+func NewIndexCache() *IndexCache {
+	return &IndexCache{Entries: map[string]*IndexCacheEntry{}}
+}
+
+// LoadIndexCache reads the cache file at path.
+//
+// A missing file is not an error; an empty cache is returned instead.
+func LoadIndexCache(path string) (*IndexCache, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return NewIndexCache(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := NewIndexCache()
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("error loading index cache %s: %w", path, err)
+	}
+	if c.Entries == nil {
+		c.Entries = map[string]*IndexCacheEntry{}
+	}
+	return c, nil
+}
+
+// WriteFile writes the cache to the given destination path.
+//
+// Only archives that were looked up since the cache was loaded are written,
+// so records for archives that have been removed are dropped.
+//
+// The mode on the file is set to 'mode'.
+func (c *IndexCache) WriteFile(dest string, mode os.FileMode) error {
+	c.mu.Lock()
+	out := &IndexCache{Entries: map[string]*IndexCacheEntry{}}
+	for name, e := range c.Entries {
+		if c.seen == nil || c.seen[name] {
+			out.Entries[name] = e
+		}
+	}
+	c.mu.Unlock()
+
+	b, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutil.AtomicWriteFile(dest, bytes.NewReader(b), mode)
+}
+
+// lookup returns the cached record for name if it still matches fi.
+func (c *IndexCache) lookup(name string, fi fs.FileInfo) (*IndexCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.markSeen(name)
+	e, ok := c.Entries[name]
+	if !ok || e.Metadata == nil || e.Size != fi.Size() || !e.ModTime.Equal(fi.ModTime()) {
+		return nil, false
+	}
+	return e, true
+}
+
+// store records the digest and metadata computed for name.
+func (c *IndexCache) store(name string, fi fs.FileInfo, digest string, md *chart.Metadata) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.markSeen(name)
+	c.Entries[name] = &IndexCacheEntry{
+		Size:     fi.Size(),
+		ModTime:  fi.ModTime(),
+		Digest:   digest,
+		Metadata: md,
+	}
+}
+
+func (c *IndexCache) markSeen(name string) {
+	if c.seen == nil {
+		c.seen = map[string]bool{}
+	}
+	c.seen[name] = true
+}
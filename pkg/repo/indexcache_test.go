@@ -0,0 +1,125 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIndexDirectoryWithCache(t *testing.T) {
+	dir := t.TempDir()
+	copyTestFile(t, "testdata/repository/frobnitz-1.2.3.tgz", filepath.Join(dir, "frobnitz-1.2.3.tgz"))
+	copyTestFile(t, "testdata/repository/universe/zarthal-1.0.0.tgz", filepath.Join(dir, "universe", "zarthal-1.0.0.tgz"))
+	cacheFile := filepath.Join(t.TempDir(), "cache.json")
+
+	cache, err := LoadIndexCache(cacheFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := IndexDirectory(dir, "http://localhost:8080", WithIndexCache(cache))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.WriteFile(cacheFile, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if l := len(cache.Entries); l != 2 {
+		t.Fatalf("Expected 2 cache entries, got %d", l)
+	}
+	original := index.Entries["zarthal"][0].Digest
+
+	// Tamper with a cached digest so that we can tell whether it was reused.
+	cache, err = LoadIndexCache(cacheFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.Entries["universe/zarthal-1.0.0.tgz"].Digest = "sha256:cached"
+	if err := cache.WriteFile(cacheFile, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err = LoadIndexCache(cacheFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err = IndexDirectory(dir, "http://localhost:8080", WithIndexCache(cache))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := index.Entries["zarthal"][0].Digest; got != "sha256:cached" {
+		t.Errorf("Expected cached digest to be reused, got %q", got)
+	}
+	if got := index.Entries["zarthal"][0].URLs[0]; got != "http://localhost:8080/universe/zarthal-1.0.0.tgz" {
+		t.Errorf("Unexpected URL %q", got)
+	}
+
+	// Replace the archive with different content but keep the modification
+	// time. The size differs, so the cache must not be used.
+	arch := filepath.Join(dir, "universe", "zarthal-1.0.0.tgz")
+	fi, err := os.Stat(arch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copyTestFile(t, "testdata/repository/sprocket-1.2.0.tgz", arch)
+	if err := os.Chtimes(arch, fi.ModTime(), fi.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	index, err = IndexDirectory(dir, "http://localhost:8080", WithIndexCache(cache))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := index.Entries["zarthal"]; ok {
+		t.Error("Expected stale cache entry for zarthal to be ignored")
+	}
+	if got := index.Entries["sprocket"][0].Digest; got == "sha256:cached" || got == original {
+		t.Errorf("Expected digest to be recomputed, got %q", got)
+	}
+
+	// Records for removed archives are dropped when the cache is written.
+	if err := os.Remove(filepath.Join(dir, "frobnitz-1.2.3.tgz")); err != nil {
+		t.Fatal(err)
+	}
+	cache, err = LoadIndexCache(cacheFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := IndexDirectory(dir, "http://localhost:8080", WithIndexCache(cache)); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.WriteFile(cacheFile, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cache, err = LoadIndexCache(cacheFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Entries["frobnitz-1.2.3.tgz"]; ok {
+		t.Error("Expected cache entry for removed archive to be dropped")
+	}
+}
+
+func TestLoadIndexCacheInvalid(t *testing.T) {
+	cacheFile := filepath.Join(t.TempDir(), "cache.json")
+	if err := os.WriteFile(cacheFile, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadIndexCache(cacheFile); err == nil {
+		t.Error("Expected error loading invalid cache file")
+	}
+}
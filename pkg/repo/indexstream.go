@@ -0,0 +1,288 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// LoadIndexFileStream reads an index file in YAML or JSON format from r.
+//
+// Unlike LoadIndexFile, the input is never held in memory as a whole. Entries
+// are decoded one chart at a time, so peak memory use is roughly the size of
+// the resulting IndexFile rather than a multiple of the size of the input.
+//
+// The result is identical to what LoadIndexFile returns for the same content.
+func LoadIndexFileStream(r io.Reader) (*IndexFile, error) {
+	return loadIndexStream(r, "stream")
+}
+
+// loadIndexStream is the streaming counterpart of loadIndex.
+//
+// The source parameter is only used for logging.
+func loadIndexStream(r io.Reader, source string) (*IndexFile, error) {
+	i := &IndexFile{}
+	br := bufio.NewReader(r)
+
+	first, err := peekNonSpace(br)
+	if errors.Is(err, io.EOF) {
+		return i, ErrEmptyIndexYaml
+	}
+	if err != nil {
+		return i, err
+	}
+
+	if first == '{' {
+		err = decodeIndexJSONStream(br, i, source)
+	} else {
+		err = decodeIndexYAMLStream(br, i, source)
+	}
+	if err != nil {
+		return i, err
+	}
+
+	i.SortEntries()
+	if i.APIVersion == "" {
+		return i, ErrNoAPIVersion
+	}
+	return i, nil
+}
+
+// peekNonSpace returns the first byte of br that is not white space without
+// consuming it.
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b, br.UnreadByte()
+	}
+}
+
+// decodeIndexJSONStream decodes a JSON index, streaming the members of the
+// top-level "entries" object one version at a time.
+func decodeIndexJSONStream(r io.Reader, i *IndexFile, source string) error {
+	dec := json.NewDecoder(r)
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	header := map[string]json.RawMessage{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := tok.(string)
+		if key != "entries" {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return err
+			}
+			header[key] = raw
+			continue
+		}
+		if err := decodeEntriesJSONStream(dec, i, source); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	h := &IndexFile{}
+	if err := json.Unmarshal(b, h); err != nil {
+		return err
+	}
+	mergeIndexHeader(i, h, source)
+	return nil
+}
+
+func decodeEntriesJSONStream(dec *json.Decoder, i *IndexFile, source string) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return fmt.Errorf("expected entries to be an object, got %v", tok)
+	}
+	if i.Entries == nil {
+		i.Entries = map[string]ChartVersions{}
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		name := tok.(string)
+
+		tok, err = dec.Token()
+		if err != nil {
+			return err
+		}
+		if tok == nil {
+			i.Entries[name] = nil
+			continue
+		}
+		if d, ok := tok.(json.Delim); !ok || d != '[' {
+			return fmt.Errorf("expected versions of chart %q to be a list, got %v", name, tok)
+		}
+		var cvs ChartVersions
+		for dec.More() {
+			var cv *ChartVersion
+			if err := dec.Decode(&cv); err != nil {
+				return err
+			}
+			cvs = append(cvs, cv)
+		}
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+		i.Entries[name] = validateChartVersions(name, cvs, source)
+	}
+	_, err = dec.Token()
+	return err
+}
+
+// decodeIndexYAMLStream decodes a YAML index.
+//
+// The document is split into chunks by indentation: each chart listed under
+// the top-level "entries" key is decoded on its own as soon as the next chart
+// starts, and everything else is collected and decoded at the end.
+func decodeIndexYAMLStream(r *bufio.Reader, i *IndexFile, source string) error {
+	var header, chunk bytes.Buffer
+	inEntries := false
+	entriesIndent := -1
+
+	flush := func() error {
+		if chunk.Len() == 0 {
+			return nil
+		}
+		defer chunk.Reset()
+		var entries map[string]ChartVersions
+		if err := yaml.UnmarshalStrict(chunk.Bytes(), &entries); err != nil {
+			return err
+		}
+		if i.Entries == nil {
+			i.Entries = map[string]ChartVersions{}
+		}
+		for name, cvs := range entries {
+			if _, ok := i.Entries[name]; ok {
+				return fmt.Errorf("duplicate entries for chart %q", name)
+			}
+			i.Entries[name] = validateChartVersions(name, cvs, source)
+		}
+		return nil
+	}
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if line == "" && errors.Is(err, io.EOF) {
+			break
+		}
+
+		trimmed := strings.TrimLeft(line, " ")
+		indent := len(line) - len(trimmed)
+		blank := strings.TrimSpace(trimmed) == ""
+
+		switch {
+		case blank:
+			// Blank lines may be significant inside block scalars.
+			if inEntries {
+				chunk.WriteString(line)
+			} else {
+				header.WriteString(line)
+			}
+		case indent == 0 && !strings.HasPrefix(trimmed, "#"):
+			if ferr := flush(); ferr != nil {
+				return ferr
+			}
+			inEntries = false
+			if strings.TrimSpace(line) == "entries:" {
+				inEntries = true
+				entriesIndent = -1
+				break
+			}
+			header.WriteString(line)
+		case inEntries:
+			if entriesIndent < 0 && !strings.HasPrefix(trimmed, "#") {
+				entriesIndent = indent
+			}
+			if indent == entriesIndent && !strings.HasPrefix(trimmed, "-") && !strings.HasPrefix(trimmed, "#") {
+				if ferr := flush(); ferr != nil {
+					return ferr
+				}
+			}
+			chunk.WriteString(line)
+		default:
+			header.WriteString(line)
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	h := &IndexFile{}
+	if err := yaml.UnmarshalStrict(header.Bytes(), h); err != nil {
+		return err
+	}
+	mergeIndexHeader(i, h, source)
+	return nil
+}
+
+// mergeIndexHeader copies everything but the streamed entries from h into i.
+func mergeIndexHeader(i, h *IndexFile, source string) {
+	i.ServerInfo = h.ServerInfo
+	i.APIVersion = h.APIVersion
+	i.Generated = h.Generated
+	i.PublicKeys = h.PublicKeys
+	i.Annotations = h.Annotations
+	if h.Entries != nil && i.Entries == nil {
+		i.Entries = map[string]ChartVersions{}
+	}
+	for name, cvs := range h.Entries {
+		i.Entries[name] = validateChartVersions(name, cvs, source)
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repo
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadIndexFileStream(t *testing.T) {
+	for _, filename := range []string{
+		testfile,
+		annotationstestfile,
+		chartmuseumtestfile,
+		unorderedTestfile,
+		jsonTestfile,
+	} {
+		t.Run(filename, func(t *testing.T) {
+			expected, err := LoadIndexFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			f, err := os.Open(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			got, err := LoadIndexFileStream(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(expected, got) {
+				t.Errorf("Streamed index differs from loaded index:\nexpected: %#v\ngot:      %#v", expected, got)
+			}
+		})
+	}
+}
+
+func TestLoadIndexFileStreamGenerated(t *testing.T) {
+	// An index as written by Helm, round-tripped through both loaders.
+	i, err := IndexDirectory("testdata/repository", "http://localhost:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	i.Annotations = map[string]string{"helm.sh/test": "multi\n\nline"}
+	i.SortEntries()
+
+	for name, write := range map[string]func(*bytes.Buffer) error{
+		"yaml": func(b *bytes.Buffer) error { return i.Write(b) },
+		"json": func(b *bytes.Buffer) error { return i.WriteJSON(b) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := write(&buf); err != nil {
+				t.Fatal(err)
+			}
+			expected, err := loadIndex(buf.Bytes(), "test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := loadIndexStream(bytes.NewReader(buf.Bytes()), "test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(expected, got) {
+				t.Errorf("Streamed index differs from loaded index:\nexpected: %#v\ngot:      %#v", expected, got)
+			}
+		})
+	}
+}
+
+func TestLoadIndexFileStreamErrors(t *testing.T) {
+	if _, err := LoadIndexFileStream(strings.NewReader("")); !errors.Is(err, ErrEmptyIndexYaml) {
+		t.Errorf("Expected ErrEmptyIndexYaml, got %v", err)
+	}
+	if _, err := LoadIndexFileStream(strings.NewReader(indexWithDuplicates)); err == nil {
+		t.Error("Expected an error when duplicate entries are present")
+	}
+	if _, err := LoadIndexFileStream(strings.NewReader("entries: {}\n")); !errors.Is(err, ErrNoAPIVersion) {
+		t.Errorf("Expected ErrNoAPIVersion, got %v", err)
+	}
+	if _, err := LoadIndexFileStream(strings.NewReader("apiVersion: v1\nunknown: field\n")); err == nil {
+		t.Error("Expected an error for unknown fields")
+	}
+
+	expected, err := loadIndex([]byte(indexWithEmptyEntry), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := loadIndexStream(strings.NewReader(indexWithEmptyEntry), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("Streamed index differs from loaded index:\nexpected: %#v\ngot:      %#v", expected, got)
+	}
+}
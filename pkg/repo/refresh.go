@@ -0,0 +1,70 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repo // import "helm.sh/helm/v4/pkg/repo"
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"helm.sh/helm/v4/pkg/helmpath"
+)
+
+// IndexStale returns whether the cached index of the repository is missing or
+// older than maxAge.
+func (r *ChartRepository) IndexStale(maxAge time.Duration) bool {
+	fi, err := os.Stat(filepath.Join(r.CachePath, helmpath.CacheIndexFile(r.Config.Name)))
+	if err != nil {
+		return true
+	}
+	return time.Since(fi.ModTime()) > maxAge
+}
+
+// RefreshStaleIndexes downloads again, concurrently, the index of each of the
+// repositories whose cached index is missing or older than maxAge, and returns
+// the repositories that were refreshed.
+//
+// A repository whose index fails to download keeps its cached index: a
+// warning is logged rather than the error returned, so that the operation
+// needing the index can go on.
+func RefreshStaleIndexes(repos []*ChartRepository, maxAge time.Duration) []*ChartRepository {
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		refreshed []*ChartRepository
+	)
+	for _, r := range repos {
+		if !r.IndexStale(maxAge) {
+			continue
+		}
+		wg.Add(1)
+		go func(r *ChartRepository) {
+			defer wg.Done()
+			if _, err := r.DownloadIndexFile(); err != nil {
+				slog.Warn("unable to refresh the stale index of the repository, using the cached index", "name", r.Config.Name, "url", r.Config.URL, slog.Any("error", err))
+				return
+			}
+			mu.Lock()
+			refreshed = append(refreshed, r)
+			mu.Unlock()
+		}(r)
+	}
+	wg.Wait()
+	return refreshed
+}
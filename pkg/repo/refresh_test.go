@@ -0,0 +1,110 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repo
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v4/pkg/getter"
+	"helm.sh/helm/v4/pkg/helmpath"
+)
+
+// refreshGetter serves empty indexes, except for the URLs containing "down".
+type refreshGetter struct {
+	mu      sync.Mutex
+	fetched []string
+}
+
+func (g *refreshGetter) Get(href string, _ ...getter.Option) (*bytes.Buffer, error) {
+	g.mu.Lock()
+	g.fetched = append(g.fetched, href)
+	g.mu.Unlock()
+	if strings.Contains(href, "down") {
+		return nil, errors.New("connection refused")
+	}
+	indexBytes, err := yaml.Marshal(&IndexFile{APIVersion: "v1", Generated: time.Now()})
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewBuffer(indexBytes), nil
+}
+
+func TestRefreshStaleIndexes(t *testing.T) {
+	g := &refreshGetter{}
+	providers := getter.Providers{{
+		Schemes: []string{"gs"},
+		New:     func(_ ...getter.Option) (getter.Getter, error) { return g, nil },
+	}}
+	cache := t.TempDir()
+	newRepo := func(name string) *ChartRepository {
+		r, err := NewChartRepository(&Entry{Name: name, URL: "gs://" + name}, providers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.CachePath = cache
+		return r
+	}
+	writeIndex := func(name string, age time.Duration) {
+		path := filepath.Join(cache, helmpath.CacheIndexFile(name))
+		if err := os.WriteFile(path, []byte("apiVersion: v1\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mtime := time.Now().Add(-age)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeIndex("fresh", time.Minute)
+	writeIndex("stale", 2*time.Hour)
+	writeIndex("down", 2*time.Hour)
+	repos := []*ChartRepository{newRepo("fresh"), newRepo("stale"), newRepo("missing"), newRepo("down")}
+
+	refreshed := RefreshStaleIndexes(repos, time.Hour)
+
+	var names []string
+	for _, r := range refreshed {
+		names = append(names, r.Config.Name)
+	}
+	if len(names) != 2 || !strings.Contains(strings.Join(names, " "), "stale") || !strings.Contains(strings.Join(names, " "), "missing") {
+		t.Errorf("expected the stale and missing repositories to be refreshed, got %v", names)
+	}
+	if len(g.fetched) != 3 {
+		t.Errorf("expected 3 index downloads, got %v", g.fetched)
+	}
+	for _, name := range []string{"stale", "missing"} {
+		if repos[0].IndexStale(time.Hour) || newRepo(name).IndexStale(time.Hour) {
+			t.Errorf("expected the index of %s to be fresh", name)
+		}
+	}
+	// The cached index of the repository that failed to refresh is kept.
+	if _, err := os.Stat(filepath.Join(cache, helmpath.CacheIndexFile("down"))); err != nil {
+		t.Error(err)
+	}
+	if !newRepo("down").IndexStale(time.Hour) {
+		t.Error("expected the index of down to be stale still")
+	}
+}
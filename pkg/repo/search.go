@@ -0,0 +1,205 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repo
+
+import (
+	"sort"
+	"strings"
+)
+
+// SearchField identifies the part of a chart that matched a search term.
+type SearchField string
+
+const (
+	// SearchFieldName indicates that the chart name matched.
+	SearchFieldName SearchField = "name"
+	// SearchFieldKeyword indicates that one of the chart keywords matched.
+	SearchFieldKeyword SearchField = "keyword"
+	// SearchFieldDescription indicates that the chart description matched.
+	SearchFieldDescription SearchField = "description"
+)
+
+// DefaultMaxDistance is the Levenshtein distance used for fuzzy matching when
+// SearchOptions.MaxDistance is not set.
+const DefaultMaxDistance = 2
+
+// SearchOptions controls how IndexFile.Search matches charts.
+type SearchOptions struct {
+	// CaseInsensitive ignores case when comparing the term with chart fields.
+	CaseInsensitive bool
+	// Fuzzy additionally matches names, keywords and description words
+	// that are within MaxDistance edits of the term.
+	Fuzzy bool
+	// MaxDistance is the largest Levenshtein distance accepted by fuzzy
+	// matching. If zero, DefaultMaxDistance is used.
+	MaxDistance int
+	// MaxResults caps the number of results returned. Zero means no limit.
+	MaxResults int
+	// AllVersions searches every version of each chart instead of only the
+	// first (by convention the newest) one.
+	AllVersions bool
+}
+
+// Result is a single IndexFile.Search match.
+type Result struct {
+	// Chart is the matching chart version.
+	Chart *ChartVersion
+	// Field is the field that produced the best match.
+	Field SearchField
+	// Match is the value of Field that matched, for example the keyword.
+	Match string
+	// Score ranks the result. Higher scores are better matches.
+	Score int
+}
+
+// Scores assigned to the kinds of match. Name matches outrank keyword matches,
+// which outrank description matches; within a field, exact matches outrank
+// prefix matches, which outrank substring matches, which outrank fuzzy ones.
+const (
+	scoreWeightName        = 300
+	scoreWeightKeyword     = 200
+	scoreWeightDescription = 100
+
+	scoreExact     = 30
+	scorePrefix    = 20
+	scoreSubstring = 10
+	scoreFuzzy     = 0
+)
+
+// Search finds charts whose name, keywords or description match term.
+//
+// Each chart version yields at most one result, for the field that matched
+// best. Results are ordered by descending score, then by chart name, then in
+// the order the versions appear in the index.
+func (i IndexFile) Search(term string, opts SearchOptions) []Result {
+	if opts.CaseInsensitive {
+		term = strings.ToLower(term)
+	}
+	maxDistance := opts.MaxDistance
+	if maxDistance == 0 {
+		maxDistance = DefaultMaxDistance
+	}
+
+	var results []Result
+	for _, cvs := range i.Entries {
+		for _, cv := range cvs {
+			if cv == nil || cv.Metadata == nil || cv.Removed {
+				continue
+			}
+			if res, ok := searchChartVersion(cv, term, opts, maxDistance); ok {
+				res.Chart = cv
+				results = append(results, res)
+			}
+			if !opts.AllVersions {
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(results, func(a, b int) bool {
+		if results[a].Score != results[b].Score {
+			return results[a].Score > results[b].Score
+		}
+		return results[a].Chart.Name < results[b].Chart.Name
+	})
+	if opts.MaxResults > 0 && len(results) > opts.MaxResults {
+		results = results[:opts.MaxResults]
+	}
+	return results
+}
+
+// searchChartVersion returns the best match of term against cv.
+func searchChartVersion(cv *ChartVersion, term string, opts SearchOptions, maxDistance int) (Result, bool) {
+	var best Result
+	found := false
+	consider := func(field SearchField, weight int, value string, words bool) {
+		if score, ok := matchScore(term, value, opts, maxDistance, words); ok {
+			if !found || weight+score > best.Score {
+				best = Result{Field: field, Match: value, Score: weight + score}
+				found = true
+			}
+		}
+	}
+
+	consider(SearchFieldName, scoreWeightName, cv.Name, false)
+	for _, kw := range cv.Keywords {
+		consider(SearchFieldKeyword, scoreWeightKeyword, kw, false)
+	}
+	consider(SearchFieldDescription, scoreWeightDescription, cv.Description, true)
+	return best, found
+}
+
+// matchScore scores how well term matches value.
+//
+// When words is true, fuzzy matching compares the term with each word of value
+// rather than with value as a whole.
+func matchScore(term, value string, opts SearchOptions, maxDistance int, words bool) (int, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if opts.CaseInsensitive {
+		value = strings.ToLower(value)
+	}
+
+	switch {
+	case value == term:
+		return scoreExact, true
+	case strings.HasPrefix(value, term):
+		return scorePrefix, true
+	case strings.Contains(value, term):
+		return scoreSubstring, true
+	case !opts.Fuzzy:
+		return 0, false
+	}
+
+	candidates := []string{value}
+	if words {
+		candidates = strings.Fields(value)
+	}
+	bestDistance := -1
+	for _, c := range candidates {
+		if d := levenshtein(term, c); d <= maxDistance && (bestDistance < 0 || d < bestDistance) {
+			bestDistance = d
+		}
+	}
+	if bestDistance < 0 {
+		return 0, false
+	}
+	return scoreFuzzy - bestDistance, true
+}
+
+// levenshtein returns the edit distance between a and b, counted in runes.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
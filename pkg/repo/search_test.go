@@ -0,0 +1,178 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repo
+
+import (
+	"testing"
+
+	chart "helm.sh/helm/v4/pkg/chart/v2"
+)
+
+func searchTestIndex() *IndexFile {
+	i := NewIndexFile()
+	for _, md := range []*chart.Metadata{
+		{Name: "nginx", Version: "0.2.0", Keywords: []string{"web server", "proxy"}, Description: "A fast web server"},
+		{Name: "nginx", Version: "0.1.0", Keywords: []string{"web server", "proxy"}, Description: "A fast web server"},
+		{Name: "nginx-ingress", Version: "1.0.0", Keywords: []string{"ingress"}, Description: "Ingress controller using nginx"},
+		{Name: "alpine", Version: "1.0.0", Keywords: []string{"linux"}, Description: "Deploy a basic Alpine Linux pod"},
+		{Name: "Postgres", Version: "2.0.0", Keywords: []string{"database", "sql"}, Description: "Relational database"},
+	} {
+		md.APIVersion = chart.APIVersionV2
+		i.MustAdd(md, md.Name+"-"+md.Version+".tgz", "https://example.com/charts", "")
+	}
+	i.SortEntries()
+	return i
+}
+
+func TestSearch(t *testing.T) {
+	i := searchTestIndex()
+
+	tests := []struct {
+		name     string
+		term     string
+		opts     SearchOptions
+		expected []string
+		field    SearchField
+	}{
+		{
+			name:     "exact name ranks above prefix",
+			term:     "nginx",
+			expected: []string{"nginx", "nginx-ingress"},
+			field:    SearchFieldName,
+		},
+		{
+			name:     "keyword",
+			term:     "sql",
+			expected: []string{"Postgres"},
+			field:    SearchFieldKeyword,
+		},
+		{
+			name:     "description",
+			term:     "Linux pod",
+			expected: []string{"alpine"},
+			field:    SearchFieldDescription,
+		},
+		{
+			name:     "case sensitive by default",
+			term:     "postgres",
+			expected: nil,
+		},
+		{
+			name:     "case insensitive",
+			term:     "postgres",
+			opts:     SearchOptions{CaseInsensitive: true},
+			expected: []string{"Postgres"},
+			field:    SearchFieldName,
+		},
+		{
+			name:     "no fuzzy match by default",
+			term:     "alpnie",
+			expected: nil,
+		},
+		{
+			name:     "fuzzy",
+			term:     "alpnie",
+			opts:     SearchOptions{Fuzzy: true},
+			expected: []string{"alpine"},
+			field:    SearchFieldName,
+		},
+		{
+			name:     "fuzzy description word",
+			term:     "relatonal",
+			opts:     SearchOptions{Fuzzy: true, CaseInsensitive: true},
+			expected: []string{"Postgres"},
+			field:    SearchFieldDescription,
+		},
+		{
+			name:     "fuzzy distance limit",
+			term:     "alpnie",
+			opts:     SearchOptions{Fuzzy: true, MaxDistance: 1},
+			expected: nil,
+		},
+		{
+			name:     "max results",
+			term:     "nginx",
+			opts:     SearchOptions{MaxResults: 1},
+			expected: []string{"nginx"},
+			field:    SearchFieldName,
+		},
+		{
+			name:     "all versions",
+			term:     "nginx",
+			opts:     SearchOptions{AllVersions: true},
+			expected: []string{"nginx", "nginx", "nginx-ingress"},
+			field:    SearchFieldName,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := i.Search(tt.term, tt.opts)
+			if len(results) != len(tt.expected) {
+				t.Fatalf("Expected %d results, got %d: %#v", len(tt.expected), len(results), results)
+			}
+			for n, name := range tt.expected {
+				if results[n].Chart.Name != name {
+					t.Errorf("Expected result %d to be %q, got %q", n, name, results[n].Chart.Name)
+				}
+			}
+			if len(results) > 0 && results[0].Field != tt.field {
+				t.Errorf("Expected best result to match on %q, got %q", tt.field, results[0].Field)
+			}
+		})
+	}
+}
+
+func TestSearchScoreAndMatch(t *testing.T) {
+	results := searchTestIndex().Search("proxy", SearchOptions{})
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+	if results[0].Match != "proxy" {
+		t.Errorf("Expected matched value %q, got %q", "proxy", results[0].Match)
+	}
+	if results[0].Chart.Version != "0.2.0" {
+		t.Errorf("Expected newest version, got %q", results[0].Chart.Version)
+	}
+
+	results = searchTestIndex().Search("nginx", SearchOptions{AllVersions: true})
+	if results[0].Chart.Version != "0.2.0" || results[1].Chart.Version != "0.1.0" {
+		t.Errorf("Expected versions in index order, got %q and %q", results[0].Chart.Version, results[1].Chart.Version)
+	}
+	if results[0].Score <= results[2].Score {
+		t.Errorf("Expected exact match to score higher than prefix match, got %d and %d", results[0].Score, results[2].Score)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	for _, tt := range []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"nginx", "nginx", 0},
+		{"héllo", "hello", 1},
+	} {
+		if got := levenshtein(tt.a, tt.b); got != tt.expected {
+			t.Errorf("levenshtein(%q, %q) = %d, expected %d", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
@@ -35,6 +35,8 @@ import (
 )
 
 var _ Driver = (*ConfigMaps)(nil)
+var _ Selector = (*ConfigMaps)(nil)
+var _ Versioner = (*ConfigMaps)(nil)
 
 // ConfigMapsDriverName is the string name of the driver.
 const ConfigMapsDriverName = "ConfigMap"
@@ -78,6 +80,7 @@ func (cfgmaps *ConfigMaps) Get(key string) (*rspb.Release, error) {
 		return nil, err
 	}
 	r.Labels = filterSystemLabels(obj.Labels)
+	r.Annotations = filterReleaseAnnotations(obj.Annotations)
 	// return the release object
 	return r, nil
 }
@@ -86,8 +89,14 @@ func (cfgmaps *ConfigMaps) Get(key string) (*rspb.Release, error) {
 // that filter(release) == true. An error is returned if the
 // configmap fails to retrieve the releases.
 func (cfgmaps *ConfigMaps) List(filter func(*rspb.Release) bool) ([]*rspb.Release, error) {
-	lsel := kblabels.Set{"owner": "helm"}.AsSelector()
-	opts := metav1.ListOptions{LabelSelector: lsel.String()}
+	return cfgmaps.ListSelector(kblabels.Everything(), filter)
+}
+
+// ListSelector fetches the releases whose configmap matches the label
+// selector, and returns those such that filter(release) == true. The
+// selection is made by the API server.
+func (cfgmaps *ConfigMaps) ListSelector(selector kblabels.Selector, filter func(*rspb.Release) bool) ([]*rspb.Release, error) {
+	opts := metav1.ListOptions{LabelSelector: withOwner(selector).String()}
 
 	list, err := cfgmaps.impl.List(context.Background(), opts)
 	if err != nil {
@@ -107,6 +116,7 @@ func (cfgmaps *ConfigMaps) List(filter func(*rspb.Release) bool) ([]*rspb.Releas
 		}
 
 		rls.Labels = item.Labels
+		rls.Annotations = filterReleaseAnnotations(item.Annotations)
 
 		if filter(rls) {
 			results = append(results, rls)
@@ -115,6 +125,24 @@ func (cfgmaps *ConfigMaps) List(filter func(*rspb.Release) bool) ([]*rspb.Releas
 	return results, nil
 }
 
+// LastVersions returns the last version of each release, read from the
+// labels of the configmaps rather than from the releases they hold.
+func (cfgmaps *ConfigMaps) LastVersions() (map[string]int, error) {
+	opts := metav1.ListOptions{LabelSelector: withOwner(kblabels.Everything()).String()}
+
+	list, err := cfgmaps.impl.List(context.Background(), opts)
+	if err != nil {
+		slog.Debug("failed to list releases", slog.Any("error", err))
+		return nil, err
+	}
+
+	versions := make(map[string]int, len(list.Items))
+	for _, item := range list.Items {
+		recordLastVersion(versions, item.Namespace, item.Labels)
+	}
+	return versions, nil
+}
+
 // Query fetches all releases that match the provided map of labels.
 // An error is returned if the configmap fails to retrieve the releases.
 func (cfgmaps *ConfigMaps) Query(labels map[string]string) ([]*rspb.Release, error) {
@@ -146,6 +174,7 @@ func (cfgmaps *ConfigMaps) Query(labels map[string]string) ([]*rspb.Release, err
 			continue
 		}
 		rls.Labels = item.Labels
+		rls.Annotations = filterReleaseAnnotations(item.Annotations)
 		results = append(results, rls)
 	}
 	return results, nil
@@ -254,8 +283,9 @@ func newConfigMapsObject(key string, rls *rspb.Release, lbs labels) (*v1.ConfigM
 	// create and return configmap object
 	return &v1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:   key,
-			Labels: lbs.toMap(),
+			Name:        key,
+			Labels:      lbs.toMap(),
+			Annotations: rls.Annotations,
 		},
 		Data: map[string]string{"release": s},
 	}, nil
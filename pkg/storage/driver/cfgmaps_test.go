@@ -141,6 +141,24 @@ func TestConfigMapList(t *testing.T) {
 	}
 }
 
+func TestConfigMapLastVersions(t *testing.T) {
+	driver := newTestFixtureCfgMaps(t, []*rspb.Release{
+		releaseStub("key-1", 1, "default", rspb.StatusSuperseded),
+		releaseStub("key-1", 2, "default", rspb.StatusDeployed),
+		releaseStub("key-2", 1, "default", rspb.StatusDeployed),
+	}...)
+
+	versions, err := driver.LastVersions()
+	if err != nil {
+		t.Fatalf("Failed to list the last versions: %s", err)
+	}
+	// The mocked objects have no namespace.
+	expect := map[string]int{"key-1": 2, "key-2": 1}
+	if !reflect.DeepEqual(versions, expect) {
+		t.Errorf("Expected last versions %v, got %v", expect, versions)
+	}
+}
+
 func TestConfigMapQuery(t *testing.T) {
 	cfgmaps := newTestFixtureCfgMaps(t, []*rspb.Release{
 		releaseStub("key-1", 1, "default", rspb.StatusUninstalled),
@@ -20,6 +20,8 @@ import (
 	"errors"
 	"fmt"
 
+	kblabels "k8s.io/apimachinery/pkg/labels"
+
 	rspb "helm.sh/helm/v4/pkg/release/v1"
 )
 
@@ -30,6 +32,8 @@ var (
 	ErrReleaseExists = errors.New("release: already exists")
 	// ErrInvalidKey indicates that a release key could not be parsed.
 	ErrInvalidKey = errors.New("release: invalid key")
+	// ErrReleaseTooLarge indicates that a release is too large for the storage backend.
+	ErrReleaseTooLarge = errors.New("release: too large")
 	// ErrNoDeployedReleases indicates that there are no releases with the given key in the deployed state
 	ErrNoDeployedReleases = errors.New("has no deployed releases")
 )
@@ -91,6 +95,40 @@ type Queryor interface {
 	Query(labels map[string]string) ([]*rspb.Release, error)
 }
 
+// Pager is the interface that wraps the QueryPage method. Drivers implement it
+// when they can paginate queries more efficiently than by loading every
+// matching release.
+//
+// QueryPage returns the releases matching the provided label set sorted by
+// descending version. The first offset releases are skipped and at most limit
+// releases are returned; a limit of 0 or less returns all remaining releases.
+// The total number of matching releases is returned as well, or
+// ErrReleaseNotFound if there are none.
+type Pager interface {
+	QueryPage(labels map[string]string, offset, limit int) ([]*rspb.Release, int, error)
+}
+
+// Selector is the interface that wraps the ListSelector method. Drivers
+// implement it when they can select releases by their labels in the backend,
+// rather than loading every release.
+//
+// ListSelector returns the set of all releases matching the label selector
+// that satisfy the filter predicate. The selector matches both the labels set
+// by Helm and the custom labels of the releases.
+type Selector interface {
+	ListSelector(selector kblabels.Selector, filter func(*rspb.Release) bool) ([]*rspb.Release, error)
+}
+
+// Versioner is the interface that wraps the LastVersions method. Drivers
+// implement it when they can read the versions of the releases from their
+// labels, rather than decoding every release.
+//
+// LastVersions returns the last version of each release, keyed by the
+// namespace and the name of the release joined by a slash.
+type Versioner interface {
+	LastVersions() (map[string]int, error)
+}
+
 // Driver is the interface composed of Creator, Updator, Deletor, and Queryor
 // interfaces. It defines the behavior for storing, updating, deleted,
 // and retrieving Helm releases from some underlying storage mechanism,
@@ -17,6 +17,7 @@ limitations under the License.
 package driver // import "helm.sh/helm/v4/pkg/storage/driver"
 
 import (
+	"compress/gzip"
 	"context"
 	"fmt"
 	"testing"
@@ -194,7 +195,7 @@ func (mock *MockSecretsInterface) Init(t *testing.T, releases ...*rspb.Release)
 	for _, rls := range releases {
 		objkey := testKey(rls.Name, rls.Version)
 
-		secret, err := newSecretsObject(objkey, rls, nil)
+		secret, err := newSecretsObject(objkey, rls, nil, gzip.BestCompression)
 		if err != nil {
 			t.Fatalf("Failed to create secret: %s", err)
 		}
@@ -17,6 +17,7 @@ limitations under the License.
 package driver // import "helm.sh/helm/v4/pkg/storage/driver"
 
 import (
+	"compress/gzip"
 	"context"
 	"fmt"
 	"log/slog"
@@ -35,24 +36,43 @@ import (
 )
 
 var _ Driver = (*Secrets)(nil)
+var _ Selector = (*Secrets)(nil)
+var _ Versioner = (*Secrets)(nil)
 
 // SecretsDriverName is the string name of the driver.
 const SecretsDriverName = "Secret"
 
+// secretDataSizeLimit is the maximum size of the data of a Secret accepted by
+// the Kubernetes API server.
+const secretDataSizeLimit = 1024 * 1024
+
 // Secrets is a wrapper around an implementation of a kubernetes
 // SecretsInterface.
 type Secrets struct {
-	impl corev1.SecretInterface
+	impl             corev1.SecretInterface
+	compressionLevel int
 }
 
 // NewSecrets initializes a new Secrets wrapping an implementation of
 // the kubernetes SecretsInterface.
 func NewSecrets(impl corev1.SecretInterface) *Secrets {
 	return &Secrets{
-		impl: impl,
+		impl:             impl,
+		compressionLevel: gzip.BestCompression,
 	}
 }
 
+// SetCompressionLevel sets the gzip compression level used to encode
+// releases, from gzip.HuffmanOnly to gzip.BestCompression. It defaults to
+// gzip.BestCompression.
+func (secrets *Secrets) SetCompressionLevel(level int) error {
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		return fmt.Errorf("invalid compression level %d: must be between %d and %d", level, gzip.HuffmanOnly, gzip.BestCompression)
+	}
+	secrets.compressionLevel = level
+	return nil
+}
+
 // Name returns the name of the driver.
 func (secrets *Secrets) Name() string {
 	return SecretsDriverName
@@ -75,6 +95,7 @@ func (secrets *Secrets) Get(key string) (*rspb.Release, error) {
 		return r, fmt.Errorf("get: failed to decode data %q: %w", key, err)
 	}
 	r.Labels = filterSystemLabels(obj.Labels)
+	r.Annotations = filterReleaseAnnotations(obj.Annotations)
 	return r, nil
 }
 
@@ -82,8 +103,14 @@ func (secrets *Secrets) Get(key string) (*rspb.Release, error) {
 // that filter(release) == true. An error is returned if the
 // secret fails to retrieve the releases.
 func (secrets *Secrets) List(filter func(*rspb.Release) bool) ([]*rspb.Release, error) {
-	lsel := kblabels.Set{"owner": "helm"}.AsSelector()
-	opts := metav1.ListOptions{LabelSelector: lsel.String()}
+	return secrets.ListSelector(kblabels.Everything(), filter)
+}
+
+// ListSelector fetches the releases whose secret matches the label selector,
+// and returns those such that filter(release) == true. The selection is made
+// by the API server.
+func (secrets *Secrets) ListSelector(selector kblabels.Selector, filter func(*rspb.Release) bool) ([]*rspb.Release, error) {
+	opts := metav1.ListOptions{LabelSelector: withOwner(selector).String()}
 
 	list, err := secrets.impl.List(context.Background(), opts)
 	if err != nil {
@@ -102,6 +129,7 @@ func (secrets *Secrets) List(filter func(*rspb.Release) bool) ([]*rspb.Release,
 		}
 
 		rls.Labels = item.Labels
+		rls.Annotations = filterReleaseAnnotations(item.Annotations)
 
 		if filter(rls) {
 			results = append(results, rls)
@@ -110,6 +138,23 @@ func (secrets *Secrets) List(filter func(*rspb.Release) bool) ([]*rspb.Release,
 	return results, nil
 }
 
+// LastVersions returns the last version of each release, read from the
+// labels of the secrets rather than from the releases they hold.
+func (secrets *Secrets) LastVersions() (map[string]int, error) {
+	opts := metav1.ListOptions{LabelSelector: withOwner(kblabels.Everything()).String()}
+
+	list, err := secrets.impl.List(context.Background(), opts)
+	if err != nil {
+		return nil, fmt.Errorf("list: failed to list: %w", err)
+	}
+
+	versions := make(map[string]int, len(list.Items))
+	for _, item := range list.Items {
+		recordLastVersion(versions, item.Namespace, item.Labels)
+	}
+	return versions, nil
+}
+
 // Query fetches all releases that match the provided map of labels.
 // An error is returned if the secret fails to retrieve the releases.
 func (secrets *Secrets) Query(labels map[string]string) ([]*rspb.Release, error) {
@@ -140,6 +185,7 @@ func (secrets *Secrets) Query(labels map[string]string) ([]*rspb.Release, error)
 			continue
 		}
 		rls.Labels = item.Labels
+		rls.Annotations = filterReleaseAnnotations(item.Annotations)
 		results = append(results, rls)
 	}
 	return results, nil
@@ -156,7 +202,7 @@ func (secrets *Secrets) Create(key string, rls *rspb.Release) error {
 	lbs.set("createdAt", fmt.Sprintf("%v", time.Now().Unix()))
 
 	// create a new secret to hold the release
-	obj, err := newSecretsObject(key, rls, lbs)
+	obj, err := newSecretsObject(key, rls, lbs, secrets.compressionLevel)
 	if err != nil {
 		return fmt.Errorf("create: failed to encode release %q: %w", rls.Name, err)
 	}
@@ -182,7 +228,7 @@ func (secrets *Secrets) Update(key string, rls *rspb.Release) error {
 	lbs.set("modifiedAt", fmt.Sprintf("%v", time.Now().Unix()))
 
 	// create a new secret object to hold the release
-	obj, err := newSecretsObject(key, rls, lbs)
+	obj, err := newSecretsObject(key, rls, lbs, secrets.compressionLevel)
 	if err != nil {
 		return fmt.Errorf("update: failed to encode release %q: %w", rls.Name, err)
 	}
@@ -210,7 +256,9 @@ func (secrets *Secrets) Delete(key string) (rls *rspb.Release, err error) {
 
 // newSecretsObject constructs a kubernetes Secret object
 // to store a release. Each secret data entry is the base64
-// encoded gzipped string of a release.
+// encoded gzipped string of a release, compressed with the
+// given gzip level. ErrReleaseTooLarge is returned if the
+// encoded release does not fit into a Secret.
 //
 // The following labels are used within each secret:
 //
@@ -220,14 +268,19 @@ func (secrets *Secrets) Delete(key string) (rls *rspb.Release, err error) {
 //	"status"         - status of the release (see pkg/release/status.go for variants)
 //	"owner"          - owner of the secret, currently "helm".
 //	"name"           - name of the release.
-func newSecretsObject(key string, rls *rspb.Release, lbs labels) (*v1.Secret, error) {
+func newSecretsObject(key string, rls *rspb.Release, lbs labels, level int) (*v1.Secret, error) {
 	const owner = "helm"
 
 	// encode the release
-	s, err := encodeRelease(rls)
+	s, err := encodeReleaseLevel(rls, level)
 	if err != nil {
 		return nil, err
 	}
+	if len(s) > secretDataSizeLimit {
+		return nil, fmt.Errorf("%w: the compressed release is %d bytes but a Secret holds at most %d bytes. "+
+			"Reduce the size of the chart or store releases with the SQL storage driver (HELM_DRIVER=sql)",
+			ErrReleaseTooLarge, len(s), secretDataSizeLimit)
+	}
 
 	if lbs == nil {
 		lbs.init()
@@ -255,8 +308,9 @@ func newSecretsObject(key string, rls *rspb.Release, lbs labels) (*v1.Secret, er
 	// and should only happen between major versions.
 	return &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:   key,
-			Labels: lbs.toMap(),
+			Name:        key,
+			Labels:      lbs.toMap(),
+			Annotations: rls.Annotations,
 		},
 		Type: "helm.sh/release.v1",
 		Data: map[string][]byte{"release": []byte(s)},
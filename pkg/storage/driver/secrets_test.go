@@ -14,13 +14,19 @@ limitations under the License.
 package driver
 
 import (
+	"compress/gzip"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math/rand/v2"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
 	v1 "k8s.io/api/core/v1"
+	kblabels "k8s.io/apimachinery/pkg/labels"
 
 	rspb "helm.sh/helm/v4/pkg/release/v1"
 )
@@ -52,6 +58,40 @@ func TestSecretGet(t *testing.T) {
 	}
 }
 
+func TestSecretAnnotations(t *testing.T) {
+	vers := 1
+	name := "smug-pigeon"
+	namespace := "default"
+	key := testKey(name, vers)
+	rel := releaseStub(name, vers, namespace, rspb.StatusDeployed)
+	rel.Annotations = map[string]string{"helm.sh/team": "payments"}
+
+	// The annotations added to the secret by other tools are not part of the
+	// release.
+	stored := *rel
+	stored.Annotations = map[string]string{
+		"helm.sh/team": "payments",
+		"kubectl.kubernetes.io/last-applied-configuration": "{}",
+	}
+	secrets := newTestFixtureSecrets(t, &stored)
+
+	got, err := secrets.Get(key)
+	if err != nil {
+		t.Fatalf("Failed to get release: %s", err)
+	}
+	if !reflect.DeepEqual(rel.Annotations, got.Annotations) {
+		t.Errorf("Expected annotations %v, got %v", rel.Annotations, got.Annotations)
+	}
+
+	rls, err := secrets.List(func(*rspb.Release) bool { return true })
+	if err != nil {
+		t.Fatalf("Failed to list releases: %s", err)
+	}
+	if len(rls) != 1 || !reflect.DeepEqual(rel.Annotations, rls[0].Annotations) {
+		t.Errorf("Expected annotations %v in the listed releases, got %v", rel.Annotations, rls)
+	}
+}
+
 func TestUNcompressedSecretGet(t *testing.T) {
 	vers := 1
 	name := "smug-pigeon"
@@ -60,7 +100,7 @@ func TestUNcompressedSecretGet(t *testing.T) {
 	rel := releaseStub(name, vers, namespace, rspb.StatusDeployed)
 
 	// Create a test fixture which contains an uncompressed release
-	secret, err := newSecretsObject(key, rel, nil)
+	secret, err := newSecretsObject(key, rel, nil, gzip.BestCompression)
 	if err != nil {
 		t.Fatalf("Failed to create secret: %s", err)
 	}
@@ -141,6 +181,56 @@ func TestSecretList(t *testing.T) {
 	}
 }
 
+func TestSecretListSelector(t *testing.T) {
+	team := releaseStub("key-2", 1, "default", rspb.StatusDeployed)
+	team.Labels["team"] = "payments"
+	secrets := newTestFixtureSecrets(t, []*rspb.Release{
+		releaseStub("key-1", 1, "default", rspb.StatusDeployed),
+		team,
+		releaseStub("key-3", 1, "default", rspb.StatusUninstalled),
+	}...)
+
+	all := func(*rspb.Release) bool { return true }
+	rls, err := secrets.ListSelector(kblabels.SelectorFromSet(kblabels.Set{"team": "payments"}), all)
+	if err != nil {
+		t.Fatalf("Failed to list: %s", err)
+	}
+	if len(rls) != 1 || rls[0].Name != "key-2" {
+		t.Errorf("Expected only key-2 to be selected, got %v", rls)
+	}
+
+	// The system labels are selectable as well.
+	selector, err := kblabels.Parse("status!=uninstalled")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rls, err = secrets.ListSelector(selector, all)
+	if err != nil {
+		t.Fatalf("Failed to list: %s", err)
+	}
+	if len(rls) != 2 {
+		t.Errorf("Expected 2 selected releases, got %d", len(rls))
+	}
+}
+
+func TestSecretLastVersions(t *testing.T) {
+	driver := newTestFixtureSecrets(t, []*rspb.Release{
+		releaseStub("key-1", 1, "default", rspb.StatusSuperseded),
+		releaseStub("key-1", 2, "default", rspb.StatusDeployed),
+		releaseStub("key-2", 1, "default", rspb.StatusDeployed),
+	}...)
+
+	versions, err := driver.LastVersions()
+	if err != nil {
+		t.Fatalf("Failed to list the last versions: %s", err)
+	}
+	// The mocked objects have no namespace.
+	expect := map[string]int{"key-1": 2, "key-2": 1}
+	if !reflect.DeepEqual(versions, expect) {
+		t.Errorf("Expected last versions %v, got %v", expect, versions)
+	}
+}
+
 func TestSecretQuery(t *testing.T) {
 	secrets := newTestFixtureSecrets(t, []*rspb.Release{
 		releaseStub("key-1", 1, "default", rspb.StatusUninstalled),
@@ -191,6 +281,92 @@ func TestSecretCreate(t *testing.T) {
 	}
 }
 
+// largeManifest returns a manifest of about size bytes made of many similar
+// ConfigMaps, the way a chart with many templates compresses.
+func largeManifest(size int) string {
+	var b strings.Builder
+	for i := 0; b.Len() < size; i++ {
+		fmt.Fprintf(&b, "---\n# Source: big/templates/cm-%d.yaml\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm-%d\ndata:\n  index: %q\n  value: %q\n",
+			i, i, strconv.Itoa(i), strings.Repeat("abcdefgh", i%32))
+	}
+	return b.String()
+}
+
+func TestSecretCreateLargeRelease(t *testing.T) {
+	secrets := newTestFixtureSecrets(t)
+
+	key := testKey("big", 1)
+	rel := releaseStub("big", 1, "default", rspb.StatusDeployed)
+	rel.Manifest = largeManifest(4 * 1024 * 1024)
+
+	if err := secrets.Create(key, rel); err != nil {
+		t.Fatalf("Failed to create large release: %s", err)
+	}
+	got, err := secrets.Get(key)
+	if err != nil {
+		t.Fatalf("Failed to get large release: %s", err)
+	}
+	if got.Manifest != rel.Manifest {
+		t.Errorf("Expected the manifest to survive the round trip")
+	}
+
+	// Without compression the same release does not fit.
+	if err := secrets.SetCompressionLevel(gzip.NoCompression); err != nil {
+		t.Fatal(err)
+	}
+	err = secrets.Update(key, rel)
+	if !errors.Is(err, ErrReleaseTooLarge) {
+		t.Fatalf("Expected %v, got %v", ErrReleaseTooLarge, err)
+	}
+	if !strings.Contains(err.Error(), "HELM_DRIVER=sql") {
+		t.Errorf("Expected the error to suggest the SQL driver, got %q", err)
+	}
+}
+
+func TestSecretIncompressibleRelease(t *testing.T) {
+	secrets := newTestFixtureSecrets(t)
+
+	data := make([]byte, 2*1024*1024)
+	rand.NewChaCha8([32]byte{}).Read(data)
+	rel := releaseStub("random", 1, "default", rspb.StatusDeployed)
+	rel.Manifest = base64.StdEncoding.EncodeToString(data)
+
+	err := secrets.Create(testKey("random", 1), rel)
+	if !errors.Is(err, ErrReleaseTooLarge) {
+		t.Fatalf("Expected %v, got %v", ErrReleaseTooLarge, err)
+	}
+}
+
+func TestSecretSetCompressionLevel(t *testing.T) {
+	secrets := newTestFixtureSecrets(t)
+	for _, level := range []int{gzip.HuffmanOnly, gzip.NoCompression, gzip.BestSpeed, gzip.BestCompression} {
+		if err := secrets.SetCompressionLevel(level); err != nil {
+			t.Errorf("Expected level %d to be accepted, got %v", level, err)
+		}
+	}
+	for _, level := range []int{-3, 10} {
+		if err := secrets.SetCompressionLevel(level); err == nil {
+			t.Errorf("Expected level %d to be rejected", level)
+		}
+	}
+
+	// Releases stored with any level can be read back.
+	if err := secrets.SetCompressionLevel(gzip.BestSpeed); err != nil {
+		t.Fatal(err)
+	}
+	rel := releaseStub("fast", 1, "default", rspb.StatusDeployed)
+	if err := secrets.Create(testKey("fast", 1), rel); err != nil {
+		t.Fatal(err)
+	}
+	got, err := secrets.Get(testKey("fast", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rel, got) {
+		t.Errorf("Expected {%v}, got {%v}", rel, got)
+	}
+}
+
 func TestSecretUpdate(t *testing.T) {
 	vers := 1
 	name := "smug-pigeon"
@@ -17,9 +17,11 @@ limitations under the License.
 package driver // import "helm.sh/helm/v4/pkg/storage/driver"
 
 import (
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"maps"
+	"path"
 	"sort"
 	"strconv"
 	"time"
@@ -36,6 +38,8 @@ import (
 )
 
 var _ Driver = (*SQL)(nil)
+var _ Pager = (*SQL)(nil)
+var _ Versioner = (*SQL)(nil)
 
 var labelMap = map[string]struct{}{
 	"modifiedAt": {},
@@ -55,16 +59,17 @@ const sqlReleaseTableName = "releases_v1"
 const sqlCustomLabelsTableName = "custom_labels_v1"
 
 const (
-	sqlReleaseTableKeyColumn        = "key"
-	sqlReleaseTableTypeColumn       = "type"
-	sqlReleaseTableBodyColumn       = "body"
-	sqlReleaseTableNameColumn       = "name"
-	sqlReleaseTableNamespaceColumn  = "namespace"
-	sqlReleaseTableVersionColumn    = "version"
-	sqlReleaseTableStatusColumn     = "status"
-	sqlReleaseTableOwnerColumn      = "owner"
-	sqlReleaseTableCreatedAtColumn  = "createdAt"
-	sqlReleaseTableModifiedAtColumn = "modifiedAt"
+	sqlReleaseTableKeyColumn         = "key"
+	sqlReleaseTableTypeColumn        = "type"
+	sqlReleaseTableBodyColumn        = "body"
+	sqlReleaseTableNameColumn        = "name"
+	sqlReleaseTableNamespaceColumn   = "namespace"
+	sqlReleaseTableVersionColumn     = "version"
+	sqlReleaseTableStatusColumn      = "status"
+	sqlReleaseTableOwnerColumn       = "owner"
+	sqlReleaseTableCreatedAtColumn   = "createdAt"
+	sqlReleaseTableModifiedAtColumn  = "modifiedAt"
+	sqlReleaseTableAnnotationsColumn = "annotations"
 
 	sqlCustomLabelsTableReleaseKeyColumn       = "releaseKey"
 	sqlCustomLabelsTableReleaseNamespaceColumn = "releaseNamespace"
@@ -83,7 +88,11 @@ const (
 	sqlReleaseDefaultType  = "helm.sh/release.v1"
 )
 
-// SQL is the sql storage driver implementation.
+// SQL is the sql storage driver implementation. Releases are stored in a
+// PostgreSQL table keyed by release name and version, with the name,
+// namespace, version, status and owner labels in indexed columns so that
+// List and Query are answered by the database. The tables are created on
+// first use.
 type SQL struct {
 	db               *sqlx.DB
 	namespace        string
@@ -232,6 +241,21 @@ func (s *SQL) ensureDBSetup() error {
 					`, sqlCustomLabelsTableName),
 				},
 			},
+			{
+				// Migrations are applied in the order of their ids, so this
+				// one must sort after "init".
+				Id: "release_annotations",
+				Up: []string{
+					fmt.Sprintf(`
+						ALTER TABLE %s ADD COLUMN %s TEXT NOT NULL DEFAULT '';
+					`, sqlReleaseTableName, sqlReleaseTableAnnotationsColumn),
+				},
+				Down: []string{
+					fmt.Sprintf(`
+						ALTER TABLE %s DROP COLUMN %s;
+					`, sqlReleaseTableName, sqlReleaseTableAnnotationsColumn),
+				},
+			},
 		},
 	}
 
@@ -266,6 +290,10 @@ type SQLReleaseWrapper struct {
 	Owner      string `db:"owner"`
 	CreatedAt  int    `db:"createdAt"`
 	ModifiedAt int    `db:"modifiedAt"`
+
+	// The annotations of the release, as a JSON object, or empty if there
+	// are none.
+	Annotations string `db:"annotations"`
 }
 
 type SQLReleaseCustomLabelWrapper struct {
@@ -275,7 +303,8 @@ type SQLReleaseCustomLabelWrapper struct {
 	Value            string `db:"value"`
 }
 
-// NewSQL initializes a new sql driver.
+// NewSQL initializes a new sql driver connected to the PostgreSQL database
+// identified by connectionString, creating its tables if they are absent.
 func NewSQL(connectionString string, namespace string) (*SQL, error) {
 	db, err := sqlx.Connect(postgreSQLDialect, connectionString)
 	if err != nil {
@@ -301,7 +330,7 @@ func (s *SQL) Get(key string) (*rspb.Release, error) {
 	var record SQLReleaseWrapper
 
 	qb := s.statementBuilder.
-		Select(sqlReleaseTableBodyColumn).
+		Select(sqlReleaseTableBodyColumn, sqlReleaseTableAnnotationsColumn).
 		From(sqlReleaseTableName).
 		Where(sq.Eq{sqlReleaseTableKeyColumn: key}).
 		Where(sq.Eq{sqlReleaseTableNamespaceColumn: s.namespace})
@@ -324,6 +353,11 @@ func (s *SQL) Get(key string) (*rspb.Release, error) {
 		return nil, err
 	}
 
+	if release.Annotations, err = decodeAnnotations(record.Annotations); err != nil {
+		slog.Debug("failed to decode annotations", "key", key, slog.Any("error", err))
+		return nil, err
+	}
+
 	if release.Labels, err = s.getReleaseCustomLabels(key, s.namespace); err != nil {
 		slog.Debug("failed to get release custom labels", "namespace", s.namespace, "key", key, slog.Any("error", err))
 		return nil, err
@@ -335,7 +369,7 @@ func (s *SQL) Get(key string) (*rspb.Release, error) {
 // List returns the list of all releases such that filter(release) == true
 func (s *SQL) List(filter func(*rspb.Release) bool) ([]*rspb.Release, error) {
 	sb := s.statementBuilder.
-		Select(sqlReleaseTableKeyColumn, sqlReleaseTableNamespaceColumn, sqlReleaseTableBodyColumn).
+		Select(sqlReleaseTableKeyColumn, sqlReleaseTableNamespaceColumn, sqlReleaseTableBodyColumn, sqlReleaseTableAnnotationsColumn).
 		From(sqlReleaseTableName).
 		Where(sq.Eq{sqlReleaseTableOwnerColumn: sqlReleaseDefaultOwner})
 
@@ -363,6 +397,10 @@ func (s *SQL) List(filter func(*rspb.Release) bool) ([]*rspb.Release, error) {
 			slog.Debug("failed to decode release", "record", record, slog.Any("error", err))
 			continue
 		}
+		if release.Annotations, err = decodeAnnotations(record.Annotations); err != nil {
+			slog.Debug("failed to decode annotations", "record", record, slog.Any("error", err))
+			continue
+		}
 
 		if release.Labels, err = s.getReleaseCustomLabels(record.Key, record.Namespace); err != nil {
 			slog.Debug("failed to get release custom labels", "namespace", record.Namespace, "key", record.Key, slog.Any("error", err))
@@ -378,30 +416,47 @@ func (s *SQL) List(filter func(*rspb.Release) bool) ([]*rspb.Release, error) {
 	return releases, nil
 }
 
-// Query returns the set of releases that match the provided set of labels.
-func (s *SQL) Query(labels map[string]string) ([]*rspb.Release, error) {
+// LastVersions returns the last version of each release, read from the
+// version column rather than from the release bodies.
+func (s *SQL) LastVersions() (map[string]int, error) {
 	sb := s.statementBuilder.
-		Select(sqlReleaseTableKeyColumn, sqlReleaseTableNamespaceColumn, sqlReleaseTableBodyColumn).
-		From(sqlReleaseTableName)
-
-	keys := make([]string, 0, len(labels))
-	for key := range labels {
-		keys = append(keys, key)
-	}
-	sort.Strings(keys)
-	for _, key := range keys {
-		if _, ok := labelMap[key]; ok {
-			sb = sb.Where(sq.Eq{key: labels[key]})
-		} else {
-			slog.Debug("unknown label", "key", key)
-			return nil, fmt.Errorf("unknown label %s", key)
-		}
-	}
+		Select(sqlReleaseTableNamespaceColumn, sqlReleaseTableNameColumn, "MAX("+sqlReleaseTableVersionColumn+") AS "+sqlReleaseTableVersionColumn).
+		From(sqlReleaseTableName).
+		Where(sq.Eq{sqlReleaseTableOwnerColumn: sqlReleaseDefaultOwner})
 
 	// If a namespace was specified, we only list releases from that namespace
 	if s.namespace != "" {
 		sb = sb.Where(sq.Eq{sqlReleaseTableNamespaceColumn: s.namespace})
 	}
+	sb = sb.GroupBy(sqlReleaseTableNamespaceColumn, sqlReleaseTableNameColumn)
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		slog.Debug("failed to build query", slog.Any("error", err))
+		return nil, err
+	}
+
+	var records = []SQLReleaseWrapper{}
+	if err := s.db.Select(&records, query, args...); err != nil {
+		slog.Debug("failed to list the last versions", slog.Any("error", err))
+		return nil, err
+	}
+
+	versions := make(map[string]int, len(records))
+	for _, record := range records {
+		versions[path.Join(record.Namespace, record.Name)] = record.Version
+	}
+	return versions, nil
+}
+
+// Query returns the set of releases that match the provided set of labels.
+func (s *SQL) Query(labels map[string]string) ([]*rspb.Release, error) {
+	sb, err := s.whereLabels(s.statementBuilder.
+		Select(sqlReleaseTableKeyColumn, sqlReleaseTableNamespaceColumn, sqlReleaseTableBodyColumn, sqlReleaseTableAnnotationsColumn).
+		From(sqlReleaseTableName), labels)
+	if err != nil {
+		return nil, err
+	}
 
 	// Build our query
 	query, args, err := sb.ToSql()
@@ -420,6 +475,98 @@ func (s *SQL) Query(labels map[string]string) ([]*rspb.Release, error) {
 		return nil, ErrReleaseNotFound
 	}
 
+	releases, err := s.decodeRecords(records)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(releases) == 0 {
+		return nil, ErrReleaseNotFound
+	}
+
+	return releases, nil
+}
+
+// QueryPage returns a page of the releases matching labels, newest version
+// first. The pagination is done by the database.
+func (s *SQL) QueryPage(labels map[string]string, offset, limit int) ([]*rspb.Release, int, error) {
+	countBuilder, err := s.whereLabels(s.statementBuilder.Select("COUNT(*)").From(sqlReleaseTableName), labels)
+	if err != nil {
+		return nil, 0, err
+	}
+	countQuery, args, err := countBuilder.ToSql()
+	if err != nil {
+		slog.Debug("failed to build count query", slog.Any("error", err))
+		return nil, 0, err
+	}
+	var total int
+	if err := s.db.Get(&total, countQuery, args...); err != nil {
+		slog.Debug("failed to count releases with labels", slog.Any("error", err))
+		return nil, 0, err
+	}
+	if total == 0 {
+		return nil, 0, ErrReleaseNotFound
+	}
+
+	sb, err := s.whereLabels(s.statementBuilder.
+		Select(sqlReleaseTableKeyColumn, sqlReleaseTableNamespaceColumn, sqlReleaseTableBodyColumn, sqlReleaseTableAnnotationsColumn).
+		From(sqlReleaseTableName), labels)
+	if err != nil {
+		return nil, 0, err
+	}
+	sb = sb.OrderBy(sqlReleaseTableVersionColumn + " DESC")
+	if offset > 0 {
+		sb = sb.Offset(uint64(offset))
+	}
+	if limit > 0 {
+		sb = sb.Limit(uint64(limit))
+	}
+	query, args, err := sb.ToSql()
+	if err != nil {
+		slog.Debug("failed to build query", slog.Any("error", err))
+		return nil, 0, err
+	}
+
+	var records = []SQLReleaseWrapper{}
+	if err := s.db.Select(&records, query, args...); err != nil {
+		slog.Debug("failed to query with labels", slog.Any("error", err))
+		return nil, 0, err
+	}
+
+	releases, err := s.decodeRecords(records)
+	if err != nil {
+		return nil, 0, err
+	}
+	return releases, total, nil
+}
+
+// whereLabels restricts sb to the releases matching labels in the namespace
+// of the driver.
+func (s *SQL) whereLabels(sb sq.SelectBuilder, labels map[string]string) (sq.SelectBuilder, error) {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, ok := labelMap[key]; ok {
+			sb = sb.Where(sq.Eq{key: labels[key]})
+		} else {
+			slog.Debug("unknown label", "key", key)
+			return sb, fmt.Errorf("unknown label %s", key)
+		}
+	}
+
+	// If a namespace was specified, we only list releases from that namespace
+	if s.namespace != "" {
+		sb = sb.Where(sq.Eq{sqlReleaseTableNamespaceColumn: s.namespace})
+	}
+	return sb, nil
+}
+
+// decodeRecords decodes the releases stored in records along with their
+// custom labels. Records that cannot be decoded are skipped.
+func (s *SQL) decodeRecords(records []SQLReleaseWrapper) ([]*rspb.Release, error) {
 	var releases []*rspb.Release
 	for _, record := range records {
 		release, err := decodeRelease(record.Body)
@@ -427,6 +574,10 @@ func (s *SQL) Query(labels map[string]string) ([]*rspb.Release, error) {
 			slog.Debug("failed to decode release", "record", record, slog.Any("error", err))
 			continue
 		}
+		if release.Annotations, err = decodeAnnotations(record.Annotations); err != nil {
+			slog.Debug("failed to decode annotations", "record", record, slog.Any("error", err))
+			continue
+		}
 
 		if release.Labels, err = s.getReleaseCustomLabels(record.Key, record.Namespace); err != nil {
 			slog.Debug("failed to get release custom labels", "namespace", record.Namespace, "key", record.Key, slog.Any("error", err))
@@ -435,11 +586,6 @@ func (s *SQL) Query(labels map[string]string) ([]*rspb.Release, error) {
 
 		releases = append(releases, release)
 	}
-
-	if len(releases) == 0 {
-		return nil, ErrReleaseNotFound
-	}
-
 	return releases, nil
 }
 
@@ -456,6 +602,11 @@ func (s *SQL) Create(key string, rls *rspb.Release) error {
 		slog.Debug("failed to encode release", slog.Any("error", err))
 		return err
 	}
+	annotations, err := encodeAnnotations(rls.Annotations)
+	if err != nil {
+		slog.Debug("failed to encode annotations", slog.Any("error", err))
+		return err
+	}
 
 	transaction, err := s.db.Beginx()
 	if err != nil {
@@ -475,6 +626,7 @@ func (s *SQL) Create(key string, rls *rspb.Release) error {
 			sqlReleaseTableStatusColumn,
 			sqlReleaseTableOwnerColumn,
 			sqlReleaseTableCreatedAtColumn,
+			sqlReleaseTableAnnotationsColumn,
 		).
 		Values(
 			key,
@@ -486,6 +638,7 @@ func (s *SQL) Create(key string, rls *rspb.Release) error {
 			rls.Info.Status.String(),
 			sqlReleaseDefaultOwner,
 			int(time.Now().Unix()),
+			annotations,
 		).ToSql()
 	if err != nil {
 		slog.Debug("failed to build insert query", slog.Any("error", err))
@@ -493,7 +646,9 @@ func (s *SQL) Create(key string, rls *rspb.Release) error {
 	}
 
 	if _, err := transaction.Exec(insertQuery, args...); err != nil {
-		defer transaction.Rollback()
+		// PostgreSQL refuses further statements in a transaction after an
+		// error, so look for an existing release outside of it.
+		transaction.Rollback()
 
 		selectQuery, args, buildErr := s.statementBuilder.
 			Select(sqlReleaseTableKeyColumn).
@@ -507,7 +662,7 @@ func (s *SQL) Create(key string, rls *rspb.Release) error {
 		}
 
 		var record SQLReleaseWrapper
-		if err := transaction.Get(&record, selectQuery, args...); err == nil {
+		if err := s.db.Get(&record, selectQuery, args...); err == nil {
 			slog.Debug("release already exists", "key", key)
 			return ErrReleaseExists
 		}
@@ -563,6 +718,11 @@ func (s *SQL) Update(key string, rls *rspb.Release) error {
 		slog.Debug("failed to encode release", slog.Any("error", err))
 		return err
 	}
+	annotations, err := encodeAnnotations(rls.Annotations)
+	if err != nil {
+		slog.Debug("failed to encode annotations", slog.Any("error", err))
+		return err
+	}
 
 	query, args, err := s.statementBuilder.
 		Update(sqlReleaseTableName).
@@ -572,6 +732,7 @@ func (s *SQL) Update(key string, rls *rspb.Release) error {
 		Set(sqlReleaseTableStatusColumn, rls.Info.Status.String()).
 		Set(sqlReleaseTableOwnerColumn, sqlReleaseDefaultOwner).
 		Set(sqlReleaseTableModifiedAtColumn, int(time.Now().Unix())).
+		Set(sqlReleaseTableAnnotationsColumn, annotations).
 		Where(sq.Eq{sqlReleaseTableKeyColumn: key}).
 		Where(sq.Eq{sqlReleaseTableNamespaceColumn: namespace}).
 		ToSql()
@@ -598,7 +759,7 @@ func (s *SQL) Delete(key string) (*rspb.Release, error) {
 	}
 
 	selectQuery, args, err := s.statementBuilder.
-		Select(sqlReleaseTableBodyColumn).
+		Select(sqlReleaseTableBodyColumn, sqlReleaseTableAnnotationsColumn).
 		From(sqlReleaseTableName).
 		Where(sq.Eq{sqlReleaseTableKeyColumn: key}).
 		Where(sq.Eq{sqlReleaseTableNamespaceColumn: s.namespace}).
@@ -621,6 +782,11 @@ func (s *SQL) Delete(key string) (*rspb.Release, error) {
 		transaction.Rollback()
 		return nil, err
 	}
+	if release.Annotations, err = decodeAnnotations(record.Annotations); err != nil {
+		slog.Debug("failed to decode annotations", "key", key, slog.Any("error", err))
+		transaction.Rollback()
+		return nil, err
+	}
 	defer transaction.Commit()
 
 	deleteQuery, args, err := s.statementBuilder.
@@ -692,3 +858,25 @@ func getReleaseSystemLabels(rls *rspb.Release) map[string]string {
 		"version": strconv.Itoa(rls.Version),
 	}
 }
+
+// encodeAnnotations encodes the annotations of a release for the annotations
+// column, as an empty string if there are none.
+func encodeAnnotations(annotations map[string]string) (string, error) {
+	if len(annotations) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(annotations)
+	return string(data), err
+}
+
+// decodeAnnotations decodes the annotations column of a release.
+func decodeAnnotations(data string) (map[string]string, error) {
+	if data == "" {
+		return nil, nil
+	}
+	var annotations map[string]string
+	if err := json.Unmarshal([]byte(data), &annotations); err != nil {
+		return nil, err
+	}
+	return annotations, nil
+}
@@ -0,0 +1,126 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	rspb "helm.sh/helm/v4/pkg/release/v1"
+)
+
+// testPostgresConnectionStringEnv names the environment variable holding the
+// connection string of a PostgreSQL database to run the SQL driver against.
+// `make test-postgres` starts a container and sets it.
+const testPostgresConnectionStringEnv = "HELM_TEST_SQL_CONNECTION_STRING"
+
+func newTestPostgres(t *testing.T, namespace string) *SQL {
+	t.Helper()
+	connectionString := os.Getenv(testPostgresConnectionStringEnv)
+	if connectionString == "" {
+		t.Skipf("%s is not set", testPostgresConnectionStringEnv)
+	}
+	s, err := NewSQL(connectionString, namespace)
+	if err != nil {
+		t.Fatalf("Failed to connect to PostgreSQL: %v", err)
+	}
+	t.Cleanup(func() {
+		s.db.MustExec("DELETE FROM " + sqlCustomLabelsTableName)
+		s.db.MustExec("DELETE FROM " + sqlReleaseTableName)
+		s.db.Close()
+	})
+	return s
+}
+
+func TestSQLPostgres(t *testing.T) {
+	s := newTestPostgres(t, "default")
+
+	// Running the migrations again is a no-op.
+	if err := s.ensureDBSetup(); err != nil {
+		t.Fatalf("Failed to re-run migrations: %v", err)
+	}
+
+	annotated := releaseStub("rls-a", 2, "default", rspb.StatusDeployed)
+	annotated.Annotations = map[string]string{"helm.sh/team": "storage"}
+	for _, rel := range []*rspb.Release{
+		releaseStub("rls-a", 1, "default", rspb.StatusSuperseded),
+		annotated,
+		releaseStub("rls-b", 1, "default", rspb.StatusDeployed),
+	} {
+		if err := s.Create(testKey(rel.Name, rel.Version), rel); err != nil {
+			t.Fatalf("Failed to create release %s: %v", rel.Name, err)
+		}
+	}
+	if err := s.Create(testKey("rls-a", 1), releaseStub("rls-a", 1, "default", rspb.StatusDeployed)); !errors.Is(err, ErrReleaseExists) {
+		t.Errorf("Expected %v creating a duplicate release, got %v", ErrReleaseExists, err)
+	}
+
+	got, err := s.Get(testKey("rls-a", 2))
+	if err != nil {
+		t.Fatalf("Failed to get release: %v", err)
+	}
+	if got.Name != "rls-a" || got.Version != 2 || got.Info.Status != rspb.StatusDeployed {
+		t.Errorf("Unexpected release %s v%d (%s)", got.Name, got.Version, got.Info.Status)
+	}
+	if got.Labels["key1"] != "val1" {
+		t.Errorf("Expected custom labels to be stored, got %v", got.Labels)
+	}
+	if got.Annotations["helm.sh/team"] != "storage" {
+		t.Errorf("Expected annotations to be stored, got %v", got.Annotations)
+	}
+
+	deployed, err := s.List(func(rel *rspb.Release) bool { return rel.Info.Status == rspb.StatusDeployed })
+	if err != nil {
+		t.Fatalf("Failed to list releases: %v", err)
+	}
+	if len(deployed) != 2 {
+		t.Errorf("Expected 2 deployed releases, got %d", len(deployed))
+	}
+
+	history, err := s.Query(map[string]string{"name": "rls-a", "owner": sqlReleaseDefaultOwner})
+	if err != nil {
+		t.Fatalf("Failed to query releases: %v", err)
+	}
+	if len(history) != 2 {
+		t.Errorf("Expected 2 revisions of rls-a, got %d", len(history))
+	}
+	if _, err := s.Query(map[string]string{"name": "rls-c"}); !errors.Is(err, ErrReleaseNotFound) {
+		t.Errorf("Expected %v querying a missing release, got %v", ErrReleaseNotFound, err)
+	}
+
+	superseded := releaseStub("rls-a", 2, "default", rspb.StatusSuperseded)
+	if err := s.Update(testKey("rls-a", 2), superseded); err != nil {
+		t.Fatalf("Failed to update release: %v", err)
+	}
+	if got, err = s.Get(testKey("rls-a", 2)); err != nil || got.Info.Status != rspb.StatusSuperseded {
+		t.Errorf("Expected updated release to be superseded, got %v (%v)", got, err)
+	}
+
+	if _, err := s.Delete(testKey("rls-b", 1)); err != nil {
+		t.Fatalf("Failed to delete release: %v", err)
+	}
+	if _, err := s.Get(testKey("rls-b", 1)); !errors.Is(err, ErrReleaseNotFound) {
+		t.Errorf("Expected %v after deleting, got %v", ErrReleaseNotFound, err)
+	}
+
+	// Releases are scoped to the namespace of the driver.
+	other := newTestPostgres(t, "other")
+	if _, err := other.Get(testKey("rls-a", 1)); !errors.Is(err, ErrReleaseNotFound) {
+		t.Errorf("Expected releases of another namespace to be hidden, got %v", err)
+	}
+}
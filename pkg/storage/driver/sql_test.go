@@ -45,8 +45,9 @@ func TestSQLGet(t *testing.T) {
 	sqlDriver, mock := newTestFixtureSQL(t)
 
 	query := fmt.Sprintf(
-		regexp.QuoteMeta("SELECT %s FROM %s WHERE %s = $1 AND %s = $2"),
+		regexp.QuoteMeta("SELECT %s, %s FROM %s WHERE %s = $1 AND %s = $2"),
 		sqlReleaseTableBodyColumn,
+		sqlReleaseTableAnnotationsColumn,
 		sqlReleaseTableName,
 		sqlReleaseTableKeyColumn,
 		sqlReleaseTableNamespaceColumn,
@@ -92,10 +93,11 @@ func TestSQLList(t *testing.T) {
 
 	for i := 0; i < 3; i++ {
 		query := fmt.Sprintf(
-			"SELECT %s, %s, %s FROM %s WHERE %s = $1 AND %s = $2",
+			"SELECT %s, %s, %s, %s FROM %s WHERE %s = $1 AND %s = $2",
 			sqlReleaseTableKeyColumn,
 			sqlReleaseTableNamespaceColumn,
 			sqlReleaseTableBodyColumn,
+			sqlReleaseTableAnnotationsColumn,
 			sqlReleaseTableName,
 			sqlReleaseTableOwnerColumn,
 			sqlReleaseTableNamespaceColumn,
@@ -181,7 +183,7 @@ func TestSqlCreate(t *testing.T) {
 	body, _ := encodeRelease(rel)
 
 	query := fmt.Sprintf(
-		"INSERT INTO %s (%s,%s,%s,%s,%s,%s,%s,%s,%s) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)",
+		"INSERT INTO %s (%s,%s,%s,%s,%s,%s,%s,%s,%s,%s) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)",
 		sqlReleaseTableName,
 		sqlReleaseTableKeyColumn,
 		sqlReleaseTableTypeColumn,
@@ -192,12 +194,13 @@ func TestSqlCreate(t *testing.T) {
 		sqlReleaseTableStatusColumn,
 		sqlReleaseTableOwnerColumn,
 		sqlReleaseTableCreatedAtColumn,
+		sqlReleaseTableAnnotationsColumn,
 	)
 
 	mock.ExpectBegin()
 	mock.
 		ExpectExec(regexp.QuoteMeta(query)).
-		WithArgs(key, sqlReleaseDefaultType, body, rel.Name, rel.Namespace, int(rel.Version), rel.Info.Status.String(), sqlReleaseDefaultOwner, int(time.Now().Unix())).
+		WithArgs(key, sqlReleaseDefaultType, body, rel.Name, rel.Namespace, int(rel.Version), rel.Info.Status.String(), sqlReleaseDefaultOwner, int(time.Now().Unix()), "").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	labelsQuery := fmt.Sprintf(
@@ -227,6 +230,63 @@ func TestSqlCreate(t *testing.T) {
 	}
 }
 
+func TestSqlAnnotations(t *testing.T) {
+	vers := 1
+	name := "smug-pigeon"
+	namespace := "default"
+	key := testKey(name, vers)
+	rel := releaseStub(name, vers, namespace, rspb.StatusDeployed)
+	rel.Annotations = map[string]string{"helm.sh/team": "storage"}
+
+	sqlDriver, mock := newTestFixtureSQL(t)
+	body, _ := encodeRelease(rel)
+	annotations := `{"helm.sh/team":"storage"}`
+
+	mock.ExpectBegin()
+	mock.
+		ExpectExec(regexp.QuoteMeta("INSERT INTO "+sqlReleaseTableName)).
+		WithArgs(key, sqlReleaseDefaultType, body, rel.Name, rel.Namespace, int(rel.Version), rel.Info.Status.String(), sqlReleaseDefaultOwner, int(time.Now().Unix()), annotations).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.MatchExpectationsInOrder(false)
+	for k, v := range filterSystemLabels(rel.Labels) {
+		mock.
+			ExpectExec(regexp.QuoteMeta("INSERT INTO "+sqlCustomLabelsTableName)).
+			WithArgs(key, rel.Namespace, k, v).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	mock.ExpectCommit()
+
+	if err := sqlDriver.Create(key, rel); err != nil {
+		t.Fatalf("failed to create release with key %s: %v", key, err)
+	}
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(fmt.Sprintf("SELECT %s, %s FROM %s", sqlReleaseTableBodyColumn, sqlReleaseTableAnnotationsColumn, sqlReleaseTableName))).
+		WithArgs(key, namespace).
+		WillReturnRows(
+			mock.NewRows([]string{
+				sqlReleaseTableBodyColumn,
+				sqlReleaseTableAnnotationsColumn,
+			}).AddRow(
+				body,
+				annotations,
+			),
+		).RowsWillBeClosed()
+	mockGetReleaseCustomLabels(mock, key, namespace, rel.Labels)
+
+	got, err := sqlDriver.Get(key)
+	if err != nil {
+		t.Fatalf("Failed to get release: %v", err)
+	}
+	if !reflect.DeepEqual(rel.Annotations, got.Annotations) {
+		t.Errorf("Expected annotations %v, got %v", rel.Annotations, got.Annotations)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("sql expectations weren't met: %v", err)
+	}
+}
+
 func TestSqlCreateAlreadyExists(t *testing.T) {
 	vers := 1
 	name := "smug-pigeon"
@@ -238,7 +298,7 @@ func TestSqlCreateAlreadyExists(t *testing.T) {
 	body, _ := encodeRelease(rel)
 
 	insertQuery := fmt.Sprintf(
-		"INSERT INTO %s (%s,%s,%s,%s,%s,%s,%s,%s,%s) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)",
+		"INSERT INTO %s (%s,%s,%s,%s,%s,%s,%s,%s,%s,%s) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)",
 		sqlReleaseTableName,
 		sqlReleaseTableKeyColumn,
 		sqlReleaseTableTypeColumn,
@@ -249,14 +309,16 @@ func TestSqlCreateAlreadyExists(t *testing.T) {
 		sqlReleaseTableStatusColumn,
 		sqlReleaseTableOwnerColumn,
 		sqlReleaseTableCreatedAtColumn,
+		sqlReleaseTableAnnotationsColumn,
 	)
 
 	// Insert fails (primary key already exists)
 	mock.ExpectBegin()
 	mock.
 		ExpectExec(regexp.QuoteMeta(insertQuery)).
-		WithArgs(key, sqlReleaseDefaultType, body, rel.Name, rel.Namespace, int(rel.Version), rel.Info.Status.String(), sqlReleaseDefaultOwner, int(time.Now().Unix())).
+		WithArgs(key, sqlReleaseDefaultType, body, rel.Name, rel.Namespace, int(rel.Version), rel.Info.Status.String(), sqlReleaseDefaultOwner, int(time.Now().Unix()), "").
 		WillReturnError(fmt.Errorf("dialect dependent SQL error"))
+	mock.ExpectRollback()
 
 	selectQuery := fmt.Sprintf(
 		regexp.QuoteMeta("SELECT %s FROM %s WHERE %s = $1 AND %s = $2"),
@@ -277,7 +339,6 @@ func TestSqlCreateAlreadyExists(t *testing.T) {
 				key,
 			),
 		).RowsWillBeClosed()
-	mock.ExpectRollback()
 
 	if err := sqlDriver.Create(key, rel); err == nil {
 		t.Fatalf("failed to create release with key %s: %v", key, err)
@@ -299,7 +360,7 @@ func TestSqlUpdate(t *testing.T) {
 	body, _ := encodeRelease(rel)
 
 	query := fmt.Sprintf(
-		"UPDATE %s SET %s = $1, %s = $2, %s = $3, %s = $4, %s = $5, %s = $6 WHERE %s = $7 AND %s = $8",
+		"UPDATE %s SET %s = $1, %s = $2, %s = $3, %s = $4, %s = $5, %s = $6, %s = $7 WHERE %s = $8 AND %s = $9",
 		sqlReleaseTableName,
 		sqlReleaseTableBodyColumn,
 		sqlReleaseTableNameColumn,
@@ -307,13 +368,14 @@ func TestSqlUpdate(t *testing.T) {
 		sqlReleaseTableStatusColumn,
 		sqlReleaseTableOwnerColumn,
 		sqlReleaseTableModifiedAtColumn,
+		sqlReleaseTableAnnotationsColumn,
 		sqlReleaseTableKeyColumn,
 		sqlReleaseTableNamespaceColumn,
 	)
 
 	mock.
 		ExpectExec(regexp.QuoteMeta(query)).
-		WithArgs(body, rel.Name, int(rel.Version), rel.Info.Status.String(), sqlReleaseDefaultOwner, int(time.Now().Unix()), key, namespace).
+		WithArgs(body, rel.Name, int(rel.Version), rel.Info.Status.String(), sqlReleaseDefaultOwner, int(time.Now().Unix()), "", key, namespace).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	if err := sqlDriver.Update(key, rel); err != nil {
@@ -325,6 +387,45 @@ func TestSqlUpdate(t *testing.T) {
 	}
 }
 
+func TestSqlLastVersions(t *testing.T) {
+	sqlDriver, mock := newTestFixtureSQL(t)
+
+	query := fmt.Sprintf(
+		"SELECT %s, %s, MAX(%s) AS %s FROM %s WHERE %s = $1 AND %s = $2 GROUP BY %s, %s",
+		sqlReleaseTableNamespaceColumn,
+		sqlReleaseTableNameColumn,
+		sqlReleaseTableVersionColumn,
+		sqlReleaseTableVersionColumn,
+		sqlReleaseTableName,
+		sqlReleaseTableOwnerColumn,
+		sqlReleaseTableNamespaceColumn,
+		sqlReleaseTableNamespaceColumn,
+		sqlReleaseTableNameColumn,
+	)
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(sqlReleaseDefaultOwner, sqlDriver.namespace).
+		WillReturnRows(
+			mock.NewRows([]string{
+				sqlReleaseTableNamespaceColumn,
+				sqlReleaseTableNameColumn,
+				sqlReleaseTableVersionColumn,
+			}).AddRow("default", "smug-pigeon", 3).AddRow("default", "happy-cat", 1),
+		).RowsWillBeClosed()
+
+	versions, err := sqlDriver.LastVersions()
+	if err != nil {
+		t.Fatalf("failed to list the last versions: %v", err)
+	}
+	expect := map[string]int{"default/smug-pigeon": 3, "default/happy-cat": 1}
+	if !reflect.DeepEqual(versions, expect) {
+		t.Errorf("Expected last versions %v, got %v", expect, versions)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("sql expectations weren't met: %v", err)
+	}
+}
+
 func TestSqlQuery(t *testing.T) {
 	// Reflect actual use cases in ../storage.go
 	labelSetUnknown := map[string]string{
@@ -351,10 +452,11 @@ func TestSqlQuery(t *testing.T) {
 	sqlDriver, mock := newTestFixtureSQL(t)
 
 	query := fmt.Sprintf(
-		"SELECT %s, %s, %s FROM %s WHERE %s = $1 AND %s = $2 AND %s = $3 AND %s = $4",
+		"SELECT %s, %s, %s, %s FROM %s WHERE %s = $1 AND %s = $2 AND %s = $3 AND %s = $4",
 		sqlReleaseTableKeyColumn,
 		sqlReleaseTableNamespaceColumn,
 		sqlReleaseTableBodyColumn,
+		sqlReleaseTableAnnotationsColumn,
 		sqlReleaseTableName,
 		sqlReleaseTableNameColumn,
 		sqlReleaseTableOwnerColumn,
@@ -385,10 +487,11 @@ func TestSqlQuery(t *testing.T) {
 	mockGetReleaseCustomLabels(mock, "", deployedRelease.Namespace, deployedRelease.Labels)
 
 	query = fmt.Sprintf(
-		"SELECT %s, %s, %s FROM %s WHERE %s = $1 AND %s = $2 AND %s = $3",
+		"SELECT %s, %s, %s, %s FROM %s WHERE %s = $1 AND %s = $2 AND %s = $3",
 		sqlReleaseTableKeyColumn,
 		sqlReleaseTableNamespaceColumn,
 		sqlReleaseTableBodyColumn,
+		sqlReleaseTableAnnotationsColumn,
 		sqlReleaseTableName,
 		sqlReleaseTableNameColumn,
 		sqlReleaseTableOwnerColumn,
@@ -449,6 +552,85 @@ func TestSqlQuery(t *testing.T) {
 	}
 }
 
+func TestSqlQueryPage(t *testing.T) {
+	labelSetAll := map[string]string{
+		"name":  "smug-pigeon",
+		"owner": sqlReleaseDefaultOwner,
+	}
+
+	release2 := releaseStub("smug-pigeon", 2, "default", rspb.StatusSuperseded)
+	release2Body, _ := encodeRelease(release2)
+
+	sqlDriver, mock := newTestFixtureSQL(t)
+
+	countQuery := fmt.Sprintf(
+		"SELECT COUNT(*) FROM %s WHERE %s = $1 AND %s = $2 AND %s = $3",
+		sqlReleaseTableName,
+		sqlReleaseTableNameColumn,
+		sqlReleaseTableOwnerColumn,
+		sqlReleaseTableNamespaceColumn,
+	)
+	query := fmt.Sprintf(
+		"SELECT %s, %s, %s, %s FROM %s WHERE %s = $1 AND %s = $2 AND %s = $3 ORDER BY %s DESC LIMIT 1 OFFSET 1",
+		sqlReleaseTableKeyColumn,
+		sqlReleaseTableNamespaceColumn,
+		sqlReleaseTableBodyColumn,
+		sqlReleaseTableAnnotationsColumn,
+		sqlReleaseTableName,
+		sqlReleaseTableNameColumn,
+		sqlReleaseTableOwnerColumn,
+		sqlReleaseTableNamespaceColumn,
+		sqlReleaseTableVersionColumn,
+	)
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(countQuery)).
+		WithArgs("smug-pigeon", sqlReleaseDefaultOwner, "default").
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(3)).
+		RowsWillBeClosed()
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs("smug-pigeon", sqlReleaseDefaultOwner, "default").
+		WillReturnRows(
+			mock.NewRows([]string{
+				sqlReleaseTableBodyColumn,
+			}).AddRow(
+				release2Body,
+			),
+		).RowsWillBeClosed()
+	mockGetReleaseCustomLabels(mock, "", release2.Namespace, release2.Labels)
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(countQuery)).
+		WithArgs("unknown-pigeon", sqlReleaseDefaultOwner, "default").
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(0)).
+		RowsWillBeClosed()
+
+	results, total, err := sqlDriver.QueryPage(labelSetAll, 1, 1)
+	if err != nil {
+		t.Fatalf("failed to query release history page for smug-pigeon: %v", err)
+	}
+	if total != 3 {
+		t.Errorf("expected a total of 3 releases, got %d", total)
+	}
+	if len(results) != 1 || !reflect.DeepEqual(results[0], release2) {
+		t.Errorf("Expected release {%v}, got {%v}", release2, results)
+	}
+
+	_, _, err = sqlDriver.QueryPage(map[string]string{"name": "unknown-pigeon", "owner": sqlReleaseDefaultOwner}, 0, 10)
+	if err != ErrReleaseNotFound {
+		t.Errorf("Expected error {%v}, got %v", ErrReleaseNotFound, err)
+	}
+
+	if _, _, err := sqlDriver.QueryPage(map[string]string{"bogus": "value"}, 0, 10); err == nil {
+		t.Error("Expected error for an unknown label")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("sql expectations weren't met: %v", err)
+	}
+}
+
 func TestSqlDelete(t *testing.T) {
 	vers := 1
 	name := "smug-pigeon"
@@ -461,8 +643,9 @@ func TestSqlDelete(t *testing.T) {
 	sqlDriver, mock := newTestFixtureSQL(t)
 
 	selectQuery := fmt.Sprintf(
-		"SELECT %s FROM %s WHERE %s = $1 AND %s = $2",
+		"SELECT %s, %s FROM %s WHERE %s = $1 AND %s = $2",
 		sqlReleaseTableBodyColumn,
+		sqlReleaseTableAnnotationsColumn,
 		sqlReleaseTableName,
 		sqlReleaseTableKeyColumn,
 		sqlReleaseTableNamespaceColumn,
@@ -590,3 +773,47 @@ func TestSqlCheckAppliedMigrations(t *testing.T) {
 		}
 	}
 }
+
+func TestSqlEnsureDBSetup(t *testing.T) {
+	sqlDriver, mock := newTestFixtureSQL(t)
+
+	// No migration has been applied, so the tables are created, in the order
+	// of the migration ids.
+	mock.ExpectQuery(`SELECT \* FROM "gorp_migrations"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "applied_at"}))
+	mock.ExpectExec(`create table if not exists "gorp_migrations"`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT \* FROM "gorp_migrations"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "applied_at"}))
+	for _, table := range []string{sqlCustomLabelsTableName, sqlReleaseTableName} {
+		mock.ExpectBegin()
+		mock.ExpectExec(`CREATE TABLE ` + table + ` \(`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`insert into "gorp_migrations"`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+	}
+	mock.ExpectBegin()
+	mock.ExpectExec(`ALTER TABLE ` + sqlReleaseTableName + ` ADD COLUMN ` + sqlReleaseTableAnnotationsColumn).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`insert into "gorp_migrations"`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	if err := sqlDriver.ensureDBSetup(); err != nil {
+		t.Fatalf("failed to set up the database: %v", err)
+	}
+
+	// Once applied, the migrations are not run again.
+	mock.ExpectQuery(`SELECT \* FROM "gorp_migrations"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "applied_at"}).
+			AddRow("init", time.Time{}).
+			AddRow("custom_labels", time.Time{}).
+			AddRow("release_annotations", time.Time{}))
+	if err := sqlDriver.ensureDBSetup(); err != nil {
+		t.Fatalf("failed to set up the database: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("sql expectations weren't met: %v", err)
+	}
+}
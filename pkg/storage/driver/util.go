@@ -22,7 +22,13 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"io"
+	"path"
 	"slices"
+	"strconv"
+	"strings"
+
+	kblabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
 
 	rspb "helm.sh/helm/v4/pkg/release/v1"
 )
@@ -36,12 +42,18 @@ var systemLabels = []string{"name", "owner", "status", "version", "createdAt", "
 // encodeRelease encodes a release returning a base64 encoded
 // gzipped string representation, or error.
 func encodeRelease(rls *rspb.Release) (string, error) {
+	return encodeReleaseLevel(rls, gzip.BestCompression)
+}
+
+// encodeReleaseLevel is like encodeRelease, compressing with the given gzip
+// level.
+func encodeReleaseLevel(rls *rspb.Release, level int) (string, error) {
 	b, err := json.Marshal(rls)
 	if err != nil {
 		return "", err
 	}
 	var buf bytes.Buffer
-	w, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	w, err := gzip.NewWriterLevel(&buf, level)
 	if err != nil {
 		return "", err
 	}
@@ -116,3 +128,55 @@ func ContainsSystemLabels(lbs map[string]string) bool {
 func GetSystemLabels() []string {
 	return systemLabels
 }
+
+// ReleaseAnnotationPrefix is the prefix of the keys of the annotations of a
+// release. The other annotations of its storage object, such as those added
+// by other tools, are not part of the release.
+const ReleaseAnnotationPrefix = "helm.sh/"
+
+// Keeps the release annotations of the annotations of a storage object
+func filterReleaseAnnotations(annotations map[string]string) map[string]string {
+	var result map[string]string
+	for k, v := range annotations {
+		if strings.HasPrefix(k, ReleaseAnnotationPrefix) {
+			if result == nil {
+				result = make(map[string]string)
+			}
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// Checks if annotations contains keys that are not release annotations
+func ContainsNonReleaseAnnotations(annotations map[string]string) bool {
+	for k := range annotations {
+		if !strings.HasPrefix(k, ReleaseAnnotationPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordLastVersion records the version of the release labeled by labels in
+// versions, keyed as by Versioner, when it is the last one seen of its release.
+func recordLastVersion(versions map[string]int, namespace string, labels map[string]string) {
+	version, err := strconv.Atoi(labels["version"])
+	if err != nil {
+		return
+	}
+	key := path.Join(namespace, labels["name"])
+	if version > versions[key] {
+		versions[key] = version
+	}
+}
+
+// withOwner restricts selector to the storage objects owned by helm
+func withOwner(selector kblabels.Selector) kblabels.Selector {
+	owner, err := kblabels.NewRequirement("owner", selection.Equals, []string{"helm"})
+	if err != nil {
+		// The requirement is constant and valid.
+		panic(err)
+	}
+	return selector.Add(*owner)
+}
@@ -20,8 +20,11 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"path"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/labels"
+
 	relutil "helm.sh/helm/v4/pkg/release/util"
 	rspb "helm.sh/helm/v4/pkg/release/v1"
 	"helm.sh/helm/v4/pkg/storage/driver"
@@ -67,6 +70,19 @@ func (s *Storage) Create(rls *rspb.Release) error {
 	return s.Driver.Create(makeKey(rls.Name, rls.Version), rls)
 }
 
+// Prune removes the oldest revisions of the release name beyond MaxHistory,
+// if positive. The deployed revision, the revisions of operations in progress
+// and the revisions they roll back to are kept.
+func (s *Storage) Prune(name string) error {
+	if s.MaxHistory <= 0 {
+		return nil
+	}
+	if err := s.removeLeastRecent(name, s.MaxHistory); err != nil && !errors.Is(err, driver.ErrReleaseNotFound) {
+		return err
+	}
+	return nil
+}
+
 // Update updates the release in storage. An error is returned if the
 // storage backend fails to update the release or if the release
 // does not exist.
@@ -90,6 +106,45 @@ func (s *Storage) ListReleases() ([]*rspb.Release, error) {
 	return s.List(func(_ *rspb.Release) bool { return true })
 }
 
+// ListSelector returns the releases matching the label selector that satisfy
+// the filter predicate.
+//
+// Drivers implementing driver.Selector select the releases in the backend;
+// for the others every release is loaded and matched against its labels.
+func (s *Storage) ListSelector(selector labels.Selector, filter func(*rspb.Release) bool) ([]*rspb.Release, error) {
+	slog.Debug("listing releases in storage", "selector", selector.String())
+	if sel, ok := s.Driver.(driver.Selector); ok {
+		return sel.ListSelector(selector, filter)
+	}
+	return s.List(func(rls *rspb.Release) bool {
+		return selector.Matches(labels.Set(rls.Labels)) && filter(rls)
+	})
+}
+
+// LastVersions returns the last version of each release, keyed by the
+// namespace and the name of the release joined by a slash.
+//
+// Drivers implementing driver.Versioner read the versions without decoding
+// the releases; for the others every release is loaded.
+func (s *Storage) LastVersions() (map[string]int, error) {
+	slog.Debug("listing the last versions of the releases in storage")
+	if v, ok := s.Driver.(driver.Versioner); ok {
+		return v.LastVersions()
+	}
+	all, err := s.ListReleases()
+	if err != nil {
+		return nil, err
+	}
+	versions := make(map[string]int, len(all))
+	for _, rls := range all {
+		key := path.Join(rls.Namespace, rls.Name)
+		if rls.Version > versions[key] {
+			versions[key] = rls.Version
+		}
+	}
+	return versions, nil
+}
+
 // ListUninstalled returns all releases with Status == UNINSTALLED. An error is returned
 // if the storage backend fails to retrieve the releases.
 func (s *Storage) ListUninstalled() ([]*rspb.Release, error) {
@@ -154,6 +209,36 @@ func (s *Storage) History(name string) ([]*rspb.Release, error) {
 	return s.Query(map[string]string{"name": name, "owner": "helm"})
 }
 
+// HistoryPage returns a page of the revisions of the release named by name,
+// newest first, along with the total number of revisions. The first offset
+// revisions are skipped and at most limit revisions are returned; a limit of
+// 0 or less returns all remaining revisions.
+//
+// Drivers implementing driver.Pager paginate in the backend; for the others
+// the whole history is loaded and paginated in memory.
+func (s *Storage) HistoryPage(name string, offset, limit int) ([]*rspb.Release, int, error) {
+	slog.Debug("getting release history page", "name", name, "offset", offset, "limit", limit)
+	offset = max(offset, 0)
+
+	query := map[string]string{"name": name, "owner": "helm"}
+	if pager, ok := s.Driver.(driver.Pager); ok {
+		return pager.QueryPage(query, offset, limit)
+	}
+
+	h, err := s.Query(query)
+	if err != nil {
+		return nil, 0, err
+	}
+	relutil.Reverse(h, relutil.SortByRevision)
+
+	total := len(h)
+	h = h[min(offset, total):]
+	if limit > 0 && limit < len(h) {
+		h = h[:limit]
+	}
+	return h, total, nil
+}
+
 // removeLeastRecent removes items from history until the length number of releases
 // does not exceed max.
 //
@@ -178,6 +263,10 @@ func (s *Storage) removeLeastRecent(name string, maximum int) error {
 	if err != nil && !errors.Is(err, driver.ErrNoDeployedReleases) {
 		return err
 	}
+	kept := inUse(h)
+	if lastDeployed != nil {
+		kept[lastDeployed.Version] = true
+	}
 
 	var toDelete []*rspb.Release
 	for _, rel := range h {
@@ -185,11 +274,7 @@ func (s *Storage) removeLeastRecent(name string, maximum int) error {
 		if len(h)-len(toDelete) == maximum {
 			break
 		}
-		if lastDeployed != nil {
-			if rel.Version != lastDeployed.Version {
-				toDelete = append(toDelete, rel)
-			}
-		} else {
+		if !kept[rel.Version] {
 			toDelete = append(toDelete, rel)
 		}
 	}
@@ -215,6 +300,27 @@ func (s *Storage) removeLeastRecent(name string, maximum int) error {
 	}
 }
 
+// inUse returns the revisions of the operations in progress in h, such as a
+// pending rollback, along with the revisions they roll back to.
+func inUse(h []*rspb.Release) map[int]bool {
+	versions := map[int]bool{}
+	for _, rel := range h {
+		if rel.Info == nil || !rel.Info.Status.IsPending() {
+			continue
+		}
+		versions[rel.Version] = true
+		// Rollbacks record their target in the description of the release
+		// until they complete.
+		var target int
+		if rel.Info.Status == rspb.StatusPendingRollback {
+			if _, err := fmt.Sscanf(rel.Info.Description, "Rollback to %d", &target); err == nil {
+				versions[target] = true
+			}
+		}
+	}
+	return versions
+}
+
 func (s *Storage) deleteReleaseVersion(name string, version int) error {
 	key := makeKey(name, version)
 	_, err := s.Delete(name, version)
@@ -22,6 +22,8 @@ import (
 	"reflect"
 	"testing"
 
+	"k8s.io/apimachinery/pkg/labels"
+
 	rspb "helm.sh/helm/v4/pkg/release/v1"
 	"helm.sh/helm/v4/pkg/storage/driver"
 )
@@ -166,6 +168,49 @@ func TestStorageList(t *testing.T) {
 	}
 }
 
+func TestStorageListSelector(t *testing.T) {
+	storage := Init(driver.NewMemory())
+
+	rls0 := ReleaseTestData{Name: "happy-catdog", Status: rspb.StatusDeployed}.ToRelease()
+	rls0.Labels = map[string]string{"team": "payments"}
+	rls1 := ReleaseTestData{Name: "livid-human", Status: rspb.StatusDeployed}.ToRelease()
+	rls1.Labels = map[string]string{"team": "search"}
+	rls2 := ReleaseTestData{Name: "relaxed-cat", Status: rspb.StatusUninstalled}.ToRelease()
+	rls2.Labels = map[string]string{"team": "payments"}
+	assertErrNil(t.Fatal, storage.Create(rls0), "Storing release 'rls0'")
+	assertErrNil(t.Fatal, storage.Create(rls1), "Storing release 'rls1'")
+	assertErrNil(t.Fatal, storage.Create(rls2), "Storing release 'rls2'")
+
+	list, err := storage.ListSelector(labels.SelectorFromSet(labels.Set{"team": "payments"}), func(rls *rspb.Release) bool {
+		return rls.Info.Status == rspb.StatusDeployed
+	})
+	assertErrNil(t.Fatal, err, "ListSelector")
+	if len(list) != 1 || list[0].Name != "happy-catdog" {
+		t.Errorf("Expected only happy-catdog to be listed, got %v", list)
+	}
+}
+
+func TestStorageLastVersions(t *testing.T) {
+	storage := Init(driver.NewMemory())
+
+	for _, rls := range []ReleaseTestData{
+		{Name: "happy-catdog", Version: 1, Namespace: "default", Status: rspb.StatusSuperseded},
+		{Name: "happy-catdog", Version: 2, Namespace: "default", Status: rspb.StatusDeployed},
+		{Name: "happy-catdog", Version: 1, Namespace: "other", Status: rspb.StatusDeployed},
+	} {
+		assertErrNil(t.Fatal, storage.Create(rls.ToRelease()), "Storing release")
+	}
+
+	// The memory driver lists the namespace of the last release created.
+	storage.Driver.(*driver.Memory).SetNamespace("")
+	versions, err := storage.LastVersions()
+	assertErrNil(t.Fatal, err, "LastVersions")
+	expect := map[string]int{"default/happy-catdog": 2, "other/happy-catdog": 1}
+	if !reflect.DeepEqual(versions, expect) {
+		t.Errorf("Expected last versions %v, got %v", expect, versions)
+	}
+}
+
 func TestStorageDeployed(t *testing.T) {
 	storage := Init(driver.NewMemory())
 
@@ -277,6 +322,52 @@ func TestStorageHistory(t *testing.T) {
 	}
 }
 
+func TestStorageHistoryPage(t *testing.T) {
+	storage := Init(driver.NewMemory())
+
+	const name = "angry-bird"
+	for _, v := range []int{3, 1, 5, 2, 4} {
+		rls := ReleaseTestData{Name: name, Version: v, Status: rspb.StatusSuperseded}.ToRelease()
+		assertErrNil(t.Fatal, storage.Create(rls), "Storing release 'angry-bird'")
+	}
+
+	versions := func(rls []*rspb.Release) []int {
+		var vs []int
+		for _, r := range rls {
+			vs = append(vs, r.Version)
+		}
+		return vs
+	}
+
+	tests := []struct {
+		offset, limit int
+		expected      []int
+	}{
+		{0, 2, []int{5, 4}},
+		{2, 2, []int{3, 2}},
+		{4, 2, []int{1}},
+		{5, 2, nil},
+		{-1, 1, []int{5}},
+		{1, 0, []int{4, 3, 2, 1}},
+	}
+	for _, tt := range tests {
+		page, total, err := storage.HistoryPage(name, tt.offset, tt.limit)
+		if err != nil {
+			t.Fatalf("Failed to get history page (%d, %d): %s", tt.offset, tt.limit, err)
+		}
+		if total != 5 {
+			t.Errorf("Expected a total of 5 revisions, got %d", total)
+		}
+		if !reflect.DeepEqual(tt.expected, versions(page)) {
+			t.Errorf("Expected page (%d, %d) to hold versions %v, got %v", tt.offset, tt.limit, tt.expected, versions(page))
+		}
+	}
+
+	if _, _, err := storage.HistoryPage("missing", 0, 10); !errors.Is(err, driver.ErrReleaseNotFound) {
+		t.Errorf("Expected %v for a missing release, got %v", driver.ErrReleaseNotFound, err)
+	}
+}
+
 var errMaxHistoryMockDriverSomethingHappened = errors.New("something happened")
 
 type MaxHistoryMockDriver struct {
@@ -440,6 +531,61 @@ func TestStorageDoNotDeleteDeployed(t *testing.T) {
 	}
 }
 
+func TestStoragePrune(t *testing.T) {
+	storage := Init(driver.NewMemory())
+
+	const name = "angry-bird"
+
+	statuses := []rspb.Status{
+		rspb.StatusSuperseded,
+		rspb.StatusDeployed,
+		rspb.StatusSuperseded,
+		rspb.StatusFailed,
+		rspb.StatusFailed,
+		rspb.StatusPendingRollback,
+	}
+	for i, status := range statuses {
+		rls := ReleaseTestData{Name: name, Version: i + 1, Status: status}.ToRelease()
+		if status == rspb.StatusPendingRollback {
+			rls.Info.Description = "Rollback to 1"
+		}
+		assertErrNil(t.Fatal, storage.Create(rls), fmt.Sprintf("Storing release 'angry-bird' (v%d)", i+1))
+	}
+
+	// Without a limit all the revisions are kept.
+	assertErrNil(t.Fatal, storage.Prune(name), "Pruning release 'angry-bird'")
+	hist, err := storage.History(name)
+	assertErrNil(t.Fatal, err, "History of release 'angry-bird'")
+	if len(hist) != len(statuses) {
+		t.Fatalf("expected %d items in history, got %d", len(statuses), len(hist))
+	}
+
+	// The deployed revision, the pending rollback and its target are kept
+	// over more recent revisions.
+	storage.MaxHistory = 4
+	assertErrNil(t.Fatal, storage.Prune(name), "Pruning release 'angry-bird'")
+	hist, err = storage.History(name)
+	assertErrNil(t.Fatal, err, "History of release 'angry-bird'")
+
+	expectedVersions := map[int]bool{
+		1: true,
+		2: true,
+		5: true,
+		6: true,
+	}
+	if len(hist) != len(expectedVersions) {
+		t.Fatalf("expected %d items in history, got %d", len(expectedVersions), len(hist))
+	}
+	for _, item := range hist {
+		if !expectedVersions[item.Version] {
+			t.Errorf("Release version %d, found when not expected", item.Version)
+		}
+	}
+
+	// Pruning an unknown release is not an error.
+	assertErrNil(t.Error, storage.Prune("unknown"), "Pruning release 'unknown'")
+}
+
 func TestStorageLast(t *testing.T) {
 	storage := Init(driver.NewMemory())
 
@@ -0,0 +1,142 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package strvals
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ParseDelete parses a line of value names and removes them from dest.
+//
+// The line is of the form name1,topname.subname,list[0].name, using the names
+// of set lines. The keys of maps are set to null rather than deleted, so that
+// they also remove the values they are coalesced with, such as the defaults
+// of a chart. Indexes remove an element from a list of dest, shifting the
+// following elements; as lists replace those they are coalesced with, there
+// is nothing to remove for lists not in dest.
+//
+// Applied after the set lines into dest, the removals win over the values set
+// on the same names.
+func ParseDelete(s string, dest map[string]interface{}) error {
+	paths, err := parseDeletePaths(s)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		unset(dest, path)
+	}
+	return nil
+}
+
+// pathElement is the key of a map, or the index of a list.
+type pathElement struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+func parseDeletePaths(s string) ([][]pathElement, error) {
+	if s == "" {
+		return nil, nil
+	}
+	sc := bytes.NewBufferString(s)
+	stop := runeSet([]rune{'.', '[', ','})
+	var paths [][]pathElement
+	var path []pathElement
+	afterIndex := false
+	for {
+		k, last, err := runesUntil(sc, stop)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		switch {
+		case afterIndex && len(k) > 0:
+			return nil, fmt.Errorf("unexpected %q after the index of %q", string(k), s)
+		case !afterIndex && len(k) == 0:
+			return nil, fmt.Errorf("key in %q has no name", s)
+		case !afterIndex:
+			path = append(path, pathElement{key: string(k)})
+		}
+		afterIndex = false
+
+		if err != nil {
+			// The end of the line.
+			return append(paths, path), nil
+		}
+		switch last {
+		case '[':
+			v, _, err := runesUntil(sc, runeSet([]rune{']'}))
+			if err != nil {
+				return nil, fmt.Errorf("unterminated index in %q", s)
+			}
+			i, err := strconv.Atoi(string(v))
+			if err != nil {
+				return nil, fmt.Errorf("invalid index %q in %q", string(v), s)
+			}
+			if i < 0 {
+				return nil, fmt.Errorf("negative %d index not allowed", i)
+			}
+			path = append(path, pathElement{index: i, isIndex: true})
+			afterIndex = true
+		case ',':
+			paths = append(paths, path)
+			path = nil
+		}
+	}
+}
+
+// unset removes path from v and returns the resulting value.
+func unset(v interface{}, path []pathElement) interface{} {
+	el := path[0]
+	if el.isIndex {
+		list, ok := v.([]interface{})
+		if !ok || el.index >= len(list) {
+			return v
+		}
+		if len(path) == 1 {
+			return append(list[:el.index:el.index], list[el.index+1:]...)
+		}
+		list[el.index] = unset(list[el.index], path[1:])
+		return list
+	}
+
+	data, ok := v.(map[string]interface{})
+	if !ok {
+		if v != nil {
+			// A value other than a map replaces any map below it.
+			return v
+		}
+		data = map[string]interface{}{}
+	}
+	if len(path) == 1 {
+		data[el.key] = nil
+		return data
+	}
+	child, present := data[el.key]
+	if present && child == nil {
+		// Already removed.
+		return data
+	}
+	if child = unset(child, path[1:]); present || child != nil {
+		data[el.key] = child
+	}
+	return data
+}
@@ -0,0 +1,124 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package strvals
+
+import (
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestParseDelete(t *testing.T) {
+	tests := []struct {
+		name   string
+		values string
+		unset  string
+		expect string
+		err    string
+	}{
+		{
+			name:   "top level key",
+			values: "name: value\nother: kept\n",
+			unset:  "name",
+			expect: "name: null\nother: kept\n",
+		},
+		{
+			name:   "nested keys",
+			values: "outer:\n  inner: value\n  kept: true\n",
+			unset:  "outer.inner,outer.missing",
+			expect: "outer:\n  inner: null\n  kept: true\n  missing: null\n",
+		},
+		{
+			name:   "keys not in the values",
+			unset:  "subchart.ingress.enabled",
+			expect: "subchart:\n  ingress:\n    enabled: null\n",
+		},
+		{
+			name:   "escaped dots",
+			values: "annotations:\n  example.com/name: value\n",
+			unset:  `annotations.example\.com/name`,
+			expect: "annotations:\n  example.com/name: null\n",
+		},
+		{
+			name:   "list element",
+			values: "list:\n- a\n- b\n- c\n",
+			unset:  "list[1]",
+			expect: "list:\n- a\n- c\n",
+		},
+		{
+			name:   "key within a list element",
+			values: "list:\n- name: a\n  port: 80\n",
+			unset:  "list[0].port",
+			expect: "list:\n- name: a\n  port: null\n",
+		},
+		{
+			name:   "nested lists",
+			values: "matrix:\n- - a\n  - b\n",
+			unset:  "matrix[0][0]",
+			expect: "matrix:\n- - b\n",
+		},
+		{
+			name:   "lists not in the values",
+			values: "list:\n- a\n",
+			unset:  "list[3],other[0],other[0].name",
+			expect: "list:\n- a\n",
+		},
+		{
+			name:   "removed keys stay removed",
+			values: "outer:\n  inner: value\n",
+			unset:  "outer,outer.inner",
+			expect: "outer: null\n",
+		},
+		{
+			name:   "keys below other values",
+			values: "scalar: 1\n",
+			unset:  "scalar.inner",
+			expect: "scalar: 1\n",
+		},
+		{name: "empty key", unset: "outer..inner", err: `key in "outer..inner" has no name`},
+		{name: "trailing comma", unset: "name,", err: `key in "name," has no name`},
+		{name: "unterminated index", unset: "list[1", err: `unterminated index in "list[1"`},
+		{name: "invalid index", unset: "list[one]", err: `invalid index "one" in "list[one]"`},
+		{name: "negative index", unset: "list[-1]", err: "negative -1 index not allowed"},
+		{name: "text after an index", unset: "list[0]name", err: `unexpected "name" after the index of "list[0]name"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := map[string]interface{}{}
+			if err := yaml.Unmarshal([]byte(tt.values), &dest); err != nil {
+				t.Fatal(err)
+			}
+			err := ParseDelete(tt.unset, dest)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := yaml.Marshal(dest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.expect {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expect, got)
+			}
+		})
+	}
+}
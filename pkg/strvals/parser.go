@@ -111,7 +111,20 @@ func ParseIntoString(s string, dest map[string]interface{}) error {
 // If a key exists in dest, the new value overwrites the dest version.
 func ParseJSON(s string, dest map[string]interface{}) error {
 	scanner := bytes.NewBufferString(s)
-	t := newJSONParser(scanner, dest)
+	t := newJSONParser(scanner, dest, false)
+	return t.parse()
+}
+
+// ParseIntoJSON parses a string with format key1=val1, key2=val2, ...
+// where values are json strings (null, or scalars, or arrays, or objects),
+// and merges the result into dest. An empty val is treated as null.
+//
+// Unlike ParseJSON, a json object is merged into an object that exists at
+// the same key in dest, keeping the keys it does not set. Other values
+// overwrite the dest version.
+func ParseIntoJSON(s string, dest map[string]interface{}) error {
+	scanner := bytes.NewBufferString(s)
+	t := newJSONParser(scanner, dest, true)
 	return t.parse()
 }
 
@@ -138,6 +151,9 @@ type parser struct {
 	data      map[string]interface{}
 	reader    RunesValueReader
 	isjsonval bool
+	// mergejson merges json objects into existing objects instead of
+	// overwriting them
+	mergejson bool
 }
 
 func newParser(sc *bytes.Buffer, data map[string]interface{}, stringBool bool) *parser {
@@ -147,8 +163,8 @@ func newParser(sc *bytes.Buffer, data map[string]interface{}, stringBool bool) *
 	return &parser{sc: sc, data: data, reader: stringConverter}
 }
 
-func newJSONParser(sc *bytes.Buffer, data map[string]interface{}) *parser {
-	return &parser{sc: sc, data: data, reader: nil, isjsonval: true}
+func newJSONParser(sc *bytes.Buffer, data map[string]interface{}, merge bool) *parser {
+	return &parser{sc: sc, data: data, reader: nil, isjsonval: true, mergejson: merge}
 }
 
 func newFileParser(sc *bytes.Buffer, data map[string]interface{}, reader RunesValueReader) *parser {
@@ -229,6 +245,9 @@ func (t *parser) key(data map[string]interface{}, nestedNameLevel int) (reterr e
 				if err = dec.Decode(&jsonval); err != nil {
 					return err
 				}
+				if t.mergejson {
+					jsonval = mergeJSON(data[string(k)], jsonval)
+				}
 				set(data, string(k), jsonval)
 				if _, err = io.CopyN(io.Discard, t.sc, dec.InputOffset()); err != nil {
 					return err
@@ -296,6 +315,23 @@ func set(data map[string]interface{}, key string, val interface{}) {
 	data[key] = val
 }
 
+// mergeJSON merges the json value val into existing. Objects are merged
+// recursively, and any other value of val replaces existing.
+func mergeJSON(existing, val interface{}) interface{} {
+	dst, ok := existing.(map[string]interface{})
+	if !ok {
+		return val
+	}
+	src, ok := val.(map[string]interface{})
+	if !ok {
+		return val
+	}
+	for k, v := range src {
+		dst[k] = mergeJSON(dst[k], v)
+	}
+	return dst
+}
+
 func setIndex(list []interface{}, index int, val interface{}) (l2 []interface{}, err error) {
 	// There are possible index values that are out of range on a target system
 	// causing a panic. This will catch the panic and return an error instead.
@@ -362,6 +398,9 @@ func (t *parser) listItem(list []interface{}, i, nestedNameLevel int) ([]interfa
 			if err = dec.Decode(&jsonval); err != nil {
 				return list, err
 			}
+			if t.mergejson && i < len(list) {
+				jsonval = mergeJSON(list[i], jsonval)
+			}
 			if list, err = setIndex(list, i, jsonval); err != nil {
 				return list, err
 			}
@@ -677,6 +677,88 @@ func TestParseJSON(t *testing.T) {
 	}
 }
 
+func TestParseIntoJSON(t *testing.T) {
+	tests := []struct {
+		input  string
+		got    map[string]interface{}
+		expect map[string]interface{}
+		err    bool
+	}{
+		{ // merge an object into an existing object, keeping its other keys
+			input: `outer={"inner1":{"a":1},"inner3":[3]}`,
+			got: map[string]interface{}{
+				"outer": map[string]interface{}{
+					"inner1": map[string]interface{}{"a": "overwrite", "b": "value"},
+					"inner2": "value2",
+					"inner3": []interface{}{1, 2},
+				},
+			},
+			expect: map[string]interface{}{
+				"outer": map[string]interface{}{
+					"inner1": map[string]interface{}{"a": 1, "b": "value"},
+					"inner2": "value2",
+					"inner3": []interface{}{3},
+				},
+			},
+		},
+		{ // merge an object into a list item
+			input: `ports[0]={"name":"http","port":80},ports[1]={"name":"https","port":443}`,
+			got: map[string]interface{}{
+				"ports": []interface{}{
+					map[string]interface{}{"name": "web", "protocol": "TCP"},
+				},
+			},
+			expect: map[string]interface{}{
+				"ports": []interface{}{
+					map[string]interface{}{"name": "http", "port": 80, "protocol": "TCP"},
+					map[string]interface{}{"name": "https", "port": 443},
+				},
+			},
+		},
+		{ // values other than objects overwrite existing values
+			input: `outer=null,other={"a":true},list=[1,{"b":null}]`,
+			got: map[string]interface{}{
+				"outer": map[string]interface{}{"inner": "value"},
+				"other": "value",
+				"list":  map[string]interface{}{"a": 1},
+			},
+			expect: map[string]interface{}{
+				"outer": nil,
+				"other": map[string]interface{}{"a": true},
+				"list":  []interface{}{1, map[string]interface{}{"b": nil}},
+			},
+		},
+		{ // syntax error
+			input: `outer={"a":1`,
+			got:   map[string]interface{}{},
+			err:   true,
+		},
+	}
+	for _, tt := range tests {
+		if err := ParseIntoJSON(tt.input, tt.got); err != nil {
+			if tt.err {
+				continue
+			}
+			t.Fatalf("%s: %s", tt.input, err)
+		}
+		if tt.err {
+			t.Fatalf("%s: Expected error. Got nil", tt.input)
+		}
+		y1, err := yaml.Marshal(tt.expect)
+		if err != nil {
+			t.Fatalf("Error serializing expected value: %s", err)
+		}
+		y2, err := yaml.Marshal(tt.got)
+		if err != nil {
+			t.Fatalf("Error serializing parsed value: %s", err)
+		}
+
+		if string(y1) != string(y2) {
+			t.Errorf("%s: Expected:\n%s\nGot:\n%s", tt.input, y1, y2)
+		}
+	}
+}
+
 func TestParseFile(t *testing.T) {
 	input := "name1=path1"
 	expect := map[string]interface{}{